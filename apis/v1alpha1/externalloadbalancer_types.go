@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ExternalLoadBalancerLabelKey is labeled on the Services which allocate listeners
+	// from an ExternalLoadBalancer, and its value is the name of the ExternalLoadBalancer.
+	ExternalLoadBalancerLabelKey = "game.kruise.io/external-load-balancer"
+)
+
+// ExternalLoadBalancerSpec defines the desired state of ExternalLoadBalancer
+type ExternalLoadBalancerSpec struct {
+	// Provider indicates the cloud provider the load balancer belongs to, such as AlibabaCloud.
+	// +optional
+	Provider string `json:"provider,omitempty"`
+	// ID is the identity of the pre-provisioned load balancer in the cloud provider.
+	ID string `json:"id"`
+	// Region is the region where the load balancer located.
+	// +optional
+	Region string `json:"region,omitempty"`
+	// PortBudget is the number of listener ports OKG can allocate on the load balancer.
+	// +optional
+	PortBudget int32 `json:"portBudget,omitempty"`
+	// Tags are the tags of the load balancer, which are owned by the infra-as-code tools.
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// ExternalLoadBalancerStatus defines the observed state of ExternalLoadBalancer
+type ExternalLoadBalancerStatus struct {
+	// ObservedGeneration is the most recent generation observed for this ExternalLoadBalancer.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// AllocatedPorts is the number of listener ports allocated by OKG.
+	AllocatedPorts int32 `json:"allocatedPorts"`
+	// RemainingPorts is the number of listener ports which can still be allocated.
+	RemainingPorts int32 `json:"remainingPorts"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="ID",type="string",JSONPath=".spec.id",description="The identity of the load balancer"
+//+kubebuilder:printcolumn:name="BUDGET",type="integer",JSONPath=".spec.portBudget",description="The port budget of the load balancer"
+//+kubebuilder:printcolumn:name="REMAINING",type="integer",JSONPath=".status.remainingPorts",description="The remaining ports of the load balancer"
+//+kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp",description="The age of ExternalLoadBalancer"
+//+kubebuilder:resource:scope=Cluster,shortName=elb
+
+// ExternalLoadBalancer is the Schema for the externalloadbalancers API.
+// It describes a load balancer provisioned outside OKG, whose listeners are allocated by OKG.
+type ExternalLoadBalancer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ExternalLoadBalancerSpec   `json:"spec,omitempty"`
+	Status ExternalLoadBalancerStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ExternalLoadBalancerList contains a list of ExternalLoadBalancer
+type ExternalLoadBalancerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ExternalLoadBalancer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ExternalLoadBalancer{}, &ExternalLoadBalancerList{})
+}
@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -33,6 +34,64 @@ const (
 	GameServerNetworkDisabled    = "game.kruise.io/network-disabled"
 	GameServerNetworkStatus      = "game.kruise.io/network-status"
 	GameServerNetworkTriggerTime = "game.kruise.io/network-trigger-time"
+	// GameServerNetworkNotReadySince is the pod annotation of the time, in RFC3339 format, since when the network
+	// of the pod turned from Ready to NotReady, which is kept Ready until the stabilization window passes.
+	GameServerNetworkNotReadySince = "game.kruise.io/network-not-ready-since"
+	// GameServerNetworkDrainUntil is the pod annotation of the deadline, in RFC3339 format, of draining the connections
+	// of the disabled network, until which the network of the pod is triggered again to finish the draining.
+	GameServerNetworkDrainUntil = "game.kruise.io/network-drain-until"
+	// GameServerNetworkReadyTime is the pod annotation of the time, in RFC3339 format, when the network of the pod
+	// became Ready for the first time, from which the provisioning latency of the GameServer is measured.
+	GameServerNetworkReadyTime = "game.kruise.io/network-ready-time"
+	// GameServerNetworkInjectEnvKey set to "true" in the pod template makes the pod webhook add an init container,
+	// which waits for the network to be ready and writes the addresses to a file shared with the other containers.
+	GameServerNetworkInjectEnvKey = "game.kruise.io/network-inject-env"
+	// GameServerReserveWhenKilledKey is the pod label synced from ReserveWhenKilled of the GameServer.
+	GameServerReserveWhenKilledKey = "game.kruise.io/gs-reserve-when-killed"
+)
+
+// GameServerCustomStatusPrefix is the prefix of the pod annotations set by the SDK, followed by the name of
+// the custom status field.
+const GameServerCustomStatusPrefix = "custom-status.game.kruise.io/"
+
+// GameServerRuntimeMetadataPrefix is the prefix of the GameServer labels and annotations which the SDK sidecar
+// forwards to the game process at runtime, followed by the name of the metadata. The annotation takes
+// precedence over the label of the same name.
+const GameServerRuntimeMetadataPrefix = "runtime.game.kruise.io/"
+
+// GameServerRepairKey is the GameServer annotation which asks the controller to rebuild the network objects
+// and/or the pod of the GameServer from scratch. It is removed once the repair is done.
+const GameServerRepairKey = "game.kruise.io/repair"
+
+type RepairTarget string
+
+const (
+	// NetworkRepairTarget deletes the Services and Ingresses of the pod, which the network plugin recreates.
+	NetworkRepairTarget RepairTarget = "network"
+	// PodRepairTarget deletes the pod, which the workload recreates.
+	PodRepairTarget RepairTarget = "pod"
+	// AllRepairTarget deletes the network objects of the pod first, and then the pod.
+	AllRepairTarget RepairTarget = "all"
+)
+
+const (
+	// GameServerShutdownStateKey is the pod annotation of the graceful shutdown state, which is set by the SDK
+	// in the preStop hook of the pod. The GameServer stops being allocated once it is set.
+	GameServerShutdownStateKey = "game.kruise.io/shutdown-state"
+	// GameServerShutdownDeadlineKey is the pod annotation of the deadline of draining sessions, in RFC3339 format.
+	GameServerShutdownDeadlineKey = "game.kruise.io/shutdown-deadline"
+	// GameServerPreDeleteHookStartedKey is the pod annotation of the time when the pod starts to wait for
+	// the PreDeleteHook, in RFC3339 format.
+	GameServerPreDeleteHookStartedKey = "game.kruise.io/pre-delete-hook-started-at"
+)
+
+type ShutdownState string
+
+const (
+	// ShutdownDraining means the game server is waiting for its sessions to end.
+	ShutdownDraining ShutdownState = "Draining"
+	// ShutdownDrained means the game server has acknowledged the shutdown and can be stopped.
+	ShutdownDrained ShutdownState = "Drained"
 )
 
 // GameServerSpec defines the desired state of GameServer
@@ -44,6 +103,13 @@ type GameServerSpec struct {
 	// Containers can be used to make the corresponding GameServer container fields
 	// different from the fields defined by GameServerTemplate in GameServerSetSpec.
 	Containers []GameServerContainer `json:"containers,omitempty"`
+	// ResourceProfile is the name of the ResourceProfile of the GameServerSet applied to the GameServer.
+	// +optional
+	ResourceProfile string `json:"resourceProfile,omitempty"`
+	// ReserveWhenKilled adds the id of the GameServer to ReserveGameServerIds of the GameServerSet when it is
+	// deleted by the opsState Kill, so that the id is retired permanently rather than created again by scaling up.
+	// +optional
+	ReserveWhenKilled bool `json:"reserveWhenKilled,omitempty"`
 }
 
 type GameServerContainer struct {
@@ -89,8 +155,21 @@ type ServiceQuality struct {
 	// When Permanent is false, ServiceQualityAction can be executed again even though ServiceQualityAction has been executed.
 	Permanent            bool                   `json:"permanent"`
 	ServiceQualityAction []ServiceQualityAction `json:"serviceQualityAction,omitempty"`
+	// ResultHistoryLimit is the number of the latest probe results recorded in the status of GameServer.
+	// Defaults to 5, and 0 means no history is recorded.
+	// +optional
+	ResultHistoryLimit *int32 `json:"resultHistoryLimit,omitempty"`
+	// ReArmAfterSeconds is the minimum seconds between two executions of the edge-triggered actions.
+	// An edge-triggered action is re-armed when the probe result stops matching it and ReArmAfterSeconds
+	// has passed since the last action of the ServiceQuality.
+	// +optional
+	ReArmAfterSeconds *int32 `json:"reArmAfterSeconds,omitempty"`
 }
 
+// DefaultServiceQualityResultHistoryLimit is the default number of the latest probe results of ServiceQuality
+// recorded in the status of GameServer.
+const DefaultServiceQualityResultHistoryLimit = 5
+
 type ServiceQualityCondition struct {
 	Name   string `json:"name"`
 	Status string `json:"status,omitempty"`
@@ -99,14 +178,106 @@ type ServiceQualityCondition struct {
 	LastProbeTime            metav1.Time `json:"lastProbeTime,omitempty"`
 	LastTransitionTime       metav1.Time `json:"lastTransitionTime,omitempty"`
 	LastActionTransitionTime metav1.Time `json:"lastActionTransitionTime,omitempty"`
+	// History is the latest probe results, the oldest first.
+	// +optional
+	History []ServiceQualityResult `json:"history,omitempty"`
+	// MatchedActions are the actions with ConsecutiveProbes which the latest probe results keep matching.
+	// +optional
+	MatchedActions []ServiceQualityMatchedAction `json:"matchedActions,omitempty"`
+}
+
+// ServiceQualityMatchedAction is a ServiceQualityAction with ConsecutiveProbes which the probe results keep matching.
+type ServiceQualityMatchedAction struct {
+	// Index is the index of the action in ServiceQualityAction.
+	Index int32 `json:"index"`
+	// DueTime is the time when the results have matched the action for ConsecutiveProbes probes.
+	DueTime metav1.Time `json:"dueTime"`
+	// Executed indicates the action has been executed since the results started matching it.
+	// +optional
+	Executed bool `json:"executed,omitempty"`
+}
+
+// ServiceQualityResult is a probe result of ServiceQuality and the action executed for it.
+type ServiceQualityResult struct {
+	ProbeTime metav1.Time `json:"probeTime,omitempty"`
+	Status    string      `json:"status,omitempty"`
+	Result    string      `json:"result,omitempty"`
+	// Action is the GameServerSpec set by the ServiceQualityAction executed for the result.
+	// It is nil if no action is executed.
+	// +optional
+	Action *GameServerSpec `json:"action,omitempty"`
 }
 
 type ServiceQualityAction struct {
 	State bool `json:"state"`
 	// Result indicate the probe message returned by the script.
 	// When Result is defined, it would exec action only when the according Result is actually returns.
-	Result         string `json:"result,omitempty"`
+	Result string `json:"result,omitempty"`
+	// ResultRange matches the results which are numbers within the range, instead of the exact Result.
+	// +optional
+	ResultRange *ServiceQualityResultRange `json:"resultRange,omitempty"`
+	// ConsecutiveProbes makes the action executed only once the results have matched it for the consecutive probes,
+	// that is, have kept matching it for ConsecutiveProbes-1 periods of the probe. The action is executed once
+	// until the results stop matching it. Defaults to 1.
+	// +optional
+	ConsecutiveProbes *int32 `json:"consecutiveProbes,omitempty"`
+	// EdgeTriggered makes the action executed only when the probe result changes from not matching the action to matching it,
+	// so that it is not executed again while the result keeps matching, and does not override manual changes of GameServerSpec.
+	// +optional
+	EdgeTriggered  bool `json:"edgeTriggered,omitempty"`
 	GameServerSpec `json:",inline"`
+	// Actions are applied in order after GameServerSpec when the ServiceQualityAction is executed, whose values are
+	// rendered from the probe result, so that a single result can drive several changes of the GameServer.
+	// +optional
+	Actions []ServiceQualityActionItem `json:"actions,omitempty"`
+}
+
+// ServiceQualityResultRange matches the numeric results, e.g. "3" or "players=3" with Field players. The results
+// which are not numbers do not match it.
+type ServiceQualityResultRange struct {
+	// Field is the key of the key=value pair in the result whose value is compared, instead of the whole result.
+	// +optional
+	Field string `json:"field,omitempty"`
+	// Min is the inclusive lower bound.
+	// +optional
+	Min *resource.Quantity `json:"min,omitempty"`
+	// Max is the inclusive upper bound.
+	// +optional
+	Max *resource.Quantity `json:"max,omitempty"`
+	// Gt is the exclusive lower bound.
+	// +optional
+	Gt *resource.Quantity `json:"gt,omitempty"`
+	// Lt is the exclusive upper bound.
+	// +optional
+	Lt *resource.Quantity `json:"lt,omitempty"`
+}
+
+type ServiceQualityActionType string
+
+const (
+	// SetOpsStateActionType sets the opsState of the GameServer to Value.
+	SetOpsStateActionType ServiceQualityActionType = "SetOpsState"
+	// SetUpdatePriorityActionType sets the updatePriority of the GameServer to Value, which should be an integer.
+	SetUpdatePriorityActionType ServiceQualityActionType = "SetUpdatePriority"
+	// SetDeletionPriorityActionType sets the deletionPriority of the GameServer to Value, which should be an integer.
+	SetDeletionPriorityActionType ServiceQualityActionType = "SetDeletionPriority"
+	// PatchLabelsActionType patches Values to the labels of the GameServer.
+	PatchLabelsActionType ServiceQualityActionType = "PatchLabels"
+	// PatchAnnotationsActionType patches Values to the annotations of the GameServer.
+	PatchAnnotationsActionType ServiceQualityActionType = "PatchAnnotations"
+)
+
+// ServiceQualityActionItem is one of the Actions of ServiceQualityAction. Value and the values of Values are Go
+// templates rendered with .State, .Result, and .Fields, the key=value pairs in the result separated by spaces,
+// commas or semicolons, e.g. {{ .Fields.players }} for the result "players=3,map=desert".
+type ServiceQualityActionItem struct {
+	Type ServiceQualityActionType `json:"type"`
+	// Value is the value of SetOpsState, SetUpdatePriority and SetDeletionPriority.
+	// +optional
+	Value string `json:"value,omitempty"`
+	// Values are the labels or annotations of PatchLabels and PatchAnnotations.
+	// +optional
+	Values map[string]string `json:"values,omitempty"`
 }
 
 // GameServerStatus defines the observed state of GameServer
@@ -125,8 +296,43 @@ type GameServerStatus struct {
 	// Conditions is an array of current observed GameServer conditions.
 	// +optional
 	Conditions []GameServerCondition `json:"conditions,omitempty" `
+	// PreUpdateJob is the status of the last pre-update Job of the GameServer.
+	// +optional
+	PreUpdateJob *PreUpdateJobStatus `json:"preUpdateJob,omitempty"`
+	// CustomStatus is the values of the custom status fields declared in the GameServerSet, set by the SDK.
+	// +optional
+	CustomStatus map[string]string `json:"customStatus,omitempty"`
+	// Connections is the number of active connections of the GameServer on its load balancer listeners,
+	// polled from the cloud monitoring service when the ConnectionPoller feature is enabled.
+	// +optional
+	Connections *ConnectionStatus `json:"connections,omitempty"`
 }
 
+type ConnectionStatus struct {
+	// Count is the sum of the active connections of the external addresses of the GameServer.
+	Count int32 `json:"count"`
+	// LastTransitionTime is the last time when Count changed, so that the GameServers without connections
+	// for a while can be told by it.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+}
+
+type PreUpdateJobStatus struct {
+	// Name is the name of the Job.
+	Name string `json:"name"`
+	// Revision is the revision which the GameServer is updated to after the Job succeeds.
+	Revision string `json:"revision"`
+	// Phase is the phase of the Job, which is Running, Succeeded or Failed.
+	Phase PreUpdateJobPhase `json:"phase"`
+}
+
+type PreUpdateJobPhase string
+
+const (
+	PreUpdateJobRunning   PreUpdateJobPhase = "Running"
+	PreUpdateJobSucceeded PreUpdateJobPhase = "Succeeded"
+	PreUpdateJobFailed    PreUpdateJobPhase = "Failed"
+)
+
 type GameServerCondition struct {
 	// Type is the type of the condition.
 	Type GameServerConditionType `json:"type"`
@@ -153,6 +359,17 @@ const (
 	NodeNormal             GameServerConditionType = "NodeNormal"
 	PersistentVolumeNormal GameServerConditionType = "PersistentVolumeNormal"
 	PodNormal              GameServerConditionType = "PodNormal"
+	// ShuttingDown is True when the pod is shutting down gracefully, with the shutdown state as the reason.
+	ShuttingDown GameServerConditionType = "ShuttingDown"
+	// NetworkNormal is False when the network is not ready, with the NetworkNotReadyReason as the reason.
+	// It is reported by the network controller.
+	NetworkNormal GameServerConditionType = "NetworkNormal"
+	// NetworkSynced is reported by the network controller, which is False with the error as the message if it fails
+	// to sync the network of the GameServer.
+	NetworkSynced GameServerConditionType = "NetworkSynced"
+	// LifecycleSynced is reported by the lifecycle controller, which is False with the error as the message if it
+	// fails to sync the GameServer with its pod.
+	LifecycleSynced GameServerConditionType = "LifecycleSynced"
 )
 
 type NetworkStatus struct {
@@ -163,6 +380,12 @@ type NetworkStatus struct {
 	CurrentNetworkState NetworkState     `json:"currentNetworkState,omitempty"`
 	CreateTime          metav1.Time      `json:"createTime,omitempty"`
 	LastTransitionTime  metav1.Time      `json:"lastTransitionTime,omitempty"`
+	// Reason is why the network is not ready, which is empty if the network is ready or the reason is unknown.
+	// +optional
+	Reason NetworkNotReadyReason `json:"reason,omitempty"`
+	// Message is the human-readable details of the reason.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 type NetworkState string
@@ -173,12 +396,32 @@ const (
 	NetworkNotReady NetworkState = "NotReady"
 )
 
+// NetworkNotReadyReason tells the provisioning problems keeping the network not ready apart.
+type NetworkNotReadyReason string
+
+const (
+	// NetworkWaitingForLB means the load balancer has not assigned the address yet.
+	NetworkWaitingForLB NetworkNotReadyReason = "WaitingForLB"
+	// NetworkPortExhausted means no port is free on the load balancers for the pod.
+	NetworkPortExhausted NetworkNotReadyReason = "PortExhausted"
+	// NetworkServiceMissing means the Service of the pod does not exist, and is being created.
+	NetworkServiceMissing NetworkNotReadyReason = "ServiceMissing"
+	// NetworkPodNotScheduled means the pod has not been scheduled to a node.
+	NetworkPodNotScheduled NetworkNotReadyReason = "PodNotScheduled"
+	// NetworkServiceConflict means a Service of the pod's name exists but is not created by the network plugin.
+	NetworkServiceConflict NetworkNotReadyReason = "ServiceConflict"
+)
+
 type NetworkAddress struct {
-	IP string `json:"ip"`
-	// TODO add IPv6
+	// IP is the IPv4 or IPv6 address. A dual-stack network has an address of each IP family for the same ports.
+	IP        string            `json:"ip"`
 	Ports     []NetworkPort     `json:"ports,omitempty"`
 	PortRange *NetworkPortRange `json:"portRange,omitempty"`
-	EndPoint  string            `json:"endPoint,omitempty"`
+	// EndPoint is the DNS name of the address, such as the hostname of the load balancer,
+	// which clients can connect to instead of the IP.
+	EndPoint string `json:"endPoint,omitempty"`
+	// LoadBalancerId is the id of the load balancer serving the address, if any.
+	LoadBalancerId string `json:"loadBalancerId,omitempty"`
 }
 
 type NetworkPort struct {
@@ -201,6 +444,9 @@ type NetworkPortRange struct {
 //+kubebuilder:printcolumn:name="UP",type="string",JSONPath=".status.updatePriority",description="The current updatePriority of GameServer"
 //+kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp",description="The age of GameServer"
 //+kubebuilder:resource:shortName=gs
+//+kubebuilder:selectablefield:JSONPath=".spec.opsState"
+//+kubebuilder:selectablefield:JSONPath=".status.currentState"
+//+kubebuilder:selectablefield:JSONPath=".status.networkStatus.currentNetworkState"
 
 // GameServer is the Schema for the gameservers API
 type GameServer struct {
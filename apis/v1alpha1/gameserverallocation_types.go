@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// GameServerAllocationKey is annotated on the allocated GameServer,
+	// and its value is the name of the GameServerAllocation.
+	GameServerAllocationKey = "game.kruise.io/allocation"
+	// GameServerAllocationChosenKey is annotated on the GameServerAllocation before the chosen GameServer is marked
+	// as Allocated, and its value is the name of the GameServer, so that a retried allocation reuses it.
+	GameServerAllocationChosenKey = "game.kruise.io/chosen-gameserver"
+	// GameServerAllocationPreemptionKey is annotated on the GameServerAllocation before any object is changed by
+	// the preemption, and its value records the victim and the replicas in JSON, so that a retried preemption
+	// skips the steps already done.
+	GameServerAllocationPreemptionKey = "game.kruise.io/preemption"
+	// GameServerAllocatedSlotsKey is annotated on the GameServer allocated by the allocation service,
+	// and its value is the number of the allocated slots, which is decreased by the release.
+	GameServerAllocatedSlotsKey = "game.kruise.io/allocated-slots"
+	// OpenMatchProfileKey is annotated on the GameServerSet whose GameServers are allocated for the matches of
+	// Open Match, and its value is the match profile in JSON.
+	OpenMatchProfileKey = "game.kruise.io/open-match-profile"
+	// OpenMatchFunctionKey is annotated on the GameServerSet, and its value is the gRPC address of the match function
+	// formatted as host:port.
+	OpenMatchFunctionKey = "game.kruise.io/open-match-function"
+	// AllocationCapacityKey is annotated on the GameServerSet, and its value is the number of the slots of each
+	// GameServer taken by the allocation service, such as the matches hosted at the same time, which is 1 if not set.
+	AllocationCapacityKey = "game.kruise.io/allocation-capacity"
+)
+
+// GameServerAllocationSpec defines the desired state of GameServerAllocation
+type GameServerAllocationSpec struct {
+	// Selector is a label query over GameServers in the same namespace,
+	// one of the idle GameServers matching it will be allocated.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// Requester is the identity of the user who created the GameServerAllocation.
+	// It is set by the webhook according to the request and can not be specified by users.
+	// +optional
+	Requester *AllocationRequester `json:"requester,omitempty"`
+	// TTLSecondsAfterFinished limits the lifetime of a GameServerAllocation that has finished.
+	// The GameServerAllocation will be deleted TTLSecondsAfterFinished seconds after it finished.
+	// If this field is unset, the GameServerAllocation won't be automatically deleted.
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	// PreemptionPolicyName is the name of PreemptionPolicy in the same namespace.
+	// When it is set and there is no idle GameServer, an idle GameServer of a lower priority fleet
+	// in the pool may be preempted according to the policy.
+	// +optional
+	PreemptionPolicyName string `json:"preemptionPolicyName,omitempty"`
+}
+
+type AllocationRequester struct {
+	Username string   `json:"username,omitempty"`
+	UID      string   `json:"uid,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+type GameServerAllocationState string
+
+const (
+	// GameServerAllocationAllocated means an idle GameServer has been allocated.
+	GameServerAllocationAllocated GameServerAllocationState = "Allocated"
+	// GameServerAllocationUnAllocated means there is no idle GameServer matching the selector.
+	GameServerAllocationUnAllocated GameServerAllocationState = "UnAllocated"
+)
+
+// GameServerAllocationStatus defines the observed state of GameServerAllocation
+type GameServerAllocationStatus struct {
+	State GameServerAllocationState `json:"state,omitempty"`
+	// GameServerName is the name of the allocated GameServer.
+	GameServerName string `json:"gameServerName,omitempty"`
+	// ExternalAddresses are the external addresses of the allocated GameServer.
+	ExternalAddresses []NetworkAddress `json:"externalAddresses,omitempty"`
+	// PreemptedGameServerName is the name of the GameServer preempted for the allocation.
+	PreemptedGameServerName string `json:"preemptedGameServerName,omitempty"`
+	// Message indicates the details of the allocation result.
+	Message        string       `json:"message,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="STATE",type="string",JSONPath=".status.state",description="The state of GameServerAllocation"
+//+kubebuilder:printcolumn:name="GAMESERVER",type="string",JSONPath=".status.gameServerName",description="The allocated GameServer"
+//+kubebuilder:printcolumn:name="REQUESTER",type="string",JSONPath=".spec.requester.username",description="The user who requested the allocation"
+//+kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp",description="The age of GameServerAllocation"
+//+kubebuilder:resource:shortName=gsa
+
+// GameServerAllocation is the Schema for the gameserverallocations API.
+// Each GameServerAllocation records one allocation request and its result.
+type GameServerAllocation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GameServerAllocationSpec   `json:"spec,omitempty"`
+	Status GameServerAllocationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GameServerAllocationList contains a list of GameServerAllocation
+type GameServerAllocationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GameServerAllocation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GameServerAllocation{}, &GameServerAllocationList{})
+}
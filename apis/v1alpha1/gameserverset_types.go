@@ -20,7 +20,10 @@ import (
 	appspub "github.com/openkruise/kruise-api/apps/pub"
 	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
 	apps "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
@@ -33,11 +36,54 @@ const (
 	GameServerSetReserveIdsKey = "game.kruise.io/reserve-ids"
 	AstsHashKey                = "game.kruise.io/asts-hash"
 	PpmHashKey                 = "game.kruise.io/ppm-hash"
+	EgressPolicyHashKey        = "game.kruise.io/egress-policy-hash"
 	GsTemplateMetadataHashKey  = "game.kruise.io/gsTemplate-metadata-hash"
+	// GsTemplateHashKey is the annotation of Advanced StatefulSet,
+	// and its value is the hash of the GameServerTemplate that the pod template is updated with.
+	GsTemplateHashKey = "game.kruise.io/gsTemplate-hash"
+	// HotUpdateBaseHashKey is the annotation of Advanced StatefulSet,
+	// and its value is the hash of the GameServerTemplate without the containers and annotations of HotUpdate.
+	HotUpdateBaseHashKey = "game.kruise.io/hot-update-base-hash"
+	// HotUpdateRevisionKey is the annotation of pods,
+	// and its value is the hash of the containers and annotations of HotUpdate.
+	HotUpdateRevisionKey = "game.kruise.io/hot-update-revision"
 )
 
 const (
 	InplaceUpdateNotReadyBlocker = "game.kruise.io/inplace-update-not-ready-blocker"
+	// PreUpdateJobBlocker is the label of pods which blocks the update until the pre-update Job succeeds.
+	PreUpdateJobBlocker = "game.kruise.io/pre-update-job-blocker"
+	// UpdateGateBlocker is the label of pods which blocks the update until the GameServer passes the UpdateGate.
+	UpdateGateBlocker = "game.kruise.io/update-gate-blocker"
+	// PreDeleteHookBlocker is the label of pods which blocks the deletion until the GameServer acknowledges
+	// the shutdown or the PreDeleteHook times out.
+	PreDeleteHookBlocker = "game.kruise.io/pre-delete-hook-blocker"
+	// IdentityRetentionFinalizer is the finalizer of GameServerSet whose IdentityRetentionPolicy is Retain.
+	IdentityRetentionFinalizer = "game.kruise.io/identity-retention"
+	// IdentityRetainedFromKey is the annotation of Services retained after the GameServerSet is deleted,
+	// and its value is the name of the GameServerSet.
+	IdentityRetainedFromKey = "game.kruise.io/identity-retained-from"
+	// UpdateFreezeOverrideKey is the annotation of GameServerSet.
+	// When it is set to "true", template rollouts will not be blocked by freeze windows.
+	UpdateFreezeOverrideKey = "game.kruise.io/update-freeze-override"
+	// GameServerOverflowKey is the label of pods which are overflowed onto serverless nodes by OverflowPolicy.
+	GameServerOverflowKey = "game.kruise.io/overflow"
+	// GameServerArchKey is the label of pods which indicates the sub-pool of ArchitecturePools the pod belongs to.
+	GameServerArchKey = "game.kruise.io/arch"
+	// GameContainerKey is the annotation of pods which indicates the name of the game container,
+	// i.e. GameContainerName of the GameServerSet.
+	GameContainerKey = "game.kruise.io/game-container"
+	// GameServerResourceProfileKey is the annotation of pods which indicates the name of the ResourceProfile
+	// applied to the pod.
+	GameServerResourceProfileKey = "game.kruise.io/resource-profile"
+	// LastAutoscaleTimeKey is the annotation of GameServerSet which records the time when ScalingPolicy scaled it last.
+	LastAutoscaleTimeKey = "game.kruise.io/last-autoscale-time"
+)
+
+// The environment variables injected into the game container, from which the SDK reads the identity of the GameServer.
+const (
+	GameServerPodNameEnv      = "POD_NAME"
+	GameServerPodNamespaceEnv = "POD_NAMESPACE"
 )
 
 // GameServerSetSpec defines the desired state of GameServerSet
@@ -57,8 +103,371 @@ type GameServerSetSpec struct {
 	UpdateStrategy       UpdateStrategy     `json:"updateStrategy,omitempty"`
 	ScaleStrategy        ScaleStrategy      `json:"scaleStrategy,omitempty"`
 	Network              *Network           `json:"network,omitempty"`
+	// ReserveGameServerIdRanges are the ranges of ids of GameServers which are not created, in addition to
+	// ReserveGameServerIds, such as "100-150" including both ends, or "100" for a single id.
+	// They are never changed by the controller, and the ids in them are not added to ReserveGameServerIds.
+	// +optional
+	ReserveGameServerIdRanges []string `json:"reserveGameServerIdRanges,omitempty"`
+	// NetworkDisabled cuts the traffic of all GameServers of the GameServerSet at once, such as during a security incident.
+	// When it is true, the network of every GameServer is disabled regardless of its own networkDisabled.
+	// +optional
+	NetworkDisabled bool `json:"networkDisabled,omitempty"`
+	// ServiceQualityTemplateName is the name of the ServiceQualityTemplate whose ServiceQualities are applied to
+	// the GameServerSet. ServiceQualities of the GameServerSet override the ones of the template with the same name.
+	// +optional
+	ServiceQualityTemplateName string `json:"serviceQualityTemplateName,omitempty"`
+	// ImagePolicy defines how images of GameServers are resolved and verified when rolling out.
+	// +optional
+	ImagePolicy *ImagePolicy `json:"imagePolicy,omitempty"`
+	// ScoringPolicy defines how GameServers are scored when selecting the targets of allocation
+	// and the victims of scaling down. GameServers with higher scores are allocated first and deleted last.
+	// +optional
+	ScoringPolicy *ScoringPolicy `json:"scoringPolicy,omitempty"`
+	// IdentityRetentionPolicy indicates whether the identity of GameServers, including the Services of Fixed network,
+	// the PVCs and the reserved IDs, is retained when the GameServerSet is deleted, so that they are reattached
+	// when a GameServerSet with the same name is created again.
+	// Default is Delete.
+	// +optional
+	IdentityRetentionPolicy IdentityRetentionPolicyType `json:"identityRetentionPolicy,omitempty"`
+	// CustomStatusFields declares the custom status fields which game servers can set through the SDK.
+	// The fields are surfaced in the status of GameServer and in metrics, where the values of String fields are labels,
+	// so they should be drawn from a small set, and the values of Number fields are the values of the gauge.
+	// +optional
+	CustomStatusFields []CustomStatusField `json:"customStatusFields,omitempty"`
+	// OverflowPolicy schedules the GameServers beyond the threshold onto serverless nodes,
+	// such as Alibaba Cloud ECI or other virtual-kubelet nodes, to absorb launch spikes without pre-provisioned nodes.
+	// +optional
+	OverflowPolicy *OverflowPolicy `json:"overflowPolicy,omitempty"`
+	// ArchitecturePools splits the GameServers by weight into sub-pools running on nodes of different CPU architectures,
+	// such as arm64 and amd64 nodes in the same fleet.
+	// +optional
+	ArchitecturePools []ArchitecturePool `json:"architecturePools,omitempty"`
+	// RestartPolicy drains and recreates the GameServers on a rolling schedule, such as nightly at low population,
+	// for the game servers whose memory fragments over long runs.
+	// +optional
+	RestartPolicy *RestartPolicy `json:"restartPolicy,omitempty"`
+	// MemoryLeakPolicy drains the GameServers whose memory usage keeps growing or stays close to the limit,
+	// according to the metrics of metrics.k8s.io, instead of waiting for them to be OOM killed mid-match.
+	// +optional
+	MemoryLeakPolicy *MemoryLeakPolicy `json:"memoryLeakPolicy,omitempty"`
+	// GameContainerName is the name of the game container of the pods running sidecars, such as log shippers.
+	// The network ports, service qualities and MemoryLeakPolicy without container names target the game container,
+	// the readiness of GameServers follows the game container rather than the sidecars, and the environment
+	// variables read by the SDK are injected into the game container.
+	// When it is not set, the first container is regarded as the game container, and the readiness of GameServers
+	// follows the pods.
+	// +optional
+	GameContainerName string `json:"gameContainerName,omitempty"`
+	// EgressPolicy restricts the outbound traffic of the GameServers to the declared CIDRs by a NetworkPolicy
+	// managed by the controller, which requires the network plugin of the cluster to enforce NetworkPolicies.
+	// +optional
+	EgressPolicy *EgressPolicy `json:"egressPolicy,omitempty"`
+	// ResourceProfiles are the named resources of the containers, such as small, medium and large,
+	// which the GameServers switch to by their ResourceProfile, for example set by a ServiceQualityAction.
+	// +optional
+	ResourceProfiles []ResourceProfile `json:"resourceProfiles,omitempty"`
+	// PreDeleteHook is the HTTP or gRPC endpoint of the game server which is called before its pod is deleted,
+	// such as for scaling down or recreate updates, so that the game server broadcasts a countdown to players
+	// and saves its state. The pod is deleted after the game server acknowledges the shutdown through the SDK,
+	// or the hook times out.
+	// +optional
+	PreDeleteHook *PreDeleteHook `json:"preDeleteHook,omitempty"`
+	// ScalingPolicy resizes the replicas to keep a buffer of idle GameServers, whose opsState is None, warm for allocation.
+	// The replicas should not be managed by other autoscalers, such as HPA or KEDA, at the same time.
+	// +optional
+	ScalingPolicy *ScalingPolicy `json:"scalingPolicy,omitempty"`
+	// ProvisioningSLO measures the ratio of GameServers whose network becomes Ready within the threshold after
+	// they are created, over a rolling window, which is reported in the status and the metrics.
+	// +optional
+	ProvisioningSLO *ProvisioningSLO `json:"provisioningSLO,omitempty"`
+}
+
+type ProvisioningSLO struct {
+	// ThresholdSeconds is the latency from the creation of a GameServer to its network being Ready,
+	// within which the GameServer meets the SLO.
+	// +kubebuilder:validation:Minimum=1
+	ThresholdSeconds int32 `json:"thresholdSeconds"`
+	// WindowSeconds is the rolling window of the SLO. The GameServers created within the window are measured.
+	// Default is 3600.
+	// +kubebuilder:validation:Minimum=60
+	// +optional
+	WindowSeconds *int32 `json:"windowSeconds,omitempty"`
+}
+
+// DefaultProvisioningSLOWindowSeconds is the default rolling window of ProvisioningSLO.
+const DefaultProvisioningSLOWindowSeconds = 3600
+
+type ScalingPolicy struct {
+	// MinIdle is the number of idle GameServers below which the GameServerSet is scaled up.
+	// +kubebuilder:validation:Minimum=0
+	MinIdle int32 `json:"minIdle"`
+	// MaxIdle is the number of idle GameServers above which the GameServerSet is scaled down.
+	// Default is MinIdle.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxIdle *int32 `json:"maxIdle,omitempty"`
+	// MinReplicas is the lower limit of the replicas. Default is 0.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas is the upper limit of the replicas.
+	// +kubebuilder:validation:Minimum=0
+	MaxReplicas int32 `json:"maxReplicas"`
+	// CooldownSeconds is how long the GameServerSet is not scaled down after the last scaling, so that the
+	// replicas do not flap when GameServers are allocated and released in bursts. Scaling up is never delayed.
+	// Default is 60.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	CooldownSeconds *int32 `json:"cooldownSeconds,omitempty"`
+	// Schedules raise the lower limit of the replicas at scheduled times, such as to 500 at 18:00 on Fridays and back
+	// to 100 at 02:00, for predictable peaks. The schedule which took effect last is active until another one takes effect.
+	// +optional
+	Schedules []ScalingSchedule `json:"schedules,omitempty"`
+}
+
+type ScalingSchedule struct {
+	// Name identifies the schedule in the events.
+	Name string `json:"name"`
+	// Cron is the time the schedule takes effect in the standard cron format of five fields:
+	// minute, hour, day of month, month and day of week, such as "0 18 * * 5".
+	Cron string `json:"cron"`
+	// TimeZone indicates the IANA time zone of Cron, such as Asia/Shanghai.
+	// Default is UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+	// Replicas is the lower limit of the replicas while the schedule is active, which overrides MinReplicas.
+	// +kubebuilder:validation:Minimum=0
+	Replicas int32 `json:"replicas"`
+}
+
+// DefaultScalingCooldownSeconds is the default cooldown of ScalingPolicy.
+const DefaultScalingCooldownSeconds = 60
+
+type PreDeleteHook struct {
+	// Path is the path of the HTTP(S) endpoint, such as /shutdown. The query grace=<GraceSeconds> is added to it.
+	// It is required unless Scheme is GRPC.
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Port is the port of the endpoint on the IP of the pod.
+	Port int32 `json:"port"`
+	// Scheme is the scheme of the endpoint, HTTP, HTTPS or GRPC. Default is HTTP.
+	// For GRPC, the method PreDelete of the service predeletehook.PreDeleteHook defined in
+	// proto/predeletehook/predeletehook.proto is called with GraceSeconds.
+	// +optional
+	Scheme corev1.URIScheme `json:"scheme,omitempty"`
+	// GraceSeconds is the countdown before the shutdown, which is passed to the endpoint. Default is 300.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	GraceSeconds *int32 `json:"graceSeconds,omitempty"`
+	// TimeoutSeconds is how long to wait for the acknowledgment after the pod starts to be deleted,
+	// after which the pod is deleted anyway. Default is GraceSeconds plus 60.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
 }
 
+// PreDeleteHookSchemeGRPC is the Scheme of PreDeleteHook served by gRPC.
+const PreDeleteHookSchemeGRPC corev1.URIScheme = "GRPC"
+
+type ResourceProfile struct {
+	// Name is the name of the profile, referred to by ResourceProfile of GameServers.
+	Name string `json:"name"`
+	// Containers are the resources of the containers in the profile.
+	Containers []ResourceProfileContainer `json:"containers"`
+	// ResizePolicy indicates how the profile is applied to the running pods. Default is InPlace.
+	// +optional
+	ResizePolicy ResizePolicyType `json:"resizePolicy,omitempty"`
+}
+
+type ResourceProfileContainer struct {
+	// Name is the name of the container. Default is the game container.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Resources are the resources of the container, which replace the ones in the template.
+	Resources corev1.ResourceRequirements `json:"resources"`
+}
+
+// +kubebuilder:validation:Enum=InPlace;RecreateAtIdle
+type ResizePolicyType string
+
+const (
+	// InPlaceResizePolicy resizes the resources of the running pods in place, which requires the feature
+	// InPlacePodVerticalScaling of the cluster.
+	InPlaceResizePolicy ResizePolicyType = "InPlace"
+	// RecreateAtIdleResizePolicy recreates the pods with the resources of the profile once the GameServers are idle,
+	// i.e. their opsState is None.
+	RecreateAtIdleResizePolicy ResizePolicyType = "RecreateAtIdle"
+)
+
+type EgressPolicy struct {
+	// CIDRs are the destinations the GameServers are allowed to connect to, such as the CIDRs of the game backends.
+	// The outbound traffic to the other destinations is denied.
+	// +optional
+	CIDRs []string `json:"cidrs,omitempty"`
+	// Ports restricts the destination ports allowed in CIDRs. All ports are allowed if it is empty.
+	// +optional
+	Ports []networkingv1.NetworkPolicyPort `json:"ports,omitempty"`
+	// DenyDNS indicates whether the DNS queries to port 53 of any destination are denied as well.
+	// Default is false, so that the GameServers can resolve the domain names of the backends.
+	// +optional
+	DenyDNS bool `json:"denyDNS,omitempty"`
+}
+
+type MemoryLeakPolicy struct {
+	// ContainerName is the name of the container whose memory usage is watched.
+	// Default is the first container of the pod.
+	// +optional
+	ContainerName string `json:"containerName,omitempty"`
+	// LimitPercent triggers the policy when the memory usage stays at or above the percentage of the memory limit
+	// of the container for DurationMinutes. It takes no effect on the containers without memory limit.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	LimitPercent *int32 `json:"limitPercent,omitempty"`
+	// GrowthPerMinute triggers the policy when the memory usage grows at or above the rate, such as 10Mi,
+	// fitted over the samples of the last DurationMinutes.
+	// +optional
+	GrowthPerMinute *resource.Quantity `json:"growthPerMinute,omitempty"`
+	// DurationMinutes is how long the memory usage crosses the thresholds before the policy is triggered.
+	// Default is 10.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	DurationMinutes *int32 `json:"durationMinutes,omitempty"`
+	// OpsState is set to the GameServers whose opsState is None when the policy is triggered,
+	// which is one of WaitToBeDeleted, Maintaining and Kill. Default is WaitToBeDeleted.
+	// +kubebuilder:validation:Enum=WaitToBeDeleted;Maintaining;Kill
+	// +optional
+	OpsState OpsState `json:"opsState,omitempty"`
+}
+
+// DefaultMemoryLeakDurationMinutes is the default DurationMinutes of MemoryLeakPolicy.
+const DefaultMemoryLeakDurationMinutes = 10
+
+type RestartPolicy struct {
+	// Cron is the schedule of the restarts in the standard cron format of five fields:
+	// minute, hour, day of month, month and day of week, such as "0 4 * * *".
+	Cron string `json:"cron"`
+	// TimeZone indicates the IANA time zone of Cron, such as Asia/Shanghai.
+	// Default is UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+	// WindowSeconds is how long the restarts last after each scheduled time. The GameServers created before the
+	// scheduled time are restarted in the window, and those which are not, such as the allocated ones, wait for the next window.
+	// Default is 3600.
+	// +kubebuilder:validation:Minimum=60
+	// +optional
+	WindowSeconds *int32 `json:"windowSeconds,omitempty"`
+	// MaxUnavailable is the maximum number of GameServers which are restarting or not ready while restarting.
+	// Value can be an absolute number (ex: 5) or a percentage of replicas (ex: 10%), rounded down to at least 1.
+	// Default is 1.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// DefaultRestartWindowSeconds is the default length of the restart window of RestartPolicy.
+const DefaultRestartWindowSeconds = 3600
+
+type ArchitecturePool struct {
+	// Arch is the CPU architecture of the nodes, i.e. the value of node label kubernetes.io/arch, such as amd64 or arm64.
+	Arch string `json:"arch"`
+	// Weight is the relative share of GameServers in this sub-pool. Default is 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Weight *int32 `json:"weight,omitempty"`
+	// Images overrides the images of containers in this sub-pool when the images of the template are not multi-arch.
+	// The overrides take effect when pods are created.
+	// +optional
+	Images []ContainerImage `json:"images,omitempty"`
+}
+
+type ContainerImage struct {
+	// Name is the name of the container.
+	Name string `json:"name"`
+	// Image is the image of the container.
+	Image string `json:"image"`
+}
+
+type OverflowPolicy struct {
+	// Threshold is the number of GameServers scheduled onto the regular nodes.
+	// The GameServers whose ids are not less than Threshold are scheduled onto the serverless nodes.
+	// +kubebuilder:validation:Minimum=0
+	Threshold int32 `json:"threshold"`
+	// NodeSelector selects the serverless nodes. Default is type: virtual-kubelet.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations tolerate the taints of the serverless nodes.
+	// Default tolerates the taint virtual-kubelet.io/provider.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+type CustomStatusField struct {
+	// Name is the name of the field, which is used as the name part of the annotation key of pod.
+	Name string `json:"name"`
+	// Type is the type of the field value, String or Number. Default is String.
+	// +optional
+	Type CustomStatusFieldType `json:"type,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=String;Number
+type CustomStatusFieldType string
+
+const (
+	StringCustomStatusFieldType CustomStatusFieldType = "String"
+	NumberCustomStatusFieldType CustomStatusFieldType = "Number"
+)
+
+// IdentityRetentionPolicyType is a string enumeration type that enumerates
+// all possible identity retention policies of GameServerSet.
+// +kubebuilder:validation:Enum=Delete;Retain
+type IdentityRetentionPolicyType string
+
+const (
+	// DeleteIdentityRetentionPolicyType indicates that the identity of GameServers is deleted with the GameServerSet.
+	DeleteIdentityRetentionPolicyType IdentityRetentionPolicyType = "Delete"
+	// RetainIdentityRetentionPolicyType indicates that the identity of GameServers is retained after the GameServerSet
+	// is deleted, and reattached to the GameServerSet created again with the same name.
+	RetainIdentityRetentionPolicyType IdentityRetentionPolicyType = "Retain"
+)
+
+type ImagePolicy struct {
+	// PinDigest indicates whether to resolve image tags to digests when the template is rolled out,
+	// so that all GameServers of one rollout run exactly the same build even if the tags are pushed again.
+	// +optional
+	PinDigest bool `json:"pinDigest,omitempty"`
+	// CosignPolicyRef is the name of the sigstore ClusterImagePolicy which verifies the signatures of images.
+	// The rollout is blocked if the policy does not exist or the namespace is not enforced by the policy controller,
+	// so that pods are never created without signature verification. Digests are always pinned when it is set.
+	// +optional
+	CosignPolicyRef string `json:"cosignPolicyRef,omitempty"`
+}
+
+type ScoringPolicy struct {
+	// Strategy is the name of the built-in scoring strategy.
+	// Default is Packed.
+	// +optional
+	Strategy ScoringStrategyType `json:"strategy,omitempty"`
+	// Expression is a CEL expression which returns the score of a GameServer, overriding the score of Strategy.
+	// The variables gameServer, node and score (the score of Strategy) can be used in the expression,
+	// such as `score - double(node.allocatedGameServers)`.
+	// +optional
+	Expression string `json:"expression,omitempty"`
+}
+
+// ScoringStrategyType is a string enumeration type that enumerates
+// all built-in scoring strategies.
+// +kubebuilder:validation:Enum=Packed;Distributed
+type ScoringStrategyType string
+
+const (
+	// PackedScoringStrategyType prefers GameServers on the nodes with more GameServers of all GameServerSets,
+	// so that GameServers are bin-packed and more nodes become empty to be scaled in.
+	PackedScoringStrategyType ScoringStrategyType = "Packed"
+	// DistributedScoringStrategyType prefers GameServers on the nodes with fewer GameServers of all GameServerSets,
+	// so that GameServers are spread across nodes and a node failure affects fewer players.
+	DistributedScoringStrategyType ScoringStrategyType = "Distributed"
+)
+
 type GameServerTemplate struct {
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// +kubebuilder:validation:Schemaless
@@ -89,6 +498,31 @@ type NetworkConfParams KVParams
 
 const (
 	AllowNotReadyContainersNetworkConfName = "AllowNotReadyContainers"
+	// StabilizationWindowSecondsNetworkConfName is the seconds that the network keeps NotReady before it turns from
+	// Ready to NotReady, so that the load balancers clearing and re-populating their status do not flip the network
+	// state back and forth. The network state flips at once if it is not set.
+	StabilizationWindowSecondsNetworkConfName = "StabilizationWindowSeconds"
+	// IPFamilyPolicyNetworkConfName is the IP family policy of the Services created by the network plugins, which is
+	// SingleStack, PreferDualStack or RequireDualStack. The default of the cluster is used if it is not set.
+	IPFamilyPolicyNetworkConfName = "IPFamilyPolicy"
+	// IPFamiliesNetworkConfName is the IP families of the Services created by the network plugins, such as IPv4,IPv6.
+	// The default of the cluster is used if it is not set.
+	IPFamiliesNetworkConfName = "IPFamilies"
+	// ServiceCollisionPolicyNetworkConfName is the policy on the Service of the pod's name which already exists but is
+	// not created by the network plugin, which is Fail, Adopt or Recreate. It is Fail if it is not set.
+	ServiceCollisionPolicyNetworkConfName = "ServiceCollisionPolicy"
+)
+
+// ServiceCollisionPolicy is the policy on the pre-existing Service not created by the network plugin.
+type ServiceCollisionPolicy string
+
+const (
+	// FailServiceCollisionPolicy leaves the Service as it is, and keeps the network NotReady with a Warning event.
+	FailServiceCollisionPolicy ServiceCollisionPolicy = "Fail"
+	// AdoptServiceCollisionPolicy labels the Service as created by the network plugin, which then updates it.
+	AdoptServiceCollisionPolicy ServiceCollisionPolicy = "Adopt"
+	// RecreateServiceCollisionPolicy deletes the Service, which is then created by the network plugin.
+	RecreateServiceCollisionPolicy ServiceCollisionPolicy = "Recreate"
 )
 
 type KVParams struct {
@@ -104,6 +538,65 @@ type UpdateStrategy struct {
 	// RollingUpdate is used to communicate parameters when Type is RollingUpdateStatefulSetStrategyType.
 	// +optional
 	RollingUpdate *RollingUpdateStatefulSetStrategy `json:"rollingUpdate,omitempty"`
+	// FreezeWindows indicates the time windows during which template rollouts are blocked, such as weekend evenings.
+	// Template rollouts can be forced during freeze windows by annotating the GameServerSet
+	// with game.kruise.io/update-freeze-override: "true".
+	// +optional
+	FreezeWindows []UpdateFreezeWindow `json:"freezeWindows,omitempty"`
+	// PreUpdateJob is the template of the Job which runs for each GameServer before its pod is updated,
+	// such as migrating the schema or the world data of the GameServer.
+	// The pod is updated only after the Job succeeds, and the Job is tracked in the GameServer status.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +optional
+	PreUpdateJob *batchv1.JobTemplateSpec `json:"preUpdateJob,omitempty"`
+	// UpdateGate holds the pods of GameServers back from being updated until they are idle,
+	// so that a new server build can be canaried on the rooms which can be updated safely.
+	// Combined with RollingUpdate.Partition, it limits the canary to a subset of GameServers.
+	// +optional
+	UpdateGate *UpdateGate `json:"updateGate,omitempty"`
+	// HotUpdate indicates the containers and annotations whose changes are hot updated.
+	// When only they are changed, pods are updated in place regardless of PodUpdatePolicy,
+	// and the game process is signalled through the game.kruise.io/hot-update-revision annotation
+	// instead of being restarted, so that live sessions are preserved.
+	// +optional
+	HotUpdate *HotUpdate `json:"hotUpdate,omitempty"`
+}
+
+type HotUpdate struct {
+	// Containers indicates the names of the containers which are hot updated, such as config sidecars.
+	// +optional
+	Containers []string `json:"containers,omitempty"`
+	// Annotations indicates the keys of the GameServerTemplate annotations which are hot updated.
+	// +optional
+	Annotations []string `json:"annotations,omitempty"`
+}
+
+type UpdateGate struct {
+	// OpsStates indicates the opsStates of GameServers which are allowed to be updated.
+	// Default is [WaitToBeDeleted].
+	// +optional
+	OpsStates []OpsState `json:"opsStates,omitempty"`
+	// PlayersField indicates the custom status field of the number of active players.
+	// GameServers whose number of active players is zero are allowed to be updated, regardless of their opsStates.
+	// +optional
+	PlayersField string `json:"playersField,omitempty"`
+}
+
+type UpdateFreezeWindow struct {
+	// Days indicates the days of the week on which the window starts, such as Saturday and Sunday.
+	// The window starts every day if Days is empty.
+	// +optional
+	Days []string `json:"days,omitempty"`
+	// Start indicates the start time of the window, in the format of HH:MM.
+	Start string `json:"start"`
+	// End indicates the end time of the window, in the format of HH:MM.
+	// If End is not later than Start, the window ends on the next day.
+	End string `json:"end"`
+	// TimeZone indicates the IANA time zone of Start and End, such as Asia/Shanghai.
+	// Default is UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
 type RollingUpdateStatefulSetStrategy struct {
@@ -152,6 +645,21 @@ type ScaleStrategy struct {
 	// Default is GeneralScaleDownStrategyType
 	// +optional
 	ScaleDownStrategyType ScaleDownStrategyType `json:"scaleDownStrategyType,omitempty"`
+	// PlayersSource indicates where the number of players of GameServers is read from,
+	// when ScaleDownStrategyType is LeastPlayers.
+	// +optional
+	PlayersSource *PlayersSource `json:"playersSource,omitempty"`
+}
+
+type PlayersSource struct {
+	// ServiceQualityName is the name of the ServiceQuality whose probe result is the number of players.
+	// +optional
+	ServiceQualityName string `json:"serviceQualityName,omitempty"`
+	// Annotation is the key of the pod annotation whose value is the number of players,
+	// such as custom-status.game.kruise.io/players set by the SDK.
+	// It is used when the probe result of ServiceQualityName is not a number.
+	// +optional
+	Annotation string `json:"annotation,omitempty"`
 }
 
 // ScaleDownStrategyType is a string enumeration type that enumerates
@@ -169,6 +677,10 @@ const (
 	// ReserveGameServerIds field when GameServers scale down, whether set by
 	// ReserveGameServerIds field or the GameServerSet controller chooses to remove it.
 	ReserveIdsScaleDownStrategyType ScaleDownStrategyType = "ReserveIds"
+	// LeastPlayersScaleDownStrategyType works as GeneralScaleDownStrategyType, and sets the DeletionPriority
+	// of GameServers to the negative number of their players read from PlayersSource,
+	// so that the GameServers with the fewest players are deleted first when scaling down.
+	LeastPlayersScaleDownStrategyType ScaleDownStrategyType = "LeastPlayers"
 )
 
 // GameServerSetStatus defines the observed state of GameServerSet
@@ -187,8 +699,100 @@ type GameServerSetStatus struct {
 	WaitToBeDeletedReplicas *int32 `json:"waitToBeDeletedReplicas,omitempty"`
 	// LabelSelector is label selectors for query over pods that should match the replica count used by HPA.
 	LabelSelector string `json:"labelSelector,omitempty"`
+	// Conditions is an array of current observed GameServerSet conditions,
+	// which aggregate the problems of child GameServers.
+	// +optional
+	Conditions []GameServerSetCondition `json:"conditions,omitempty"`
+	// ArchitectureReplicas is the number of GameServers in each sub-pool of ArchitecturePools, keyed by architecture.
+	// +optional
+	ArchitectureReplicas map[string]int32 `json:"architectureReplicas,omitempty"`
+	// StateReplicas is the number of GameServers in each state, such as Ready and Crash.
+	// +optional
+	StateReplicas map[GameServerState]int32 `json:"stateReplicas,omitempty"`
+	// OpsStateReplicas is the number of GameServers in each opsState, such as None and Allocated.
+	// +optional
+	OpsStateReplicas map[OpsState]int32 `json:"opsStateReplicas,omitempty"`
+	// LastScaleDownDecision records the GameServers chosen to delete and the reasons when the GameServerSet scaled down last time.
+	// +optional
+	LastScaleDownDecision *ScaleDownDecision `json:"lastScaleDownDecision,omitempty"`
+	// ProvisioningSLO is the result of ProvisioningSLO over the rolling window.
+	// +optional
+	ProvisioningSLO *ProvisioningSLOStatus `json:"provisioningSLO,omitempty"`
+}
+
+type ProvisioningSLOStatus struct {
+	// Total is the number of the GameServers created within the window, whose network is Ready, or is not Ready
+	// after the threshold. The GameServers which are still within the threshold are not counted yet.
+	Total int32 `json:"total"`
+	// WithinThreshold is the number of the GameServers whose network became Ready within the threshold.
+	WithinThreshold int32 `json:"withinThreshold"`
+	// Percentage is WithinThreshold divided by Total in percent with two decimals, such as 99.50.
+	// It is empty if Total is 0.
+	// +optional
+	Percentage string `json:"percentage,omitempty"`
 }
 
+// ScaleDownDecision describes which GameServers were deleted by a scale-down and why.
+type ScaleDownDecision struct {
+	// Time is when the decision was made.
+	Time metav1.Time `json:"time"`
+	// CurrentReplicas is the number of GameServers before scaling down.
+	CurrentReplicas int32 `json:"currentReplicas"`
+	// ExpectedReplicas is the number of GameServers expected after scaling down.
+	ExpectedReplicas int32 `json:"expectedReplicas"`
+	// GameServers are the GameServers chosen to delete.
+	// +optional
+	GameServers []ScaleDownGameServer `json:"gameServers,omitempty"`
+}
+
+type ScaleDownGameServer struct {
+	// Id is the ordinal of the GameServer.
+	Id int `json:"id"`
+	// Reason is why the GameServer was chosen, Reserved or DeleteSequence.
+	Reason ScaleDownReason `json:"reason"`
+	// Message describes the attributes the choice was made by, such as the opsState, deletion priority and score.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type ScaleDownReason string
+
+const (
+	// ScaleDownReasonReserved means the id of the GameServer was added to ReserveGameServerIds.
+	ScaleDownReasonReserved ScaleDownReason = "Reserved"
+	// ScaleDownReasonDeleteSequence means the GameServer came first in the delete sequence,
+	// which is ordered by opsState, deletion priority, score if ScoringPolicy is set, and ordinal.
+	ScaleDownReasonDeleteSequence ScaleDownReason = "DeleteSequence"
+)
+
+type GameServerSetCondition struct {
+	// Type is the type of the condition.
+	Type GameServerSetConditionType `json:"type"`
+	// Status is the status of the condition.
+	// Can be True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+	// Last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Unique, one-word, CamelCase reason for the condition's last transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Human-readable message indicating details about last transition.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type GameServerSetConditionType string
+
+const (
+	// GameServerSetProgressing means the GameServerSet is rolling out, i.e. not all GameServers are updated yet.
+	GameServerSetProgressing GameServerSetConditionType = "Progressing"
+	// GameServerSetDegraded means some GameServers fail, such as image pull failures or network errors.
+	GameServerSetDegraded GameServerSetConditionType = "Degraded"
+	// GameServerSetAvailable means the desired number of GameServers are available.
+	GameServerSetAvailable GameServerSetConditionType = "Available"
+)
+
 //+genclient
 //+kubebuilder:object:root=true
 //+kubebuilder:printcolumn:name="DESIRED",type="integer",JSONPath=".spec.replicas",description="The desired number of GameServers."
@@ -197,6 +801,7 @@ type GameServerSetStatus struct {
 //+kubebuilder:printcolumn:name="READY",type="integer",JSONPath=".status.readyReplicas",description="The number of GameServers ready."
 //+kubebuilder:printcolumn:name="Maintaining",type="integer",JSONPath=".status.maintainingReplicas",description="The number of GameServers Maintaining."
 //+kubebuilder:printcolumn:name="WaitToBeDeleted",type="integer",JSONPath=".status.waitToBeDeletedReplicas",description="The number of GameServers WaitToBeDeleted."
+//+kubebuilder:printcolumn:name="Allocated",type="integer",JSONPath=".status.opsStateReplicas.Allocated",description="The number of GameServers Allocated."
 //+kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp",description="The age of GameServerSet."
 //+kubebuilder:subresource:status
 //+kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.labelSelector
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// NodePoolUpgradeSpec defines the desired state of NodePoolUpgrade
+type NodePoolUpgradeSpec struct {
+	// NodeSelector selects the nodes to be upgraded, from which the GameServers are moved to the other nodes.
+	NodeSelector metav1.LabelSelector `json:"nodeSelector"`
+	// GameServerSets are the fleets drained in order, in the format of namespace/name. The other fleets with
+	// GameServers on the nodes are drained after them, in the order of namespace and name.
+	// +optional
+	GameServerSets []string `json:"gameServerSets,omitempty"`
+	// MaxUnavailable is the maximum number of GameServers of a fleet which are being drained or not ready at a time.
+	// Default is 1.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	// DrainPolicy indicates how the GameServers not idle are drained.
+	// +optional
+	DrainPolicy *UpgradeDrainPolicy `json:"drainPolicy,omitempty"`
+	// Paused stops draining GameServers, while the nodes are kept cordoned.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+type UpgradeDrainPolicy struct {
+	// Type is Wait or Force. Default is Wait.
+	// +optional
+	Type UpgradeDrainPolicyType `json:"type,omitempty"`
+	// TimeoutSeconds is how long to wait for the GameServers not idle since the fleet starts draining, after which
+	// they are deleted if Type is Force. Default is 3600.
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+type UpgradeDrainPolicyType string
+
+const (
+	// WaitUpgradeDrainPolicyType waits until the GameServers not idle, such as Allocated ones, become idle.
+	WaitUpgradeDrainPolicyType UpgradeDrainPolicyType = "Wait"
+	// ForceUpgradeDrainPolicyType deletes the GameServers not idle once TimeoutSeconds passes.
+	ForceUpgradeDrainPolicyType UpgradeDrainPolicyType = "Force"
+)
+
+// DefaultUpgradeDrainTimeoutSeconds is the default TimeoutSeconds of UpgradeDrainPolicy.
+const DefaultUpgradeDrainTimeoutSeconds = 3600
+
+// NodePoolUpgradeDrainKey is annotated on the GameServer whose opsState is set to WaitToBeDeleted by a NodePoolUpgrade
+// to drain it, and its value is the name of the NodePoolUpgrade. The opsState is set back to None once the GameServer
+// is recreated on another node.
+const NodePoolUpgradeDrainKey = "game.kruise.io/node-pool-upgrade"
+
+type NodePoolUpgradePhase string
+
+const (
+	// NodePoolUpgradeDraining means the GameServers of a fleet are being moved from the nodes.
+	NodePoolUpgradeDraining NodePoolUpgradePhase = "Draining"
+	// NodePoolUpgradeWaitingForCapacity means the GameServers of a fleet have left the nodes, and the replacements
+	// are not all ready yet.
+	NodePoolUpgradeWaitingForCapacity NodePoolUpgradePhase = "WaitingForCapacity"
+	// NodePoolUpgradePaused means the upgrade is paused.
+	NodePoolUpgradePaused NodePoolUpgradePhase = "Paused"
+	// NodePoolUpgradeCompleted means there are no GameServers left on the nodes, which can be upgraded now.
+	NodePoolUpgradeCompleted NodePoolUpgradePhase = "Completed"
+)
+
+// NodePoolUpgradeStatus defines the observed state of NodePoolUpgrade
+type NodePoolUpgradeStatus struct {
+	// ObservedGeneration is the most recent generation observed for this NodePoolUpgrade.
+	ObservedGeneration int64                `json:"observedGeneration,omitempty"`
+	Phase              NodePoolUpgradePhase `json:"phase,omitempty"`
+	// Nodes is the number of nodes selected and cordoned.
+	Nodes int32 `json:"nodes,omitempty"`
+	// RemainingGameServers is the number of GameServers left on the nodes.
+	RemainingGameServers int32 `json:"remainingGameServers,omitempty"`
+	// CurrentGameServerSet is the fleet being drained, in the format of namespace/name.
+	CurrentGameServerSet string `json:"currentGameServerSet,omitempty"`
+	// CurrentStartTime is when the current fleet starts draining.
+	CurrentStartTime *metav1.Time `json:"currentStartTime,omitempty"`
+	// CompletedGameServerSets are the fleets drained, whose replacements are all ready.
+	CompletedGameServerSets []string `json:"completedGameServerSets,omitempty"`
+	Message                 string   `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="PHASE",type="string",JSONPath=".status.phase",description="The phase of the upgrade"
+//+kubebuilder:printcolumn:name="CURRENT",type="string",JSONPath=".status.currentGameServerSet",description="The fleet being drained"
+//+kubebuilder:printcolumn:name="REMAINING",type="integer",JSONPath=".status.remainingGameServers",description="The GameServers left on the nodes"
+//+kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp",description="The age of NodePoolUpgrade"
+//+kubebuilder:resource:scope=Cluster,shortName=npu
+
+// NodePoolUpgrade is the Schema for the nodepoolupgrades API.
+// It moves the GameServers off the nodes to be upgraded fleet by fleet, idle GameServers first.
+type NodePoolUpgrade struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NodePoolUpgradeSpec   `json:"spec,omitempty"`
+	Status NodePoolUpgradeStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// NodePoolUpgradeList contains a list of NodePoolUpgrade
+type NodePoolUpgradeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NodePoolUpgrade `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NodePoolUpgrade{}, &NodePoolUpgradeList{})
+}
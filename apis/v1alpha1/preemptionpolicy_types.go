@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PreemptionPolicySpec defines the desired state of PreemptionPolicy
+type PreemptionPolicySpec struct {
+	// GameServerSets are the fleets in the pool and their priorities.
+	// An idle GameServer can only be preempted by an allocation to a fleet with higher priority.
+	GameServerSets []PreemptionGameServerSet `json:"gameServerSets"`
+	// MaxReplicas is the replica quota of the pool, which is the sum of replicas of all fleets in it.
+	// Preemption only happens when the quota is hit.
+	MaxReplicas int32 `json:"maxReplicas"`
+	// Action indicates how to preempt the idle GameServer. Default is Drain.
+	// +optional
+	Action PreemptionAction `json:"action,omitempty"`
+}
+
+type PreemptionGameServerSet struct {
+	Name     string `json:"name"`
+	Priority int32  `json:"priority"`
+}
+
+type PreemptionAction string
+
+const (
+	// PreemptionActionDrain marks the preempted GameServer as WaitToBeDeleted and scales down its GameServerSet,
+	// so that it is deleted by the scale-down strategy of OpsState.
+	PreemptionActionDrain PreemptionAction = "Drain"
+	// PreemptionActionDelete marks the preempted GameServer as Kill, so that it is deleted immediately.
+	PreemptionActionDelete PreemptionAction = "Delete"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:printcolumn:name="MAX",type="integer",JSONPath=".spec.maxReplicas",description="The replica quota of the pool"
+//+kubebuilder:printcolumn:name="ACTION",type="string",JSONPath=".spec.action",description="The action to preempt GameServers"
+//+kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp",description="The age of PreemptionPolicy"
+//+kubebuilder:resource:shortName=pp
+
+// PreemptionPolicy is the Schema for the preemptionpolicies API.
+// It defines a pool of GameServerSets in which the idle GameServers of low priority fleets
+// can be preempted by the allocations to high priority fleets.
+type PreemptionPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PreemptionPolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PreemptionPolicyList contains a list of PreemptionPolicy
+type PreemptionPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PreemptionPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PreemptionPolicy{}, &PreemptionPolicyList{})
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ServiceQualityTemplateSpec defines the desired state of ServiceQualityTemplate
+type ServiceQualityTemplateSpec struct {
+	// ServiceQualities are applied to all GameServerSets which reference the template.
+	// A ServiceQuality of the GameServerSet with the same name overrides the one of the template.
+	ServiceQualities []ServiceQuality `json:"serviceQualities"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster,shortName=sqt
+//+kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp",description="The age of ServiceQualityTemplate"
+
+// ServiceQualityTemplate is the Schema for the servicequalitytemplates API.
+// It defines the ServiceQualities shared by many GameServerSets, such as the idle probe of all fleets.
+type ServiceQualityTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ServiceQualityTemplateSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ServiceQualityTemplateList contains a list of ServiceQualityTemplate
+type ServiceQualityTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServiceQualityTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ServiceQualityTemplate{}, &ServiceQualityTemplateList{})
+}
@@ -23,11 +23,228 @@ package v1alpha1
 
 import (
 	"github.com/openkruise/kruise-api/apps/pub"
+	batchv1 "k8s.io/api/batch/v1"
 	"k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllocationRequester) DeepCopyInto(out *AllocationRequester) {
+	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllocationRequester.
+func (in *AllocationRequester) DeepCopy() *AllocationRequester {
+	if in == nil {
+		return nil
+	}
+	out := new(AllocationRequester)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArchitecturePool) DeepCopyInto(out *ArchitecturePool) {
+	*out = *in
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]ContainerImage, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArchitecturePool.
+func (in *ArchitecturePool) DeepCopy() *ArchitecturePool {
+	if in == nil {
+		return nil
+	}
+	out := new(ArchitecturePool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerImage) DeepCopyInto(out *ContainerImage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerImage.
+func (in *ContainerImage) DeepCopy() *ContainerImage {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerImage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionStatus) DeepCopyInto(out *ConnectionStatus) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionStatus.
+func (in *ConnectionStatus) DeepCopy() *ConnectionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomStatusField) DeepCopyInto(out *CustomStatusField) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomStatusField.
+func (in *CustomStatusField) DeepCopy() *CustomStatusField {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomStatusField)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EgressPolicy) DeepCopyInto(out *EgressPolicy) {
+	*out = *in
+	if in.CIDRs != nil {
+		in, out := &in.CIDRs, &out.CIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]networkingv1.NetworkPolicyPort, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EgressPolicy.
+func (in *EgressPolicy) DeepCopy() *EgressPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(EgressPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalLoadBalancer) DeepCopyInto(out *ExternalLoadBalancer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalLoadBalancer.
+func (in *ExternalLoadBalancer) DeepCopy() *ExternalLoadBalancer {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalLoadBalancer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExternalLoadBalancer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalLoadBalancerList) DeepCopyInto(out *ExternalLoadBalancerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ExternalLoadBalancer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalLoadBalancerList.
+func (in *ExternalLoadBalancerList) DeepCopy() *ExternalLoadBalancerList {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalLoadBalancerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExternalLoadBalancerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalLoadBalancerSpec) DeepCopyInto(out *ExternalLoadBalancerSpec) {
+	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalLoadBalancerSpec.
+func (in *ExternalLoadBalancerSpec) DeepCopy() *ExternalLoadBalancerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalLoadBalancerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalLoadBalancerStatus) DeepCopyInto(out *ExternalLoadBalancerStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalLoadBalancerStatus.
+func (in *ExternalLoadBalancerStatus) DeepCopy() *ExternalLoadBalancerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalLoadBalancerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GameServer) DeepCopyInto(out *GameServer) {
 	*out = *in
@@ -55,6 +272,121 @@ func (in *GameServer) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServerAllocation) DeepCopyInto(out *GameServerAllocation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerAllocation.
+func (in *GameServerAllocation) DeepCopy() *GameServerAllocation {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerAllocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GameServerAllocation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServerAllocationList) DeepCopyInto(out *GameServerAllocationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GameServerAllocation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerAllocationList.
+func (in *GameServerAllocationList) DeepCopy() *GameServerAllocationList {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerAllocationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GameServerAllocationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServerAllocationSpec) DeepCopyInto(out *GameServerAllocationSpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Requester != nil {
+		in, out := &in.Requester, &out.Requester
+		*out = new(AllocationRequester)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerAllocationSpec.
+func (in *GameServerAllocationSpec) DeepCopy() *GameServerAllocationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerAllocationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServerAllocationStatus) DeepCopyInto(out *GameServerAllocationStatus) {
+	*out = *in
+	if in.ExternalAddresses != nil {
+		in, out := &in.ExternalAddresses, &out.ExternalAddresses
+		*out = make([]NetworkAddress, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerAllocationStatus.
+func (in *GameServerAllocationStatus) DeepCopy() *GameServerAllocationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerAllocationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GameServerCondition) DeepCopyInto(out *GameServerCondition) {
 	*out = *in
@@ -147,6 +479,22 @@ func (in *GameServerSet) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServerSetCondition) DeepCopyInto(out *GameServerSetCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerSetCondition.
+func (in *GameServerSetCondition) DeepCopy() *GameServerSetCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerSetCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GameServerSetList) DeepCopyInto(out *GameServerSetList) {
 	*out = *in
@@ -207,12 +555,81 @@ func (in *GameServerSetSpec) DeepCopyInto(out *GameServerSetSpec) {
 		*out = new(Network)
 		(*in).DeepCopyInto(*out)
 	}
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerSetSpec.
-func (in *GameServerSetSpec) DeepCopy() *GameServerSetSpec {
-	if in == nil {
-		return nil
+	if in.ReserveGameServerIdRanges != nil {
+		in, out := &in.ReserveGameServerIdRanges, &out.ReserveGameServerIdRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImagePolicy != nil {
+		in, out := &in.ImagePolicy, &out.ImagePolicy
+		*out = new(ImagePolicy)
+		**out = **in
+	}
+	if in.ScoringPolicy != nil {
+		in, out := &in.ScoringPolicy, &out.ScoringPolicy
+		*out = new(ScoringPolicy)
+		**out = **in
+	}
+	if in.CustomStatusFields != nil {
+		in, out := &in.CustomStatusFields, &out.CustomStatusFields
+		*out = make([]CustomStatusField, len(*in))
+		copy(*out, *in)
+	}
+	if in.OverflowPolicy != nil {
+		in, out := &in.OverflowPolicy, &out.OverflowPolicy
+		*out = new(OverflowPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ArchitecturePools != nil {
+		in, out := &in.ArchitecturePools, &out.ArchitecturePools
+		*out = make([]ArchitecturePool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RestartPolicy != nil {
+		in, out := &in.RestartPolicy, &out.RestartPolicy
+		*out = new(RestartPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MemoryLeakPolicy != nil {
+		in, out := &in.MemoryLeakPolicy, &out.MemoryLeakPolicy
+		*out = new(MemoryLeakPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EgressPolicy != nil {
+		in, out := &in.EgressPolicy, &out.EgressPolicy
+		*out = new(EgressPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResourceProfiles != nil {
+		in, out := &in.ResourceProfiles, &out.ResourceProfiles
+		*out = make([]ResourceProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PreDeleteHook != nil {
+		in, out := &in.PreDeleteHook, &out.PreDeleteHook
+		*out = new(PreDeleteHook)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScalingPolicy != nil {
+		in, out := &in.ScalingPolicy, &out.ScalingPolicy
+		*out = new(ScalingPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProvisioningSLO != nil {
+		in, out := &in.ProvisioningSLO, &out.ProvisioningSLO
+		*out = new(ProvisioningSLO)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerSetSpec.
+func (in *GameServerSetSpec) DeepCopy() *GameServerSetSpec {
+	if in == nil {
+		return nil
 	}
 	out := new(GameServerSetSpec)
 	in.DeepCopyInto(out)
@@ -232,6 +649,44 @@ func (in *GameServerSetStatus) DeepCopyInto(out *GameServerSetStatus) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]GameServerSetCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ArchitectureReplicas != nil {
+		in, out := &in.ArchitectureReplicas, &out.ArchitectureReplicas
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StateReplicas != nil {
+		in, out := &in.StateReplicas, &out.StateReplicas
+		*out = make(map[GameServerState]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.OpsStateReplicas != nil {
+		in, out := &in.OpsStateReplicas, &out.OpsStateReplicas
+		*out = make(map[OpsState]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LastScaleDownDecision != nil {
+		in, out := &in.LastScaleDownDecision, &out.LastScaleDownDecision
+		*out = new(ScaleDownDecision)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ProvisioningSLO != nil {
+		in, out := &in.ProvisioningSLO, &out.ProvisioningSLO
+		*out = new(ProvisioningSLOStatus)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerSetStatus.
@@ -279,315 +734,1152 @@ func (in *GameServerSpec) DeepCopy() *GameServerSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GameServerStatus) DeepCopyInto(out *GameServerStatus) {
 	*out = *in
-	in.NetworkStatus.DeepCopyInto(&out.NetworkStatus)
-	in.PodStatus.DeepCopyInto(&out.PodStatus)
-	if in.ServiceQualitiesCondition != nil {
-		in, out := &in.ServiceQualitiesCondition, &out.ServiceQualitiesCondition
-		*out = make([]ServiceQualityCondition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	in.NetworkStatus.DeepCopyInto(&out.NetworkStatus)
+	in.PodStatus.DeepCopyInto(&out.PodStatus)
+	if in.ServiceQualitiesCondition != nil {
+		in, out := &in.ServiceQualitiesCondition, &out.ServiceQualitiesCondition
+		*out = make([]ServiceQualityCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.UpdatePriority != nil {
+		in, out := &in.UpdatePriority, &out.UpdatePriority
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.DeletionPriority != nil {
+		in, out := &in.DeletionPriority, &out.DeletionPriority
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]GameServerCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PreUpdateJob != nil {
+		in, out := &in.PreUpdateJob, &out.PreUpdateJob
+		*out = new(PreUpdateJobStatus)
+		**out = **in
+	}
+	if in.CustomStatus != nil {
+		in, out := &in.CustomStatus, &out.CustomStatus
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Connections != nil {
+		in, out := &in.Connections, &out.Connections
+		*out = new(ConnectionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerStatus.
+func (in *GameServerStatus) DeepCopy() *GameServerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServerTemplate) DeepCopyInto(out *GameServerTemplate) {
+	*out = *in
+	in.PodTemplateSpec.DeepCopyInto(&out.PodTemplateSpec)
+	if in.VolumeClaimTemplates != nil {
+		in, out := &in.VolumeClaimTemplates, &out.VolumeClaimTemplates
+		*out = make([]v1.PersistentVolumeClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerTemplate.
+func (in *GameServerTemplate) DeepCopy() *GameServerTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HotUpdate) DeepCopyInto(out *HotUpdate) {
+	*out = *in
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HotUpdate.
+func (in *HotUpdate) DeepCopy() *HotUpdate {
+	if in == nil {
+		return nil
+	}
+	out := new(HotUpdate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImagePolicy) DeepCopyInto(out *ImagePolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImagePolicy.
+func (in *ImagePolicy) DeepCopy() *ImagePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ImagePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KVParams) DeepCopyInto(out *KVParams) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KVParams.
+func (in *KVParams) DeepCopy() *KVParams {
+	if in == nil {
+		return nil
+	}
+	out := new(KVParams)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemoryLeakPolicy) DeepCopyInto(out *MemoryLeakPolicy) {
+	*out = *in
+	if in.LimitPercent != nil {
+		in, out := &in.LimitPercent, &out.LimitPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.GrowthPerMinute != nil {
+		in, out := &in.GrowthPerMinute, &out.GrowthPerMinute
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.DurationMinutes != nil {
+		in, out := &in.DurationMinutes, &out.DurationMinutes
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemoryLeakPolicy.
+func (in *MemoryLeakPolicy) DeepCopy() *MemoryLeakPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(MemoryLeakPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Network) DeepCopyInto(out *Network) {
+	*out = *in
+	if in.NetworkConf != nil {
+		in, out := &in.NetworkConf, &out.NetworkConf
+		*out = make([]NetworkConfParams, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Network.
+func (in *Network) DeepCopy() *Network {
+	if in == nil {
+		return nil
+	}
+	out := new(Network)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkAddress) DeepCopyInto(out *NetworkAddress) {
+	*out = *in
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]NetworkPort, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PortRange != nil {
+		in, out := &in.PortRange, &out.PortRange
+		*out = new(NetworkPortRange)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkAddress.
+func (in *NetworkAddress) DeepCopy() *NetworkAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkConfParams) DeepCopyInto(out *NetworkConfParams) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkConfParams.
+func (in *NetworkConfParams) DeepCopy() *NetworkConfParams {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkConfParams)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPort) DeepCopyInto(out *NetworkPort) {
+	*out = *in
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPort.
+func (in *NetworkPort) DeepCopy() *NetworkPort {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPortRange) DeepCopyInto(out *NetworkPortRange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPortRange.
+func (in *NetworkPortRange) DeepCopy() *NetworkPortRange {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPortRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkStatus) DeepCopyInto(out *NetworkStatus) {
+	*out = *in
+	if in.InternalAddresses != nil {
+		in, out := &in.InternalAddresses, &out.InternalAddresses
+		*out = make([]NetworkAddress, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExternalAddresses != nil {
+		in, out := &in.ExternalAddresses, &out.ExternalAddresses
+		*out = make([]NetworkAddress, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.CreateTime.DeepCopyInto(&out.CreateTime)
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkStatus.
+func (in *NetworkStatus) DeepCopy() *NetworkStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolUpgrade) DeepCopyInto(out *NodePoolUpgrade) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolUpgrade.
+func (in *NodePoolUpgrade) DeepCopy() *NodePoolUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodePoolUpgrade) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolUpgradeList) DeepCopyInto(out *NodePoolUpgradeList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NodePoolUpgrade, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolUpgradeList.
+func (in *NodePoolUpgradeList) DeepCopy() *NodePoolUpgradeList {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolUpgradeList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodePoolUpgradeList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolUpgradeSpec) DeepCopyInto(out *NodePoolUpgradeSpec) {
+	*out = *in
+	in.NodeSelector.DeepCopyInto(&out.NodeSelector)
+	if in.GameServerSets != nil {
+		in, out := &in.GameServerSets, &out.GameServerSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.DrainPolicy != nil {
+		in, out := &in.DrainPolicy, &out.DrainPolicy
+		*out = new(UpgradeDrainPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolUpgradeSpec.
+func (in *NodePoolUpgradeSpec) DeepCopy() *NodePoolUpgradeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolUpgradeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolUpgradeStatus) DeepCopyInto(out *NodePoolUpgradeStatus) {
+	*out = *in
+	if in.CurrentStartTime != nil {
+		in, out := &in.CurrentStartTime, &out.CurrentStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletedGameServerSets != nil {
+		in, out := &in.CompletedGameServerSets, &out.CompletedGameServerSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolUpgradeStatus.
+func (in *NodePoolUpgradeStatus) DeepCopy() *NodePoolUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OverflowPolicy) DeepCopyInto(out *OverflowPolicy) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OverflowPolicy.
+func (in *OverflowPolicy) DeepCopy() *OverflowPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(OverflowPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlayersSource) DeepCopyInto(out *PlayersSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlayersSource.
+func (in *PlayersSource) DeepCopy() *PlayersSource {
+	if in == nil {
+		return nil
+	}
+	out := new(PlayersSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreDeleteHook) DeepCopyInto(out *PreDeleteHook) {
+	*out = *in
+	if in.GraceSeconds != nil {
+		in, out := &in.GraceSeconds, &out.GraceSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreDeleteHook.
+func (in *PreDeleteHook) DeepCopy() *PreDeleteHook {
+	if in == nil {
+		return nil
+	}
+	out := new(PreDeleteHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreUpdateJobStatus) DeepCopyInto(out *PreUpdateJobStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreUpdateJobStatus.
+func (in *PreUpdateJobStatus) DeepCopy() *PreUpdateJobStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PreUpdateJobStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreemptionGameServerSet) DeepCopyInto(out *PreemptionGameServerSet) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreemptionGameServerSet.
+func (in *PreemptionGameServerSet) DeepCopy() *PreemptionGameServerSet {
+	if in == nil {
+		return nil
+	}
+	out := new(PreemptionGameServerSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreemptionPolicy) DeepCopyInto(out *PreemptionPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreemptionPolicy.
+func (in *PreemptionPolicy) DeepCopy() *PreemptionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PreemptionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PreemptionPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreemptionPolicyList) DeepCopyInto(out *PreemptionPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PreemptionPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreemptionPolicyList.
+func (in *PreemptionPolicyList) DeepCopy() *PreemptionPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(PreemptionPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PreemptionPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreemptionPolicySpec) DeepCopyInto(out *PreemptionPolicySpec) {
+	*out = *in
+	if in.GameServerSets != nil {
+		in, out := &in.GameServerSets, &out.GameServerSets
+		*out = make([]PreemptionGameServerSet, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreemptionPolicySpec.
+func (in *PreemptionPolicySpec) DeepCopy() *PreemptionPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PreemptionPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvisioningSLO) DeepCopyInto(out *ProvisioningSLO) {
+	*out = *in
+	if in.WindowSeconds != nil {
+		in, out := &in.WindowSeconds, &out.WindowSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisioningSLO.
+func (in *ProvisioningSLO) DeepCopy() *ProvisioningSLO {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvisioningSLO)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvisioningSLOStatus) DeepCopyInto(out *ProvisioningSLOStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisioningSLOStatus.
+func (in *ProvisioningSLOStatus) DeepCopy() *ProvisioningSLOStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvisioningSLOStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceProfile) DeepCopyInto(out *ResourceProfile) {
+	*out = *in
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]ResourceProfileContainer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceProfile.
+func (in *ResourceProfile) DeepCopy() *ResourceProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceProfileContainer) DeepCopyInto(out *ResourceProfileContainer) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceProfileContainer.
+func (in *ResourceProfileContainer) DeepCopy() *ResourceProfileContainer {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceProfileContainer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestartPolicy) DeepCopyInto(out *RestartPolicy) {
+	*out = *in
+	if in.WindowSeconds != nil {
+		in, out := &in.WindowSeconds, &out.WindowSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestartPolicy.
+func (in *RestartPolicy) DeepCopy() *RestartPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RestartPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingUpdateStatefulSetStrategy) DeepCopyInto(out *RollingUpdateStatefulSetStrategy) {
+	*out = *in
+	if in.Partition != nil {
+		in, out := &in.Partition, &out.Partition
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.InPlaceUpdateStrategy != nil {
+		in, out := &in.InPlaceUpdateStrategy, &out.InPlaceUpdateStrategy
+		*out = new(pub.InPlaceUpdateStrategy)
+		**out = **in
+	}
+	if in.MinReadySeconds != nil {
+		in, out := &in.MinReadySeconds, &out.MinReadySeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateStatefulSetStrategy.
+func (in *RollingUpdateStatefulSetStrategy) DeepCopy() *RollingUpdateStatefulSetStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingUpdateStatefulSetStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleDownDecision) DeepCopyInto(out *ScaleDownDecision) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.GameServers != nil {
+		in, out := &in.GameServers, &out.GameServers
+		*out = make([]ScaleDownGameServer, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleDownDecision.
+func (in *ScaleDownDecision) DeepCopy() *ScaleDownDecision {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleDownDecision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleDownGameServer) DeepCopyInto(out *ScaleDownGameServer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleDownGameServer.
+func (in *ScaleDownGameServer) DeepCopy() *ScaleDownGameServer {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleDownGameServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScaleStrategy) DeepCopyInto(out *ScaleStrategy) {
+	*out = *in
+	in.StatefulSetScaleStrategy.DeepCopyInto(&out.StatefulSetScaleStrategy)
+	if in.PlayersSource != nil {
+		in, out := &in.PlayersSource, &out.PlayersSource
+		*out = new(PlayersSource)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleStrategy.
+func (in *ScaleStrategy) DeepCopy() *ScaleStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(ScaleStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScalingPolicy) DeepCopyInto(out *ScalingPolicy) {
+	*out = *in
+	if in.MaxIdle != nil {
+		in, out := &in.MaxIdle, &out.MaxIdle
+		*out = new(int32)
+		**out = **in
 	}
-	if in.UpdatePriority != nil {
-		in, out := &in.UpdatePriority, &out.UpdatePriority
-		*out = new(intstr.IntOrString)
+	if in.MinReplicas != nil {
+		in, out := &in.MinReplicas, &out.MinReplicas
+		*out = new(int32)
 		**out = **in
 	}
-	if in.DeletionPriority != nil {
-		in, out := &in.DeletionPriority, &out.DeletionPriority
-		*out = new(intstr.IntOrString)
+	if in.CooldownSeconds != nil {
+		in, out := &in.CooldownSeconds, &out.CooldownSeconds
+		*out = new(int32)
 		**out = **in
 	}
-	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]GameServerCondition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.Schedules != nil {
+		in, out := &in.Schedules, &out.Schedules
+		*out = make([]ScalingSchedule, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerStatus.
-func (in *GameServerStatus) DeepCopy() *GameServerStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingPolicy.
+func (in *ScalingPolicy) DeepCopy() *ScalingPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(GameServerStatus)
+	out := new(ScalingPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GameServerTemplate) DeepCopyInto(out *GameServerTemplate) {
+func (in *ScalingSchedule) DeepCopyInto(out *ScalingSchedule) {
 	*out = *in
-	in.PodTemplateSpec.DeepCopyInto(&out.PodTemplateSpec)
-	if in.VolumeClaimTemplates != nil {
-		in, out := &in.VolumeClaimTemplates, &out.VolumeClaimTemplates
-		*out = make([]v1.PersistentVolumeClaim, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerTemplate.
-func (in *GameServerTemplate) DeepCopy() *GameServerTemplate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScalingSchedule.
+func (in *ScalingSchedule) DeepCopy() *ScalingSchedule {
 	if in == nil {
 		return nil
 	}
-	out := new(GameServerTemplate)
+	out := new(ScalingSchedule)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KVParams) DeepCopyInto(out *KVParams) {
+func (in *ScoringPolicy) DeepCopyInto(out *ScoringPolicy) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KVParams.
-func (in *KVParams) DeepCopy() *KVParams {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScoringPolicy.
+func (in *ScoringPolicy) DeepCopy() *ScoringPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(KVParams)
+	out := new(ScoringPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Network) DeepCopyInto(out *Network) {
+func (in *ServiceQuality) DeepCopyInto(out *ServiceQuality) {
 	*out = *in
-	if in.NetworkConf != nil {
-		in, out := &in.NetworkConf, &out.NetworkConf
-		*out = make([]NetworkConfParams, len(*in))
-		copy(*out, *in)
+	in.Probe.DeepCopyInto(&out.Probe)
+	if in.ServiceQualityAction != nil {
+		in, out := &in.ServiceQualityAction, &out.ServiceQualityAction
+		*out = make([]ServiceQualityAction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ResultHistoryLimit != nil {
+		in, out := &in.ResultHistoryLimit, &out.ResultHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReArmAfterSeconds != nil {
+		in, out := &in.ReArmAfterSeconds, &out.ReArmAfterSeconds
+		*out = new(int32)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Network.
-func (in *Network) DeepCopy() *Network {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceQuality.
+func (in *ServiceQuality) DeepCopy() *ServiceQuality {
 	if in == nil {
 		return nil
 	}
-	out := new(Network)
+	out := new(ServiceQuality)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NetworkAddress) DeepCopyInto(out *NetworkAddress) {
+func (in *ServiceQualityAction) DeepCopyInto(out *ServiceQualityAction) {
 	*out = *in
-	if in.Ports != nil {
-		in, out := &in.Ports, &out.Ports
-		*out = make([]NetworkPort, len(*in))
+	if in.ResultRange != nil {
+		in, out := &in.ResultRange, &out.ResultRange
+		*out = new(ServiceQualityResultRange)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConsecutiveProbes != nil {
+		in, out := &in.ConsecutiveProbes, &out.ConsecutiveProbes
+		*out = new(int32)
+		**out = **in
+	}
+	in.GameServerSpec.DeepCopyInto(&out.GameServerSpec)
+	if in.Actions != nil {
+		in, out := &in.Actions, &out.Actions
+		*out = make([]ServiceQualityActionItem, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.PortRange != nil {
-		in, out := &in.PortRange, &out.PortRange
-		*out = new(NetworkPortRange)
-		**out = **in
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkAddress.
-func (in *NetworkAddress) DeepCopy() *NetworkAddress {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceQualityAction.
+func (in *ServiceQualityAction) DeepCopy() *ServiceQualityAction {
 	if in == nil {
 		return nil
 	}
-	out := new(NetworkAddress)
+	out := new(ServiceQualityAction)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NetworkConfParams) DeepCopyInto(out *NetworkConfParams) {
+func (in *ServiceQualityActionItem) DeepCopyInto(out *ServiceQualityActionItem) {
 	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkConfParams.
-func (in *NetworkConfParams) DeepCopy() *NetworkConfParams {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceQualityActionItem.
+func (in *ServiceQualityActionItem) DeepCopy() *ServiceQualityActionItem {
 	if in == nil {
 		return nil
 	}
-	out := new(NetworkConfParams)
+	out := new(ServiceQualityActionItem)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NetworkPort) DeepCopyInto(out *NetworkPort) {
+func (in *ServiceQualityCondition) DeepCopyInto(out *ServiceQualityCondition) {
 	*out = *in
-	if in.Port != nil {
-		in, out := &in.Port, &out.Port
-		*out = new(intstr.IntOrString)
-		**out = **in
+	in.LastProbeTime.DeepCopyInto(&out.LastProbeTime)
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	in.LastActionTransitionTime.DeepCopyInto(&out.LastActionTransitionTime)
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]ServiceQualityResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MatchedActions != nil {
+		in, out := &in.MatchedActions, &out.MatchedActions
+		*out = make([]ServiceQualityMatchedAction, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPort.
-func (in *NetworkPort) DeepCopy() *NetworkPort {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceQualityCondition.
+func (in *ServiceQualityCondition) DeepCopy() *ServiceQualityCondition {
 	if in == nil {
 		return nil
 	}
-	out := new(NetworkPort)
+	out := new(ServiceQualityCondition)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NetworkPortRange) DeepCopyInto(out *NetworkPortRange) {
+func (in *ServiceQualityMatchedAction) DeepCopyInto(out *ServiceQualityMatchedAction) {
 	*out = *in
+	in.DueTime.DeepCopyInto(&out.DueTime)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPortRange.
-func (in *NetworkPortRange) DeepCopy() *NetworkPortRange {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceQualityMatchedAction.
+func (in *ServiceQualityMatchedAction) DeepCopy() *ServiceQualityMatchedAction {
 	if in == nil {
 		return nil
 	}
-	out := new(NetworkPortRange)
+	out := new(ServiceQualityMatchedAction)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NetworkStatus) DeepCopyInto(out *NetworkStatus) {
+func (in *ServiceQualityResult) DeepCopyInto(out *ServiceQualityResult) {
 	*out = *in
-	if in.InternalAddresses != nil {
-		in, out := &in.InternalAddresses, &out.InternalAddresses
-		*out = make([]NetworkAddress, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.ExternalAddresses != nil {
-		in, out := &in.ExternalAddresses, &out.ExternalAddresses
-		*out = make([]NetworkAddress, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	in.ProbeTime.DeepCopyInto(&out.ProbeTime)
+	if in.Action != nil {
+		in, out := &in.Action, &out.Action
+		*out = new(GameServerSpec)
+		(*in).DeepCopyInto(*out)
 	}
-	in.CreateTime.DeepCopyInto(&out.CreateTime)
-	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkStatus.
-func (in *NetworkStatus) DeepCopy() *NetworkStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceQualityResult.
+func (in *ServiceQualityResult) DeepCopy() *ServiceQualityResult {
 	if in == nil {
 		return nil
 	}
-	out := new(NetworkStatus)
+	out := new(ServiceQualityResult)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RollingUpdateStatefulSetStrategy) DeepCopyInto(out *RollingUpdateStatefulSetStrategy) {
+func (in *ServiceQualityResultRange) DeepCopyInto(out *ServiceQualityResultRange) {
 	*out = *in
-	if in.Partition != nil {
-		in, out := &in.Partition, &out.Partition
-		*out = new(int32)
-		**out = **in
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		x := (*in).DeepCopy()
+		*out = &x
 	}
-	if in.MaxUnavailable != nil {
-		in, out := &in.MaxUnavailable, &out.MaxUnavailable
-		*out = new(intstr.IntOrString)
-		**out = **in
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		x := (*in).DeepCopy()
+		*out = &x
 	}
-	if in.InPlaceUpdateStrategy != nil {
-		in, out := &in.InPlaceUpdateStrategy, &out.InPlaceUpdateStrategy
-		*out = new(pub.InPlaceUpdateStrategy)
-		**out = **in
+	if in.Gt != nil {
+		in, out := &in.Gt, &out.Gt
+		x := (*in).DeepCopy()
+		*out = &x
 	}
-	if in.MinReadySeconds != nil {
-		in, out := &in.MinReadySeconds, &out.MinReadySeconds
-		*out = new(int32)
-		**out = **in
+	if in.Lt != nil {
+		in, out := &in.Lt, &out.Lt
+		x := (*in).DeepCopy()
+		*out = &x
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateStatefulSetStrategy.
-func (in *RollingUpdateStatefulSetStrategy) DeepCopy() *RollingUpdateStatefulSetStrategy {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceQualityResultRange.
+func (in *ServiceQualityResultRange) DeepCopy() *ServiceQualityResultRange {
 	if in == nil {
 		return nil
 	}
-	out := new(RollingUpdateStatefulSetStrategy)
+	out := new(ServiceQualityResultRange)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ScaleStrategy) DeepCopyInto(out *ScaleStrategy) {
+func (in *ServiceQualityTemplate) DeepCopyInto(out *ServiceQualityTemplate) {
 	*out = *in
-	in.StatefulSetScaleStrategy.DeepCopyInto(&out.StatefulSetScaleStrategy)
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScaleStrategy.
-func (in *ScaleStrategy) DeepCopy() *ScaleStrategy {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceQualityTemplate.
+func (in *ServiceQualityTemplate) DeepCopy() *ServiceQualityTemplate {
 	if in == nil {
 		return nil
 	}
-	out := new(ScaleStrategy)
+	out := new(ServiceQualityTemplate)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServiceQualityTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServiceQuality) DeepCopyInto(out *ServiceQuality) {
+func (in *ServiceQualityTemplateList) DeepCopyInto(out *ServiceQualityTemplateList) {
 	*out = *in
-	in.Probe.DeepCopyInto(&out.Probe)
-	if in.ServiceQualityAction != nil {
-		in, out := &in.ServiceQualityAction, &out.ServiceQualityAction
-		*out = make([]ServiceQualityAction, len(*in))
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ServiceQualityTemplate, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceQuality.
-func (in *ServiceQuality) DeepCopy() *ServiceQuality {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceQualityTemplateList.
+func (in *ServiceQualityTemplateList) DeepCopy() *ServiceQualityTemplateList {
 	if in == nil {
 		return nil
 	}
-	out := new(ServiceQuality)
+	out := new(ServiceQualityTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServiceQualityTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceQualityTemplateSpec) DeepCopyInto(out *ServiceQualityTemplateSpec) {
+	*out = *in
+	if in.ServiceQualities != nil {
+		in, out := &in.ServiceQualities, &out.ServiceQualities
+		*out = make([]ServiceQuality, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceQualityTemplateSpec.
+func (in *ServiceQualityTemplateSpec) DeepCopy() *ServiceQualityTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceQualityTemplateSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServiceQualityAction) DeepCopyInto(out *ServiceQualityAction) {
+func (in *UpdateFreezeWindow) DeepCopyInto(out *UpdateFreezeWindow) {
 	*out = *in
-	in.GameServerSpec.DeepCopyInto(&out.GameServerSpec)
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceQualityAction.
-func (in *ServiceQualityAction) DeepCopy() *ServiceQualityAction {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateFreezeWindow.
+func (in *UpdateFreezeWindow) DeepCopy() *UpdateFreezeWindow {
 	if in == nil {
 		return nil
 	}
-	out := new(ServiceQualityAction)
+	out := new(UpdateFreezeWindow)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServiceQualityCondition) DeepCopyInto(out *ServiceQualityCondition) {
+func (in *UpdateGate) DeepCopyInto(out *UpdateGate) {
 	*out = *in
-	in.LastProbeTime.DeepCopyInto(&out.LastProbeTime)
-	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
-	in.LastActionTransitionTime.DeepCopyInto(&out.LastActionTransitionTime)
+	if in.OpsStates != nil {
+		in, out := &in.OpsStates, &out.OpsStates
+		*out = make([]OpsState, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceQualityCondition.
-func (in *ServiceQualityCondition) DeepCopy() *ServiceQualityCondition {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateGate.
+func (in *UpdateGate) DeepCopy() *UpdateGate {
 	if in == nil {
 		return nil
 	}
-	out := new(ServiceQualityCondition)
+	out := new(UpdateGate)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -600,6 +1892,28 @@ func (in *UpdateStrategy) DeepCopyInto(out *UpdateStrategy) {
 		*out = new(RollingUpdateStatefulSetStrategy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.FreezeWindows != nil {
+		in, out := &in.FreezeWindows, &out.FreezeWindows
+		*out = make([]UpdateFreezeWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PreUpdateJob != nil {
+		in, out := &in.PreUpdateJob, &out.PreUpdateJob
+		*out = new(batchv1.JobTemplateSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpdateGate != nil {
+		in, out := &in.UpdateGate, &out.UpdateGate
+		*out = new(UpdateGate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HotUpdate != nil {
+		in, out := &in.HotUpdate, &out.HotUpdate
+		*out = new(HotUpdate)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateStrategy.
@@ -611,3 +1925,23 @@ func (in *UpdateStrategy) DeepCopy() *UpdateStrategy {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeDrainPolicy) DeepCopyInto(out *UpgradeDrainPolicy) {
+	*out = *in
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeDrainPolicy.
+func (in *UpgradeDrainPolicy) DeepCopy() *UpgradeDrainPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeDrainPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
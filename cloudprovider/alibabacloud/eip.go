@@ -9,6 +9,7 @@ import (
 	"github.com/openkruise/kruise-game/cloudprovider/utils"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -97,14 +98,18 @@ func (E EipPlugin) OnPodUpdated(client client.Client, pod *corev1.Pod, ctx conte
 		return pod, nil
 	}
 
+	// the EIP is bound to the ENI of the pod, so that the container ports are exposed as they are
+	ports := getContainerNetworkPorts(pod)
 	networkStatus.InternalAddresses = []gamekruiseiov1alpha1.NetworkAddress{
 		{
-			IP: podEip.Status.PrivateIPAddress,
+			IP:    podEip.Status.PrivateIPAddress,
+			Ports: ports,
 		},
 	}
 	networkStatus.ExternalAddresses = []gamekruiseiov1alpha1.NetworkAddress{
 		{
-			IP: podEip.Status.EipAddress,
+			IP:    podEip.Status.EipAddress,
+			Ports: ports,
 		},
 	}
 
@@ -114,6 +119,22 @@ func (E EipPlugin) OnPodUpdated(client client.Client, pod *corev1.Pod, ctx conte
 	return pod, errors.ToPluginError(err, errors.InternalError)
 }
 
+// getContainerNetworkPorts returns the ports declared by the containers of the pod.
+func getContainerNetworkPorts(pod *corev1.Pod) []gamekruiseiov1alpha1.NetworkPort {
+	var ports []gamekruiseiov1alpha1.NetworkPort
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			containerPortIs := intstr.FromInt(int(port.ContainerPort))
+			ports = append(ports, gamekruiseiov1alpha1.NetworkPort{
+				Name:     container.Name + "-" + containerPortIs.String(),
+				Port:     &containerPortIs,
+				Protocol: port.Protocol,
+			})
+		}
+	}
+	return ports
+}
+
 func (E EipPlugin) OnPodDeleted(client client.Client, pod *corev1.Pod, ctx context.Context) errors.PluginError {
 	return nil
 }
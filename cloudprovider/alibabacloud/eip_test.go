@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alibabacloud
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/cloudprovider/alibabacloud/apis/v1beta1"
+)
+
+func TestEipOnPodUpdated(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1beta1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "case-0",
+			Annotations: map[string]string{
+				gamekruiseiov1alpha1.GameServerNetworkType:   EIPNetwork,
+				gamekruiseiov1alpha1.GameServerNetworkStatus: `{"currentNetworkState":"Waiting"}`,
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "game",
+					Ports: []corev1.ContainerPort{
+						{ContainerPort: 7777, Protocol: corev1.ProtocolUDP},
+						{ContainerPort: 8080, Protocol: corev1.ProtocolTCP},
+					},
+				},
+			},
+		},
+	}
+	podEip := &v1beta1.PodEIP{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "case-0"},
+		Status: v1beta1.PodEIPStatus{
+			PrivateIPAddress: "192.168.0.10",
+			EipAddress:       "47.0.0.10",
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(podEip).Build()
+
+	pod, err := EipPlugin{}.OnPodUpdated(c, pod, context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	networkStatus := &gamekruiseiov1alpha1.NetworkStatus{}
+	if err := json.Unmarshal([]byte(pod.Annotations[gamekruiseiov1alpha1.GameServerNetworkStatus]), networkStatus); err != nil {
+		t.Fatal(err)
+	}
+	if networkStatus.CurrentNetworkState != gamekruiseiov1alpha1.NetworkReady {
+		t.Errorf("expect network state Ready, but actually got %s", networkStatus.CurrentNetworkState)
+	}
+	udpPort := intstr.FromInt(7777)
+	tcpPort := intstr.FromInt(8080)
+	expectPorts := []gamekruiseiov1alpha1.NetworkPort{
+		{Name: "game-7777", Port: &udpPort, Protocol: corev1.ProtocolUDP},
+		{Name: "game-8080", Port: &tcpPort, Protocol: corev1.ProtocolTCP},
+	}
+	expectExternal := []gamekruiseiov1alpha1.NetworkAddress{{IP: "47.0.0.10", Ports: expectPorts}}
+	if !reflect.DeepEqual(networkStatus.ExternalAddresses, expectExternal) {
+		t.Errorf("expect external addresses %v, but actually got %v", expectExternal, networkStatus.ExternalAddresses)
+	}
+	expectInternal := []gamekruiseiov1alpha1.NetworkAddress{{IP: "192.168.0.10", Ports: expectPorts}}
+	if !reflect.DeepEqual(networkStatus.InternalAddresses, expectInternal) {
+		t.Errorf("expect internal addresses %v, but actually got %v", expectInternal, networkStatus.InternalAddresses)
+	}
+}
@@ -18,15 +18,19 @@ package alibabacloud
 
 import (
 	"context"
+	"fmt"
 	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
 	"github.com/openkruise/kruise-game/cloudprovider"
 	"github.com/openkruise/kruise-game/cloudprovider/alibabacloud/apis/v1beta1"
 	"github.com/openkruise/kruise-game/cloudprovider/errors"
 	"github.com/openkruise/kruise-game/cloudprovider/utils"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
 	"strings"
 )
 
@@ -61,6 +65,10 @@ func (n NatGwPlugin) OnPodAdded(c client.Client, pod *corev1.Pod, ctx context.Co
 	networkManager := utils.NewNetworkManager(pod, c)
 	conf := networkManager.GetNetworkConfig()
 	ports, protocol, fixed := parseConfig(conf)
+	ports, err := expandPortRanges(ports)
+	if err != nil {
+		return pod, errors.NewPluginError(errors.ParameterError, err.Error())
+	}
 	pod.Annotations[DnatAnsKey] = "true"
 	pod.Annotations[PortsAnsKey] = ports
 	if protocol != "" {
@@ -133,6 +141,19 @@ func (n NatGwPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context.
 }
 
 func (n NatGwPlugin) OnPodDeleted(c client.Client, pod *corev1.Pod, ctx context.Context) errors.PluginError {
+	// the DNAT entries are kept for the pod recreated with the same name when Fixed is true
+	if pod.Annotations[FixedAnsKey] == "true" {
+		return nil
+	}
+	podDNat := &v1beta1.PodDNAT{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.GetName(),
+			Namespace: pod.GetNamespace(),
+		},
+	}
+	if err := c.Delete(ctx, podDNat); err != nil && !k8serrors.IsNotFound(err) {
+		return errors.NewPluginError(errors.ApiCallError, err.Error())
+	}
 	return nil
 }
 
@@ -156,3 +177,29 @@ func parseConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) (string, string,
 	}
 	return ports, protocol, fixed
 }
+
+// expandPortRanges expands the port ranges in ports, such as 8000-8002, into the ports separated by commas,
+// which are expected by the annotation of PodDNAT.
+func expandPortRanges(ports string) (string, error) {
+	var expanded []string
+	for _, port := range strings.Split(ports, ",") {
+		port = strings.TrimSpace(port)
+		if !strings.Contains(port, "-") {
+			expanded = append(expanded, port)
+			continue
+		}
+		bounds := strings.SplitN(port, "-", 2)
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return "", fmt.Errorf("invalid port range %s", port)
+		}
+		end, err := strconv.Atoi(bounds[1])
+		if err != nil || end < start || start < 1 || end > 65535 {
+			return "", fmt.Errorf("invalid port range %s", port)
+		}
+		for p := start; p <= end; p++ {
+			expanded = append(expanded, strconv.Itoa(p))
+		}
+	}
+	return strings.Join(expanded, ","), nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alibabacloud
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openkruise/kruise-game/cloudprovider/alibabacloud/apis/v1beta1"
+)
+
+func TestExpandPortRanges(t *testing.T) {
+	tests := []struct {
+		ports    string
+		expanded string
+		wantErr  bool
+	}{
+		{
+			ports:    "80,8080",
+			expanded: "80,8080",
+		},
+		{
+			ports:    "80, 8000-8003",
+			expanded: "80,8000,8001,8002,8003",
+		},
+		{
+			ports:   "8003-8000",
+			wantErr: true,
+		},
+		{
+			ports:   "80-x",
+			wantErr: true,
+		},
+	}
+
+	for i, test := range tests {
+		expanded, err := expandPortRanges(test.ports)
+		if (err != nil) != test.wantErr {
+			t.Errorf("case %d: expect error %v but got %v", i, test.wantErr, err)
+		}
+		if expanded != test.expanded {
+			t.Errorf("case %d: expect ports %s but got %s", i, test.expanded, expanded)
+		}
+	}
+}
+
+func TestNatGwOnPodDeleted(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1beta1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		fixed   string
+		deleted bool
+	}{
+		{
+			fixed:   "true",
+			deleted: false,
+		},
+		{
+			fixed:   "false",
+			deleted: true,
+		},
+	}
+
+	for i, test := range tests {
+		podDNat := &v1beta1.PodDNAT{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "case-0"}}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(podDNat).Build()
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "default",
+				Name:        "case-0",
+				Annotations: map[string]string{FixedAnsKey: test.fixed},
+			},
+		}
+		if err := (NatGwPlugin{}).OnPodDeleted(c, pod, context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+		err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "case-0"}, &v1beta1.PodDNAT{})
+		if deleted := errors.IsNotFound(err); deleted != test.deleted {
+			t.Errorf("case %d: expect PodDNAT deleted %v but got %v", i, test.deleted, deleted)
+		}
+	}
+}
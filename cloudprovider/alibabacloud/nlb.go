@@ -90,6 +90,49 @@ type nlbConfig struct {
 	lBHealthCheckMethod         string
 	lBHealthyThreshold          string
 	lBUnhealthyThreshold        string
+	ipFamilies                  utils.IPFamilyConf
+}
+
+// hash returns the value of SlbConfigHashKey. ipFamilies is hashed only when set, so that an upgrade
+// does not re-sync Services of unchanged network config.
+func (nc *nlbConfig) hash() string {
+	// nlbConfig shadows the package-level type so that the hash of the original fields stays the same
+	type nlbConfig struct {
+		lbIds                       []string
+		targetPorts                 []int
+		protocols                   []corev1.Protocol
+		isFixed                     bool
+		lBHealthCheckFlag           string
+		lBHealthCheckType           string
+		lBHealthCheckConnectPort    string
+		lBHealthCheckConnectTimeout string
+		lBHealthCheckInterval       string
+		lBHealthCheckUri            string
+		lBHealthCheckDomain         string
+		lBHealthCheckMethod         string
+		lBHealthyThreshold          string
+		lBUnhealthyThreshold        string
+	}
+	base := &nlbConfig{
+		lbIds:                       nc.lbIds,
+		targetPorts:                 nc.targetPorts,
+		protocols:                   nc.protocols,
+		isFixed:                     nc.isFixed,
+		lBHealthCheckFlag:           nc.lBHealthCheckFlag,
+		lBHealthCheckType:           nc.lBHealthCheckType,
+		lBHealthCheckConnectPort:    nc.lBHealthCheckConnectPort,
+		lBHealthCheckConnectTimeout: nc.lBHealthCheckConnectTimeout,
+		lBHealthCheckInterval:       nc.lBHealthCheckInterval,
+		lBHealthCheckUri:            nc.lBHealthCheckUri,
+		lBHealthCheckDomain:         nc.lBHealthCheckDomain,
+		lBHealthCheckMethod:         nc.lBHealthCheckMethod,
+		lBHealthyThreshold:          nc.lBHealthyThreshold,
+		lBUnhealthyThreshold:        nc.lBUnhealthyThreshold,
+	}
+	if nc.ipFamilies.Policy == nil && len(nc.ipFamilies.Families) == 0 {
+		return util.GetHash(base)
+	}
+	return util.GetHash([]interface{}{base, nc.ipFamilies})
 }
 
 func (n *NlbPlugin) Name() string {
@@ -103,9 +146,20 @@ func (n *NlbPlugin) Alias() string {
 func (n *NlbPlugin) Init(c client.Client, options cloudprovider.CloudProviderOptions, ctx context.Context) error {
 	n.mutex.Lock()
 	defer n.mutex.Unlock()
+	return n.init(c, options, ctx, nil)
+}
+
+// Reconfigure rebuilds the port cache with the new options. The ports allocated to the pods whose Services are not
+// created yet are kept, so that they are not allocated to other pods.
+func (n *NlbPlugin) Reconfigure(c client.Client, options cloudprovider.CloudProviderOptions, ctx context.Context) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.init(c, options, ctx, n.podAllocate)
+}
+
+func (n *NlbPlugin) init(c client.Client, options cloudprovider.CloudProviderOptions, ctx context.Context, allocated map[string]string) error {
 	slbOptions := options.(provideroptions.AlibabaCloudOptions).NLBOptions
-	n.minPort = slbOptions.MinPort
-	n.maxPort = slbOptions.MaxPort
+	minPort, maxPort := slbOptions.MinPort, slbOptions.MaxPort
 
 	svcList := &corev1.ServiceList{}
 	err := c.List(ctx, svcList)
@@ -113,7 +167,17 @@ func (n *NlbPlugin) Init(c client.Client, options cloudprovider.CloudProviderOpt
 		return err
 	}
 
-	n.cache, n.podAllocate = initLbCache(svcList.Items, n.minPort, n.maxPort)
+	cache, podAllocate := initLbCache(svcList.Items, minPort, maxPort)
+	for podKey, allocatedPorts := range allocated {
+		if _, ok := podAllocate[podKey]; !ok {
+			podAllocate[podKey] = allocatedPorts
+		}
+	}
+	if len(allocated) != 0 {
+		cache = buildLbCache(podAllocate, minPort, maxPort)
+	}
+	n.minPort, n.maxPort = minPort, maxPort
+	n.cache, n.podAllocate = cache, podAllocate
 	log.Infof("[%s] podAllocate cache complete initialization: %v", NlbNetwork, n.podAllocate)
 	return nil
 }
@@ -150,13 +214,22 @@ func (n *NlbPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context.C
 			if err != nil {
 				return pod, cperrors.ToPluginError(err, cperrors.ParameterError)
 			}
+			utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkServiceMissing, "service is being created")
+			if pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod); err != nil {
+				return pod, cperrors.NewPluginError(cperrors.InternalError, err.Error())
+			}
 			return pod, cperrors.ToPluginError(c.Create(ctx, service), cperrors.ApiCallError)
 		}
 		return pod, cperrors.NewPluginError(cperrors.ApiCallError, err.Error())
 	}
 
+	// handle the Service of the pod's name not created by the plugin
+	if stop, pod, pluginErr := utils.HandleServiceCollision(c, ctx, networkManager, pod, svc, networkStatus); stop {
+		return pod, pluginErr
+	}
+
 	// update svc
-	if util.GetHash(sc) != svc.GetAnnotations()[SlbConfigHashKey] {
+	if sc.hash() != svc.GetAnnotations()[SlbConfigHashKey] {
 		networkStatus.CurrentNetworkState = gamekruiseiov1alpha1.NetworkNotReady
 		pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
 		if err != nil {
@@ -182,8 +255,9 @@ func (n *NlbPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context.C
 	}
 
 	// network not ready
-	if svc.Status.LoadBalancer.Ingress == nil {
-		networkStatus.CurrentNetworkState = gamekruiseiov1alpha1.NetworkNotReady
+	lbIPs, lbHostname := utils.GetLoadBalancerAddresses(svc.Status.LoadBalancer.Ingress)
+	if len(lbIPs) == 0 && lbHostname == "" {
+		utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkWaitingForLB, "load balancer has not assigned the address")
 		pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
 		return pod, cperrors.ToPluginError(err, cperrors.InternalError)
 	}
@@ -204,34 +278,41 @@ func (n *NlbPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context.C
 	}
 
 	// network ready
+	if len(lbIPs) == 0 {
+		lbIPs = []string{""}
+	}
+	podIPs := utils.GetPodIPs(pod)
 	internalAddresses := make([]gamekruiseiov1alpha1.NetworkAddress, 0)
 	externalAddresses := make([]gamekruiseiov1alpha1.NetworkAddress, 0)
 	for _, port := range svc.Spec.Ports {
 		instrIPort := port.TargetPort
 		instrEPort := intstr.FromInt(int(port.Port))
-		internalAddress := gamekruiseiov1alpha1.NetworkAddress{
-			IP: pod.Status.PodIP,
-			Ports: []gamekruiseiov1alpha1.NetworkPort{
-				{
-					Name:     instrIPort.String(),
-					Port:     &instrIPort,
-					Protocol: port.Protocol,
+		for _, podIP := range podIPs {
+			internalAddresses = append(internalAddresses, gamekruiseiov1alpha1.NetworkAddress{
+				IP: podIP,
+				Ports: []gamekruiseiov1alpha1.NetworkPort{
+					{
+						Name:     instrIPort.String(),
+						Port:     &instrIPort,
+						Protocol: port.Protocol,
+					},
 				},
-			},
+			})
 		}
-		externalAddress := gamekruiseiov1alpha1.NetworkAddress{
-			EndPoint: svc.Status.LoadBalancer.Ingress[0].Hostname,
-			IP:       svc.Status.LoadBalancer.Ingress[0].IP,
-			Ports: []gamekruiseiov1alpha1.NetworkPort{
-				{
-					Name:     instrIPort.String(),
-					Port:     &instrEPort,
-					Protocol: port.Protocol,
+		// an address of each IP family for dual-stack load balancers
+		for _, lbIP := range lbIPs {
+			externalAddresses = append(externalAddresses, gamekruiseiov1alpha1.NetworkAddress{
+				IP:       lbIP,
+				EndPoint: lbHostname,
+				Ports: []gamekruiseiov1alpha1.NetworkPort{
+					{
+						Name:     instrIPort.String(),
+						Port:     &instrEPort,
+						Protocol: port.Protocol,
+					},
 				},
-			},
+			})
 		}
-		internalAddresses = append(internalAddresses, internalAddress)
-		externalAddresses = append(externalAddresses, externalAddress)
 	}
 	networkStatus.InternalAddresses = internalAddresses
 	networkStatus.ExternalAddresses = externalAddresses
@@ -240,6 +321,20 @@ func (n *NlbPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context.C
 	return pod, cperrors.ToPluginError(err, cperrors.InternalError)
 }
 
+func (n *NlbPlugin) ValidateNetworkConf(conf []gamekruiseiov1alpha1.NetworkConfParams) error {
+	nc, err := parseNlbConfig(conf)
+	if err != nil {
+		return err
+	}
+	if len(nc.lbIds) == 0 {
+		return fmt.Errorf("%s is required", NlbIdsConfigName)
+	}
+	if len(nc.targetPorts) == 0 {
+		return fmt.Errorf("%s is required", PortProtocolsConfigName)
+	}
+	return utils.ValidatePortCount(len(nc.targetPorts), n.minPort, n.maxPort)
+}
+
 func (n *NlbPlugin) OnPodDeleted(c client.Client, pod *corev1.Pod, ctx context.Context) cperrors.PluginError {
 	networkManager := utils.NewNetworkManager(pod, c)
 	networkConfig := networkManager.GetNetworkConfig()
@@ -258,6 +353,14 @@ func (n *NlbPlugin) OnPodDeleted(c client.Client, pod *corev1.Pod, ctx context.C
 		if err == nil && gss.GetDeletionTimestamp() == nil {
 			return nil
 		}
+		// identity of gss is retained, do not deAllocate.
+		retained, err := util.IsServiceRetained(pod, c, ctx)
+		if err != nil {
+			return cperrors.ToPluginError(err, cperrors.ApiCallError)
+		}
+		if retained {
+			return nil
+		}
 		// gss not exists in cluster, deAllocate all the ports related to it.
 		for key := range n.podAllocate {
 			gssName := pod.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]
@@ -314,7 +417,7 @@ func (n *NlbPlugin) consSvc(nc *nlbConfig, pod *corev1.Pod, c client.Client, ctx
 	svcAnnotations := map[string]string{
 		SlbListenerOverrideKey:         "true",
 		SlbIdAnnotationKey:             lbId,
-		SlbConfigHashKey:               util.GetHash(nc),
+		SlbConfigHashKey:               nc.hash(),
 		LBHealthCheckFlagAnnotationKey: nc.lBHealthCheckFlag,
 	}
 	if nc.lBHealthCheckFlag == "on" {
@@ -335,6 +438,7 @@ func (n *NlbPlugin) consSvc(nc *nlbConfig, pod *corev1.Pod, c client.Client, ctx
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            pod.GetName(),
 			Namespace:       pod.GetNamespace(),
+			Labels:          utils.ServiceLabels(NlbNetwork, nil),
 			Annotations:     svcAnnotations,
 			OwnerReferences: getSvcOwnerReference(c, ctx, pod, nc.isFixed),
 		},
@@ -348,9 +452,22 @@ func (n *NlbPlugin) consSvc(nc *nlbConfig, pod *corev1.Pod, c client.Client, ctx
 			LoadBalancerClass: &loadBalancerClass,
 		},
 	}
+	nc.ipFamilies.ApplyTo(&svc.Spec)
 	return svc, nil
 }
 
+// Capacity returns the number of pods which can still be allocated ports from the nlbs of the network conf.
+func (n *NlbPlugin) Capacity(c client.Client, conf []gamekruiseiov1alpha1.NetworkConfParams, ctx context.Context) (int, error) {
+	sc, err := parseNlbConfig(conf)
+	if err != nil {
+		return 0, err
+	}
+
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	return lbCapacity(n.cache, nil, sc.lbIds, len(sc.targetPorts), n.minPort, n.maxPort), nil
+}
+
 func (n *NlbPlugin) allocate(lbIds []string, num int, nsName string) (string, []int32) {
 	n.mutex.Lock()
 	defer n.mutex.Unlock()
@@ -444,23 +561,15 @@ func parseNlbConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) (*nlbConfig,
 				}
 			}
 		case PortProtocolsConfigName:
-			for _, pp := range strings.Split(c.Value, ",") {
-				ppSlice := strings.Split(pp, "/")
-				port, err := strconv.Atoi(ppSlice[0])
-				if err != nil {
-					continue
-				}
-				ports = append(ports, port)
-				if len(ppSlice) != 2 {
-					protocols = append(protocols, corev1.ProtocolTCP)
-				} else {
-					protocols = append(protocols, corev1.Protocol(ppSlice[1]))
-				}
+			var err error
+			ports, protocols, err = utils.ParsePortProtocols(c.Value)
+			if err != nil {
+				return nil, err
 			}
 		case FixedConfigName:
 			v, err := strconv.ParseBool(c.Value)
 			if err != nil {
-				continue
+				return nil, fmt.Errorf("invalid %s: %s", FixedConfigName, c.Value)
 			}
 			isFixed = v
 		case LBHealthCheckFlagConfigName:
@@ -538,6 +647,10 @@ func parseNlbConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) (*nlbConfig,
 			lBHealthCheckMethod = method
 		}
 	}
+	ipFamilies, err := utils.ParseIPFamilyConf(conf)
+	if err != nil {
+		return nil, err
+	}
 	return &nlbConfig{
 		lbIds:                       lbIds,
 		protocols:                   protocols,
@@ -553,6 +666,7 @@ func parseNlbConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) (*nlbConfig,
 		lBHealthCheckMethod:         lBHealthCheckMethod,
 		lBHealthyThreshold:          lBHealthyThreshold,
 		lBUnhealthyThreshold:        lBUnhealthyThreshold,
+		ipFamilies:                  ipFamilies,
 	}, nil
 }
 
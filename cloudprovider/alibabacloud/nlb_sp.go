@@ -2,6 +2,7 @@ package alibabacloud
 
 import (
 	"context"
+	"fmt"
 	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
 	"github.com/openkruise/kruise-game/cloudprovider"
 	cperrors "github.com/openkruise/kruise-game/cloudprovider/errors"
@@ -42,13 +43,16 @@ func (N *NlbSpPlugin) Init(client client.Client, options cloudprovider.CloudProv
 
 func (N *NlbSpPlugin) OnPodAdded(c client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, cperrors.PluginError) {
 	networkManager := utils.NewNetworkManager(pod, c)
-	podNetConfig := parseNLbSpConfig(networkManager.GetNetworkConfig())
+	podNetConfig, err := parseNLbSpConfig(networkManager.GetNetworkConfig())
+	if err != nil {
+		return pod, cperrors.NewPluginError(cperrors.ParameterError, err.Error())
+	}
 
 	pod.Labels[SlbIdLabelKey] = podNetConfig.lbId
 
 	// Get Svc
 	svc := &corev1.Service{}
-	err := c.Get(ctx, types.NamespacedName{
+	err = c.Get(ctx, types.NamespacedName{
 		Namespace: pod.GetNamespace(),
 		Name:      podNetConfig.lbId,
 	}, svc)
@@ -73,11 +77,14 @@ func (N *NlbSpPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context
 	}
 
 	networkConfig := networkManager.GetNetworkConfig()
-	podNetConfig := parseNLbSpConfig(networkConfig)
+	podNetConfig, err := parseNLbSpConfig(networkConfig)
+	if err != nil {
+		return pod, cperrors.NewPluginError(cperrors.ParameterError, err.Error())
+	}
 
 	// Get Svc
 	svc := &corev1.Service{}
-	err := c.Get(context.Background(), types.NamespacedName{
+	err = c.Get(context.Background(), types.NamespacedName{
 		Namespace: pod.GetNamespace(),
 		Name:      podNetConfig.lbId,
 	}, svc)
@@ -119,7 +126,8 @@ func (N *NlbSpPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context
 	}
 
 	// network not ready
-	if svc.Status.LoadBalancer.Ingress == nil {
+	lbIP, lbHostname := utils.GetLoadBalancerAddress(svc.Status.LoadBalancer.Ingress)
+	if lbIP == "" && lbHostname == "" {
 		return pod, nil
 	}
 
@@ -155,7 +163,8 @@ func (N *NlbSpPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context
 			},
 		}
 		externalAddress := gamekruiseiov1alpha1.NetworkAddress{
-			EndPoint: svc.Status.LoadBalancer.Ingress[0].Hostname,
+			IP:       lbIP,
+			EndPoint: lbHostname,
 			Ports: []gamekruiseiov1alpha1.NetworkPort{
 				{
 					Name:     instrIPort.String(),
@@ -174,6 +183,20 @@ func (N *NlbSpPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context
 	return pod, cperrors.ToPluginError(err, cperrors.InternalError)
 }
 
+func (N *NlbSpPlugin) ValidateNetworkConf(conf []gamekruiseiov1alpha1.NetworkConfParams) error {
+	nc, err := parseNLbSpConfig(conf)
+	if err != nil {
+		return err
+	}
+	if nc.lbId == "" {
+		return fmt.Errorf("%s is required", NlbIdsConfigName)
+	}
+	if len(nc.ports) == 0 {
+		return fmt.Errorf("%s is required", PortProtocolsConfigName)
+	}
+	return nil
+}
+
 func (N *NlbSpPlugin) OnPodDeleted(client client.Client, pod *corev1.Pod, ctx context.Context) cperrors.PluginError {
 	return nil
 }
@@ -184,7 +207,7 @@ type nlbSpConfig struct {
 	protocols []corev1.Protocol
 }
 
-func parseNLbSpConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) *nlbSpConfig {
+func parseNLbSpConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) (*nlbSpConfig, error) {
 	var lbIds string
 	var ports []int
 	var protocols []corev1.Protocol
@@ -193,14 +216,18 @@ func parseNLbSpConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) *nlbSpConfi
 		case NlbIdsConfigName:
 			lbIds = c.Value
 		case PortProtocolsConfigName:
-			ports, protocols = parsePortProtocols(c.Value)
+			var err error
+			ports, protocols, err = utils.ParsePortProtocols(c.Value)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 	return &nlbSpConfig{
 		lbId:      lbIds,
 		ports:     ports,
 		protocols: protocols,
-	}
+	}, nil
 }
 
 func consNlbSvc(nc *nlbSpConfig, pod *corev1.Pod, c client.Client, ctx context.Context) *corev1.Service {
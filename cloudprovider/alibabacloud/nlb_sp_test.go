@@ -50,7 +50,7 @@ func TestParseNLbSpConfig(t *testing.T) {
 
 	for i, test := range tests {
 		expect := test.nc
-		actual := parseNLbSpConfig(test.conf)
+		actual, _ := parseNLbSpConfig(test.conf)
 		if !reflect.DeepEqual(expect, actual) {
 			t.Errorf("case %d: expect nlbSpConfig is %v, but actually is %v", i, expect, actual)
 		}
@@ -3,7 +3,6 @@ package alibabacloud
 import (
 	"context"
 	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
-	"github.com/openkruise/kruise-game/pkg/util"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -243,10 +242,11 @@ func TestNlbPlugin_consSvc(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "test-pod",
 					Namespace: "default",
+					Labels:    map[string]string{gamekruiseiov1alpha1.GameServerNetworkType: NlbNetwork},
 					Annotations: map[string]string{
 						SlbListenerOverrideKey: "true",
 						SlbIdAnnotationKey:     "clb-xxx",
-						SlbConfigHashKey: util.GetHash(&nlbConfig{
+						SlbConfigHashKey: (&nlbConfig{
 							lbIds:       []string{"clb-xxx"},
 							targetPorts: []int{82},
 							protocols: []corev1.Protocol{
@@ -263,7 +263,7 @@ func TestNlbPlugin_consSvc(t *testing.T) {
 							lBHealthCheckUri:            "",
 							lBHealthCheckDomain:         "",
 							lBHealthCheckMethod:         "",
-						}),
+						}).hash(),
 						LBHealthCheckFlagAnnotationKey:           "on",
 						LBHealthCheckTypeAnnotationKey:           "tcp",
 						LBHealthCheckConnectPortAnnotationKey:    "0",
@@ -320,3 +320,17 @@ func TestNlbPlugin_consSvc(t *testing.T) {
 		}
 	}
 }
+
+func TestNlbConfigHash(t *testing.T) {
+	// the hash of the network config existing before upgrading
+	nc, err := parseNlbConfig([]gamekruiseiov1alpha1.NetworkConfParams{
+		{Name: NlbIdsConfigName, Value: "xxx-A"},
+		{Name: PortProtocolsConfigName, Value: "80"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nc.hash() != "2846656187" {
+		t.Errorf("expect hash 2846656187, but actually got %s", nc.hash())
+	}
+}
@@ -19,9 +19,14 @@ package alibabacloud
 import (
 	"context"
 	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -44,24 +49,45 @@ const (
 	SlbNetwork              = "AlibabaCloud-SLB"
 	AliasSLB                = "LB-Network"
 	SlbIdsConfigName        = "SlbIds"
+	ExternalLbsConfigName   = "ExternalLoadBalancers"
 	PortProtocolsConfigName = "PortProtocols"
 	SlbListenerOverrideKey  = "service.beta.kubernetes.io/alibaba-cloud-loadbalancer-force-override-listeners"
 	SlbIdAnnotationKey      = "service.beta.kubernetes.io/alibaba-cloud-loadbalancer-id"
 	SlbIdLabelKey           = "service.k8s.alibaba/loadbalancer-id"
 	SvcSelectorKey          = "statefulset.kubernetes.io/pod-name"
 	SlbConfigHashKey        = "game.kruise.io/network-config-hash"
+	SlbDrainStartTimeKey    = "game.kruise.io/network-drain-start-time"
 )
 
 const (
 	// annotations provided by AlibabaCloud Cloud Controller Manager
 	LBHealthCheckSwitchAnnotationKey       = "service.beta.kubernetes.io/alibaba-cloud-loadbalancer-health-check-switch"
 	LBHealthCheckProtocolPortAnnotationKey = "service.beta.kubernetes.io/alibaba-cloud-loadbalancer-protocol-port"
+	LBBackendWeightAnnotationKey           = "service.beta.kubernetes.io/alibaba-cloud-loadbalancer-weight"
 
 	// ConfigNames defined by OKG
 	LBHealthCheckSwitchConfigName       = "LBHealthCheckSwitch"
 	LBHealthCheckProtocolPortConfigName = "LBHealthCheckProtocolPort"
+	LBDrainModeConfigName               = "LBDrainMode"
+	LBDrainTimeoutConfigName            = "LBDrainTimeout"
+	LBDrainSessionsFieldConfigName      = "LBDrainSessionsField"
 )
 
+const (
+	// LBDrainModeImmediate switches the Service to ClusterIP as soon as the network is disabled.
+	LBDrainModeImmediate = "immediate"
+	// LBDrainModeGraceful sets the backend weight to 0 first, so that no new connections will be
+	// forwarded while existing flows are kept, and switches the Service to ClusterIP after LBDrainTimeout,
+	// or once the pod reports no active sessions.
+	LBDrainModeGraceful = "graceful"
+	// MaxLBDrainTimeout is the upper bound, in seconds, of LBDrainTimeout.
+	MaxLBDrainTimeout = 3600
+)
+
+// slbProtocols are the protocols of PortProtocols supported by SLB, in which TCPUDP results in a TCP and a UDP
+// listener sharing the external port.
+var slbProtocols = []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolUDP, corev1.ProtocolSCTP, utils.ProtocolTCPUDP}
+
 type portAllocated map[int32]bool
 
 type SlbPlugin struct {
@@ -70,13 +96,27 @@ type SlbPlugin struct {
 	cache       map[string]portAllocated
 	podAllocate map[string]string
 	mutex       sync.RWMutex
+	// store persists the allocations if the state ConfigMap is configured
+	store *slbStateStore
+	// stateMutex serializes the read-modify-write of the state ConfigMap
+	stateMutex sync.Mutex
+	// portBudgets limits the number of ports allocated from the lbs of ExternalLoadBalancers, keyed by lb id
+	portBudgets map[string]int32
+	// notFoundSince records when the sweeper first found the pods of the allocations not exist, keyed by pod
+	notFoundSince map[string]time.Time
 }
 
 type slbConfig struct {
-	lbIds       []string
+	lbIds []string
+	// externalLbs maps the lb id to the name of ExternalLoadBalancer it comes from
+	externalLbs map[string]string
+	// portBudgets maps the lb id to the PortBudget of ExternalLoadBalancer it comes from, which is not hashed
+	portBudgets map[string]int32
 	targetPorts []int
 	protocols   []corev1.Protocol
 	isFixed     bool
+	// blueGreenListeners pre-provisions the listeners of the new network config during a rollout
+	blueGreenListeners bool
 
 	lBHealthCheckSwitch         string
 	lBHealthCheckProtocolPort   string
@@ -89,6 +129,86 @@ type slbConfig struct {
 	lBHealthCheckMethod         string
 	lBHealthyThreshold          string
 	lBUnhealthyThreshold        string
+
+	lBDrainMode    string
+	lBDrainTimeout int
+	// lBDrainSessionsField is the custom status field of the number of active sessions reported by the SDK
+	lBDrainSessionsField string
+
+	portAllocationPolicy string
+
+	ipFamilies utils.IPFamilyConf
+}
+
+// hash returns the value of SlbConfigHashKey. The fields added to slbConfig later are hashed only
+// when they are set to non-default values, so that an upgrade does not re-sync Services of
+// unchanged network config.
+func (sc *slbConfig) hash() string {
+	// slbConfig shadows the package-level type so that the hash of the original fields stays the same
+	type slbConfig struct {
+		lbIds       []string
+		targetPorts []int
+		protocols   []corev1.Protocol
+		isFixed     bool
+
+		lBHealthCheckSwitch         string
+		lBHealthCheckProtocolPort   string
+		lBHealthCheckFlag           string
+		lBHealthCheckType           string
+		lBHealthCheckConnectTimeout string
+		lBHealthCheckInterval       string
+		lBHealthCheckUri            string
+		lBHealthCheckDomain         string
+		lBHealthCheckMethod         string
+		lBHealthyThreshold          string
+		lBUnhealthyThreshold        string
+	}
+	type slbConfigExtension struct {
+		externalLbs          map[string]string
+		blueGreenListeners   bool
+		lBDrainMode          string
+		lBDrainTimeout       int
+		lBDrainSessionsField string
+		portAllocationPolicy string
+		ipFamilies           utils.IPFamilyConf
+	}
+	base := &slbConfig{
+		lbIds:                       sc.lbIds,
+		targetPorts:                 sc.targetPorts,
+		protocols:                   sc.protocols,
+		isFixed:                     sc.isFixed,
+		lBHealthCheckSwitch:         sc.lBHealthCheckSwitch,
+		lBHealthCheckProtocolPort:   sc.lBHealthCheckProtocolPort,
+		lBHealthCheckFlag:           sc.lBHealthCheckFlag,
+		lBHealthCheckType:           sc.lBHealthCheckType,
+		lBHealthCheckConnectTimeout: sc.lBHealthCheckConnectTimeout,
+		lBHealthCheckInterval:       sc.lBHealthCheckInterval,
+		lBHealthCheckUri:            sc.lBHealthCheckUri,
+		lBHealthCheckDomain:         sc.lBHealthCheckDomain,
+		lBHealthCheckMethod:         sc.lBHealthCheckMethod,
+		lBHealthyThreshold:          sc.lBHealthyThreshold,
+		lBUnhealthyThreshold:        sc.lBUnhealthyThreshold,
+	}
+	ext := slbConfigExtension{
+		blueGreenListeners:   sc.blueGreenListeners,
+		lBDrainMode:          sc.lBDrainMode,
+		lBDrainTimeout:       sc.lBDrainTimeout,
+		lBDrainSessionsField: sc.lBDrainSessionsField,
+		portAllocationPolicy: sc.portAllocationPolicy,
+		ipFamilies:           sc.ipFamilies,
+	}
+	if len(sc.externalLbs) != 0 {
+		ext.externalLbs = sc.externalLbs
+	}
+	defaultExt := slbConfigExtension{
+		lBDrainMode:          LBDrainModeImmediate,
+		lBDrainTimeout:       30,
+		portAllocationPolicy: PortAllocationPolicyRandom,
+	}
+	if reflect.DeepEqual(ext, defaultExt) {
+		return util.GetHash(base)
+	}
+	return util.GetHash([]interface{}{base, ext})
 }
 
 func (s *SlbPlugin) Name() string {
@@ -102,17 +222,56 @@ func (s *SlbPlugin) Alias() string {
 func (s *SlbPlugin) Init(c client.Client, options cloudprovider.CloudProviderOptions, ctx context.Context) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
+	return s.init(c, options, ctx, nil)
+}
+
+// Reconfigure rebuilds the port cache with the new options. The ports allocated to the pods whose Services are not
+// created yet are kept, so that they are not allocated to other pods.
+func (s *SlbPlugin) Reconfigure(c client.Client, options cloudprovider.CloudProviderOptions, ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.init(c, options, ctx, s.podAllocate)
+}
+
+// init builds the port cache from the Services and the allocations not backed by Services yet. The state of the
+// plugin is left unchanged if it fails.
+func (s *SlbPlugin) init(c client.Client, options cloudprovider.CloudProviderOptions, ctx context.Context, allocated map[string]string) error {
 	slbOptions := options.(provideroptions.AlibabaCloudOptions).SLBOptions
-	s.minPort = slbOptions.MinPort
-	s.maxPort = slbOptions.MaxPort
+	minPort, maxPort := slbOptions.MinPort, slbOptions.MaxPort
+	store, err := newSlbStateStore(slbOptions.StateConfigMap)
+	if err != nil {
+		return err
+	}
 
 	svcList := &corev1.ServiceList{}
-	err := c.List(ctx, svcList)
+	err = c.List(ctx, svcList)
 	if err != nil {
 		return err
 	}
 
-	s.cache, s.podAllocate = initLbCache(svcList.Items, s.minPort, s.maxPort)
+	// free the ports of the Services whose pods no longer exist
+	svcs, orphans, err := gcOrphanServices(c, ctx, svcList.Items)
+	if err != nil {
+		return err
+	}
+
+	cache, podAllocate := initLbCache(svcs, minPort, maxPort)
+	if store != nil {
+		podAllocate, err = store.init(c, ctx, podAllocate, orphans)
+		if err != nil {
+			return err
+		}
+	}
+	for podKey, allocatedPorts := range allocated {
+		if _, ok := podAllocate[podKey]; !ok {
+			podAllocate[podKey] = allocatedPorts
+		}
+	}
+	if store != nil || len(allocated) != 0 {
+		cache = buildLbCache(podAllocate, minPort, maxPort)
+	}
+	s.minPort, s.maxPort, s.store = minPort, maxPort, store
+	s.cache, s.podAllocate = cache, podAllocate
 	log.Infof("[%s] podAllocate cache complete initialization: %v", SlbNetwork, s.podAllocate)
 	return nil
 }
@@ -130,7 +289,7 @@ func initLbCache(svcList []corev1.Service, minPort, maxPort int32) (map[string]p
 				}
 			}
 			var ports []int32
-			for _, port := range getPorts(svc.Spec.Ports) {
+			for _, port := range utils.GetExternalPorts(svc.Spec.Ports) {
 				if port <= maxPort && port >= minPort {
 					newCache[lbId][port] = true
 					ports = append(ports, port)
@@ -163,6 +322,10 @@ func (s *SlbPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context.C
 	if err != nil {
 		return pod, cperrors.ToPluginError(err, cperrors.ParameterError)
 	}
+	if err := resolveExternalLbs(c, ctx, sc); err != nil {
+		return pod, cperrors.ToPluginError(err, cperrors.ApiCallError)
+	}
+	s.setPortBudgets(sc)
 
 	// get svc
 	svc := &corev1.Service{}
@@ -174,30 +337,75 @@ func (s *SlbPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context.C
 		if errors.IsNotFound(err) {
 			service, err := s.consSvc(sc, pod, c, ctx)
 			if err != nil {
+				if utils.IsPortExhausted(err) {
+					utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkPortExhausted, err.Error())
+					pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
+					return pod, cperrors.ToPluginError(err, cperrors.InternalError)
+				}
 				return pod, cperrors.ToPluginError(err, cperrors.ParameterError)
 			}
+			utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkServiceMissing, "service is being created")
+			if pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod); err != nil {
+				return pod, cperrors.NewPluginError(cperrors.InternalError, err.Error())
+			}
 			return pod, cperrors.ToPluginError(c.Create(ctx, service), cperrors.ApiCallError)
 		}
 		return pod, cperrors.NewPluginError(cperrors.ApiCallError, err.Error())
 	}
 
+	// handle the Service of the pod's name not created by the plugin
+	if stop, pod, pluginErr := utils.HandleServiceCollision(c, ctx, networkManager, pod, svc, networkStatus); stop {
+		return pod, pluginErr
+	}
+
 	// update svc
-	if util.GetHash(sc) != svc.GetAnnotations()[SlbConfigHashKey] {
+	if sc.hash() != svc.GetAnnotations()[SlbConfigHashKey] {
 		networkStatus.CurrentNetworkState = gamekruiseiov1alpha1.NetworkNotReady
 		pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
 		if err != nil {
 			return pod, cperrors.NewPluginError(cperrors.InternalError, err.Error())
 		}
+		// switch to the listeners pre-provisioned, which replace the serving ones in a single update
+		if greenPorts := svc.GetAnnotations()[SlbGreenPortsKey]; greenPorts != "" && svc.GetAnnotations()[SlbGreenConfigHashKey] == sc.hash() {
+			if err := s.promoteGreenPorts(c, ctx, pod.GetNamespace()+"/"+pod.GetName(), greenPorts); err != nil {
+				return pod, cperrors.ToPluginError(err, cperrors.ApiCallError)
+			}
+		}
 		service, err := s.consSvc(sc, pod, c, ctx)
 		if err != nil {
-			return pod, cperrors.NewPluginError(cperrors.ParameterError, err.Error())
+			return pod, cperrors.ToPluginError(err, cperrors.ParameterError)
 		}
 		return pod, cperrors.ToPluginError(c.Update(ctx, service), cperrors.ApiCallError)
 	}
 
+	// pre-provision the listeners of the new network config during a rollout
+	if sc.isFixed && sc.blueGreenListeners {
+		updated, err := s.syncGreenListeners(c, ctx, pod, svc)
+		if err != nil {
+			return pod, cperrors.ToPluginError(err, cperrors.ApiCallError)
+		}
+		if updated {
+			return pod, nil
+		}
+	}
+
 	// disable network
 	if networkManager.GetNetworkDisabled() && svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		if sc.lBDrainMode == LBDrainModeGraceful && !isDrainCompleted(svc, pod, sc.lBDrainTimeout, sc.lBDrainSessionsField) {
+			// the deadline on the pod keeps the network triggered until the draining is completed
+			if startTime := svc.GetAnnotations()[SlbDrainStartTimeKey]; startTime != "" {
+				// draining, wait for timeout
+				return setDrainUntil(pod, startTime, sc.lBDrainTimeout), nil
+			}
+			startTime := time.Now().Format(time.RFC3339)
+			svc.Annotations[LBBackendWeightAnnotationKey] = "0"
+			svc.Annotations[SlbDrainStartTimeKey] = startTime
+			return setDrainUntil(pod, startTime, sc.lBDrainTimeout), cperrors.ToPluginError(c.Update(ctx, svc), cperrors.ApiCallError)
+		}
 		svc.Spec.Type = corev1.ServiceTypeClusterIP
+		delete(svc.Annotations, LBBackendWeightAnnotationKey)
+		delete(svc.Annotations, SlbDrainStartTimeKey)
+		delete(pod.Annotations, gamekruiseiov1alpha1.GameServerNetworkDrainUntil)
 		return pod, cperrors.ToPluginError(c.Update(ctx, svc), cperrors.ApiCallError)
 	}
 
@@ -207,9 +415,18 @@ func (s *SlbPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context.C
 		return pod, cperrors.ToPluginError(c.Update(ctx, svc), cperrors.ApiCallError)
 	}
 
+	// network enabled again before draining completed
+	if !networkManager.GetNetworkDisabled() && svc.GetAnnotations()[SlbDrainStartTimeKey] != "" {
+		delete(svc.Annotations, LBBackendWeightAnnotationKey)
+		delete(svc.Annotations, SlbDrainStartTimeKey)
+		delete(pod.Annotations, gamekruiseiov1alpha1.GameServerNetworkDrainUntil)
+		return pod, cperrors.ToPluginError(c.Update(ctx, svc), cperrors.ApiCallError)
+	}
+
 	// network not ready
-	if svc.Status.LoadBalancer.Ingress == nil {
-		networkStatus.CurrentNetworkState = gamekruiseiov1alpha1.NetworkNotReady
+	lbIPs, lbHostname := utils.GetLoadBalancerAddresses(svc.Status.LoadBalancer.Ingress)
+	if len(lbIPs) == 0 && lbHostname == "" {
+		utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkWaitingForLB, "load balancer has not assigned the address")
 		pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
 		return pod, cperrors.ToPluginError(err, cperrors.InternalError)
 	}
@@ -230,33 +447,45 @@ func (s *SlbPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context.C
 	}
 
 	// network ready
+	if len(lbIPs) == 0 {
+		lbIPs = []string{""}
+	}
+	podIPs := utils.GetPodIPs(pod)
 	internalAddresses := make([]gamekruiseiov1alpha1.NetworkAddress, 0)
 	externalAddresses := make([]gamekruiseiov1alpha1.NetworkAddress, 0)
 	for _, port := range svc.Spec.Ports {
+		if isGreenServicePort(port) {
+			continue
+		}
 		instrIPort := port.TargetPort
 		instrEPort := intstr.FromInt(int(port.Port))
-		internalAddress := gamekruiseiov1alpha1.NetworkAddress{
-			IP: pod.Status.PodIP,
-			Ports: []gamekruiseiov1alpha1.NetworkPort{
-				{
-					Name:     instrIPort.String(),
-					Port:     &instrIPort,
-					Protocol: port.Protocol,
+		for _, podIP := range podIPs {
+			internalAddresses = append(internalAddresses, gamekruiseiov1alpha1.NetworkAddress{
+				IP: podIP,
+				Ports: []gamekruiseiov1alpha1.NetworkPort{
+					{
+						Name:     instrIPort.String(),
+						Port:     &instrIPort,
+						Protocol: port.Protocol,
+					},
 				},
-			},
+			})
 		}
-		externalAddress := gamekruiseiov1alpha1.NetworkAddress{
-			IP: svc.Status.LoadBalancer.Ingress[0].IP,
-			Ports: []gamekruiseiov1alpha1.NetworkPort{
-				{
-					Name:     instrIPort.String(),
-					Port:     &instrEPort,
-					Protocol: port.Protocol,
+		// an address of each IP family for dual-stack load balancers
+		for _, lbIP := range lbIPs {
+			externalAddresses = append(externalAddresses, gamekruiseiov1alpha1.NetworkAddress{
+				IP:       lbIP,
+				EndPoint: lbHostname,
+				Ports: []gamekruiseiov1alpha1.NetworkPort{
+					{
+						Name:     instrIPort.String(),
+						Port:     &instrEPort,
+						Protocol: port.Protocol,
+					},
 				},
-			},
+				LoadBalancerId: svc.GetAnnotations()[SlbIdAnnotationKey],
+			})
 		}
-		internalAddresses = append(internalAddresses, internalAddress)
-		externalAddresses = append(externalAddresses, externalAddress)
 	}
 	networkStatus.InternalAddresses = internalAddresses
 	networkStatus.ExternalAddresses = externalAddresses
@@ -265,12 +494,29 @@ func (s *SlbPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context.C
 	return pod, cperrors.ToPluginError(err, cperrors.InternalError)
 }
 
+func (s *SlbPlugin) ValidateNetworkConf(conf []gamekruiseiov1alpha1.NetworkConfParams) error {
+	sc, err := parseLbConfig(conf)
+	if err != nil {
+		return err
+	}
+	if len(sc.lbIds) == 0 && len(sc.externalLbs) == 0 {
+		return fmt.Errorf("%s or %s is required", SlbIdsConfigName, ExternalLbsConfigName)
+	}
+	if len(sc.targetPorts) == 0 {
+		return fmt.Errorf("%s is required", PortProtocolsConfigName)
+	}
+	if sc.blueGreenListeners && !sc.isFixed {
+		return fmt.Errorf("%s requires %s to be true", BlueGreenListenersConfigName, FixedConfigName)
+	}
+	return utils.ValidatePortCount(len(sc.targetPorts), s.minPort, s.maxPort)
+}
+
 func (s *SlbPlugin) OnPodDeleted(c client.Client, pod *corev1.Pod, ctx context.Context) cperrors.PluginError {
 	networkManager := utils.NewNetworkManager(pod, c)
 	networkConfig := networkManager.GetNetworkConfig()
 	sc, err := parseLbConfig(networkConfig)
 	if err != nil {
-		return cperrors.NewPluginError(cperrors.ParameterError, err.Error())
+		return cperrors.ToPluginError(err, cperrors.ParameterError)
 	}
 
 	var podKeys []string
@@ -283,6 +529,14 @@ func (s *SlbPlugin) OnPodDeleted(c client.Client, pod *corev1.Pod, ctx context.C
 		if err == nil && gss.GetDeletionTimestamp() == nil {
 			return nil
 		}
+		// identity of gss is retained, do not deAllocate.
+		retained, err := util.IsServiceRetained(pod, c, ctx)
+		if err != nil {
+			return cperrors.ToPluginError(err, cperrors.ApiCallError)
+		}
+		if retained {
+			return nil
+		}
 		// gss not exists in cluster, deAllocate all the ports related to it.
 		for key := range s.podAllocate {
 			gssName := pod.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]
@@ -294,60 +548,86 @@ func (s *SlbPlugin) OnPodDeleted(c client.Client, pod *corev1.Pod, ctx context.C
 		podKeys = append(podKeys, pod.GetNamespace()+"/"+pod.GetName())
 	}
 
-	for _, podKey := range podKeys {
-		s.deAllocate(podKey)
-	}
-
-	return nil
+	return cperrors.ToPluginError(s.deAllocatePorts(c, ctx, podKeys), cperrors.ApiCallError)
 }
 
-func (s *SlbPlugin) allocate(lbIds []string, num int, nsName string) (string, []int32) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+// Capacity returns the number of pods which can still be allocated ports from the slbs of the network conf.
+func (s *SlbPlugin) Capacity(c client.Client, conf []gamekruiseiov1alpha1.NetworkConfParams, ctx context.Context) (int, error) {
+	sc, err := parseLbConfig(conf)
+	if err != nil {
+		return 0, err
+	}
+	if err := resolveExternalLbs(c, ctx, sc); err != nil {
+		return 0, err
+	}
+	s.setPortBudgets(sc)
 
-	var ports []int32
-	var lbId string
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return lbCapacity(s.cache, s.portBudgets, sc.lbIds, len(sc.targetPorts), s.minPort, s.maxPort), nil
+}
 
-	// find lb with adequate ports
-	for _, slbId := range lbIds {
-		sum := 0
-		for i := s.minPort; i < s.maxPort; i++ {
-			if !s.cache[slbId][i] {
-				sum++
-			}
-			if sum >= num {
-				lbId = slbId
-				break
+// lbCapacity returns the number of pods which can still be allocated num ports from one of the lbs,
+// within the port budgets of the lbs.
+func lbCapacity(cache map[string]portAllocated, budgets map[string]int32, lbIds []string, num int, minPort, maxPort int32) int {
+	if num == 0 {
+		return math.MaxInt32
+	}
+	capacity := 0
+	for _, lbId := range lbIds {
+		free := 0
+		for i := minPort; i < maxPort; i++ {
+			if !cache[lbId][i] {
+				free++
 			}
 		}
+		if remaining := remainingBudget(budgets, cache[lbId], lbId); remaining >= 0 && remaining < free {
+			free = remaining
+		}
+		capacity += free / num
 	}
-	if lbId == "" {
-		return "", nil
+	return capacity
+}
+
+func (s *SlbPlugin) allocate(lbIds []string, num int, nsName string, policy string) (string, []int32, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.allocateLocked(lbIds, num, nsName, policy)
+}
+
+func (s *SlbPlugin) allocateLocked(lbIds []string, num int, nsName string, policy string) (string, []int32, error) {
+	allocator, ok := portAllocators[policy]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown port allocation policy %s", policy)
 	}
+	podName := nsName[strings.LastIndex(nsName, "/")+1:]
 
-	// select ports
-	for i := 0; i < num; i++ {
-		var port int32
+	// select ports from the first lb with adequate ports, spilling over to the next one when it is exhausted
+	var reasons []string
+	for _, lbId := range lbIds {
+		if remaining := remainingBudget(s.portBudgets, s.cache[lbId], lbId); remaining >= 0 && remaining < num {
+			reasons = append(reasons, fmt.Sprintf("%s: port budget %d is exhausted", lbId, s.portBudgets[lbId]))
+			continue
+		}
+		ports, err := allocator.selectPorts(s.cache[lbId], num, podName, s.minPort, s.maxPort)
+		if err != nil {
+			reasons = append(reasons, lbId+": "+err.Error())
+			continue
+		}
 		if s.cache[lbId] == nil {
 			s.cache[lbId] = make(portAllocated, s.maxPort-s.minPort)
 			for i := s.minPort; i < s.maxPort; i++ {
 				s.cache[lbId][i] = false
 			}
 		}
-
-		for p, allocated := range s.cache[lbId] {
-			if !allocated {
-				port = p
-				break
-			}
+		for _, port := range ports {
+			s.cache[lbId][port] = true
 		}
-		s.cache[lbId][port] = true
-		ports = append(ports, port)
+		s.podAllocate[nsName] = lbId + ":" + util.Int32SliceToString(ports, ",")
+		log.Infof("pod %s allocate slb %s ports %v", nsName, lbId, ports)
+		return lbId, ports, nil
 	}
-
-	s.podAllocate[nsName] = lbId + ":" + util.Int32SliceToString(ports, ",")
-	log.Infof("pod %s allocate slb %s ports %v", nsName, lbId, ports)
-	return lbId, ports
+	return "", nil, utils.NewPortExhaustedError("there are no available ports for %v: %s", lbIds, strings.Join(reasons, "; "))
 }
 
 func (s *SlbPlugin) deAllocate(nsName string) {
@@ -379,9 +659,11 @@ func init() {
 
 func parseLbConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) (*slbConfig, error) {
 	var lbIds []string
+	externalLbs := make(map[string]string)
 	ports := make([]int, 0)
 	protocols := make([]corev1.Protocol, 0)
 	isFixed := false
+	blueGreenListeners := false
 
 	lBHealthCheckSwitch := "on"
 	lBHealthCheckProtocolPort := ""
@@ -394,6 +676,10 @@ func parseLbConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) (*slbConfig, e
 	lBHealthCheckUri := ""
 	lBHealthCheckDomain := ""
 	lBHealthCheckMethod := ""
+	lBDrainMode := LBDrainModeImmediate
+	lBDrainTimeout := 30
+	lBDrainSessionsField := ""
+	portAllocationPolicy := PortAllocationPolicyRandom
 	for _, c := range conf {
 		switch c.Name {
 		case SlbIdsConfigName:
@@ -402,26 +688,34 @@ func parseLbConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) (*slbConfig, e
 					lbIds = append(lbIds, slbId)
 				}
 			}
-		case PortProtocolsConfigName:
-			for _, pp := range strings.Split(c.Value, ",") {
-				ppSlice := strings.Split(pp, "/")
-				port, err := strconv.Atoi(ppSlice[0])
-				if err != nil {
-					continue
-				}
-				ports = append(ports, port)
-				if len(ppSlice) != 2 {
-					protocols = append(protocols, corev1.ProtocolTCP)
-				} else {
-					protocols = append(protocols, corev1.Protocol(ppSlice[1]))
+		case ExternalLbsConfigName:
+			for _, name := range strings.Split(c.Value, ",") {
+				if name != "" {
+					// lb id is unknown until the ExternalLoadBalancer is resolved
+					externalLbs[name] = ""
 				}
 			}
+		case PortProtocolsConfigName:
+			var err error
+			ports, protocols, err = utils.ParsePortProtocols(c.Value)
+			if err != nil {
+				return nil, err
+			}
+			if err := validateProtocols(protocols, slbProtocols); err != nil {
+				return nil, err
+			}
 		case FixedConfigName:
 			v, err := strconv.ParseBool(c.Value)
 			if err != nil {
-				continue
+				return nil, fmt.Errorf("invalid %s: %s", FixedConfigName, c.Value)
 			}
 			isFixed = v
+		case BlueGreenListenersConfigName:
+			v, err := strconv.ParseBool(c.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", BlueGreenListenersConfigName, c.Value)
+			}
+			blueGreenListeners = v
 		case LBHealthCheckSwitchConfigName:
 			checkSwitch := strings.ToLower(c.Value)
 			if checkSwitch != "on" && checkSwitch != "off" {
@@ -497,13 +791,42 @@ func parseLbConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) (*slbConfig, e
 				return nil, fmt.Errorf("invalid lb health check method: %s", c.Value)
 			}
 			lBHealthCheckMethod = method
+		case LBDrainModeConfigName:
+			mode := strings.ToLower(c.Value)
+			if mode != LBDrainModeImmediate && mode != LBDrainModeGraceful {
+				return nil, fmt.Errorf("invalid lb drain mode: %s", c.Value)
+			}
+			lBDrainMode = mode
+		case LBDrainTimeoutConfigName:
+			timeoutInt, err := strconv.Atoi(c.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid lb drain timeout: %s", c.Value)
+			}
+			if timeoutInt < 0 || timeoutInt > MaxLBDrainTimeout {
+				return nil, fmt.Errorf("invalid lb drain timeout: %d, which should be in [0, %d]", timeoutInt, MaxLBDrainTimeout)
+			}
+			lBDrainTimeout = timeoutInt
+		case LBDrainSessionsFieldConfigName:
+			lBDrainSessionsField = strings.TrimSpace(c.Value)
+		case PortAllocationPolicyConfigName:
+			policy := strings.ToLower(c.Value)
+			if _, ok := portAllocators[policy]; !ok {
+				return nil, fmt.Errorf("invalid port allocation policy: %s", c.Value)
+			}
+			portAllocationPolicy = policy
 		}
 	}
+	ipFamilies, err := utils.ParseIPFamilyConf(conf)
+	if err != nil {
+		return nil, err
+	}
 	return &slbConfig{
 		lbIds:                       lbIds,
+		externalLbs:                 externalLbs,
 		protocols:                   protocols,
 		targetPorts:                 ports,
 		isFixed:                     isFixed,
+		blueGreenListeners:          blueGreenListeners,
 		lBHealthCheckSwitch:         lBHealthCheckSwitch,
 		lBHealthCheckFlag:           lBHealthCheckFlag,
 		lBHealthCheckType:           lBHealthCheckType,
@@ -515,15 +838,126 @@ func parseLbConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) (*slbConfig, e
 		lBHealthCheckMethod:         lBHealthCheckMethod,
 		lBHealthyThreshold:          lBHealthyThreshold,
 		lBUnhealthyThreshold:        lBUnhealthyThreshold,
+		lBDrainMode:                 lBDrainMode,
+		lBDrainTimeout:              lBDrainTimeout,
+		lBDrainSessionsField:        lBDrainSessionsField,
+		portAllocationPolicy:        portAllocationPolicy,
+		ipFamilies:                  ipFamilies,
 	}, nil
 }
 
-func getPorts(ports []corev1.ServicePort) []int32 {
-	var ret []int32
-	for _, port := range ports {
-		ret = append(ret, port.Port)
+// resolveExternalLbs replaces the ExternalLoadBalancer names parsed from network config
+// with the lb ids they describe, and appends the ids to the candidate lbIds. The port budgets
+// of the ExternalLoadBalancers are recorded as well.
+func resolveExternalLbs(c client.Client, ctx context.Context, sc *slbConfig) error {
+	names := make([]string, 0, len(sc.externalLbs))
+	for name := range sc.externalLbs {
+		names = append(names, name)
 	}
-	return ret
+	sort.Strings(names)
+
+	resolved := make(map[string]string)
+	budgets := make(map[string]int32)
+	for _, name := range names {
+		elb := &gamekruiseiov1alpha1.ExternalLoadBalancer{}
+		if err := c.Get(ctx, types.NamespacedName{Name: name}, elb); err != nil {
+			return fmt.Errorf("failed to get ExternalLoadBalancer %s: %s", name, err.Error())
+		}
+		if elb.Spec.ID == "" {
+			return fmt.Errorf("ExternalLoadBalancer %s has no lb id", name)
+		}
+		if _, exist := resolved[elb.Spec.ID]; exist {
+			continue
+		}
+		resolved[elb.Spec.ID] = name
+		if elb.Spec.PortBudget > 0 {
+			budgets[elb.Spec.ID] = elb.Spec.PortBudget
+		}
+		if !util.IsStringInList(elb.Spec.ID, sc.lbIds) {
+			sc.lbIds = append(sc.lbIds, elb.Spec.ID)
+		}
+	}
+	sc.externalLbs = resolved
+	sc.portBudgets = budgets
+	return nil
+}
+
+// setPortBudgets updates the port budgets of the ExternalLoadBalancers resolved in sc, which are
+// enforced when allocating ports.
+func (s *SlbPlugin) setPortBudgets(sc *slbConfig) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for lbId := range sc.externalLbs {
+		budget, ok := sc.portBudgets[lbId]
+		if !ok {
+			delete(s.portBudgets, lbId)
+			continue
+		}
+		if s.portBudgets == nil {
+			s.portBudgets = make(map[string]int32)
+		}
+		s.portBudgets[lbId] = budget
+	}
+}
+
+// remainingBudget returns the number of ports which can still be allocated from the lb within its port budget,
+// or -1 if the lb has no port budget.
+func remainingBudget(budgets map[string]int32, allocated portAllocated, lbId string) int {
+	budget, ok := budgets[lbId]
+	if !ok {
+		return -1
+	}
+	used := 0
+	for _, isAllocated := range allocated {
+		if isAllocated {
+			used++
+		}
+	}
+	if used >= int(budget) {
+		return 0
+	}
+	return int(budget) - used
+}
+
+// isDrainCompleted returns true when the svc has been draining for longer than timeout seconds, or the pod has
+// no active sessions since the draining started.
+func isDrainCompleted(svc *corev1.Service, pod *corev1.Pod, timeout int, sessionsField string) bool {
+	startTime, err := time.Parse(time.RFC3339, svc.GetAnnotations()[SlbDrainStartTimeKey])
+	if err != nil {
+		return false
+	}
+	if time.Since(startTime) >= time.Duration(timeout)*time.Second {
+		return true
+	}
+	return isSessionsDrained(pod, sessionsField)
+}
+
+// setDrainUntil returns the pod with the deadline of the draining started at startTime, so that the network of the pod
+// is triggered again until the draining is completed, even if LBDrainTimeout is longer than NETWORK_TOTAL_WAIT_TIME.
+func setDrainUntil(pod *corev1.Pod, startTime string, timeout int) *corev1.Pod {
+	start, err := time.Parse(time.RFC3339, startTime)
+	if err != nil {
+		return pod
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[gamekruiseiov1alpha1.GameServerNetworkDrainUntil] = start.Add(time.Duration(timeout) * time.Second).Format(time.RFC3339)
+	return pod
+}
+
+// isSessionsDrained returns true when the game server acknowledges the shutdown through the SDK, or the custom
+// status field sessionsField, if not empty, reports no active sessions.
+func isSessionsDrained(pod *corev1.Pod, sessionsField string) bool {
+	annotations := pod.GetAnnotations()
+	if annotations[gamekruiseiov1alpha1.GameServerShutdownStateKey] == string(gamekruiseiov1alpha1.ShutdownDrained) {
+		return true
+	}
+	if sessionsField == "" {
+		return false
+	}
+	sessions, err := strconv.ParseFloat(annotations[gamekruiseiov1alpha1.GameServerCustomStatusPrefix+sessionsField], 64)
+	return err == nil && sessions <= 0
 }
 
 func (s *SlbPlugin) consSvc(sc *slbConfig, pod *corev1.Pod, c client.Client, ctx context.Context) (*corev1.Service, error) {
@@ -536,26 +970,22 @@ func (s *SlbPlugin) consSvc(sc *slbConfig, pod *corev1.Pod, c client.Client, ctx
 		lbId = slbPorts[0]
 		ports = util.StringToInt32Slice(slbPorts[1], ",")
 	} else {
-		lbId, ports = s.allocate(sc.lbIds, len(sc.targetPorts), podKey)
-		if lbId == "" && ports == nil {
-			return nil, fmt.Errorf("there are no avaialable ports for %v", sc.lbIds)
+		var err error
+		lbId, ports, err = s.allocatePorts(c, ctx, sc.lbIds, len(sc.targetPorts), podKey, sc.portAllocationPolicy)
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	svcPorts := make([]corev1.ServicePort, 0)
 	for i := 0; i < len(sc.targetPorts); i++ {
-		svcPorts = append(svcPorts, corev1.ServicePort{
-			Name:       strconv.Itoa(sc.targetPorts[i]),
-			Port:       ports[i],
-			Protocol:   sc.protocols[i],
-			TargetPort: intstr.FromInt(sc.targetPorts[i]),
-		})
+		svcPorts = append(svcPorts, utils.ConsServicePorts(sc.targetPorts[i], ports[i], sc.protocols[i])...)
 	}
 
 	svcAnnotations := map[string]string{
 		SlbListenerOverrideKey:           "true",
 		SlbIdAnnotationKey:               lbId,
-		SlbConfigHashKey:                 util.GetHash(sc),
+		SlbConfigHashKey:                 sc.hash(),
 		LBHealthCheckFlagAnnotationKey:   sc.lBHealthCheckFlag,
 		LBHealthCheckSwitchAnnotationKey: sc.lBHealthCheckSwitch,
 	}
@@ -573,10 +1003,18 @@ func (s *SlbPlugin) consSvc(sc *slbConfig, pod *corev1.Pod, c client.Client, ctx
 		}
 	}
 
+	var svcLabels map[string]string
+	if elbName, ok := sc.externalLbs[lbId]; ok {
+		svcLabels = map[string]string{
+			gamekruiseiov1alpha1.ExternalLoadBalancerLabelKey: elbName,
+		}
+	}
+
 	svc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            pod.GetName(),
 			Namespace:       pod.GetNamespace(),
+			Labels:          utils.ServiceLabels(SlbNetwork, svcLabels),
 			Annotations:     svcAnnotations,
 			OwnerReferences: getSvcOwnerReference(c, ctx, pod, sc.isFixed),
 		},
@@ -588,6 +1026,7 @@ func (s *SlbPlugin) consSvc(sc *slbConfig, pod *corev1.Pod, c client.Client, ctx
 			Ports: svcPorts,
 		},
 	}
+	sc.ipFamilies.ApplyTo(&svc.Spec)
 	return svc, nil
 }
 
@@ -620,6 +1059,20 @@ func getSvcOwnerReference(c client.Client, ctx context.Context, pod *corev1.Pod,
 	return ownerReferences
 }
 
+// validateProtocols returns the error naming the first protocol not in the allowed ones.
+func validateProtocols(protocols []corev1.Protocol, allowed []corev1.Protocol) error {
+	for _, protocol := range protocols {
+		if !slices.Contains(allowed, protocol) {
+			names := make([]string, 0, len(allowed))
+			for _, p := range allowed {
+				names = append(names, string(p))
+			}
+			return fmt.Errorf("unsupported protocol %q in %s, which should be one of %s", protocol, PortProtocolsConfigName, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
 func validateHttpProtocolPort(protocolPort string) error {
 	protocolPorts := strings.Split(protocolPort, ",")
 	for _, pp := range protocolPorts {
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alibabacloud
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	log "k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openkruise/kruise-game/cloudprovider/utils"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+const (
+	// BlueGreenListenersConfigName pre-provisions the listeners of the new network config on the Service of each pod
+	// during a rollout of a Fixed GameServerSet, and switches to them at once when the pod is recreated.
+	BlueGreenListenersConfigName = "BlueGreenListeners"
+
+	// SlbGreenConfigHashKey is the hash of the network config whose listeners are pre-provisioned on the Service.
+	SlbGreenConfigHashKey = "game.kruise.io/network-green-config-hash"
+	// SlbGreenPortsKey is the lb id and the ports of the pre-provisioned listeners, e.g. lb-xxx:500,501.
+	SlbGreenPortsKey = "game.kruise.io/network-green-ports"
+
+	// slbGreenPortNamePrefix prefixes the names of the pre-provisioned ServicePorts, which may share the target
+	// ports with the serving ones.
+	slbGreenPortNamePrefix = "green-"
+	// slbGreenKeySuffix suffixes the pod key of the allocation of the pre-provisioned ports.
+	slbGreenKeySuffix = "#green"
+)
+
+// isGreenServicePort returns true if the ServicePort is pre-provisioned for the new network config.
+func isGreenServicePort(port corev1.ServicePort) bool {
+	return strings.HasPrefix(port.Name, slbGreenPortNamePrefix)
+}
+
+// syncGreenListeners pre-provisions the listeners of the network config of the GameServerSet on the Service of the
+// pod, if it differs from the one the Service serves, or removes the listeners pre-provisioned if it no longer
+// differs. It returns true if the Service is updated.
+func (s *SlbPlugin) syncGreenListeners(c client.Client, ctx context.Context, pod *corev1.Pod, svc *corev1.Service) (bool, error) {
+	gss, err := util.GetGameServerSetOfPod(pod, c, ctx)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if gss.Spec.Network == nil || (gss.Spec.Network.NetworkType != SlbNetwork && gss.Spec.Network.NetworkType != AliasSLB) {
+		return false, nil
+	}
+	conf, err := utils.ResolveNetworkConfig(ctx, c, pod, gss.Spec.Network.NetworkConf)
+	if err != nil {
+		return false, err
+	}
+	gssSc, err := parseLbConfig(conf)
+	if err != nil {
+		// rejected by the webhook of GameServerSet
+		return false, nil
+	}
+	if err := resolveExternalLbs(c, ctx, gssSc); err != nil {
+		return false, err
+	}
+	s.setPortBudgets(gssSc)
+
+	podKey := pod.GetNamespace() + "/" + pod.GetName()
+	greenHash := gssSc.hash()
+	annotations := svc.GetAnnotations()
+	if greenHash == annotations[SlbGreenConfigHashKey] {
+		return false, nil
+	}
+
+	// release the listeners pre-provisioned for a former network config, such as a rollout rolled back
+	if annotations[SlbGreenConfigHashKey] != "" {
+		if err := s.deAllocatePorts(c, ctx, []string{podKey + slbGreenKeySuffix}); err != nil {
+			return false, err
+		}
+		svc.Spec.Ports = removeGreenServicePorts(svc.Spec.Ports)
+		delete(svc.Annotations, SlbGreenConfigHashKey)
+		delete(svc.Annotations, SlbGreenPortsKey)
+		if greenHash == annotations[SlbConfigHashKey] {
+			return true, c.Update(ctx, svc)
+		}
+	}
+	if greenHash == annotations[SlbConfigHashKey] {
+		return false, nil
+	}
+
+	// the listeners can only be pre-provisioned on the lb serving the pod
+	lbId := annotations[SlbIdAnnotationKey]
+	if !util.IsStringInList(lbId, gssSc.lbIds) {
+		log.Infof("[%s] skip pre-provisioning listeners for pod %s, whose lb %s is not in the new network config", SlbNetwork, podKey, lbId)
+		return false, nil
+	}
+	_, ports, err := s.allocatePorts(c, ctx, []string{lbId}, len(gssSc.targetPorts), podKey+slbGreenKeySuffix, gssSc.portAllocationPolicy)
+	if err != nil {
+		if utils.IsPortExhausted(err) {
+			// the Service will be updated in place when the pod is recreated
+			log.Warningf("[%s] skip pre-provisioning listeners for pod %s: %s", SlbNetwork, podKey, err.Error())
+			return false, nil
+		}
+		return false, err
+	}
+	for i := 0; i < len(gssSc.targetPorts); i++ {
+		for _, port := range utils.ConsServicePorts(gssSc.targetPorts[i], ports[i], gssSc.protocols[i]) {
+			port.Name = slbGreenPortNamePrefix + port.Name
+			svc.Spec.Ports = append(svc.Spec.Ports, port)
+		}
+	}
+	svc.Annotations[SlbGreenConfigHashKey] = greenHash
+	svc.Annotations[SlbGreenPortsKey] = lbId + ":" + util.Int32SliceToString(ports, ",")
+	if err := c.Update(ctx, svc); err != nil {
+		// the ports will be allocated again in the next reconcile
+		if deErr := s.deAllocatePorts(c, ctx, []string{podKey + slbGreenKeySuffix}); deErr != nil {
+			log.Errorf("[%s] failed to release the ports pre-provisioned for pod %s: %s", SlbNetwork, podKey, deErr.Error())
+		}
+		return false, err
+	}
+	log.Infof("[%s] pre-provision listeners of ports %v for pod %s", SlbNetwork, ports, podKey)
+	return true, nil
+}
+
+func removeGreenServicePorts(ports []corev1.ServicePort) []corev1.ServicePort {
+	ret := make([]corev1.ServicePort, 0, len(ports))
+	for _, port := range ports {
+		if !isGreenServicePort(port) {
+			ret = append(ret, port)
+		}
+	}
+	return ret
+}
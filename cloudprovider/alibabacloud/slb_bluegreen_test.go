@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alibabacloud
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestSyncGreenListeners(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := gamekruiseiov1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	oldConf := []gamekruiseiov1alpha1.NetworkConfParams{
+		{Name: SlbIdsConfigName, Value: "lb-A"},
+		{Name: PortProtocolsConfigName, Value: "80"},
+		{Name: FixedConfigName, Value: "true"},
+		{Name: BlueGreenListenersConfigName, Value: "true"},
+	}
+	newConf := []gamekruiseiov1alpha1.NetworkConfParams{
+		{Name: SlbIdsConfigName, Value: "lb-A"},
+		{Name: PortProtocolsConfigName, Value: "80,81/UDP"},
+		{Name: FixedConfigName, Value: "true"},
+		{Name: BlueGreenListenersConfigName, Value: "true"},
+		{Name: PortAllocationPolicyConfigName, Value: PortAllocationPolicySequential},
+	}
+	oldSc, err := parseLbConfig(oldConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newSc, err := parseLbConfig(newConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gss := &gamekruiseiov1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gss"},
+		Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+			Network: &gamekruiseiov1alpha1.Network{NetworkType: SlbNetwork, NetworkConf: newConf},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "gss-0",
+			Labels:    map[string]string{gamekruiseiov1alpha1.GameServerOwnerGssKey: "gss"},
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "gss-0",
+			Annotations: map[string]string{
+				SlbIdAnnotationKey: "lb-A",
+				SlbConfigHashKey:   oldSc.hash(),
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeLoadBalancer,
+			Selector: map[string]string{SvcSelectorKey: "gss-0"},
+			Ports:    []corev1.ServicePort{{Name: "80", Port: 500, Protocol: corev1.ProtocolTCP}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gss, pod, svc).Build()
+	s := &SlbPlugin{
+		minPort:     500,
+		maxPort:     510,
+		cache:       map[string]portAllocated{"lb-A": {500: true}},
+		podAllocate: map[string]string{"default/gss-0": "lb-A:500"},
+	}
+
+	// pre-provision the listeners of the new network config
+	updated, err := s.syncGreenListeners(c, ctx, pod, svc.DeepCopy())
+	if err != nil || !updated {
+		t.Fatalf("expect the Service updated, but actually got %v %v", updated, err)
+	}
+	actual := &corev1.Service{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "gss-0"}, actual); err != nil {
+		t.Fatal(err)
+	}
+	expectPorts := []corev1.ServicePort{
+		{Name: "80", Port: 500, Protocol: corev1.ProtocolTCP},
+		{Name: "green-80", Port: 501, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(80)},
+		{Name: "green-81", Port: 502, Protocol: corev1.ProtocolUDP, TargetPort: intstr.FromInt(81)},
+	}
+	if !reflect.DeepEqual(actual.Spec.Ports, expectPorts) {
+		t.Errorf("expect ports %v, but actually got %v", expectPorts, actual.Spec.Ports)
+	}
+	if actual.Annotations[SlbGreenConfigHashKey] != newSc.hash() || actual.Annotations[SlbGreenPortsKey] != "lb-A:501,502" {
+		t.Errorf("expect green annotations of lb-A:501,502, but actually got %v", actual.Annotations)
+	}
+
+	// pre-provisioned already
+	updated, err = s.syncGreenListeners(c, ctx, pod, actual.DeepCopy())
+	if err != nil || updated {
+		t.Errorf("expect the Service not updated, but actually got %v %v", updated, err)
+	}
+
+	// rolled back before the pod is recreated
+	gss.Spec.Network.NetworkConf = oldConf
+	if err := c.Update(ctx, gss); err != nil {
+		t.Fatal(err)
+	}
+	updated, err = s.syncGreenListeners(c, ctx, pod, actual.DeepCopy())
+	if err != nil || !updated {
+		t.Fatalf("expect the Service updated, but actually got %v %v", updated, err)
+	}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "gss-0"}, actual); err != nil {
+		t.Fatal(err)
+	}
+	if len(actual.Spec.Ports) != 1 || actual.Annotations[SlbGreenConfigHashKey] != "" || actual.Annotations[SlbGreenPortsKey] != "" {
+		t.Errorf("expect the listeners pre-provisioned removed, but actually got %v %v", actual.Spec.Ports, actual.Annotations)
+	}
+	if _, exist := s.podAllocate["default/gss-0"+slbGreenKeySuffix]; exist {
+		t.Errorf("expect the ports pre-provisioned released, but actually got %v", s.podAllocate)
+	}
+
+	// rolled out again
+	gss.Spec.Network.NetworkConf = newConf
+	if err := c.Update(ctx, gss); err != nil {
+		t.Fatal(err)
+	}
+	updated, err = s.syncGreenListeners(c, ctx, pod, actual.DeepCopy())
+	if err != nil || !updated {
+		t.Fatalf("expect the Service updated, but actually got %v %v", updated, err)
+	}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "gss-0"}, actual); err != nil {
+		t.Fatal(err)
+	}
+
+	// cut over to the listeners pre-provisioned
+	if err := s.promoteGreenPorts(c, ctx, "default/gss-0", actual.Annotations[SlbGreenPortsKey]); err != nil {
+		t.Fatal(err)
+	}
+	if s.podAllocate["default/gss-0"] != "lb-A:501,502" || s.podAllocate["default/gss-0"+slbGreenKeySuffix] != "" {
+		t.Errorf("expect the pod allocated lb-A:501,502, but actually got %v", s.podAllocate)
+	}
+	if s.cache["lb-A"][500] || !s.cache["lb-A"][501] || !s.cache["lb-A"][502] {
+		t.Errorf("expect port 500 released and ports 501,502 allocated, but actually got %v", s.cache["lb-A"])
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alibabacloud
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	log "k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/cloudprovider/utils"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+// staleAllocationGracePeriod is the minimum time for which an allocation whose pod is not found must be seen so by
+// the sweeper before it is removed. Ports are allocated by the pod webhook before the pod is created, so that the pod
+// of a new allocation may not be found yet.
+const staleAllocationGracePeriod = 5 * time.Minute
+
+// isSlbService returns true if the Service is created by the SlbPlugin for a pod.
+func isSlbService(svc *corev1.Service) bool {
+	return svc.GetAnnotations()[SlbConfigHashKey] != "" &&
+		svc.Spec.Selector[SvcSelectorKey] == svc.GetName() &&
+		svc.Spec.LoadBalancerClass == nil
+}
+
+// isOrphanService returns true if the pod of the Service no longer exists, and the Service is neither
+// owned by a live GameServerSet with Fixed=true nor retained for the identity of a GameServerSet.
+func isOrphanService(c client.Client, ctx context.Context, svc *corev1.Service) (bool, error) {
+	if svc.GetAnnotations()[gamekruiseiov1alpha1.IdentityRetainedFromKey] != "" {
+		return false, nil
+	}
+	err := c.Get(ctx, types.NamespacedName{Namespace: svc.GetNamespace(), Name: svc.GetName()}, &corev1.Pod{})
+	if err == nil || !errors.IsNotFound(err) {
+		return false, err
+	}
+	for _, owner := range svc.GetOwnerReferences() {
+		if owner.Kind != "GameServerSet" {
+			continue
+		}
+		gss := &gamekruiseiov1alpha1.GameServerSet{}
+		err := c.Get(ctx, types.NamespacedName{Namespace: svc.GetNamespace(), Name: owner.Name}, gss)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		if gss.GetUID() == owner.UID && gss.GetDeletionTimestamp() == nil {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// gcOrphanServices deletes the orphaned Services of the SlbPlugin, so that their ports are not leaked forever.
+// It returns the Services left, and the keys of the deleted ones.
+func gcOrphanServices(c client.Client, ctx context.Context, svcList []corev1.Service) ([]corev1.Service, []string, error) {
+	var left []corev1.Service
+	var deleted []string
+	for i := range svcList {
+		svc := &svcList[i]
+		if !isSlbService(svc) || svc.GetDeletionTimestamp() != nil {
+			left = append(left, *svc)
+			continue
+		}
+		orphan, err := isOrphanService(c, ctx, svc)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !orphan {
+			left = append(left, *svc)
+			continue
+		}
+		if err := c.Delete(ctx, svc); err != nil && !errors.IsNotFound(err) {
+			return nil, nil, err
+		}
+		deleted = append(deleted, svc.GetNamespace()+"/"+svc.GetName())
+		log.Infof("[%s] orphaned service %s/%s is deleted, ports %v are freed", SlbNetwork, svc.GetNamespace(), svc.GetName(), utils.GetExternalPorts(svc.Spec.Ports))
+	}
+	return left, deleted, nil
+}
+
+// sweepStaleAllocations returns the port allocations left after the stale ones are removed, and the keys of
+// the removed ones. The allocations backed by Services are taken from the Services, repairing the recorded ports
+// which differ from them. The allocations not backed by Services, e.g. recorded before a crash before the Service
+// was created, are removed if their ports are held by the Services of other pods, or if their pods have not been
+// found for staleAllocationGracePeriod, so that recreated pods never inherit them. notFoundSince records when the
+// pods of the allocations were first not found, and is updated in place.
+func sweepStaleAllocations(c client.Client, ctx context.Context, podAllocate, svcPodAllocate map[string]string, notFoundSince map[string]time.Time, now time.Time) (map[string]string, []string, error) {
+	swept := make(map[string]string, len(podAllocate))
+	type lbPort struct {
+		lbId string
+		port int32
+	}
+	inUse := make(map[lbPort]bool)
+	for podKey, allocatedPorts := range svcPodAllocate {
+		swept[podKey] = allocatedPorts
+		slbPorts := strings.Split(allocatedPorts, ":")
+		for _, port := range util.StringToInt32Slice(slbPorts[1], ",") {
+			inUse[lbPort{slbPorts[0], port}] = true
+		}
+	}
+
+	var podKeys []string
+	for podKey := range podAllocate {
+		if _, backed := svcPodAllocate[podKey]; !backed {
+			podKeys = append(podKeys, podKey)
+		}
+	}
+	sort.Strings(podKeys)
+	for podKey := range notFoundSince {
+		if _, ok := podAllocate[podKey]; !ok {
+			delete(notFoundSince, podKey)
+		}
+	}
+	var removed []string
+	for _, podKey := range podKeys {
+		allocatedPorts := podAllocate[podKey]
+		nsName := strings.SplitN(podKey, "/", 2)
+		slbPorts := strings.Split(allocatedPorts, ":")
+		if len(nsName) != 2 || len(slbPorts) != 2 {
+			removed = append(removed, podKey)
+			continue
+		}
+		err := c.Get(ctx, types.NamespacedName{Namespace: nsName[0], Name: nsName[1]}, &corev1.Pod{})
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, nil, err
+		}
+		stale := false
+		if errors.IsNotFound(err) {
+			if _, ok := notFoundSince[podKey]; !ok {
+				notFoundSince[podKey] = now
+			}
+			stale = now.Sub(notFoundSince[podKey]) >= staleAllocationGracePeriod
+		} else {
+			delete(notFoundSince, podKey)
+		}
+		ports := util.StringToInt32Slice(slbPorts[1], ",")
+		for _, port := range ports {
+			if inUse[lbPort{slbPorts[0], port}] {
+				stale = true
+			}
+		}
+		if stale {
+			delete(notFoundSince, podKey)
+			removed = append(removed, podKey)
+			continue
+		}
+		swept[podKey] = allocatedPorts
+		for _, port := range ports {
+			inUse[lbPort{slbPorts[0], port}] = true
+		}
+	}
+	return swept, removed, nil
+}
+
+// Sweep removes the stale port allocations of the plugin, and the state ConfigMap if it is configured.
+func (s *SlbPlugin) Sweep(c client.Client, ctx context.Context) error {
+	if s.notFoundSince == nil {
+		s.notFoundSince = make(map[string]time.Time)
+	}
+	svcList := &corev1.ServiceList{}
+	if err := c.List(ctx, svcList); err != nil {
+		return err
+	}
+
+	if s.store == nil {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		_, svcPodAllocate := initLbCache(svcList.Items, s.minPort, s.maxPort)
+		podAllocate, removed, err := sweepStaleAllocations(c, ctx, s.podAllocate, svcPodAllocate, s.notFoundSince, time.Now())
+		if err != nil {
+			return err
+		}
+		s.podAllocate, s.cache = podAllocate, buildLbCache(podAllocate, s.minPort, s.maxPort)
+		if len(removed) != 0 {
+			log.Infof("[%s] stale allocations of pods %v are removed", SlbNetwork, removed)
+		}
+		return nil
+	}
+
+	s.stateMutex.Lock()
+	defer s.stateMutex.Unlock()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, podAllocate, err := s.store.load(c, ctx)
+		if err != nil {
+			return err
+		}
+		_, svcPodAllocate := initLbCache(svcList.Items, s.minPort, s.maxPort)
+		swept, removed, err := sweepStaleAllocations(c, ctx, podAllocate, svcPodAllocate, s.notFoundSince, time.Now())
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(swept, podAllocate) {
+			if err := s.store.save(c, ctx, cm, swept); err != nil {
+				return err
+			}
+		}
+		s.syncState(swept)
+		if len(removed) != 0 {
+			log.Infof("[%s] stale allocations of pods %v are removed from state configmap %s/%s", SlbNetwork, removed, s.store.namespace, s.store.name)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alibabacloud
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestGcOrphanServices(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := gamekruiseiov1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	newSvc := func(name string, owner *metav1.OwnerReference, annotations map[string]string) *corev1.Service {
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "default",
+				Name:        name,
+				Annotations: map[string]string{SlbConfigHashKey: "123"},
+			},
+			Spec: corev1.ServiceSpec{
+				Type:     corev1.ServiceTypeLoadBalancer,
+				Selector: map[string]string{SvcSelectorKey: name},
+				Ports:    []corev1.ServicePort{{Port: 500}},
+			},
+		}
+		for k, v := range annotations {
+			svc.Annotations[k] = v
+		}
+		if owner != nil {
+			svc.OwnerReferences = []metav1.OwnerReference{*owner}
+		}
+		return svc
+	}
+	gssOwner := func(name string, uid types.UID) *metav1.OwnerReference {
+		return &metav1.OwnerReference{APIVersion: "game.kruise.io/v1alpha1", Kind: "GameServerSet", Name: name, UID: uid}
+	}
+
+	svcs := []*corev1.Service{
+		// pod exists
+		newSvc("gss-a-0", nil, nil),
+		// pod not exists
+		newSvc("gss-a-1", &metav1.OwnerReference{APIVersion: "v1", Kind: "Pod", Name: "gss-a-1", UID: "pod-uid"}, nil),
+		// owned by live GameServerSet with Fixed=true
+		newSvc("gss-b-0", gssOwner("gss-b", "gss-b-uid"), nil),
+		// owned by GameServerSet which is deleted and recreated
+		newSvc("gss-b-1", gssOwner("gss-b", "old-uid"), nil),
+		// owned by GameServerSet which is deleted
+		newSvc("gss-c-0", gssOwner("gss-c", "gss-c-uid"), nil),
+		// retained for the identity of GameServerSet
+		newSvc("gss-c-1", gssOwner("gss-c", "gss-c-uid"), map[string]string{gamekruiseiov1alpha1.IdentityRetainedFromKey: "gss-c"}),
+	}
+	notSlb := newSvc("not-slb", nil, nil)
+	notSlb.Annotations = nil
+	svcs = append(svcs, notSlb)
+
+	objs := []client.Object{
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gss-a-0"}},
+		&gamekruiseiov1alpha1.GameServerSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gss-b", UID: "gss-b-uid"}},
+	}
+	svcList := make([]corev1.Service, 0, len(svcs))
+	for _, svc := range svcs {
+		objs = append(objs, svc.DeepCopy())
+		svcList = append(svcList, *svc)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+	left, deleted, err := gcOrphanServices(c, context.Background(), svcList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var leftNames []string
+	for _, svc := range left {
+		leftNames = append(leftNames, svc.GetName())
+	}
+	sort.Strings(leftNames)
+	expectLeft := []string{"gss-a-0", "gss-b-0", "gss-c-1", "not-slb"}
+	if !reflect.DeepEqual(leftNames, expectLeft) {
+		t.Errorf("expect services left %v, but actually got %v", expectLeft, leftNames)
+	}
+	expectDeleted := []string{"default/gss-a-1", "default/gss-b-1", "default/gss-c-0"}
+	if !reflect.DeepEqual(deleted, expectDeleted) {
+		t.Errorf("expect services deleted %v, but actually got %v", expectDeleted, deleted)
+	}
+	for _, key := range expectDeleted {
+		err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: key[len("default/"):]}, &corev1.Service{})
+		if !errors.IsNotFound(err) {
+			t.Errorf("expect service %s deleted, but actually got %v", key, err)
+		}
+	}
+}
+
+func TestSweepStaleAllocations(t *testing.T) {
+	ctx := context.Background()
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+	}
+	newSvc := func(name string, port int32) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels:    map[string]string{SlbIdLabelKey: "lb-A"},
+			},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Port: port}},
+			},
+		}
+	}
+	c := fake.NewClientBuilder().WithObjects(
+		newPod("gs-0"), newPod("gs-1"), newPod("gs-2"), newPod("gs-3"),
+		// the Service of gs-0 has been updated to another port
+		newSvc("gs-0", 503),
+		newSvc("gs-1", 501),
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "kruise-game-system",
+				Name:      "kruise-game-slb-allocation",
+			},
+			Data: map[string]string{
+				// gs-2 claims the port held by the Service of gs-1, gs-3 has not created its Service yet,
+				// and gs-4 is deleted before its Service is created
+				"lb-A": "default/gs-0=500;default/gs-1=501;default/gs-2=501;default/gs-3=502;default/gs-4=504",
+			},
+		},
+	).Build()
+
+	s := &SlbPlugin{
+		minPort: 500,
+		maxPort: 504,
+		store:   &slbStateStore{namespace: "kruise-game-system", name: "kruise-game-slb-allocation"},
+	}
+	if err := s.Sweep(c, ctx); err != nil {
+		t.Fatal(err)
+	}
+	// the pod of gs-4 may not be created yet, so that its allocation is kept within the grace period
+	if s.podAllocate["default/gs-4"] != "lb-A:504" {
+		t.Errorf("expect the allocation of gs-4 kept within the grace period, but got %v", s.podAllocate)
+	}
+	if _, ok := s.notFoundSince["default/gs-4"]; !ok || len(s.notFoundSince) != 1 {
+		t.Errorf("expect only gs-4 recorded as not found, but got %v", s.notFoundSince)
+	}
+	s.notFoundSince["default/gs-4"] = time.Now().Add(-staleAllocationGracePeriod)
+	if err := s.Sweep(c, ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	expectPodAllocate := map[string]string{
+		"default/gs-0": "lb-A:503",
+		"default/gs-1": "lb-A:501",
+		"default/gs-3": "lb-A:502",
+	}
+	if !reflect.DeepEqual(s.podAllocate, expectPodAllocate) {
+		t.Errorf("expect podAllocate %v but got %v", expectPodAllocate, s.podAllocate)
+	}
+	if s.cache["lb-A"][500] || s.cache["lb-A"][504] || !s.cache["lb-A"][503] {
+		t.Errorf("expect ports 500, 504 free and 503 in use, but got %v", s.cache["lb-A"])
+	}
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "kruise-game-system", Name: "kruise-game-slb-allocation"}, cm); err != nil {
+		t.Fatal(err)
+	}
+	expectData := map[string]string{"lb-A": "default/gs-0=503;default/gs-1=501;default/gs-3=502"}
+	if !reflect.DeepEqual(cm.Data, expectData) {
+		t.Errorf("expect data %v but got %v", expectData, cm.Data)
+	}
+
+	if len(s.notFoundSince) != 0 {
+		t.Errorf("expect no allocation recorded as not found, but got %v", s.notFoundSince)
+	}
+
+	// without the state ConfigMap, the allocations in memory are swept
+	s = &SlbPlugin{
+		minPort:       500,
+		maxPort:       504,
+		podAllocate:   map[string]string{"default/gs-3": "lb-A:502", "default/gs-4": "lb-A:504"},
+		notFoundSince: map[string]time.Time{"default/gs-4": time.Now().Add(-staleAllocationGracePeriod)},
+	}
+	if err := s.Sweep(c, ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s.podAllocate, expectPodAllocate) {
+		t.Errorf("expect podAllocate %v but got %v", expectPodAllocate, s.podAllocate)
+	}
+}
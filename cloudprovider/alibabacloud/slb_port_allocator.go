@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alibabacloud
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+const (
+	PortAllocationPolicyConfigName = "PortAllocationPolicy"
+
+	// PortAllocationPolicyRandom allocates free ports at random.
+	PortAllocationPolicyRandom = "random"
+	// PortAllocationPolicySequential allocates the lowest free ports.
+	PortAllocationPolicySequential = "sequential"
+	// PortAllocationPolicyOrdinal allocates minPort+ordinal*num+i to the i-th port of the pod,
+	// so that a pod always gets the same ports by its ordinal.
+	PortAllocationPolicyOrdinal = "ordinal"
+)
+
+// portAllocator selects num ports of a lb for the pod, among the ports in [minPort, maxPort) not allocated yet.
+type portAllocator interface {
+	selectPorts(allocated portAllocated, num int, podName string, minPort, maxPort int32) ([]int32, error)
+}
+
+var portAllocators = map[string]portAllocator{
+	PortAllocationPolicyRandom:     randomPortAllocator{},
+	PortAllocationPolicySequential: sequentialPortAllocator{},
+	PortAllocationPolicyOrdinal:    ordinalPortAllocator{},
+}
+
+func freePorts(allocated portAllocated, minPort, maxPort int32) []int32 {
+	var ports []int32
+	for port := minPort; port < maxPort; port++ {
+		if !allocated[port] {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+type randomPortAllocator struct{}
+
+func (randomPortAllocator) selectPorts(allocated portAllocated, num int, podName string, minPort, maxPort int32) ([]int32, error) {
+	free := freePorts(allocated, minPort, maxPort)
+	if len(free) < num {
+		return nil, fmt.Errorf("only %d ports are free", len(free))
+	}
+	rand.Shuffle(len(free), func(i, j int) { free[i], free[j] = free[j], free[i] })
+	return free[:num], nil
+}
+
+type sequentialPortAllocator struct{}
+
+func (sequentialPortAllocator) selectPorts(allocated portAllocated, num int, podName string, minPort, maxPort int32) ([]int32, error) {
+	free := freePorts(allocated, minPort, maxPort)
+	if len(free) < num {
+		return nil, fmt.Errorf("only %d ports are free", len(free))
+	}
+	return free[:num], nil
+}
+
+type ordinalPortAllocator struct{}
+
+func (ordinalPortAllocator) selectPorts(allocated portAllocated, num int, podName string, minPort, maxPort int32) ([]int32, error) {
+	base := minPort + int32(util.GetIndexFromGsName(podName)*num)
+	ports := make([]int32, 0, num)
+	for i := 0; i < num; i++ {
+		port := base + int32(i)
+		if port >= maxPort {
+			return nil, fmt.Errorf("port %d of pod %s exceeds the port range [%d, %d)", port, podName, minPort, maxPort)
+		}
+		if allocated[port] {
+			return nil, fmt.Errorf("port %d of pod %s has been allocated to another pod", port, podName)
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alibabacloud
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSelectPorts(t *testing.T) {
+	allocated := portAllocated{500: true, 501: false, 502: true, 503: false, 504: false}
+	tests := []struct {
+		policy  string
+		num     int
+		podName string
+		ports   []int32
+		wantErr bool
+	}{
+		{
+			policy: PortAllocationPolicySequential,
+			num:    2,
+			ports:  []int32{501, 503},
+		},
+		{
+			policy:  PortAllocationPolicySequential,
+			num:     9,
+			wantErr: true,
+		},
+		{
+			policy:  PortAllocationPolicyOrdinal,
+			num:     2,
+			podName: "gs-2",
+			ports:   []int32{504, 505},
+		},
+		{
+			// port 502 is allocated
+			policy:  PortAllocationPolicyOrdinal,
+			num:     2,
+			podName: "gs-1",
+			wantErr: true,
+		},
+		{
+			// port 510 exceeds the range
+			policy:  PortAllocationPolicyOrdinal,
+			num:     2,
+			podName: "gs-5",
+			wantErr: true,
+		},
+	}
+	for i, test := range tests {
+		ports, err := portAllocators[test.policy].selectPorts(allocated, test.num, test.podName, 500, 510)
+		if (err != nil) != test.wantErr {
+			t.Errorf("case %d: expect error %v, but actually got %v", i, test.wantErr, err)
+		}
+		if !reflect.DeepEqual(ports, test.ports) {
+			t.Errorf("case %d: expect ports %v, but actually got %v", i, test.ports, ports)
+		}
+	}
+
+	ports, err := portAllocators[PortAllocationPolicyRandom].selectPorts(allocated, 3, "gs-0", 500, 510)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+	if len(ports) != 3 || ports[0] == ports[1] || ports[1] == ports[2] {
+		t.Errorf("expect 3 different ports, but actually got %v", ports)
+	}
+	for _, port := range ports {
+		if allocated[port] || port < 500 || port >= 510 {
+			t.Errorf("port %d should not be allocated", port)
+		}
+	}
+}
+
+func TestAllocateOrdinal(t *testing.T) {
+	slb := &SlbPlugin{
+		maxPort:     int32(510),
+		minPort:     int32(500),
+		cache:       map[string]portAllocated{"lb-a": {502: true}},
+		podAllocate: make(map[string]string),
+	}
+	// ports 502 and 503 of lb-a are taken, so gs-1 spills over to lb-b
+	lbId, ports, err := slb.allocate([]string{"lb-a", "lb-b"}, 2, "default/gs-1", PortAllocationPolicyOrdinal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lbId != "lb-b" || !reflect.DeepEqual(ports, []int32{502, 503}) {
+		t.Errorf("expect lb-b [502 503], but actually got %s %v", lbId, ports)
+	}
+	if _, _, err := slb.allocate([]string{"lb-a", "lb-b"}, 2, "default/gs-5", PortAllocationPolicyOrdinal); err == nil {
+		t.Errorf("expect error as the ports of gs-5 exceed the range")
+	}
+}
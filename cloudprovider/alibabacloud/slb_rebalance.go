@@ -0,0 +1,290 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alibabacloud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	log "k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/cloudprovider"
+	"github.com/openkruise/kruise-game/cloudprovider/utils"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+// rebalanceCandidate is an idle pod whose Service can be migrated to another slb of its network conf.
+type rebalanceCandidate struct {
+	namespace string
+	name      string
+	from      string
+	lbIds     []string
+	num       int
+	policy    string
+	svc       *corev1.Service
+}
+
+type rebalanceMove struct {
+	candidate *rebalanceCandidate
+	to        string
+}
+
+// Rebalance migrates the Services of idle pods from the slbs whose port utilization exceeds opts.MaxUtilization
+// to the emptiest slbs of their network conf. The ports are allocated on the new slb before the old Service is
+// deleted, and the Service is created on the new slb by OnPodUpdated.
+func (s *SlbPlugin) Rebalance(c client.Client, ctx context.Context, opts cloudprovider.RebalanceOptions) (*cloudprovider.RebalanceResult, error) {
+	if opts.MaxUtilization <= 0 || opts.MaxUtilization > 1 {
+		return nil, fmt.Errorf("max utilization should be in (0, 1], now it is %v", opts.MaxUtilization)
+	}
+	if opts.MaxMoves <= 0 {
+		return nil, fmt.Errorf("max moves should be greater than 0, now it is %d", opts.MaxMoves)
+	}
+	used, total, err := s.portUsage()
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := listRebalanceCandidates(c, ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	moves := planRebalanceMoves(candidates, used, total, opts.MaxUtilization, opts.MaxMoves)
+	result := &cloudprovider.RebalanceResult{
+		Moves:       make([]cloudprovider.RebalanceMove, 0, len(moves)),
+		Utilization: make(map[string]float64, len(used)),
+	}
+	for lbId, ports := range used {
+		result.Utilization[lbId] = float64(ports) / float64(total)
+	}
+	for _, move := range moves {
+		candidate := move.candidate
+		if !opts.DryRun {
+			if err := s.migrate(c, ctx, move); err != nil {
+				return result, fmt.Errorf("failed to migrate pod %s/%s from slb %s to %s after %d migrations: %s", candidate.namespace, candidate.name, candidate.from, move.to, len(result.Moves), err.Error())
+			}
+			log.Infof("[%s] pod %s/%s is migrated from slb %s to %s", SlbNetwork, candidate.namespace, candidate.name, candidate.from, move.to)
+		}
+		result.Moves = append(result.Moves, cloudprovider.RebalanceMove{
+			Namespace: candidate.namespace,
+			Name:      candidate.name,
+			From:      candidate.from,
+			To:        move.to,
+		})
+	}
+	return result, nil
+}
+
+// portUsage returns the number of allocated ports of each slb, and the number of ports of the port range.
+func (s *SlbPlugin) portUsage() (map[string]int, int, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.cache == nil {
+		return nil, 0, fmt.Errorf("%s is not initialized", SlbNetwork)
+	}
+	used := make(map[string]int, len(s.cache))
+	for lbId, allocated := range s.cache {
+		for port := s.minPort; port < s.maxPort; port++ {
+			if allocated[port] {
+				used[lbId]++
+			}
+		}
+	}
+	return used, int(s.maxPort - s.minPort), nil
+}
+
+// listRebalanceCandidates returns the idle pods selected by opts, whose network conf has more than one slb.
+func listRebalanceCandidates(c client.Client, ctx context.Context, opts cloudprovider.RebalanceOptions) ([]rebalanceCandidate, error) {
+	listOpts := []client.ListOption{client.HasLabels{gamekruiseiov1alpha1.GameServerOwnerGssKey}}
+	if opts.Namespace != "" {
+		listOpts = append(listOpts, client.InNamespace(opts.Namespace))
+	}
+	if opts.GameServerSet != "" {
+		listOpts = append(listOpts, client.MatchingLabels{gamekruiseiov1alpha1.GameServerOwnerGssKey: opts.GameServerSet})
+	}
+	podList := &corev1.PodList{}
+	if err := c.List(ctx, podList, listOpts...); err != nil {
+		return nil, err
+	}
+
+	var candidates []rebalanceCandidate
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		networkType := pod.GetAnnotations()[gamekruiseiov1alpha1.GameServerNetworkType]
+		if networkType != SlbNetwork && networkType != AliasSLB {
+			continue
+		}
+		sc, err := parseLbConfig(utils.NewNetworkManager(pod, c).GetNetworkConfig())
+		if err != nil {
+			log.Warningf("[%s] skip rebalancing pod %s/%s, because of %s", SlbNetwork, pod.GetNamespace(), pod.GetName(), err.Error())
+			continue
+		}
+		if err := resolveExternalLbs(c, ctx, sc); err != nil {
+			return nil, err
+		}
+		if len(sc.lbIds) < 2 {
+			continue
+		}
+		svc := &corev1.Service{}
+		err = c.Get(ctx, types.NamespacedName{Namespace: pod.GetNamespace(), Name: pod.GetName()}, svc)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if !isRebalanceable(pod, svc, sc) {
+			continue
+		}
+		candidates = append(candidates, rebalanceCandidate{
+			namespace: pod.GetNamespace(),
+			name:      pod.GetName(),
+			from:      svc.GetAnnotations()[SlbIdAnnotationKey],
+			lbIds:     sc.lbIds,
+			num:       len(sc.targetPorts),
+			policy:    sc.portAllocationPolicy,
+			svc:       svc,
+		})
+	}
+	return candidates, nil
+}
+
+// isRebalanceable returns true if the game server of the pod is idle and its network is neither disabled nor
+// draining, so that migrating its Service to another slb drops no players.
+func isRebalanceable(pod *corev1.Pod, svc *corev1.Service, sc *slbConfig) bool {
+	if pod.GetDeletionTimestamp() != nil || svc.GetDeletionTimestamp() != nil {
+		return false
+	}
+	opsState := pod.GetLabels()[gamekruiseiov1alpha1.GameServerOpsStateKey]
+	if opsState != string(gamekruiseiov1alpha1.None) && opsState != string(gamekruiseiov1alpha1.WaitToDelete) {
+		return false
+	}
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer || svc.GetAnnotations()[SlbDrainStartTimeKey] != "" {
+		return false
+	}
+	if svc.GetAnnotations()[SlbIdAnnotationKey] == "" {
+		return false
+	}
+	if sc.lBDrainSessionsField != "" && !isSessionsDrained(pod, sc.lBDrainSessionsField) {
+		return false
+	}
+	return true
+}
+
+// planRebalanceMoves moves the candidates of the fullest slb exceeding maxUtilization to the emptiest slb of their
+// network conf, one at a time, as long as the new slb stays within maxUtilization. used is updated by the moves.
+func planRebalanceMoves(candidates []rebalanceCandidate, used map[string]int, total int, maxUtilization float64, maxMoves int) []rebalanceMove {
+	limit := int(maxUtilization * float64(total))
+	moved := make([]bool, len(candidates))
+	var moves []rebalanceMove
+	for len(moves) < maxMoves {
+		best, bestTo := -1, ""
+		for i := range candidates {
+			candidate := &candidates[i]
+			if moved[i] || used[candidate.from] <= limit {
+				continue
+			}
+			to := ""
+			for _, lbId := range candidate.lbIds {
+				if lbId == candidate.from || used[lbId]+candidate.num > limit {
+					continue
+				}
+				if to == "" || used[lbId] < used[to] {
+					to = lbId
+				}
+			}
+			if to == "" {
+				continue
+			}
+			if best < 0 || used[candidate.from] > used[candidates[best].from] {
+				best, bestTo = i, to
+			}
+		}
+		if best < 0 {
+			break
+		}
+		candidate := &candidates[best]
+		moved[best] = true
+		used[candidate.from] -= candidate.num
+		used[bestTo] += candidate.num
+		moves = append(moves, rebalanceMove{candidate: candidate, to: bestTo})
+	}
+	return moves
+}
+
+// migrate allocates the ports of the new slb to the pod, and deletes its Service, which is created on the new slb
+// by OnPodUpdated.
+func (s *SlbPlugin) migrate(c client.Client, ctx context.Context, move rebalanceMove) error {
+	candidate := move.candidate
+	if err := s.reallocatePorts(c, ctx, move.to, candidate.num, candidate.namespace+"/"+candidate.name, candidate.policy); err != nil {
+		return err
+	}
+	return client.IgnoreNotFound(c.Delete(ctx, candidate.svc))
+}
+
+// reallocatePorts moves the allocation of the pod to the slb lbId, and persists it if the state ConfigMap is configured.
+func (s *SlbPlugin) reallocatePorts(c client.Client, ctx context.Context, lbId string, num int, nsName string, policy string) error {
+	if s.store == nil {
+		return s.reallocate(lbId, num, nsName, policy)
+	}
+
+	s.stateMutex.Lock()
+	defer s.stateMutex.Unlock()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, podAllocate, err := s.store.load(c, ctx)
+		if err != nil {
+			return err
+		}
+		s.syncState(podAllocate)
+		if err := s.reallocate(lbId, num, nsName, policy); err != nil {
+			return err
+		}
+		s.mutex.RLock()
+		podAllocate[nsName] = s.podAllocate[nsName]
+		s.mutex.RUnlock()
+		// the state will be reloaded from the ConfigMap when retrying
+		return s.store.save(c, ctx, cm, podAllocate)
+	})
+}
+
+// reallocate allocates num ports of the slb lbId to the pod, and frees the ports allocated to it before.
+func (s *SlbPlugin) reallocate(lbId string, num int, nsName string, policy string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	oldAllocated, exist := s.podAllocate[nsName]
+	if _, _, err := s.allocateLocked([]string{lbId}, num, nsName, policy); err != nil {
+		return err
+	}
+	if !exist {
+		return nil
+	}
+	slbPorts := strings.Split(oldAllocated, ":")
+	if slbPorts[0] == lbId || s.cache[slbPorts[0]] == nil {
+		return nil
+	}
+	for _, port := range util.StringToInt32Slice(slbPorts[1], ",") {
+		s.cache[slbPorts[0]][port] = false
+	}
+	return nil
+}
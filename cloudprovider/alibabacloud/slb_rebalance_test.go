@@ -0,0 +1,230 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alibabacloud
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/cloudprovider"
+)
+
+func TestPlanRebalanceMoves(t *testing.T) {
+	candidate := func(name, from string, num int) rebalanceCandidate {
+		return rebalanceCandidate{namespace: "xxx", name: name, from: from, lbIds: []string{"lb-A", "lb-B", "lb-C"}, num: num}
+	}
+	tests := []struct {
+		candidates []rebalanceCandidate
+		used       map[string]int
+		maxMoves   int
+		moves      map[string]string
+		used2      map[string]int
+	}{
+		// lb-A exceeds 80 of 100 ports, moved to the emptiest lb until it is within the limit
+		{
+			candidates: []rebalanceCandidate{candidate("gs-0", "lb-A", 2), candidate("gs-1", "lb-A", 2), candidate("gs-2", "lb-A", 2)},
+			used:       map[string]int{"lb-A": 84, "lb-B": 10},
+			maxMoves:   10,
+			moves:      map[string]string{"gs-0": "lb-C", "gs-1": "lb-C"},
+			used2:      map[string]int{"lb-A": 80, "lb-B": 10, "lb-C": 4},
+		},
+		// limited by max moves
+		{
+			candidates: []rebalanceCandidate{candidate("gs-0", "lb-A", 2), candidate("gs-1", "lb-A", 2)},
+			used:       map[string]int{"lb-A": 90, "lb-B": 10, "lb-C": 20},
+			maxMoves:   1,
+			moves:      map[string]string{"gs-0": "lb-B"},
+			used2:      map[string]int{"lb-A": 88, "lb-B": 12, "lb-C": 20},
+		},
+		// the fullest lb goes first
+		{
+			candidates: []rebalanceCandidate{candidate("gs-0", "lb-A", 1), candidate("gs-1", "lb-B", 1)},
+			used:       map[string]int{"lb-A": 81, "lb-B": 90},
+			maxMoves:   1,
+			moves:      map[string]string{"gs-1": "lb-C"},
+			used2:      map[string]int{"lb-A": 81, "lb-B": 89, "lb-C": 1},
+		},
+		// no lb is within the limit after the move
+		{
+			candidates: []rebalanceCandidate{candidate("gs-0", "lb-A", 2)},
+			used:       map[string]int{"lb-A": 90, "lb-B": 79, "lb-C": 80},
+			maxMoves:   10,
+			moves:      map[string]string{},
+			used2:      map[string]int{"lb-A": 90, "lb-B": 79, "lb-C": 80},
+		},
+		// no lb exceeds the limit
+		{
+			candidates: []rebalanceCandidate{candidate("gs-0", "lb-A", 2)},
+			used:       map[string]int{"lb-A": 80},
+			maxMoves:   10,
+			moves:      map[string]string{},
+			used2:      map[string]int{"lb-A": 80},
+		},
+	}
+
+	for i, test := range tests {
+		moves := planRebalanceMoves(test.candidates, test.used, 100, 0.8, test.maxMoves)
+		actual := make(map[string]string, len(moves))
+		for _, move := range moves {
+			actual[move.candidate.name] = move.to
+		}
+		if !reflect.DeepEqual(actual, test.moves) {
+			t.Errorf("case %d: expect moves %v, but got %v", i, test.moves, actual)
+		}
+		if !reflect.DeepEqual(test.used, test.used2) {
+			t.Errorf("case %d: expect used ports %v, but got %v", i, test.used2, test.used)
+		}
+	}
+}
+
+func TestIsRebalanceable(t *testing.T) {
+	newPod := func(opsState gamekruiseiov1alpha1.OpsState, annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Labels:      map[string]string{gamekruiseiov1alpha1.GameServerOpsStateKey: string(opsState)},
+			Annotations: annotations,
+		}}
+	}
+	newSvc := func(annotations map[string]string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		}
+	}
+	tests := []struct {
+		pod      *corev1.Pod
+		svc      *corev1.Service
+		sc       *slbConfig
+		expected bool
+	}{
+		{
+			pod:      newPod(gamekruiseiov1alpha1.None, nil),
+			svc:      newSvc(map[string]string{SlbIdAnnotationKey: "lb-A"}),
+			sc:       &slbConfig{},
+			expected: true,
+		},
+		{
+			pod:      newPod(gamekruiseiov1alpha1.Allocated, nil),
+			svc:      newSvc(map[string]string{SlbIdAnnotationKey: "lb-A"}),
+			sc:       &slbConfig{},
+			expected: false,
+		},
+		{
+			pod:      newPod(gamekruiseiov1alpha1.WaitToDelete, nil),
+			svc:      newSvc(map[string]string{SlbIdAnnotationKey: "lb-A", SlbDrainStartTimeKey: "2024-01-01T00:00:00Z"}),
+			sc:       &slbConfig{},
+			expected: false,
+		},
+		{
+			pod:      newPod(gamekruiseiov1alpha1.None, map[string]string{"sessions": "3"}),
+			svc:      newSvc(map[string]string{SlbIdAnnotationKey: "lb-A"}),
+			sc:       &slbConfig{lBDrainSessionsField: "sessions"},
+			expected: false,
+		},
+		{
+			pod:      newPod(gamekruiseiov1alpha1.None, nil),
+			svc:      newSvc(nil),
+			sc:       &slbConfig{},
+			expected: false,
+		},
+	}
+
+	for i, test := range tests {
+		if actual := isRebalanceable(test.pod, test.svc, test.sc); actual != test.expected {
+			t.Errorf("case %d: expect %v, but got %v", i, test.expected, actual)
+		}
+	}
+}
+
+func TestSlbRebalance(t *testing.T) {
+	conf, _ := json.Marshal([]gamekruiseiov1alpha1.NetworkConfParams{
+		{Name: SlbIdsConfigName, Value: "lb-A,lb-B"},
+		{Name: PortProtocolsConfigName, Value: "80"},
+	})
+	var objs []client.Object
+	podAllocate := make(map[string]string)
+	for i, name := range []string{"gs-0", "gs-1", "gs-2"} {
+		opsState := gamekruiseiov1alpha1.None
+		if i == 0 {
+			opsState = gamekruiseiov1alpha1.Allocated
+		}
+		objs = append(objs,
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      name,
+				Labels: map[string]string{
+					gamekruiseiov1alpha1.GameServerOwnerGssKey: "gs",
+					gamekruiseiov1alpha1.GameServerOpsStateKey: string(opsState),
+				},
+				Annotations: map[string]string{
+					gamekruiseiov1alpha1.GameServerNetworkType: SlbNetwork,
+					gamekruiseiov1alpha1.GameServerNetworkConf: string(conf),
+				},
+			}},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: name, Annotations: map[string]string{SlbIdAnnotationKey: "lb-A"}},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			})
+		podAllocate["xxx/"+name] = "lb-A:" + []string{"500", "501", "502"}[i]
+	}
+	c := fake.NewClientBuilder().WithObjects(objs...).Build()
+	s := &SlbPlugin{minPort: 500, maxPort: 504, podAllocate: podAllocate}
+	s.cache = buildLbCache(s.podAllocate, s.minPort, s.maxPort)
+
+	// dry run changes nothing
+	result, err := s.Rebalance(c, context.Background(), cloudprovider.RebalanceOptions{MaxUtilization: 0.5, MaxMoves: 10, DryRun: true})
+	if err != nil {
+		t.Fatalf("failed to rebalance: %s", err.Error())
+	}
+	expectMoves := []cloudprovider.RebalanceMove{{Namespace: "xxx", Name: "gs-1", From: "lb-A", To: "lb-B"}}
+	expectUtilization := map[string]float64{"lb-A": 0.5, "lb-B": 0.25}
+	if !reflect.DeepEqual(result.Moves, expectMoves) || !reflect.DeepEqual(result.Utilization, expectUtilization) {
+		t.Errorf("expect moves %v with utilization %v, but got %v", expectMoves, expectUtilization, result)
+	}
+	if s.podAllocate["xxx/gs-1"] != "lb-A:501" {
+		t.Errorf("expect allocation unchanged by dry run, but got %s", s.podAllocate["xxx/gs-1"])
+	}
+
+	result, err = s.Rebalance(c, context.Background(), cloudprovider.RebalanceOptions{MaxUtilization: 0.5, MaxMoves: 10})
+	if err != nil {
+		t.Fatalf("failed to rebalance: %s", err.Error())
+	}
+	if !reflect.DeepEqual(result.Moves, expectMoves) {
+		t.Errorf("expect moves %v, but got %v", expectMoves, result.Moves)
+	}
+	if !strings.HasPrefix(s.podAllocate["xxx/gs-1"], "lb-B:") || s.cache["lb-A"][501] {
+		t.Errorf("expect gs-1 reallocated to lb-B, but got %s %v", s.podAllocate["xxx/gs-1"], s.cache)
+	}
+	err = c.Get(context.Background(), types.NamespacedName{Namespace: "xxx", Name: "gs-1"}, &corev1.Service{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("expect Service of gs-1 deleted, but got %v", err)
+	}
+
+	if _, err := s.Rebalance(c, context.Background(), cloudprovider.RebalanceOptions{MaxUtilization: 1.5, MaxMoves: 10}); err == nil {
+		t.Errorf("expect error of invalid max utilization")
+	}
+}
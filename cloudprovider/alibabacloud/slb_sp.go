@@ -50,7 +50,10 @@ type lbSpConfig struct {
 
 func (s *SlbSpPlugin) OnPodAdded(c client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, cperrors.PluginError) {
 	networkManager := utils.NewNetworkManager(pod, c)
-	podNetConfig := parseLbSpConfig(networkManager.GetNetworkConfig())
+	podNetConfig, err := parseLbSpConfig(networkManager.GetNetworkConfig())
+	if err != nil {
+		return pod, cperrors.NewPluginError(cperrors.ParameterError, err.Error())
+	}
 
 	lbId, err := s.getOrAllocate(podNetConfig, pod)
 	if err != nil {
@@ -87,7 +90,10 @@ func (s *SlbSpPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context
 		return pod, cperrors.ToPluginError(err, cperrors.InternalError)
 	}
 
-	podNetConfig := parseLbSpConfig(networkManager.GetNetworkConfig())
+	podNetConfig, err := parseLbSpConfig(networkManager.GetNetworkConfig())
+	if err != nil {
+		return pod, cperrors.NewPluginError(cperrors.ParameterError, err.Error())
+	}
 	podSlbId, err := s.getOrAllocate(podNetConfig, pod)
 	if err != nil {
 		return pod, cperrors.NewPluginError(cperrors.ParameterError, err.Error())
@@ -127,7 +133,8 @@ func (s *SlbSpPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context
 	}
 
 	// network not ready
-	if svc.Status.LoadBalancer.Ingress == nil {
+	lbIP, lbHostname := utils.GetLoadBalancerAddress(svc.Status.LoadBalancer.Ingress)
+	if lbIP == "" && lbHostname == "" {
 		return pod, nil
 	}
 
@@ -163,7 +170,8 @@ func (s *SlbSpPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context
 			},
 		}
 		externalAddress := gamekruiseiov1alpha1.NetworkAddress{
-			IP: svc.Status.LoadBalancer.Ingress[0].IP,
+			IP:       lbIP,
+			EndPoint: lbHostname,
 			Ports: []gamekruiseiov1alpha1.NetworkPort{
 				{
 					Name:     instrIPort.String(),
@@ -182,6 +190,20 @@ func (s *SlbSpPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context
 	return pod, cperrors.ToPluginError(err, cperrors.InternalError)
 }
 
+func (s *SlbSpPlugin) ValidateNetworkConf(conf []gamekruiseiov1alpha1.NetworkConfParams) error {
+	sc, err := parseLbSpConfig(conf)
+	if err != nil {
+		return err
+	}
+	if len(sc.lbIds) == 0 {
+		return fmt.Errorf("%s is required", SlbIdsConfigName)
+	}
+	if len(sc.ports) == 0 {
+		return fmt.Errorf("%s is required", PortProtocolsConfigName)
+	}
+	return nil
+}
+
 func (s *SlbSpPlugin) OnPodDeleted(c client.Client, pod *corev1.Pod, ctx context.Context) cperrors.PluginError {
 	s.deAllocate(pod.GetNamespace() + "/" + pod.GetName())
 	return nil
@@ -307,7 +329,7 @@ func (s *SlbSpPlugin) deAllocate(nsName string) {
 	delete(s.podSlbId, nsName)
 }
 
-func parseLbSpConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) *lbSpConfig {
+func parseLbSpConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) (*lbSpConfig, error) {
 	var lbIds []string
 	var ports []int
 	var protocols []corev1.Protocol
@@ -316,33 +338,18 @@ func parseLbSpConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) *lbSpConfig
 		case SlbIdsConfigName:
 			lbIds = parseLbIds(c.Value)
 		case PortProtocolsConfigName:
-			ports, protocols = parsePortProtocols(c.Value)
+			var err error
+			ports, protocols, err = utils.ParsePortProtocols(c.Value)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 	return &lbSpConfig{
 		lbIds:     lbIds,
 		ports:     ports,
 		protocols: protocols,
-	}
-}
-
-func parsePortProtocols(value string) ([]int, []corev1.Protocol) {
-	ports := make([]int, 0)
-	protocols := make([]corev1.Protocol, 0)
-	for _, pp := range strings.Split(value, ",") {
-		ppSlice := strings.Split(pp, "/")
-		port, err := strconv.Atoi(ppSlice[0])
-		if err != nil {
-			continue
-		}
-		ports = append(ports, port)
-		if len(ppSlice) != 2 {
-			protocols = append(protocols, corev1.ProtocolTCP)
-		} else {
-			protocols = append(protocols, corev1.Protocol(ppSlice[1]))
-		}
-	}
-	return ports, protocols
+	}, nil
 }
 
 func parseLbIds(value string) []string {
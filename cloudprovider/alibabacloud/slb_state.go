@@ -0,0 +1,297 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alibabacloud
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	log "k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+// slbStateBackoff retries the writes conflicting with other replicas for about 2.5 seconds, within the timeout of
+// the admission in which the ports are allocated.
+var slbStateBackoff = wait.Backoff{
+	Steps:    8,
+	Duration: 20 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+}
+
+// slbStateStore persists the port allocations of the SlbPlugin in a ConfigMap, so that the allocator
+// state stays consistent across controller restarts and replicas, even if Services are deleted out-of-band.
+// Each data key of the ConfigMap is a lb id, whose value records the ports allocated to each pod,
+// e.g. "default/gs-0=500,501;default/gs-1=502,503".
+// Writes carry the resourceVersion of the ConfigMap read, and are retried from a fresh read on conflicts.
+// The ConfigMap is expected to be read from the API server rather than the informer cache, see main.go.
+type slbStateStore struct {
+	namespace string
+	name      string
+}
+
+func newSlbStateStore(stateConfigMap string) (*slbStateStore, error) {
+	if stateConfigMap == "" {
+		return nil, nil
+	}
+	nsName := strings.Split(stateConfigMap, "/")
+	if len(nsName) != 2 || nsName[0] == "" || nsName[1] == "" {
+		return nil, fmt.Errorf("invalid state_configmap %s, which should be in the format of <namespace>/<name>", stateConfigMap)
+	}
+	return &slbStateStore{namespace: nsName[0], name: nsName[1]}, nil
+}
+
+// load returns the state ConfigMap and the pod allocations recorded in it.
+// A ConfigMap without resourceVersion is returned if it does not exist yet.
+func (st *slbStateStore) load(c client.Client, ctx context.Context) (*corev1.ConfigMap, map[string]string, error) {
+	cm := &corev1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: st.namespace, Name: st.name}, cm)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, nil, err
+		}
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: st.namespace,
+				Name:      st.name,
+			},
+		}
+	}
+	return cm, decodeSlbState(cm.Data), nil
+}
+
+// save writes the pod allocations into the state ConfigMap returned by load.
+// A conflict error is returned if the ConfigMap has been changed since then.
+func (st *slbStateStore) save(c client.Client, ctx context.Context, cm *corev1.ConfigMap, podAllocate map[string]string) error {
+	cm.Data = encodeSlbState(podAllocate)
+	if cm.ResourceVersion == "" {
+		err := c.Create(ctx, cm)
+		if errors.IsAlreadyExists(err) {
+			return errors.NewConflict(corev1.Resource("configmaps"), cm.Name, err)
+		}
+		return err
+	}
+	return c.Update(ctx, cm)
+}
+
+func encodeSlbState(podAllocate map[string]string) map[string]string {
+	lbAllocations := make(map[string][]string)
+	for podKey, allocatedPorts := range podAllocate {
+		slbPorts := strings.Split(allocatedPorts, ":")
+		if len(slbPorts) != 2 {
+			continue
+		}
+		lbAllocations[slbPorts[0]] = append(lbAllocations[slbPorts[0]], podKey+"="+slbPorts[1])
+	}
+	data := make(map[string]string, len(lbAllocations))
+	for lbId, allocations := range lbAllocations {
+		sort.Strings(allocations)
+		data[lbId] = strings.Join(allocations, ";")
+	}
+	return data
+}
+
+func decodeSlbState(data map[string]string) map[string]string {
+	podAllocate := make(map[string]string)
+	for lbId, allocations := range data {
+		for _, allocation := range strings.Split(allocations, ";") {
+			podPorts := strings.Split(allocation, "=")
+			if len(podPorts) != 2 || podPorts[0] == "" || podPorts[1] == "" {
+				continue
+			}
+			podAllocate[podPorts[0]] = lbId + ":" + podPorts[1]
+		}
+	}
+	return podAllocate
+}
+
+// buildLbCache rebuilds the per-lb port bitmap from the pod allocations.
+func buildLbCache(podAllocate map[string]string, minPort, maxPort int32) map[string]portAllocated {
+	newCache := make(map[string]portAllocated)
+	for _, allocatedPorts := range podAllocate {
+		slbPorts := strings.Split(allocatedPorts, ":")
+		lbId := slbPorts[0]
+		if newCache[lbId] == nil {
+			newCache[lbId] = make(portAllocated, maxPort-minPort)
+			for i := minPort; i < maxPort; i++ {
+				newCache[lbId][i] = false
+			}
+		}
+		for _, port := range util.StringToInt32Slice(slbPorts[1], ",") {
+			if port <= maxPort && port >= minPort {
+				newCache[lbId][port] = true
+			}
+		}
+	}
+	return newCache
+}
+
+// syncState replaces the in-memory allocator state with a copy of the pod allocations.
+func (s *SlbPlugin) syncState(podAllocate map[string]string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.podAllocate = make(map[string]string, len(podAllocate))
+	for podKey, allocatedPorts := range podAllocate {
+		s.podAllocate[podKey] = allocatedPorts
+	}
+	s.cache = buildLbCache(podAllocate, s.minPort, s.maxPort)
+}
+
+// init merges the allocations derived from Services into the state ConfigMap, drops the allocations of
+// the orphaned Services garbage-collected, and returns the merged allocations.
+func (st *slbStateStore) init(c client.Client, ctx context.Context, svcPodAllocate map[string]string, orphans []string) (map[string]string, error) {
+	var podAllocate map[string]string
+	err := retry.RetryOnConflict(slbStateBackoff, func() error {
+		var cm *corev1.ConfigMap
+		var err error
+		cm, podAllocate, err = st.load(c, ctx)
+		if err != nil {
+			return err
+		}
+		for _, podKey := range orphans {
+			delete(podAllocate, podKey)
+		}
+		for podKey, allocatedPorts := range svcPodAllocate {
+			podAllocate[podKey] = allocatedPorts
+		}
+		return st.save(c, ctx, cm, podAllocate)
+	})
+	return podAllocate, err
+}
+
+// allocatePorts allocates ports for the pod. If the state ConfigMap is configured, the allocation is made
+// against the latest persisted state and is persisted before the ports are returned.
+func (s *SlbPlugin) allocatePorts(c client.Client, ctx context.Context, lbIds []string, num int, nsName string, policy string) (string, []int32, error) {
+	if s.store == nil {
+		return s.allocate(lbIds, num, nsName, policy)
+	}
+
+	s.stateMutex.Lock()
+	defer s.stateMutex.Unlock()
+	var lbId string
+	var ports []int32
+	err := retry.RetryOnConflict(slbStateBackoff, func() error {
+		cm, podAllocate, err := s.store.load(c, ctx)
+		if err != nil {
+			return err
+		}
+		s.syncState(podAllocate)
+		if allocatedPorts, exist := podAllocate[nsName]; exist {
+			// allocated by another replica or before restart
+			slbPorts := strings.Split(allocatedPorts, ":")
+			lbId, ports = slbPorts[0], util.StringToInt32Slice(slbPorts[1], ",")
+			return nil
+		}
+		lbId, ports, err = s.allocate(lbIds, num, nsName, policy)
+		if err != nil {
+			return err
+		}
+		s.mutex.RLock()
+		podAllocate[nsName] = s.podAllocate[nsName]
+		s.mutex.RUnlock()
+		err = s.store.save(c, ctx, cm, podAllocate)
+		if err != nil {
+			// the state will be reloaded from the ConfigMap when retrying
+			s.deAllocate(nsName)
+		}
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return lbId, ports, nil
+}
+
+// deAllocatePorts releases the ports of the pods, and persists the release if the state ConfigMap is configured.
+func (s *SlbPlugin) deAllocatePorts(c client.Client, ctx context.Context, nsNames []string) error {
+	if s.store == nil {
+		for _, nsName := range nsNames {
+			s.deAllocate(nsName)
+		}
+		return nil
+	}
+
+	s.stateMutex.Lock()
+	defer s.stateMutex.Unlock()
+	return retry.RetryOnConflict(slbStateBackoff, func() error {
+		cm, podAllocate, err := s.store.load(c, ctx)
+		if err != nil {
+			return err
+		}
+		s.syncState(podAllocate)
+		released := false
+		for _, nsName := range nsNames {
+			if _, exist := podAllocate[nsName]; exist {
+				delete(podAllocate, nsName)
+				s.deAllocate(nsName)
+				released = true
+			}
+		}
+		if !released {
+			return nil
+		}
+		if err := s.store.save(c, ctx, cm, podAllocate); err != nil {
+			return err
+		}
+		log.Infof("[%s] pods %v released ports in state configmap %s/%s", SlbNetwork, nsNames, s.store.namespace, s.store.name)
+		return nil
+	})
+}
+
+// promoteGreenPorts replaces the ports allocated to the pod with the ports pre-provisioned for it, and persists the
+// replacement if the state ConfigMap is configured.
+func (s *SlbPlugin) promoteGreenPorts(c client.Client, ctx context.Context, nsName string, greenPorts string) error {
+	promote := func(podAllocate map[string]string) {
+		podAllocate[nsName] = greenPorts
+		delete(podAllocate, nsName+slbGreenKeySuffix)
+	}
+	if s.store == nil {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		promote(s.podAllocate)
+		s.cache = buildLbCache(s.podAllocate, s.minPort, s.maxPort)
+		log.Infof("pod %s promote slb ports %s", nsName, greenPorts)
+		return nil
+	}
+
+	s.stateMutex.Lock()
+	defer s.stateMutex.Unlock()
+	return retry.RetryOnConflict(slbStateBackoff, func() error {
+		cm, podAllocate, err := s.store.load(c, ctx)
+		if err != nil {
+			return err
+		}
+		promote(podAllocate)
+		if err := s.store.save(c, ctx, cm, podAllocate); err != nil {
+			return err
+		}
+		s.syncState(podAllocate)
+		log.Infof("[%s] pod %s promoted ports %s in state configmap %s/%s", SlbNetwork, nsName, greenPorts, s.store.namespace, s.store.name)
+		return nil
+	})
+}
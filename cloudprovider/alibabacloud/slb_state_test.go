@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package alibabacloud
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestEncodeDecodeSlbState(t *testing.T) {
+	podAllocate := map[string]string{
+		"default/gs-0": "lb-A:500,501",
+		"default/gs-1": "lb-A:502,503",
+		"default/gs-2": "lb-B:500,501",
+	}
+	data := encodeSlbState(podAllocate)
+	expectData := map[string]string{
+		"lb-A": "default/gs-0=500,501;default/gs-1=502,503",
+		"lb-B": "default/gs-2=500,501",
+	}
+	if !reflect.DeepEqual(data, expectData) {
+		t.Errorf("expect data %v but got %v", expectData, data)
+	}
+	if actual := decodeSlbState(data); !reflect.DeepEqual(actual, podAllocate) {
+		t.Errorf("expect podAllocate %v but got %v", podAllocate, actual)
+	}
+}
+
+func TestNewSlbStateStore(t *testing.T) {
+	tests := []struct {
+		stateConfigMap string
+		store          *slbStateStore
+		wantErr        bool
+	}{
+		{
+			stateConfigMap: "",
+		},
+		{
+			stateConfigMap: "kruise-game-system/kruise-game-slb-allocation",
+			store:          &slbStateStore{namespace: "kruise-game-system", name: "kruise-game-slb-allocation"},
+		},
+		{
+			stateConfigMap: "kruise-game-slb-allocation",
+			wantErr:        true,
+		},
+	}
+	for i, test := range tests {
+		store, err := newSlbStateStore(test.stateConfigMap)
+		if (err != nil) != test.wantErr {
+			t.Errorf("case %d: expect error %v but got %v", i, test.wantErr, err)
+		}
+		if !reflect.DeepEqual(store, test.store) {
+			t.Errorf("case %d: expect store %v but got %v", i, test.store, store)
+		}
+	}
+}
+
+func TestPersistedAllocation(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "kruise-game-system",
+			Name:      "kruise-game-slb-allocation",
+		},
+		Data: map[string]string{
+			"lb-A": "default/gs-0=500,501,502",
+		},
+	}).Build()
+	newPlugin := func() *SlbPlugin {
+		s := &SlbPlugin{
+			minPort: 500,
+			maxPort: 504,
+			store:   &slbStateStore{namespace: "kruise-game-system", name: "kruise-game-slb-allocation"},
+		}
+		podAllocate, err := s.store.init(c, ctx, map[string]string{}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.cache, s.podAllocate = buildLbCache(podAllocate, s.minPort, s.maxPort), podAllocate
+		return s
+	}
+	// two replicas sharing the same state
+	s1, s2 := newPlugin(), newPlugin()
+
+	lbId, ports, err := s1.allocatePorts(c, ctx, []string{"lb-A"}, 1, "default/gs-1", PortAllocationPolicyRandom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lbId != "lb-A" || !reflect.DeepEqual(ports, []int32{503}) {
+		t.Errorf("expect lb-A [503] but got %s %v", lbId, ports)
+	}
+	// s2 has not seen the allocation of s1 in memory, and should not allocate the same ports
+	lbId, ports, err = s2.allocatePorts(c, ctx, []string{"lb-A"}, 1, "default/gs-2", PortAllocationPolicyRandom)
+	if err == nil {
+		t.Errorf("expect no ports available but got %s %v", lbId, ports)
+	}
+	// s2 returns the ports allocated by s1
+	lbId, ports, err = s2.allocatePorts(c, ctx, []string{"lb-A"}, 1, "default/gs-1", PortAllocationPolicyRandom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lbId != "lb-A" || !reflect.DeepEqual(ports, []int32{503}) {
+		t.Errorf("expect lb-A [503] but got %s %v", lbId, ports)
+	}
+
+	if err := s2.deAllocatePorts(c, ctx, []string{"default/gs-0"}); err != nil {
+		t.Fatal(err)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "kruise-game-system", Name: "kruise-game-slb-allocation"}, cm); err != nil {
+		t.Fatal(err)
+	}
+	expectData := map[string]string{"lb-A": "default/gs-1=503"}
+	if !reflect.DeepEqual(cm.Data, expectData) {
+		t.Errorf("expect data %v but got %v", expectData, cm.Data)
+	}
+
+	// s1 allocates the ports released by s2
+	lbId, ports, err = s1.allocatePorts(c, ctx, []string{"lb-A"}, 3, "default/gs-2", PortAllocationPolicyRandom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+	if lbId != "lb-A" || !reflect.DeepEqual(ports, []int32{500, 501, 502}) {
+		t.Errorf("expect lb-A [500 501 502] but got %s %v", lbId, ports)
+	}
+}
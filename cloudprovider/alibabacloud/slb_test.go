@@ -17,13 +17,21 @@ limitations under the License.
 package alibabacloud
 
 import (
+	"context"
 	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	cperrors "github.com/openkruise/kruise-game/cloudprovider/errors"
+	provideroptions "github.com/openkruise/kruise-game/cloudprovider/options"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestAllocateDeAllocate(t *testing.T) {
@@ -45,7 +53,10 @@ func TestAllocateDeAllocate(t *testing.T) {
 		num:    3,
 	}
 
-	lbId, ports := test.slb.allocate(test.lbIds, test.num, test.podKey)
+	lbId, ports, err := test.slb.allocate(test.lbIds, test.num, test.podKey, PortAllocationPolicyRandom)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if _, exist := test.slb.podAllocate[test.podKey]; !exist {
 		t.Errorf("podAllocate[%s] is empty after allocated", test.podKey)
 	}
@@ -134,6 +145,7 @@ func TestParseLbConfig(t *testing.T) {
 			},
 			slbConfig: &slbConfig{
 				lbIds:                       []string{"xxx-A"},
+				externalLbs:                 map[string]string{},
 				targetPorts:                 []int{80},
 				protocols:                   []corev1.Protocol{corev1.ProtocolTCP},
 				isFixed:                     false,
@@ -148,6 +160,9 @@ func TestParseLbConfig(t *testing.T) {
 				lBHealthyThreshold:          "5",
 				lBUnhealthyThreshold:        "5",
 				lBHealthCheckProtocolPort:   "http:80",
+				lBDrainMode:                 "immediate",
+				lBDrainTimeout:              30,
+				portAllocationPolicy:        PortAllocationPolicyRandom,
 			},
 		},
 		{
@@ -164,9 +179,22 @@ func TestParseLbConfig(t *testing.T) {
 					Name:  FixedConfigName,
 					Value: "true",
 				},
+				{
+					Name:  LBDrainModeConfigName,
+					Value: "Graceful",
+				},
+				{
+					Name:  LBDrainTimeoutConfigName,
+					Value: "20",
+				},
+				{
+					Name:  ExternalLbsConfigName,
+					Value: "elb-a,elb-b",
+				},
 			},
 			slbConfig: &slbConfig{
 				lbIds:                       []string{"xxx-A", "xxx-B"},
+				externalLbs:                 map[string]string{"elb-a": "", "elb-b": ""},
 				targetPorts:                 []int{81, 82, 83},
 				protocols:                   []corev1.Protocol{corev1.ProtocolUDP, corev1.ProtocolTCP, corev1.ProtocolTCP},
 				isFixed:                     true,
@@ -181,6 +209,9 @@ func TestParseLbConfig(t *testing.T) {
 				lBHealthCheckDomain:         "",
 				lBHealthCheckMethod:         "",
 				lBHealthCheckProtocolPort:   "",
+				lBDrainMode:                 "graceful",
+				lBDrainTimeout:              20,
+				portAllocationPolicy:        PortAllocationPolicyRandom,
 			},
 		},
 	}
@@ -196,6 +227,110 @@ func TestParseLbConfig(t *testing.T) {
 	}
 }
 
+func TestValidateProtocols(t *testing.T) {
+	tests := []struct {
+		protocols []corev1.Protocol
+		expectErr string
+	}{
+		{
+			protocols: []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolUDP, "TCPUDP"},
+		},
+		{
+			protocols: []corev1.Protocol{corev1.ProtocolTCP, "HTTP"},
+			expectErr: `unsupported protocol "HTTP" in PortProtocols, which should be one of TCP, UDP, SCTP, TCPUDP`,
+		},
+	}
+	for i, test := range tests {
+		err := validateProtocols(test.protocols, slbProtocols)
+		actualErr := ""
+		if err != nil {
+			actualErr = err.Error()
+		}
+		if actualErr != test.expectErr {
+			t.Errorf("case %d: expect error %q, but actually got %q", i, test.expectErr, actualErr)
+		}
+	}
+
+	// the bad token is kept in the plugin error
+	_, err := parseLbConfig([]gamekruiseiov1alpha1.NetworkConfParams{{Name: PortProtocolsConfigName, Value: "80/%d"}})
+	if err == nil || !strings.Contains(cperrors.ToPluginError(err, cperrors.ParameterError).Error(), `"%d"`) {
+		t.Errorf("expect the error naming the protocol %%d, but actually got %v", err)
+	}
+}
+
+func TestResolveExternalLbs(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gamekruiseiov1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	elbs := []gamekruiseiov1alpha1.ExternalLoadBalancer{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "elb-a"},
+			Spec:       gamekruiseiov1alpha1.ExternalLoadBalancerSpec{ID: "lb-a"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "elb-b"},
+			Spec:       gamekruiseiov1alpha1.ExternalLoadBalancerSpec{ID: "lb-b"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "elb-dup"},
+			Spec:       gamekruiseiov1alpha1.ExternalLoadBalancerSpec{ID: "xxx-A"},
+		},
+	}
+
+	tests := []struct {
+		sc          *slbConfig
+		lbIds       []string
+		externalLbs map[string]string
+		isErr       bool
+	}{
+		{
+			sc: &slbConfig{
+				lbIds:       []string{"xxx-A"},
+				externalLbs: map[string]string{"elb-b": "", "elb-a": ""},
+			},
+			lbIds:       []string{"xxx-A", "lb-a", "lb-b"},
+			externalLbs: map[string]string{"lb-a": "elb-a", "lb-b": "elb-b"},
+		},
+		{
+			sc: &slbConfig{
+				lbIds:       []string{"xxx-A"},
+				externalLbs: map[string]string{"elb-dup": ""},
+			},
+			lbIds:       []string{"xxx-A"},
+			externalLbs: map[string]string{"xxx-A": "elb-dup"},
+		},
+		{
+			sc: &slbConfig{
+				externalLbs: map[string]string{"elb-not-exist": ""},
+			},
+			isErr: true,
+		},
+	}
+
+	for i, test := range tests {
+		builder := fake.NewClientBuilder().WithScheme(scheme)
+		for j := range elbs {
+			builder.WithObjects(&elbs[j])
+		}
+		c := builder.Build()
+		err := resolveExternalLbs(c, context.Background(), test.sc)
+		if (err != nil) != test.isErr {
+			t.Errorf("case %d: expect error %v, but actually got %v", i, test.isErr, err)
+			continue
+		}
+		if test.isErr {
+			continue
+		}
+		if !reflect.DeepEqual(test.lbIds, test.sc.lbIds) {
+			t.Errorf("case %d: expect lbIds %v, but actually got %v", i, test.lbIds, test.sc.lbIds)
+		}
+		if !reflect.DeepEqual(test.externalLbs, test.sc.externalLbs) {
+			t.Errorf("case %d: expect externalLbs %v, but actually got %v", i, test.externalLbs, test.sc.externalLbs)
+		}
+	}
+}
+
 func TestInitLbCache(t *testing.T) {
 	test := struct {
 		svcList     []corev1.Service
@@ -260,6 +395,11 @@ func TestInitLbCache(t *testing.T) {
 							Port:       555,
 							Protocol:   corev1.ProtocolTCP,
 						},
+						{
+							TargetPort: intstr.FromInt(8080),
+							Port:       555,
+							Protocol:   corev1.ProtocolUDP,
+						},
 					},
 				},
 			},
@@ -278,3 +418,422 @@ func TestInitLbCache(t *testing.T) {
 		t.Errorf("podAllocate expect %v, but actully got %v", test.podAllocate, actualPodAllocate)
 	}
 }
+
+func TestSlbReconfigure(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithObjects(
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "gs-0",
+				Labels:    map[string]string{SlbIdLabelKey: "lb-A"},
+			},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Port: 650, TargetPort: intstr.FromInt(80)}},
+			},
+		},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gs-0"}},
+	).Build()
+	s := &SlbPlugin{minPort: 600, maxPort: 800}
+	s.podAllocate = map[string]string{
+		"default/gs-0": "lb-A:650",
+		// the Service of gs-1 is not created yet
+		"default/gs-1": "lb-A:651",
+	}
+	s.cache = buildLbCache(s.podAllocate, s.minPort, s.maxPort)
+
+	options := provideroptions.AlibabaCloudOptions{SLBOptions: provideroptions.SLBOptions{MinPort: 500, MaxPort: 700}}
+	if err := s.Reconfigure(c, options, ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s.minPort != 500 || s.maxPort != 700 {
+		t.Errorf("expect port range 500-700, but actually got %d-%d", s.minPort, s.maxPort)
+	}
+	expectPodAllocate := map[string]string{"default/gs-0": "lb-A:650", "default/gs-1": "lb-A:651"}
+	if !reflect.DeepEqual(s.podAllocate, expectPodAllocate) {
+		t.Errorf("expect podAllocate %v, but actually got %v", expectPodAllocate, s.podAllocate)
+	}
+	if !s.cache["lb-A"][650] || !s.cache["lb-A"][651] {
+		t.Errorf("expect ports 650 and 651 allocated, but actually got %v", s.cache["lb-A"])
+	}
+	if allocated, ok := s.cache["lb-A"][500]; !ok || allocated {
+		t.Errorf("expect port 500 free in the new port range")
+	}
+}
+
+func TestSlbValidateNetworkConf(t *testing.T) {
+	s := &SlbPlugin{minPort: 500, maxPort: 502}
+	tests := []struct {
+		conf  []gamekruiseiov1alpha1.NetworkConfParams
+		valid bool
+	}{
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: SlbIdsConfigName, Value: "xxx-A"},
+				{Name: PortProtocolsConfigName, Value: "80,81/UDP"},
+			},
+			valid: true,
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: ExternalLbsConfigName, Value: "elb-a"},
+				{Name: PortProtocolsConfigName, Value: "80"},
+			},
+			valid: true,
+		},
+		// no lb
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: PortProtocolsConfigName, Value: "80"},
+			},
+			valid: false,
+		},
+		// no port
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: SlbIdsConfigName, Value: "xxx-A"},
+			},
+			valid: false,
+		},
+		// the ports exceed the port range
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: SlbIdsConfigName, Value: "xxx-A"},
+				{Name: PortProtocolsConfigName, Value: "80,81,82"},
+			},
+			valid: false,
+		},
+		// blue/green listeners without Fixed
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: SlbIdsConfigName, Value: "xxx-A"},
+				{Name: PortProtocolsConfigName, Value: "80"},
+				{Name: BlueGreenListenersConfigName, Value: "true"},
+			},
+			valid: false,
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: SlbIdsConfigName, Value: "xxx-A"},
+				{Name: PortProtocolsConfigName, Value: "80"},
+				{Name: FixedConfigName, Value: "true"},
+				{Name: BlueGreenListenersConfigName, Value: "true"},
+			},
+			valid: true,
+		},
+	}
+	for i, test := range tests {
+		if err := s.ValidateNetworkConf(test.conf); (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestIsDrainCompleted(t *testing.T) {
+	tests := []struct {
+		annotations    map[string]string
+		podAnnotations map[string]string
+		timeout        int
+		sessionsField  string
+		expect         bool
+	}{
+		{
+			annotations: nil,
+			timeout:     0,
+			expect:      false,
+		},
+		{
+			annotations: map[string]string{
+				SlbDrainStartTimeKey: time.Now().Format(time.RFC3339),
+			},
+			timeout: 60,
+			expect:  false,
+		},
+		{
+			annotations: map[string]string{
+				SlbDrainStartTimeKey: time.Now().Add(-2 * time.Minute).Format(time.RFC3339),
+			},
+			timeout: 60,
+			expect:  true,
+		},
+		{
+			annotations: map[string]string{
+				SlbDrainStartTimeKey: "invalid",
+			},
+			timeout: 60,
+			expect:  false,
+		},
+		{
+			annotations: map[string]string{
+				SlbDrainStartTimeKey: time.Now().Format(time.RFC3339),
+			},
+			podAnnotations: map[string]string{
+				gamekruiseiov1alpha1.GameServerCustomStatusPrefix + "sessions": "0",
+			},
+			timeout:       60,
+			sessionsField: "sessions",
+			expect:        true,
+		},
+		{
+			annotations: map[string]string{
+				SlbDrainStartTimeKey: time.Now().Format(time.RFC3339),
+			},
+			podAnnotations: map[string]string{
+				gamekruiseiov1alpha1.GameServerCustomStatusPrefix + "sessions": "3",
+			},
+			timeout:       60,
+			sessionsField: "sessions",
+			expect:        false,
+		},
+		{
+			annotations: map[string]string{
+				SlbDrainStartTimeKey: time.Now().Format(time.RFC3339),
+			},
+			podAnnotations: map[string]string{
+				gamekruiseiov1alpha1.GameServerCustomStatusPrefix + "sessions": "0",
+			},
+			timeout: 60,
+			expect:  false,
+		},
+		{
+			annotations: map[string]string{
+				SlbDrainStartTimeKey: time.Now().Format(time.RFC3339),
+			},
+			podAnnotations: map[string]string{
+				gamekruiseiov1alpha1.GameServerShutdownStateKey: string(gamekruiseiov1alpha1.ShutdownDrained),
+			},
+			timeout: 60,
+			expect:  true,
+		},
+		{
+			annotations: nil,
+			podAnnotations: map[string]string{
+				gamekruiseiov1alpha1.GameServerShutdownStateKey: string(gamekruiseiov1alpha1.ShutdownDrained),
+			},
+			timeout: 60,
+			expect:  false,
+		},
+	}
+
+	for i, test := range tests {
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: test.annotations,
+			},
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: test.podAnnotations,
+			},
+		}
+		actual := isDrainCompleted(svc, pod, test.timeout, test.sessionsField)
+		if actual != test.expect {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.expect, actual)
+		}
+	}
+}
+
+func TestSetDrainUntil(t *testing.T) {
+	start := time.Now().Truncate(time.Second)
+	tests := []struct {
+		startTime string
+		timeout   int
+		expect    string
+	}{
+		{
+			startTime: start.Format(time.RFC3339),
+			timeout:   300,
+			expect:    start.Add(300 * time.Second).Format(time.RFC3339),
+		},
+		{
+			startTime: "invalid",
+			timeout:   300,
+			expect:    "",
+		},
+	}
+
+	for i, test := range tests {
+		pod := setDrainUntil(&corev1.Pod{}, test.startTime, test.timeout)
+		if actual := pod.GetAnnotations()[gamekruiseiov1alpha1.GameServerNetworkDrainUntil]; actual != test.expect {
+			t.Errorf("case %d: expect %q, but actually got %q", i, test.expect, actual)
+		}
+	}
+
+	// the drain timeout is bounded
+	for _, timeout := range []string{"-1", "3601"} {
+		if _, err := parseLbConfig([]gamekruiseiov1alpha1.NetworkConfParams{{Name: LBDrainTimeoutConfigName, Value: timeout}}); err == nil {
+			t.Errorf("expect the lb drain timeout %s rejected", timeout)
+		}
+	}
+}
+
+func TestLbCapacity(t *testing.T) {
+	cache := map[string]portAllocated{
+		"lb-a": {500: true, 501: true, 502: false, 503: false, 504: false},
+	}
+	tests := []struct {
+		lbIds    []string
+		budgets  map[string]int32
+		num      int
+		expected int
+	}{
+		{lbIds: []string{"lb-a"}, num: 1, expected: 8},
+		{lbIds: []string{"lb-a"}, num: 3, expected: 2},
+		// lb-b has no port allocated
+		{lbIds: []string{"lb-a", "lb-b"}, num: 3, expected: 5},
+		{lbIds: []string{"lb-a"}, num: 11, expected: 0},
+		// 2 ports of the budget of lb-a are allocated
+		{lbIds: []string{"lb-a"}, budgets: map[string]int32{"lb-a": 5}, num: 1, expected: 3},
+		{lbIds: []string{"lb-a", "lb-b"}, budgets: map[string]int32{"lb-a": 2, "lb-b": 4}, num: 2, expected: 2},
+	}
+	for i, test := range tests {
+		if actual := lbCapacity(cache, test.budgets, test.lbIds, test.num, 500, 510); actual != test.expected {
+			t.Errorf("case %d: expect capacity %d, but actually got %d", i, test.expected, actual)
+		}
+	}
+}
+
+func TestAllocateSpillover(t *testing.T) {
+	slb := &SlbPlugin{
+		maxPort:     int32(504),
+		minPort:     int32(500),
+		cache:       make(map[string]portAllocated),
+		podAllocate: make(map[string]string),
+		mutex:       sync.RWMutex{},
+	}
+	lbIds := []string{"lb-a", "lb-b"}
+	expectLbIds := []string{"lb-a", "lb-a", "lb-b", "lb-b", ""}
+	for i, expect := range expectLbIds {
+		lbId, _, _ := slb.allocate(lbIds, 2, "default/gs-"+strconv.Itoa(i), PortAllocationPolicyRandom)
+		if lbId != expect {
+			t.Errorf("pod %d: expect lb %q, but actually got %q", i, expect, lbId)
+		}
+	}
+
+	// ports released by lb-a are allocated before lb-b
+	slb.deAllocate("default/gs-0")
+	slb.deAllocate("default/gs-2")
+	if lbId, _, _ := slb.allocate(lbIds, 2, "default/gs-5", PortAllocationPolicyRandom); lbId != "lb-a" {
+		t.Errorf("expect lb %q, but actually got %q", "lb-a", lbId)
+	}
+}
+
+func TestAllocatePortBudget(t *testing.T) {
+	slb := &SlbPlugin{
+		maxPort:     int32(510),
+		minPort:     int32(500),
+		cache:       make(map[string]portAllocated),
+		podAllocate: make(map[string]string),
+		portBudgets: map[string]int32{"lb-a": 3},
+	}
+	lbIds := []string{"lb-a", "lb-b"}
+	// lb-a spills over to lb-b when its budget is not enough, though it has free ports
+	expectLbIds := []string{"lb-a", "lb-b"}
+	for i, expect := range expectLbIds {
+		lbId, _, err := slb.allocate(lbIds, 2, "default/gs-"+strconv.Itoa(i), PortAllocationPolicyRandom)
+		if err != nil || lbId != expect {
+			t.Errorf("pod %d: expect lb %q, but actually got %q, %v", i, expect, lbId, err)
+		}
+	}
+	if _, _, err := slb.allocate([]string{"lb-a"}, 2, "default/gs-2", PortAllocationPolicyRandom); err == nil {
+		t.Errorf("expect the budget of lb-a exhausted")
+	}
+}
+
+func FuzzParseLbConfig(f *testing.F) {
+	f.Add("lb-xxa,lb-xxb", "80/TCP,7777/UDP", "true", "5")
+	f.Add("", "80", "false", "")
+	f.Add(" lb-xxa ", " 80 , 80/UDP ", "yes", "0")
+	f.Add(",", "80,80", "", "301")
+	f.Add("lb-xxa", "80/tcp", "1", "abc")
+	f.Fuzz(func(t *testing.T, lbIds, portProtocols, fixed, connectTimeout string) {
+		conf := []gamekruiseiov1alpha1.NetworkConfParams{
+			{Name: SlbIdsConfigName, Value: lbIds},
+			{Name: PortProtocolsConfigName, Value: portProtocols},
+			{Name: FixedConfigName, Value: fixed},
+		}
+		if connectTimeout != "" {
+			conf = append(conf, gamekruiseiov1alpha1.NetworkConfParams{Name: LBHealthCheckConnectTimeoutConfigName, Value: connectTimeout})
+		}
+		sc, err := parseLbConfig(conf)
+		if err != nil {
+			return
+		}
+		if len(sc.targetPorts) == 0 || len(sc.targetPorts) != len(sc.protocols) {
+			t.Fatalf("unexpected target ports %v and protocols %v of %q", sc.targetPorts, sc.protocols, portProtocols)
+		}
+		for _, lbId := range sc.lbIds {
+			if lbId == "" {
+				t.Fatalf("unexpected empty lb id of %q", lbIds)
+			}
+		}
+	})
+}
+
+func TestSlbConfigHash(t *testing.T) {
+	tests := []struct {
+		conf        []gamekruiseiov1alpha1.NetworkConfParams
+		expectEqual bool
+	}{
+		// the hash of the network config existing before upgrading
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: SlbIdsConfigName, Value: "xxx-A"},
+				{Name: PortProtocolsConfigName, Value: "80"},
+			},
+			expectEqual: true,
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: SlbIdsConfigName, Value: "xxx-A"},
+				{Name: PortProtocolsConfigName, Value: "80"},
+				{Name: LBDrainModeConfigName, Value: LBDrainModeGraceful},
+			},
+			expectEqual: false,
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: SlbIdsConfigName, Value: "xxx-A"},
+				{Name: PortProtocolsConfigName, Value: "80"},
+				{Name: ExternalLbsConfigName, Value: "elb-a"},
+			},
+			expectEqual: false,
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: SlbIdsConfigName, Value: "xxx-A"},
+				{Name: PortProtocolsConfigName, Value: "80"},
+				{Name: PortAllocationPolicyConfigName, Value: PortAllocationPolicyOrdinal},
+			},
+			expectEqual: false,
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: SlbIdsConfigName, Value: "xxx-A"},
+				{Name: PortProtocolsConfigName, Value: "80"},
+				{Name: gamekruiseiov1alpha1.IPFamiliesNetworkConfName, Value: "IPv4,IPv6"},
+				{Name: gamekruiseiov1alpha1.IPFamilyPolicyNetworkConfName, Value: "RequireDualStack"},
+			},
+			expectEqual: false,
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: SlbIdsConfigName, Value: "xxx-A"},
+				{Name: PortProtocolsConfigName, Value: "80"},
+				{Name: FixedConfigName, Value: "true"},
+				{Name: BlueGreenListenersConfigName, Value: "true"},
+			},
+			expectEqual: false,
+		},
+	}
+	for i, test := range tests {
+		sc, err := parseLbConfig(test.conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := sc.hash() == "3138438929"; actual != test.expectEqual {
+			t.Errorf("case %d: expect hash equal to the former one %v, but actually got %s", i, test.expectEqual, sc.hash())
+		}
+	}
+}
@@ -412,6 +412,14 @@ func (n *NlbPlugin) OnPodDeleted(client client.Client, pod *corev1.Pod, ctx cont
 		if err == nil && gss.GetDeletionTimestamp() == nil {
 			return nil
 		}
+		// identity of gss is retained, do not deAllocate.
+		retained, err := util.IsServiceRetained(pod, client, ctx)
+		if err != nil {
+			return cperrors.ToPluginError(err, cperrors.ApiCallError)
+		}
+		if retained {
+			return nil
+		}
 		// gss not exists in cluster, deAllocate all the ports related to it.
 		for key := range n.podAllocate {
 			gssName := pod.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]
@@ -0,0 +1,208 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azure
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+func newLbPlugin(minPort, maxPort int32) *LbPlugin {
+	return &LbPlugin{
+		minPort:     minPort,
+		maxPort:     maxPort,
+		cache:       make(map[string]portAllocated),
+		podAllocate: make(map[string]string),
+		mutex:       sync.RWMutex{},
+	}
+}
+
+func TestAllocateDeAllocate(t *testing.T) {
+	plugin := newLbPlugin(500, 503)
+	ips := []string{"1.1.1.1", "2.2.2.2"}
+
+	ip, ports, err := plugin.allocate(ips, 2, "xxx/case-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip != "1.1.1.1" || !reflect.DeepEqual(ports, []int32{500, 501}) {
+		t.Errorf("expect 1.1.1.1 [500 501], but actually got %s %v", ip, ports)
+	}
+
+	// the first ip has not enough ports
+	ip, ports, err = plugin.allocate(ips, 2, "xxx/case-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip != "2.2.2.2" || !reflect.DeepEqual(ports, []int32{500, 501}) {
+		t.Errorf("expect 2.2.2.2 [500 501], but actually got %s %v", ip, ports)
+	}
+
+	if _, _, err = plugin.allocate(ips, 2, "xxx/case-2"); err == nil {
+		t.Errorf("expect error when ports are exhausted")
+	}
+
+	plugin.deAllocate("xxx/case-0")
+	if _, exist := plugin.podAllocate["xxx/case-0"]; exist {
+		t.Errorf("podAllocate[xxx/case-0] exists after deallocated")
+	}
+	ip, ports, err = plugin.allocate(ips, 2, "xxx/case-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip != "1.1.1.1" || !reflect.DeepEqual(ports, []int32{500, 501}) {
+		t.Errorf("expect 1.1.1.1 [500 501], but actually got %s %v", ip, ports)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	plugin := newLbPlugin(500, 510)
+	if _, _, err := plugin.allocate([]string{"1.1.1.1"}, 3, "xxx/case-0"); err != nil {
+		t.Fatal(err)
+	}
+	conf := []gamekruiseiov1alpha1.NetworkConfParams{
+		{Name: IPsConfigName, Value: "1.1.1.1,2.2.2.2"},
+		{Name: PortProtocolsConfigName, Value: "80/TCP,81/UDP"},
+	}
+	capacity, err := plugin.Capacity(nil, conf, context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 7 free ports of 1.1.1.1 and 10 free ports of 2.2.2.2
+	if capacity != 3+5 {
+		t.Errorf("expect capacity 8, but actually got %d", capacity)
+	}
+}
+
+func TestParseLbConfig(t *testing.T) {
+	tests := []struct {
+		conf     []gamekruiseiov1alpha1.NetworkConfParams
+		lbConfig *lbConfig
+	}{
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: IPsConfigName, Value: "1.1.1.1,2.2.2.2"},
+				{Name: PortProtocolsConfigName, Value: "80,81/UDP"},
+				{Name: ResourceGroupConfigName, Value: "rg-game"},
+				{Name: FixedConfigName, Value: "true"},
+				{Name: LbAnnotations, Value: "service.beta.kubernetes.io/azure-load-balancer-tcp-idle-timeout:30"},
+			},
+			lbConfig: &lbConfig{
+				ips:           []string{"1.1.1.1", "2.2.2.2"},
+				resourceGroup: "rg-game",
+				targetPorts:   []int{80, 81},
+				protocols:     []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolUDP},
+				isFixed:       true,
+				annotations:   map[string]string{"service.beta.kubernetes.io/azure-load-balancer-tcp-idle-timeout": "30"},
+			},
+		},
+	}
+	for i, test := range tests {
+		actual := parseLbConfig(test.conf)
+		if !reflect.DeepEqual(actual, test.lbConfig) {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.lbConfig, actual)
+		}
+	}
+}
+
+func TestInitLbCache(t *testing.T) {
+	svcList := []corev1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "xxx",
+				Name:        "case-0",
+				Annotations: map[string]string{LbIPAnnotationKey: "1.1.1.1"},
+			},
+			Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 501}, {Port: 502}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "other"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 503}}},
+		},
+	}
+	cache, podAllocate := initLbCache(svcList, 500, 510)
+	if !cache["1.1.1.1"][501] || !cache["1.1.1.1"][502] || cache["1.1.1.1"][503] {
+		t.Errorf("unexpected cache %v", cache)
+	}
+	if !reflect.DeepEqual(podAllocate, map[string]string{"xxx/case-0": "1.1.1.1:501,502"}) {
+		t.Errorf("unexpected podAllocate %v", podAllocate)
+	}
+}
+
+func TestConsSvc(t *testing.T) {
+	plugin := newLbPlugin(500, 510)
+	config := &lbConfig{
+		ips:           []string{"1.1.1.1"},
+		resourceGroup: "rg-game",
+		targetPorts:   []int{80},
+		protocols:     []corev1.Protocol{corev1.ProtocolUDP},
+		annotations:   map[string]string{},
+	}
+	pod := &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0", UID: "uid-pod"},
+	}
+	svc, err := plugin.consSvc(config, pod, nil, context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "xxx",
+			Name:      "case-0",
+			Labels:    map[string]string{gamekruiseiov1alpha1.GameServerNetworkType: LbNetwork},
+			Annotations: map[string]string{
+				LbIPv4AnnotationKey:          "1.1.1.1",
+				LbIPAnnotationKey:            "1.1.1.1",
+				LbResourceGroupAnnotationKey: "rg-game",
+				LbConfigHashKey:              util.GetHash(config),
+			},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion:         "v1",
+				Kind:               "Pod",
+				Name:               "case-0",
+				UID:                "uid-pod",
+				Controller:         ptr.To[bool](true),
+				BlockOwnerDeletion: ptr.To[bool](true),
+			}},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:                  corev1.ServiceTypeLoadBalancer,
+			ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+			Selector:              map[string]string{SvcSelectorKey: "case-0"},
+			Ports: []corev1.ServicePort{{
+				Name:       "80",
+				Port:       500,
+				Protocol:   corev1.ProtocolUDP,
+				TargetPort: intstr.FromInt(80),
+			}},
+		},
+	}
+	if !reflect.DeepEqual(svc, expected) {
+		t.Errorf("expect %v, but actually got %v", expected, svc)
+	}
+}
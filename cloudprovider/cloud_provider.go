@@ -18,6 +18,7 @@ package cloudprovider
 
 import (
 	"context"
+	"github.com/openkruise/kruise-game/apis/v1alpha1"
 	"github.com/openkruise/kruise-game/cloudprovider/errors"
 	corev1 "k8s.io/api/core/v1"
 	client "sigs.k8s.io/controller-runtime/pkg/client"
@@ -43,6 +44,66 @@ type Plugin interface {
 	OnPodDeleted(client client.Client, pod *corev1.Pod, ctx context.Context) errors.PluginError
 }
 
+// CapacityPlugin is implemented by the plugins which allocate ports from load balancers shared by GameServerSets,
+// so that the scale-up beyond the remaining ports can be rejected at admission.
+type CapacityPlugin interface {
+	// Capacity returns the number of pods with the network conf which can still be allocated ports.
+	Capacity(client client.Client, conf []v1alpha1.NetworkConfParams, ctx context.Context) (int, error)
+}
+
+// ReconfigurablePlugin is implemented by the plugins whose options can be changed without restarting the manager.
+type ReconfigurablePlugin interface {
+	// Reconfigure applies the new options of the cloud provider, rebuilding the state derived from the options,
+	// such as the port cache of the port range.
+	Reconfigure(client client.Client, options CloudProviderOptions, ctx context.Context) error
+}
+
+// NetworkConfValidator is implemented by the plugins which parse the network conf strictly, so that the invalid
+// network conf is rejected at admission rather than failing when the pods are reconciled.
+type NetworkConfValidator interface {
+	// ValidateNetworkConf returns the error parsing the network conf, or nil if it is valid.
+	ValidateNetworkConf(conf []v1alpha1.NetworkConfParams) error
+}
+
+// SweepablePlugin is implemented by the plugins which record the ports allocated to pods, so that the allocations
+// left stale by crashes can be removed periodically.
+type SweepablePlugin interface {
+	// Sweep removes the allocations which are no longer backed by the Services or the pods.
+	Sweep(client client.Client, ctx context.Context) error
+}
+
+// RebalanceablePlugin is implemented by the plugins sharing the ports of multiple load balancers among pods, which
+// migrate the Services of idle pods from the fullest load balancers to the emptier ones on demand.
+type RebalanceablePlugin interface {
+	// Rebalance migrates the idle pods until the port utilization of each load balancer is within the options.
+	Rebalance(client client.Client, ctx context.Context, opts RebalanceOptions) (*RebalanceResult, error)
+}
+
+type RebalanceOptions struct {
+	// Namespace and GameServerSet select the pods to migrate. All pods are selected if they are empty.
+	Namespace     string
+	GameServerSet string
+	// MaxUtilization is the upper bound of the ratio of the allocated ports of each load balancer, in (0, 1].
+	MaxUtilization float64
+	// MaxMoves is the maximum number of pods migrated at once.
+	MaxMoves int
+	// DryRun only plans the migrations.
+	DryRun bool
+}
+
+type RebalanceMove struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+}
+
+type RebalanceResult struct {
+	Moves []RebalanceMove `json:"moves"`
+	// Utilization is the ratio of the allocated ports of each load balancer after the migrations.
+	Utilization map[string]float64 `json:"utilization"`
+}
+
 type CloudProvider interface {
 	Name() string
 	ListPlugins() (map[string]Plugin, error)
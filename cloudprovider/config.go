@@ -22,12 +22,24 @@ import (
 	"k8s.io/klog/v2"
 )
 
-import "flag"
+import (
+	"flag"
+	"os"
+	"time"
+)
 
 var Opt *Options
 
 type Options struct {
 	CloudProviderConfigFile string
+	// CloudProviderConfigReloadInterval is the interval of checking the changes of the config file, 0 disables it.
+	CloudProviderConfigReloadInterval time.Duration
+	// AllocationSweepInterval is the interval of removing the stale port allocations of the plugins, 0 disables it.
+	AllocationSweepInterval time.Duration
+	// EnableNetworkRebalanceAPI serves the endpoint rebalancing the ports across the load balancers on the leader.
+	EnableNetworkRebalanceAPI bool
+	// NetworkRebalanceBindAddress is the address the endpoint rebalancing the ports binds to.
+	NetworkRebalanceBindAddress string
 }
 
 func init() {
@@ -36,6 +48,10 @@ func init() {
 
 func InitCloudProviderFlags() {
 	flag.StringVar(&Opt.CloudProviderConfigFile, "provider-config", "/etc/kruise-game/config.toml", "Cloud Provider Config File Path.")
+	flag.DurationVar(&Opt.CloudProviderConfigReloadInterval, "provider-config-reload-interval", 30*time.Second, "The interval of checking the changes of the Cloud Provider Config File, which are applied to the plugins without restarting. 0 disables the reloading.")
+	flag.DurationVar(&Opt.AllocationSweepInterval, "allocation-sweep-interval", 10*time.Minute, "The interval of removing the port allocations of the plugins which are no longer backed by Services or pods. 0 disables the sweeping.")
+	flag.BoolVar(&Opt.EnableNetworkRebalanceAPI, "enable-network-rebalance-api", false, "Serve the endpoint /network/rebalance over TLS on the elected leader, which migrates the idle GameServers from the busy load balancers to the idle ones.")
+	flag.StringVar(&Opt.NetworkRebalanceBindAddress, "network-rebalance-bind-address", ":9445", "The address the endpoint /network/rebalance binds to.")
 }
 
 type ConfigFile struct {
@@ -47,6 +63,10 @@ type CloudProviderConfig struct {
 	AlibabaCloudOptions       CloudProviderOptions
 	VolcengineOptions         CloudProviderOptions
 	AmazonsWebServicesOptions CloudProviderOptions
+	GoogleCloudOptions        CloudProviderOptions
+	AzureOptions              CloudProviderOptions
+	HwCloudOptions            CloudProviderOptions
+	ExternalOptions           CloudProviderOptions
 }
 
 type tomlConfigs struct {
@@ -54,13 +74,29 @@ type tomlConfigs struct {
 	AlibabaCloud       options.AlibabaCloudOptions       `toml:"alibabacloud"`
 	Volcengine         options.VolcengineOptions         `toml:"volcengine"`
 	AmazonsWebServices options.AmazonsWebServicesOptions `toml:"aws"`
+	GoogleCloud        options.GoogleCloudOptions        `toml:"googlecloud"`
+	Azure              options.AzureOptions              `toml:"azure"`
+	HwCloud            options.HwCloudOptions            `toml:"hwcloud"`
+	External           options.ExternalOptions           `toml:"external"`
 }
 
 func (cf *ConfigFile) Parse() *CloudProviderConfig {
+	data, err := os.ReadFile(cf.Path)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	config, err := ParseConfig(data)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	return config
+}
 
+// ParseConfig parses the content of the config file.
+func ParseConfig(data []byte) (*CloudProviderConfig, error) {
 	var config tomlConfigs
-	if _, err := toml.DecodeFile(cf.Path, &config); err != nil {
-		klog.Fatal(err)
+	if _, err := toml.Decode(string(data), &config); err != nil {
+		return nil, err
 	}
 
 	return &CloudProviderConfig{
@@ -68,7 +104,11 @@ func (cf *ConfigFile) Parse() *CloudProviderConfig {
 		AlibabaCloudOptions:       config.AlibabaCloud,
 		VolcengineOptions:         config.Volcengine,
 		AmazonsWebServicesOptions: config.AmazonsWebServices,
-	}
+		GoogleCloudOptions:        config.GoogleCloud,
+		AzureOptions:              config.Azure,
+		HwCloudOptions:            config.HwCloud,
+		ExternalOptions:           config.External,
+	}, nil
 }
 
 func NewConfigFile(path string) *ConfigFile {
@@ -6,6 +6,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestParse(t *testing.T) {
@@ -68,3 +69,36 @@ enable = true
 		os.Remove(tempFile)
 	}
 }
+
+func TestParseExternal(t *testing.T) {
+	config, err := ParseConfig([]byte(`
+[external]
+enable = true
+
+	[[external.plugins]]
+	name = "Corp-LB"
+	address = "unix:///var/run/kruise-game/corp-lb.sock"
+	timeout = "3s"
+	retries = 0
+
+		[external.plugins.options]
+		region = "dc-1"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	retries := 0
+	expected := options.ExternalOptions{
+		Enable: true,
+		Plugins: []options.ExternalPluginOptions{{
+			Name:    "Corp-LB",
+			Address: "unix:///var/run/kruise-game/corp-lb.sock",
+			Timeout: 3 * time.Second,
+			Retries: &retries,
+			Options: map[string]string{"region": "dc-1"},
+		}},
+	}
+	if !reflect.DeepEqual(config.ExternalOptions, expected) {
+		t.Errorf("expect ExternalOptions: %v, but got %v", expected, config.ExternalOptions)
+	}
+}
@@ -16,13 +16,17 @@ limitations under the License.
 
 package errors
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"strings"
+)
 
 // PluginErrorType describes a high-level category of a given error
 type PluginErrorType string
 
 const (
-	// ApiCallError is an error related to communication with k8s API server
+	// ApiCallError is an error related to communication with k8s API server or cloud APIs
 	ApiCallError PluginErrorType = "apiCallError"
 	// InternalError is an error inside plugin
 	InternalError PluginErrorType = "internalError"
@@ -40,9 +44,39 @@ type PluginError interface {
 	Type() PluginErrorType
 }
 
+// TracedPluginError is the PluginError carrying the failing API operation and the ID of the request in which it
+// occurs. It is optional for the plugins, and detected by a type assertion, e.g. in OperationOf and CorrelationIDOf.
+type TracedPluginError interface {
+	PluginError
+
+	// Operation returns the failing API operation, e.g. create Service default/gss-0, or empty if unknown
+	Operation() string
+
+	// CorrelationID returns the ID of the request in which the error occurs, or empty if unknown
+	CorrelationID() string
+}
+
+// OperationOf returns the failing API operation of the plugin error, or empty if it is not a TracedPluginError.
+func OperationOf(err PluginError) string {
+	if traced, ok := err.(TracedPluginError); ok {
+		return traced.Operation()
+	}
+	return ""
+}
+
+// CorrelationIDOf returns the correlation ID of the plugin error, or empty if it is not a TracedPluginError.
+func CorrelationIDOf(err PluginError) string {
+	if traced, ok := err.(TracedPluginError); ok {
+		return traced.CorrelationID()
+	}
+	return ""
+}
+
 type pluginErrorImplErrorImpl struct {
-	errorType PluginErrorType
-	msg       string
+	errorType     PluginErrorType
+	msg           string
+	operation     string
+	correlationID string
 }
 
 func (c pluginErrorImplErrorImpl) Error() string {
@@ -53,6 +87,14 @@ func (c pluginErrorImplErrorImpl) Type() PluginErrorType {
 	return c.errorType
 }
 
+func (c pluginErrorImplErrorImpl) Operation() string {
+	return c.operation
+}
+
+func (c pluginErrorImplErrorImpl) CorrelationID() string {
+	return c.correlationID
+}
+
 // NewPluginError returns new plugin error with a message constructed from format string
 func NewPluginError(errorType PluginErrorType, msg string, args ...interface{}) PluginError {
 	return pluginErrorImplErrorImpl{
@@ -70,3 +112,59 @@ func ToPluginError(err error, errorType PluginErrorType) PluginError {
 		msg:       err.Error(),
 	}
 }
+
+// WithOperation returns the plugin error with the failing API operation, unless it is known already.
+func WithOperation(err PluginError, operation string) PluginError {
+	if err == nil || OperationOf(err) != "" {
+		return err
+	}
+	return pluginErrorImplErrorImpl{
+		errorType:     err.Type(),
+		msg:           err.Error(),
+		operation:     operation,
+		correlationID: CorrelationIDOf(err),
+	}
+}
+
+// WithCorrelationID returns the plugin error with the ID of the request in which it occurs.
+func WithCorrelationID(err PluginError, correlationID string) PluginError {
+	if err == nil {
+		return nil
+	}
+	return pluginErrorImplErrorImpl{
+		errorType:     err.Type(),
+		msg:           err.Error(),
+		operation:     OperationOf(err),
+		correlationID: correlationID,
+	}
+}
+
+// Describe returns the message of the plugin error followed by its operation and correlation ID, which is used in
+// the events and logs, so that they can be traced to each other and to the audit logs.
+func Describe(err PluginError) string {
+	var details []string
+	if operation := OperationOf(err); operation != "" {
+		details = append(details, "operation: "+operation)
+	}
+	if correlationID := CorrelationIDOf(err); correlationID != "" {
+		details = append(details, "correlation ID: "+correlationID)
+	}
+	if len(details) == 0 {
+		return err.Error()
+	}
+	return fmt.Sprintf("%s (%s)", err.Error(), strings.Join(details, ", "))
+}
+
+type correlationIDKey struct{}
+
+// NewContextWithCorrelationID returns the context carrying the ID of the request, such as the UID of the admission
+// request, which is passed to the plugins and the out-of-tree plugins.
+func NewContextWithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationIDFromContext returns the ID of the request carried by the context, or empty if there is none.
+func CorrelationIDFromContext(ctx context.Context) string {
+	correlationID, _ := ctx.Value(correlationIDKey{}).(string)
+	return correlationID
+}
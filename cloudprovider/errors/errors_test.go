@@ -0,0 +1,184 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDescribe(t *testing.T) {
+	err := NewPluginError(ApiCallError, "services %q already exists", "gss-0")
+	tests := []struct {
+		err    PluginError
+		expect string
+	}{
+		{
+			err:    err,
+			expect: `services "gss-0" already exists`,
+		},
+		{
+			err:    WithOperation(err, "create Service default/gss-0"),
+			expect: `services "gss-0" already exists (operation: create Service default/gss-0)`,
+		},
+		{
+			err:    WithCorrelationID(WithOperation(err, "create Service default/gss-0"), "uid-1"),
+			expect: `services "gss-0" already exists (operation: create Service default/gss-0, correlation ID: uid-1)`,
+		},
+		// the operation known is kept
+		{
+			err:    WithOperation(WithOperation(err, "create Service default/gss-0"), "get Pod default/gss-0"),
+			expect: `services "gss-0" already exists (operation: create Service default/gss-0)`,
+		},
+	}
+	for i, test := range tests {
+		if actual := Describe(test.err); actual != test.expect {
+			t.Errorf("case %d: expect %s, but actually got %s", i, test.expect, actual)
+		}
+	}
+}
+
+// legacyPluginError is the PluginError of an out-of-tree plugin, which does not implement TracedPluginError.
+type legacyPluginError struct{}
+
+func (e legacyPluginError) Error() string {
+	return "quota exceeded"
+}
+
+func (e legacyPluginError) Type() PluginErrorType {
+	return ApiCallError
+}
+
+func TestLegacyPluginError(t *testing.T) {
+	var err PluginError = legacyPluginError{}
+	if op, id := OperationOf(err), CorrelationIDOf(err); op != "" || id != "" {
+		t.Errorf("expect no operation and correlation ID, but actually got %s and %s", op, id)
+	}
+	if actual := Describe(err); actual != "quota exceeded" {
+		t.Errorf("expect quota exceeded, but actually got %s", actual)
+	}
+	expect := "quota exceeded (operation: create Service default/gss-0, correlation ID: uid-1)"
+	if actual := Describe(WithCorrelationID(WithOperation(err, "create Service default/gss-0"), "uid-1")); actual != expect {
+		t.Errorf("expect %s, but actually got %s", expect, actual)
+	}
+}
+
+func TestCallCloudAPI(t *testing.T) {
+	ctx := NewContextWithCorrelationID(context.Background(), "uid-1")
+	tests := []struct {
+		ctx     context.Context
+		err     error
+		expect  string
+		errType PluginErrorType
+	}{
+		{
+			ctx: ctx,
+		},
+		{
+			ctx:     ctx,
+			err:     fmt.Errorf("Throttling.User"),
+			expect:  "Throttling.User (operation: CreateLoadBalancerListener lb-1, correlation ID: uid-1)",
+			errType: ApiCallError,
+		},
+		{
+			ctx:     context.Background(),
+			err:     fmt.Errorf("Throttling.User"),
+			expect:  "Throttling.User (operation: CreateLoadBalancerListener lb-1)",
+			errType: ApiCallError,
+		},
+		// the type and the operation known are kept
+		{
+			ctx:     ctx,
+			err:     WithOperation(NewPluginError(ParameterError, "invalid port"), "DescribeLoadBalancer lb-1"),
+			expect:  "invalid port (operation: DescribeLoadBalancer lb-1, correlation ID: uid-1)",
+			errType: ParameterError,
+		},
+	}
+	for i, test := range tests {
+		err := CallCloudAPI(test.ctx, "CreateLoadBalancerListener lb-1", func(ctx context.Context) error {
+			if CorrelationIDFromContext(ctx) != CorrelationIDFromContext(test.ctx) {
+				t.Errorf("case %d: expect the context passed to the call", i)
+			}
+			return test.err
+		})
+		if test.err == nil {
+			if err != nil {
+				t.Errorf("case %d: expect no error, but actually got %v", i, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("case %d: expect error %s, but actually got nil", i, test.expect)
+			continue
+		}
+		if actual := Describe(err); actual != test.expect {
+			t.Errorf("case %d: expect %s, but actually got %s", i, test.expect, actual)
+		}
+		if err.Type() != test.errType {
+			t.Errorf("case %d: expect type %s, but actually got %s", i, test.errType, err.Type())
+		}
+	}
+}
+
+func TestCorrelationIDFromContext(t *testing.T) {
+	if id := CorrelationIDFromContext(context.Background()); id != "" {
+		t.Errorf("expect no correlation ID, but actually got %s", id)
+	}
+	if id := CorrelationIDFromContext(NewContextWithCorrelationID(context.Background(), "uid-1")); id != "uid-1" {
+		t.Errorf("expect correlation ID uid-1, but actually got %s", id)
+	}
+}
+
+func TestOperationRecorder(t *testing.T) {
+	ctx := context.Background()
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "gss-0"}}
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(svc).Build()
+	r := NewOperationRecorder(c)
+	apiErr := NewPluginError(ApiCallError, "failed")
+
+	// the objects not found are not failing operations
+	if err := r.Get(ctx, types.NamespacedName{Namespace: "default", Name: "gss-1"}, &corev1.Service{}); err == nil {
+		t.Fatal("expect gss-1 not found")
+	}
+	if op := OperationOf(r.Attach(apiErr)); op != "" {
+		t.Errorf("expect no operation, but actually got %s", op)
+	}
+
+	if err := r.Create(ctx, svc.DeepCopy()); err == nil {
+		t.Fatal("expect gss-0 already exists")
+	}
+	if op := OperationOf(r.Attach(apiErr)); op != "create Service default/gss-0" {
+		t.Errorf("expect operation create Service default/gss-0, but actually got %s", op)
+	}
+	if op := OperationOf(r.Attach(NewPluginError(ParameterError, "invalid"))); op != "" {
+		t.Errorf("expect no operation attached to the parameter error, but actually got %s", op)
+	}
+
+	if err := r.Delete(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-0"}}); err == nil {
+		t.Fatal("expect node-0 not found")
+	}
+	if op := OperationOf(r.Attach(apiErr)); op != "delete Node node-0" {
+		t.Errorf("expect operation delete Node node-0, but actually got %s", op)
+	}
+}
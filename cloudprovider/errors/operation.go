@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// OperationRecorder is the client recording the last failing API operation of a plugin, which is attached to the
+// error returned by the plugin, since the plugins do not name the operations failing.
+type OperationRecorder struct {
+	client.Client
+
+	lock      sync.Mutex
+	operation string
+}
+
+// NewOperationRecorder returns the OperationRecorder of the client, which is used for a single call of a plugin.
+func NewOperationRecorder(c client.Client) *OperationRecorder {
+	return &OperationRecorder{Client: c}
+}
+
+// Attach returns the plugin error with the last failing operation if the error is of ApiCallError.
+func (r *OperationRecorder) Attach(err PluginError) PluginError {
+	if err == nil || err.Type() != ApiCallError {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.operation == "" {
+		return err
+	}
+	return WithOperation(err, r.operation)
+}
+
+func (r *OperationRecorder) record(verb string, obj runtime.Object, key client.ObjectKey, err error) error {
+	// the objects not found are usually created then
+	if err == nil || ((verb == "get" || verb == "list") && apierrors.IsNotFound(err)) {
+		return err
+	}
+	kind := reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
+	if gvk, gvkErr := apiutil.GVKForObject(obj, r.Scheme()); gvkErr == nil {
+		kind = gvk.Kind
+	}
+	if verb == "list" {
+		kind = strings.TrimSuffix(kind, "List")
+	}
+	operation := fmt.Sprintf("%s %s", verb, kind)
+	if key.Name != "" && key.Namespace != "" {
+		operation = fmt.Sprintf("%s %s", operation, key.String())
+	} else if key.Name != "" {
+		operation = fmt.Sprintf("%s %s", operation, key.Name)
+	} else if key.Namespace != "" {
+		operation = fmt.Sprintf("%s in %s", operation, key.Namespace)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.operation = operation
+	return err
+}
+
+func (r *OperationRecorder) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	return r.record("get", obj, key, r.Client.Get(ctx, key, obj))
+}
+
+func (r *OperationRecorder) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	return r.record("list", list, client.ObjectKey{Namespace: listOpts.Namespace}, r.Client.List(ctx, list, opts...))
+}
+
+func (r *OperationRecorder) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return r.record("create", obj, client.ObjectKeyFromObject(obj), r.Client.Create(ctx, obj, opts...))
+}
+
+func (r *OperationRecorder) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	return r.record("delete", obj, client.ObjectKeyFromObject(obj), r.Client.Delete(ctx, obj, opts...))
+}
+
+func (r *OperationRecorder) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return r.record("update", obj, client.ObjectKeyFromObject(obj), r.Client.Update(ctx, obj, opts...))
+}
+
+func (r *OperationRecorder) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return r.record("patch", obj, client.ObjectKeyFromObject(obj), r.Client.Patch(ctx, obj, patch, opts...))
+}
+
+// CallCloudAPI makes the call of the cloud SDK named by operation, e.g. CreateLoadBalancerListener lb-xxx, and returns
+// the ApiCallError with the operation and the correlation ID of ctx if it fails, so that the failing call can be found
+// in the audit logs of the cloud. The operation known already of a TracedPluginError returned by the call is kept.
+func CallCloudAPI(ctx context.Context, operation string, call func(ctx context.Context) error) PluginError {
+	err := call(ctx)
+	if err == nil {
+		return nil
+	}
+	pluginErr, ok := err.(PluginError)
+	if !ok {
+		pluginErr = ToPluginError(err, ApiCallError)
+	}
+	pluginErr = WithOperation(pluginErr, operation)
+	if correlationID := CorrelationIDFromContext(ctx); correlationID != "" {
+		pluginErr = WithCorrelationID(pluginErr, correlationID)
+	}
+	return pluginErr
+}
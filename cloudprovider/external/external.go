@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"fmt"
+
+	"github.com/openkruise/kruise-game/cloudprovider"
+	"github.com/openkruise/kruise-game/cloudprovider/options"
+)
+
+const (
+	External = "External"
+)
+
+// Provider serves the out-of-tree network plugins, which are configured in the config file
+// rather than registered in init.
+type Provider struct {
+	plugins map[string]cloudprovider.Plugin
+}
+
+func (ep *Provider) Name() string {
+	return External
+}
+
+func (ep *Provider) ListPlugins() (map[string]cloudprovider.Plugin, error) {
+	if ep.plugins == nil {
+		return make(map[string]cloudprovider.Plugin), nil
+	}
+
+	return ep.plugins, nil
+}
+
+// NewExternalProvider returns the provider with a plugin for each of the plugins in the options.
+// Adding or removing plugins requires restarting the manager.
+func NewExternalProvider(opts options.ExternalOptions) (cloudprovider.CloudProvider, error) {
+	ep := &Provider{
+		plugins: make(map[string]cloudprovider.Plugin),
+	}
+	for _, conf := range opts.Plugins {
+		if _, ok := ep.plugins[conf.Name]; ok {
+			return nil, fmt.Errorf("duplicated external plugin %s", conf.Name)
+		}
+		ep.plugins[conf.Name] = newGrpcPlugin(conf.Name)
+	}
+	return ep, nil
+}
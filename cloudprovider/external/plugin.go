@@ -0,0 +1,324 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/cloudprovider"
+	"github.com/openkruise/kruise-game/cloudprovider/errors"
+	"github.com/openkruise/kruise-game/cloudprovider/options"
+	"github.com/openkruise/kruise-game/cloudprovider/ratelimit"
+	"github.com/openkruise/kruise-game/cloudprovider/utils"
+	"github.com/openkruise/kruise-game/pkg/metrics"
+	"github.com/openkruise/kruise-game/pkg/networkplugin"
+)
+
+const (
+	defaultTimeout       = 2 * time.Second
+	defaultRetries       = 2
+	defaultRetryInterval = 200 * time.Millisecond
+)
+
+// grpcPlugin forwards the calls of the manager to an out-of-tree network plugin over gRPC.
+type grpcPlugin struct {
+	name string
+	// limiter limits the calls by the rate limit keys, on behalf of the cloud APIs called by the plugin
+	limiter *ratelimit.Limiter
+
+	// mutex guards the fields below, and serializes the handshakes
+	mutex  sync.Mutex
+	conf   options.ExternalPluginOptions
+	conn   *grpc.ClientConn
+	client networkplugin.NetworkPluginClient
+	alias  string
+	// version is the negotiated protocol version, 0 if the handshake is to be done
+	version uint32
+}
+
+func newGrpcPlugin(name string) *grpcPlugin {
+	return &grpcPlugin{name: name, limiter: ratelimit.NewLimiter(0, 0)}
+}
+
+func (p *grpcPlugin) Name() string {
+	return p.name
+}
+
+func (p *grpcPlugin) Alias() string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.alias
+}
+
+// Init connects to the plugin. The plugin is still registered if it is not available yet,
+// and the handshake is done again before the next call.
+func (p *grpcPlugin) Init(c client.Client, opts cloudprovider.CloudProviderOptions, ctx context.Context) error {
+	return p.configure(opts, ctx)
+}
+
+// Reconfigure applies the changed options of the plugin, reconnecting to it if the address changes.
+func (p *grpcPlugin) Reconfigure(c client.Client, opts cloudprovider.CloudProviderOptions, ctx context.Context) error {
+	return p.configure(opts, ctx)
+}
+
+func (p *grpcPlugin) configure(opts cloudprovider.CloudProviderOptions, ctx context.Context) error {
+	externalOptions, ok := opts.(options.ExternalOptions)
+	if !ok {
+		return fmt.Errorf("failed to convert options to external options")
+	}
+	idx := slices.IndexFunc(externalOptions.Plugins, func(conf options.ExternalPluginOptions) bool {
+		return conf.Name == p.name
+	})
+	if idx < 0 {
+		return fmt.Errorf("plugin %s is not found in the options", p.name)
+	}
+	conf := externalOptions.Plugins[idx]
+
+	p.mutex.Lock()
+	if p.conn == nil || conf.Address != p.conf.Address {
+		// the connection is established in background, and re-established when it is lost
+		conn, err := grpc.Dial(conf.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			p.mutex.Unlock()
+			return err
+		}
+		if p.conn != nil {
+			p.conn.Close()
+		}
+		p.conn = conn
+		p.client = networkplugin.NewNetworkPluginClient(conn)
+	}
+	p.conf = conf
+	p.limiter.SetRate(conf.QPS, conf.Burst)
+	// the handshake and Init are done again with the new options
+	p.version = 0
+	p.mutex.Unlock()
+
+	_, err := p.connect(ctx)
+	return err
+}
+
+// connect does the handshake and Init if they are not done yet, and returns the client of the plugin.
+func (p *grpcPlugin) connect(ctx context.Context) (networkplugin.NetworkPluginClient, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.client == nil {
+		return nil, fmt.Errorf("plugin %s is not initialized", p.name)
+	}
+	if p.version != 0 {
+		return p.client, nil
+	}
+
+	var resp *networkplugin.HandshakeResponse
+	err := retry(ctx, p.conf, func(ctx context.Context) error {
+		var err error
+		resp, err = p.client.Handshake(ctx, &networkplugin.HandshakeRequest{Name: p.name, Versions: networkplugin.SupportedVersions})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("handshake with plugin %s failed, because of %s", p.name, err.Error())
+	}
+	if !slices.Contains(networkplugin.SupportedVersions, resp.GetVersion()) {
+		return nil, fmt.Errorf("plugin %s chose protocol version %d, which is not in %v", p.name, resp.GetVersion(), networkplugin.SupportedVersions)
+	}
+	if resp.GetName() != p.name {
+		return nil, fmt.Errorf("plugin %s is served by plugin %s", p.name, resp.GetName())
+	}
+
+	err = retry(ctx, p.conf, func(ctx context.Context) error {
+		_, err := p.client.Init(ctx, &networkplugin.InitRequest{Options: p.conf.Options})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s failed to init, because of %s", p.name, err.Error())
+	}
+	p.version = resp.GetVersion()
+	p.alias = resp.GetAlias()
+	klog.Infof("plugin [%s] is connected with protocol version %d", p.name, p.version)
+	return p.client, nil
+}
+
+// invoke calls fn with the client of the plugin, with the timeout and retries of the options. Each call, including
+// the retries, waits for the token bucket of the rate limit key of the network conf.
+func (p *grpcPlugin) invoke(ctx context.Context, conf []v1alpha1.NetworkConfParams, fn func(context.Context, networkplugin.NetworkPluginClient) error) error {
+	c, err := p.connect(ctx)
+	if err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	pluginConf := p.conf
+	p.mutex.Unlock()
+
+	key := rateLimitKey(p.name, pluginConf.RateLimitKey, conf)
+	err = retry(ctx, pluginConf, func(callCtx context.Context) error {
+		// wait with ctx, so that the time waited is not taken from the timeout of the call
+		waited, err := p.limiter.Wait(ctx, key)
+		metrics.CloudAPIRateLimitWaitSeconds.WithLabelValues(p.name).Observe(waited.Seconds())
+		if err != nil {
+			return err
+		}
+		err = fn(callCtx, c)
+		if status.Code(err) == codes.ResourceExhausted {
+			metrics.CloudAPIThrottledTotal.WithLabelValues(p.name, key).Inc()
+		}
+		return err
+	})
+	if status.Code(err) == codes.Unavailable {
+		// the plugin may be restarted with another version, so the handshake is done again
+		p.mutex.Lock()
+		p.version = 0
+		p.mutex.Unlock()
+	}
+	return err
+}
+
+// rateLimitKey returns the plugin name, suffixed by the value of the network conf parameter keyParam if it is set.
+func rateLimitKey(pluginName, keyParam string, conf []v1alpha1.NetworkConfParams) string {
+	if keyParam == "" {
+		return pluginName
+	}
+	for _, param := range conf {
+		if param.Name == keyParam {
+			return pluginName + "/" + param.Value
+		}
+	}
+	return pluginName
+}
+
+// retry calls fn with the timeout of the options, and retries it with jittered exponential backoff if it fails
+// because the plugin is unavailable, times out or is throttled. The other errors are returned without retrying.
+func retry(ctx context.Context, conf options.ExternalPluginOptions, fn func(context.Context) error) error {
+	timeout := conf.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	retries := defaultRetries
+	if conf.Retries != nil {
+		retries = *conf.Retries
+	}
+	interval := conf.RetryInterval
+	if interval == 0 {
+		interval = defaultRetryInterval
+	}
+
+	for i := 0; ; i++ {
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := fn(callCtx)
+		cancel()
+		if err == nil || i >= retries || !isRetryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(ratelimit.Jitter(interval)):
+		}
+		interval *= 2
+	}
+}
+
+func isRetryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+type podCall func(networkplugin.NetworkPluginClient, context.Context, *networkplugin.PodRequest, ...grpc.CallOption) (*networkplugin.PodResponse, error)
+
+func (p *grpcPlugin) OnPodAdded(c client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, errors.PluginError) {
+	return p.onPod(pod, ctx, networkplugin.NetworkPluginClient.OnPodAdded)
+}
+
+func (p *grpcPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, errors.PluginError) {
+	return p.onPod(pod, ctx, networkplugin.NetworkPluginClient.OnPodUpdated)
+}
+
+func (p *grpcPlugin) OnPodDeleted(c client.Client, pod *corev1.Pod, ctx context.Context) errors.PluginError {
+	_, err := p.onPod(pod, ctx, networkplugin.NetworkPluginClient.OnPodDeleted)
+	return err
+}
+
+func (p *grpcPlugin) onPod(pod *corev1.Pod, ctx context.Context, call podCall) (*corev1.Pod, errors.PluginError) {
+	data, err := networkplugin.EncodePod(pod)
+	if err != nil {
+		return pod, errors.ToPluginError(err, errors.InternalError)
+	}
+	var conf []v1alpha1.NetworkConfParams
+	if nm := utils.NewNetworkManager(pod, nil); nm != nil {
+		conf = nm.GetNetworkConfig()
+	}
+	var resp *networkplugin.PodResponse
+	var trailer metadata.MD
+	err = p.invoke(networkplugin.NewOutgoingContext(ctx), conf, func(ctx context.Context, c networkplugin.NetworkPluginClient) error {
+		var err error
+		resp, err = call(c, ctx, &networkplugin.PodRequest{Pod: data}, grpc.Trailer(&trailer))
+		return err
+	})
+	if err != nil {
+		return pod, errors.NewPluginError(errors.InternalError, "failed to call plugin %s, because of %s", p.name, err.Error())
+	}
+	pluginErr := errors.WithOperation(networkplugin.ToPluginError(resp.GetError()), networkplugin.OperationFromTrailer(trailer))
+	if len(resp.GetPod()) == 0 {
+		return pod, pluginErr
+	}
+	newPod, err := networkplugin.DecodePod(resp.GetPod())
+	if err != nil {
+		return pod, errors.NewPluginError(errors.InternalError, "failed to decode the pod returned by plugin %s, because of %s", p.name, err.Error())
+	}
+	return newPod, pluginErr
+}
+
+// ValidateNetworkConf asks the plugin to validate the network conf. The network conf is accepted
+// if the plugin is not available or does not implement the validation.
+func (p *grpcPlugin) ValidateNetworkConf(conf []v1alpha1.NetworkConfParams) error {
+	req := &networkplugin.ValidateNetworkConfRequest{}
+	for _, param := range conf {
+		req.NetworkConf = append(req.NetworkConf, &networkplugin.NetworkConfParams{Name: param.Name, Value: param.Value})
+	}
+	var resp *networkplugin.ValidateNetworkConfResponse
+	err := p.invoke(context.Background(), conf, func(ctx context.Context, c networkplugin.NetworkPluginClient) error {
+		var err error
+		resp, err = c.ValidateNetworkConf(ctx, req)
+		return err
+	})
+	if err != nil {
+		if status.Code(err) != codes.Unimplemented {
+			klog.Warningf("plugin %s failed to validate network conf, because of %s", p.name, err.Error())
+		}
+		return nil
+	}
+	if resp.GetError() != nil {
+		return fmt.Errorf("%s", resp.GetError().GetMessage())
+	}
+	return nil
+}
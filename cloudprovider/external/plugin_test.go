@@ -0,0 +1,244 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/cloudprovider/errors"
+	"github.com/openkruise/kruise-game/cloudprovider/options"
+	"github.com/openkruise/kruise-game/pkg/networkplugin"
+)
+
+type fakeHandler struct {
+	options map[string]string
+}
+
+func (h *fakeHandler) Name() string {
+	return "Corp-LB"
+}
+
+func (h *fakeHandler) Alias() string {
+	return "LB"
+}
+
+func (h *fakeHandler) Init(options map[string]string) error {
+	h.options = options
+	return nil
+}
+
+func (h *fakeHandler) OnPodAdded(ctx context.Context, pod *corev1.Pod) (*corev1.Pod, errors.PluginError) {
+	return pod, nil
+}
+
+func (h *fakeHandler) OnPodUpdated(ctx context.Context, pod *corev1.Pod) (*corev1.Pod, errors.PluginError) {
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations["lb"] = h.options["region"]
+	return pod, nil
+}
+
+func (h *fakeHandler) OnPodDeleted(ctx context.Context, pod *corev1.Pod) errors.PluginError {
+	if correlationID := errors.CorrelationIDFromContext(ctx); correlationID != "" {
+		return errors.CallCloudAPI(ctx, "DeleteLoadBalancer "+pod.GetName(), func(ctx context.Context) error {
+			return fmt.Errorf("failed to release %s in %s", pod.GetName(), correlationID)
+		})
+	}
+	return errors.NewPluginError(errors.ApiCallError, "failed to release %s", pod.GetName())
+}
+
+func (h *fakeHandler) ValidateNetworkConf(conf []v1alpha1.NetworkConfParams) error {
+	for _, c := range conf {
+		if c.Name != "Region" {
+			return fmt.Errorf("unknown parameter %s", c.Name)
+		}
+	}
+	return nil
+}
+
+// versionServer chooses a protocol version which the manager does not support.
+type versionServer struct {
+	networkplugin.UnimplementedNetworkPluginServer
+}
+
+func (s *versionServer) Handshake(ctx context.Context, req *networkplugin.HandshakeRequest) (*networkplugin.HandshakeResponse, error) {
+	return &networkplugin.HandshakeResponse{Version: 99, Name: req.GetName()}, nil
+}
+
+func serve(t *testing.T, srv networkplugin.NetworkPluginServer) string {
+	path := filepath.Join(t.TempDir(), "plugin.sock")
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	networkplugin.RegisterNetworkPluginServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return "unix://" + path
+}
+
+func newOptions(name, address string) options.ExternalOptions {
+	return options.ExternalOptions{
+		Enable: true,
+		Plugins: []options.ExternalPluginOptions{{
+			Name:    name,
+			Address: address,
+			Options: map[string]string{"region": "dc-1"},
+		}},
+	}
+}
+
+func TestGrpcPlugin(t *testing.T) {
+	address := serve(t, networkplugin.NewServer(&fakeHandler{}))
+	ep, err := NewExternalProvider(newOptions("Corp-LB", address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plugins, _ := ep.ListPlugins()
+	p := plugins["Corp-LB"]
+	if err := p.Init(nil, newOptions("Corp-LB", address), context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if p.Alias() != "LB" {
+		t.Errorf("expect alias LB, but actually got %s", p.Alias())
+	}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "case-0"}}
+	newPod, pluginErr := p.OnPodUpdated(nil, pod, context.Background())
+	if pluginErr != nil {
+		t.Fatal(pluginErr)
+	}
+	if newPod.GetName() != "case-0" || newPod.Annotations["lb"] != "dc-1" {
+		t.Errorf("unexpected pod %v", newPod)
+	}
+
+	pluginErr = p.OnPodDeleted(nil, pod, context.Background())
+	if pluginErr == nil || pluginErr.Type() != errors.ApiCallError || pluginErr.Error() != "failed to release case-0" {
+		t.Errorf("unexpected error %v", pluginErr)
+	}
+
+	// the correlation ID is passed to the plugin, and the operation is returned
+	pluginErr = p.OnPodDeleted(nil, pod, errors.NewContextWithCorrelationID(context.Background(), "req-1"))
+	if pluginErr == nil || pluginErr.Error() != "failed to release case-0 in req-1" || errors.OperationOf(pluginErr) != "DeleteLoadBalancer case-0" {
+		t.Errorf("expect the error of req-1 with the operation, but actually got %v", pluginErr)
+	}
+
+	validator := p.(*grpcPlugin)
+	if err := validator.ValidateNetworkConf([]v1alpha1.NetworkConfParams{{Name: "Region", Value: "dc-1"}}); err != nil {
+		t.Errorf("expect valid network conf, but actually got %v", err)
+	}
+	if err := validator.ValidateNetworkConf([]v1alpha1.NetworkConfParams{{Name: "Zone", Value: "a"}}); err == nil || err.Error() != "unknown parameter Zone" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// the new options are passed to the plugin by the handshake and Init done again
+	newOpts := newOptions("Corp-LB", address)
+	newOpts.Plugins[0].Options["region"] = "dc-2"
+	if err := p.(*grpcPlugin).Reconfigure(nil, newOpts, context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if newPod, _ = p.OnPodUpdated(nil, pod, context.Background()); newPod.Annotations["lb"] != "dc-2" {
+		t.Errorf("expect the reconfigured option, but actually got %v", newPod.Annotations)
+	}
+}
+
+func TestGrpcPluginHandshake(t *testing.T) {
+	tests := []struct {
+		name   string
+		server networkplugin.NetworkPluginServer
+	}{
+		{name: "Other-LB", server: networkplugin.NewServer(&fakeHandler{})},
+		{name: "Corp-LB", server: &versionServer{}},
+	}
+	for _, test := range tests {
+		address := serve(t, test.server)
+		p := newGrpcPlugin(test.name)
+		if err := p.Init(nil, newOptions(test.name, address), context.Background()); err == nil {
+			t.Errorf("%s: expect the handshake to fail, but actually succeeded", test.name)
+		}
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "case-0"}}
+		if newPod, err := p.OnPodAdded(nil, pod, context.Background()); err == nil || err.Type() != errors.InternalError || newPod != pod {
+			t.Errorf("%s: expect an internal error and the original pod, but actually got %v and %v", test.name, newPod, err)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	conf := options.ExternalPluginOptions{Timeout: time.Second, Retries: ptr.To(2), RetryInterval: time.Millisecond}
+	tests := []struct {
+		name          string
+		errs          []error
+		expectedCalls int
+		expectedCode  codes.Code
+	}{
+		{name: "succeed after retries", errs: []error{status.Error(codes.Unavailable, ""), status.Error(codes.DeadlineExceeded, ""), nil}, expectedCalls: 3, expectedCode: codes.OK},
+		{name: "not retryable", errs: []error{status.Error(codes.InvalidArgument, ""), nil}, expectedCalls: 1, expectedCode: codes.InvalidArgument},
+		{name: "retry throttled", errs: []error{status.Error(codes.ResourceExhausted, ""), nil}, expectedCalls: 2, expectedCode: codes.OK},
+		{name: "retries exhausted", errs: []error{status.Error(codes.Unavailable, ""), status.Error(codes.Unavailable, ""), status.Error(codes.Unavailable, ""), nil}, expectedCalls: 3, expectedCode: codes.Unavailable},
+	}
+	for _, test := range tests {
+		calls := 0
+		err := retry(context.Background(), conf, func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("%s: expect the call to have a deadline", test.name)
+			}
+			err := test.errs[calls]
+			calls++
+			return err
+		})
+		if calls != test.expectedCalls || status.Code(err) != test.expectedCode {
+			t.Errorf("%s: expect %d calls and code %s, but actually got %d calls and %v", test.name, test.expectedCalls, test.expectedCode, calls, err)
+		}
+	}
+}
+
+func TestExternalOptionsValid(t *testing.T) {
+	tests := []struct {
+		opts     options.ExternalOptions
+		expected bool
+	}{
+		{opts: newOptions("Corp-LB", "unix:///tmp/a.sock"), expected: true},
+		{opts: newOptions("", "unix:///tmp/a.sock"), expected: false},
+		{opts: newOptions("Corp-LB", ""), expected: false},
+		{opts: options.ExternalOptions{Plugins: []options.ExternalPluginOptions{{Name: "a", Address: "x"}, {Name: "a", Address: "y"}}}, expected: false},
+		{opts: options.ExternalOptions{Plugins: []options.ExternalPluginOptions{{Name: "a", Address: "x", Retries: ptr.To(-1)}}}, expected: false},
+		{opts: options.ExternalOptions{Plugins: []options.ExternalPluginOptions{{Name: "a", Address: "x", QPS: 10, Burst: 20}}}, expected: true},
+		{opts: options.ExternalOptions{Plugins: []options.ExternalPluginOptions{{Name: "a", Address: "x", QPS: -1}}}, expected: false},
+	}
+	for i, test := range tests {
+		if actual := test.opts.Valid(); actual != test.expected {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.expected, actual)
+		}
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	conf := []v1alpha1.NetworkConfParams{{Name: "LbId", Value: "lb-a"}}
+	tests := []struct {
+		keyParam string
+		expected string
+	}{
+		{keyParam: "", expected: "Corp-LB"},
+		{keyParam: "LbId", expected: "Corp-LB/lb-a"},
+		// the parameter is not set
+		{keyParam: "Zone", expected: "Corp-LB"},
+	}
+	for i, test := range tests {
+		if actual := rateLimitKey("Corp-LB", test.keyParam, conf); actual != test.expected {
+			t.Errorf("case %d: expect %s, but actually got %s", i, test.expected, actual)
+		}
+	}
+}
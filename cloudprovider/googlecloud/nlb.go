@@ -0,0 +1,542 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package googlecloud
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	log "k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/cloudprovider"
+	cperrors "github.com/openkruise/kruise-game/cloudprovider/errors"
+	provideroptions "github.com/openkruise/kruise-game/cloudprovider/options"
+	"github.com/openkruise/kruise-game/cloudprovider/utils"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+const (
+	NlbNetwork              = "GoogleCloud-NLB"
+	AliasNLB                = "GCP-NLB-Network"
+	IPsConfigName           = "IPs"
+	PortProtocolsConfigName = "PortProtocols"
+	FixedConfigName         = "Fixed"
+	NlbAnnotations          = "Annotations"
+	NlbConfigHashKey        = "game.kruise.io/network-config-hash"
+	// NlbIPAnnotationKey is the annotation of Services created by the plugin, whose value is the reserved IP
+	// address shared by the forwarding rules of the Services.
+	NlbIPAnnotationKey = "game.kruise.io/gcp-nlb-ip"
+	// NlbRBSAnnotationKey makes GKE provision a backend service-based external passthrough Network Load Balancer.
+	NlbRBSAnnotationKey = "cloud.google.com/l4-rbs"
+	NlbRBSEnabled       = "enabled"
+	SvcSelectorKey      = "statefulset.kubernetes.io/pod-name"
+)
+
+type portAllocated map[int32]bool
+
+// NlbPlugin creates a LoadBalancer Service for each pod, and GKE creates a forwarding rule of an external passthrough
+// Network Load Balancer for each Service. The forwarding rules of the pods share the reserved regional IP addresses
+// with different ports, which are allocated from the port range of the provider options.
+type NlbPlugin struct {
+	maxPort     int32
+	minPort     int32
+	cache       map[string]portAllocated
+	podAllocate map[string]string
+	mutex       sync.RWMutex
+}
+
+type nlbConfig struct {
+	ips         []string
+	targetPorts []int
+	protocols   []corev1.Protocol
+	isFixed     bool
+	annotations map[string]string
+}
+
+func (n *NlbPlugin) Name() string {
+	return NlbNetwork
+}
+
+func (n *NlbPlugin) Alias() string {
+	return AliasNLB
+}
+
+func (n *NlbPlugin) Init(client client.Client, options cloudprovider.CloudProviderOptions, ctx context.Context) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	gcpOptions, ok := options.(provideroptions.GoogleCloudOptions)
+	if !ok {
+		return cperrors.ToPluginError(fmt.Errorf("failed to convert options to googleCloudOptions"), cperrors.InternalError)
+	}
+	n.minPort = gcpOptions.NLBOptions.MinPort
+	n.maxPort = gcpOptions.NLBOptions.MaxPort
+
+	svcList := &corev1.ServiceList{}
+	err := client.List(ctx, svcList)
+	if err != nil {
+		return err
+	}
+
+	n.cache, n.podAllocate = initLbCache(svcList.Items, n.minPort, n.maxPort)
+	return nil
+}
+
+func initLbCache(svcList []corev1.Service, minPort, maxPort int32) (map[string]portAllocated, map[string]string) {
+	newCache := make(map[string]portAllocated)
+	newPodAllocate := make(map[string]string)
+	for _, svc := range svcList {
+		ip := svc.GetAnnotations()[NlbIPAnnotationKey]
+		if ip == "" {
+			continue
+		}
+		if newCache[ip] == nil {
+			newCache[ip] = newPortAllocated(minPort, maxPort)
+		}
+		var ports []int32
+		for _, port := range svc.Spec.Ports {
+			if port.Port < maxPort && port.Port >= minPort {
+				newCache[ip][port.Port] = true
+				ports = append(ports, port.Port)
+			}
+		}
+		if len(ports) != 0 {
+			newPodAllocate[svc.GetNamespace()+"/"+svc.GetName()] = ip + ":" + util.Int32SliceToString(ports, ",")
+		}
+	}
+	log.Infof("[%s] podAllocate cache complete initialization: %v", NlbNetwork, newPodAllocate)
+	return newCache, newPodAllocate
+}
+
+func newPortAllocated(minPort, maxPort int32) portAllocated {
+	allocated := make(portAllocated, maxPort-minPort)
+	for i := minPort; i < maxPort; i++ {
+		allocated[i] = false
+	}
+	return allocated
+}
+
+func (n *NlbPlugin) OnPodAdded(client client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, cperrors.PluginError) {
+	return pod, nil
+}
+
+func (n *NlbPlugin) OnPodUpdated(client client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, cperrors.PluginError) {
+	networkManager := utils.NewNetworkManager(pod, client)
+
+	networkStatus, err := networkManager.GetNetworkStatus()
+	if err != nil {
+		return pod, cperrors.ToPluginError(err, cperrors.InternalError)
+	}
+	networkConfig := networkManager.GetNetworkConfig()
+	config := parseLbConfig(networkConfig)
+	if networkStatus == nil {
+		pod, err := networkManager.UpdateNetworkStatus(gamekruiseiov1alpha1.NetworkStatus{
+			CurrentNetworkState: gamekruiseiov1alpha1.NetworkNotReady,
+		}, pod)
+		return pod, cperrors.ToPluginError(err, cperrors.InternalError)
+	}
+
+	// get svc
+	svc := &corev1.Service{}
+	err = client.Get(ctx, types.NamespacedName{
+		Name:      pod.GetName(),
+		Namespace: pod.GetNamespace(),
+	}, svc)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			newSvc, err := n.consSvc(config, pod, client, ctx)
+			if err != nil {
+				if utils.IsPortExhausted(err) {
+					utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkPortExhausted, err.Error())
+					pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
+					return pod, cperrors.ToPluginError(err, cperrors.InternalError)
+				}
+				return pod, cperrors.ToPluginError(err, cperrors.ParameterError)
+			}
+			utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkServiceMissing, "service is being created")
+			if pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod); err != nil {
+				return pod, cperrors.NewPluginError(cperrors.InternalError, err.Error())
+			}
+			return pod, cperrors.ToPluginError(client.Create(ctx, newSvc), cperrors.ApiCallError)
+		}
+		return pod, cperrors.NewPluginError(cperrors.ApiCallError, err.Error())
+	}
+
+	// handle the Service of the pod's name not created by the plugin
+	if stop, pod, pluginErr := utils.HandleServiceCollision(client, ctx, networkManager, pod, svc, networkStatus); stop {
+		return pod, pluginErr
+	}
+
+	// update svc
+	if util.GetHash(config) != svc.GetAnnotations()[NlbConfigHashKey] {
+		networkStatus.CurrentNetworkState = gamekruiseiov1alpha1.NetworkNotReady
+		pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
+		if err != nil {
+			return pod, cperrors.NewPluginError(cperrors.InternalError, err.Error())
+		}
+		newSvc, err := n.consSvc(config, pod, client, ctx)
+		if err != nil {
+			return pod, cperrors.ToPluginError(err, cperrors.ParameterError)
+		}
+		newSvc.SetResourceVersion(svc.GetResourceVersion())
+		return pod, cperrors.ToPluginError(client.Update(ctx, newSvc), cperrors.ApiCallError)
+	}
+
+	// disable network
+	if networkManager.GetNetworkDisabled() && svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		svc.Spec.Type = corev1.ServiceTypeClusterIP
+		return pod, cperrors.ToPluginError(client.Update(ctx, svc), cperrors.ApiCallError)
+	}
+
+	// enable network
+	if !networkManager.GetNetworkDisabled() && svc.Spec.Type == corev1.ServiceTypeClusterIP {
+		svc.Spec.Type = corev1.ServiceTypeLoadBalancer
+		return pod, cperrors.ToPluginError(client.Update(ctx, svc), cperrors.ApiCallError)
+	}
+
+	// network not ready
+	lbIP, lbHostname := utils.GetLoadBalancerAddress(svc.Status.LoadBalancer.Ingress)
+	if lbIP == "" && lbHostname == "" {
+		utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkWaitingForLB, "load balancer has not assigned the address")
+		pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
+		return pod, cperrors.ToPluginError(err, cperrors.InternalError)
+	}
+
+	// allow not ready containers
+	if util.IsAllowNotReadyContainers(networkManager.GetNetworkConfig()) {
+		toUpDateSvc, err := utils.AllowNotReadyContainers(client, ctx, pod, svc, false)
+		if err != nil {
+			return pod, err
+		}
+
+		if toUpDateSvc {
+			err := client.Update(ctx, svc)
+			if err != nil {
+				return pod, cperrors.ToPluginError(err, cperrors.ApiCallError)
+			}
+		}
+	}
+
+	// network ready
+	internalAddresses := make([]gamekruiseiov1alpha1.NetworkAddress, 0)
+	externalAddresses := make([]gamekruiseiov1alpha1.NetworkAddress, 0)
+	for _, port := range svc.Spec.Ports {
+		instrIPort := port.TargetPort
+		instrEPort := intstr.FromInt(int(port.Port))
+		internalAddresses = append(internalAddresses, gamekruiseiov1alpha1.NetworkAddress{
+			IP: pod.Status.PodIP,
+			Ports: []gamekruiseiov1alpha1.NetworkPort{
+				{
+					Name:     instrIPort.String(),
+					Port:     &instrIPort,
+					Protocol: port.Protocol,
+				},
+			},
+		})
+		externalAddresses = append(externalAddresses, gamekruiseiov1alpha1.NetworkAddress{
+			IP:       lbIP,
+			EndPoint: lbHostname,
+			Ports: []gamekruiseiov1alpha1.NetworkPort{
+				{
+					Name:     instrIPort.String(),
+					Port:     &instrEPort,
+					Protocol: port.Protocol,
+				},
+			},
+		})
+	}
+	networkStatus.InternalAddresses = internalAddresses
+	networkStatus.ExternalAddresses = externalAddresses
+	networkStatus.CurrentNetworkState = gamekruiseiov1alpha1.NetworkReady
+	pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
+	return pod, cperrors.ToPluginError(err, cperrors.InternalError)
+}
+
+func (n *NlbPlugin) OnPodDeleted(client client.Client, pod *corev1.Pod, ctx context.Context) cperrors.PluginError {
+	networkManager := utils.NewNetworkManager(pod, client)
+	networkConfig := networkManager.GetNetworkConfig()
+	sc := parseLbConfig(networkConfig)
+
+	var podKeys []string
+	if sc.isFixed {
+		gss, err := util.GetGameServerSetOfPod(pod, client, ctx)
+		if err != nil && !errors.IsNotFound(err) {
+			return cperrors.ToPluginError(err, cperrors.ApiCallError)
+		}
+		// gss exists in cluster, do not deAllocate.
+		if err == nil && gss.GetDeletionTimestamp() == nil {
+			return nil
+		}
+		// identity of gss is retained, do not deAllocate.
+		retained, err := util.IsServiceRetained(pod, client, ctx)
+		if err != nil {
+			return cperrors.ToPluginError(err, cperrors.ApiCallError)
+		}
+		if retained {
+			return nil
+		}
+		// gss not exists in cluster, deAllocate all the ports related to it.
+		gssName := pod.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]
+		n.mutex.RLock()
+		for key := range n.podAllocate {
+			if strings.Contains(key, pod.GetNamespace()+"/"+gssName) {
+				podKeys = append(podKeys, key)
+			}
+		}
+		n.mutex.RUnlock()
+	} else {
+		podKeys = append(podKeys, pod.GetNamespace()+"/"+pod.GetName())
+	}
+
+	for _, podKey := range podKeys {
+		n.deAllocate(podKey)
+	}
+
+	return nil
+}
+
+// Capacity returns the number of pods which can still be allocated ports on the IP addresses of the network conf.
+func (n *NlbPlugin) Capacity(client client.Client, conf []gamekruiseiov1alpha1.NetworkConfParams, ctx context.Context) (int, error) {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	config := parseLbConfig(conf)
+	num := len(config.targetPorts)
+	if num == 0 {
+		return math.MaxInt32, nil
+	}
+	capacity := 0
+	for _, ip := range config.ips {
+		free := 0
+		for i := n.minPort; i < n.maxPort; i++ {
+			if !n.cache[ip][i] {
+				free++
+			}
+		}
+		capacity += free / num
+	}
+	return capacity, nil
+}
+
+// allocate selects the first IP address with adequate free ports, and allocates the lowest free ports of it.
+func (n *NlbPlugin) allocate(ips []string, num int, nsName string) (string, []int32, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	for _, ip := range ips {
+		if n.cache[ip] == nil {
+			n.cache[ip] = newPortAllocated(n.minPort, n.maxPort)
+		}
+		var ports []int32
+		for p := n.minPort; p < n.maxPort && len(ports) < num; p++ {
+			if !n.cache[ip][p] {
+				ports = append(ports, p)
+			}
+		}
+		if len(ports) < num {
+			continue
+		}
+		for _, port := range ports {
+			n.cache[ip][port] = true
+		}
+		n.podAllocate[nsName] = ip + ":" + util.Int32SliceToString(ports, ",")
+		log.Infof("pod %s allocate gcp nlb ip %s ports %v", nsName, ip, ports)
+		return ip, ports, nil
+	}
+	return "", nil, utils.NewPortExhaustedError("no enough ports of the IP addresses %v for pod %s", ips, nsName)
+}
+
+func (n *NlbPlugin) deAllocate(nsName string) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	allocatedPorts, exist := n.podAllocate[nsName]
+	if !exist {
+		return
+	}
+
+	ipPorts := strings.Split(allocatedPorts, ":")
+	ip := ipPorts[0]
+	ports := util.StringToInt32Slice(ipPorts[1], ",")
+	for _, port := range ports {
+		n.cache[ip][port] = false
+	}
+
+	delete(n.podAllocate, nsName)
+	log.Infof("pod %s deallocate gcp nlb ip %s ports %v", nsName, ip, ports)
+}
+
+func init() {
+	nlbPlugin := NlbPlugin{
+		mutex: sync.RWMutex{},
+	}
+	googleCloudProvider.registerPlugin(&nlbPlugin)
+}
+
+func parseLbConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) *nlbConfig {
+	var ips []string
+	ports := make([]int, 0)
+	protocols := make([]corev1.Protocol, 0)
+	isFixed := false
+	annotations := map[string]string{}
+	for _, c := range conf {
+		switch c.Name {
+		case IPsConfigName:
+			for _, ip := range strings.Split(c.Value, ",") {
+				if ip != "" {
+					ips = append(ips, ip)
+				}
+			}
+		case PortProtocolsConfigName:
+			for _, pp := range strings.Split(c.Value, ",") {
+				ppSlice := strings.Split(pp, "/")
+				port, err := strconv.Atoi(ppSlice[0])
+				if err != nil {
+					continue
+				}
+				ports = append(ports, port)
+				if len(ppSlice) != 2 {
+					protocols = append(protocols, corev1.ProtocolTCP)
+				} else {
+					protocols = append(protocols, corev1.Protocol(ppSlice[1]))
+				}
+			}
+		case FixedConfigName:
+			v, err := strconv.ParseBool(c.Value)
+			if err != nil {
+				continue
+			}
+			isFixed = v
+		case NlbAnnotations:
+			for _, anno := range strings.Split(c.Value, ",") {
+				annoKV := strings.SplitN(anno, ":", 2)
+				if len(annoKV) == 2 {
+					annotations[annoKV[0]] = annoKV[1]
+				} else {
+					log.Warningf("gcp nlb annotation %s is invalid", annoKV[0])
+				}
+			}
+		}
+	}
+	return &nlbConfig{
+		ips:         ips,
+		protocols:   protocols,
+		targetPorts: ports,
+		isFixed:     isFixed,
+		annotations: annotations,
+	}
+}
+
+func (n *NlbPlugin) consSvc(config *nlbConfig, pod *corev1.Pod, client client.Client, ctx context.Context) (*corev1.Service, error) {
+	var ports []int32
+	var ip string
+	podKey := pod.GetNamespace() + "/" + pod.GetName()
+	n.mutex.RLock()
+	allocatedPorts, exist := n.podAllocate[podKey]
+	n.mutex.RUnlock()
+	if exist {
+		ipPorts := strings.Split(allocatedPorts, ":")
+		ip = ipPorts[0]
+		ports = util.StringToInt32Slice(ipPorts[1], ",")
+	} else {
+		var err error
+		ip, ports, err = n.allocate(config.ips, len(config.targetPorts), podKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(ports) != len(config.targetPorts) {
+		return nil, fmt.Errorf("the number of allocated ports %v of pod %s does not match PortProtocols", ports, podKey)
+	}
+
+	svcPorts := make([]corev1.ServicePort, 0)
+	for i := 0; i < len(config.targetPorts); i++ {
+		svcPorts = append(svcPorts, corev1.ServicePort{
+			Name:       strconv.Itoa(config.targetPorts[i]),
+			Port:       ports[i],
+			Protocol:   config.protocols[i],
+			TargetPort: intstr.FromInt(config.targetPorts[i]),
+		})
+	}
+
+	annotations := map[string]string{
+		NlbRBSAnnotationKey: NlbRBSEnabled,
+		NlbIPAnnotationKey:  ip,
+		NlbConfigHashKey:    util.GetHash(config),
+	}
+	for key, value := range config.annotations {
+		annotations[key] = value
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            pod.GetName(),
+			Namespace:       pod.GetNamespace(),
+			Labels:          utils.ServiceLabels(NlbNetwork, nil),
+			Annotations:     annotations,
+			OwnerReferences: getSvcOwnerReference(client, ctx, pod, config.isFixed),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:                  corev1.ServiceTypeLoadBalancer,
+			LoadBalancerIP:        ip,
+			ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+			Selector: map[string]string{
+				SvcSelectorKey: pod.GetName(),
+			},
+			Ports: svcPorts,
+		},
+	}, nil
+}
+
+func getSvcOwnerReference(c client.Client, ctx context.Context, pod *corev1.Pod, isFixed bool) []metav1.OwnerReference {
+	ownerReferences := []metav1.OwnerReference{
+		{
+			APIVersion:         pod.APIVersion,
+			Kind:               pod.Kind,
+			Name:               pod.GetName(),
+			UID:                pod.GetUID(),
+			Controller:         ptr.To[bool](true),
+			BlockOwnerDeletion: ptr.To[bool](true),
+		},
+	}
+	if isFixed {
+		gss, err := util.GetGameServerSetOfPod(pod, c, ctx)
+		if err == nil {
+			ownerReferences = []metav1.OwnerReference{
+				{
+					APIVersion:         gss.APIVersion,
+					Kind:               gss.Kind,
+					Name:               gss.GetName(),
+					UID:                gss.GetUID(),
+					Controller:         ptr.To[bool](true),
+					BlockOwnerDeletion: ptr.To[bool](true),
+				},
+			}
+		}
+	}
+	return ownerReferences
+}
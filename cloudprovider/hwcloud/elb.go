@@ -0,0 +1,567 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hwcloud
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	log "k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/cloudprovider"
+	cperrors "github.com/openkruise/kruise-game/cloudprovider/errors"
+	provideroptions "github.com/openkruise/kruise-game/cloudprovider/options"
+	"github.com/openkruise/kruise-game/cloudprovider/utils"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+const (
+	ElbNetwork              = "HwCloud-ELB"
+	AliasELB                = "HwCloud-ELB-Network"
+	ElbIdsConfigName        = "ElbIds"
+	ElbClassConfigName      = "ElbClass"
+	PortProtocolsConfigName = "PortProtocols"
+	FixedConfigName         = "Fixed"
+	ElbAnnotations          = "Annotations"
+	ElbConfigHashKey        = "game.kruise.io/network-config-hash"
+	// ElbIdLabelKey is the label of Services created by the plugin, whose value is the id of the ELB instance
+	// shared by the listeners of the Services.
+	ElbIdLabelKey = "game.kruise.io/hwcloud-elb-id"
+	// ElbIdAnnotationKey specifies the existing ELB instance of the Service in CCE.
+	ElbIdAnnotationKey = "kubernetes.io/elb.id"
+	// ElbClassAnnotationKey specifies the type of the ELB instance in CCE, which is union for shared load balancers
+	// and performance for dedicated load balancers.
+	ElbClassAnnotationKey = "kubernetes.io/elb.class"
+	SvcSelectorKey        = "statefulset.kubernetes.io/pod-name"
+)
+
+const (
+	// SharedElbClass is the shared load balancer of Huawei Cloud ELB.
+	SharedElbClass = "shared"
+	// DedicatedElbClass is the dedicated load balancer of Huawei Cloud ELB.
+	DedicatedElbClass = "dedicated"
+)
+
+var elbClassAnnotationValues = map[string]string{
+	SharedElbClass:    "union",
+	DedicatedElbClass: "performance",
+}
+
+type portAllocated map[int32]bool
+
+// ElbPlugin creates a LoadBalancer Service for each pod, and the cloud controller of CCE creates the listeners of
+// the existing Huawei Cloud ELB instances for each Service. The listeners of the pods share the ELB instances
+// with different ports, which are allocated from the port range of the provider options.
+type ElbPlugin struct {
+	maxPort     int32
+	minPort     int32
+	cache       map[string]portAllocated
+	podAllocate map[string]string
+	mutex       sync.RWMutex
+}
+
+type elbConfig struct {
+	elbIds      []string
+	elbClass    string
+	targetPorts []int
+	protocols   []corev1.Protocol
+	isFixed     bool
+	annotations map[string]string
+}
+
+func (e *ElbPlugin) Name() string {
+	return ElbNetwork
+}
+
+func (e *ElbPlugin) Alias() string {
+	return AliasELB
+}
+
+func (e *ElbPlugin) Init(client client.Client, options cloudprovider.CloudProviderOptions, ctx context.Context) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	hwOptions, ok := options.(provideroptions.HwCloudOptions)
+	if !ok {
+		return cperrors.ToPluginError(fmt.Errorf("failed to convert options to hwCloudOptions"), cperrors.InternalError)
+	}
+	e.minPort = hwOptions.ELBOptions.MinPort
+	e.maxPort = hwOptions.ELBOptions.MaxPort
+
+	svcList := &corev1.ServiceList{}
+	err := client.List(ctx, svcList)
+	if err != nil {
+		return err
+	}
+
+	e.cache, e.podAllocate = initElbCache(svcList.Items, e.minPort, e.maxPort)
+	return nil
+}
+
+func initElbCache(svcList []corev1.Service, minPort, maxPort int32) (map[string]portAllocated, map[string]string) {
+	newCache := make(map[string]portAllocated)
+	newPodAllocate := make(map[string]string)
+	for _, svc := range svcList {
+		elbId := svc.GetLabels()[ElbIdLabelKey]
+		if elbId == "" {
+			continue
+		}
+		if newCache[elbId] == nil {
+			newCache[elbId] = newPortAllocated(minPort, maxPort)
+		}
+		var ports []int32
+		for _, port := range svc.Spec.Ports {
+			if port.Port < maxPort && port.Port >= minPort {
+				newCache[elbId][port.Port] = true
+				ports = append(ports, port.Port)
+			}
+		}
+		if len(ports) != 0 {
+			newPodAllocate[svc.GetNamespace()+"/"+svc.GetName()] = elbId + ":" + util.Int32SliceToString(ports, ",")
+		}
+	}
+	log.Infof("[%s] podAllocate cache complete initialization: %v", ElbNetwork, newPodAllocate)
+	return newCache, newPodAllocate
+}
+
+func newPortAllocated(minPort, maxPort int32) portAllocated {
+	allocated := make(portAllocated, maxPort-minPort)
+	for i := minPort; i < maxPort; i++ {
+		allocated[i] = false
+	}
+	return allocated
+}
+
+func (e *ElbPlugin) OnPodAdded(client client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, cperrors.PluginError) {
+	return pod, nil
+}
+
+func (e *ElbPlugin) OnPodUpdated(client client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, cperrors.PluginError) {
+	networkManager := utils.NewNetworkManager(pod, client)
+
+	networkStatus, err := networkManager.GetNetworkStatus()
+	if err != nil {
+		return pod, cperrors.ToPluginError(err, cperrors.InternalError)
+	}
+	networkConfig := networkManager.GetNetworkConfig()
+	config := parseElbConfig(networkConfig)
+	if networkStatus == nil {
+		pod, err := networkManager.UpdateNetworkStatus(gamekruiseiov1alpha1.NetworkStatus{
+			CurrentNetworkState: gamekruiseiov1alpha1.NetworkNotReady,
+		}, pod)
+		return pod, cperrors.ToPluginError(err, cperrors.InternalError)
+	}
+
+	// get svc
+	svc := &corev1.Service{}
+	err = client.Get(ctx, types.NamespacedName{
+		Name:      pod.GetName(),
+		Namespace: pod.GetNamespace(),
+	}, svc)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			newSvc, err := e.consSvc(config, pod, client, ctx)
+			if err != nil {
+				if utils.IsPortExhausted(err) {
+					utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkPortExhausted, err.Error())
+					pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
+					return pod, cperrors.ToPluginError(err, cperrors.InternalError)
+				}
+				return pod, cperrors.ToPluginError(err, cperrors.ParameterError)
+			}
+			utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkServiceMissing, "service is being created")
+			if pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod); err != nil {
+				return pod, cperrors.NewPluginError(cperrors.InternalError, err.Error())
+			}
+			return pod, cperrors.ToPluginError(client.Create(ctx, newSvc), cperrors.ApiCallError)
+		}
+		return pod, cperrors.NewPluginError(cperrors.ApiCallError, err.Error())
+	}
+
+	// handle the Service of the pod's name not created by the plugin
+	if stop, pod, pluginErr := utils.HandleServiceCollision(client, ctx, networkManager, pod, svc, networkStatus); stop {
+		return pod, pluginErr
+	}
+
+	// update svc
+	if util.GetHash(config) != svc.GetAnnotations()[ElbConfigHashKey] {
+		networkStatus.CurrentNetworkState = gamekruiseiov1alpha1.NetworkNotReady
+		pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
+		if err != nil {
+			return pod, cperrors.NewPluginError(cperrors.InternalError, err.Error())
+		}
+		newSvc, err := e.consSvc(config, pod, client, ctx)
+		if err != nil {
+			return pod, cperrors.ToPluginError(err, cperrors.ParameterError)
+		}
+		newSvc.SetResourceVersion(svc.GetResourceVersion())
+		return pod, cperrors.ToPluginError(client.Update(ctx, newSvc), cperrors.ApiCallError)
+	}
+
+	// disable network
+	if networkManager.GetNetworkDisabled() && svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		svc.Spec.Type = corev1.ServiceTypeClusterIP
+		return pod, cperrors.ToPluginError(client.Update(ctx, svc), cperrors.ApiCallError)
+	}
+
+	// enable network
+	if !networkManager.GetNetworkDisabled() && svc.Spec.Type == corev1.ServiceTypeClusterIP {
+		svc.Spec.Type = corev1.ServiceTypeLoadBalancer
+		return pod, cperrors.ToPluginError(client.Update(ctx, svc), cperrors.ApiCallError)
+	}
+
+	// network not ready
+	lbIP, lbHostname := utils.GetLoadBalancerAddress(svc.Status.LoadBalancer.Ingress)
+	if lbIP == "" && lbHostname == "" {
+		utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkWaitingForLB, "load balancer has not assigned the address")
+		pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
+		return pod, cperrors.ToPluginError(err, cperrors.InternalError)
+	}
+
+	// allow not ready containers
+	if util.IsAllowNotReadyContainers(networkManager.GetNetworkConfig()) {
+		toUpDateSvc, err := utils.AllowNotReadyContainers(client, ctx, pod, svc, false)
+		if err != nil {
+			return pod, err
+		}
+
+		if toUpDateSvc {
+			err := client.Update(ctx, svc)
+			if err != nil {
+				return pod, cperrors.ToPluginError(err, cperrors.ApiCallError)
+			}
+		}
+	}
+
+	// network ready
+	internalAddresses := make([]gamekruiseiov1alpha1.NetworkAddress, 0)
+	externalAddresses := make([]gamekruiseiov1alpha1.NetworkAddress, 0)
+	for _, port := range svc.Spec.Ports {
+		instrIPort := port.TargetPort
+		instrEPort := intstr.FromInt(int(port.Port))
+		internalAddresses = append(internalAddresses, gamekruiseiov1alpha1.NetworkAddress{
+			IP: pod.Status.PodIP,
+			Ports: []gamekruiseiov1alpha1.NetworkPort{
+				{
+					Name:     instrIPort.String(),
+					Port:     &instrIPort,
+					Protocol: port.Protocol,
+				},
+			},
+		})
+		externalAddresses = append(externalAddresses, gamekruiseiov1alpha1.NetworkAddress{
+			IP:             lbIP,
+			EndPoint:       lbHostname,
+			LoadBalancerId: svc.GetLabels()[ElbIdLabelKey],
+			Ports: []gamekruiseiov1alpha1.NetworkPort{
+				{
+					Name:     instrIPort.String(),
+					Port:     &instrEPort,
+					Protocol: port.Protocol,
+				},
+			},
+		})
+	}
+	networkStatus.InternalAddresses = internalAddresses
+	networkStatus.ExternalAddresses = externalAddresses
+	networkStatus.CurrentNetworkState = gamekruiseiov1alpha1.NetworkReady
+	pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
+	return pod, cperrors.ToPluginError(err, cperrors.InternalError)
+}
+
+func (e *ElbPlugin) OnPodDeleted(client client.Client, pod *corev1.Pod, ctx context.Context) cperrors.PluginError {
+	networkManager := utils.NewNetworkManager(pod, client)
+	networkConfig := networkManager.GetNetworkConfig()
+	sc := parseElbConfig(networkConfig)
+
+	var podKeys []string
+	if sc.isFixed {
+		gss, err := util.GetGameServerSetOfPod(pod, client, ctx)
+		if err != nil && !errors.IsNotFound(err) {
+			return cperrors.ToPluginError(err, cperrors.ApiCallError)
+		}
+		// gss exists in cluster, do not deAllocate.
+		if err == nil && gss.GetDeletionTimestamp() == nil {
+			return nil
+		}
+		// identity of gss is retained, do not deAllocate.
+		retained, err := util.IsServiceRetained(pod, client, ctx)
+		if err != nil {
+			return cperrors.ToPluginError(err, cperrors.ApiCallError)
+		}
+		if retained {
+			return nil
+		}
+		// gss not exists in cluster, deAllocate all the ports related to it.
+		gssName := pod.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]
+		e.mutex.RLock()
+		for key := range e.podAllocate {
+			if strings.Contains(key, pod.GetNamespace()+"/"+gssName) {
+				podKeys = append(podKeys, key)
+			}
+		}
+		e.mutex.RUnlock()
+	} else {
+		podKeys = append(podKeys, pod.GetNamespace()+"/"+pod.GetName())
+	}
+
+	for _, podKey := range podKeys {
+		e.deAllocate(podKey)
+	}
+
+	return nil
+}
+
+// Capacity returns the number of pods which can still be allocated ports on the ELB instances of the network conf.
+func (e *ElbPlugin) Capacity(client client.Client, conf []gamekruiseiov1alpha1.NetworkConfParams, ctx context.Context) (int, error) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	config := parseElbConfig(conf)
+	num := len(config.targetPorts)
+	if num == 0 {
+		return math.MaxInt32, nil
+	}
+	capacity := 0
+	for _, elbId := range config.elbIds {
+		free := 0
+		for i := e.minPort; i < e.maxPort; i++ {
+			if !e.cache[elbId][i] {
+				free++
+			}
+		}
+		capacity += free / num
+	}
+	return capacity, nil
+}
+
+// allocate selects the first ELB instance with adequate free ports, and allocates the lowest free ports of it.
+func (e *ElbPlugin) allocate(elbIds []string, num int, nsName string) (string, []int32, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for _, elbId := range elbIds {
+		if e.cache[elbId] == nil {
+			e.cache[elbId] = newPortAllocated(e.minPort, e.maxPort)
+		}
+		var ports []int32
+		for p := e.minPort; p < e.maxPort && len(ports) < num; p++ {
+			if !e.cache[elbId][p] {
+				ports = append(ports, p)
+			}
+		}
+		if len(ports) < num {
+			continue
+		}
+		for _, port := range ports {
+			e.cache[elbId][port] = true
+		}
+		e.podAllocate[nsName] = elbId + ":" + util.Int32SliceToString(ports, ",")
+		log.Infof("pod %s allocate hwcloud elb %s ports %v", nsName, elbId, ports)
+		return elbId, ports, nil
+	}
+	return "", nil, utils.NewPortExhaustedError("no enough ports of the ELB instances %v for pod %s", elbIds, nsName)
+}
+
+func (e *ElbPlugin) deAllocate(nsName string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	allocatedPorts, exist := e.podAllocate[nsName]
+	if !exist {
+		return
+	}
+
+	elbPorts := strings.Split(allocatedPorts, ":")
+	elbId := elbPorts[0]
+	ports := util.StringToInt32Slice(elbPorts[1], ",")
+	for _, port := range ports {
+		e.cache[elbId][port] = false
+	}
+
+	delete(e.podAllocate, nsName)
+	log.Infof("pod %s deallocate hwcloud elb %s ports %v", nsName, elbId, ports)
+}
+
+func init() {
+	elbPlugin := ElbPlugin{
+		mutex: sync.RWMutex{},
+	}
+	hwcloudProvider.registerPlugin(&elbPlugin)
+}
+
+func parseElbConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) *elbConfig {
+	var elbIds []string
+	elbClass := SharedElbClass
+	ports := make([]int, 0)
+	protocols := make([]corev1.Protocol, 0)
+	isFixed := false
+	annotations := map[string]string{}
+	for _, c := range conf {
+		switch c.Name {
+		case ElbIdsConfigName:
+			for _, elbId := range strings.Split(c.Value, ",") {
+				if elbId != "" {
+					elbIds = append(elbIds, elbId)
+				}
+			}
+		case ElbClassConfigName:
+			if _, ok := elbClassAnnotationValues[c.Value]; ok {
+				elbClass = c.Value
+			} else {
+				log.Warningf("hwcloud elb class %s is invalid", c.Value)
+			}
+		case PortProtocolsConfigName:
+			for _, pp := range strings.Split(c.Value, ",") {
+				ppSlice := strings.Split(pp, "/")
+				port, err := strconv.Atoi(ppSlice[0])
+				if err != nil {
+					continue
+				}
+				ports = append(ports, port)
+				if len(ppSlice) != 2 {
+					protocols = append(protocols, corev1.ProtocolTCP)
+				} else {
+					protocols = append(protocols, corev1.Protocol(ppSlice[1]))
+				}
+			}
+		case FixedConfigName:
+			v, err := strconv.ParseBool(c.Value)
+			if err != nil {
+				continue
+			}
+			isFixed = v
+		case ElbAnnotations:
+			for _, anno := range strings.Split(c.Value, ",") {
+				annoKV := strings.SplitN(anno, ":", 2)
+				if len(annoKV) == 2 {
+					annotations[annoKV[0]] = annoKV[1]
+				} else {
+					log.Warningf("hwcloud elb annotation %s is invalid", annoKV[0])
+				}
+			}
+		}
+	}
+	return &elbConfig{
+		elbIds:      elbIds,
+		elbClass:    elbClass,
+		protocols:   protocols,
+		targetPorts: ports,
+		isFixed:     isFixed,
+		annotations: annotations,
+	}
+}
+
+func (e *ElbPlugin) consSvc(config *elbConfig, pod *corev1.Pod, client client.Client, ctx context.Context) (*corev1.Service, error) {
+	var ports []int32
+	var elbId string
+	podKey := pod.GetNamespace() + "/" + pod.GetName()
+	e.mutex.RLock()
+	allocatedPorts, exist := e.podAllocate[podKey]
+	e.mutex.RUnlock()
+	if exist {
+		elbPorts := strings.Split(allocatedPorts, ":")
+		elbId = elbPorts[0]
+		ports = util.StringToInt32Slice(elbPorts[1], ",")
+	} else {
+		var err error
+		elbId, ports, err = e.allocate(config.elbIds, len(config.targetPorts), podKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(ports) != len(config.targetPorts) {
+		return nil, fmt.Errorf("the number of allocated ports %v of pod %s does not match PortProtocols", ports, podKey)
+	}
+
+	svcPorts := make([]corev1.ServicePort, 0)
+	for i := 0; i < len(config.targetPorts); i++ {
+		svcPorts = append(svcPorts, corev1.ServicePort{
+			Name:       strconv.Itoa(config.targetPorts[i]),
+			Port:       ports[i],
+			Protocol:   config.protocols[i],
+			TargetPort: intstr.FromInt(config.targetPorts[i]),
+		})
+	}
+
+	annotations := map[string]string{
+		ElbIdAnnotationKey:    elbId,
+		ElbClassAnnotationKey: elbClassAnnotationValues[config.elbClass],
+		ElbConfigHashKey:      util.GetHash(config),
+	}
+	for key, value := range config.annotations {
+		annotations[key] = value
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.GetName(),
+			Namespace: pod.GetNamespace(),
+			Labels: utils.ServiceLabels(ElbNetwork, map[string]string{
+				ElbIdLabelKey: elbId,
+			}),
+			Annotations:     annotations,
+			OwnerReferences: getSvcOwnerReference(client, ctx, pod, config.isFixed),
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeLoadBalancer,
+			Selector: map[string]string{
+				SvcSelectorKey: pod.GetName(),
+			},
+			Ports: svcPorts,
+		},
+	}, nil
+}
+
+func getSvcOwnerReference(c client.Client, ctx context.Context, pod *corev1.Pod, isFixed bool) []metav1.OwnerReference {
+	ownerReferences := []metav1.OwnerReference{
+		{
+			APIVersion:         pod.APIVersion,
+			Kind:               pod.Kind,
+			Name:               pod.GetName(),
+			UID:                pod.GetUID(),
+			Controller:         ptr.To[bool](true),
+			BlockOwnerDeletion: ptr.To[bool](true),
+		},
+	}
+	if isFixed {
+		gss, err := util.GetGameServerSetOfPod(pod, c, ctx)
+		if err == nil {
+			ownerReferences = []metav1.OwnerReference{
+				{
+					APIVersion:         gss.APIVersion,
+					Kind:               gss.Kind,
+					Name:               gss.GetName(),
+					UID:                gss.GetUID(),
+					Controller:         ptr.To[bool](true),
+					BlockOwnerDeletion: ptr.To[bool](true),
+				},
+			}
+		}
+	}
+	return ownerReferences
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hwcloud
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+func newElbPlugin(minPort, maxPort int32) *ElbPlugin {
+	return &ElbPlugin{
+		minPort:     minPort,
+		maxPort:     maxPort,
+		cache:       make(map[string]portAllocated),
+		podAllocate: make(map[string]string),
+		mutex:       sync.RWMutex{},
+	}
+}
+
+func TestAllocateDeAllocate(t *testing.T) {
+	plugin := newElbPlugin(500, 503)
+	elbIds := []string{"elb-1", "elb-2"}
+
+	elbId, ports, err := plugin.allocate(elbIds, 2, "xxx/case-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elbId != "elb-1" || !reflect.DeepEqual(ports, []int32{500, 501}) {
+		t.Errorf("expect elb-1 [500 501], but actually got %s %v", elbId, ports)
+	}
+
+	// the first elb has not enough ports
+	elbId, ports, err = plugin.allocate(elbIds, 2, "xxx/case-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elbId != "elb-2" || !reflect.DeepEqual(ports, []int32{500, 501}) {
+		t.Errorf("expect elb-2 [500 501], but actually got %s %v", elbId, ports)
+	}
+
+	if _, _, err = plugin.allocate(elbIds, 2, "xxx/case-2"); err == nil {
+		t.Errorf("expect error when ports are exhausted")
+	}
+
+	plugin.deAllocate("xxx/case-0")
+	if _, exist := plugin.podAllocate["xxx/case-0"]; exist {
+		t.Errorf("podAllocate[xxx/case-0] exists after deallocated")
+	}
+	elbId, ports, err = plugin.allocate(elbIds, 2, "xxx/case-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elbId != "elb-1" || !reflect.DeepEqual(ports, []int32{500, 501}) {
+		t.Errorf("expect elb-1 [500 501], but actually got %s %v", elbId, ports)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	plugin := newElbPlugin(500, 510)
+	if _, _, err := plugin.allocate([]string{"elb-1"}, 3, "xxx/case-0"); err != nil {
+		t.Fatal(err)
+	}
+	conf := []gamekruiseiov1alpha1.NetworkConfParams{
+		{Name: ElbIdsConfigName, Value: "elb-1,elb-2"},
+		{Name: PortProtocolsConfigName, Value: "80/TCP,81/UDP"},
+	}
+	capacity, err := plugin.Capacity(nil, conf, context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 7 free ports of elb-1 and 10 free ports of elb-2
+	if capacity != 3+5 {
+		t.Errorf("expect capacity 8, but actually got %d", capacity)
+	}
+}
+
+func TestParseElbConfig(t *testing.T) {
+	tests := []struct {
+		conf      []gamekruiseiov1alpha1.NetworkConfParams
+		elbConfig *elbConfig
+	}{
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: ElbIdsConfigName, Value: "elb-1,elb-2"},
+				{Name: ElbClassConfigName, Value: DedicatedElbClass},
+				{Name: PortProtocolsConfigName, Value: "80,81/UDP"},
+				{Name: FixedConfigName, Value: "true"},
+				{Name: ElbAnnotations, Value: "kubernetes.io/elb.lb-algorithm:ROUND_ROBIN"},
+			},
+			elbConfig: &elbConfig{
+				elbIds:      []string{"elb-1", "elb-2"},
+				elbClass:    DedicatedElbClass,
+				targetPorts: []int{80, 81},
+				protocols:   []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolUDP},
+				isFixed:     true,
+				annotations: map[string]string{"kubernetes.io/elb.lb-algorithm": "ROUND_ROBIN"},
+			},
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: ElbIdsConfigName, Value: "elb-1"},
+				{Name: ElbClassConfigName, Value: "unknown"},
+				{Name: PortProtocolsConfigName, Value: "80"},
+			},
+			elbConfig: &elbConfig{
+				elbIds:      []string{"elb-1"},
+				elbClass:    SharedElbClass,
+				targetPorts: []int{80},
+				protocols:   []corev1.Protocol{corev1.ProtocolTCP},
+				annotations: map[string]string{},
+			},
+		},
+	}
+	for i, test := range tests {
+		actual := parseElbConfig(test.conf)
+		if !reflect.DeepEqual(actual, test.elbConfig) {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.elbConfig, actual)
+		}
+	}
+}
+
+func TestInitElbCache(t *testing.T) {
+	svcList := []corev1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      "case-0",
+				Labels:    map[string]string{ElbIdLabelKey: "elb-1"},
+			},
+			Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 501}, {Port: 502}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "other"},
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 503}}},
+		},
+	}
+	cache, podAllocate := initElbCache(svcList, 500, 510)
+	if !cache["elb-1"][501] || !cache["elb-1"][502] || cache["elb-1"][503] {
+		t.Errorf("unexpected cache %v", cache)
+	}
+	if !reflect.DeepEqual(podAllocate, map[string]string{"xxx/case-0": "elb-1:501,502"}) {
+		t.Errorf("unexpected podAllocate %v", podAllocate)
+	}
+}
+
+func TestConsSvc(t *testing.T) {
+	plugin := newElbPlugin(500, 510)
+	config := &elbConfig{
+		elbIds:      []string{"elb-1"},
+		elbClass:    DedicatedElbClass,
+		targetPorts: []int{80},
+		protocols:   []corev1.Protocol{corev1.ProtocolUDP},
+		annotations: map[string]string{},
+	}
+	pod := &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0", UID: "uid-pod"},
+	}
+	svc, err := plugin.consSvc(config, pod, nil, context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "xxx",
+			Name:      "case-0",
+			Labels:    map[string]string{ElbIdLabelKey: "elb-1", gamekruiseiov1alpha1.GameServerNetworkType: ElbNetwork},
+			Annotations: map[string]string{
+				ElbIdAnnotationKey:    "elb-1",
+				ElbClassAnnotationKey: "performance",
+				ElbConfigHashKey:      util.GetHash(config),
+			},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion:         "v1",
+				Kind:               "Pod",
+				Name:               "case-0",
+				UID:                "uid-pod",
+				Controller:         ptr.To[bool](true),
+				BlockOwnerDeletion: ptr.To[bool](true),
+			}},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeLoadBalancer,
+			Selector: map[string]string{SvcSelectorKey: "case-0"},
+			Ports: []corev1.ServicePort{{
+				Name:       "80",
+				Port:       500,
+				Protocol:   corev1.ProtocolUDP,
+				TargetPort: intstr.FromInt(80),
+			}},
+		},
+	}
+	if !reflect.DeepEqual(svc, expected) {
+		t.Errorf("expect %v, but actually got %v", expected, svc)
+	}
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	log "k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/cloudprovider"
+	"github.com/openkruise/kruise-game/cloudprovider/errors"
+	"github.com/openkruise/kruise-game/cloudprovider/utils"
+	"github.com/openkruise/kruise-game/pkg/sdk"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+const (
+	HostNetworkNetwork = "Kubernetes-HostNetwork"
+	// ContainerPortNamesConfigName is the named ports which the game server listens on the host network.
+	// Its value format is as follows, containerName:name1/protocol1,name2/protocol2,... e.g. game-server:game/UDP,query/TCP
+	// When no protocol is specified, TCP is used by default. When no container name is specified, the ports belong to
+	// the game container.
+	ContainerPortNamesConfigName = "ContainerPortNames"
+)
+
+// HostNetworkPlugin runs game servers on the host network. Since the ports of the host network can not be mapped,
+// the plugin allocates host ports from the port range of Kubernetes-HostPort, declares them as both the container
+// ports and the host ports so that the scheduler never puts two pods using the same port on a node, and tells the
+// game server the ports to listen on by the environment variables OKG_EXTERNAL_PORT_<NAME>.
+type HostNetworkPlugin struct {
+	hostPort *HostPortPlugin
+}
+
+func init() {
+	kubernetesProvider.registerPlugin(&HostNetworkPlugin{hostPort: hostPortPlugin})
+}
+
+type hostNetworkPort struct {
+	containerName string
+	name          string
+	protocol      corev1.Protocol
+}
+
+type hostNetworkConfig struct {
+	ports                 []hostNetworkPort
+	nodeAddressAnnotation string
+}
+
+func (hnp *HostNetworkPlugin) Name() string {
+	return HostNetworkNetwork
+}
+
+func (hnp *HostNetworkPlugin) Alias() string {
+	return ""
+}
+
+// Init does nothing, since the allocated host ports are shared with Kubernetes-HostPort and rebuilt by its Init.
+func (hnp *HostNetworkPlugin) Init(c client.Client, options cloudprovider.CloudProviderOptions, ctx context.Context) error {
+	return nil
+}
+
+func (hnp *HostNetworkPlugin) OnPodAdded(c client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, errors.PluginError) {
+	log.Infof("Receiving pod %s/%s ADD Operation", pod.GetNamespace(), pod.GetName())
+	if util.IsWindowsPodSpec(&pod.Spec) {
+		return pod, errors.NewPluginError(errors.ParameterError, "hostNetwork is not supported by Windows nodes")
+	}
+	podNow := &corev1.Pod{}
+	err := c.Get(ctx, types.NamespacedName{
+		Namespace: pod.GetNamespace(),
+		Name:      pod.GetName(),
+	}, podNow)
+	if err == nil {
+		log.Infof("There is a pod with same ns/name(%s/%s) exists in cluster, do not allocate", pod.GetNamespace(), pod.GetName())
+		return pod, errors.NewPluginError(errors.InternalError, "There is a pod with same ns/name exists in cluster")
+	}
+	if !k8serrors.IsNotFound(err) {
+		return pod, errors.NewPluginError(errors.ApiCallError, err.Error())
+	}
+
+	hnc, err := parseHostNetworkConfig(utils.NewNetworkManager(pod, c).GetNetworkConfig())
+	if err != nil {
+		return pod, errors.NewPluginError(errors.ParameterError, err.Error())
+	}
+	containerIndexes := make([]int, len(hnc.ports))
+	for i, port := range hnc.ports {
+		containerIndexes[i] = getContainerIndex(pod, port.containerName)
+		if containerIndexes[i] < 0 {
+			return pod, errors.NewPluginError(errors.ParameterError, fmt.Sprintf("container of port %s is not found", port.name))
+		}
+	}
+
+	nsname := pod.GetNamespace() + "/" + pod.GetName()
+	var hostPorts []int32
+	if str, ok := hnp.hostPort.podAllocated[nsname]; ok {
+		hostPorts = util.StringToInt32Slice(str, ",")
+		log.Infof("pod %s/%s use hostPorts %v , which are allocated before", pod.GetNamespace(), pod.GetName(), hostPorts)
+	} else {
+		hostPorts = hnp.hostPort.allocate(len(hnc.ports), nsname, pod.Spec.NodeName)
+		if hostPorts == nil {
+			return pod, errors.NewPluginError(errors.InternalError, fmt.Sprintf("no enough host ports for pod %s/%s", pod.GetNamespace(), pod.GetName()))
+		}
+		log.Infof("pod %s/%s allocated hostPorts %v", pod.GetNamespace(), pod.GetName(), hostPorts)
+	}
+	if len(hostPorts) != len(hnc.ports) {
+		return pod, errors.NewPluginError(errors.InternalError, fmt.Sprintf("pod %s/%s is allocated %d host ports, but %d ports are declared", pod.GetNamespace(), pod.GetName(), len(hostPorts), len(hnc.ports)))
+	}
+
+	pod.Spec.HostNetwork = true
+	if pod.Spec.DNSPolicy == "" || pod.Spec.DNSPolicy == corev1.DNSClusterFirst {
+		// keep resolving the Services of the cluster on the host network
+		pod.Spec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+	}
+	// the container port must equal to the host port on the host network, and the args of the containers
+	// can refer to the ports by $(OKG_EXTERNAL_PORT_<NAME>)
+	for i, port := range hnc.ports {
+		container := &pod.Spec.Containers[containerIndexes[i]]
+		container.Ports = append(container.Ports, corev1.ContainerPort{
+			ContainerPort: hostPorts[i],
+			HostPort:      hostPorts[i],
+			Protocol:      port.protocol,
+		})
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:  sdk.ExternalPortEnv(port.name),
+			Value: strconv.Itoa(int(hostPorts[i])),
+		})
+	}
+	return pod, nil
+}
+
+func (hnp *HostNetworkPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, errors.PluginError) {
+	log.Infof("Receiving pod %s/%s UPDATE Operation", pod.GetNamespace(), pod.GetName())
+	networkManager := utils.NewNetworkManager(pod, c)
+	hnc, err := parseHostNetworkConfig(networkManager.GetNetworkConfig())
+	if err != nil {
+		return pod, errors.NewPluginError(errors.ParameterError, err.Error())
+	}
+
+	// network not ready
+	networkPorts := getHostNetworkPorts(pod, hnc)
+	if pod.Spec.NodeName == "" || pod.Status.PodIP == "" || len(networkPorts) != len(hnc.ports) {
+		pod, err := networkManager.UpdateNetworkStatus(gamekruiseiov1alpha1.NetworkStatus{
+			CurrentNetworkState: gamekruiseiov1alpha1.NetworkNotReady,
+		}, pod)
+		return pod, errors.ToPluginError(err, errors.InternalError)
+	}
+
+	node := &corev1.Node{}
+	err = c.Get(ctx, types.NamespacedName{
+		Name: pod.Spec.NodeName,
+	}, node)
+	if err != nil {
+		return pod, errors.NewPluginError(errors.ApiCallError, err.Error())
+	}
+	hnp.hostPort.markNode(node.GetName(), hnp.hostPort.getHostPorts(pod))
+
+	// the game server listens on the same ports of the internal and the external addresses
+	networkStatus := gamekruiseiov1alpha1.NetworkStatus{
+		InternalAddresses: []gamekruiseiov1alpha1.NetworkAddress{
+			{
+				IP:    pod.Status.PodIP,
+				Ports: networkPorts,
+			},
+		},
+		ExternalAddresses: []gamekruiseiov1alpha1.NetworkAddress{
+			{
+				IP:    getNodeAddress(node, hnc.nodeAddressAnnotation),
+				Ports: networkPorts,
+			},
+		},
+		CurrentNetworkState: gamekruiseiov1alpha1.NetworkReady,
+	}
+	pod, err = networkManager.UpdateNetworkStatus(networkStatus, pod)
+	return pod, errors.ToPluginError(err, errors.InternalError)
+}
+
+func (hnp *HostNetworkPlugin) OnPodDeleted(c client.Client, pod *corev1.Pod, ctx context.Context) errors.PluginError {
+	return hnp.hostPort.OnPodDeleted(c, pod, ctx)
+}
+
+func (hnp *HostNetworkPlugin) ValidateNetworkConf(conf []gamekruiseiov1alpha1.NetworkConfParams) error {
+	_, err := parseHostNetworkConfig(conf)
+	return err
+}
+
+func parseHostNetworkConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) (*hostNetworkConfig, error) {
+	hnc := &hostNetworkConfig{}
+	names := make(map[string]bool)
+	for _, c := range conf {
+		switch c.Name {
+		case ContainerPortNamesConfigName:
+			containerName := ""
+			value := c.Value
+			if i := strings.Index(value, ":"); i >= 0 {
+				containerName = strings.TrimSpace(value[:i])
+				value = value[i+1:]
+			}
+			for _, item := range strings.Split(value, ",") {
+				npSlice := strings.Split(strings.TrimSpace(item), "/")
+				name := strings.TrimSpace(npSlice[0])
+				if name == "" || len(npSlice) > 2 {
+					return nil, fmt.Errorf("invalid port name %q of %s", item, ContainerPortNamesConfigName)
+				}
+				if names[name] {
+					return nil, fmt.Errorf("port name %s of %s is repeated", name, ContainerPortNamesConfigName)
+				}
+				names[name] = true
+				protocol := corev1.ProtocolTCP
+				if len(npSlice) == 2 {
+					protocol = corev1.Protocol(strings.TrimSpace(npSlice[1]))
+				}
+				if protocol != corev1.ProtocolTCP && protocol != corev1.ProtocolUDP && protocol != corev1.ProtocolSCTP {
+					return nil, fmt.Errorf("invalid protocol %s of port %s, which should be TCP, UDP or SCTP", protocol, name)
+				}
+				hnc.ports = append(hnc.ports, hostNetworkPort{
+					containerName: containerName,
+					name:          name,
+					protocol:      protocol,
+				})
+			}
+		case NodeAddressAnnotationConfigName:
+			hnc.nodeAddressAnnotation = c.Value
+		}
+	}
+	if len(hnc.ports) == 0 {
+		return nil, fmt.Errorf("%s is required", ContainerPortNamesConfigName)
+	}
+	return hnc, nil
+}
+
+// getContainerIndex returns the index of the container, or the game container if the name is empty.
+// It returns -1 if the container is not found.
+func getContainerIndex(pod *corev1.Pod, containerName string) int {
+	if containerName == "" {
+		containerName = util.GetGameContainerName(pod)
+	}
+	for i, container := range pod.Spec.Containers {
+		if container.Name == containerName {
+			return i
+		}
+	}
+	return -1
+}
+
+// getHostNetworkPorts returns the network ports named in the config, whose ports are read from the environment
+// variables injected on admission.
+func getHostNetworkPorts(pod *corev1.Pod, hnc *hostNetworkConfig) []gamekruiseiov1alpha1.NetworkPort {
+	networkPorts := make([]gamekruiseiov1alpha1.NetworkPort, 0)
+	for _, port := range hnc.ports {
+		index := getContainerIndex(pod, port.containerName)
+		if index < 0 {
+			continue
+		}
+		envName := sdk.ExternalPortEnv(port.name)
+		for _, env := range pod.Spec.Containers[index].Env {
+			if env.Name != envName {
+				continue
+			}
+			if p, err := strconv.Atoi(env.Value); err == nil {
+				portIs := intstr.FromInt(p)
+				networkPorts = append(networkPorts, gamekruiseiov1alpha1.NetworkPort{
+					Name:     port.name,
+					Port:     &portIs,
+					Protocol: port.protocol,
+				})
+			}
+			break
+		}
+	}
+	return networkPorts
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/sdk"
+)
+
+func TestParseHostNetworkConfig(t *testing.T) {
+	tests := []struct {
+		conf   []gamekruiseiov1alpha1.NetworkConfParams
+		result *hostNetworkConfig
+		valid  bool
+	}{
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: ContainerPortNamesConfigName, Value: "game-server:game/UDP, query"},
+				{Name: NodeAddressAnnotationConfigName, Value: "example.com/public-ip"},
+			},
+			result: &hostNetworkConfig{
+				ports: []hostNetworkPort{
+					{containerName: "game-server", name: "game", protocol: corev1.ProtocolUDP},
+					{containerName: "game-server", name: "query", protocol: corev1.ProtocolTCP},
+				},
+				nodeAddressAnnotation: "example.com/public-ip",
+			},
+			valid: true,
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: ContainerPortNamesConfigName, Value: "game/UDP"},
+			},
+			result: &hostNetworkConfig{
+				ports: []hostNetworkPort{{name: "game", protocol: corev1.ProtocolUDP}},
+			},
+			valid: true,
+		},
+		{
+			conf:  nil,
+			valid: false,
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: ContainerPortNamesConfigName, Value: "game/UDP,game/TCP"},
+			},
+			valid: false,
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: ContainerPortNamesConfigName, Value: "game/QUIC"},
+			},
+			valid: false,
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: ContainerPortNamesConfigName, Value: "game,"},
+			},
+			valid: false,
+		},
+	}
+
+	for i, test := range tests {
+		hnc, err := parseHostNetworkConfig(test.conf)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+			continue
+		}
+		if test.valid && !reflect.DeepEqual(hnc, test.result) {
+			t.Errorf("case %d: expect config %v but got %v", i, test.result, hnc)
+		}
+	}
+}
+
+func TestHostNetworkPlugin(t *testing.T) {
+	hnp := &HostNetworkPlugin{
+		hostPort: &HostPortPlugin{
+			minPort:       800,
+			maxPort:       801,
+			podAllocated:  make(map[string]string),
+			portAmount:    map[int32]int{800: 0, 801: 0},
+			amountStat:    []int{2},
+			nodeAllocated: make(map[string]portAllocated),
+			mutex:         sync.RWMutex{},
+		},
+	}
+	conf, _ := json.Marshal([]gamekruiseiov1alpha1.NetworkConfParams{
+		{Name: ContainerPortNamesConfigName, Value: "game/UDP,query/TCP"},
+	})
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "xxx",
+			Name:      "case-0",
+			Annotations: map[string]string{
+				gamekruiseiov1alpha1.GameServerNetworkType: HostNetworkNetwork,
+				gamekruiseiov1alpha1.GameServerNetworkConf: string(conf),
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "game-server"}, {Name: "sidecar"}},
+		},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "192.168.0.1"},
+				{Type: corev1.NodeExternalIP, Address: "47.0.0.1"},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithObjects(node).Build()
+
+	pod, err := hnp.OnPodAdded(c, pod, context.Background())
+	if err != nil {
+		t.Fatalf("failed to add pod: %s", err.Error())
+	}
+	if !pod.Spec.HostNetwork || pod.Spec.DNSPolicy != corev1.DNSClusterFirstWithHostNet {
+		t.Errorf("expect hostNetwork with dnsPolicy ClusterFirstWithHostNet, but got %v %s", pod.Spec.HostNetwork, pod.Spec.DNSPolicy)
+	}
+	container := pod.Spec.Containers[0]
+	ports := map[string]int32{}
+	for i, port := range container.Ports {
+		if port.ContainerPort != port.HostPort {
+			t.Errorf("expect container port equal to host port, but got %v", port)
+		}
+		ports[container.Env[i].Name] = port.ContainerPort
+		if container.Env[i].Value != strconv.Itoa(int(port.ContainerPort)) {
+			t.Errorf("expect env %s to be %d, but got %s", container.Env[i].Name, port.ContainerPort, container.Env[i].Value)
+		}
+	}
+	if len(ports) != 2 || ports["OKG_EXTERNAL_PORT_GAME"] == ports["OKG_EXTERNAL_PORT_QUERY"] {
+		t.Errorf("expect two different ports, but got %v", ports)
+	}
+	if len(pod.Spec.Containers[1].Ports) != 0 {
+		t.Errorf("expect no ports of sidecar, but got %v", pod.Spec.Containers[1].Ports)
+	}
+
+	// the network is not ready before the pod is scheduled
+	pod, err = hnp.OnPodUpdated(c, pod, context.Background())
+	if err != nil {
+		t.Fatalf("failed to update pod: %s", err.Error())
+	}
+	status := &gamekruiseiov1alpha1.NetworkStatus{}
+	_ = json.Unmarshal([]byte(pod.Annotations[gamekruiseiov1alpha1.GameServerNetworkStatus]), status)
+	if status.CurrentNetworkState != gamekruiseiov1alpha1.NetworkNotReady {
+		t.Errorf("expect network NotReady, but got %s", status.CurrentNetworkState)
+	}
+
+	pod.Spec.NodeName = "node-a"
+	pod.Status.PodIP = "192.168.0.1"
+	pod, err = hnp.OnPodUpdated(c, pod, context.Background())
+	if err != nil {
+		t.Fatalf("failed to update pod: %s", err.Error())
+	}
+	status = &gamekruiseiov1alpha1.NetworkStatus{}
+	_ = json.Unmarshal([]byte(pod.Annotations[gamekruiseiov1alpha1.GameServerNetworkStatus]), status)
+	if status.CurrentNetworkState != gamekruiseiov1alpha1.NetworkReady || len(status.ExternalAddresses) != 1 || status.ExternalAddresses[0].IP != "47.0.0.1" {
+		t.Fatalf("expect network Ready on 47.0.0.1, but got %v", status)
+	}
+	for _, port := range status.ExternalAddresses[0].Ports {
+		if int32(port.Port.IntValue()) != ports[sdk.ExternalPortEnv(port.Name)] {
+			t.Errorf("expect port %s to be %d, but got %v", port.Name, ports[sdk.ExternalPortEnv(port.Name)], port.Port)
+		}
+	}
+	if !hnp.hostPort.nodeAllocated["node-a"][800] || !hnp.hostPort.nodeAllocated["node-a"][801] {
+		t.Errorf("expect ports marked on node-a, but got %v", hnp.hostPort.nodeAllocated)
+	}
+
+	if err := hnp.OnPodDeleted(c, pod, context.Background()); err != nil {
+		t.Errorf("failed to delete pod: %s", err.Error())
+	}
+	if len(hnp.hostPort.podAllocated) != 0 || len(hnp.hostPort.nodeAllocated) != 0 {
+		t.Errorf("expect ports deallocated, but got %v %v", hnp.hostPort.podAllocated, hnp.hostPort.nodeAllocated)
+	}
+}
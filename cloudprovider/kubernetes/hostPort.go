@@ -18,6 +18,7 @@ package kubernetes
 
 import (
 	"context"
+	"fmt"
 	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
 	"github.com/openkruise/kruise-game/cloudprovider"
 	"github.com/openkruise/kruise-game/cloudprovider/errors"
@@ -31,6 +32,7 @@ import (
 	log "k8s.io/klog/v2"
 	"net"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -44,21 +46,31 @@ const (
 	ContainerPortsKey = "ContainerPorts"
 )
 
+type portAllocated map[int32]bool
+
+// HostPortPlugin allocates host ports of the port range to pods. Since host ports only conflict on the same node,
+// the ports used by pods on different nodes can be the same, and the ports used the least are allocated first.
 type HostPortPlugin struct {
 	maxPort      int32
 	minPort      int32
 	podAllocated map[string]string
 	portAmount   map[int32]int
 	amountStat   []int
-	mutex        sync.RWMutex
+	// nodeAllocated is the host ports in use on each node, keyed by node name.
+	nodeAllocated map[string]portAllocated
+	mutex         sync.RWMutex
+}
+
+// hostPortPlugin is shared by Kubernetes-HostPort and Kubernetes-HostNetwork, so that the host ports allocated by
+// the two plugins never conflict.
+var hostPortPlugin = &HostPortPlugin{
+	mutex:         sync.RWMutex{},
+	podAllocated:  make(map[string]string),
+	nodeAllocated: make(map[string]portAllocated),
 }
 
 func init() {
-	hostPortPlugin := HostPortPlugin{
-		mutex:        sync.RWMutex{},
-		podAllocated: make(map[string]string),
-	}
-	kubernetesProvider.registerPlugin(&hostPortPlugin)
+	kubernetesProvider.registerPlugin(hostPortPlugin)
 }
 
 func (hpp *HostPortPlugin) Name() string {
@@ -87,13 +99,21 @@ func (hpp *HostPortPlugin) OnPodAdded(c client.Client, pod *corev1.Pod, ctx cont
 	networkManager := utils.NewNetworkManager(pod, c)
 	conf := networkManager.GetNetworkConfig()
 	containerPortsMap, containerProtocolsMap, numToAlloc := parseConfig(conf, pod)
+	for _, protocols := range containerProtocolsMap {
+		if err := validateProtocolsForOS(pod, protocols); err != nil {
+			return pod, errors.NewPluginError(errors.ParameterError, err.Error())
+		}
+	}
 
 	var hostPorts []int32
 	if str, ok := hpp.podAllocated[pod.GetNamespace()+"/"+pod.GetName()]; ok {
 		hostPorts = util.StringToInt32Slice(str, ",")
 		log.Infof("pod %s/%s use hostPorts %v , which are allocated before", pod.GetNamespace(), pod.GetName(), hostPorts)
 	} else {
-		hostPorts = hpp.allocate(numToAlloc, pod.GetNamespace()+"/"+pod.GetName())
+		hostPorts = hpp.allocate(numToAlloc, pod.GetNamespace()+"/"+pod.GetName(), pod.Spec.NodeName)
+		if hostPorts == nil && numToAlloc != 0 {
+			return pod, errors.NewPluginError(errors.InternalError, fmt.Sprintf("no enough host ports for pod %s/%s", pod.GetNamespace(), pod.GetName()))
+		}
 		log.Infof("pod %s/%s allocated hostPorts %v", pod.GetNamespace(), pod.GetName(), hostPorts)
 	}
 
@@ -128,6 +148,7 @@ func (hpp *HostPortPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx co
 		return pod, errors.NewPluginError(errors.ApiCallError, err.Error())
 	}
 	nodeIp := getAddress(node)
+	hpp.markNode(node.GetName(), hpp.getHostPorts(pod))
 
 	networkManager := utils.NewNetworkManager(pod, c)
 
@@ -186,16 +207,8 @@ func (hpp *HostPortPlugin) OnPodDeleted(c client.Client, pod *corev1.Pod, ctx co
 		return nil
 	}
 
-	hostPorts := make([]int32, 0)
-	for _, container := range pod.Spec.Containers {
-		for _, port := range container.Ports {
-			if port.HostPort >= hpp.minPort && port.HostPort <= hpp.maxPort {
-				hostPorts = append(hostPorts, port.HostPort)
-			}
-		}
-	}
-
-	hpp.deAllocate(hostPorts, pod.GetNamespace()+"/"+pod.GetName())
+	hostPorts := hpp.getHostPorts(pod)
+	hpp.deAllocate(hostPorts, pod.GetNamespace()+"/"+pod.GetName(), pod.Spec.NodeName)
 	log.Infof("pod %s/%s deallocated hostPorts %v", pod.GetNamespace(), pod.GetName(), hostPorts)
 	return nil
 }
@@ -212,25 +225,32 @@ func (hpp *HostPortPlugin) Init(c client.Client, options cloudprovider.CloudProv
 	for i := hpp.minPort; i <= hpp.maxPort; i++ {
 		newPortAmount[i] = 0
 	}
+	newNodeAllocated := make(map[string]portAllocated)
 	podList := &corev1.PodList{}
 	err := c.List(ctx, podList)
 	if err != nil {
 		return err
 	}
-	for _, pod := range podList.Items {
-		var hostPorts []int32
-		if pod.GetAnnotations()[gamekruiseiov1alpha1.GameServerNetworkType] == HostPortNetwork {
-			for _, container := range pod.Spec.Containers {
-				for _, port := range container.Ports {
-					if port.HostPort >= hpp.minPort && port.HostPort <= hpp.maxPort {
-						newPortAmount[port.HostPort]++
-						hostPorts = append(hostPorts, port.HostPort)
-					}
-				}
-			}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if networkType := pod.GetAnnotations()[gamekruiseiov1alpha1.GameServerNetworkType]; networkType != HostPortNetwork && networkType != HostNetworkNetwork {
+			continue
+		}
+		hostPorts := hpp.getHostPorts(pod)
+		if len(hostPorts) == 0 {
+			continue
 		}
-		if len(hostPorts) != 0 {
-			hpp.podAllocated[pod.GetNamespace()+"/"+pod.GetName()] = util.Int32SliceToString(hostPorts, ",")
+		for _, hostPort := range hostPorts {
+			newPortAmount[hostPort]++
+		}
+		hpp.podAllocated[pod.GetNamespace()+"/"+pod.GetName()] = util.Int32SliceToString(hostPorts, ",")
+		if nodeName := pod.Spec.NodeName; nodeName != "" {
+			if newNodeAllocated[nodeName] == nil {
+				newNodeAllocated[nodeName] = make(portAllocated)
+			}
+			for _, hostPort := range hostPorts {
+				newNodeAllocated[nodeName][hostPort] = true
+			}
 		}
 	}
 
@@ -247,16 +267,33 @@ func (hpp *HostPortPlugin) Init(c client.Client, options cloudprovider.CloudProv
 
 	hpp.portAmount = newPortAmount
 	hpp.amountStat = newAmountStat
+	hpp.nodeAllocated = newNodeAllocated
 	log.Infof("[Kubernetes-HostPort] podAllocated init: %v", hpp.podAllocated)
 	return nil
 }
 
-func (hpp *HostPortPlugin) allocate(num int, nsname string) []int32 {
+// Reconfigure rebuilds the port amounts of the new port range from the pods using host ports.
+func (hpp *HostPortPlugin) Reconfigure(c client.Client, options cloudprovider.CloudProviderOptions, ctx context.Context) error {
+	return hpp.Init(c, options, ctx)
+}
+
+// allocate selects the ports used the least. If the pod is already bound to a node,
+// only the ports free on the node are selected.
+func (hpp *HostPortPlugin) allocate(num int, nsname string, nodeName string) []int32 {
 	hpp.mutex.Lock()
 	defer hpp.mutex.Unlock()
 
-	hostPorts, index := selectPorts(hpp.amountStat, hpp.portAmount, num)
+	var hostPorts []int32
+	if nodeName == "" {
+		hostPorts, _ = selectPorts(hpp.amountStat, hpp.portAmount, num)
+	} else {
+		hostPorts = selectNodePorts(hpp.portAmount, hpp.nodeAllocated[nodeName], hpp.minPort, hpp.maxPort, num)
+	}
+	if len(hostPorts) < num {
+		return nil
+	}
 	for _, hostPort := range hostPorts {
+		index := hpp.portAmount[hostPort]
 		hpp.portAmount[hostPort]++
 		hpp.amountStat[index]--
 		if index+1 >= len(hpp.amountStat) {
@@ -264,12 +301,15 @@ func (hpp *HostPortPlugin) allocate(num int, nsname string) []int32 {
 		}
 		hpp.amountStat[index+1]++
 	}
+	if nodeName != "" {
+		hpp.markNodeLocked(nodeName, hostPorts)
+	}
 
 	hpp.podAllocated[nsname] = util.Int32SliceToString(hostPorts, ",")
 	return hostPorts
 }
 
-func (hpp *HostPortPlugin) deAllocate(hostPorts []int32, nsname string) {
+func (hpp *HostPortPlugin) deAllocate(hostPorts []int32, nsname string, nodeName string) {
 	hpp.mutex.Lock()
 	defer hpp.mutex.Unlock()
 
@@ -279,10 +319,50 @@ func (hpp *HostPortPlugin) deAllocate(hostPorts []int32, nsname string) {
 		hpp.amountStat[amount]--
 		hpp.amountStat[amount-1]++
 	}
+	if allocated, ok := hpp.nodeAllocated[nodeName]; ok {
+		for _, hostPort := range hostPorts {
+			delete(allocated, hostPort)
+		}
+		if len(allocated) == 0 {
+			delete(hpp.nodeAllocated, nodeName)
+		}
+	}
 
 	delete(hpp.podAllocated, nsname)
 }
 
+// markNode records the host ports of the pod scheduled onto the node.
+func (hpp *HostPortPlugin) markNode(nodeName string, hostPorts []int32) {
+	hpp.mutex.Lock()
+	defer hpp.mutex.Unlock()
+	hpp.markNodeLocked(nodeName, hostPorts)
+}
+
+func (hpp *HostPortPlugin) markNodeLocked(nodeName string, hostPorts []int32) {
+	if nodeName == "" || len(hostPorts) == 0 {
+		return
+	}
+	if hpp.nodeAllocated[nodeName] == nil {
+		hpp.nodeAllocated[nodeName] = make(portAllocated)
+	}
+	for _, hostPort := range hostPorts {
+		hpp.nodeAllocated[nodeName][hostPort] = true
+	}
+}
+
+// getHostPorts returns the host ports of the pod in the port range.
+func (hpp *HostPortPlugin) getHostPorts(pod *corev1.Pod) []int32 {
+	hostPorts := make([]int32, 0)
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.HostPort >= hpp.minPort && port.HostPort <= hpp.maxPort {
+				hostPorts = append(hostPorts, port.HostPort)
+			}
+		}
+	}
+	return hostPorts
+}
+
 func verifyContainerName(containerName string, pod *corev1.Pod) bool {
 	for _, container := range pod.Spec.Containers {
 		if container.Name == containerName {
@@ -292,6 +372,20 @@ func verifyContainerName(containerName string, pod *corev1.Pod) bool {
 	return false
 }
 
+// validateProtocolsForOS returns an error if the pod runs on Windows nodes and any of the protocols is not supported there.
+// Windows nodes support TCP and UDP host ports and node ports, but not SCTP.
+func validateProtocolsForOS(pod *corev1.Pod, protocols []corev1.Protocol) error {
+	if !util.IsWindowsPodSpec(&pod.Spec) {
+		return nil
+	}
+	for _, protocol := range protocols {
+		if protocol == corev1.ProtocolSCTP {
+			return fmt.Errorf("protocol %s is not supported by Windows nodes", protocol)
+		}
+	}
+	return nil
+}
+
 func getAddress(node *corev1.Node) string {
 	nodeIp := ""
 
@@ -331,6 +425,10 @@ func parseConfig(conf []gamekruiseiov1alpha1.NetworkConfParams, pod *corev1.Pod)
 	for _, c := range conf {
 		if c.Name == ContainerPortsKey {
 			cpSlice := strings.Split(c.Value, ":")
+			if len(cpSlice) == 1 {
+				// the ports without container name belong to the game container
+				cpSlice = []string{util.GetGameContainerName(pod), cpSlice[0]}
+			}
 			containerName := cpSlice[0]
 			if verifyContainerName(containerName, pod) && len(cpSlice) == 2 {
 				ports := make([]int32, 0)
@@ -380,3 +478,20 @@ func selectPorts(amountStat []int, portAmount map[int32]int, num int) ([]int32,
 	}
 	return hostPorts, index
 }
+
+// selectNodePorts selects the ports free on the node, and the ports used the least by other nodes first.
+func selectNodePorts(portAmount map[int32]int, nodeAllocated portAllocated, minPort, maxPort int32, num int) []int32 {
+	candidates := make([]int32, 0)
+	for port := minPort; port <= maxPort; port++ {
+		if !nodeAllocated[port] {
+			candidates = append(candidates, port)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return portAmount[candidates[i]] < portAmount[candidates[j]]
+	})
+	if len(candidates) > num {
+		candidates = candidates[:num]
+	}
+	return candidates
+}
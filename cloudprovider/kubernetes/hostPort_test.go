@@ -17,6 +17,11 @@ limitations under the License.
 package kubernetes
 
 import (
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"sync"
 	"testing"
 )
 
@@ -57,3 +62,141 @@ func TestSelectPorts(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateProtocolsForOS(t *testing.T) {
+	tests := []struct {
+		pod       *corev1.Pod
+		protocols []corev1.Protocol
+		wantErr   bool
+	}{
+		{
+			pod:       &corev1.Pod{},
+			protocols: []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolSCTP},
+			wantErr:   false,
+		},
+		{
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					OS: &corev1.PodOS{Name: corev1.Windows},
+				},
+			},
+			protocols: []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolUDP},
+			wantErr:   false,
+		},
+		{
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					NodeSelector: map[string]string{corev1.LabelOSStable: "windows"},
+				},
+			},
+			protocols: []corev1.Protocol{corev1.ProtocolUDP, corev1.ProtocolSCTP},
+			wantErr:   true,
+		},
+	}
+
+	for i, test := range tests {
+		err := validateProtocolsForOS(test.pod, test.protocols)
+		if (err != nil) != test.wantErr {
+			t.Errorf("case %d: expect error %v but got %v", i, test.wantErr, err)
+		}
+	}
+}
+
+func TestSelectNodePorts(t *testing.T) {
+	tests := []struct {
+		portAmount    map[int32]int
+		nodeAllocated portAllocated
+		num           int
+		hostPorts     []int32
+	}{
+		// the ports used by other nodes can be reused
+		{
+			portAmount:    map[int32]int{800: 1, 801: 1, 802: 2, 803: 0},
+			nodeAllocated: portAllocated{803: true},
+			num:           2,
+			hostPorts:     []int32{800, 801},
+		},
+		// the ports used the least first
+		{
+			portAmount:    map[int32]int{800: 2, 801: 1, 802: 2, 803: 0},
+			nodeAllocated: portAllocated{801: true},
+			num:           2,
+			hostPorts:     []int32{803, 800},
+		},
+		{
+			portAmount:    map[int32]int{800: 1, 801: 1, 802: 1, 803: 1},
+			nodeAllocated: portAllocated{800: true, 801: true, 802: true},
+			num:           2,
+			hostPorts:     []int32{803},
+		},
+	}
+
+	for i, test := range tests {
+		hostPorts := selectNodePorts(test.portAmount, test.nodeAllocated, 800, 803, test.num)
+		if !reflect.DeepEqual(hostPorts, test.hostPorts) {
+			t.Errorf("case %d: expect hostPorts %v but got %v", i, test.hostPorts, hostPorts)
+		}
+	}
+}
+
+func TestAllocateOnNode(t *testing.T) {
+	hpp := &HostPortPlugin{
+		minPort:       800,
+		maxPort:       802,
+		podAllocated:  make(map[string]string),
+		portAmount:    map[int32]int{800: 0, 801: 0, 802: 0},
+		amountStat:    []int{3},
+		nodeAllocated: make(map[string]portAllocated),
+		mutex:         sync.RWMutex{},
+	}
+
+	// pods on different nodes reuse the same ports
+	if hostPorts := hpp.allocate(2, "xxx/case-0", "node-a"); !reflect.DeepEqual(hostPorts, []int32{800, 801}) {
+		t.Errorf("expect hostPorts [800 801] but got %v", hostPorts)
+	}
+	if hostPorts := hpp.allocate(2, "xxx/case-1", "node-b"); !reflect.DeepEqual(hostPorts, []int32{802, 800}) {
+		t.Errorf("expect hostPorts [802 800] but got %v", hostPorts)
+	}
+	// no enough ports on the node
+	if hostPorts := hpp.allocate(2, "xxx/case-2", "node-a"); hostPorts != nil {
+		t.Errorf("expect no hostPorts but got %v", hostPorts)
+	}
+	if !reflect.DeepEqual(hpp.portAmount, map[int32]int{800: 2, 801: 1, 802: 1}) {
+		t.Errorf("unexpected portAmount %v", hpp.portAmount)
+	}
+
+	hpp.deAllocate([]int32{800, 801}, "xxx/case-0", "node-a")
+	if _, ok := hpp.nodeAllocated["node-a"]; ok {
+		t.Errorf("node-a should be removed from nodeAllocated after deallocated")
+	}
+	if hostPorts := hpp.allocate(3, "xxx/case-2", "node-a"); len(hostPorts) != 3 {
+		t.Errorf("expect 3 hostPorts but got %v", hostPorts)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{gamekruiseiov1alpha1.GameContainerKey: "game"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "logtail"}, {Name: "game"}},
+		},
+	}
+	conf := []gamekruiseiov1alpha1.NetworkConfParams{
+		{Name: ContainerPortsKey, Value: "7777/UDP,8080"},
+		{Name: ContainerPortsKey, Value: "logtail:9000"},
+	}
+	portsMap, protocolsMap, numToAlloc := parseConfig(conf, pod)
+	if numToAlloc != 3 {
+		t.Errorf("expect 3 ports to allocate, but got %d", numToAlloc)
+	}
+	expectPorts := map[string][]int32{"game": {7777, 8080}, "logtail": {9000}}
+	if !reflect.DeepEqual(portsMap, expectPorts) {
+		t.Errorf("expect ports %v, but got %v", expectPorts, portsMap)
+	}
+	expectProtocols := map[string][]corev1.Protocol{"game": {corev1.ProtocolUDP, corev1.ProtocolTCP}, "logtail": {corev1.ProtocolTCP}}
+	if !reflect.DeepEqual(protocolsMap, expectProtocols) {
+		t.Errorf("expect protocols %v, but got %v", expectProtocols, protocolsMap)
+	}
+}
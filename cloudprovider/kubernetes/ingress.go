@@ -28,12 +28,14 @@ const (
 	// PathKey is matched against the path of an incoming request, the meaning of which is same as Path in HTTPIngressPath.
 	// Users can add <id> to any position of the path, and the network plugin will generate the path corresponding to the game server.
 	// e.g. /game<id>(/|$)(.*) The ingress path of GameServer 0 is /game0(/|$)(.*), the ingress path of GameServer 1 is /game1(/|$)(.*), and so on.
+	// Users can also add <name> to any position of the path, which is replaced with the name of the game server.
 	PathKey = "Path"
 	// PortKey indicates the exposed port value of game server.
 	PortKey = "Port"
 	// IngressClassNameKey indicates the name of the IngressClass cluster resource, which is same as IngressClassName in IngressSpec.
 	IngressClassNameKey = "IngressClassName"
 	// HostKey indicates domain name, which is same as Host in IngressRule.
+	// Same as the path, <id> and <name> in the host are replaced with the id and the name of the game server.
 	HostKey = "Host"
 	// TlsHostsKey indicates hosts that included in the TLS certificate, the meaning of which is the same as that of Hosts in IngressTLS.
 	// Its corresponding value format is as follows, host1,host2,... e.g. xxx.xx.com
@@ -105,10 +107,20 @@ func (i IngressPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx contex
 	}, svc)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkServiceMissing, "service is being created")
+			if pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod); err != nil {
+				return pod, cperrors.NewPluginError(cperrors.InternalError, err.Error())
+			}
 			return pod, cperrors.ToPluginError(c.Create(ctx, consSvc(ic, pod, c, ctx)), cperrors.ApiCallError)
 		}
 		return pod, cperrors.NewPluginError(cperrors.ApiCallError, err.Error())
 	}
+
+	// handle the Service of the pod's name not created by the plugin
+	if stop, pod, pluginErr := utils.HandleServiceCollision(c, ctx, networkManager, pod, svc, networkStatus); stop {
+		return pod, pluginErr
+	}
+
 	// update svc
 	if util.GetHash(ic.ports) != svc.GetAnnotations()[ServiceHashKey] {
 		networkStatus.CurrentNetworkState = gamekruiseiov1alpha1.NetworkNotReady
@@ -172,6 +184,12 @@ func (i IngressPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx contex
 		EndPoint: ing.Spec.Rules[0].Host,
 		Ports:    networkPorts,
 	}
+	// report the address of the ingress controller, and its hostname if the rule has no host
+	ip, hostname := utils.GetLoadBalancerAddress(ing.Status.LoadBalancer.Ingress)
+	externalAddress.IP = ip
+	if externalAddress.EndPoint == "" {
+		externalAddress.EndPoint = hostname
+	}
 
 	networkStatus.InternalAddresses = append(internalAddresses, internalAddress)
 	networkStatus.ExternalAddresses = append(externalAddresses, externalAddress)
@@ -212,15 +230,11 @@ func parseIngConfig(conf []gamekruiseiov1alpha1.NetworkConfParams, pod *corev1.P
 			port, _ := strconv.ParseInt(c.Value, 10, 32)
 			ic.ports = append(ic.ports, int32(port))
 		case HostKey:
-			strs := strings.Split(c.Value, "<id>")
-			switch len(strs) {
-			case 2:
-				ic.host = strs[0] + strconv.Itoa(id) + strs[1]
-			case 1:
-				ic.host = strs[0]
-			default:
-				return ingConfig{}, fmt.Errorf("%s", paramsError)
+			host, err := parseIngTemplate(c.Value, pod.GetName(), id)
+			if err != nil {
+				return ingConfig{}, err
 			}
+			ic.host = host
 		case IngressClassNameKey:
 			ic.ingressClassName = ptr.To[string](c.Value)
 		case TlsSecretNameKey:
@@ -228,15 +242,11 @@ func parseIngConfig(conf []gamekruiseiov1alpha1.NetworkConfParams, pod *corev1.P
 		case TlsHostsKey:
 			ic.tlsHosts = strings.Split(c.Value, ",")
 		case PathKey:
-			strs := strings.Split(c.Value, "<id>")
-			switch len(strs) {
-			case 2:
-				ic.paths = append(ic.paths, strs[0]+strconv.Itoa(id)+strs[1])
-			case 1:
-				ic.paths = append(ic.paths, strs[0])
-			default:
-				return ingConfig{}, fmt.Errorf("%s", paramsError)
+			path, err := parseIngTemplate(c.Value, pod.GetName(), id)
+			if err != nil {
+				return ingConfig{}, err
 			}
+			ic.paths = append(ic.paths, path)
 		case AnnotationKey:
 			kv := strings.Split(c.Value, ": ")
 			if len(kv) != 2 {
@@ -256,6 +266,16 @@ func parseIngConfig(conf []gamekruiseiov1alpha1.NetworkConfParams, pod *corev1.P
 	return ic, nil
 }
 
+// parseIngTemplate replaces <id> with the id of the game server, which is allowed at most once,
+// and <name> with the name of the game server.
+func parseIngTemplate(template, name string, id int) (string, error) {
+	if strings.Count(template, "<id>") > 1 {
+		return "", fmt.Errorf("%s", paramsError)
+	}
+	s := strings.Replace(template, "<id>", strconv.Itoa(id), 1)
+	return strings.ReplaceAll(s, "<name>", name), nil
+}
+
 func consIngress(ic ingConfig, pod *corev1.Pod, c client.Client, ctx context.Context) *v1.Ingress {
 	pathSlice := ic.paths
 	pathTypeSlice := ic.pathTypes
@@ -340,6 +360,7 @@ func consSvc(ic ingConfig, pod *corev1.Pod, c client.Client, ctx context.Context
 			Namespace:       pod.GetNamespace(),
 			OwnerReferences: consOwnerReference(c, ctx, pod, ic.fixed),
 			Annotations:     annoatations,
+			Labels:          utils.ServiceLabels(IngressNetwork, nil),
 		},
 		Spec: corev1.ServiceSpec{
 			Type: corev1.ServiceTypeClusterIP,
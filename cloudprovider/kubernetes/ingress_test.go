@@ -126,6 +126,60 @@ func TestParseIngConfig(t *testing.T) {
 				annotations: map[string]string{},
 			},
 		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{
+					Name:  PathKey,
+					Value: "/<name>/ws",
+				},
+				{
+					Name:  PortKey,
+					Value: "8080",
+				},
+				{
+					Name:  PathTypeKey,
+					Value: string(v1.PathTypePrefix),
+				},
+				{
+					Name:  HostKey,
+					Value: "<name>.xxx.xxx.com",
+				},
+			},
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "pod-2",
+				},
+			},
+			ic: ingConfig{
+				paths:       []string{"/pod-2/ws"},
+				ports:       []int32{8080},
+				pathTypes:   []*v1.PathType{&pathTypePrefix},
+				host:        "pod-2.xxx.xxx.com",
+				annotations: map[string]string{},
+			},
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{
+					Name:  PathKey,
+					Value: "/game<id>/<id>",
+				},
+				{
+					Name:  PortKey,
+					Value: "8080",
+				},
+				{
+					Name:  PathTypeKey,
+					Value: string(v1.PathTypePrefix),
+				},
+			},
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "pod-2",
+				},
+			},
+			err: fmt.Errorf("%s", paramsError),
+		},
 	}
 
 	for i, test := range tests {
@@ -374,6 +428,7 @@ func TestConsSvc(t *testing.T) {
 	baseSvcObjectMeta := metav1.ObjectMeta{
 		Name:      "pod-3",
 		Namespace: "ns",
+		Labels:    map[string]string{gamekruiseiov1alpha1.GameServerNetworkType: IngressNetwork},
 		OwnerReferences: []metav1.OwnerReference{
 			{
 				APIVersion:         "v1",
@@ -0,0 +1,352 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/cloudprovider"
+	cperrors "github.com/openkruise/kruise-game/cloudprovider/errors"
+	"github.com/openkruise/kruise-game/cloudprovider/utils"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+const (
+	LoadBalancerNetwork = "Kubernetes-LoadBalancer"
+
+	// LBIPAMConfigName indicates the implementation assigning the IPs of LoadBalancer Services, MetalLB or Cilium.
+	LBIPAMConfigName = "LBIPAM"
+	// AddressPoolConfigName is the address pool which the IPs of the Services are assigned from.
+	AddressPoolConfigName = "AddressPool"
+	// LoadBalancerClassConfigName is the LoadBalancerClass of the Services, which selects the implementation
+	// when there are more than one in the cluster.
+	LoadBalancerClassConfigName = "LoadBalancerClass"
+
+	MetalLBIPAM = "MetalLB"
+	CiliumIPAM  = "Cilium"
+
+	MetalLBAddressPoolAnnotation = "metallb.universe.tf/address-pool"
+	MetalLBIPsAnnotation         = "metallb.universe.tf/loadBalancerIPs"
+	CiliumIPsAnnotation          = "lbipam.cilium.io/ips"
+	// CiliumAddressPoolLabel is the label of the Services selected by the serviceSelector of CiliumLoadBalancerIPPools.
+	CiliumAddressPoolLabel = "game.kruise.io/address-pool"
+)
+
+type LoadBalancerPlugin struct {
+}
+
+func (l *LoadBalancerPlugin) Name() string {
+	return LoadBalancerNetwork
+}
+
+func (l *LoadBalancerPlugin) Alias() string {
+	return ""
+}
+
+func (l *LoadBalancerPlugin) Init(client client.Client, options cloudprovider.CloudProviderOptions, ctx context.Context) error {
+	return nil
+}
+
+func (l *LoadBalancerPlugin) OnPodAdded(client client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, cperrors.PluginError) {
+	return pod, nil
+}
+
+func (l *LoadBalancerPlugin) OnPodUpdated(c client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, cperrors.PluginError) {
+	networkManager := utils.NewNetworkManager(pod, c)
+
+	networkStatus, _ := networkManager.GetNetworkStatus()
+	lbc, err := parseLoadBalancerConfig(networkManager.GetNetworkConfig())
+	if err != nil {
+		return pod, cperrors.NewPluginError(cperrors.ParameterError, err.Error())
+	}
+	if networkStatus == nil {
+		pod, err := networkManager.UpdateNetworkStatus(gamekruiseiov1alpha1.NetworkStatus{
+			CurrentNetworkState: gamekruiseiov1alpha1.NetworkNotReady,
+		}, pod)
+		return pod, cperrors.ToPluginError(err, cperrors.InternalError)
+	}
+
+	// get svc
+	svc := &corev1.Service{}
+	err = c.Get(ctx, types.NamespacedName{
+		Name:      pod.GetName(),
+		Namespace: pod.GetNamespace(),
+	}, svc)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkServiceMissing, "service is being created")
+			if pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod); err != nil {
+				return pod, cperrors.NewPluginError(cperrors.InternalError, err.Error())
+			}
+			return pod, cperrors.ToPluginError(c.Create(ctx, consLoadBalancerSvc(lbc, pod, c, ctx, "")), cperrors.ApiCallError)
+		}
+		return pod, cperrors.NewPluginError(cperrors.ApiCallError, err.Error())
+	}
+
+	// handle the Service of the pod's name not created by the plugin
+	if stop, pod, pluginErr := utils.HandleServiceCollision(c, ctx, networkManager, pod, svc, networkStatus); stop {
+		return pod, pluginErr
+	}
+
+	// update svc, keeping the IP of the fixed network
+	if util.GetHash(lbc) != svc.GetAnnotations()[ServiceHashKey] {
+		networkStatus.CurrentNetworkState = gamekruiseiov1alpha1.NetworkNotReady
+		pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
+		if err != nil {
+			return pod, cperrors.NewPluginError(cperrors.InternalError, err.Error())
+		}
+		ip := ""
+		if lbc.isFixed {
+			ips, _ := utils.GetLoadBalancerAddresses(svc.Status.LoadBalancer.Ingress)
+			ip = strings.Join(ips, ",")
+		}
+		newSvc := consLoadBalancerSvc(lbc, pod, c, ctx, ip)
+		newSvc.ResourceVersion = svc.ResourceVersion
+		return pod, cperrors.ToPluginError(c.Update(ctx, newSvc), cperrors.ApiCallError)
+	}
+
+	// disable network
+	if networkManager.GetNetworkDisabled() && svc.Spec.Selector[SvcSelectorKey] == pod.GetName() {
+		newSelector := svc.Spec.Selector
+		newSelector[SvcSelectorDisabledKey] = pod.GetName()
+		delete(svc.Spec.Selector, SvcSelectorKey)
+		svc.Spec.Selector = newSelector
+		return pod, cperrors.ToPluginError(c.Update(ctx, svc), cperrors.ApiCallError)
+	}
+
+	// enable network
+	if !networkManager.GetNetworkDisabled() && svc.Spec.Selector[SvcSelectorDisabledKey] == pod.GetName() {
+		newSelector := svc.Spec.Selector
+		newSelector[SvcSelectorKey] = pod.GetName()
+		delete(svc.Spec.Selector, SvcSelectorDisabledKey)
+		svc.Spec.Selector = newSelector
+		return pod, cperrors.ToPluginError(c.Update(ctx, svc), cperrors.ApiCallError)
+	}
+
+	// allow not ready containers
+	if util.IsAllowNotReadyContainers(networkManager.GetNetworkConfig()) {
+		toUpDateSvc, err := utils.AllowNotReadyContainers(c, ctx, pod, svc, false)
+		if err != nil {
+			return pod, err
+		}
+		if toUpDateSvc {
+			err := c.Update(ctx, svc)
+			if err != nil {
+				return pod, cperrors.ToPluginError(err, cperrors.ApiCallError)
+			}
+		}
+	}
+
+	// network not ready
+	ips, hostname := utils.GetLoadBalancerAddresses(svc.Status.LoadBalancer.Ingress)
+	podIPs := utils.GetPodIPs(pod)
+	if len(ips) == 0 || len(podIPs) == 0 {
+		if len(ips) == 0 {
+			utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkWaitingForLB, "load balancer has not assigned the address")
+		} else {
+			utils.SetNetworkNotReady(networkStatus, "", "")
+		}
+		pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
+		return pod, cperrors.ToPluginError(err, cperrors.InternalError)
+	}
+
+	// pin the assigned IPs, so that they are reused if the Service is recreated
+	ipsAnnotation := getIPsAnnotation(lbc.ipam)
+	ip := strings.Join(ips, ",")
+	if lbc.isFixed && svc.GetAnnotations()[ipsAnnotation] != ip {
+		patchSvc := map[string]interface{}{"metadata": map[string]map[string]string{"annotations": {ipsAnnotation: ip}}}
+		patchSvcBytes, err := json.Marshal(patchSvc)
+		if err != nil {
+			return pod, cperrors.NewPluginError(cperrors.InternalError, err.Error())
+		}
+		if err := c.Patch(ctx, svc, client.RawPatch(types.MergePatchType, patchSvcBytes)); err != nil {
+			return pod, cperrors.NewPluginError(cperrors.ApiCallError, err.Error())
+		}
+	}
+
+	// network ready
+	internalAddresses := make([]gamekruiseiov1alpha1.NetworkAddress, 0)
+	externalAddresses := make([]gamekruiseiov1alpha1.NetworkAddress, 0)
+	for _, port := range svc.Spec.Ports {
+		instrIPort := port.TargetPort
+		instrEPort := intstr.FromInt(int(port.Port))
+		for _, podIP := range podIPs {
+			internalAddresses = append(internalAddresses, gamekruiseiov1alpha1.NetworkAddress{
+				IP: podIP,
+				Ports: []gamekruiseiov1alpha1.NetworkPort{
+					{
+						Name:     instrIPort.String(),
+						Port:     &instrIPort,
+						Protocol: port.Protocol,
+					},
+				},
+			})
+		}
+		// an address of each IP family for dual-stack load balancers
+		for _, lbIP := range ips {
+			externalAddresses = append(externalAddresses, gamekruiseiov1alpha1.NetworkAddress{
+				IP:       lbIP,
+				EndPoint: hostname,
+				Ports: []gamekruiseiov1alpha1.NetworkPort{
+					{
+						Name:     instrIPort.String(),
+						Port:     &instrEPort,
+						Protocol: port.Protocol,
+					},
+				},
+			})
+		}
+	}
+	networkStatus.InternalAddresses = internalAddresses
+	networkStatus.ExternalAddresses = externalAddresses
+	networkStatus.CurrentNetworkState = gamekruiseiov1alpha1.NetworkReady
+	pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
+	return pod, cperrors.ToPluginError(err, cperrors.InternalError)
+}
+
+func (l *LoadBalancerPlugin) ValidateNetworkConf(conf []gamekruiseiov1alpha1.NetworkConfParams) error {
+	_, err := parseLoadBalancerConfig(conf)
+	return err
+}
+
+func (l *LoadBalancerPlugin) OnPodDeleted(client client.Client, pod *corev1.Pod, ctx context.Context) cperrors.PluginError {
+	return nil
+}
+
+func init() {
+	kubernetesProvider.registerPlugin(&LoadBalancerPlugin{})
+}
+
+type loadBalancerConfig struct {
+	ports             []int
+	protocols         []corev1.Protocol
+	ipam              string
+	addressPool       string
+	loadBalancerClass string
+	isFixed           bool
+	ipFamilies        utils.IPFamilyConf
+}
+
+func parseLoadBalancerConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) (*loadBalancerConfig, error) {
+	lbc := &loadBalancerConfig{ipam: MetalLBIPAM}
+	for _, c := range conf {
+		switch c.Name {
+		case PortProtocolsConfigName:
+			ports, protocols, err := utils.ParsePortProtocols(c.Value)
+			if err != nil {
+				return nil, err
+			}
+			lbc.ports, lbc.protocols = ports, protocols
+		case LBIPAMConfigName:
+			if c.Value != MetalLBIPAM && c.Value != CiliumIPAM {
+				return nil, fmt.Errorf("invalid %s %s, which should be %s or %s", LBIPAMConfigName, c.Value, MetalLBIPAM, CiliumIPAM)
+			}
+			lbc.ipam = c.Value
+		case AddressPoolConfigName:
+			lbc.addressPool = strings.TrimSpace(c.Value)
+		case LoadBalancerClassConfigName:
+			lbc.loadBalancerClass = strings.TrimSpace(c.Value)
+		case FixedKey:
+			v, err := strconv.ParseBool(c.Value)
+			if err != nil {
+				return nil, err
+			}
+			lbc.isFixed = v
+		}
+	}
+	if len(lbc.ports) == 0 {
+		return nil, fmt.Errorf("no port is found in %s", PortProtocolsConfigName)
+	}
+	ipFamilies, err := utils.ParseIPFamilyConf(conf)
+	if err != nil {
+		return nil, err
+	}
+	lbc.ipFamilies = ipFamilies
+	return lbc, nil
+}
+
+func getIPsAnnotation(ipam string) string {
+	if ipam == CiliumIPAM {
+		return CiliumIPsAnnotation
+	}
+	return MetalLBIPsAnnotation
+}
+
+// consLoadBalancerSvc constructs the LoadBalancer Service of the pod, which requests the IPs, separated by commas,
+// if it is not empty.
+func consLoadBalancerSvc(lbc *loadBalancerConfig, pod *corev1.Pod, c client.Client, ctx context.Context, ip string) *corev1.Service {
+	svcPorts := make([]corev1.ServicePort, 0)
+	for i := 0; i < len(lbc.ports); i++ {
+		svcPorts = append(svcPorts, corev1.ServicePort{
+			Name:       strconv.Itoa(lbc.ports[i]),
+			Port:       int32(lbc.ports[i]),
+			Protocol:   lbc.protocols[i],
+			TargetPort: intstr.FromInt(lbc.ports[i]),
+		})
+	}
+
+	annotations := map[string]string{
+		ServiceHashKey: util.GetHash(lbc),
+	}
+	labels := make(map[string]string)
+	if lbc.addressPool != "" {
+		if lbc.ipam == CiliumIPAM {
+			labels[CiliumAddressPoolLabel] = lbc.addressPool
+		} else {
+			annotations[MetalLBAddressPoolAnnotation] = lbc.addressPool
+		}
+	}
+	if ip != "" {
+		annotations[getIPsAnnotation(lbc.ipam)] = ip
+	}
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            pod.GetName(),
+			Namespace:       pod.GetNamespace(),
+			Annotations:     annotations,
+			Labels:          utils.ServiceLabels(LoadBalancerNetwork, labels),
+			OwnerReferences: consOwnerReference(c, ctx, pod, lbc.isFixed),
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeLoadBalancer,
+			Selector: map[string]string{
+				SvcSelectorKey: pod.GetName(),
+			},
+			Ports:                 svcPorts,
+			ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyTypeLocal,
+		},
+	}
+	if lbc.loadBalancerClass != "" {
+		svc.Spec.LoadBalancerClass = ptr.To[string](lbc.loadBalancerClass)
+	}
+	lbc.ipFamilies.ApplyTo(&svc.Spec)
+	return svc
+}
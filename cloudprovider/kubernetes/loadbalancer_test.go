@@ -0,0 +1,207 @@
+package kubernetes
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/cloudprovider/utils"
+)
+
+func TestParseLoadBalancerConfig(t *testing.T) {
+	tests := []struct {
+		conf      []gamekruiseiov1alpha1.NetworkConfParams
+		lbConfig  *loadBalancerConfig
+		expectErr bool
+	}{
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{
+					Name:  PortProtocolsConfigName,
+					Value: "7777/UDP",
+				},
+				{
+					Name:  AddressPoolConfigName,
+					Value: "game-pool",
+				},
+			},
+			lbConfig: &loadBalancerConfig{
+				ports:       []int{7777},
+				protocols:   []corev1.Protocol{corev1.ProtocolUDP},
+				ipam:        MetalLBIPAM,
+				addressPool: "game-pool",
+			},
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{
+					Name:  PortProtocolsConfigName,
+					Value: "80",
+				},
+				{
+					Name:  LBIPAMConfigName,
+					Value: CiliumIPAM,
+				},
+				{
+					Name:  LoadBalancerClassConfigName,
+					Value: "io.cilium/bgp-control-plane",
+				},
+				{
+					Name:  FixedKey,
+					Value: "true",
+				},
+			},
+			lbConfig: &loadBalancerConfig{
+				ports:             []int{80},
+				protocols:         []corev1.Protocol{corev1.ProtocolTCP},
+				ipam:              CiliumIPAM,
+				loadBalancerClass: "io.cilium/bgp-control-plane",
+				isFixed:           true,
+			},
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{
+					Name:  PortProtocolsConfigName,
+					Value: "80",
+				},
+				{
+					Name:  gamekruiseiov1alpha1.IPFamilyPolicyNetworkConfName,
+					Value: "RequireDualStack",
+				},
+				{
+					Name:  gamekruiseiov1alpha1.IPFamiliesNetworkConfName,
+					Value: "IPv4,IPv6",
+				},
+			},
+			lbConfig: &loadBalancerConfig{
+				ports:     []int{80},
+				protocols: []corev1.Protocol{corev1.ProtocolTCP},
+				ipam:      MetalLBIPAM,
+				ipFamilies: utils.IPFamilyConf{
+					Policy:   ptr.To(corev1.IPFamilyPolicyRequireDualStack),
+					Families: []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol},
+				},
+			},
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{
+					Name:  PortProtocolsConfigName,
+					Value: "80",
+				},
+				{
+					Name:  LBIPAMConfigName,
+					Value: "kube-vip",
+				},
+			},
+			expectErr: true,
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{
+					Name:  AddressPoolConfigName,
+					Value: "game-pool",
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i, test := range tests {
+		lbConfig, err := parseLoadBalancerConfig(test.conf)
+		if (err != nil) != test.expectErr {
+			t.Errorf("case %d: expect error %v, but actually got %v", i, test.expectErr, err)
+		}
+		if !reflect.DeepEqual(lbConfig, test.lbConfig) {
+			t.Errorf("case %d: expect lbConfig: %v, but actual: %v", i, test.lbConfig, lbConfig)
+		}
+	}
+}
+
+func TestConsLoadBalancerSvc(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "pod-0",
+		},
+	}
+	tests := []struct {
+		lbc               *loadBalancerConfig
+		ip                string
+		expectAnnotations map[string]string
+		expectLabels      map[string]string
+		expectIPFamilies  []corev1.IPFamily
+	}{
+		{
+			lbc: &loadBalancerConfig{
+				ports:       []int{7777},
+				protocols:   []corev1.Protocol{corev1.ProtocolUDP},
+				ipam:        MetalLBIPAM,
+				addressPool: "game-pool",
+			},
+			expectAnnotations: map[string]string{
+				MetalLBAddressPoolAnnotation: "game-pool",
+			},
+			expectLabels: map[string]string{gamekruiseiov1alpha1.GameServerNetworkType: LoadBalancerNetwork},
+		},
+		{
+			lbc: &loadBalancerConfig{
+				ports:       []int{7777},
+				protocols:   []corev1.Protocol{corev1.ProtocolUDP},
+				ipam:        CiliumIPAM,
+				addressPool: "game-pool",
+			},
+			ip: "10.0.0.10",
+			expectAnnotations: map[string]string{
+				CiliumIPsAnnotation: "10.0.0.10",
+			},
+			expectLabels: map[string]string{
+				CiliumAddressPoolLabel:                     "game-pool",
+				gamekruiseiov1alpha1.GameServerNetworkType: LoadBalancerNetwork,
+			},
+		},
+		{
+			lbc: &loadBalancerConfig{
+				ports:     []int{7777},
+				protocols: []corev1.Protocol{corev1.ProtocolUDP},
+				ipam:      MetalLBIPAM,
+				ipFamilies: utils.IPFamilyConf{
+					Policy:   ptr.To(corev1.IPFamilyPolicyPreferDualStack),
+					Families: []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol},
+				},
+			},
+			ip: "10.0.0.10,fd00::10",
+			expectAnnotations: map[string]string{
+				MetalLBIPsAnnotation: "10.0.0.10,fd00::10",
+			},
+			expectLabels:     map[string]string{gamekruiseiov1alpha1.GameServerNetworkType: LoadBalancerNetwork},
+			expectIPFamilies: []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol},
+		},
+	}
+
+	for i, test := range tests {
+		svc := consLoadBalancerSvc(test.lbc, pod, nil, context.TODO(), test.ip)
+		if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			t.Errorf("case %d: expect service type %s, but actually got %s", i, corev1.ServiceTypeLoadBalancer, svc.Spec.Type)
+		}
+		delete(svc.Annotations, ServiceHashKey)
+		if !reflect.DeepEqual(svc.Annotations, test.expectAnnotations) {
+			t.Errorf("case %d: expect annotations %v, but actually got %v", i, test.expectAnnotations, svc.Annotations)
+		}
+		if !reflect.DeepEqual(svc.Labels, test.expectLabels) {
+			t.Errorf("case %d: expect labels %v, but actually got %v", i, test.expectLabels, svc.Labels)
+		}
+		if !reflect.DeepEqual(svc.Spec.IPFamilies, test.expectIPFamilies) {
+			t.Errorf("case %d: expect ip families %v, but actually got %v", i, test.expectIPFamilies, svc.Spec.IPFamilies)
+		}
+		if svc.Spec.Ports[0].Port != 7777 || svc.Spec.Ports[0].Protocol != corev1.ProtocolUDP {
+			t.Errorf("case %d: unexpected ports %v", i, svc.Spec.Ports)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package kubernetes
 
 import (
 	"context"
+	"fmt"
 	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
 	"github.com/openkruise/kruise-game/cloudprovider"
 	cperrors "github.com/openkruise/kruise-game/cloudprovider/errors"
@@ -37,6 +38,9 @@ const (
 	NodePortNetwork = "Kubernetes-NodePort"
 
 	PortProtocolsConfigName = "PortProtocols"
+	// NodeAddressAnnotationConfigName is the annotation of nodes holding their external addresses, for the nodes
+	// whose public IPs are not reported as ExternalIP, such as bare-metal nodes behind NAT.
+	NodeAddressAnnotationConfigName = "NodeAddressAnnotation"
 
 	SvcSelectorDisabledKey = "game.kruise.io/svc-selector-disabled"
 )
@@ -69,6 +73,9 @@ func (n *NodePortPlugin) OnPodUpdated(client client.Client, pod *corev1.Pod, ctx
 	if err != nil {
 		return pod, cperrors.NewPluginError(cperrors.ParameterError, err.Error())
 	}
+	if err := validateProtocolsForOS(pod, npc.protocols); err != nil {
+		return pod, cperrors.NewPluginError(cperrors.ParameterError, err.Error())
+	}
 
 	if networkStatus == nil {
 		pod, err := networkManager.UpdateNetworkStatus(gamekruiseiov1alpha1.NetworkStatus{
@@ -85,13 +92,22 @@ func (n *NodePortPlugin) OnPodUpdated(client client.Client, pod *corev1.Pod, ctx
 	}, svc)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkServiceMissing, "service is being created")
+			if pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod); err != nil {
+				return pod, cperrors.NewPluginError(cperrors.InternalError, err.Error())
+			}
 			return pod, cperrors.ToPluginError(client.Create(ctx, consNodePortSvc(npc, pod, client, ctx)), cperrors.ApiCallError)
 		}
 		return pod, cperrors.NewPluginError(cperrors.ApiCallError, err.Error())
 	}
 
+	// handle the Service of the pod's name not created by the plugin
+	if stop, pod, pluginErr := utils.HandleServiceCollision(client, ctx, networkManager, pod, svc, networkStatus); stop {
+		return pod, pluginErr
+	}
+
 	// update svc
-	if util.GetHash(npc) != svc.GetAnnotations()[ServiceHashKey] {
+	if npc.hash() != svc.GetAnnotations()[ServiceHashKey] {
 		networkStatus.CurrentNetworkState = gamekruiseiov1alpha1.NetworkNotReady
 		pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
 		if err != nil {
@@ -170,7 +186,7 @@ func (n *NodePortPlugin) OnPodUpdated(client client.Client, pod *corev1.Pod, ctx
 			},
 		}
 		externalAddress := gamekruiseiov1alpha1.NetworkAddress{
-			IP: getAddress(node),
+			IP: getNodeAddress(node, npc.nodeAddressAnnotation),
 			Ports: []gamekruiseiov1alpha1.NetworkPort{
 				{
 					Name:     instrIPort.String(),
@@ -189,6 +205,17 @@ func (n *NodePortPlugin) OnPodUpdated(client client.Client, pod *corev1.Pod, ctx
 	return pod, cperrors.ToPluginError(err, cperrors.InternalError)
 }
 
+func (n *NodePortPlugin) ValidateNetworkConf(conf []gamekruiseiov1alpha1.NetworkConfParams) error {
+	npc, err := parseNodePortConfig(conf)
+	if err != nil {
+		return err
+	}
+	if len(npc.ports) == 0 {
+		return fmt.Errorf("%s is required", PortProtocolsConfigName)
+	}
+	return nil
+}
+
 func (n *NodePortPlugin) OnPodDeleted(client client.Client, pod *corev1.Pod, ctx context.Context) cperrors.PluginError {
 	return nil
 }
@@ -198,20 +225,46 @@ func init() {
 }
 
 type nodePortConfig struct {
-	ports     []int
-	protocols []corev1.Protocol
-	isFixed   bool
+	ports                 []int
+	protocols             []corev1.Protocol
+	isFixed               bool
+	nodeAddressAnnotation string
+}
+
+// hash returns the value of ServiceHashKey. nodeAddressAnnotation is hashed only when set, so that an
+// upgrade does not re-sync Services of unchanged network config.
+func (npc *nodePortConfig) hash() string {
+	// nodePortConfig shadows the package-level type so that the hash of the original fields stays the same
+	type nodePortConfig struct {
+		ports     []int
+		protocols []corev1.Protocol
+		isFixed   bool
+	}
+	base := &nodePortConfig{
+		ports:     npc.ports,
+		protocols: npc.protocols,
+		isFixed:   npc.isFixed,
+	}
+	if npc.nodeAddressAnnotation == "" {
+		return util.GetHash(base)
+	}
+	return util.GetHash([]interface{}{base, npc.nodeAddressAnnotation})
 }
 
 func parseNodePortConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) (*nodePortConfig, error) {
 	var ports []int
 	var protocols []corev1.Protocol
 	isFixed := false
+	nodeAddressAnnotation := ""
 
 	for _, c := range conf {
 		switch c.Name {
 		case PortProtocolsConfigName:
-			ports, protocols = parsePortProtocols(c.Value)
+			var err error
+			ports, protocols, err = utils.ParsePortProtocols(c.Value)
+			if err != nil {
+				return nil, err
+			}
 
 		case FixedKey:
 			var err error
@@ -219,32 +272,27 @@ func parseNodePortConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) (*nodePo
 			if err != nil {
 				return nil, err
 			}
+		case NodeAddressAnnotationConfigName:
+			nodeAddressAnnotation = c.Value
 		}
 	}
 	return &nodePortConfig{
-		ports:     ports,
-		protocols: protocols,
-		isFixed:   isFixed,
+		ports:                 ports,
+		protocols:             protocols,
+		isFixed:               isFixed,
+		nodeAddressAnnotation: nodeAddressAnnotation,
 	}, nil
 }
 
-func parsePortProtocols(value string) ([]int, []corev1.Protocol) {
-	ports := make([]int, 0)
-	protocols := make([]corev1.Protocol, 0)
-	for _, pp := range strings.Split(value, ",") {
-		ppSlice := strings.Split(pp, "/")
-		port, err := strconv.Atoi(ppSlice[0])
-		if err != nil {
-			continue
-		}
-		ports = append(ports, port)
-		if len(ppSlice) != 2 {
-			protocols = append(protocols, corev1.ProtocolTCP)
-		} else {
-			protocols = append(protocols, corev1.Protocol(ppSlice[1]))
+// getNodeAddress returns the value of the annotation of the node if it is set,
+// otherwise the ExternalIP of the node, falling back to its InternalIP.
+func getNodeAddress(node *corev1.Node, annotation string) string {
+	if annotation != "" {
+		if address := strings.TrimSpace(node.GetAnnotations()[annotation]); address != "" {
+			return address
 		}
 	}
-	return ports, protocols
+	return getAddress(node)
 }
 
 func consNodePortSvc(npc *nodePortConfig, pod *corev1.Pod, c client.Client, ctx context.Context) *corev1.Service {
@@ -263,8 +311,9 @@ func consNodePortSvc(npc *nodePortConfig, pod *corev1.Pod, c client.Client, ctx
 			Name:      pod.GetName(),
 			Namespace: pod.GetNamespace(),
 			Annotations: map[string]string{
-				ServiceHashKey: util.GetHash(npc),
+				ServiceHashKey: npc.hash(),
 			},
+			Labels:          utils.ServiceLabels(NodePortNetwork, nil),
 			OwnerReferences: consOwnerReference(c, ctx, pod, npc.isFixed),
 		},
 		Spec: corev1.ServiceSpec{
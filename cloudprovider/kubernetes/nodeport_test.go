@@ -10,7 +10,6 @@ import (
 	"k8s.io/utils/ptr"
 
 	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
-	"github.com/openkruise/kruise-game/pkg/util"
 )
 
 func TestParseNPConfig(t *testing.T) {
@@ -43,6 +42,24 @@ func TestParseNPConfig(t *testing.T) {
 				protocols: []corev1.Protocol{corev1.ProtocolUDP},
 			},
 		},
+
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{
+					Name:  PortProtocolsConfigName,
+					Value: "7777/UDP",
+				},
+				{
+					Name:  NodeAddressAnnotationConfigName,
+					Value: "flannel.alpha.coreos.com/public-ip",
+				},
+			},
+			podNetConfig: &nodePortConfig{
+				ports:                 []int{7777},
+				protocols:             []corev1.Protocol{corev1.ProtocolUDP},
+				nodeAddressAnnotation: "flannel.alpha.coreos.com/public-ip",
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -53,6 +70,42 @@ func TestParseNPConfig(t *testing.T) {
 	}
 }
 
+func TestGetNodeAddress(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{"flannel.alpha.coreos.com/public-ip": "47.0.0.1"},
+		},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "192.168.0.1"},
+				{Type: corev1.NodeExternalIP, Address: "120.0.0.1"},
+			},
+		},
+	}
+	tests := []struct {
+		annotation string
+		address    string
+	}{
+		{
+			annotation: "",
+			address:    "120.0.0.1",
+		},
+		{
+			annotation: "flannel.alpha.coreos.com/public-ip",
+			address:    "47.0.0.1",
+		},
+		{
+			annotation: "example.com/public-ip",
+			address:    "120.0.0.1",
+		},
+	}
+	for i, test := range tests {
+		if address := getNodeAddress(node, test.annotation); address != test.address {
+			t.Errorf("case %d: expect address %s, but actually got %s", i, test.address, address)
+		}
+	}
+}
+
 func TestConsNPSvc(t *testing.T) {
 	pod := &corev1.Pod{
 		TypeMeta: metav1.TypeMeta{
@@ -82,8 +135,9 @@ func TestConsNPSvc(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "pod-3",
 			Namespace: "ns",
+			Labels:    map[string]string{gamekruiseiov1alpha1.GameServerNetworkType: NodePortNetwork},
 			Annotations: map[string]string{
-				ServiceHashKey: util.GetHash(npcCase0),
+				ServiceHashKey: npcCase0.hash(),
 			},
 			OwnerReferences: []metav1.OwnerReference{
 				{
@@ -132,8 +186,9 @@ func TestConsNPSvc(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "pod-3",
 			Namespace: "ns",
+			Labels:    map[string]string{gamekruiseiov1alpha1.GameServerNetworkType: NodePortNetwork},
 			Annotations: map[string]string{
-				ServiceHashKey: util.GetHash(npcCase1),
+				ServiceHashKey: npcCase1.hash(),
 			},
 			OwnerReferences: []metav1.OwnerReference{
 				{
@@ -183,3 +238,37 @@ func TestConsNPSvc(t *testing.T) {
 		}
 	}
 }
+
+func FuzzParseNodePortConfig(f *testing.F) {
+	f.Add("80/TCP,7777/UDP", "true")
+	f.Add("80", "")
+	f.Add(" 80 , 80/UDP ", "yes")
+	f.Add("80,80", "false")
+	f.Add("80/tcp,", "1")
+	f.Fuzz(func(t *testing.T, portProtocols, fixed string) {
+		conf := []gamekruiseiov1alpha1.NetworkConfParams{{Name: PortProtocolsConfigName, Value: portProtocols}}
+		if fixed != "" {
+			conf = append(conf, gamekruiseiov1alpha1.NetworkConfParams{Name: FixedKey, Value: fixed})
+		}
+		npc, err := parseNodePortConfig(conf)
+		if err != nil {
+			return
+		}
+		if len(npc.ports) == 0 || len(npc.ports) != len(npc.protocols) {
+			t.Fatalf("unexpected ports %v and protocols %v of %q", npc.ports, npc.protocols, portProtocols)
+		}
+	})
+}
+
+func TestNodePortConfigHash(t *testing.T) {
+	// the hash of the network config existing before upgrading
+	npc, err := parseNodePortConfig([]gamekruiseiov1alpha1.NetworkConfParams{
+		{Name: PortProtocolsConfigName, Value: "80"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if npc.hash() != "3594902903" {
+		t.Errorf("expect hash 3594902903, but actually got %s", npc.hash())
+	}
+}
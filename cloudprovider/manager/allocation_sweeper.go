@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AllocationSweeper removes the stale port allocations of the plugins periodically, such as the ones recorded
+// before the manager crashed in the middle of creating the Service, whose pods have been deleted since then.
+type AllocationSweeper struct {
+	manager  *ProviderManager
+	client   client.Client
+	interval time.Duration
+}
+
+// NewAllocationSweeper returns an AllocationSweeper of the plugins of the ProviderManager.
+func NewAllocationSweeper(pm *ProviderManager, c client.Client, interval time.Duration) *AllocationSweeper {
+	return &AllocationSweeper{
+		manager:  pm,
+		client:   c,
+		interval: interval,
+	}
+}
+
+// Start implements manager.Runnable.
+func (s *AllocationSweeper) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, s.sweep, s.interval)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Only the leader sweeps, so that the allocations
+// shared through the state of the plugins are not removed by several replicas at the same time.
+func (s *AllocationSweeper) NeedLeaderElection() bool {
+	return true
+}
+
+func (s *AllocationSweeper) sweep(ctx context.Context) {
+	s.manager.Sweep(s.client, ctx)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	log "k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openkruise/kruise-game/cloudprovider"
+)
+
+// ConfigWatcher checks the changes of the cloud provider config file periodically, and reloads the options of the
+// cloud providers when it changes. The config file mounted from a ConfigMap is updated by kubelet in place, which is
+// detected by comparing the content rather than watching the file events.
+type ConfigWatcher struct {
+	manager  *ProviderManager
+	client   client.Client
+	path     string
+	interval time.Duration
+	checksum [sha256.Size]byte
+}
+
+// NewConfigWatcher returns a ConfigWatcher of the config file, whose current content is regarded as loaded.
+func NewConfigWatcher(pm *ProviderManager, c client.Client, path string, interval time.Duration) *ConfigWatcher {
+	w := &ConfigWatcher{
+		manager:  pm,
+		client:   c,
+		path:     path,
+		interval: interval,
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		w.checksum = sha256.Sum256(data)
+	}
+	return w
+}
+
+// Start implements manager.Runnable.
+func (w *ConfigWatcher) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, w.check, w.interval)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. The plugins of every replica allocate ports, so
+// that all of them reload the config file.
+func (w *ConfigWatcher) NeedLeaderElection() bool {
+	return false
+}
+
+func (w *ConfigWatcher) check(ctx context.Context) {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		log.Warningf("failed to read cloud provider config file %s, because of %s", w.path, err.Error())
+		return
+	}
+	checksum := sha256.Sum256(data)
+	if checksum == w.checksum {
+		return
+	}
+	w.checksum = checksum
+	configs, err := cloudprovider.ParseConfig(data)
+	if err != nil {
+		log.Errorf("failed to parse cloud provider config file %s, the options are not reloaded, because of %s", w.path, err.Error())
+		return
+	}
+	log.Infof("cloud provider config file %s has changed, reloading the options", w.path)
+	w.manager.Reload(w.client, configs)
+}
@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openkruise/kruise-game/cloudprovider"
+	cperrors "github.com/openkruise/kruise-game/cloudprovider/errors"
+	"github.com/openkruise/kruise-game/cloudprovider/options"
+)
+
+type fakeReconfigurablePlugin struct {
+	options []cloudprovider.CloudProviderOptions
+}
+
+func (f *fakeReconfigurablePlugin) Name() string {
+	return "Fake-HostPort"
+}
+
+func (f *fakeReconfigurablePlugin) Alias() string {
+	return ""
+}
+
+func (f *fakeReconfigurablePlugin) Init(client client.Client, options cloudprovider.CloudProviderOptions, ctx context.Context) error {
+	return nil
+}
+
+func (f *fakeReconfigurablePlugin) OnPodAdded(client client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, cperrors.PluginError) {
+	return pod, nil
+}
+
+func (f *fakeReconfigurablePlugin) OnPodUpdated(client client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, cperrors.PluginError) {
+	return pod, nil
+}
+
+func (f *fakeReconfigurablePlugin) OnPodDeleted(client client.Client, pod *corev1.Pod, ctx context.Context) cperrors.PluginError {
+	return nil
+}
+
+func (f *fakeReconfigurablePlugin) Reconfigure(client client.Client, options cloudprovider.CloudProviderOptions, ctx context.Context) error {
+	f.options = append(f.options, options)
+	return nil
+}
+
+type fakeCloudProvider struct {
+	plugin cloudprovider.Plugin
+}
+
+func (f *fakeCloudProvider) Name() string {
+	return "Kubernetes"
+}
+
+func (f *fakeCloudProvider) ListPlugins() (map[string]cloudprovider.Plugin, error) {
+	return map[string]cloudprovider.Plugin{f.plugin.Name(): f.plugin}, nil
+}
+
+func TestConfigWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("[kubernetes]\nenable = true\n[kubernetes.hostPort]\nmax_port = 9000\nmin_port = 8000\n")
+
+	plugin := &fakeReconfigurablePlugin{}
+	pm := &ProviderManager{
+		CloudProviders: map[string]cloudprovider.CloudProvider{"Kubernetes": &fakeCloudProvider{plugin: plugin}},
+		CPOptions: map[string]cloudprovider.CloudProviderOptions{
+			"Kubernetes": options.KubernetesOptions{Enable: true, HostPort: options.HostPortOptions{MaxPort: 9000, MinPort: 8000}},
+		},
+	}
+	w := NewConfigWatcher(pm, nil, path, 0)
+
+	// unchanged
+	w.check(context.TODO())
+	if len(plugin.options) != 0 {
+		t.Errorf("expect no reconfiguration, but actually got %v", plugin.options)
+	}
+
+	// the port range changes
+	write("[kubernetes]\nenable = true\n[kubernetes.hostPort]\nmax_port = 9500\nmin_port = 8000\n")
+	w.check(context.TODO())
+	expect := options.KubernetesOptions{Enable: true, HostPort: options.HostPortOptions{MaxPort: 9500, MinPort: 8000}}
+	if len(plugin.options) != 1 || plugin.options[0] != expect {
+		t.Errorf("expect reconfigured with %v, but actually got %v", expect, plugin.options)
+	}
+	if pm.FindConfigs("Kubernetes") != expect {
+		t.Errorf("expect options %v, but actually got %v", expect, pm.FindConfigs("Kubernetes"))
+	}
+
+	// invalid port range is not applied
+	write("[kubernetes]\nenable = true\n[kubernetes.hostPort]\nmax_port = 7000\nmin_port = 8000\n")
+	w.check(context.TODO())
+	if len(plugin.options) != 1 {
+		t.Errorf("expect invalid options not applied, but actually got %v", plugin.options)
+	}
+
+	// unparsable config file is not applied
+	write("[kubernetes\n")
+	w.check(context.TODO())
+	if len(plugin.options) != 1 {
+		t.Errorf("expect unparsable config file not applied, but actually got %v", plugin.options)
+	}
+}
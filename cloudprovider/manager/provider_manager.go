@@ -22,19 +22,30 @@ import (
 	"github.com/openkruise/kruise-game/cloudprovider"
 	"github.com/openkruise/kruise-game/cloudprovider/alibabacloud"
 	aws "github.com/openkruise/kruise-game/cloudprovider/amazonswebservices"
+	"github.com/openkruise/kruise-game/cloudprovider/azure"
+	"github.com/openkruise/kruise-game/cloudprovider/external"
+	"github.com/openkruise/kruise-game/cloudprovider/googlecloud"
+	"github.com/openkruise/kruise-game/cloudprovider/hwcloud"
 	"github.com/openkruise/kruise-game/cloudprovider/kubernetes"
+	"github.com/openkruise/kruise-game/cloudprovider/options"
 	volcengine "github.com/openkruise/kruise-game/cloudprovider/volcengine"
 	corev1 "k8s.io/api/core/v1"
 	log "k8s.io/klog/v2"
+	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sync"
 )
 
 type ProviderManager struct {
 	CloudProviders map[string]cloudprovider.CloudProvider
 	CPOptions      map[string]cloudprovider.CloudProviderOptions
+	// optionsMutex guards CPOptions, which are replaced when the config file is reloaded
+	optionsMutex sync.RWMutex
 }
 
 func (pm *ProviderManager) FindConfigs(cpName string) cloudprovider.CloudProviderOptions {
+	pm.optionsMutex.RLock()
+	defer pm.optionsMutex.RUnlock()
 	return pm.CPOptions[cpName]
 }
 
@@ -44,7 +55,9 @@ func (pm *ProviderManager) RegisterCloudProvider(provider cloudprovider.CloudPro
 	}
 
 	pm.CloudProviders[provider.Name()] = provider
+	pm.optionsMutex.Lock()
 	pm.CPOptions[provider.Name()] = options
+	pm.optionsMutex.Unlock()
 }
 
 func (pm *ProviderManager) FindAvailablePlugins(pod *corev1.Pod) (cloudprovider.Plugin, bool) {
@@ -53,7 +66,11 @@ func (pm *ProviderManager) FindAvailablePlugins(pod *corev1.Pod) (cloudprovider.
 		log.V(5).Infof("Pod %s has no plugin configured and skip", pod.Name)
 		return nil, false
 	}
+	return pm.FindPlugin(pluginType)
+}
 
+// FindPlugin returns the plugin of the network type.
+func (pm *ProviderManager) FindPlugin(pluginType string) (cloudprovider.Plugin, bool) {
 	for _, cp := range pm.CloudProviders {
 		plugins, err := cp.ListPlugins()
 		if err != nil {
@@ -88,6 +105,74 @@ func (pm *ProviderManager) Init(client client.Client) {
 	}
 }
 
+// Reload applies the changed options of the registered cloud providers to their plugins implementing
+// cloudprovider.ReconfigurablePlugin. Enabling or disabling a cloud provider still requires restarting the manager.
+func (pm *ProviderManager) Reload(client client.Client, configs *cloudprovider.CloudProviderConfig) {
+	newOptions := providerOptions(configs)
+	for name, cp := range pm.CloudProviders {
+		options, ok := newOptions[name]
+		if !ok || reflect.DeepEqual(options, pm.FindConfigs(name)) {
+			continue
+		}
+		if !options.Valid() || !options.Enabled() {
+			log.Warningf("Cloud Provider [%s] has invalid or disabled options, which are not reloaded", name)
+			continue
+		}
+		plugins, err := cp.ListPlugins()
+		if err != nil {
+			continue
+		}
+		pm.optionsMutex.Lock()
+		pm.CPOptions[name] = options
+		pm.optionsMutex.Unlock()
+		log.Infof("Cloud Provider [%s] options have been reloaded", name)
+		for _, p := range plugins {
+			rp, ok := p.(cloudprovider.ReconfigurablePlugin)
+			if !ok {
+				continue
+			}
+			if err := rp.Reconfigure(client, options, context.Background()); err != nil {
+				log.Errorf("plugin [%s] failed to reconfigure, because of %s", p.Name(), err.Error())
+				continue
+			}
+			log.Infof("plugin [%s] has been reconfigured", p.Name())
+		}
+	}
+}
+
+// Sweep removes the stale port allocations of the plugins implementing cloudprovider.SweepablePlugin.
+func (pm *ProviderManager) Sweep(client client.Client, ctx context.Context) {
+	for _, cp := range pm.CloudProviders {
+		plugins, err := cp.ListPlugins()
+		if err != nil {
+			continue
+		}
+		for _, p := range plugins {
+			sp, ok := p.(cloudprovider.SweepablePlugin)
+			if !ok {
+				continue
+			}
+			if err := sp.Sweep(client, ctx); err != nil {
+				log.Errorf("plugin [%s] failed to sweep stale allocations, because of %s", p.Name(), err.Error())
+			}
+		}
+	}
+}
+
+// providerOptions returns the options of the cloud providers in the configs, keyed by the names of the providers.
+func providerOptions(configs *cloudprovider.CloudProviderConfig) map[string]cloudprovider.CloudProviderOptions {
+	return map[string]cloudprovider.CloudProviderOptions{
+		"Kubernetes":              configs.KubernetesOptions,
+		alibabacloud.AlibabaCloud: configs.AlibabaCloudOptions,
+		volcengine.Volcengine:     configs.VolcengineOptions,
+		aws.AmazonsWebServices:    configs.AmazonsWebServicesOptions,
+		googlecloud.GoogleCloud:   configs.GoogleCloudOptions,
+		azure.Azure:               configs.AzureOptions,
+		hwcloud.HwCloud:           configs.HwCloudOptions,
+		external.External:         configs.ExternalOptions,
+	}
+}
+
 // NewProviderManager return a new cloud provider manager instance
 func NewProviderManager() (*ProviderManager, error) {
 	configFile := cloudprovider.NewConfigFile(cloudprovider.Opt.CloudProviderConfigFile)
@@ -138,5 +223,45 @@ func NewProviderManager() (*ProviderManager, error) {
 		}
 	}
 
+	if configs.GoogleCloudOptions.Valid() && configs.GoogleCloudOptions.Enabled() {
+		// build and register google cloud provider
+		gcp, err := googlecloud.NewGoogleCloudProvider()
+		if err != nil {
+			log.Errorf("Failed to initialize google cloud provider.because of %s", err.Error())
+		} else {
+			pm.RegisterCloudProvider(gcp, configs.GoogleCloudOptions)
+		}
+	}
+
+	if configs.AzureOptions.Valid() && configs.AzureOptions.Enabled() {
+		// build and register azure cloud provider
+		azureProvider, err := azure.NewAzureProvider()
+		if err != nil {
+			log.Errorf("Failed to initialize azure provider.because of %s", err.Error())
+		} else {
+			pm.RegisterCloudProvider(azureProvider, configs.AzureOptions)
+		}
+	}
+
+	if configs.HwCloudOptions.Valid() && configs.HwCloudOptions.Enabled() {
+		// build and register huawei cloud provider
+		hwcloudProvider, err := hwcloud.NewHwCloudProvider()
+		if err != nil {
+			log.Errorf("Failed to initialize huawei cloud provider.because of %s", err.Error())
+		} else {
+			pm.RegisterCloudProvider(hwcloudProvider, configs.HwCloudOptions)
+		}
+	}
+
+	if configs.ExternalOptions.Valid() && configs.ExternalOptions.Enabled() {
+		// build and register the provider of out-of-tree plugins served over gRPC
+		externalProvider, err := external.NewExternalProvider(configs.ExternalOptions.(options.ExternalOptions))
+		if err != nil {
+			log.Errorf("Failed to initialize external provider.because of %s", err.Error())
+		} else {
+			pm.RegisterCloudProvider(externalProvider, configs.ExternalOptions)
+		}
+	}
+
 	return pm, nil
 }
@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	log "k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/cloudprovider"
+	"github.com/openkruise/kruise-game/pkg/httpauth"
+	"github.com/openkruise/kruise-game/pkg/readonly"
+)
+
+// RebalancePath is the path of the rebalance endpoint served over TLS by the elected leader.
+const RebalancePath = "/network/rebalance"
+
+// The query parameters of the rebalance endpoint.
+const (
+	NetworkTypeParam    = "networkType"
+	NamespaceParam      = "namespace"
+	GameServerSetParam  = "gameServerSet"
+	MaxUtilizationParam = "maxUtilization"
+	MaxMovesParam       = "maxMoves"
+	DryRunParam         = "dryRun"
+)
+
+const (
+	DefaultMaxUtilization = 0.8
+	DefaultMaxMoves       = 10
+)
+
+// NewRebalanceHandler returns the handler of the rebalance endpoint, which migrates the idle GameServers from the
+// busy load balancers to the idle ones by the plugin of the network type implementing cloudprovider.RebalanceablePlugin.
+// The users are required to be allowed to update the GameServers of the namespace, or of all namespaces if not set.
+func NewRebalanceHandler(pm *ProviderManager, c client.Client) http.Handler {
+	return httpauth.Authorized(c, getRebalanceAttributes, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if readonly.Enabled() {
+			http.Error(w, readonly.ErrReadOnly.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		query := req.URL.Query()
+		networkType := query.Get(NetworkTypeParam)
+		opts, err := parseRebalanceOptions(query.Get)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		plugin, ok := pm.FindPlugin(networkType)
+		if !ok {
+			http.Error(w, fmt.Sprintf("network type %q is not found", networkType), http.StatusNotFound)
+			return
+		}
+		rp, ok := plugin.(cloudprovider.RebalanceablePlugin)
+		if !ok {
+			http.Error(w, fmt.Sprintf("network type %q does not support rebalancing", networkType), http.StatusBadRequest)
+			return
+		}
+
+		result, err := rp.Rebalance(c, req.Context(), opts)
+		if err != nil {
+			log.Errorf("plugin [%s] failed to rebalance, because of %s", networkType, err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			log.Errorf("failed to write rebalance result, because of %s", err.Error())
+		}
+	}))
+}
+
+func getRebalanceAttributes(req *http.Request) *authorizationv1.ResourceAttributes {
+	return &authorizationv1.ResourceAttributes{
+		Namespace: req.URL.Query().Get(NamespaceParam),
+		Verb:      "update",
+		Group:     gamekruiseiov1alpha1.GroupVersion.Group,
+		Resource:  "gameservers",
+	}
+}
+
+func parseRebalanceOptions(get func(string) string) (cloudprovider.RebalanceOptions, error) {
+	opts := cloudprovider.RebalanceOptions{
+		Namespace:      get(NamespaceParam),
+		GameServerSet:  get(GameServerSetParam),
+		MaxUtilization: DefaultMaxUtilization,
+		MaxMoves:       DefaultMaxMoves,
+	}
+	if get(NetworkTypeParam) == "" {
+		return opts, fmt.Errorf("%s is required", NetworkTypeParam)
+	}
+	var err error
+	if v := get(MaxUtilizationParam); v != "" {
+		if opts.MaxUtilization, err = strconv.ParseFloat(v, 64); err != nil || opts.MaxUtilization <= 0 || opts.MaxUtilization > 1 {
+			return opts, fmt.Errorf("%s should be a number in (0, 1], but got %q", MaxUtilizationParam, v)
+		}
+	}
+	if v := get(MaxMovesParam); v != "" {
+		if opts.MaxMoves, err = strconv.Atoi(v); err != nil || opts.MaxMoves <= 0 {
+			return opts, fmt.Errorf("%s should be a positive integer, but got %q", MaxMovesParam, v)
+		}
+	}
+	if v := get(DryRunParam); v != "" {
+		if opts.DryRun, err = strconv.ParseBool(v); err != nil {
+			return opts, fmt.Errorf("%s should be a boolean, but got %q", DryRunParam, v)
+		}
+	}
+	return opts, nil
+}
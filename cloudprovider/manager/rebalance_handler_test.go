@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/cloudprovider"
+	"github.com/openkruise/kruise-game/pkg/readonly"
+)
+
+type fakeRebalanceablePlugin struct {
+	fakeReconfigurablePlugin
+	opts []cloudprovider.RebalanceOptions
+}
+
+func (f *fakeRebalanceablePlugin) Rebalance(client client.Client, ctx context.Context, opts cloudprovider.RebalanceOptions) (*cloudprovider.RebalanceResult, error) {
+	f.opts = append(f.opts, opts)
+	return &cloudprovider.RebalanceResult{
+		Moves:       []cloudprovider.RebalanceMove{{Namespace: "xxx", Name: "gs-0", From: "lb-A", To: "lb-B"}},
+		Utilization: map[string]float64{"lb-A": 0.5, "lb-B": 0.5},
+	}, nil
+}
+
+// reviewClient authenticates the tokens as the users of the same names, and allows each of them to update the
+// GameServers of the namespace it is mapped to, or of all namespaces if it is mapped to empty.
+type reviewClient struct {
+	client.Client
+	namespaces map[string]string
+}
+
+func (c *reviewClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	switch review := obj.(type) {
+	case *authenticationv1.TokenReview:
+		if _, ok := c.namespaces[review.Spec.Token]; ok {
+			review.Status.Authenticated = true
+			review.Status.User.Username = review.Spec.Token
+		}
+		return nil
+	case *authorizationv1.SubjectAccessReview:
+		attributes := review.Spec.ResourceAttributes
+		namespace, ok := c.namespaces[review.Spec.User]
+		review.Status.Allowed = ok && attributes.Verb == "update" && attributes.Resource == "gameservers" &&
+			attributes.Group == gamekruiseiov1alpha1.GroupVersion.Group && (namespace == "" || namespace == attributes.Namespace)
+		return nil
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestRebalanceHandler(t *testing.T) {
+	plugin := &fakeRebalanceablePlugin{}
+	pm := &ProviderManager{
+		CloudProviders: map[string]cloudprovider.CloudProvider{"Kubernetes": &fakeCloudProvider{plugin: plugin}},
+	}
+	handler := NewRebalanceHandler(pm, &reviewClient{namespaces: map[string]string{"admin": "", "ops": "xxx"}})
+
+	tests := []struct {
+		method   string
+		query    string
+		token    string
+		readOnly bool
+		code     int
+		opts     *cloudprovider.RebalanceOptions
+	}{
+		{
+			method: http.MethodPost,
+			query:  "?networkType=Fake-HostPort",
+			code:   http.StatusOK,
+			opts:   &cloudprovider.RebalanceOptions{MaxUtilization: DefaultMaxUtilization, MaxMoves: DefaultMaxMoves},
+		},
+		{
+			method: http.MethodPost,
+			query:  "?networkType=Fake-HostPort&namespace=xxx&gameServerSet=gs&maxUtilization=0.6&maxMoves=3&dryRun=true",
+			code:   http.StatusOK,
+			opts:   &cloudprovider.RebalanceOptions{Namespace: "xxx", GameServerSet: "gs", MaxUtilization: 0.6, MaxMoves: 3, DryRun: true},
+		},
+		{
+			method: http.MethodGet,
+			query:  "?networkType=Fake-HostPort",
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			method: http.MethodPost,
+			query:  "",
+			code:   http.StatusBadRequest,
+		},
+		{
+			method: http.MethodPost,
+			query:  "?networkType=Fake-HostPort&maxUtilization=1.2",
+			code:   http.StatusBadRequest,
+		},
+		{
+			method: http.MethodPost,
+			query:  "?networkType=Fake-HostPort&maxMoves=0",
+			code:   http.StatusBadRequest,
+		},
+		{
+			method: http.MethodPost,
+			query:  "?networkType=Unknown",
+			code:   http.StatusNotFound,
+		},
+		// the user allowed in its namespace only
+		{
+			method: http.MethodPost,
+			query:  "?networkType=Fake-HostPort&namespace=xxx",
+			token:  "ops",
+			code:   http.StatusOK,
+			opts:   &cloudprovider.RebalanceOptions{Namespace: "xxx", MaxUtilization: DefaultMaxUtilization, MaxMoves: DefaultMaxMoves},
+		},
+		{
+			method: http.MethodPost,
+			query:  "?networkType=Fake-HostPort",
+			token:  "ops",
+			code:   http.StatusForbidden,
+		},
+		// the request without the token is not authenticated
+		{
+			method: http.MethodPost,
+			query:  "?networkType=Fake-HostPort",
+			token:  "-",
+			code:   http.StatusUnauthorized,
+		},
+		{
+			method: http.MethodPost,
+			query:  "?networkType=Fake-HostPort",
+			token:  "unknown",
+			code:   http.StatusUnauthorized,
+		},
+		// nothing is moved in read-only mode
+		{
+			method:   http.MethodPost,
+			query:    "?networkType=Fake-HostPort",
+			readOnly: true,
+			code:     http.StatusServiceUnavailable,
+		},
+	}
+
+	for i, test := range tests {
+		plugin.opts = nil
+		readonly.Set(test.readOnly)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRebalanceRequest(test.method, test.query, test.token))
+		readonly.Set(false)
+		if w.Code != test.code {
+			t.Errorf("case %d: expect code %d, but got %d: %s", i, test.code, w.Code, w.Body.String())
+			continue
+		}
+		if test.opts == nil {
+			if len(plugin.opts) != 0 {
+				t.Errorf("case %d: expect plugin not called, but got %v", i, plugin.opts)
+			}
+			continue
+		}
+		if len(plugin.opts) != 1 || !reflect.DeepEqual(plugin.opts[0], *test.opts) {
+			t.Errorf("case %d: expect options %v, but got %v", i, *test.opts, plugin.opts)
+		}
+		result := &cloudprovider.RebalanceResult{}
+		if err := json.Unmarshal(w.Body.Bytes(), result); err != nil || len(result.Moves) != 1 {
+			t.Errorf("case %d: expect one move, but got %s", i, w.Body.String())
+		}
+	}
+
+	// the plugins not implementing RebalanceablePlugin
+	pm.CloudProviders["Kubernetes"] = &fakeCloudProvider{plugin: &fakeReconfigurablePlugin{}}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newRebalanceRequest(http.MethodPost, "?networkType=Fake-HostPort", ""))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expect code %d, but got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// newRebalanceRequest returns the request with the token, which is of admin if empty, or no token if -.
+func newRebalanceRequest(method, query, token string) *http.Request {
+	req := httptest.NewRequest(method, RebalancePath+query, nil)
+	switch token {
+	case "":
+		req.Header.Set("Authorization", "Bearer admin")
+	case "-":
+	default:
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
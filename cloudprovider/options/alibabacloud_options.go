@@ -9,6 +9,9 @@ type AlibabaCloudOptions struct {
 type SLBOptions struct {
 	MaxPort int32 `toml:"max_port"`
 	MinPort int32 `toml:"min_port"`
+	// StateConfigMap is the <namespace>/<name> of the ConfigMap persisting the port allocations.
+	// The allocations are only kept in memory and rebuilt from Services at startup if it is empty.
+	StateConfigMap string `toml:"state_configmap"`
 }
 
 type NLBOptions struct {
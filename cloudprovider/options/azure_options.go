@@ -0,0 +1,32 @@
+package options
+
+type AzureOptions struct {
+	Enable    bool           `toml:"enable"`
+	LBOptions AzureLBOptions `toml:"lb"`
+}
+
+type AzureLBOptions struct {
+	MaxPort int32 `toml:"max_port"`
+	MinPort int32 `toml:"min_port"`
+}
+
+func (o AzureOptions) Valid() bool {
+	lbOptions := o.LBOptions
+
+	if lbOptions.MaxPort > 65535 {
+		return false
+	}
+
+	if lbOptions.MinPort < 1 {
+		return false
+	}
+
+	if lbOptions.MaxPort < lbOptions.MinPort {
+		return false
+	}
+	return true
+}
+
+func (o AzureOptions) Enabled() bool {
+	return o.Enable
+}
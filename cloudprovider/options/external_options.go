@@ -0,0 +1,50 @@
+package options
+
+import "time"
+
+type ExternalOptions struct {
+	Enable  bool                    `toml:"enable"`
+	Plugins []ExternalPluginOptions `toml:"plugins"`
+}
+
+// ExternalPluginOptions configures an out-of-tree network plugin served over gRPC.
+type ExternalPluginOptions struct {
+	// Name is the network type served by the plugin.
+	Name string `toml:"name"`
+	// Address is the gRPC target of the plugin, such as unix:///var/run/kruise-game/plugin.sock.
+	Address string `toml:"address"`
+	// Timeout is the timeout of each call, 2s by default.
+	Timeout time.Duration `toml:"timeout"`
+	// Retries is the number of retries of a call failed for unavailability or timeout, 2 by default.
+	Retries *int `toml:"retries"`
+	// RetryInterval is the interval before the first retry, which is doubled for each retry, 200ms by default.
+	RetryInterval time.Duration `toml:"retry_interval"`
+	// QPS is the rate of the calls to the plugin for each rate limit key, 0 by default which means no limit.
+	// The calls are limited on behalf of the cloud APIs called by the plugin.
+	QPS float64 `toml:"qps"`
+	// Burst is the burst of the calls for each rate limit key, 1 if it is not set while QPS is set.
+	Burst int `toml:"burst"`
+	// RateLimitKey is the name of the network conf parameter, such as the load balancer id, by whose value the calls
+	// are limited separately. The calls of all pods share a token bucket if it is empty.
+	RateLimitKey string `toml:"rate_limit_key"`
+	// Options are passed to the plugin as they are.
+	Options map[string]string `toml:"options"`
+}
+
+func (o ExternalOptions) Valid() bool {
+	names := make(map[string]bool)
+	for _, p := range o.Plugins {
+		if p.Name == "" || p.Address == "" || names[p.Name] {
+			return false
+		}
+		if p.Timeout < 0 || p.RetryInterval < 0 || (p.Retries != nil && *p.Retries < 0) || p.QPS < 0 || p.Burst < 0 {
+			return false
+		}
+		names[p.Name] = true
+	}
+	return true
+}
+
+func (o ExternalOptions) Enabled() bool {
+	return o.Enable
+}
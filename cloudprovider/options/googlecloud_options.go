@@ -0,0 +1,32 @@
+package options
+
+type GoogleCloudOptions struct {
+	Enable     bool          `toml:"enable"`
+	NLBOptions GcpNLBOptions `toml:"nlb"`
+}
+
+type GcpNLBOptions struct {
+	MaxPort int32 `toml:"max_port"`
+	MinPort int32 `toml:"min_port"`
+}
+
+func (o GoogleCloudOptions) Valid() bool {
+	nlbOptions := o.NLBOptions
+
+	if nlbOptions.MaxPort > 65535 {
+		return false
+	}
+
+	if nlbOptions.MinPort < 1 {
+		return false
+	}
+
+	if nlbOptions.MaxPort < nlbOptions.MinPort {
+		return false
+	}
+	return true
+}
+
+func (o GoogleCloudOptions) Enabled() bool {
+	return o.Enable
+}
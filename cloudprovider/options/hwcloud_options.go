@@ -0,0 +1,32 @@
+package options
+
+type HwCloudOptions struct {
+	Enable     bool              `toml:"enable"`
+	ELBOptions HwCloudELBOptions `toml:"elb"`
+}
+
+type HwCloudELBOptions struct {
+	MaxPort int32 `toml:"max_port"`
+	MinPort int32 `toml:"min_port"`
+}
+
+func (o HwCloudOptions) Valid() bool {
+	elbOptions := o.ELBOptions
+
+	if elbOptions.MaxPort > 65535 {
+		return false
+	}
+
+	if elbOptions.MinPort < 1 {
+		return false
+	}
+
+	if elbOptions.MaxPort < elbOptions.MinPort {
+		return false
+	}
+	return true
+}
+
+func (o HwCloudOptions) Enabled() bool {
+	return o.Enable
+}
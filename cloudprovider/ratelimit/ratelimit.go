@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ratelimit limits the calls of the network plugins to the cloud APIs with token buckets by keys, such as
+// the account or the load balancer, so that a scaling of game servers does not trip the flow control of the cloud.
+package ratelimit
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limiter holds a token bucket for each key. The buckets are created on demand with the same rate.
+type Limiter struct {
+	mutex   sync.Mutex
+	limit   rate.Limit
+	burst   int
+	buckets map[string]*rate.Limiter
+}
+
+// NewLimiter returns the limiter allowing qps calls per second with the burst for each key.
+// A limiter with qps 0 does not limit the calls.
+func NewLimiter(qps float64, burst int) *Limiter {
+	l := &Limiter{buckets: make(map[string]*rate.Limiter)}
+	l.SetRate(qps, burst)
+	return l
+}
+
+// SetRate changes the rate of all the buckets. The burst is at least 1 if qps is not 0.
+func (l *Limiter) SetRate(qps float64, burst int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.limit = rate.Limit(qps)
+	if qps > 0 && burst < 1 {
+		burst = 1
+	}
+	l.burst = burst
+	for _, b := range l.buckets {
+		b.SetLimit(l.limit)
+		b.SetBurst(l.burst)
+	}
+}
+
+// Wait blocks until a call of the key is allowed, and returns the time waited. An error is returned if ctx is
+// done before that.
+func (l *Limiter) Wait(ctx context.Context, key string) (time.Duration, error) {
+	l.mutex.Lock()
+	if l.limit <= 0 {
+		l.mutex.Unlock()
+		return 0, nil
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = rate.NewLimiter(l.limit, l.burst)
+		l.buckets[key] = b
+	}
+	l.mutex.Unlock()
+
+	start := time.Now()
+	err := b.Wait(ctx)
+	return time.Since(start), err
+}
+
+// Jitter returns a random duration in [d, 1.5d), so that the calls throttled together are not retried together.
+func Jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/2+1))
+}
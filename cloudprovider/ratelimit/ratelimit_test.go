@@ -0,0 +1,63 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	// not limited
+	l := NewLimiter(0, 0)
+	for i := 0; i < 100; i++ {
+		if waited, err := l.Wait(ctx, "lb-a"); err != nil || waited != 0 {
+			t.Fatalf("expect no wait, but actually waited %s with error %v", waited, err)
+		}
+	}
+
+	// the burst is used up, and the next call of the same key waits
+	l.SetRate(1, 2)
+	for i := 0; i < 2; i++ {
+		if _, err := l.Wait(ctx, "lb-a"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Wait(timeoutCtx, "lb-a"); err == nil {
+		t.Errorf("expect lb-a to be limited")
+	}
+	// the other keys have their own buckets
+	if _, err := l.Wait(timeoutCtx, "lb-b"); err != nil {
+		t.Errorf("expect lb-b not to be limited, but actually got %v", err)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if d := Jitter(time.Second); d < time.Second || d >= 1500*time.Millisecond {
+			t.Fatalf("expect jitter in [1s, 1.5s), but actually got %s", d)
+		}
+	}
+	if d := Jitter(0); d != 0 {
+		t.Errorf("expect 0, but actually got %s", d)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+// SecretKeyRefScheme is the scheme of the network conf values referencing a key of a Secret in the namespace of
+// the pod, in the form of secretKeyRef://{namespace}/{name}/{key}.
+const SecretKeyRefScheme = "secretKeyRef"
+
+// ValueResolver resolves the network conf values in the form of {scheme}://{ref}, so that sensitive values, such
+// as tokens and certificate ids, are not stored in plaintext in GameServerSets.
+type ValueResolver interface {
+	Resolve(ctx context.Context, c client.Client, pod *corev1.Pod, ref string) (string, error)
+}
+
+var (
+	valueResolversMutex sync.RWMutex
+	valueResolvers      = map[string]ValueResolver{
+		SecretKeyRefScheme: &secretKeyRefResolver{},
+	}
+)
+
+// RegisterValueResolver registers the resolver of the network conf values with the scheme.
+func RegisterValueResolver(scheme string, resolver ValueResolver) {
+	valueResolversMutex.Lock()
+	defer valueResolversMutex.Unlock()
+	valueResolvers[scheme] = resolver
+}
+
+// ParseValueRef returns the resolver and the ref of the network conf value, or false if the value does not
+// reference a registered scheme and is used as it is.
+func ParseValueRef(value string) (ValueResolver, string, bool) {
+	scheme, ref, found := strings.Cut(value, "://")
+	if !found {
+		return nil, "", false
+	}
+	valueResolversMutex.RLock()
+	defer valueResolversMutex.RUnlock()
+	resolver, ok := valueResolvers[scheme]
+	return resolver, ref, ok
+}
+
+// ResolveNetworkConfig returns a copy of conf whose values referencing a registered scheme are resolved.
+func ResolveNetworkConfig(ctx context.Context, c client.Client, pod *corev1.Pod, conf []v1alpha1.NetworkConfParams) ([]v1alpha1.NetworkConfParams, error) {
+	resolved := make([]v1alpha1.NetworkConfParams, len(conf))
+	for i, param := range conf {
+		resolved[i] = param
+		resolver, ref, ok := ParseValueRef(param.Value)
+		if !ok {
+			continue
+		}
+		value, err := resolver.Resolve(ctx, c, pod, ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve network conf %s, because of %s", param.Name, err.Error())
+		}
+		resolved[i].Value = value
+	}
+	return resolved, nil
+}
+
+type secretKeyRefResolver struct{}
+
+// ParseSecretKeyRef returns the namespace, name and key of the Secret referenced by ref.
+func ParseSecretKeyRef(ref string) (string, string, string, error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("%s://%s should be in the form of %s://{namespace}/{name}/{key}", SecretKeyRefScheme, ref, SecretKeyRefScheme)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// Resolve reads the key of the Secret, which must be in the namespace of the pod, so that a GameServerSet cannot
+// read Secrets of other namespaces through the network plugins.
+func (r *secretKeyRefResolver) Resolve(ctx context.Context, c client.Client, pod *corev1.Pod, ref string) (string, error) {
+	namespace, name, key, err := ParseSecretKeyRef(ref)
+	if err != nil {
+		return "", err
+	}
+	if namespace != pod.GetNamespace() {
+		return "", fmt.Errorf("secret %s/%s is not in the namespace %s of the pod", namespace, name, pod.GetNamespace())
+	}
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret); err != nil {
+		return "", err
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s/%s has no key %s", namespace, name, key)
+	}
+	return string(value), nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestResolveNetworkConfig(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "lb-secret"},
+		Data:       map[string][]byte{"certId": []byte("cert-123")},
+	}
+	otherSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "yyy", Name: "lb-secret"},
+		Data:       map[string][]byte{"certId": []byte("cert-456")},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, otherSecret).Build()
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0"}}
+
+	tests := []struct {
+		conf     []v1alpha1.NetworkConfParams
+		expected []v1alpha1.NetworkConfParams
+		err      bool
+	}{
+		{
+			conf: []v1alpha1.NetworkConfParams{
+				{Name: "PortProtocols", Value: "80"},
+				{Name: "Url", Value: "https://example.com"},
+				{Name: "CertId", Value: "secretKeyRef://xxx/lb-secret/certId"},
+			},
+			expected: []v1alpha1.NetworkConfParams{
+				{Name: "PortProtocols", Value: "80"},
+				{Name: "Url", Value: "https://example.com"},
+				{Name: "CertId", Value: "cert-123"},
+			},
+		},
+		// the secret is in another namespace
+		{
+			conf: []v1alpha1.NetworkConfParams{{Name: "CertId", Value: "secretKeyRef://yyy/lb-secret/certId"}},
+			err:  true,
+		},
+		// the key does not exist
+		{
+			conf: []v1alpha1.NetworkConfParams{{Name: "CertId", Value: "secretKeyRef://xxx/lb-secret/token"}},
+			err:  true,
+		},
+		// the ref is malformed
+		{
+			conf: []v1alpha1.NetworkConfParams{{Name: "CertId", Value: "secretKeyRef://lb-secret/certId"}},
+			err:  true,
+		},
+	}
+
+	for i, test := range tests {
+		actual, err := ResolveNetworkConfig(context.Background(), c, pod, test.conf)
+		if (err != nil) != test.err {
+			t.Errorf("case %d: expect err %v, but got %v", i, test.err, err)
+			continue
+		}
+		if !test.err && !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("case %d: expect conf %v, but got %v", i, test.expected, actual)
+		}
+	}
+}
+
+func TestGetNetworkConfig(t *testing.T) {
+	conf, _ := json.Marshal([]v1alpha1.NetworkConfParams{{Name: "CertId", Value: "secretKeyRef://xxx/lb-secret/certId"}})
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "xxx",
+			Name:      "case-0",
+			Annotations: map[string]string{
+				v1alpha1.GameServerNetworkType: "Fake-LB",
+				v1alpha1.GameServerNetworkConf: string(conf),
+			},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "lb-secret"},
+		Data:       map[string][]byte{"certId": []byte("cert-123")},
+	}
+
+	// the value is kept as it is if the Secret does not exist
+	nm := NewNetworkManager(pod, fake.NewClientBuilder().WithScheme(scheme).Build())
+	if value := nm.GetNetworkConfig()[0].Value; value != "secretKeyRef://xxx/lb-secret/certId" {
+		t.Errorf("expect the unresolved value, but got %s", value)
+	}
+
+	nm = NewNetworkManager(pod, fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build())
+	if value := nm.GetNetworkConfig()[0].Value; value != "cert-123" {
+		t.Errorf("expect value cert-123, but got %s", value)
+	}
+	if value := pod.Annotations[v1alpha1.GameServerNetworkConf]; value != string(conf) {
+		t.Errorf("expect the network conf of the pod unchanged, but got %s", value)
+	}
+}
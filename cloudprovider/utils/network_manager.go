@@ -25,6 +25,7 @@ import (
 	log "k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"strconv"
+	"time"
 )
 
 type NetworkManager struct {
@@ -34,6 +35,7 @@ type NetworkManager struct {
 	networkStatus   *v1alpha1.NetworkStatus
 	networkDisabled bool
 	client          client.Client
+	resolvedConf    []v1alpha1.NetworkConfParams
 }
 
 func (nm *NetworkManager) GetNetworkDisabled() bool {
@@ -78,6 +80,8 @@ func (nm *NetworkManager) GetNetworkStatus() (*v1alpha1.NetworkStatus, error) {
 }
 
 func (nm *NetworkManager) UpdateNetworkStatus(networkStatus v1alpha1.NetworkStatus, pod *corev1.Pod) (*corev1.Pod, error) {
+	networkStatus = nm.stabilizeNetworkStatus(networkStatus, pod, time.Now())
+	networkStatus = reasonNetworkStatus(networkStatus, pod)
 	networkStatusBytes, err := json.Marshal(networkStatus)
 	if err != nil {
 		log.Errorf("pod %s can not update networkStatus,because of %s", nm.pod.Name, err.Error())
@@ -87,8 +91,80 @@ func (nm *NetworkManager) UpdateNetworkStatus(networkStatus v1alpha1.NetworkStat
 	return pod, nil
 }
 
+// stabilizeNetworkStatus keeps the previous Ready status until the network stays NotReady for the stabilization
+// window, during which the time it turned NotReady is recorded in the pod annotations. The network turning NotReady
+// because it is disabled is not delayed.
+func (nm *NetworkManager) stabilizeNetworkStatus(networkStatus v1alpha1.NetworkStatus, pod *corev1.Pod, now time.Time) v1alpha1.NetworkStatus {
+	window := nm.getStabilizationWindow()
+	if window <= 0 || nm.networkDisabled || nm.networkStatus == nil ||
+		nm.networkStatus.CurrentNetworkState != v1alpha1.NetworkReady || networkStatus.CurrentNetworkState != v1alpha1.NetworkNotReady {
+		delete(pod.Annotations, v1alpha1.GameServerNetworkNotReadySince)
+		return networkStatus
+	}
+	since, err := time.Parse(time.RFC3339, pod.Annotations[v1alpha1.GameServerNetworkNotReadySince])
+	if err != nil {
+		pod.Annotations[v1alpha1.GameServerNetworkNotReadySince] = now.Format(time.RFC3339)
+		return *nm.networkStatus
+	}
+	if now.Sub(since) < window {
+		return *nm.networkStatus
+	}
+	delete(pod.Annotations, v1alpha1.GameServerNetworkNotReadySince)
+	return networkStatus
+}
+
+// SetNetworkNotReady marks the network not ready, because of the reason.
+func SetNetworkNotReady(networkStatus *v1alpha1.NetworkStatus, reason v1alpha1.NetworkNotReadyReason, message string) {
+	networkStatus.CurrentNetworkState = v1alpha1.NetworkNotReady
+	networkStatus.Reason = reason
+	networkStatus.Message = message
+}
+
+// reasonNetworkStatus clears the reason of the ready network, and reasons the network not ready of the pod not
+// scheduled yet, if the plugin does not tell the reason.
+func reasonNetworkStatus(networkStatus v1alpha1.NetworkStatus, pod *corev1.Pod) v1alpha1.NetworkStatus {
+	if networkStatus.CurrentNetworkState == v1alpha1.NetworkReady {
+		networkStatus.Reason = ""
+		networkStatus.Message = ""
+		return networkStatus
+	}
+	if networkStatus.Reason == "" && pod.Spec.NodeName == "" {
+		networkStatus.Reason = v1alpha1.NetworkPodNotScheduled
+		networkStatus.Message = "pod has not been scheduled to a node"
+	}
+	return networkStatus
+}
+
+func (nm *NetworkManager) getStabilizationWindow() time.Duration {
+	for _, c := range nm.networkConf {
+		if c.Name == v1alpha1.StabilizationWindowSecondsNetworkConfName {
+			seconds, err := strconv.Atoi(c.Value)
+			if err != nil {
+				log.Warningf("Pod %s has invalid %s %s", nm.pod.Name, c.Name, c.Value)
+				return 0
+			}
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return 0
+}
+
+// GetNetworkConfig returns the network conf whose values referencing Secrets or other registered schemes are
+// resolved. The values failed to be resolved are returned as they are, with the error logged.
 func (nm *NetworkManager) GetNetworkConfig() []v1alpha1.NetworkConfParams {
-	return nm.networkConf
+	if nm.client == nil {
+		return nm.networkConf
+	}
+	if nm.resolvedConf != nil {
+		return nm.resolvedConf
+	}
+	resolved, err := ResolveNetworkConfig(context.Background(), nm.client, nm.pod, nm.networkConf)
+	if err != nil {
+		log.Errorf("Pod %s/%s %s", nm.pod.Namespace, nm.pod.Name, err.Error())
+		return nm.networkConf
+	}
+	nm.resolvedConf = resolved
+	return nm.resolvedConf
 }
 
 func (nm *NetworkManager) GetNetworkType() string {
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openkruise/kruise-game/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/json"
+)
+
+func TestStabilizeNetworkStatus(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	ready, _ := json.Marshal(v1alpha1.NetworkStatus{CurrentNetworkState: v1alpha1.NetworkReady})
+	newPod := func(window, since string, disabled bool) *corev1.Pod {
+		conf, _ := json.Marshal([]v1alpha1.NetworkConfParams{{Name: v1alpha1.StabilizationWindowSecondsNetworkConfName, Value: window}})
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "case-0",
+				Annotations: map[string]string{
+					v1alpha1.GameServerNetworkType:   "Fake-LB",
+					v1alpha1.GameServerNetworkConf:   string(conf),
+					v1alpha1.GameServerNetworkStatus: string(ready),
+				},
+				Labels: map[string]string{v1alpha1.GameServerNetworkDisabled: "false"},
+			},
+		}
+		if since != "" {
+			pod.Annotations[v1alpha1.GameServerNetworkNotReadySince] = since
+		}
+		if disabled {
+			pod.Labels[v1alpha1.GameServerNetworkDisabled] = "true"
+		}
+		return pod
+	}
+	tests := []struct {
+		pod         *corev1.Pod
+		state       v1alpha1.NetworkState
+		expectState v1alpha1.NetworkState
+		expectSince string
+	}{
+		// no stabilization window
+		{
+			pod:         newPod("0", "", false),
+			state:       v1alpha1.NetworkNotReady,
+			expectState: v1alpha1.NetworkNotReady,
+		},
+		// turns NotReady, kept Ready
+		{
+			pod:         newPod("30", "", false),
+			state:       v1alpha1.NetworkNotReady,
+			expectState: v1alpha1.NetworkReady,
+			expectSince: now.Format(time.RFC3339),
+		},
+		// keeps NotReady within the window
+		{
+			pod:         newPod("30", now.Add(-10*time.Second).Format(time.RFC3339), false),
+			state:       v1alpha1.NetworkNotReady,
+			expectState: v1alpha1.NetworkReady,
+			expectSince: now.Add(-10 * time.Second).Format(time.RFC3339),
+		},
+		// keeps NotReady beyond the window
+		{
+			pod:         newPod("30", now.Add(-time.Minute).Format(time.RFC3339), false),
+			state:       v1alpha1.NetworkNotReady,
+			expectState: v1alpha1.NetworkNotReady,
+		},
+		// turns Ready again within the window
+		{
+			pod:         newPod("30", now.Add(-10*time.Second).Format(time.RFC3339), false),
+			state:       v1alpha1.NetworkReady,
+			expectState: v1alpha1.NetworkReady,
+		},
+		// turns NotReady since the network is disabled
+		{
+			pod:         newPod("30", "", true),
+			state:       v1alpha1.NetworkNotReady,
+			expectState: v1alpha1.NetworkNotReady,
+		},
+	}
+	for i, test := range tests {
+		nm := NewNetworkManager(test.pod, nil)
+		status := nm.stabilizeNetworkStatus(v1alpha1.NetworkStatus{CurrentNetworkState: test.state}, test.pod, now)
+		if status.CurrentNetworkState != test.expectState {
+			t.Errorf("case %d: expect network state %s, but actually got %s", i, test.expectState, status.CurrentNetworkState)
+		}
+		if since := test.pod.Annotations[v1alpha1.GameServerNetworkNotReadySince]; since != test.expectSince {
+			t.Errorf("case %d: expect not ready since %s, but actually got %s", i, test.expectSince, since)
+		}
+	}
+}
+
+func TestReasonNetworkStatus(t *testing.T) {
+	tests := []struct {
+		networkStatus v1alpha1.NetworkStatus
+		nodeName      string
+		reason        v1alpha1.NetworkNotReadyReason
+	}{
+		{
+			networkStatus: v1alpha1.NetworkStatus{CurrentNetworkState: v1alpha1.NetworkReady, Reason: v1alpha1.NetworkWaitingForLB},
+			reason:        "",
+		},
+		{
+			networkStatus: v1alpha1.NetworkStatus{CurrentNetworkState: v1alpha1.NetworkNotReady},
+			reason:        v1alpha1.NetworkPodNotScheduled,
+		},
+		{
+			networkStatus: v1alpha1.NetworkStatus{CurrentNetworkState: v1alpha1.NetworkNotReady},
+			nodeName:      "node-0",
+			reason:        "",
+		},
+		// the reason told by the plugin is kept
+		{
+			networkStatus: v1alpha1.NetworkStatus{CurrentNetworkState: v1alpha1.NetworkNotReady, Reason: v1alpha1.NetworkPortExhausted},
+			reason:        v1alpha1.NetworkPortExhausted,
+		},
+	}
+
+	for i, test := range tests {
+		pod := &corev1.Pod{Spec: corev1.PodSpec{NodeName: test.nodeName}}
+		actual := reasonNetworkStatus(test.networkStatus, pod)
+		if actual.Reason != test.reason {
+			t.Errorf("case %d: expect reason %q, but got %q", i, test.reason, actual.Reason)
+		}
+	}
+}
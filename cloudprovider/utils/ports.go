@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"strconv"
+	"strings"
+)
+
+// ProtocolTCPUDP is the protocol of PortProtocols exposing the same external port on both TCP and UDP, which is
+// supported by the load balancer plugins allocating external ports, such as SLB and CLB.
+const ProtocolTCPUDP corev1.Protocol = "TCPUDP"
+
+// ParsePortProtocols parses the network parameter PortProtocols formatted as `port1/protocol1,port2/protocol2,...`,
+// in which the protocol is TCP if omitted. Spaces around the items are ignored. An error is returned for an empty
+// value, an invalid port or protocol, or a port exposed on the same protocol more than once.
+func ParsePortProtocols(value string) ([]int, []corev1.Protocol, error) {
+	ports := make([]int, 0)
+	protocols := make([]corev1.Protocol, 0)
+	seen := make(map[string]bool)
+	for _, pp := range strings.Split(value, ",") {
+		pp = strings.TrimSpace(pp)
+		if pp == "" {
+			return nil, nil, fmt.Errorf("invalid PortProtocols %q, which has an empty item", value)
+		}
+		ppSlice := strings.Split(pp, "/")
+		if len(ppSlice) > 2 {
+			return nil, nil, fmt.Errorf("invalid PortProtocols item %q, which should be port/protocol", pp)
+		}
+		port, err := strconv.Atoi(strings.TrimSpace(ppSlice[0]))
+		if err != nil || port < 1 || port > 65535 {
+			return nil, nil, fmt.Errorf("invalid port %q in PortProtocols, which should be in 1-65535", ppSlice[0])
+		}
+		protocol := corev1.ProtocolTCP
+		if len(ppSlice) == 2 {
+			protocol = corev1.Protocol(strings.TrimSpace(ppSlice[1]))
+		}
+		switch protocol {
+		case corev1.ProtocolTCP, corev1.ProtocolUDP, corev1.ProtocolSCTP, ProtocolTCPUDP:
+		default:
+			return nil, nil, fmt.Errorf("invalid protocol %q in PortProtocols, which should be TCP, UDP, SCTP or TCPUDP", protocol)
+		}
+		key := strconv.Itoa(port) + "/" + string(protocol)
+		if seen[key] {
+			return nil, nil, fmt.Errorf("duplicate port %s in PortProtocols", key)
+		}
+		seen[key] = true
+		ports = append(ports, port)
+		protocols = append(protocols, protocol)
+	}
+	return ports, protocols, nil
+}
+
+// ValidatePortCount returns an error if the ports of a pod can not fit in the port range [minPort, maxPort) of a
+// load balancer, in which case the network of the pods can never be ready.
+func ValidatePortCount(num int, minPort, maxPort int32) error {
+	if num > int(maxPort-minPort) {
+		return fmt.Errorf("%d ports in PortProtocols exceed the port range [%d, %d) of a load balancer", num, minPort, maxPort)
+	}
+	return nil
+}
+
+// portExhaustedError is the error of allocating ports when no port is free for the pod.
+type portExhaustedError struct {
+	msg string
+}
+
+func (e *portExhaustedError) Error() string {
+	return e.msg
+}
+
+// NewPortExhaustedError returns the error of allocating ports when no port is free for the pod.
+func NewPortExhaustedError(format string, args ...interface{}) error {
+	return &portExhaustedError{msg: fmt.Sprintf(format, args...)}
+}
+
+// IsPortExhausted returns whether the error is, or wraps, the error of no port free for the pod.
+func IsPortExhausted(err error) bool {
+	var e *portExhaustedError
+	return errors.As(err, &e)
+}
+
+// ConsServicePorts returns the ServicePorts forwarding the external port to the target port. The protocol TCPUDP
+// results in a TCP and a UDP ServicePort sharing the external port, named by the target port suffixed with the
+// protocol in lowercase.
+func ConsServicePorts(targetPort int, port int32, protocol corev1.Protocol) []corev1.ServicePort {
+	if protocol != ProtocolTCPUDP {
+		return []corev1.ServicePort{
+			{
+				Name:       strconv.Itoa(targetPort),
+				Port:       port,
+				Protocol:   protocol,
+				TargetPort: intstr.FromInt(targetPort),
+			},
+		}
+	}
+	svcPorts := make([]corev1.ServicePort, 0, 2)
+	for _, p := range []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolUDP} {
+		svcPorts = append(svcPorts, corev1.ServicePort{
+			Name:       strconv.Itoa(targetPort) + "-" + strings.ToLower(string(p)),
+			Port:       port,
+			Protocol:   p,
+			TargetPort: intstr.FromInt(targetPort),
+		})
+	}
+	return svcPorts
+}
+
+// GetExternalPorts returns the distinct external ports of the ServicePorts in order, in which the TCP and UDP
+// ServicePorts of the protocol TCPUDP share a port.
+func GetExternalPorts(ports []corev1.ServicePort) []int32 {
+	var ret []int32
+	seen := make(map[int32]bool)
+	for _, port := range ports {
+		if seen[port.Port] {
+			continue
+		}
+		seen[port.Port] = true
+		ret = append(ret, port.Port)
+	}
+	return ret
+}
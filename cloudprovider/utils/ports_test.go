@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestConsServicePorts(t *testing.T) {
+	tests := []struct {
+		targetPort int
+		port       int32
+		protocol   corev1.Protocol
+		svcPorts   []corev1.ServicePort
+	}{
+		{
+			targetPort: 80,
+			port:       500,
+			protocol:   corev1.ProtocolUDP,
+			svcPorts: []corev1.ServicePort{
+				{Name: "80", Port: 500, Protocol: corev1.ProtocolUDP, TargetPort: intstr.FromInt(80)},
+			},
+		},
+		{
+			targetPort: 8000,
+			port:       501,
+			protocol:   ProtocolTCPUDP,
+			svcPorts: []corev1.ServicePort{
+				{Name: "8000-tcp", Port: 501, Protocol: corev1.ProtocolTCP, TargetPort: intstr.FromInt(8000)},
+				{Name: "8000-udp", Port: 501, Protocol: corev1.ProtocolUDP, TargetPort: intstr.FromInt(8000)},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		actual := ConsServicePorts(test.targetPort, test.port, test.protocol)
+		if !reflect.DeepEqual(actual, test.svcPorts) {
+			t.Errorf("case %d: expect ServicePorts %v, but actually got %v", i, test.svcPorts, actual)
+		}
+		if ports := GetExternalPorts(actual); !reflect.DeepEqual(ports, []int32{test.port}) {
+			t.Errorf("case %d: expect external ports [%d], but actually got %v", i, test.port, ports)
+		}
+	}
+}
+
+func TestIsPortExhausted(t *testing.T) {
+	err := NewPortExhaustedError("no enough ports for pod %s", "default/gs-0")
+	if !IsPortExhausted(err) || err.Error() != "no enough ports for pod default/gs-0" {
+		t.Errorf("expect the port exhausted error, but got %v", err)
+	}
+	if !IsPortExhausted(fmt.Errorf("failed to allocate: %w", err)) {
+		t.Errorf("expect the wrapped port exhausted error")
+	}
+	if IsPortExhausted(fmt.Errorf("no enough ports")) {
+		t.Errorf("expect not the port exhausted error")
+	}
+}
+
+func TestParsePortProtocols(t *testing.T) {
+	tests := []struct {
+		value     string
+		ports     []int
+		protocols []corev1.Protocol
+		expectErr bool
+	}{
+		{
+			value:     "80,7777/UDP",
+			ports:     []int{80, 7777},
+			protocols: []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolUDP},
+		},
+		{
+			value:     " 80 / TCP , 80/UDP ",
+			ports:     []int{80, 80},
+			protocols: []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolUDP},
+		},
+		{
+			value:     "8000/TCPUDP",
+			ports:     []int{8000},
+			protocols: []corev1.Protocol{ProtocolTCPUDP},
+		},
+		{
+			value:     "",
+			expectErr: true,
+		},
+		{
+			value:     "80,",
+			expectErr: true,
+		},
+		{
+			value:     "80/tcp",
+			expectErr: true,
+		},
+		{
+			value:     "80/TCP/UDP",
+			expectErr: true,
+		},
+		{
+			value:     "70000",
+			expectErr: true,
+		},
+		{
+			value:     "80,80/TCP",
+			expectErr: true,
+		},
+	}
+
+	for i, test := range tests {
+		ports, protocols, err := ParsePortProtocols(test.value)
+		if (err != nil) != test.expectErr {
+			t.Errorf("case %d: expect error %v, but actually got %v", i, test.expectErr, err)
+		}
+		if test.expectErr {
+			continue
+		}
+		if !reflect.DeepEqual(ports, test.ports) || !reflect.DeepEqual(protocols, test.protocols) {
+			t.Errorf("case %d: expect %v %v, but actually got %v %v", i, test.ports, test.protocols, ports, protocols)
+		}
+	}
+}
+
+func TestValidatePortCount(t *testing.T) {
+	tests := []struct {
+		num   int
+		valid bool
+	}{
+		{num: 1, valid: true},
+		{num: 10, valid: true},
+		{num: 11, valid: false},
+	}
+	for i, test := range tests {
+		if err := ValidatePortCount(test.num, 500, 510); (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func FuzzParsePortProtocols(f *testing.F) {
+	for _, seed := range []string{"80", "80/TCP,7777/UDP", " 80 / UDP ", "8000/TCPUDP", "", ",", "80,80", "80/tcp", "-1", "0x50/TCP", "80//UDP"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		ports, protocols, err := ParsePortProtocols(value)
+		if err != nil {
+			return
+		}
+		if len(ports) == 0 || len(ports) != len(protocols) {
+			t.Fatalf("unexpected ports %v and protocols %v of %q", ports, protocols, value)
+		}
+		seen := make(map[string]bool)
+		for i, port := range ports {
+			if port < 1 || port > 65535 {
+				t.Fatalf("invalid port %d of %q", port, value)
+			}
+			key := strconv.Itoa(port) + "/" + string(protocols[i])
+			if seen[key] {
+				t.Fatalf("duplicate port %s of %q", key, value)
+			}
+			seen[key] = true
+		}
+	})
+}
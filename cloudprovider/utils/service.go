@@ -2,12 +2,18 @@ package utils
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	kruisePub "github.com/openkruise/kruise-api/apps/pub"
 	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
 	cperrors "github.com/openkruise/kruise-game/cloudprovider/errors"
 	"github.com/openkruise/kruise-game/pkg/util"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	log "k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"strings"
 )
@@ -83,3 +89,183 @@ func AllowNotReadyContainers(c client.Client, ctx context.Context, pod *corev1.P
 	}
 	return false, nil
 }
+
+// GetLoadBalancerAddress returns the first IP and the first DNS name among the ingress points of the load balancer.
+// Some load balancers, such as the ones of AWS and GCP, report DNS names rather than IPs, and either of them
+// makes the network ready.
+func GetLoadBalancerAddress(ingress []corev1.LoadBalancerIngress) (ip string, hostname string) {
+	ips, hostname := GetLoadBalancerAddresses(ingress)
+	if len(ips) != 0 {
+		ip = ips[0]
+	}
+	return ip, hostname
+}
+
+// GetLoadBalancerAddresses returns the distinct IPs and the first DNS name among the ingress points of the load
+// balancer. A dual-stack load balancer reports an IP of each IP family.
+func GetLoadBalancerAddresses(ingress []corev1.LoadBalancerIngress) (ips []string, hostname string) {
+	for _, i := range ingress {
+		if i.IP != "" && !util.IsStringInList(i.IP, ips) {
+			ips = append(ips, i.IP)
+		}
+		if hostname == "" {
+			hostname = i.Hostname
+		}
+	}
+	return ips, hostname
+}
+
+// GetPodIPs returns the IPs of the pod, which has an IP of each IP family in a dual-stack cluster.
+func GetPodIPs(pod *corev1.Pod) []string {
+	var ips []string
+	for _, podIP := range pod.Status.PodIPs {
+		if podIP.IP != "" {
+			ips = append(ips, podIP.IP)
+		}
+	}
+	if len(ips) == 0 && pod.Status.PodIP != "" {
+		ips = append(ips, pod.Status.PodIP)
+	}
+	return ips
+}
+
+// IPFamilyConf is the IP families of the Services created by a network plugin.
+type IPFamilyConf struct {
+	Policy   *corev1.IPFamilyPolicyType
+	Families []corev1.IPFamily
+}
+
+// ParseIPFamilyConf parses IPFamilyPolicy and IPFamilies of the network conf. Two IP families require a dual-stack
+// policy, as the Service API does.
+func ParseIPFamilyConf(conf []gamekruiseiov1alpha1.NetworkConfParams) (IPFamilyConf, error) {
+	ipf := IPFamilyConf{}
+	for _, c := range conf {
+		switch c.Name {
+		case gamekruiseiov1alpha1.IPFamilyPolicyNetworkConfName:
+			policy := corev1.IPFamilyPolicyType(strings.TrimSpace(c.Value))
+			switch policy {
+			case corev1.IPFamilyPolicySingleStack, corev1.IPFamilyPolicyPreferDualStack, corev1.IPFamilyPolicyRequireDualStack:
+				ipf.Policy = &policy
+			default:
+				return ipf, fmt.Errorf("invalid %s %s, which should be %s, %s or %s", c.Name, c.Value,
+					corev1.IPFamilyPolicySingleStack, corev1.IPFamilyPolicyPreferDualStack, corev1.IPFamilyPolicyRequireDualStack)
+			}
+		case gamekruiseiov1alpha1.IPFamiliesNetworkConfName:
+			ipf.Families = nil
+			for _, v := range strings.Split(c.Value, ",") {
+				family := corev1.IPFamily(strings.TrimSpace(v))
+				if family != corev1.IPv4Protocol && family != corev1.IPv6Protocol {
+					return ipf, fmt.Errorf("invalid %s %s, which should be %s, %s or both of them", c.Name, c.Value, corev1.IPv4Protocol, corev1.IPv6Protocol)
+				}
+				for _, f := range ipf.Families {
+					if f == family {
+						return ipf, fmt.Errorf("invalid %s %s, in which %s is duplicated", c.Name, c.Value, family)
+					}
+				}
+				ipf.Families = append(ipf.Families, family)
+			}
+		}
+	}
+	if len(ipf.Families) == 2 && (ipf.Policy == nil || *ipf.Policy == corev1.IPFamilyPolicySingleStack) {
+		return ipf, fmt.Errorf("%s should be %s or %s with two %s", gamekruiseiov1alpha1.IPFamilyPolicyNetworkConfName,
+			corev1.IPFamilyPolicyPreferDualStack, corev1.IPFamilyPolicyRequireDualStack, gamekruiseiov1alpha1.IPFamiliesNetworkConfName)
+	}
+	return ipf, nil
+}
+
+// ApplyTo sets the IP families of the Service spec, leaving them to the cluster defaults if they are not set.
+func (ipf IPFamilyConf) ApplyTo(spec *corev1.ServiceSpec) {
+	if ipf.Policy != nil {
+		policy := *ipf.Policy
+		spec.IPFamilyPolicy = &policy
+	}
+	if len(ipf.Families) != 0 {
+		spec.IPFamilies = append([]corev1.IPFamily(nil), ipf.Families...)
+	}
+}
+
+// ServiceLabels returns the labels of the Service created by the network plugin of the network type, which tell it
+// apart from the pre-existing Services of the same name.
+func ServiceLabels(networkType string, svcLabels map[string]string) map[string]string {
+	if svcLabels == nil {
+		svcLabels = make(map[string]string)
+	}
+	svcLabels[gamekruiseiov1alpha1.GameServerNetworkType] = networkType
+	return svcLabels
+}
+
+// IsServiceOwned returns whether the Service of the pod's name is created by the network plugin of the network type.
+// The Services created before they were labeled are told by their owner, the pod or its GameServerSet.
+func IsServiceOwned(svc *corev1.Service, pod *corev1.Pod, networkType string) bool {
+	if svc.GetLabels()[gamekruiseiov1alpha1.GameServerNetworkType] == networkType {
+		return true
+	}
+	for _, ref := range svc.GetOwnerReferences() {
+		if ref.UID == pod.GetUID() {
+			return true
+		}
+		if ref.Kind == "GameServerSet" && ref.Name == pod.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey] {
+			return true
+		}
+	}
+	return false
+}
+
+// GetServiceCollisionPolicy returns the ServiceCollisionPolicy of the network conf, which is Fail if it is not set.
+func GetServiceCollisionPolicy(conf []gamekruiseiov1alpha1.NetworkConfParams) (gamekruiseiov1alpha1.ServiceCollisionPolicy, error) {
+	for _, c := range conf {
+		if c.Name != gamekruiseiov1alpha1.ServiceCollisionPolicyNetworkConfName {
+			continue
+		}
+		policy := gamekruiseiov1alpha1.ServiceCollisionPolicy(strings.TrimSpace(c.Value))
+		switch policy {
+		case gamekruiseiov1alpha1.FailServiceCollisionPolicy, gamekruiseiov1alpha1.AdoptServiceCollisionPolicy, gamekruiseiov1alpha1.RecreateServiceCollisionPolicy:
+			return policy, nil
+		default:
+			return gamekruiseiov1alpha1.FailServiceCollisionPolicy, fmt.Errorf("invalid %s %s, which should be %s, %s or %s", c.Name, c.Value,
+				gamekruiseiov1alpha1.FailServiceCollisionPolicy, gamekruiseiov1alpha1.AdoptServiceCollisionPolicy, gamekruiseiov1alpha1.RecreateServiceCollisionPolicy)
+		}
+	}
+	return gamekruiseiov1alpha1.FailServiceCollisionPolicy, nil
+}
+
+// HandleServiceCollision applies the ServiceCollisionPolicy to the Service of the pod's name, if it is not created by
+// the network plugin. The owned Services created before they were labeled get labeled. It returns true if the plugin
+// should stop handling the pod, whose network status has been updated.
+func HandleServiceCollision(c client.Client, ctx context.Context, nm *NetworkManager, pod *corev1.Pod, svc *corev1.Service,
+	networkStatus *gamekruiseiov1alpha1.NetworkStatus) (bool, *corev1.Pod, cperrors.PluginError) {
+	networkType := nm.GetNetworkType()
+	owned := IsServiceOwned(svc, pod, networkType)
+	if owned && svc.GetLabels()[gamekruiseiov1alpha1.GameServerNetworkType] == networkType {
+		return false, pod, nil
+	}
+
+	policy, err := GetServiceCollisionPolicy(nm.GetNetworkConfig())
+	if err != nil {
+		log.Warningf("Pod %s/%s %s", pod.GetNamespace(), pod.GetName(), err.Error())
+	}
+	if owned || policy == gamekruiseiov1alpha1.AdoptServiceCollisionPolicy {
+		patchSvc := map[string]interface{}{"metadata": map[string]map[string]string{"labels": {gamekruiseiov1alpha1.GameServerNetworkType: networkType}}}
+		patchSvcBytes, err := json.Marshal(patchSvc)
+		if err != nil {
+			return true, pod, cperrors.NewPluginError(cperrors.InternalError, err.Error())
+		}
+		if err := c.Patch(ctx, svc, client.RawPatch(types.MergePatchType, patchSvcBytes)); err != nil {
+			return true, pod, cperrors.NewPluginError(cperrors.ApiCallError, err.Error())
+		}
+		return false, pod, nil
+	}
+
+	message := fmt.Sprintf("service %s exists but is not created by network %s", svc.GetName(), networkType)
+	if policy == gamekruiseiov1alpha1.RecreateServiceCollisionPolicy {
+		uid := svc.GetUID()
+		err := c.Delete(ctx, svc, &client.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+		if err != nil && !errors.IsNotFound(err) {
+			return true, pod, cperrors.NewPluginError(cperrors.ApiCallError, err.Error())
+		}
+		message += ", and is deleted to be recreated"
+	}
+	SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkServiceConflict, message)
+	pod, err = nm.UpdateNetworkStatus(*networkStatus, pod)
+	return true, pod, cperrors.ToPluginError(err, cperrors.InternalError)
+}
@@ -9,9 +9,12 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"strings"
 	"testing"
 )
 
@@ -287,3 +290,210 @@ func TestAllowNotReadyContainers(t *testing.T) {
 		}
 	}
 }
+
+func TestGetLoadBalancerAddress(t *testing.T) {
+	tests := []struct {
+		ingress  []corev1.LoadBalancerIngress
+		ip       string
+		hostname string
+	}{
+		{ingress: nil},
+		{ingress: []corev1.LoadBalancerIngress{{IP: "1.1.1.1"}}, ip: "1.1.1.1"},
+		{ingress: []corev1.LoadBalancerIngress{{Hostname: "a.elb.amazonaws.com"}}, hostname: "a.elb.amazonaws.com"},
+		{
+			ingress:  []corev1.LoadBalancerIngress{{Hostname: "a.example.com"}, {IP: "1.1.1.1", Hostname: "b.example.com"}, {IP: "2.2.2.2"}},
+			ip:       "1.1.1.1",
+			hostname: "a.example.com",
+		},
+	}
+	for i, test := range tests {
+		ip, hostname := GetLoadBalancerAddress(test.ingress)
+		if ip != test.ip || hostname != test.hostname {
+			t.Errorf("case %d: expect %s and %s, but actually got %s and %s", i, test.ip, test.hostname, ip, hostname)
+		}
+	}
+}
+
+func TestGetLoadBalancerAddresses(t *testing.T) {
+	ips, hostname := GetLoadBalancerAddresses([]corev1.LoadBalancerIngress{
+		{IP: "1.1.1.1"}, {IP: "2001:db8::1", Hostname: "a.example.com"}, {IP: "1.1.1.1"},
+	})
+	if !reflect.DeepEqual(ips, []string{"1.1.1.1", "2001:db8::1"}) || hostname != "a.example.com" {
+		t.Errorf("expect [1.1.1.1 2001:db8::1] and a.example.com, but actually got %v and %s", ips, hostname)
+	}
+}
+
+func TestGetPodIPs(t *testing.T) {
+	tests := []struct {
+		status corev1.PodStatus
+		ips    []string
+	}{
+		{status: corev1.PodStatus{}},
+		{status: corev1.PodStatus{PodIP: "10.0.0.1"}, ips: []string{"10.0.0.1"}},
+		{
+			status: corev1.PodStatus{PodIP: "10.0.0.1", PodIPs: []corev1.PodIP{{IP: "10.0.0.1"}, {IP: "fd00::1"}}},
+			ips:    []string{"10.0.0.1", "fd00::1"},
+		},
+	}
+	for i, test := range tests {
+		ips := GetPodIPs(&corev1.Pod{Status: test.status})
+		if !reflect.DeepEqual(ips, test.ips) {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.ips, ips)
+		}
+	}
+}
+
+func TestParseIPFamilyConf(t *testing.T) {
+	dualStack := corev1.IPFamilyPolicyRequireDualStack
+	tests := []struct {
+		conf      []gamekruiseiov1alpha1.NetworkConfParams
+		ipf       IPFamilyConf
+		expectErr bool
+	}{
+		{conf: nil},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: gamekruiseiov1alpha1.IPFamilyPolicyNetworkConfName, Value: "RequireDualStack"},
+				{Name: gamekruiseiov1alpha1.IPFamiliesNetworkConfName, Value: "IPv6, IPv4"},
+			},
+			ipf: IPFamilyConf{Policy: &dualStack, Families: []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol}},
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: gamekruiseiov1alpha1.IPFamilyPolicyNetworkConfName, Value: "DualStack"},
+			},
+			expectErr: true,
+		},
+		{
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: gamekruiseiov1alpha1.IPFamiliesNetworkConfName, Value: "IPv4,IPv4"},
+			},
+			expectErr: true,
+		},
+		{
+			// two IP families require a dual-stack policy
+			conf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: gamekruiseiov1alpha1.IPFamiliesNetworkConfName, Value: "IPv4,IPv6"},
+			},
+			expectErr: true,
+		},
+	}
+	for i, test := range tests {
+		ipf, err := ParseIPFamilyConf(test.conf)
+		if (err != nil) != test.expectErr {
+			t.Errorf("case %d: expect error %v, but actually got %v", i, test.expectErr, err)
+		}
+		if test.expectErr {
+			continue
+		}
+		if !reflect.DeepEqual(ipf, test.ipf) {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.ipf, ipf)
+		}
+		spec := &corev1.ServiceSpec{}
+		ipf.ApplyTo(spec)
+		if !reflect.DeepEqual(spec.IPFamilyPolicy, test.ipf.Policy) || !reflect.DeepEqual(spec.IPFamilies, test.ipf.Families) {
+			t.Errorf("case %d: expect service ip families %v, but actually got %v %v", i, test.ipf, spec.IPFamilyPolicy, spec.IPFamilies)
+		}
+	}
+}
+
+func TestHandleServiceCollision(t *testing.T) {
+	tests := []struct {
+		policy       string
+		svcLabels    map[string]string
+		ownerUID     types.UID
+		expectStop   bool
+		expectLabel  bool
+		expectExists bool
+	}{
+		// the Service created by the plugin
+		{
+			svcLabels:    map[string]string{gamekruiseiov1alpha1.GameServerNetworkType: "Kubernetes-NodePort"},
+			expectLabel:  true,
+			expectExists: true,
+		},
+		// the Service created by the plugin before it was labeled
+		{
+			ownerUID:     "uid-pod",
+			expectLabel:  true,
+			expectExists: true,
+		},
+		{
+			expectStop:   true,
+			expectExists: true,
+		},
+		{
+			policy:       string(gamekruiseiov1alpha1.AdoptServiceCollisionPolicy),
+			expectLabel:  true,
+			expectExists: true,
+		},
+		{
+			policy:     string(gamekruiseiov1alpha1.RecreateServiceCollisionPolicy),
+			expectStop: true,
+		},
+		// the Service created by another network plugin
+		{
+			svcLabels:    map[string]string{gamekruiseiov1alpha1.GameServerNetworkType: "Kubernetes-LoadBalancer"},
+			expectStop:   true,
+			expectExists: true,
+		},
+	}
+	for i, test := range tests {
+		networkConf := "[]"
+		if test.policy != "" {
+			networkConf = `[{"name":"ServiceCollisionPolicy","value":"` + test.policy + `"}]`
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      "case-0",
+				UID:       "uid-pod",
+				Annotations: map[string]string{
+					gamekruiseiov1alpha1.GameServerNetworkType: "Kubernetes-NodePort",
+					gamekruiseiov1alpha1.GameServerNetworkConf: networkConf,
+				},
+			},
+		}
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      "case-0",
+				UID:       "uid-svc",
+				Labels:    test.svcLabels,
+			},
+		}
+		if test.ownerUID != "" {
+			svc.OwnerReferences = []metav1.OwnerReference{{APIVersion: "v1", Kind: "Pod", Name: "case-0", UID: test.ownerUID}}
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(svc).Build()
+		nm := NewNetworkManager(pod, c)
+		networkStatus := &gamekruiseiov1alpha1.NetworkStatus{NetworkType: "Kubernetes-NodePort"}
+		stop, pod, err := HandleServiceCollision(c, context.Background(), nm, pod, svc, networkStatus)
+		if err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		if stop != test.expectStop {
+			t.Errorf("case %d: expect stop %v, but actually got %v", i, test.expectStop, stop)
+		}
+		if stop && !strings.Contains(pod.Annotations[gamekruiseiov1alpha1.GameServerNetworkStatus], string(gamekruiseiov1alpha1.NetworkServiceConflict)) {
+			t.Errorf("case %d: expect network status of reason %s, but actually got %s", i, gamekruiseiov1alpha1.NetworkServiceConflict,
+				pod.Annotations[gamekruiseiov1alpha1.GameServerNetworkStatus])
+		}
+		actual := &corev1.Service{}
+		err2 := c.Get(context.Background(), types.NamespacedName{Namespace: "xxx", Name: "case-0"}, actual)
+		if (err2 == nil) != test.expectExists {
+			t.Errorf("case %d: expect Service exists %v, but actually got %v", i, test.expectExists, err2)
+			continue
+		}
+		if test.expectExists && (actual.Labels[gamekruiseiov1alpha1.GameServerNetworkType] == "Kubernetes-NodePort") != test.expectLabel {
+			t.Errorf("case %d: expect Service labeled %v, but actually got labels %v", i, test.expectLabel, actual.Labels)
+		}
+	}
+
+	if _, err := GetServiceCollisionPolicy([]gamekruiseiov1alpha1.NetworkConfParams{
+		{Name: gamekruiseiov1alpha1.ServiceCollisionPolicyNetworkConfName, Value: "Overwrite"},
+	}); err == nil {
+		t.Errorf("expect error of invalid %s", gamekruiseiov1alpha1.ServiceCollisionPolicyNetworkConfName)
+	}
+}
@@ -118,7 +118,7 @@ func initLbCache(svcList []corev1.Service, minPort, maxPort int32) (map[string]p
 				}
 			}
 			var ports []int32
-			for _, port := range getPorts(svc.Spec.Ports) {
+			for _, port := range utils.GetExternalPorts(svc.Spec.Ports) {
 				if port <= maxPort && port >= minPort {
 					newCache[lbId][port] = true
 					ports = append(ports, port)
@@ -161,11 +161,20 @@ func (c *ClbPlugin) OnPodUpdated(client client.Client, pod *corev1.Pod, ctx cont
 	}, svc)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkServiceMissing, "service is being created")
+			if pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod); err != nil {
+				return pod, cperrors.NewPluginError(cperrors.InternalError, err.Error())
+			}
 			return pod, cperrors.ToPluginError(client.Create(ctx, c.consSvc(config, pod, client, ctx)), cperrors.ApiCallError)
 		}
 		return pod, cperrors.NewPluginError(cperrors.ApiCallError, err.Error())
 	}
 
+	// handle the Service of the pod's name not created by the plugin
+	if stop, pod, pluginErr := utils.HandleServiceCollision(client, ctx, networkManager, pod, svc, networkStatus); stop {
+		return pod, pluginErr
+	}
+
 	// update svc
 	if util.GetHash(config) != svc.GetAnnotations()[ClbConfigHashKey] {
 		networkStatus.CurrentNetworkState = gamekruiseiov1alpha1.NetworkNotReady
@@ -189,8 +198,9 @@ func (c *ClbPlugin) OnPodUpdated(client client.Client, pod *corev1.Pod, ctx cont
 	}
 
 	// network not ready
-	if len(svc.Status.LoadBalancer.Ingress) == 0 {
-		networkStatus.CurrentNetworkState = gamekruiseiov1alpha1.NetworkNotReady
+	lbIP, lbHostname := utils.GetLoadBalancerAddress(svc.Status.LoadBalancer.Ingress)
+	if lbIP == "" && lbHostname == "" {
+		utils.SetNetworkNotReady(networkStatus, gamekruiseiov1alpha1.NetworkWaitingForLB, "load balancer has not assigned the address")
 		pod, err = networkManager.UpdateNetworkStatus(*networkStatus, pod)
 		return pod, cperrors.ToPluginError(err, cperrors.InternalError)
 	}
@@ -227,7 +237,8 @@ func (c *ClbPlugin) OnPodUpdated(client client.Client, pod *corev1.Pod, ctx cont
 			},
 		}
 		externalAddress := gamekruiseiov1alpha1.NetworkAddress{
-			IP: svc.Status.LoadBalancer.Ingress[0].IP,
+			IP:       lbIP,
+			EndPoint: lbHostname,
 			Ports: []gamekruiseiov1alpha1.NetworkPort{
 				{
 					Name:     instrIPort.String(),
@@ -261,6 +272,14 @@ func (c *ClbPlugin) OnPodDeleted(client client.Client, pod *corev1.Pod, ctx cont
 		if err == nil && gss.GetDeletionTimestamp() == nil {
 			return nil
 		}
+		// identity of gss is retained, do not deAllocate.
+		retained, err := util.IsServiceRetained(pod, client, ctx)
+		if err != nil {
+			return cperrors.ToPluginError(err, cperrors.ApiCallError)
+		}
+		if retained {
+			return nil
+		}
 		// gss not exists in cluster, deAllocate all the ports related to it.
 		for key := range c.podAllocate {
 			gssName := pod.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]
@@ -414,14 +433,6 @@ func parseLbConfig(conf []gamekruiseiov1alpha1.NetworkConfParams) *clbConfig {
 	}
 }
 
-func getPorts(ports []corev1.ServicePort) []int32 {
-	var ret []int32
-	for _, port := range ports {
-		ret = append(ret, port.Port)
-	}
-	return ret
-}
-
 func (c *ClbPlugin) consSvc(config *clbConfig, pod *corev1.Pod, client client.Client, ctx context.Context) *corev1.Service {
 	var ports []int32
 	var lbId string
@@ -437,12 +448,7 @@ func (c *ClbPlugin) consSvc(config *clbConfig, pod *corev1.Pod, client client.Cl
 
 	svcPorts := make([]corev1.ServicePort, 0)
 	for i := 0; i < len(config.targetPorts); i++ {
-		svcPorts = append(svcPorts, corev1.ServicePort{
-			Name:       strconv.Itoa(config.targetPorts[i]),
-			Port:       ports[i],
-			Protocol:   config.protocols[i],
-			TargetPort: intstr.FromInt(config.targetPorts[i]),
-		})
+		svcPorts = append(svcPorts, utils.ConsServicePorts(config.targetPorts[i], ports[i], config.protocols[i])...)
 	}
 
 	annotations := map[string]string{
@@ -459,6 +465,7 @@ func (c *ClbPlugin) consSvc(config *clbConfig, pod *corev1.Pod, client client.Cl
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            pod.GetName(),
 			Namespace:       pod.GetNamespace(),
+			Labels:          utils.ServiceLabels(ClbNetwork, nil),
 			Annotations:     annotations,
 			OwnerReferences: getSvcOwnerReference(client, ctx, pod, config.isFixed),
 		},
@@ -286,6 +286,7 @@ func TestClbPlugin_consSvc(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "test-pod",
 					Namespace: "default",
+					Labels:    map[string]string{gamekruiseiov1alpha1.GameServerNetworkType: ClbNetwork},
 					Annotations: map[string]string{
 						ClbSchedulerKey:    ClbSchedulerWRR,
 						ClbAddressTypeKey:  ClbAddressTypePublic,
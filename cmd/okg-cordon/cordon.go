@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	kruisegameclientset "github.com/openkruise/kruise-game/pkg/client/clientset/versioned"
+)
+
+type Options struct {
+	Namespace    string
+	Name         string
+	Uncordon     bool
+	Wait         bool
+	PollInterval time.Duration
+	Timeout      time.Duration
+}
+
+// Cordoner cuts or restores the traffic of all GameServers of a GameServerSet by its networkDisabled.
+type Cordoner struct {
+	kruisegameClient kruisegameclientset.Interface
+	opts             *Options
+}
+
+func NewCordoner(kruisegameClient kruisegameclientset.Interface, opts *Options) *Cordoner {
+	return &Cordoner{
+		kruisegameClient: kruisegameClient,
+		opts:             opts,
+	}
+}
+
+// Run sets networkDisabled of the GameServerSet, and waits until the network of its GameServers is switched.
+func (c *Cordoner) Run() error {
+	disabled := !c.opts.Uncordon
+	patch := fmt.Sprintf(`{"spec":{"networkDisabled":%t}}`, disabled)
+	if _, err := c.kruisegameClient.GameV1alpha1().GameServerSets(c.opts.Namespace).Patch(context.TODO(), c.opts.Name, types.MergePatchType, []byte(patch), metav1.PatchOptions{}); err != nil {
+		return err
+	}
+	klog.Infof("set networkDisabled of GameServerSet %s in %s to %t", c.opts.Name, c.opts.Namespace, disabled)
+	if !c.opts.Wait {
+		return nil
+	}
+
+	return wait.PollImmediate(c.opts.PollInterval, c.opts.Timeout, func() (bool, error) {
+		gsList, err := c.kruisegameClient.GameV1alpha1().GameServers(c.opts.Namespace).List(context.TODO(), metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: c.opts.Name}).String(),
+		})
+		if err != nil {
+			return false, err
+		}
+		pending := countPendingGameServers(gsList.Items, disabled)
+		if pending > 0 {
+			klog.Infof("waiting for the network of %d/%d GameServers to be switched", pending, len(gsList.Items))
+			return false, nil
+		}
+		klog.Infof("the network of all %d GameServers is switched", len(gsList.Items))
+		return true, nil
+	})
+}
+
+// countPendingGameServers returns the number of GameServers whose network has not reached the state of the fleet.
+// GameServers without network are skipped, and so are the ones disabled by themselves when the fleet is uncordoned.
+func countPendingGameServers(gsList []gameKruiseV1alpha1.GameServer, disabled bool) int {
+	pending := 0
+	for _, gs := range gsList {
+		if gs.Status.NetworkStatus.NetworkType == "" {
+			continue
+		}
+		expect := gameKruiseV1alpha1.NetworkReady
+		if disabled || gs.Spec.NetworkDisabled {
+			expect = gameKruiseV1alpha1.NetworkNotReady
+		}
+		if gs.Status.NetworkStatus.CurrentNetworkState != expect {
+			pending++
+		}
+	}
+	return pending
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	kruisegamefake "github.com/openkruise/kruise-game/pkg/client/clientset/versioned/fake"
+)
+
+func newGameServer(name string, disabled bool, state gameKruiseV1alpha1.NetworkState) *gameKruiseV1alpha1.GameServer {
+	return &gameKruiseV1alpha1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+			Labels:    map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "minecraft"},
+		},
+		Spec: gameKruiseV1alpha1.GameServerSpec{NetworkDisabled: disabled},
+		Status: gameKruiseV1alpha1.GameServerStatus{
+			NetworkStatus: gameKruiseV1alpha1.NetworkStatus{
+				NetworkType:         "Kubernetes-HostPort",
+				CurrentNetworkState: state,
+			},
+		},
+	}
+}
+
+func TestCountPendingGameServers(t *testing.T) {
+	gsList := []gameKruiseV1alpha1.GameServer{
+		*newGameServer("minecraft-0", false, gameKruiseV1alpha1.NetworkReady),
+		*newGameServer("minecraft-1", true, gameKruiseV1alpha1.NetworkNotReady),
+		*newGameServer("minecraft-2", false, gameKruiseV1alpha1.NetworkNotReady),
+		{ObjectMeta: metav1.ObjectMeta{Name: "minecraft-3"}},
+	}
+	if pending := countPendingGameServers(gsList, true); pending != 1 {
+		t.Errorf("expect 1 GameServer pending to be cordoned, but actually got %d", pending)
+	}
+	if pending := countPendingGameServers(gsList, false); pending != 1 {
+		t.Errorf("expect 1 GameServer pending to be uncordoned, but actually got %d", pending)
+	}
+}
+
+func TestCordonerRun(t *testing.T) {
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "minecraft"},
+	}
+	client := kruisegamefake.NewSimpleClientset(gss, newGameServer("minecraft-0", false, gameKruiseV1alpha1.NetworkNotReady))
+	c := NewCordoner(client, &Options{
+		Namespace:    "default",
+		Name:         "minecraft",
+		Wait:         true,
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+	})
+	if err := c.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	newGss, err := client.GameV1alpha1().GameServerSets("default").Get(context.TODO(), "minecraft", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !newGss.Spec.NetworkDisabled {
+		t.Errorf("expect networkDisabled of GameServerSet to be true")
+	}
+
+	// the GameServer is still NotReady, so waiting for uncordon times out
+	c.opts.Uncordon = true
+	c.opts.Timeout = 50 * time.Millisecond
+	if err := c.Run(); err == nil {
+		t.Errorf("expect timeout of waiting for the network to be restored")
+	}
+}
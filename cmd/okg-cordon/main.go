@@ -0,0 +1,57 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	kruisegameclientset "github.com/openkruise/kruise-game/pkg/client/clientset/versioned"
+)
+
+func main() {
+	opts := &Options{}
+	var kubeconfig string
+	flag.StringVar(&kubeconfig, "kubeconfig", os.Getenv("KUBECONFIG"), "Path to the kubeconfig file. In-cluster config is used if empty.")
+	flag.StringVar(&opts.Namespace, "namespace", "", "The namespace of the GameServerSet to cordon.")
+	flag.StringVar(&opts.Name, "name", "", "The name of the GameServerSet to cordon.")
+	flag.BoolVar(&opts.Uncordon, "uncordon", false, "Restore the traffic of the GameServerSet instead of cutting it.")
+	flag.BoolVar(&opts.Wait, "wait", true, "Wait until the network of all GameServers reaches the desired state.")
+	flag.DurationVar(&opts.PollInterval, "poll-interval", time.Second, "The interval to poll the network state of GameServers.")
+	flag.DurationVar(&opts.Timeout, "timeout", 5*time.Minute, "The timeout of waiting for the network state of GameServers.")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if opts.Namespace == "" || opts.Name == "" {
+		klog.Fatalf("--namespace and --name are required")
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		klog.Fatalf("failed to build kubeconfig, because of %s", err.Error())
+	}
+
+	c := NewCordoner(kruisegameclientset.NewForConfigOrDie(config), opts)
+	if err := c.Run(); err != nil {
+		klog.Errorf("failed to cordon GameServerSet %s in %s, because of %s", opts.Name, opts.Namespace, err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+
+	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	kruisegameclientset "github.com/openkruise/kruise-game/pkg/client/clientset/versioned"
+)
+
+const (
+	// LoadgenLabelKey marks the GameServerSets created by okg-loadgen.
+	LoadgenLabelKey = "game.kruise.io/loadgen"
+
+	ScaleUpStep   = "ScaleUp"
+	AllocateStep  = "Allocate"
+	ScaleDownStep = "ScaleDown"
+)
+
+type Options struct {
+	Namespace    string
+	Fleets       int
+	Replicas     int
+	ScaleStep    int
+	Allocations  int
+	Rounds       int
+	Image        string
+	PollInterval time.Duration
+	Timeout      time.Duration
+	Cleanup      bool
+}
+
+// Loadgen creates synthetic GameServerSets and drives allocation and scale churn against them,
+// recording how long the controller takes to converge.
+type Loadgen struct {
+	kruisegameClient kruisegameclientset.Interface
+	kubeClient       kubernetes.Interface
+	opts             *Options
+	report           *Report
+}
+
+func NewLoadgen(kruisegameClient kruisegameclientset.Interface, kubeClient kubernetes.Interface, opts *Options) *Loadgen {
+	return &Loadgen{
+		kruisegameClient: kruisegameClient,
+		kubeClient:       kubeClient,
+		opts:             opts,
+		report:           NewReport(),
+	}
+}
+
+func (lg *Loadgen) Run() (*Report, error) {
+	if err := lg.setup(); err != nil {
+		return lg.report, err
+	}
+	if lg.opts.Cleanup {
+		defer lg.cleanup()
+	}
+
+	for i := 0; i < lg.opts.Fleets; i++ {
+		if err := lg.scale(lg.fleetName(i), 0, lg.opts.Replicas); err != nil {
+			return lg.report, err
+		}
+	}
+
+	for round := 0; round < lg.opts.Rounds; round++ {
+		klog.Infof("round %d starts", round)
+		for i := 0; i < lg.opts.Fleets; i++ {
+			name := lg.fleetName(i)
+			if err := lg.scale(name, lg.opts.Replicas, lg.opts.Replicas+lg.opts.ScaleStep); err != nil {
+				return lg.report, err
+			}
+			if err := lg.allocate(name, lg.opts.Allocations); err != nil {
+				return lg.report, err
+			}
+			if err := lg.scale(name, lg.opts.Replicas+lg.opts.ScaleStep, lg.opts.Replicas); err != nil {
+				return lg.report, err
+			}
+			if err := lg.release(name); err != nil {
+				return lg.report, err
+			}
+		}
+	}
+	return lg.report, nil
+}
+
+func (lg *Loadgen) fleetName(i int) string {
+	return "loadgen-" + strconv.Itoa(i)
+}
+
+func (lg *Loadgen) setup() error {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: lg.opts.Namespace,
+		},
+	}
+	_, err := lg.kubeClient.CoreV1().Namespaces().Create(context.TODO(), ns, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	for i := 0; i < lg.opts.Fleets; i++ {
+		_, err := lg.kruisegameClient.GameV1alpha1().GameServerSets(lg.opts.Namespace).Create(context.TODO(), lg.newGameServerSet(lg.fleetName(i)), metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (lg *Loadgen) cleanup() {
+	err := lg.kruisegameClient.GameV1alpha1().GameServerSets(lg.opts.Namespace).DeleteCollection(context.TODO(), metav1.DeleteOptions{}, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{LoadgenLabelKey: "true"}).String(),
+	})
+	if err != nil {
+		klog.Errorf("failed to clean up GameServerSets in %s, because of %s", lg.opts.Namespace, err.Error())
+	}
+}
+
+func (lg *Loadgen) newGameServerSet(name string) *gameKruiseV1alpha1.GameServerSet {
+	return &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: lg.opts.Namespace,
+			Labels:    map[string]string{LoadgenLabelKey: "true"},
+		},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			Replicas: ptr.To[int32](0),
+			UpdateStrategy: gameKruiseV1alpha1.UpdateStrategy{
+				Type: apps.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &gameKruiseV1alpha1.RollingUpdateStatefulSetStrategy{
+					PodUpdatePolicy: kruiseV1beta1.InPlaceIfPossiblePodUpdateStrategyType,
+				},
+			},
+			GameServerTemplate: gameKruiseV1alpha1.GameServerTemplate{
+				PodTemplateSpec: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name:  "game",
+								Image: lg.opts.Image,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// scale changes the replicas of GameServerSet and records the time each GameServer takes to be Ready,
+// or the time all the GameServers take to be deleted when scaling down.
+func (lg *Loadgen) scale(name string, from, to int) error {
+	// the GameServers already Ready before scaling are not recorded
+	ready := make(map[string]bool)
+	gsList, err := lg.listGameServers(name)
+	if err != nil {
+		return err
+	}
+	for _, gs := range gsList.Items {
+		if gs.Status.CurrentState == gameKruiseV1alpha1.Ready {
+			ready[gs.GetName()] = true
+		}
+	}
+
+	data := []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, to))
+	_, err = lg.kruisegameClient.GameV1alpha1().GameServerSets(lg.opts.Namespace).Patch(context.TODO(), name, types.MergePatchType, data, metav1.PatchOptions{})
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+
+	if to < from {
+		err = wait.PollImmediate(lg.opts.PollInterval, lg.opts.Timeout, func() (bool, error) {
+			podList, err := lg.listPods(name)
+			if err != nil {
+				return false, err
+			}
+			return len(podList.Items) == to, nil
+		})
+		if err == nil {
+			lg.report.Record(ScaleDownStep, time.Since(start))
+		}
+		return err
+	}
+
+	return wait.PollImmediate(lg.opts.PollInterval, lg.opts.Timeout, func() (bool, error) {
+		gsList, err := lg.listGameServers(name)
+		if err != nil {
+			return false, err
+		}
+		for _, gs := range gsList.Items {
+			if gs.Status.CurrentState == gameKruiseV1alpha1.Ready && !ready[gs.GetName()] {
+				ready[gs.GetName()] = true
+				lg.report.Record(ScaleUpStep, time.Since(start))
+			}
+		}
+		return len(ready) >= to, nil
+	})
+}
+
+// allocate sets the opsState of idle GameServers to Allocated, and records the time
+// the controller takes to sync the opsState to pods.
+func (lg *Loadgen) allocate(name string, num int) error {
+	gsList, err := lg.listGameServers(name)
+	if err != nil {
+		return err
+	}
+
+	data := []byte(fmt.Sprintf(`{"spec":{"opsState":"%s"}}`, gameKruiseV1alpha1.Allocated))
+	for _, gs := range gsList.Items {
+		if num <= 0 {
+			break
+		}
+		if gs.Spec.OpsState != gameKruiseV1alpha1.None && gs.Spec.OpsState != "" {
+			continue
+		}
+		_, err := lg.kruisegameClient.GameV1alpha1().GameServers(lg.opts.Namespace).Patch(context.TODO(), gs.GetName(), types.MergePatchType, data, metav1.PatchOptions{})
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		err = wait.PollImmediate(lg.opts.PollInterval, lg.opts.Timeout, func() (bool, error) {
+			pod, err := lg.kubeClient.CoreV1().Pods(lg.opts.Namespace).Get(context.TODO(), gs.GetName(), metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+			return pod.GetLabels()[gameKruiseV1alpha1.GameServerOpsStateKey] == string(gameKruiseV1alpha1.Allocated), nil
+		})
+		if err != nil {
+			return err
+		}
+		lg.report.Record(AllocateStep, time.Since(start))
+		num--
+	}
+	return nil
+}
+
+// release sets the opsState of all the GameServers of GameServerSet back to None.
+func (lg *Loadgen) release(name string) error {
+	gsList, err := lg.listGameServers(name)
+	if err != nil {
+		return err
+	}
+	data := []byte(fmt.Sprintf(`{"spec":{"opsState":"%s"}}`, gameKruiseV1alpha1.None))
+	for _, gs := range gsList.Items {
+		if gs.Spec.OpsState != gameKruiseV1alpha1.Allocated {
+			continue
+		}
+		_, err := lg.kruisegameClient.GameV1alpha1().GameServers(lg.opts.Namespace).Patch(context.TODO(), gs.GetName(), types.MergePatchType, data, metav1.PatchOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (lg *Loadgen) listGameServers(name string) (*gameKruiseV1alpha1.GameServerList, error) {
+	return lg.kruisegameClient.GameV1alpha1().GameServers(lg.opts.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: name}).String(),
+	})
+}
+
+func (lg *Loadgen) listPods(name string) (*corev1.PodList, error) {
+	return lg.kubeClient.CoreV1().Pods(lg.opts.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: name}).String(),
+	})
+}
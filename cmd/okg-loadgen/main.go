@@ -0,0 +1,63 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	kruisegameclientset "github.com/openkruise/kruise-game/pkg/client/clientset/versioned"
+)
+
+func main() {
+	opts := &Options{}
+	var kubeconfig string
+	flag.StringVar(&kubeconfig, "kubeconfig", os.Getenv("KUBECONFIG"), "Path to the kubeconfig file. In-cluster config is used if empty.")
+	flag.StringVar(&opts.Namespace, "namespace", "okg-loadgen", "The namespace where synthetic GameServerSets are created.")
+	flag.IntVar(&opts.Fleets, "fleets", 3, "The number of synthetic GameServerSets.")
+	flag.IntVar(&opts.Replicas, "replicas", 10, "The initial replicas of each GameServerSet.")
+	flag.IntVar(&opts.ScaleStep, "scale-step", 5, "The number of GameServers to scale up and down in each round.")
+	flag.IntVar(&opts.Allocations, "allocations", 5, "The number of GameServers to allocate in each fleet in each round.")
+	flag.IntVar(&opts.Rounds, "rounds", 3, "The number of churn rounds.")
+	flag.StringVar(&opts.Image, "image", "registry.k8s.io/pause:3.9", "The image of synthetic game servers.")
+	flag.DurationVar(&opts.PollInterval, "poll-interval", 500*time.Millisecond, "The interval to poll the state of GameServers.")
+	flag.DurationVar(&opts.Timeout, "timeout", 5*time.Minute, "The timeout of each step.")
+	flag.BoolVar(&opts.Cleanup, "cleanup", true, "Delete the synthetic GameServerSets after the test.")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		klog.Fatalf("failed to build kubeconfig, because of %s", err.Error())
+	}
+
+	lg := NewLoadgen(kruisegameclientset.NewForConfigOrDie(config), kubernetes.NewForConfigOrDie(config), opts)
+	report, err := lg.Run()
+	if err != nil {
+		klog.Errorf("load test failed, because of %s", err.Error())
+	}
+	fmt.Print(report.String())
+	if err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Report records the latencies of each step.
+type Report struct {
+	mutex     sync.Mutex
+	latencies map[string][]time.Duration
+}
+
+func NewReport() *Report {
+	return &Report{
+		latencies: make(map[string][]time.Duration),
+	}
+}
+
+func (r *Report) Record(step string, latency time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.latencies[step] = append(r.latencies[step], latency)
+}
+
+func (r *Report) String() string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var steps []string
+	for step := range r.latencies {
+		steps = append(steps, step)
+	}
+	sort.Strings(steps)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-12s %8s %12s %12s %12s %12s\n", "STEP", "COUNT", "P50", "P90", "P99", "MAX")
+	for _, step := range steps {
+		latencies := r.latencies[step]
+		fmt.Fprintf(&b, "%-12s %8d %12v %12v %12v %12v\n", step, len(latencies),
+			Percentile(latencies, 50), Percentile(latencies, 90), Percentile(latencies, 99), Percentile(latencies, 100))
+	}
+	return b.String()
+}
+
+// Percentile returns the p-th percentile of latencies using the nearest-rank method.
+func Percentile(latencies []time.Duration, p float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1].Round(time.Millisecond)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	latencies := []time.Duration{
+		5 * time.Second, 1 * time.Second, 3 * time.Second, 2 * time.Second, 4 * time.Second,
+		10 * time.Second, 9 * time.Second, 8 * time.Second, 7 * time.Second, 6 * time.Second,
+	}
+	tests := []struct {
+		latencies []time.Duration
+		p         float64
+		result    time.Duration
+	}{
+		{
+			latencies: nil,
+			p:         50,
+			result:    0,
+		},
+		{
+			latencies: latencies,
+			p:         50,
+			result:    5 * time.Second,
+		},
+		{
+			latencies: latencies,
+			p:         90,
+			result:    9 * time.Second,
+		},
+		{
+			latencies: latencies,
+			p:         99,
+			result:    10 * time.Second,
+		},
+		{
+			latencies: latencies,
+			p:         0,
+			result:    1 * time.Second,
+		},
+	}
+
+	for i, test := range tests {
+		actual := Percentile(test.latencies, test.p)
+		if actual != test.result {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.result, actual)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	kruiseclientset "github.com/openkruise/kruise-api/client/clientset/versioned"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	kruisegameclientset "github.com/openkruise/kruise-game/pkg/client/clientset/versioned"
+)
+
+func main() {
+	opts := &Options{}
+	var kubeconfig string
+	flag.StringVar(&kubeconfig, "kubeconfig", os.Getenv("KUBECONFIG"), "Path to the kubeconfig file. In-cluster config is used if empty.")
+	flag.StringVar(&opts.Namespace, "namespace", "", "The namespace of the GameServerSet to migrate.")
+	flag.StringVar(&opts.Name, "name", "", "The name of the GameServerSet to migrate.")
+	flag.StringVar(&opts.TargetNamespace, "target-namespace", "", "The namespace which the GameServerSet is migrated to.")
+	flag.StringVar(&opts.TargetName, "target-name", "", "The name of the GameServerSet which the GameServer is transferred to, in the same namespace.")
+	flag.IntVar(&opts.ID, "id", -1, "The ID of the GameServer to transfer. Required with --target-name.")
+	flag.BoolVar(&opts.DryRun, "dry-run", false, "Only print the objects to migrate without changing anything.")
+	flag.DurationVar(&opts.PollInterval, "poll-interval", time.Second, "The interval to poll the deletion of pods and PersistentVolumeClaims.")
+	flag.DurationVar(&opts.Timeout, "timeout", 10*time.Minute, "The timeout of waiting for the deletion of pods and PersistentVolumeClaims.")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if opts.Namespace == "" || opts.Name == "" {
+		klog.Fatalf("--namespace and --name are required")
+	}
+	if opts.TargetName != "" {
+		if opts.TargetNamespace != "" {
+			klog.Fatalf("--target-name and --target-namespace are mutually exclusive")
+		}
+		if opts.ID < 0 {
+			klog.Fatalf("--id is required with --target-name")
+		}
+	} else {
+		if opts.TargetNamespace == "" {
+			klog.Fatalf("either --target-namespace or --target-name is required")
+		}
+		if opts.Namespace == opts.TargetNamespace {
+			klog.Fatalf("--target-namespace must be different from --namespace")
+		}
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		klog.Fatalf("failed to build kubeconfig, because of %s", err.Error())
+	}
+
+	if opts.TargetName != "" {
+		t := NewTransferer(kruisegameclientset.NewForConfigOrDie(config), kruiseclientset.NewForConfigOrDie(config), kubernetes.NewForConfigOrDie(config), opts)
+		if err := t.Run(); err != nil {
+			klog.Errorf("failed to transfer GameServer %s-%d to GameServerSet %s in %s, because of %s", opts.Name, opts.ID, opts.TargetName, opts.Namespace, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	m := NewMigrator(kruisegameclientset.NewForConfigOrDie(config), kruiseclientset.NewForConfigOrDie(config), kubernetes.NewForConfigOrDie(config), opts)
+	if err := m.Run(); err != nil {
+		klog.Errorf("failed to migrate GameServerSet %s from %s to %s, because of %s", opts.Name, opts.Namespace, opts.TargetNamespace, err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,471 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	kruiseclientset "github.com/openkruise/kruise-api/client/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	kruisegameclientset "github.com/openkruise/kruise-game/pkg/client/clientset/versioned"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+const (
+	// migrationRecordSuffix names the ConfigMap in the target namespace which records the plan of the migration,
+	// so that an interrupted migration can be resumed after the source GameServerSet is deleted.
+	migrationRecordSuffix = "-migration"
+	migrationPlanKey      = "plan"
+	// migratedFromKey is annotated on the migration record, and its value is <namespace>/<name> of the source GameServerSet.
+	migratedFromKey = "game.kruise.io/migrated-from"
+)
+
+type Options struct {
+	Namespace       string
+	Name            string
+	TargetNamespace string
+	// TargetName and ID are set to transfer the GameServer with ID to another GameServerSet in the same namespace.
+	TargetName   string
+	ID           int
+	DryRun       bool
+	PollInterval time.Duration
+	Timeout      time.Duration
+}
+
+// Migrator moves a GameServerSet to another namespace, keeping the IDs, the fixed network Services
+// and the data of PersistentVolumeClaims of its GameServers.
+type Migrator struct {
+	kruisegameClient kruisegameclientset.Interface
+	kruiseClient     kruiseclientset.Interface
+	kubeClient       kubernetes.Interface
+	opts             *Options
+}
+
+// Plan is the set of objects which identify the GameServers of the GameServerSet to migrate.
+type Plan struct {
+	GameServerSet   *gameKruiseV1alpha1.GameServerSet
+	ReserveOrdinals []int
+	Services        []corev1.Service
+	Pvcs            []corev1.PersistentVolumeClaim
+}
+
+func NewMigrator(kruisegameClient kruisegameclientset.Interface, kruiseClient kruiseclientset.Interface, kubeClient kubernetes.Interface, opts *Options) *Migrator {
+	return &Migrator{
+		kruisegameClient: kruisegameClient,
+		kruiseClient:     kruiseClient,
+		kubeClient:       kubeClient,
+		opts:             opts,
+	}
+}
+
+// Run migrates the GameServerSet. Everything which can be done ahead is done before the GameServerSet is deleted,
+// so that the GameServers are only unavailable while their pods are recreated in the target namespace.
+// Every step skips what is done already, so that Run can be retried until it succeeds.
+func (m *Migrator) Run() error {
+	plan, err := m.Plan()
+	if err != nil {
+		return err
+	}
+	for _, svc := range plan.Services {
+		klog.Infof("Service %s will be moved to %s", svc.GetName(), m.opts.TargetNamespace)
+	}
+	for _, pvc := range plan.Pvcs {
+		klog.Infof("PersistentVolumeClaim %s bound to PersistentVolume %s will be moved to %s", pvc.GetName(), pvc.Spec.VolumeName, m.opts.TargetNamespace)
+	}
+	if m.opts.DryRun {
+		return nil
+	}
+
+	steps := []struct {
+		name string
+		fn   func(*Plan) error
+	}{
+		{"prepare target namespace", m.prepareNamespace},
+		{"retain PersistentVolumes", m.retainVolumes},
+		{"orphan Services", m.orphanServices},
+		{"delete source GameServerSet", m.deleteGameServerSet},
+		{"move Services", m.moveServices},
+		{"move PersistentVolumeClaims", m.movePvcs},
+		{"create target GameServerSet", m.createGameServerSet},
+		{"delete migration record", m.deleteMigrationRecord},
+	}
+	for _, step := range steps {
+		klog.Infof("step %q starts", step.name)
+		if err := step.fn(plan); err != nil {
+			return fmt.Errorf("step %q failed: %s", step.name, err.Error())
+		}
+	}
+	klog.Infof("GameServerSet %s has been migrated from %s to %s", m.opts.Name, m.opts.Namespace, m.opts.TargetNamespace)
+	return nil
+}
+
+// Plan collects the objects to migrate without changing anything.
+// If a migration is interrupted, the plan recorded in the target namespace is returned.
+func (m *Migrator) Plan() (*Plan, error) {
+	if plan, err := m.getRecordedPlan(); err != nil || plan != nil {
+		return plan, err
+	}
+
+	gss, err := m.kruisegameClient.GameV1alpha1().GameServerSets(m.opts.Namespace).Get(context.TODO(), m.opts.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	_, err = m.kruisegameClient.GameV1alpha1().GameServerSets(m.opts.TargetNamespace).Get(context.TODO(), m.opts.Name, metav1.GetOptions{})
+	if err == nil {
+		return nil, fmt.Errorf("GameServerSet %s already exists in %s", m.opts.Name, m.opts.TargetNamespace)
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	plan := &Plan{GameServerSet: gss}
+
+	asts, err := m.kruiseClient.AppsV1beta1().StatefulSets(m.opts.Namespace).Get(context.TODO(), m.opts.Name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil {
+		plan.ReserveOrdinals = asts.Spec.ReserveOrdinals
+	}
+
+	// the Services of Fixed network are owned by GameServerSet, others are recreated along with pods
+	svcList, err := m.kubeClient.CoreV1().Services(m.opts.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, svc := range svcList.Items {
+		for _, or := range svc.GetOwnerReferences() {
+			if or.UID == gss.GetUID() {
+				plan.Services = append(plan.Services, svc)
+				break
+			}
+		}
+	}
+
+	pvcList, err := m.kubeClient.CoreV1().PersistentVolumeClaims(m.opts.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, pvc := range pvcList.Items {
+		if pvc.Spec.VolumeName == "" {
+			continue
+		}
+		for _, template := range gss.Spec.GameServerTemplate.VolumeClaimTemplates {
+			if isPvcOfTemplate(pvc.GetName(), template.GetName(), gss.GetName()) {
+				plan.Pvcs = append(plan.Pvcs, pvc)
+				break
+			}
+		}
+	}
+	return plan, nil
+}
+
+// getRecordedPlan returns the plan recorded by an interrupted migration, or nil if there is none.
+func (m *Migrator) getRecordedPlan() (*Plan, error) {
+	cm, err := m.kubeClient.CoreV1().ConfigMaps(m.opts.TargetNamespace).Get(context.TODO(), m.opts.Name+migrationRecordSuffix, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if cm.GetAnnotations()[migratedFromKey] != m.opts.Namespace+"/"+m.opts.Name {
+		return nil, fmt.Errorf("ConfigMap %s already exists in %s and is not the migration record of GameServerSet %s", cm.GetName(), m.opts.TargetNamespace, m.opts.Name)
+	}
+	plan := &Plan{}
+	if err := json.Unmarshal([]byte(cm.Data[migrationPlanKey]), plan); err != nil {
+		return nil, fmt.Errorf("invalid migration record %s: %s", cm.GetName(), err.Error())
+	}
+	klog.Infof("resume the migration recorded in ConfigMap %s in %s", cm.GetName(), m.opts.TargetNamespace)
+	return plan, nil
+}
+
+// newMigrationRecord returns the ConfigMap which records the plan, keeping only the fields used by the migration.
+func newMigrationRecord(namespace, name string, plan *Plan) (*corev1.ConfigMap, error) {
+	recorded := &Plan{
+		GameServerSet: &gameKruiseV1alpha1.GameServerSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   plan.GameServerSet.GetNamespace(),
+				Name:        plan.GameServerSet.GetName(),
+				UID:         plan.GameServerSet.GetUID(),
+				Labels:      plan.GameServerSet.GetLabels(),
+				Annotations: plan.GameServerSet.GetAnnotations(),
+			},
+			Spec: plan.GameServerSet.Spec,
+		},
+		ReserveOrdinals: plan.ReserveOrdinals,
+	}
+	for _, svc := range plan.Services {
+		recorded.Services = append(recorded.Services, corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: svc.GetName(), Labels: svc.GetLabels(), Annotations: svc.GetAnnotations()},
+			Spec:       svc.Spec,
+		})
+	}
+	for _, pvc := range plan.Pvcs {
+		recorded.Pvcs = append(recorded.Pvcs, corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: pvc.GetName(), Labels: pvc.GetLabels(), Annotations: pvc.GetAnnotations()},
+			Spec:       pvc.Spec,
+		})
+	}
+	data, err := json.Marshal(recorded)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        name + migrationRecordSuffix,
+			Annotations: map[string]string{migratedFromKey: plan.GameServerSet.GetNamespace() + "/" + name},
+		},
+		Data: map[string]string{migrationPlanKey: string(data)},
+	}, nil
+}
+
+// isPvcOfTemplate returns whether the PersistentVolumeClaim is created from the template for a GameServer,
+// whose name is in the form of <template>-<gss>-<id>.
+func isPvcOfTemplate(pvcName, templateName, gssName string) bool {
+	prefix := templateName + "-" + gssName + "-"
+	if !strings.HasPrefix(pvcName, prefix) {
+		return false
+	}
+	_, err := strconv.Atoi(strings.TrimPrefix(pvcName, prefix))
+	return err == nil
+}
+
+func (m *Migrator) prepareNamespace(plan *Plan) error {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: m.opts.TargetNamespace,
+		},
+	}
+	_, err := m.kubeClient.CoreV1().Namespaces().Create(context.TODO(), ns, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	// record the plan before anything is changed, so that the migration can be resumed
+	record, err := newMigrationRecord(m.opts.TargetNamespace, m.opts.Name, plan)
+	if err != nil {
+		return err
+	}
+	_, err = m.kubeClient.CoreV1().ConfigMaps(m.opts.TargetNamespace).Create(context.TODO(), record, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	// record the identity so that the GameServerSet controller reattaches it to the target GameServerSet
+	identity := util.NewIdentityRecord(m.opts.TargetNamespace, m.opts.Name, util.GetReserveIds(plan.GameServerSet), plan.ReserveOrdinals)
+	cm, err := m.kubeClient.CoreV1().ConfigMaps(m.opts.TargetNamespace).Get(context.TODO(), identity.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = m.kubeClient.CoreV1().ConfigMaps(m.opts.TargetNamespace).Create(context.TODO(), identity, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if cm.GetAnnotations()[gameKruiseV1alpha1.IdentityRetainedFromKey] != m.opts.Name {
+		return fmt.Errorf("ConfigMap %s already exists in %s and is not the identity record of GameServerSet %s", cm.GetName(), m.opts.TargetNamespace, m.opts.Name)
+	}
+	cm.Data = identity.Data
+	_, err = m.kubeClient.CoreV1().ConfigMaps(m.opts.TargetNamespace).Update(context.TODO(), cm, metav1.UpdateOptions{})
+	return err
+}
+
+// retainVolumes keeps the PersistentVolumes from being reclaimed when their claims are deleted.
+func (m *Migrator) retainVolumes(plan *Plan) error {
+	data := []byte(fmt.Sprintf(`{"spec":{"persistentVolumeReclaimPolicy":"%s"}}`, corev1.PersistentVolumeReclaimRetain))
+	for _, pvc := range plan.Pvcs {
+		_, err := m.kubeClient.CoreV1().PersistentVolumes().Patch(context.TODO(), pvc.Spec.VolumeName, types.MergePatchType, data, metav1.PatchOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orphanServices removes the owner references of Services, which are marked as retained so that
+// cloud providers do not deallocate their ports when the pods are deleted.
+func (m *Migrator) orphanServices(plan *Plan) error {
+	for _, planned := range plan.Services {
+		svc, err := m.kubeClient.CoreV1().Services(m.opts.Namespace).Get(context.TODO(), planned.GetName(), metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				// the Service is moved already
+				continue
+			}
+			return err
+		}
+		var ownerReferences []metav1.OwnerReference
+		for _, or := range svc.GetOwnerReferences() {
+			if or.UID != plan.GameServerSet.GetUID() {
+				ownerReferences = append(ownerReferences, or)
+			}
+		}
+		if len(ownerReferences) == len(svc.GetOwnerReferences()) && svc.GetAnnotations()[gameKruiseV1alpha1.IdentityRetainedFromKey] == plan.GameServerSet.GetName() {
+			continue
+		}
+		svc.SetOwnerReferences(ownerReferences)
+		if svc.Annotations == nil {
+			svc.Annotations = make(map[string]string)
+		}
+		svc.Annotations[gameKruiseV1alpha1.IdentityRetainedFromKey] = plan.GameServerSet.GetName()
+		if _, err := m.kubeClient.CoreV1().Services(m.opts.Namespace).Update(context.TODO(), svc, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) deleteGameServerSet(plan *Plan) error {
+	// never delete the GameServerSet created again with the same name
+	err := m.kruisegameClient.GameV1alpha1().GameServerSets(m.opts.Namespace).Delete(context.TODO(), m.opts.Name, metav1.DeleteOptions{
+		Preconditions: metav1.NewUIDPreconditions(string(plan.GameServerSet.GetUID())),
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return wait.PollImmediate(m.opts.PollInterval, m.opts.Timeout, func() (bool, error) {
+		podList, err := m.kubeClient.CoreV1().Pods(m.opts.Namespace).List(context.TODO(), metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: m.opts.Name}).String(),
+		})
+		if err != nil {
+			return false, err
+		}
+		return len(podList.Items) == 0, nil
+	})
+}
+
+// moveServices recreates the Services in the target namespace, keeping their specs and annotations
+// so that the GameServers are accessed by the same endpoints. The SLB plugin keeps the ports of the
+// moved Services allocated under the source namespace until kruise-game-manager restarts, so they are
+// not allocated to other GameServers in the meantime.
+func (m *Migrator) moveServices(plan *Plan) error {
+	for _, svc := range plan.Services {
+		err := m.kubeClient.CoreV1().Services(m.opts.Namespace).Delete(context.TODO(), svc.GetName(), metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		annotations := make(map[string]string)
+		for k, v := range svc.GetAnnotations() {
+			annotations[k] = v
+		}
+		annotations[gameKruiseV1alpha1.IdentityRetainedFromKey] = plan.GameServerSet.GetName()
+		newSvc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   m.opts.TargetNamespace,
+				Name:        svc.GetName(),
+				Labels:      svc.GetLabels(),
+				Annotations: annotations,
+			},
+			Spec: *svc.Spec.DeepCopy(),
+		}
+		newSvc.Spec.ClusterIP = ""
+		newSvc.Spec.ClusterIPs = nil
+		_, err = m.kubeClient.CoreV1().Services(m.opts.TargetNamespace).Create(context.TODO(), newSvc, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// movePvcs deletes the PersistentVolumeClaims, and then binds their PersistentVolumes to the claims
+// with the same names in the target namespace.
+func (m *Migrator) movePvcs(plan *Plan) error {
+	for _, pvc := range plan.Pvcs {
+		err := m.kubeClient.CoreV1().PersistentVolumeClaims(m.opts.Namespace).Delete(context.TODO(), pvc.GetName(), metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		err = wait.PollImmediate(m.opts.PollInterval, m.opts.Timeout, func() (bool, error) {
+			_, err := m.kubeClient.CoreV1().PersistentVolumeClaims(m.opts.Namespace).Get(context.TODO(), pvc.GetName(), metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		})
+		if err != nil {
+			return err
+		}
+
+		data := []byte(fmt.Sprintf(`{"spec":{"claimRef":{"namespace":"%s","name":"%s","uid":null,"resourceVersion":null}}}`, m.opts.TargetNamespace, pvc.GetName()))
+		_, err = m.kubeClient.CoreV1().PersistentVolumes().Patch(context.TODO(), pvc.Spec.VolumeName, types.MergePatchType, data, metav1.PatchOptions{})
+		if err != nil {
+			return err
+		}
+
+		// the annotations of binding are set again by the PersistentVolume controller
+		annotations := make(map[string]string)
+		for k, v := range pvc.GetAnnotations() {
+			if !strings.HasPrefix(k, "pv.kubernetes.io/") {
+				annotations[k] = v
+			}
+		}
+		newPvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   m.opts.TargetNamespace,
+				Name:        pvc.GetName(),
+				Labels:      pvc.GetLabels(),
+				Annotations: annotations,
+			},
+			Spec: *pvc.Spec.DeepCopy(),
+		}
+		_, err = m.kubeClient.CoreV1().PersistentVolumeClaims(m.opts.TargetNamespace).Create(context.TODO(), newPvc, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) createGameServerSet(plan *Plan) error {
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   m.opts.TargetNamespace,
+			Name:        plan.GameServerSet.GetName(),
+			Labels:      plan.GameServerSet.GetLabels(),
+			Annotations: plan.GameServerSet.GetAnnotations(),
+		},
+		Spec: *plan.GameServerSet.Spec.DeepCopy(),
+	}
+	_, err := m.kruisegameClient.GameV1alpha1().GameServerSets(m.opts.TargetNamespace).Create(context.TODO(), gss, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func (m *Migrator) deleteMigrationRecord(plan *Plan) error {
+	err := m.kubeClient.CoreV1().ConfigMaps(m.opts.TargetNamespace).Delete(context.TODO(), m.opts.Name+migrationRecordSuffix, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
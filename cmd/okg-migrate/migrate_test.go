@@ -0,0 +1,251 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	kruisefake "github.com/openkruise/kruise-api/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	kruisegamefake "github.com/openkruise/kruise-game/pkg/client/clientset/versioned/fake"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+func TestIsPvcOfTemplate(t *testing.T) {
+	tests := []struct {
+		pvcName  string
+		expected bool
+	}{
+		{pvcName: "data-case-0", expected: true},
+		{pvcName: "data-case-12", expected: true},
+		{pvcName: "data-case-x-0", expected: false},
+		{pvcName: "log-case-0", expected: false},
+		{pvcName: "data-case-", expected: false},
+	}
+	for _, test := range tests {
+		if actual := isPvcOfTemplate(test.pvcName, "data", "case"); actual != test.expected {
+			t.Errorf("%s: expect %v, but actually got %v", test.pvcName, test.expected, actual)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "old", Name: "case", UID: "uid-gss"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			Replicas:             ptr.To[int32](2),
+			ReserveGameServerIds: []int{1},
+			GameServerTemplate: gameKruiseV1alpha1.GameServerTemplate{
+				VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "data"}}},
+			},
+		},
+	}
+	asts := &kruiseV1beta1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "old", Name: "case"},
+		Spec:       kruiseV1beta1.StatefulSetSpec{ReserveOrdinals: []int{1}},
+	}
+	fixedSvc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "old",
+			Name:            "case-0",
+			Annotations:     map[string]string{"service.beta.kubernetes.io/alibaba-cloud-loadbalancer-id": "lb-xxx"},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "GameServerSet", Name: "case", UID: "uid-gss"}},
+		},
+		Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, ClusterIP: "10.0.0.1"},
+	}
+	podSvc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "old",
+			Name:            "case-2",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Pod", Name: "case-2", UID: "uid-pod"}},
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "old",
+			Name:        "data-case-0",
+			Annotations: map[string]string{"pv.kubernetes.io/bind-completed": "yes"},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{VolumeName: "pv-0"},
+	}
+	otherPvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "old", Name: "data-other-0"},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+	}
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-0"},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+			ClaimRef:                      &corev1.ObjectReference{Namespace: "old", Name: "data-case-0", UID: "uid-pvc"},
+		},
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(fixedSvc, podSvc, pvc, otherPvc, pv)
+	kruisegameClient := kruisegamefake.NewSimpleClientset(gss)
+	m := NewMigrator(kruisegameClient, kruisefake.NewSimpleClientset(asts), kubeClient, &Options{
+		Namespace:       "old",
+		Name:            "case",
+		TargetNamespace: "new",
+		PollInterval:    time.Millisecond,
+		Timeout:         time.Second,
+	})
+	if err := m.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := kruisegameClient.GameV1alpha1().GameServerSets("old").Get(context.TODO(), "case", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expect source GameServerSet to be deleted, but actually got %v", err)
+	}
+	newGss, err := kruisegameClient.GameV1alpha1().GameServerSets("new").Get(context.TODO(), "case", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(newGss.Spec, gss.Spec) {
+		t.Errorf("expect spec %v, but actually got %v", gss.Spec, newGss.Spec)
+	}
+
+	cm, err := kubeClient.CoreV1().ConfigMaps("new").Get(context.TODO(), util.GetIdentityRecordName("case"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reserveIds, reserveOrdinals := util.ParseIdentityRecord(cm); !reflect.DeepEqual(reserveIds, []int{1}) || !reflect.DeepEqual(reserveOrdinals, []int{1}) {
+		t.Errorf("unexpected identity record %v", cm.Data)
+	}
+
+	if _, err := kubeClient.CoreV1().Services("old").Get(context.TODO(), "case-0", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expect Service case-0 to be deleted in old, but actually got %v", err)
+	}
+	svc, err := kubeClient.CoreV1().Services("new").Get(context.TODO(), "case-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc.Spec.ClusterIP != "" || len(svc.GetOwnerReferences()) != 0 || svc.GetAnnotations()[gameKruiseV1alpha1.IdentityRetainedFromKey] != "case" ||
+		svc.GetAnnotations()["service.beta.kubernetes.io/alibaba-cloud-loadbalancer-id"] != "lb-xxx" {
+		t.Errorf("unexpected Service case-0 in new %v", svc)
+	}
+	if _, err := kubeClient.CoreV1().Services("old").Get(context.TODO(), "case-2", metav1.GetOptions{}); err != nil {
+		t.Errorf("expect Service case-2 not to be moved, but actually got %v", err)
+	}
+
+	newPvc, err := kubeClient.CoreV1().PersistentVolumeClaims("new").Get(context.TODO(), "data-case-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newPvc.Spec.VolumeName != "pv-0" || len(newPvc.GetAnnotations()) != 0 {
+		t.Errorf("unexpected PersistentVolumeClaim data-case-0 in new %v", newPvc)
+	}
+	if _, err := kubeClient.CoreV1().PersistentVolumeClaims("old").Get(context.TODO(), "data-other-0", metav1.GetOptions{}); err != nil {
+		t.Errorf("expect PersistentVolumeClaim data-other-0 not to be moved, but actually got %v", err)
+	}
+	newPv, err := kubeClient.CoreV1().PersistentVolumes().Get(context.TODO(), "pv-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newPv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimRetain ||
+		newPv.Spec.ClaimRef.Namespace != "new" || newPv.Spec.ClaimRef.Name != "data-case-0" || newPv.Spec.ClaimRef.UID != "" {
+		t.Errorf("unexpected PersistentVolume pv-0 %v", newPv.Spec)
+	}
+}
+
+func TestRunResume(t *testing.T) {
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "old", Name: "case", UID: "uid-gss"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			Replicas: ptr.To[int32](1),
+			GameServerTemplate: gameKruiseV1alpha1.GameServerTemplate{
+				VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "data"}}},
+			},
+		},
+	}
+	fixedSvc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "old",
+			Name:            "case-0",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "GameServerSet", Name: "case", UID: "uid-gss"}},
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "old", Name: "data-case-0"},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-0"},
+	}
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-0"},
+		Spec:       corev1.PersistentVolumeSpec{ClaimRef: &corev1.ObjectReference{Namespace: "old", Name: "data-case-0"}},
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(fixedSvc, pvc, pv)
+	// the migration is interrupted after the source GameServerSet is deleted
+	interrupted := true
+	kubeClient.PrependReactor("create", "persistentvolumeclaims", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if interrupted {
+			return true, nil, errors.New("interrupted")
+		}
+		return false, nil, nil
+	})
+	kruisegameClient := kruisegamefake.NewSimpleClientset(gss)
+	m := NewMigrator(kruisegameClient, kruisefake.NewSimpleClientset(), kubeClient, &Options{
+		Namespace:       "old",
+		Name:            "case",
+		TargetNamespace: "new",
+		PollInterval:    time.Millisecond,
+		Timeout:         time.Second,
+	})
+	if err := m.Run(); err == nil {
+		t.Fatal("expect the migration to be interrupted")
+	}
+	if _, err := kruisegameClient.GameV1alpha1().GameServerSets("old").Get(context.TODO(), "case", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("expect source GameServerSet to be deleted, but actually got %v", err)
+	}
+
+	interrupted = false
+	if err := m.Run(); err != nil {
+		t.Fatal(err)
+	}
+	newGss, err := kruisegameClient.GameV1alpha1().GameServerSets("new").Get(context.TODO(), "case", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(newGss.Spec, gss.Spec) {
+		t.Errorf("expect spec %v, but actually got %v", gss.Spec, newGss.Spec)
+	}
+	svc, err := kubeClient.CoreV1().Services("new").Get(context.TODO(), "case-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc.GetAnnotations()[gameKruiseV1alpha1.IdentityRetainedFromKey] != "case" {
+		t.Errorf("expect Service case-0 to be retained from case, but actually got %v", svc.GetAnnotations())
+	}
+	if _, err := kubeClient.CoreV1().PersistentVolumeClaims("new").Get(context.TODO(), "data-case-0", metav1.GetOptions{}); err != nil {
+		t.Errorf("expect PersistentVolumeClaim data-case-0 in new, but actually got %v", err)
+	}
+	if _, err := kubeClient.CoreV1().ConfigMaps("new").Get(context.TODO(), "case"+migrationRecordSuffix, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expect migration record to be deleted, but actually got %v", err)
+	}
+}
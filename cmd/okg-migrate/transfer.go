@@ -0,0 +1,465 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	kruiseclientset "github.com/openkruise/kruise-api/client/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	kruisegameclientset "github.com/openkruise/kruise-game/pkg/client/clientset/versioned"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+// Transferer moves a GameServer from a GameServerSet to another one in the same namespace, keeping its ID,
+// the fixed network Service and the data of its PersistentVolumeClaims.
+type Transferer struct {
+	kruisegameClient kruisegameclientset.Interface
+	kruiseClient     kruiseclientset.Interface
+	kubeClient       kubernetes.Interface
+	opts             *Options
+}
+
+// TransferPlan is the set of objects which identify the GameServer to transfer.
+type TransferPlan struct {
+	Source  *gameKruiseV1alpha1.GameServerSet
+	Target  *gameKruiseV1alpha1.GameServerSet
+	Service *corev1.Service
+	Pvcs    []corev1.PersistentVolumeClaim
+}
+
+func NewTransferer(kruisegameClient kruisegameclientset.Interface, kruiseClient kruiseclientset.Interface, kubeClient kubernetes.Interface, opts *Options) *Transferer {
+	return &Transferer{
+		kruisegameClient: kruisegameClient,
+		kruiseClient:     kruiseClient,
+		kubeClient:       kubeClient,
+		opts:             opts,
+	}
+}
+
+func (t *Transferer) sourceName() string {
+	return t.opts.Name + "-" + strconv.Itoa(t.opts.ID)
+}
+
+func (t *Transferer) targetName() string {
+	return t.opts.TargetName + "-" + strconv.Itoa(t.opts.ID)
+}
+
+// Run transfers the GameServer. The GameServer is only unavailable from the deletion of its pod in the source
+// GameServerSet to the pod running again in the target GameServerSet.
+func (t *Transferer) Run() error {
+	plan, err := t.Plan()
+	if err != nil {
+		return err
+	}
+	if plan.Service != nil {
+		klog.Infof("Service %s will be moved to %s", plan.Service.GetName(), t.targetName())
+	}
+	for _, pvc := range plan.Pvcs {
+		klog.Infof("PersistentVolumeClaim %s bound to PersistentVolume %s will be moved to GameServerSet %s", pvc.GetName(), pvc.Spec.VolumeName, t.opts.TargetName)
+	}
+	if t.opts.DryRun {
+		return nil
+	}
+
+	steps := []struct {
+		name string
+		fn   func(*TransferPlan) error
+	}{
+		{"retain PersistentVolumes", t.retainVolumes},
+		{"orphan Service", t.orphanService},
+		{"remove GameServer from source GameServerSet", t.removeFromSource},
+		{"move Service", t.moveService},
+		{"move PersistentVolumeClaims", t.movePvcs},
+		{"add GameServer to target GameServerSet", t.addToTarget},
+	}
+	for _, step := range steps {
+		klog.Infof("step %q starts", step.name)
+		if err := step.fn(plan); err != nil {
+			return fmt.Errorf("step %q failed: %s", step.name, err.Error())
+		}
+	}
+	klog.Infof("GameServer %s has been transferred to %s", t.sourceName(), t.targetName())
+	return nil
+}
+
+// Plan validates the transfer and collects the objects to transfer without changing anything.
+func (t *Transferer) Plan() (*TransferPlan, error) {
+	if t.opts.Name == t.opts.TargetName {
+		return nil, fmt.Errorf("the target GameServerSet must be different from the source one")
+	}
+	source, err := t.kruisegameClient.GameV1alpha1().GameServerSets(t.opts.Namespace).Get(context.TODO(), t.opts.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	target, err := t.kruisegameClient.GameV1alpha1().GameServerSets(t.opts.Namespace).Get(context.TODO(), t.opts.TargetName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// the endpoint is kept only if the target GameServerSet allocates it in the same way
+	if !reflect.DeepEqual(source.Spec.Network, target.Spec.Network) {
+		return nil, fmt.Errorf("the network of GameServerSet %s is different from %s", t.opts.TargetName, t.opts.Name)
+	}
+	for _, template := range source.Spec.GameServerTemplate.VolumeClaimTemplates {
+		if !hasVolumeClaimTemplate(target, template.GetName()) {
+			return nil, fmt.Errorf("volumeClaimTemplate %s is not found in GameServerSet %s", template.GetName(), t.opts.TargetName)
+		}
+	}
+
+	pod, err := t.kubeClient.CoreV1().Pods(t.opts.Namespace).Get(context.TODO(), t.sourceName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if pod.GetDeletionTimestamp() != nil {
+		return nil, fmt.Errorf("pod %s is being deleted", pod.GetName())
+	}
+	if pod.GetLabels()[gameKruiseV1alpha1.GameServerOpsStateKey] == string(gameKruiseV1alpha1.Allocated) {
+		return nil, fmt.Errorf("GameServer %s is allocated", pod.GetName())
+	}
+
+	targetIds, err := t.getTargetIds()
+	if err != nil {
+		return nil, err
+	}
+	if util.IsNumInList(t.opts.ID, targetIds) {
+		return nil, fmt.Errorf("ID %d is already used by GameServerSet %s", t.opts.ID, t.opts.TargetName)
+	}
+	if _, err := t.kubeClient.CoreV1().Pods(t.opts.Namespace).Get(context.TODO(), t.targetName(), metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("pod %s already exists", t.targetName())
+	}
+	if _, err := t.kubeClient.CoreV1().Services(t.opts.Namespace).Get(context.TODO(), t.targetName(), metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("Service %s already exists", t.targetName())
+	}
+	plan := &TransferPlan{Source: source, Target: target}
+
+	// only the Service of Fixed network, which is owned by GameServerSet, outlives the pod
+	svc, err := t.kubeClient.CoreV1().Services(t.opts.Namespace).Get(context.TODO(), t.sourceName(), metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil {
+		for _, or := range svc.GetOwnerReferences() {
+			if or.UID == source.GetUID() {
+				plan.Service = svc
+				break
+			}
+		}
+	}
+
+	for _, template := range source.Spec.GameServerTemplate.VolumeClaimTemplates {
+		pvc, err := t.kubeClient.CoreV1().PersistentVolumeClaims(t.opts.Namespace).Get(context.TODO(), template.GetName()+"-"+t.sourceName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if pvc.Spec.VolumeName == "" {
+			continue
+		}
+		targetPvcName := template.GetName() + "-" + t.targetName()
+		if _, err := t.kubeClient.CoreV1().PersistentVolumeClaims(t.opts.Namespace).Get(context.TODO(), targetPvcName, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("PersistentVolumeClaim %s already exists", targetPvcName)
+		}
+		plan.Pvcs = append(plan.Pvcs, *pvc)
+	}
+	return plan, nil
+}
+
+func hasVolumeClaimTemplate(gss *gameKruiseV1alpha1.GameServerSet, name string) bool {
+	for _, template := range gss.Spec.GameServerTemplate.VolumeClaimTemplates {
+		if template.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getTargetIds returns the IDs of the GameServers managed by the workload of the target GameServerSet.
+func (t *Transferer) getTargetIds() ([]int, error) {
+	asts, err := t.kruiseClient.AppsV1beta1().StatefulSets(t.opts.Namespace).Get(context.TODO(), t.opts.TargetName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return getManageIds(int(ptr.Deref(asts.Spec.Replicas, 0)), asts.Spec.ReserveOrdinals), nil
+}
+
+// getManageIds returns the IDs of the GameServers managed by a workload with the replicas and reserve ordinals.
+func getManageIds(replicas int, reserveOrdinals []int) []int {
+	var ids []int
+	for i := 0; len(ids) < replicas; i++ {
+		if !util.IsNumInList(i, reserveOrdinals) {
+			ids = append(ids, i)
+		}
+	}
+	return ids
+}
+
+// getTargetReserveIds returns the reserved IDs which make the GameServerSet create exactly the GameServer with id
+// when it is scaled up by one, that is all the IDs which are not managed below the largest one except id.
+func getTargetReserveIds(reserveIds, manageIds []int, id int) []int {
+	last := id
+	for _, manageId := range manageIds {
+		if manageId > last {
+			last = manageId
+		}
+	}
+	var newReserveIds []int
+	for i := 0; i < last; i++ {
+		if i != id && !util.IsNumInList(i, manageIds) {
+			newReserveIds = append(newReserveIds, i)
+		}
+	}
+	for _, reserveId := range reserveIds {
+		if reserveId > last {
+			newReserveIds = append(newReserveIds, reserveId)
+		}
+	}
+	sort.Ints(newReserveIds)
+	return newReserveIds
+}
+
+// retainVolumes keeps the PersistentVolumes from being reclaimed when their claims are deleted.
+func (t *Transferer) retainVolumes(plan *TransferPlan) error {
+	data := []byte(fmt.Sprintf(`{"spec":{"persistentVolumeReclaimPolicy":"%s"}}`, corev1.PersistentVolumeReclaimRetain))
+	for _, pvc := range plan.Pvcs {
+		_, err := t.kubeClient.CoreV1().PersistentVolumes().Patch(context.TODO(), pvc.Spec.VolumeName, types.MergePatchType, data, metav1.PatchOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orphanService removes the owner reference of the Service, which is marked as retained so that
+// cloud providers do not deallocate its ports when the pod is deleted.
+func (t *Transferer) orphanService(plan *TransferPlan) error {
+	if plan.Service == nil {
+		return nil
+	}
+	svc := plan.Service
+	var ownerReferences []metav1.OwnerReference
+	for _, or := range svc.GetOwnerReferences() {
+		if or.UID != plan.Source.GetUID() {
+			ownerReferences = append(ownerReferences, or)
+		}
+	}
+	svc.SetOwnerReferences(ownerReferences)
+	if svc.Annotations == nil {
+		svc.Annotations = make(map[string]string)
+	}
+	svc.Annotations[gameKruiseV1alpha1.IdentityRetainedFromKey] = plan.Source.GetName()
+	newSvc, err := t.kubeClient.CoreV1().Services(t.opts.Namespace).Update(context.TODO(), svc, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	plan.Service = newSvc
+	return nil
+}
+
+// removeFromSource reserves the ID in the source GameServerSet and scales it down by one, so that
+// the GameServer is deleted and not created again.
+func (t *Transferer) removeFromSource(plan *TransferPlan) error {
+	err := wait.PollImmediate(t.opts.PollInterval, t.opts.Timeout, func() (bool, error) {
+		gss, err := t.kruisegameClient.GameV1alpha1().GameServerSets(t.opts.Namespace).Get(context.TODO(), t.opts.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		reserveIds := util.GetReserveIds(gss)
+		if util.IsNumInList(t.opts.ID, reserveIds) {
+			return true, nil
+		}
+		gss.Spec.ReserveGameServerIds = append(gss.Spec.ReserveGameServerIds, t.opts.ID)
+		gss.Spec.Replicas = ptr.To[int32](ptr.Deref(gss.Spec.Replicas, 1) - 1)
+		_, err = t.kruisegameClient.GameV1alpha1().GameServerSets(t.opts.Namespace).Update(context.TODO(), gss, metav1.UpdateOptions{})
+		if apierrors.IsConflict(err) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if err != nil {
+		return err
+	}
+	return wait.PollImmediate(t.opts.PollInterval, t.opts.Timeout, func() (bool, error) {
+		_, err := t.kubeClient.CoreV1().Pods(t.opts.Namespace).Get(context.TODO(), t.sourceName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+// moveService recreates the Service with the name of the target GameServer, keeping its spec and annotations
+// so that the GameServer is accessed by the same endpoint. The Service is owned by the target GameServerSet.
+func (t *Transferer) moveService(plan *TransferPlan) error {
+	if plan.Service == nil {
+		return nil
+	}
+	svc := plan.Service
+	err := t.kubeClient.CoreV1().Services(t.opts.Namespace).Delete(context.TODO(), svc.GetName(), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	annotations := make(map[string]string)
+	for k, v := range svc.GetAnnotations() {
+		if k != gameKruiseV1alpha1.IdentityRetainedFromKey {
+			annotations[k] = v
+		}
+	}
+	newSvc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   t.opts.Namespace,
+			Name:        t.targetName(),
+			Labels:      renameValues(svc.GetLabels(), t.sourceName(), t.targetName()),
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         gameKruiseV1alpha1.GroupVersion.String(),
+					Kind:               "GameServerSet",
+					Name:               plan.Target.GetName(),
+					UID:                plan.Target.GetUID(),
+					Controller:         ptr.To[bool](true),
+					BlockOwnerDeletion: ptr.To[bool](true),
+				},
+			},
+		},
+		Spec: *svc.Spec.DeepCopy(),
+	}
+	newSvc.Spec.ClusterIP = ""
+	newSvc.Spec.ClusterIPs = nil
+	newSvc.Spec.Selector = renameValues(svc.Spec.Selector, t.sourceName(), t.targetName())
+	_, err = t.kubeClient.CoreV1().Services(t.opts.Namespace).Create(context.TODO(), newSvc, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// renameValues returns a copy of m, in which the values equal to the old name of the GameServer are replaced.
+func renameValues(m map[string]string, oldName, newName string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	ret := make(map[string]string, len(m))
+	for k, v := range m {
+		if v == oldName {
+			v = newName
+		}
+		ret[k] = v
+	}
+	return ret
+}
+
+// movePvcs deletes the PersistentVolumeClaims, and then binds their PersistentVolumes to the claims
+// of the target GameServer, which are named <template>-<target-gss>-<id>.
+func (t *Transferer) movePvcs(plan *TransferPlan) error {
+	for _, pvc := range plan.Pvcs {
+		err := t.kubeClient.CoreV1().PersistentVolumeClaims(t.opts.Namespace).Delete(context.TODO(), pvc.GetName(), metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		err = wait.PollImmediate(t.opts.PollInterval, t.opts.Timeout, func() (bool, error) {
+			_, err := t.kubeClient.CoreV1().PersistentVolumeClaims(t.opts.Namespace).Get(context.TODO(), pvc.GetName(), metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		})
+		if err != nil {
+			return err
+		}
+
+		newName := strings.TrimSuffix(pvc.GetName(), t.sourceName()) + t.targetName()
+		data := []byte(fmt.Sprintf(`{"spec":{"claimRef":{"namespace":"%s","name":"%s","uid":null,"resourceVersion":null}}}`, t.opts.Namespace, newName))
+		_, err = t.kubeClient.CoreV1().PersistentVolumes().Patch(context.TODO(), pvc.Spec.VolumeName, types.MergePatchType, data, metav1.PatchOptions{})
+		if err != nil {
+			return err
+		}
+
+		// the annotations of binding are set again by the PersistentVolume controller
+		annotations := make(map[string]string)
+		for k, v := range pvc.GetAnnotations() {
+			if !strings.HasPrefix(k, "pv.kubernetes.io/") {
+				annotations[k] = v
+			}
+		}
+		pvcLabels := renameValues(pvc.GetLabels(), t.sourceName(), t.targetName())
+		if _, ok := pvcLabels[gameKruiseV1alpha1.GameServerOwnerGssKey]; ok {
+			pvcLabels[gameKruiseV1alpha1.GameServerOwnerGssKey] = t.opts.TargetName
+		}
+		newPvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   t.opts.Namespace,
+				Name:        newName,
+				Labels:      pvcLabels,
+				Annotations: annotations,
+			},
+			Spec: *pvc.Spec.DeepCopy(),
+		}
+		_, err = t.kubeClient.CoreV1().PersistentVolumeClaims(t.opts.Namespace).Create(context.TODO(), newPvc, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// addToTarget scales the target GameServerSet up by one. The IDs which are not managed below the transferred one
+// are reserved, so that the GameServer is created with the transferred ID and reuses the Service and the claims.
+func (t *Transferer) addToTarget(plan *TransferPlan) error {
+	return wait.PollImmediate(t.opts.PollInterval, t.opts.Timeout, func() (bool, error) {
+		gss, err := t.kruisegameClient.GameV1alpha1().GameServerSets(t.opts.Namespace).Get(context.TODO(), t.opts.TargetName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		targetIds, err := t.getTargetIds()
+		if err != nil {
+			return false, err
+		}
+		if rangeIds, _ := util.ParseReserveIdRanges(gss.Spec.ReserveGameServerIdRanges); util.IsNumInList(t.opts.ID, rangeIds) {
+			return false, fmt.Errorf("ID %d is reserved by reserveGameServerIdRanges of the target GameServerSet", t.opts.ID)
+		}
+		reserveIds := getTargetReserveIds(util.GetReserveIds(gss), targetIds, t.opts.ID)
+		gss.Spec.ReserveGameServerIds = util.GetSpecReserveIds(gss, reserveIds)
+		gss.Spec.Replicas = ptr.To[int32](ptr.Deref(gss.Spec.Replicas, 0) + 1)
+		// the recorded IDs are updated together, so that the IDs not managed below the transferred one
+		// are not created by the scaling
+		if gss.Annotations == nil {
+			gss.Annotations = make(map[string]string)
+		}
+		gss.Annotations[gameKruiseV1alpha1.GameServerSetReserveIdsKey] = util.IntSliceToString(reserveIds, ",")
+		_, err = t.kruisegameClient.GameV1alpha1().GameServerSets(t.opts.Namespace).Update(context.TODO(), gss, metav1.UpdateOptions{})
+		if apierrors.IsConflict(err) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	kruisefake "github.com/openkruise/kruise-api/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	kruisegamefake "github.com/openkruise/kruise-game/pkg/client/clientset/versioned/fake"
+)
+
+func TestGetTargetReserveIds(t *testing.T) {
+	tests := []struct {
+		name       string
+		reserveIds []int
+		manageIds  []int
+		id         int
+		expected   []int
+	}{
+		{name: "next id", manageIds: []int{0, 1}, id: 2, expected: nil},
+		{name: "id after gaps", reserveIds: []int{1}, manageIds: []int{0}, id: 3, expected: []int{1, 2}},
+		{name: "reserved id below managed ones", reserveIds: []int{1}, manageIds: []int{0, 2}, id: 1, expected: nil},
+		{name: "reserved ids above are kept", reserveIds: []int{2, 5}, manageIds: []int{0}, id: 1, expected: []int{2, 5}},
+	}
+	for _, test := range tests {
+		if actual := getTargetReserveIds(test.reserveIds, test.manageIds, test.id); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s: expect %v, but actually got %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func newTransferObjects() (*gameKruiseV1alpha1.GameServerSet, *gameKruiseV1alpha1.GameServerSet, *kruiseV1beta1.StatefulSet, *corev1.Pod) {
+	network := &gameKruiseV1alpha1.Network{
+		NetworkType: "AlibabaCloud-SLB",
+		NetworkConf: []gameKruiseV1alpha1.NetworkConfParams{{Name: "Fixed", Value: "true"}},
+	}
+	template := gameKruiseV1alpha1.GameServerTemplate{
+		VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "data"}}},
+	}
+	source := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test", UID: "uid-test"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			Replicas:           ptr.To[int32](2),
+			Network:            network.DeepCopy(),
+			GameServerTemplate: *template.DeepCopy(),
+		},
+	}
+	target := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "prod", UID: "uid-prod"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			Replicas:             ptr.To[int32](1),
+			ReserveGameServerIds: []int{2},
+			Network:              network.DeepCopy(),
+			GameServerTemplate:   *template.DeepCopy(),
+		},
+	}
+	asts := &kruiseV1beta1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "prod"},
+		Spec:       kruiseV1beta1.StatefulSetSpec{Replicas: ptr.To[int32](1)},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "test-1",
+			Labels:    map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "test"},
+		},
+	}
+	return source, target, asts, pod
+}
+
+func TestTransferPlan(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     int
+		modify func(source, target *gameKruiseV1alpha1.GameServerSet, asts *kruiseV1beta1.StatefulSet, pod *corev1.Pod)
+	}{
+		{
+			name: "different network",
+			id:   1,
+			modify: func(source, target *gameKruiseV1alpha1.GameServerSet, asts *kruiseV1beta1.StatefulSet, pod *corev1.Pod) {
+				target.Spec.Network.NetworkConf = nil
+			},
+		},
+		{
+			name: "missing volumeClaimTemplate",
+			id:   1,
+			modify: func(source, target *gameKruiseV1alpha1.GameServerSet, asts *kruiseV1beta1.StatefulSet, pod *corev1.Pod) {
+				target.Spec.GameServerTemplate.VolumeClaimTemplates = nil
+			},
+		},
+		{
+			name: "allocated GameServer",
+			id:   1,
+			modify: func(source, target *gameKruiseV1alpha1.GameServerSet, asts *kruiseV1beta1.StatefulSet, pod *corev1.Pod) {
+				pod.Labels[gameKruiseV1alpha1.GameServerOpsStateKey] = string(gameKruiseV1alpha1.Allocated)
+			},
+		},
+		{
+			name: "id used by target",
+			id:   1,
+			modify: func(source, target *gameKruiseV1alpha1.GameServerSet, asts *kruiseV1beta1.StatefulSet, pod *corev1.Pod) {
+				asts.Spec.Replicas = ptr.To[int32](2)
+			},
+		},
+		{
+			name: "GameServer not found",
+			id:   3,
+			modify: func(source, target *gameKruiseV1alpha1.GameServerSet, asts *kruiseV1beta1.StatefulSet, pod *corev1.Pod) {
+			},
+		},
+	}
+	for _, test := range tests {
+		source, target, asts, pod := newTransferObjects()
+		test.modify(source, target, asts, pod)
+		tr := NewTransferer(kruisegamefake.NewSimpleClientset(source, target), kruisefake.NewSimpleClientset(asts), kubefake.NewSimpleClientset(pod), &Options{
+			Namespace:  "default",
+			Name:       "test",
+			TargetName: "prod",
+			ID:         test.id,
+		})
+		if _, err := tr.Plan(); err == nil {
+			t.Errorf("%s: expect an error, but actually got nil", test.name)
+		}
+	}
+}
+
+func TestTransferRun(t *testing.T) {
+	source, target, asts, pod := newTransferObjects()
+	fixedSvc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "test-1",
+			Annotations:     map[string]string{"service.beta.kubernetes.io/alibaba-cloud-loadbalancer-id": "lb-xxx"},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "GameServerSet", Name: "test", UID: "uid-test"}},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:      corev1.ServiceTypeLoadBalancer,
+			ClusterIP: "10.0.0.1",
+			Selector:  map[string]string{"statefulset.kubernetes.io/pod-name": "test-1"},
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "data-test-1",
+			Labels:      map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "test"},
+			Annotations: map[string]string{"pv.kubernetes.io/bind-completed": "yes"},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+	}
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+			ClaimRef:                      &corev1.ObjectReference{Namespace: "default", Name: "data-test-1", UID: "uid-pvc"},
+		},
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(pod, fixedSvc, pvc, pv)
+	kruisegameClient := kruisegamefake.NewSimpleClientset(source, target)
+	// the GameServerSet controller deletes the pod whose ID is reserved
+	kruisegameClient.PrependReactor("update", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gss := action.(k8stesting.UpdateAction).GetObject().(*gameKruiseV1alpha1.GameServerSet)
+		if gss.GetName() == "test" {
+			if err := kubeClient.CoreV1().Pods("default").Delete(context.TODO(), "test-1", metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return true, nil, err
+			}
+		}
+		return false, nil, nil
+	})
+	tr := NewTransferer(kruisegameClient, kruisefake.NewSimpleClientset(asts), kubeClient, &Options{
+		Namespace:    "default",
+		Name:         "test",
+		TargetName:   "prod",
+		ID:           1,
+		PollInterval: time.Millisecond,
+		Timeout:      time.Second,
+	})
+	if err := tr.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	newSource, err := kruisegameClient.GameV1alpha1().GameServerSets("default").Get(context.TODO(), "test", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *newSource.Spec.Replicas != 1 || !reflect.DeepEqual(newSource.Spec.ReserveGameServerIds, []int{1}) {
+		t.Errorf("unexpected source GameServerSet spec %v", newSource.Spec)
+	}
+	newTarget, err := kruisegameClient.GameV1alpha1().GameServerSets("default").Get(context.TODO(), "prod", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *newTarget.Spec.Replicas != 2 || !reflect.DeepEqual(newTarget.Spec.ReserveGameServerIds, []int{2}) || newTarget.GetAnnotations()[gameKruiseV1alpha1.GameServerSetReserveIdsKey] != "2" {
+		t.Errorf("unexpected target GameServerSet %v", newTarget)
+	}
+
+	if _, err := kubeClient.CoreV1().Services("default").Get(context.TODO(), "test-1", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expect Service test-1 to be deleted, but actually got %v", err)
+	}
+	svc, err := kubeClient.CoreV1().Services("default").Get(context.TODO(), "prod-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc.Spec.ClusterIP != "" || svc.Spec.Selector["statefulset.kubernetes.io/pod-name"] != "prod-1" ||
+		len(svc.GetOwnerReferences()) != 1 || svc.GetOwnerReferences()[0].UID != "uid-prod" ||
+		svc.GetAnnotations()[gameKruiseV1alpha1.IdentityRetainedFromKey] != "" ||
+		svc.GetAnnotations()["service.beta.kubernetes.io/alibaba-cloud-loadbalancer-id"] != "lb-xxx" {
+		t.Errorf("unexpected Service prod-1 %v", svc)
+	}
+
+	newPvc, err := kubeClient.CoreV1().PersistentVolumeClaims("default").Get(context.TODO(), "data-prod-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newPvc.Spec.VolumeName != "pv-1" || len(newPvc.GetAnnotations()) != 0 || newPvc.GetLabels()[gameKruiseV1alpha1.GameServerOwnerGssKey] != "prod" {
+		t.Errorf("unexpected PersistentVolumeClaim data-prod-1 %v", newPvc)
+	}
+	newPv, err := kubeClient.CoreV1().PersistentVolumes().Get(context.TODO(), "pv-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newPv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimRetain ||
+		newPv.Spec.ClaimRef.Name != "data-prod-1" || newPv.Spec.ClaimRef.UID != "" {
+		t.Errorf("unexpected PersistentVolume pv-1 %v", newPv.Spec)
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// okg-network-init is the init container injected into the pods annotated with game.kruise.io/network-inject-env.
+// It waits for the network of the game server to be ready, and writes the external and internal addresses to
+// the directory shared with the other containers, so that the game server knows its addresses at startup.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/sdk"
+)
+
+func main() {
+	var statusFile, outputDir string
+	var timeout, pollInterval time.Duration
+	flag.StringVar(&statusFile, "status-file", sdk.DefaultNetworkStatusFile, "The file of the network status projected from the pod annotation by the downward API.")
+	flag.StringVar(&outputDir, "output-dir", sdk.DefaultNetworkDir, "The directory shared with the other containers, to which the network status is written.")
+	flag.DurationVar(&timeout, "timeout", 5*time.Minute, "The longest time to wait for the network to be ready.")
+	flag.DurationVar(&pollInterval, "poll-interval", time.Second, "The interval to read the network status.")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := readNetworkStatus(statusFile)
+		if err != nil {
+			klog.Warningf("failed to read network status, because of %s", err.Error())
+		} else if status != nil && status.CurrentNetworkState == gameKruiseV1alpha1.NetworkReady {
+			if err := writeNetworkStatus(outputDir, status); err != nil {
+				klog.Errorf("failed to write network status, because of %s", err.Error())
+				os.Exit(1)
+			}
+			klog.Infof("network is ready, written to %s", outputDir)
+			return
+		}
+		if time.Now().After(deadline) {
+			klog.Errorf("network is not ready in %s", timeout)
+			os.Exit(1)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// readNetworkStatus returns nil if the network status has not been set.
+func readNetworkStatus(file string) (*gameKruiseV1alpha1.NetworkStatus, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	status := &gameKruiseV1alpha1.NetworkStatus{}
+	if err := json.Unmarshal(data, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func writeNetworkStatus(dir string, status *gameKruiseV1alpha1.NetworkStatus) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(dir, sdk.NetworkStatusFileName), data); err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, sdk.NetworkEnvFileName), []byte(sdk.FormatEnvFile(sdk.NetworkEnv(status))))
+}
+
+// writeFile writes the file atomically, so that the readers never see a partial file.
+func writeFile(name string, data []byte) error {
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// okg-sdk-sidecar runs as a sidecar of the game server and serves the SDK gRPC API on the loopback address,
+// so that the game process subscribes to the changes of its own GameServer, such as the opsState set to
+// WaitToBeDeleted or the labels changed by ops, instead of polling them. It also forwards the runtime metadata
+// of the GameServer to the game process through files or an HTTP callback, and notifies the game process of
+// the hot updates of its pod through a signal or an HTTP callback. The HTTP API of the Agones local SDK server
+// can be served as well, so that the game servers integrated with the Agones SDK run without changes.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/openkruise/kruise-game/pkg/sdk"
+)
+
+func main() {
+	var address, agonesAddress, metadataDir, metadataCallback string
+	var metadataCallbackTimeout time.Duration
+	var hotUpdateFile, hotUpdateProcess, hotUpdateSignal, hotUpdateCallback string
+	flag.StringVar(&address, "address", "127.0.0.1:9357", "The address the SDK gRPC API binds to.")
+	flag.StringVar(&agonesAddress, "agones-address", "", "The address the HTTP API of the Agones local SDK server binds to, such as 127.0.0.1:9358. Disabled if empty.")
+	flag.StringVar(&metadataDir, "metadata-dir", "", "The directory to write the runtime metadata of the GameServer to, one file per metadata. Disabled if empty.")
+	flag.StringVar(&metadataCallback, "metadata-callback", "", "The URL to post the runtime metadata of the GameServer to when they change. Disabled if empty.")
+	flag.DurationVar(&metadataCallbackTimeout, "metadata-callback-timeout", 5*time.Second, "The timeout of posting the runtime metadata.")
+	flag.StringVar(&hotUpdateFile, "hot-update-file", "", "The downwardAPI file of the game.kruise.io/hot-update-revision annotation of the pod. Hot updates are not notified if empty.")
+	flag.StringVar(&hotUpdateProcess, "hot-update-process", "", "The name of the game process to signal on hot updates, which requires shareProcessNamespace of the pod. Disabled if empty.")
+	flag.StringVar(&hotUpdateSignal, "hot-update-signal", "SIGHUP", "The signal sent to the game process on hot updates, one of SIGHUP, SIGUSR1 and SIGUSR2.")
+	flag.StringVar(&hotUpdateCallback, "hot-update-callback", "", "The URL to post the hot update revision to on hot updates. Disabled if empty.")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	server, err := sdk.NewServer()
+	if err != nil {
+		klog.Errorf("failed to create SDK server, because of %s", err.Error())
+		os.Exit(1)
+	}
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		klog.Errorf("failed to listen on %s, because of %s", address, err.Error())
+		os.Exit(1)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer cancel()
+
+	var forwarders []sdk.MetadataForwarder
+	if metadataDir != "" {
+		forwarders = append(forwarders, sdk.NewFileForwarder(metadataDir))
+	}
+	if metadataCallback != "" {
+		forwarders = append(forwarders, sdk.NewCallbackForwarder(metadataCallback, metadataCallbackTimeout))
+	}
+	if len(forwarders) > 0 {
+		go wait.UntilWithContext(ctx, func(ctx context.Context) {
+			if err := sdk.ForwardRuntimeMetadata(ctx, server, forwarders...); err != nil {
+				klog.Errorf("failed to forward runtime metadata, because of %s", err.Error())
+			}
+		}, 5*time.Second)
+	}
+
+	if hotUpdateFile != "" {
+		var notifiers []sdk.HotUpdateNotifier
+		if hotUpdateProcess != "" {
+			sig, err := parseSignal(hotUpdateSignal)
+			if err != nil {
+				klog.Errorf("failed to parse hot update signal, because of %s", err.Error())
+				os.Exit(1)
+			}
+			notifiers = append(notifiers, sdk.NewSignalNotifier(hotUpdateProcess, sig))
+		}
+		if hotUpdateCallback != "" {
+			notifiers = append(notifiers, sdk.NewHotUpdateCallbackNotifier(hotUpdateCallback, metadataCallbackTimeout))
+		}
+		watcher := sdk.NewHotUpdateWatcher(hotUpdateFile, notifiers...)
+		go wait.UntilWithContext(ctx, func(ctx context.Context) {
+			if err := watcher.Sync(ctx); err != nil {
+				klog.Errorf("failed to notify hot update, because of %s", err.Error())
+			}
+		}, 5*time.Second)
+	}
+
+	if agonesAddress != "" {
+		agonesServer := &http.Server{Addr: agonesAddress, Handler: sdk.NewAgonesServer(server).Handler(), ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			<-ctx.Done()
+			_ = agonesServer.Close()
+		}()
+		go func() {
+			klog.Infof("Agones SDK server listening on %s", agonesAddress)
+			if err := agonesServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				klog.Errorf("failed to serve Agones SDK API, because of %s", err.Error())
+				os.Exit(1)
+			}
+		}()
+	}
+
+	grpcServer := grpc.NewServer()
+	sdk.RegisterSDKServer(grpcServer, server)
+	go func() {
+		<-ctx.Done()
+		grpcServer.Stop()
+	}()
+
+	klog.Infof("SDK server listening on %s", address)
+	if err := grpcServer.Serve(lis); err != nil {
+		klog.Errorf("failed to serve SDK gRPC API, because of %s", err.Error())
+		os.Exit(1)
+	}
+}
+
+func parseSignal(name string) (os.Signal, error) {
+	switch name {
+	case "SIGHUP":
+		return syscall.SIGHUP, nil
+	case "SIGUSR1":
+		return syscall.SIGUSR1, nil
+	case "SIGUSR2":
+		return syscall.SIGUSR2, nil
+	}
+	return nil, fmt.Errorf("unsupported signal %s", name)
+}
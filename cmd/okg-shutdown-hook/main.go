@@ -0,0 +1,67 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// okg-shutdown-hook is a sample preStop hook which drains the game server before the pod is stopped.
+// It marks the game server as Draining so that allocators stop routing players to it, waits for its
+// sessions to end or the deadline, and then acknowledges the shutdown.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/openkruise/kruise-game/pkg/sdk"
+)
+
+func main() {
+	var timeout, pollInterval time.Duration
+	var sessionsField string
+	flag.DurationVar(&timeout, "timeout", 25*time.Second, "The longest time to wait for the sessions to end. It should be less than the terminationGracePeriodSeconds of the pod.")
+	flag.DurationVar(&pollInterval, "poll-interval", time.Second, "The interval to poll the state of the game server.")
+	flag.StringVar(&sessionsField, "sessions-field", "", "The custom status field of the number of sessions. The game server is drained when it becomes 0. If empty, the hook waits for the game server to acknowledge the shutdown through the SDK.")
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	s, err := sdk.NewSDK()
+	if err != nil {
+		klog.Errorf("failed to create SDK, because of %s", err.Error())
+		os.Exit(1)
+	}
+
+	deadline := time.Now().Add(timeout)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	if err := s.StartShutdown(ctx, deadline); err != nil {
+		klog.Errorf("failed to start shutdown, because of %s", err.Error())
+		os.Exit(1)
+	}
+	if err := s.WaitForDrained(ctx, sessionsField, pollInterval); err != nil {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			klog.Errorf("failed to wait for the sessions to end, because of %s", err.Error())
+			os.Exit(1)
+		}
+		klog.Warningf("sessions have not ended before the deadline %s", deadline.Format(time.RFC3339))
+	}
+	if err := s.AckShutdown(context.Background()); err != nil {
+		klog.Errorf("failed to acknowledge shutdown, because of %s", err.Error())
+		os.Exit(1)
+	}
+}
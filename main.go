@@ -19,13 +19,16 @@ package main
 import (
 	"flag"
 	"net"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	ackv1alpha1 "github.com/aws-controllers-k8s/elbv2-controller/apis/v1alpha1"
 	kruiseV1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
 	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
 	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -35,6 +38,7 @@ import (
 	"k8s.io/client-go/rest"
 	elbv2api "sigs.k8s.io/aws-load-balancer-controller/apis/elbv2/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
@@ -44,10 +48,18 @@ import (
 	cpmanager "github.com/openkruise/kruise-game/cloudprovider/manager"
 	kruisegameclientset "github.com/openkruise/kruise-game/pkg/client/clientset/versioned"
 	kruisegamevisions "github.com/openkruise/kruise-game/pkg/client/informers/externalversions"
+	"github.com/openkruise/kruise-game/pkg/cloudevents"
+	"github.com/openkruise/kruise-game/pkg/connection"
 	controller "github.com/openkruise/kruise-game/pkg/controllers"
+	"github.com/openkruise/kruise-game/pkg/custommetrics"
 	"github.com/openkruise/kruise-game/pkg/externalscaler"
+	"github.com/openkruise/kruise-game/pkg/features"
+	"github.com/openkruise/kruise-game/pkg/httpauth"
 	"github.com/openkruise/kruise-game/pkg/metrics"
+	"github.com/openkruise/kruise-game/pkg/readonly"
+	"github.com/openkruise/kruise-game/pkg/topology"
 	utilclient "github.com/openkruise/kruise-game/pkg/util/client"
+	"github.com/openkruise/kruise-game/pkg/watchdog"
 	"github.com/openkruise/kruise-game/pkg/webhook"
 	//+kubebuilder:scaffold:imports
 )
@@ -80,6 +92,19 @@ func main() {
 	var namespace string
 	var syncPeriodStr string
 	var scaleServerAddr string
+	var featureGates string
+	var connectionPollerAddr string
+	var connectionPollerQuery string
+	var connectionPollerInterval time.Duration
+	var stuckThreshold time.Duration
+	var cloudEventsSink string
+	var cloudEventsTimeout time.Duration
+	var stuckCheckInterval time.Duration
+	var enableCustomMetricsAPI bool
+	var topologyAddr string
+	var readOnly bool
+	var readOnlyConfigMap string
+	var readOnlyCheckInterval time.Duration
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8082", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
@@ -91,6 +116,20 @@ func main() {
 	flag.StringVar(&scaleServerAddr, "scale-server-bind-address", ":6000", "The address the scale server endpoint binds to.")
 	flag.IntVar(&apiServerSustainedQPSFlag, "api-server-qps", 0, "Maximum sustained queries per second to send to the API server")
 	flag.IntVar(&apiServerBurstQPSFlag, "api-server-qps-burst", 0, "Maximum burst queries per second to send to the API server")
+	flag.StringVar(&featureGates, "feature-gates", "", "A set of key=value pairs that describe feature gates for optional subsystems. Options are:\n"+
+		strings.Join(features.DefaultMutableFeatureGate.KnownFeatures(), "\n"))
+	flag.StringVar(&connectionPollerAddr, "connection-poller-address", "", "The address of the Prometheus-compatible query API of the cloud monitoring service, from which the ConnectionPoller reads the active connections.")
+	flag.StringVar(&connectionPollerQuery, "connection-poller-query", "", "The query template of the active connections of a listener of GameServer, with the variables .Namespace, .Name, .IP, .Port, .Protocol and .LoadBalancerId.")
+	flag.DurationVar(&connectionPollerInterval, "connection-poller-interval", connection.DefaultInterval, "The interval of the ConnectionPoller polling the active connections.")
+	flag.DurationVar(&stuckThreshold, "stuck-threshold", watchdog.DefaultThreshold, "The time after which a GameServer or GameServerSet not converging is reported as stuck. Set 0 to disable the watchdog.")
+	flag.DurationVar(&stuckCheckInterval, "stuck-check-interval", watchdog.DefaultInterval, "The interval of the watchdog checking the stuck GameServers and GameServerSets.")
+	flag.StringVar(&cloudEventsSink, "cloudevents-sink", "", "The URL of the HTTP sink which the lifecycle events of GameServers are published to as CloudEvents. Disabled if empty.")
+	flag.DurationVar(&cloudEventsTimeout, "cloudevents-timeout", cloudevents.DefaultTimeout, "The timeout of publishing a CloudEvent to the sink.")
+	flag.BoolVar(&readOnly, "read-only", false, "Put kruise-game-manager into read-only mode, in which it observes the cluster and updates the status of objects only, without writing Services, deleting pods or other changes.")
+	flag.StringVar(&readOnlyConfigMap, "read-only-configmap", "", "The ConfigMap in the form of {namespace}/{name}, whose readOnly: \"true\" puts kruise-game-manager into read-only mode. Disabled if empty.")
+	flag.DurationVar(&readOnlyCheckInterval, "read-only-check-interval", readonly.DefaultInterval, "The interval of checking the read-only ConfigMap.")
+	flag.BoolVar(&enableCustomMetricsAPI, "enable-custom-metrics-api", false, "Serve the metrics of GameServerSets as custom.metrics.k8s.io/v1beta1 on the webhook server, so that HPA can scale GameServerSets on them.")
+	flag.StringVar(&topologyAddr, "topology-bind-address", "", "The address the topology endpoint binds to, which serves the GameServers grouped by zones and nodes over TLS. Disabled if empty.")
 
 	// Add cloud provider flags
 	cloudprovider.InitCloudProviderFlags()
@@ -103,6 +142,11 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if err := features.DefaultMutableFeatureGate.Set(featureGates); err != nil {
+		setupLog.Error(err, "unable to parse feature gates")
+		os.Exit(1)
+	}
+
 	// syncPeriod parsed
 	var syncPeriod *time.Duration
 	if syncPeriodStr != "" {
@@ -114,6 +158,8 @@ func main() {
 		}
 	}
 
+	readonly.Set(readOnly)
+
 	restConfig := ctrl.GetConfigOrDie()
 	setRestConfig(restConfig)
 	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
@@ -137,6 +183,9 @@ func main() {
 		Namespace:  namespace,
 		SyncPeriod: syncPeriod,
 		NewClient:  utilclient.NewClient,
+		// Secrets and ConfigMaps are read by get only, rather than cached cluster-wide, so that the state
+		// ConfigMaps of the network plugins are always written from the latest data
+		ClientDisableCacheFor: []client.Object{&corev1.Secret{}, &corev1.ConfigMap{}},
 	})
 
 	if err != nil {
@@ -157,6 +206,13 @@ func main() {
 		setupLog.Error(err, "unable to set up webhook server")
 		os.Exit(1)
 	}
+	// the HTTP APIs are served with the certificate of the webhook server, unless one is set
+	httpauth.SetDefaultCertDir(webhook.GetCertDir())
+	if enableCustomMetricsAPI {
+		customMetricsHandler := custommetrics.NewHandler(mgr.GetClient())
+		mgr.GetWebhookServer().Register(custommetrics.Path, customMetricsHandler)
+		mgr.GetWebhookServer().Register(custommetrics.Path+"/", customMetricsHandler)
+	}
 
 	//+kubebuilder:scaffold:builder
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -167,6 +223,67 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if features.DefaultFeatureGate.Enabled(features.ConnectionPoller) {
+		querier, err := connection.NewPrometheusQuerier(connectionPollerAddr)
+		if err != nil {
+			setupLog.Error(err, "unable to create connection querier")
+			os.Exit(1)
+		}
+		poller, err := connection.NewPoller(mgr.GetClient(), querier, connectionPollerQuery, connectionPollerInterval)
+		if err != nil {
+			setupLog.Error(err, "unable to create connection poller")
+			os.Exit(1)
+		}
+		if err := mgr.Add(poller); err != nil {
+			setupLog.Error(err, "unable to set up connection poller")
+			os.Exit(1)
+		}
+	}
+	if stuckThreshold > 0 {
+		wd := watchdog.NewWatchdog(mgr.GetClient(), mgr.GetEventRecorderFor("stuck-watchdog"), stuckThreshold, stuckCheckInterval)
+		if err := mgr.Add(wd); err != nil {
+			setupLog.Error(err, "unable to set up stuck watchdog")
+			os.Exit(1)
+		}
+	}
+	if readOnlyConfigMap != "" {
+		watcher, err := readonly.NewWatcher(mgr.GetAPIReader(), readOnlyConfigMap, readOnly, readOnlyCheckInterval)
+		if err != nil {
+			setupLog.Error(err, "unable to create read-only watcher")
+			os.Exit(1)
+		}
+		if err := mgr.Add(watcher); err != nil {
+			setupLog.Error(err, "unable to set up read-only watcher")
+			os.Exit(1)
+		}
+	}
+	if cloudEventsSink != "" {
+		emitter := cloudevents.NewEmitter(mgr.GetCache(), cloudevents.NewHTTPSink(cloudEventsSink, cloudEventsTimeout))
+		if err := mgr.Add(emitter); err != nil {
+			setupLog.Error(err, "unable to set up cloudevents emitter")
+			os.Exit(1)
+		}
+	}
+	if topologyAddr != "" {
+		// the topology is aggregated from the cache of every replica, for the users allowed to list GameServers
+		mux := http.NewServeMux()
+		mux.Handle(topology.Path, topology.NewHandler(mgr.GetClient()))
+		topologyServer := &httpauth.Server{Name: "topology endpoint", Addr: topologyAddr, Handler: mux}
+		if err := mgr.Add(topologyServer); err != nil {
+			setupLog.Error(err, "unable to set up topology endpoint")
+			os.Exit(1)
+		}
+	}
+	if cloudprovider.Opt.EnableNetworkRebalanceAPI {
+		// the port allocations are rebalanced by the leader only, which is authorized by the RBAC of the users
+		mux := http.NewServeMux()
+		mux.Handle(cpmanager.RebalancePath, cpmanager.NewRebalanceHandler(cloudProviderManager, mgr.GetClient()))
+		rebalanceServer := &httpauth.Server{Name: "network rebalance endpoint", Addr: cloudprovider.Opt.NetworkRebalanceBindAddress, Handler: mux, LeaderElection: true}
+		if err := mgr.Add(rebalanceServer); err != nil {
+			setupLog.Error(err, "unable to set up network rebalance endpoint")
+			os.Exit(1)
+		}
+	}
 
 	signal := ctrl.SetupSignalHandler()
 	go func() {
@@ -180,6 +297,18 @@ func main() {
 		if mgr.GetCache().WaitForCacheSync(signal) {
 			setupLog.Info("cache synced, cloud provider manager start to init")
 			cloudProviderManager.Init(mgr.GetClient())
+			if interval := cloudprovider.Opt.CloudProviderConfigReloadInterval; interval > 0 {
+				watcher := cpmanager.NewConfigWatcher(cloudProviderManager, mgr.GetClient(), cloudprovider.Opt.CloudProviderConfigFile, interval)
+				go func() {
+					_ = watcher.Start(signal)
+				}()
+			}
+			if interval := cloudprovider.Opt.AllocationSweepInterval; interval > 0 {
+				sweeper := cpmanager.NewAllocationSweeper(cloudProviderManager, mgr.GetClient(), interval)
+				if err := mgr.Add(sweeper); err != nil {
+					setupLog.Error(err, "unable to set up allocation sweeper")
+				}
+			}
 		}
 	}()
 
@@ -197,16 +326,18 @@ func main() {
 		}
 	}()
 
-	externalScaler := externalscaler.NewExternalScaler(mgr.GetClient())
-	go func() {
-		grpcServer := grpc.NewServer()
-		lis, _ := net.Listen("tcp", scaleServerAddr)
-		externalscaler.RegisterExternalScalerServer(grpcServer, externalScaler)
-		if err := grpcServer.Serve(lis); err != nil {
-			setupLog.Error(err, "unable to setup ExternalScalerServer")
-			os.Exit(1)
-		}
-	}()
+	if features.DefaultFeatureGate.Enabled(features.AutoScaler) {
+		externalScaler := externalscaler.NewExternalScaler(mgr.GetClient())
+		go func() {
+			grpcServer := grpc.NewServer()
+			lis, _ := net.Listen("tcp", scaleServerAddr)
+			externalscaler.RegisterExternalScalerServer(grpcServer, externalScaler)
+			if err := grpcServer.Serve(lis); err != nil {
+				setupLog.Error(err, "unable to setup ExternalScalerServer")
+				os.Exit(1)
+			}
+		}()
+	}
 
 	setupLog.Info("starting kruise-game-manager")
 
@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package helpers provides functions for backend services, such as matchmakers, to query
+// GameServers through the typed listers instead of dynamic clients and unstructured parsing.
+package helpers
+
+import (
+	"net"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	listers "github.com/openkruise/kruise-game/pkg/client/listers/apis/v1alpha1"
+)
+
+// IsIdle returns true if the GameServer is ready to serve players and has not been allocated,
+// marked to be maintained or deleted, or started shutting down.
+func IsIdle(gs *gameKruiseV1alpha1.GameServer) bool {
+	if gs.Status.CurrentState != gameKruiseV1alpha1.Ready {
+		return false
+	}
+	if IsShuttingDown(gs) {
+		return false
+	}
+	if gs.Spec.OpsState != gameKruiseV1alpha1.None && gs.Spec.OpsState != "" {
+		return false
+	}
+	if gs.Spec.NetworkDisabled {
+		return false
+	}
+	networkStatus := gs.Status.NetworkStatus
+	return networkStatus.NetworkType == "" || networkStatus.CurrentNetworkState == gameKruiseV1alpha1.NetworkReady
+}
+
+// IsShuttingDown returns true if the game server of the GameServer has started the graceful shutdown,
+// which means new players should not be routed to it.
+func IsShuttingDown(gs *gameKruiseV1alpha1.GameServer) bool {
+	for _, condition := range gs.Status.Conditions {
+		if condition.Type == gameKruiseV1alpha1.ShuttingDown {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// ListGameServersOfGameServerSet lists the GameServers belonging to the GameServerSet, sorted by name.
+func ListGameServersOfGameServerSet(lister listers.GameServerLister, namespace, gssName string) ([]*gameKruiseV1alpha1.GameServer, error) {
+	gsList, err := lister.GameServers(namespace).List(labels.SelectorFromSet(map[string]string{
+		gameKruiseV1alpha1.GameServerOwnerGssKey: gssName,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(gsList, func(i, j int) bool { return gsList[i].GetName() < gsList[j].GetName() })
+	return gsList, nil
+}
+
+// ListIdleGameServers lists the idle GameServers belonging to the GameServerSet.
+func ListIdleGameServers(lister listers.GameServerLister, namespace, gssName string) ([]*gameKruiseV1alpha1.GameServer, error) {
+	return FilterGameServers(lister, namespace, gssName, IsIdle)
+}
+
+// ListGameServersByOpsState lists the GameServers belonging to the GameServerSet with the given opsState.
+func ListGameServersByOpsState(lister listers.GameServerLister, namespace, gssName string, opsState gameKruiseV1alpha1.OpsState) ([]*gameKruiseV1alpha1.GameServer, error) {
+	return FilterGameServers(lister, namespace, gssName, func(gs *gameKruiseV1alpha1.GameServer) bool {
+		return gs.Spec.OpsState == opsState
+	})
+}
+
+// FilterGameServers lists the GameServers belonging to the GameServerSet which satisfy the filter.
+func FilterGameServers(lister listers.GameServerLister, namespace, gssName string, filter func(gs *gameKruiseV1alpha1.GameServer) bool) ([]*gameKruiseV1alpha1.GameServer, error) {
+	gsList, err := ListGameServersOfGameServerSet(lister, namespace, gssName)
+	if err != nil {
+		return nil, err
+	}
+	var ret []*gameKruiseV1alpha1.GameServer
+	for _, gs := range gsList {
+		if filter(gs) {
+			ret = append(ret, gs)
+		}
+	}
+	return ret, nil
+}
+
+// GetExternalAddresses returns the external addresses of the GameServer in the format of ip:port, or [ip]:port for IPv6.
+func GetExternalAddresses(gs *gameKruiseV1alpha1.GameServer) []string {
+	var addresses []string
+	for _, address := range gs.Status.NetworkStatus.ExternalAddresses {
+		host := address.IP
+		if host == "" {
+			host = address.EndPoint
+		}
+		for _, port := range address.Ports {
+			if port.Port == nil {
+				continue
+			}
+			addresses = append(addresses, net.JoinHostPort(host, port.Port.String()))
+		}
+	}
+	return addresses
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/cache"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	listers "github.com/openkruise/kruise-game/pkg/client/listers/apis/v1alpha1"
+)
+
+func newGameServer(name string, state gameKruiseV1alpha1.GameServerState, opsState gameKruiseV1alpha1.OpsState) *gameKruiseV1alpha1.GameServer {
+	return &gameKruiseV1alpha1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "xxx",
+			Labels:    map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "case"},
+		},
+		Spec: gameKruiseV1alpha1.GameServerSpec{
+			OpsState: opsState,
+		},
+		Status: gameKruiseV1alpha1.GameServerStatus{
+			CurrentState: state,
+		},
+	}
+}
+
+func TestListIdleGameServers(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	gsList := []*gameKruiseV1alpha1.GameServer{
+		newGameServer("case-2", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+		newGameServer("case-0", gameKruiseV1alpha1.Ready, ""),
+		newGameServer("case-1", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated),
+		newGameServer("case-3", gameKruiseV1alpha1.Creating, gameKruiseV1alpha1.None),
+	}
+	networkNotReady := newGameServer("case-4", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None)
+	networkNotReady.Status.NetworkStatus = gameKruiseV1alpha1.NetworkStatus{
+		NetworkType:         "Kubernetes-HostPort",
+		CurrentNetworkState: gameKruiseV1alpha1.NetworkNotReady,
+	}
+	gsList = append(gsList, networkNotReady)
+	shuttingDown := newGameServer("case-5", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None)
+	shuttingDown.Status.Conditions = []gameKruiseV1alpha1.GameServerCondition{{
+		Type:   gameKruiseV1alpha1.ShuttingDown,
+		Status: corev1.ConditionTrue,
+		Reason: string(gameKruiseV1alpha1.ShutdownDraining),
+	}}
+	gsList = append(gsList, shuttingDown)
+	for _, gs := range gsList {
+		if err := indexer.Add(gs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lister := listers.NewGameServerLister(indexer)
+
+	idle, err := ListIdleGameServers(lister, "xxx", "case")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, gs := range idle {
+		names = append(names, gs.GetName())
+	}
+	if !reflect.DeepEqual(names, []string{"case-0", "case-2"}) {
+		t.Errorf("expect idle GameServers [case-0 case-2], but actually got %v", names)
+	}
+
+	allocated, err := ListGameServersByOpsState(lister, "xxx", "case", gameKruiseV1alpha1.Allocated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allocated) != 1 || allocated[0].GetName() != "case-1" {
+		t.Errorf("expect allocated GameServers [case-1], but actually got %v", allocated)
+	}
+}
+
+func TestGetExternalAddresses(t *testing.T) {
+	port := intstr.FromInt(8080)
+	gs := &gameKruiseV1alpha1.GameServer{
+		Status: gameKruiseV1alpha1.GameServerStatus{
+			NetworkStatus: gameKruiseV1alpha1.NetworkStatus{
+				ExternalAddresses: []gameKruiseV1alpha1.NetworkAddress{
+					{
+						IP: "1.2.3.4",
+						Ports: []gameKruiseV1alpha1.NetworkPort{
+							{
+								Name: "8080",
+								Port: &port,
+							},
+						},
+					},
+					{
+						IP: "2001:db8::1",
+						Ports: []gameKruiseV1alpha1.NetworkPort{
+							{
+								Name: "8080",
+								Port: &port,
+							},
+						},
+					},
+					{
+						EndPoint: "xxx.com",
+						Ports: []gameKruiseV1alpha1.NetworkPort{
+							{
+								Name: "8080",
+								Port: &port,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	expect := []string{"1.2.3.4:8080", "[2001:db8::1]:8080", "xxx.com:8080"}
+	if actual := GetExternalAddresses(gs); !reflect.DeepEqual(actual, expect) {
+		t.Errorf("expect %v, but actually got %v", expect, actual)
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"context"
+
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+const (
+	// IndexOpsState indexes GameServers by spec.opsState, where an empty opsState is indexed as None.
+	IndexOpsState = "spec.opsState"
+	// IndexNetworkState indexes GameServers by status.networkStatus.currentNetworkState.
+	IndexNetworkState = "status.networkStatus.currentNetworkState"
+	// IndexOwnerGss indexes GameServers by the name of the GameServerSet owning them.
+	IndexOwnerGss = "metadata.ownerGss"
+)
+
+// GameServerIndexFuncs returns the values of the GameServer for each index.
+var GameServerIndexFuncs = map[string]func(gs *gameKruiseV1alpha1.GameServer) []string{
+	IndexOpsState: func(gs *gameKruiseV1alpha1.GameServer) []string {
+		if gs.Spec.OpsState == "" {
+			return []string{string(gameKruiseV1alpha1.None)}
+		}
+		return []string{string(gs.Spec.OpsState)}
+	},
+	IndexNetworkState: func(gs *gameKruiseV1alpha1.GameServer) []string {
+		return []string{string(gs.Status.NetworkStatus.CurrentNetworkState)}
+	},
+	IndexOwnerGss: func(gs *gameKruiseV1alpha1.GameServer) []string {
+		return []string{gs.GetLabels()[gameKruiseV1alpha1.GameServerOwnerGssKey]}
+	},
+}
+
+// IndexerFunc converts the index of GameServers to a client.IndexerFunc of controller-runtime.
+func IndexerFunc(field string) client.IndexerFunc {
+	indexFunc := GameServerIndexFuncs[field]
+	return func(obj client.Object) []string {
+		gs, ok := obj.(*gameKruiseV1alpha1.GameServer)
+		if !ok || indexFunc == nil {
+			return nil
+		}
+		return indexFunc(gs)
+	}
+}
+
+// IndexGameServers registers the GameServer indexes in the cache of controller-runtime,
+// so that GameServers can be listed with client.MatchingFields, e.g.
+// client.MatchingFields{helpers.IndexOwnerGss: "gss", helpers.IndexOpsState: "None"}.
+func IndexGameServers(ctx context.Context, indexer client.FieldIndexer) error {
+	for field := range GameServerIndexFuncs {
+		if err := indexer.IndexField(ctx, &gameKruiseV1alpha1.GameServer{}, field, IndexerFunc(field)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GameServerIndexers returns the GameServer indexes for the informers of client-go, which should be added
+// before the informer starts, e.g. informer.AddIndexers(helpers.GameServerIndexers()).
+func GameServerIndexers() cache.Indexers {
+	indexers := cache.Indexers{}
+	for field, indexFunc := range GameServerIndexFuncs {
+		indexFunc := indexFunc
+		indexers[field] = func(obj interface{}) ([]string, error) {
+			gs, ok := obj.(*gameKruiseV1alpha1.GameServer)
+			if !ok {
+				return nil, nil
+			}
+			return indexFunc(gs), nil
+		}
+	}
+	return indexers
+}
+
+// ListGameServersByIndex lists the GameServers of all namespaces whose index value of the field equals to the value,
+// from the indexer of a GameServer informer with GameServerIndexers added.
+func ListGameServersByIndex(indexer cache.Indexer, field, value string) ([]*gameKruiseV1alpha1.GameServer, error) {
+	objs, err := indexer.ByIndex(field, value)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*gameKruiseV1alpha1.GameServer, 0, len(objs))
+	for _, obj := range objs {
+		if gs, ok := obj.(*gameKruiseV1alpha1.GameServer); ok {
+			ret = append(ret, gs)
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"k8s.io/client-go/tools/cache"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestListGameServersByIndex(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, GameServerIndexers())
+	gsList := []*gameKruiseV1alpha1.GameServer{
+		newGameServer("case-0", gameKruiseV1alpha1.Ready, ""),
+		newGameServer("case-1", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated),
+		newGameServer("case-2", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+	}
+	gsList[2].Status.NetworkStatus.CurrentNetworkState = gameKruiseV1alpha1.NetworkReady
+	other := newGameServer("other-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None)
+	other.Labels[gameKruiseV1alpha1.GameServerOwnerGssKey] = "other"
+	gsList = append(gsList, other)
+	for _, gs := range gsList {
+		if err := indexer.Add(gs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		field string
+		value string
+		names []string
+	}{
+		{
+			field: IndexOpsState,
+			value: string(gameKruiseV1alpha1.None),
+			names: []string{"case-0", "case-2", "other-0"},
+		},
+		{
+			field: IndexOpsState,
+			value: string(gameKruiseV1alpha1.Allocated),
+			names: []string{"case-1"},
+		},
+		{
+			field: IndexNetworkState,
+			value: string(gameKruiseV1alpha1.NetworkReady),
+			names: []string{"case-2"},
+		},
+		{
+			field: IndexOwnerGss,
+			value: "case",
+			names: []string{"case-0", "case-1", "case-2"},
+		},
+	}
+
+	for _, test := range tests {
+		gss, err := ListGameServersByIndex(indexer, test.field, test.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, 0, len(gss))
+		for _, gs := range gss {
+			names = append(names, gs.GetName())
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, test.names) {
+			t.Errorf("%s=%s: expect %v, but actually got %v", test.field, test.value, test.names, names)
+		}
+	}
+}
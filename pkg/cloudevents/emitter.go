@@ -0,0 +1,203 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevents
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/wait"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+const (
+	GameServerCreated   = "io.openkruise.game.gameserver.created"
+	GameServerReady     = "io.openkruise.game.gameserver.ready"
+	GameServerAllocated = "io.openkruise.game.gameserver.allocated"
+	GameServerDraining  = "io.openkruise.game.gameserver.draining"
+	GameServerDeleted   = "io.openkruise.game.gameserver.deleted"
+
+	// bufferSize is the number of events waiting to be sent, beyond which the events are dropped,
+	// so that an unavailable sink does not block the informer.
+	bufferSize = 1024
+)
+
+// sendBackoff retries sending an event for about 7 seconds before it is dropped.
+var sendBackoff = wait.Backoff{
+	Steps:    4,
+	Duration: time.Second,
+	Factor:   2,
+}
+
+// Event is a CloudEvent of the lifecycle of a GameServer.
+type Event struct {
+	ID      string
+	Type    string
+	Source  string
+	Subject string
+	Time    time.Time
+	Data    GameServerData
+}
+
+// GameServerData is the data of the events, which is the GameServer at the time of the event.
+type GameServerData struct {
+	Namespace     string                             `json:"namespace"`
+	Name          string                             `json:"name"`
+	GameServerSet string                             `json:"gameServerSet,omitempty"`
+	State         gameKruiseV1alpha1.GameServerState `json:"state,omitempty"`
+	OpsState      gameKruiseV1alpha1.OpsState        `json:"opsState,omitempty"`
+	NetworkStatus gameKruiseV1alpha1.NetworkStatus   `json:"networkStatus,omitempty"`
+}
+
+// Emitter watches the GameServers and publishes their lifecycle events to the sink: created, ready when the
+// state turns Ready, allocated when the opsState turns Allocated, draining when the opsState turns WaitToBeDeleted,
+// and deleted. The events are delivered on a best-effort basis: they are retried for a few seconds, and the ones
+// during leader transitions may be lost.
+type Emitter struct {
+	cache   cache.Cache
+	sink    Sink
+	events  chan *Event
+	now     func() time.Time
+	started time.Time
+}
+
+func NewEmitter(c cache.Cache, sink Sink) *Emitter {
+	return &Emitter{
+		cache:  c,
+		sink:   sink,
+		events: make(chan *Event, bufferSize),
+		now:    time.Now,
+	}
+}
+
+// Start implements manager.Runnable.
+func (e *Emitter) Start(ctx context.Context) error {
+	e.started = e.now()
+	informer, err := e.cache.GetInformer(ctx, &gameKruiseV1alpha1.GameServer{})
+	if err != nil {
+		return err
+	}
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    e.onAdd,
+		UpdateFunc: e.onUpdate,
+		DeleteFunc: e.onDelete,
+	})
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-e.events:
+			e.send(ctx, event)
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that only the leader publishes the events.
+func (e *Emitter) NeedLeaderElection() bool {
+	return true
+}
+
+func (e *Emitter) onAdd(obj interface{}) {
+	gs, ok := obj.(*gameKruiseV1alpha1.GameServer)
+	if !ok {
+		return
+	}
+	// the GameServers listed when the informer starts are created before
+	if gs.GetCreationTimestamp().Time.Before(e.started.Truncate(time.Second)) {
+		return
+	}
+	e.emit(GameServerCreated, gs)
+}
+
+func (e *Emitter) onUpdate(oldObj, newObj interface{}) {
+	oldGs, ok := oldObj.(*gameKruiseV1alpha1.GameServer)
+	if !ok {
+		return
+	}
+	newGs, ok := newObj.(*gameKruiseV1alpha1.GameServer)
+	if !ok {
+		return
+	}
+	for _, eventType := range getTransitions(oldGs, newGs) {
+		e.emit(eventType, newGs)
+	}
+}
+
+func (e *Emitter) onDelete(obj interface{}) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	gs, ok := obj.(*gameKruiseV1alpha1.GameServer)
+	if !ok {
+		return
+	}
+	e.emit(GameServerDeleted, gs)
+}
+
+// getTransitions returns the types of the events which the GameServer transits to.
+func getTransitions(oldGs, newGs *gameKruiseV1alpha1.GameServer) []string {
+	var eventTypes []string
+	if oldGs.Status.CurrentState != gameKruiseV1alpha1.Ready && newGs.Status.CurrentState == gameKruiseV1alpha1.Ready {
+		eventTypes = append(eventTypes, GameServerReady)
+	}
+	if oldGs.Spec.OpsState != gameKruiseV1alpha1.Allocated && newGs.Spec.OpsState == gameKruiseV1alpha1.Allocated {
+		eventTypes = append(eventTypes, GameServerAllocated)
+	}
+	if oldGs.Spec.OpsState != gameKruiseV1alpha1.WaitToDelete && newGs.Spec.OpsState == gameKruiseV1alpha1.WaitToDelete {
+		eventTypes = append(eventTypes, GameServerDraining)
+	}
+	return eventTypes
+}
+
+func (e *Emitter) emit(eventType string, gs *gameKruiseV1alpha1.GameServer) {
+	event := &Event{
+		ID:      string(uuid.NewUUID()),
+		Type:    eventType,
+		Source:  fmt.Sprintf("/apis/game.kruise.io/v1alpha1/namespaces/%s/gameservers", gs.GetNamespace()),
+		Subject: gs.GetName(),
+		Time:    e.now(),
+		Data: GameServerData{
+			Namespace:     gs.GetNamespace(),
+			Name:          gs.GetName(),
+			GameServerSet: gs.GetLabels()[gameKruiseV1alpha1.GameServerOwnerGssKey],
+			State:         gs.Status.CurrentState,
+			OpsState:      gs.Spec.OpsState,
+			NetworkStatus: gs.Status.NetworkStatus,
+		},
+	}
+	select {
+	case e.events <- event:
+	default:
+		klog.Warningf("failed to publish %s of GameServer %s in %s,because of the full buffer.", eventType, gs.GetName(), gs.GetNamespace())
+	}
+}
+
+func (e *Emitter) send(ctx context.Context, event *Event) {
+	err := retry.OnError(sendBackoff, func(error) bool { return ctx.Err() == nil }, func() error {
+		return e.sink.Send(ctx, event)
+	})
+	if err != nil {
+		klog.Errorf("failed to publish %s of GameServer %s in %s,because of %s.", event.Type, event.Data.Name, event.Data.Namespace, err.Error())
+	}
+}
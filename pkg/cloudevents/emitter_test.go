@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevents
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func newGameServer(created time.Time, state gameKruiseV1alpha1.GameServerState, opsState gameKruiseV1alpha1.OpsState) *gameKruiseV1alpha1.GameServer {
+	return &gameKruiseV1alpha1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "xxx",
+			Name:              "minecraft-0",
+			CreationTimestamp: metav1.NewTime(created),
+			Labels:            map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "minecraft"},
+		},
+		Spec: gameKruiseV1alpha1.GameServerSpec{
+			OpsState: opsState,
+		},
+		Status: gameKruiseV1alpha1.GameServerStatus{
+			CurrentState: state,
+		},
+	}
+}
+
+func drain(e *Emitter) []string {
+	var eventTypes []string
+	for {
+		select {
+		case event := <-e.events:
+			eventTypes = append(eventTypes, event.Type)
+		default:
+			return eventTypes
+		}
+	}
+}
+
+func TestEmitter(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	e := NewEmitter(nil, nil)
+	e.now = func() time.Time { return now }
+	e.started = now
+
+	// the GameServers listed when the informer starts are not created
+	e.onAdd(newGameServer(now.Add(-time.Hour), gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None))
+	e.onAdd(newGameServer(now, "", ""))
+	if actual, expected := drain(e), []string{GameServerCreated}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expect events %v, but actually got %v", expected, actual)
+	}
+
+	tests := []struct {
+		oldGs    *gameKruiseV1alpha1.GameServer
+		newGs    *gameKruiseV1alpha1.GameServer
+		expected []string
+	}{
+		{
+			oldGs:    newGameServer(now, gameKruiseV1alpha1.Creating, gameKruiseV1alpha1.None),
+			newGs:    newGameServer(now, gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			expected: []string{GameServerReady},
+		},
+		{
+			oldGs:    newGameServer(now, gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			newGs:    newGameServer(now, gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated),
+			expected: []string{GameServerAllocated},
+		},
+		{
+			oldGs:    newGameServer(now, gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated),
+			newGs:    newGameServer(now, gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.WaitToDelete),
+			expected: []string{GameServerDraining},
+		},
+		{
+			oldGs: newGameServer(now, gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated),
+			newGs: newGameServer(now, gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated),
+		},
+		{
+			oldGs:    newGameServer(now, gameKruiseV1alpha1.Updating, gameKruiseV1alpha1.None),
+			newGs:    newGameServer(now, gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.WaitToDelete),
+			expected: []string{GameServerReady, GameServerDraining},
+		},
+	}
+	for i, test := range tests {
+		e.onUpdate(test.oldGs, test.newGs)
+		if actual := drain(e); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("case %d: expect events %v, but actually got %v", i, test.expected, actual)
+		}
+	}
+
+	gs := newGameServer(now, gameKruiseV1alpha1.Deleting, gameKruiseV1alpha1.WaitToDelete)
+	e.onDelete(gs)
+	e.onDelete(toolscache.DeletedFinalStateUnknown{Key: "xxx/minecraft-0", Obj: gs})
+	if actual, expected := drain(e), []string{GameServerDeleted, GameServerDeleted}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expect events %v, but actually got %v", expected, actual)
+	}
+
+	e.emit(GameServerReady, gs)
+	event := <-e.events
+	expected := GameServerData{
+		Namespace:     "xxx",
+		Name:          "minecraft-0",
+		GameServerSet: "minecraft",
+		State:         gameKruiseV1alpha1.Deleting,
+		OpsState:      gameKruiseV1alpha1.WaitToDelete,
+	}
+	if !reflect.DeepEqual(event.Data, expected) {
+		t.Errorf("expect data %v, but actually got %v", expected, event.Data)
+	}
+	if event.ID == "" || event.Subject != "minecraft-0" || event.Source != "/apis/game.kruise.io/v1alpha1/namespaces/xxx/gameservers" {
+		t.Errorf("unexpected attributes of event %v", event)
+	}
+}
+
+type fakeSink struct {
+	failures int
+	sent     []*Event
+}
+
+func (f *fakeSink) Send(_ context.Context, event *Event) error {
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("sink unavailable")
+	}
+	f.sent = append(f.sent, event)
+	return nil
+}
+
+func TestEmitterSend(t *testing.T) {
+	backoff := sendBackoff
+	sendBackoff.Duration = time.Millisecond
+	defer func() { sendBackoff = backoff }()
+
+	sink := &fakeSink{failures: 2}
+	e := NewEmitter(nil, sink)
+	e.send(context.TODO(), &Event{Type: GameServerReady})
+	if len(sink.sent) != 1 {
+		t.Errorf("expect the event sent after retries, but actually sent %d", len(sink.sent))
+	}
+
+	sink = &fakeSink{failures: sendBackoff.Steps}
+	e = NewEmitter(nil, sink)
+	e.send(context.TODO(), &Event{Type: GameServerReady})
+	if len(sink.sent) != 0 {
+		t.Errorf("expect the event dropped after %d failures, but actually sent %d", sendBackoff.Steps, len(sink.sent))
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultTimeout is the default timeout of sending an event to the sink.
+const DefaultTimeout = 5 * time.Second
+
+const specVersion = "1.0"
+
+// Sink delivers the CloudEvents to the event-driven backends.
+type Sink interface {
+	Send(ctx context.Context, event *Event) error
+}
+
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPSink returns the Sink posting the events to the url in the binary content mode of the CloudEvents
+// HTTP protocol binding, in which the attributes are the ce- headers and the data is the JSON body, so that
+// the events are accepted by Knative brokers, Amazon EventBridge API destinations and the like.
+// Responses other than 2xx are errors.
+func NewHTTPSink(url string, timeout time.Duration) Sink {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &httpSink{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *httpSink) Send(ctx context.Context, event *Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("ce-specversion", specVersion)
+	req.Header.Set("ce-id", event.ID)
+	req.Header.Set("ce-source", event.Source)
+	req.Header.Set("ce-type", event.Type)
+	req.Header.Set("ce-subject", event.Subject)
+	req.Header.Set("ce-time", event.Time.UTC().Format(time.RFC3339Nano))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sink %s responded %s", s.url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestHTTPSink(t *testing.T) {
+	var header http.Header
+	var data GameServerData
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	event := &Event{
+		ID:      "4f6b1c2e",
+		Type:    GameServerAllocated,
+		Source:  "/apis/game.kruise.io/v1alpha1/namespaces/xxx/gameservers",
+		Subject: "minecraft-0",
+		Time:    time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+		Data: GameServerData{
+			Namespace: "xxx",
+			Name:      "minecraft-0",
+			State:     gameKruiseV1alpha1.Ready,
+			OpsState:  gameKruiseV1alpha1.Allocated,
+		},
+	}
+	if err := NewHTTPSink(server.URL, time.Second).Send(context.TODO(), event); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"Ce-Specversion": "1.0",
+		"Ce-Id":          "4f6b1c2e",
+		"Ce-Type":        GameServerAllocated,
+		"Ce-Source":      "/apis/game.kruise.io/v1alpha1/namespaces/xxx/gameservers",
+		"Ce-Subject":     "minecraft-0",
+		"Ce-Time":        "2024-06-01T12:00:00Z",
+		"Content-Type":   "application/json",
+	}
+	for k, v := range expected {
+		if header.Get(k) != v {
+			t.Errorf("expect header %s %s, but actually got %s", k, v, header.Get(k))
+		}
+	}
+	if !reflect.DeepEqual(data, event.Data) {
+		t.Errorf("expect data %v, but actually got %v", event.Data, data)
+	}
+
+	failed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failed.Close()
+	if err := NewHTTPSink(failed.URL, time.Second).Send(context.TODO(), event); err == nil {
+		t.Errorf("expect error when the sink responds 503")
+	}
+}
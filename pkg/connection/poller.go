@@ -0,0 +1,199 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connection
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+// DefaultInterval is the default interval of polling the connections.
+const DefaultInterval = time.Minute
+
+// Querier queries the cloud monitoring service.
+type Querier interface {
+	// Query returns the sum of the samples of the instant query.
+	Query(ctx context.Context, query string) (float64, error)
+}
+
+type prometheusQuerier struct {
+	api promv1.API
+}
+
+// NewPrometheusQuerier returns the Querier of the Prometheus-compatible query API at the address, which is served
+// by the managed Prometheus of most cloud monitoring services, such as Alibaba Cloud ARMS and Google Cloud Managed
+// Service for Prometheus.
+func NewPrometheusQuerier(address string) (Querier, error) {
+	c, err := api.NewClient(api.Config{Address: address})
+	if err != nil {
+		return nil, err
+	}
+	return &prometheusQuerier{api: promv1.NewAPI(c)}, nil
+}
+
+func (q *prometheusQuerier) Query(ctx context.Context, query string) (float64, error) {
+	value, _, err := q.api.Query(ctx, query, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	switch v := value.(type) {
+	case model.Vector:
+		sum := 0.0
+		for _, sample := range v {
+			sum += float64(sample.Value)
+		}
+		return sum, nil
+	case *model.Scalar:
+		return float64(v.Value), nil
+	}
+	return 0, fmt.Errorf("unsupported result type %s of query %s", value.Type(), query)
+}
+
+// Listener is the variables of the query template, which is one of the external addresses of a GameServer.
+type Listener struct {
+	Namespace      string
+	Name           string
+	IP             string
+	Port           string
+	Protocol       string
+	LoadBalancerId string
+}
+
+// Poller polls the active connections of the listeners of the GameServers periodically, and writes the sum of them
+// to the status of the GameServers.
+type Poller struct {
+	client   client.Client
+	querier  Querier
+	query    *template.Template
+	interval time.Duration
+}
+
+// NewPoller returns a Poller which renders the query template for each listener of the GameServers.
+func NewPoller(c client.Client, querier Querier, query string, interval time.Duration) (*Poller, error) {
+	tmpl, err := template.New("query").Option("missingkey=error").Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query template: %s", err.Error())
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Poller{
+		client:   c,
+		querier:  querier,
+		query:    tmpl,
+		interval: interval,
+	}, nil
+}
+
+// Start implements manager.Runnable.
+func (p *Poller) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, p.poll, p.interval)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that only the leader writes the status.
+func (p *Poller) NeedLeaderElection() bool {
+	return true
+}
+
+func (p *Poller) poll(ctx context.Context) {
+	gsList := &gameKruiseV1alpha1.GameServerList{}
+	if err := p.client.List(ctx, gsList); err != nil {
+		klog.Errorf("failed to list GameServers to poll connections, because of %s.", err.Error())
+		return
+	}
+	for i := range gsList.Items {
+		gs := &gsList.Items[i]
+		if err := p.syncConnections(ctx, gs, metav1.Now()); err != nil {
+			klog.Errorf("failed to sync connections of GameServer %s in %s, because of %s.", gs.GetName(), gs.GetNamespace(), err.Error())
+		}
+	}
+}
+
+// syncConnections patches the connections of the GameServer when its count changes. The GameServers without
+// listeners are skipped.
+func (p *Poller) syncConnections(ctx context.Context, gs *gameKruiseV1alpha1.GameServer, now metav1.Time) error {
+	listeners := getListeners(gs)
+	if len(listeners) == 0 {
+		return nil
+	}
+	count := 0.0
+	for _, listener := range listeners {
+		var query bytes.Buffer
+		if err := p.query.Execute(&query, listener); err != nil {
+			return err
+		}
+		connections, err := p.querier.Query(ctx, query.String())
+		if err != nil {
+			return err
+		}
+		count += connections
+	}
+
+	old := gs.Status.Connections
+	if old != nil && old.Count == int32(count) {
+		return nil
+	}
+	patchStatus := map[string]interface{}{"status": map[string]interface{}{
+		"connections": gameKruiseV1alpha1.ConnectionStatus{
+			Count:              int32(count),
+			LastTransitionTime: now,
+		},
+	}}
+	jsonPatchStatus, err := json.Marshal(patchStatus)
+	if err != nil {
+		return err
+	}
+	return p.client.Status().Patch(ctx, gs, client.RawPatch(types.MergePatchType, jsonPatchStatus))
+}
+
+// getListeners returns the ports of the external addresses of the GameServer. The port ranges are not listeners
+// of load balancers, and are skipped.
+func getListeners(gs *gameKruiseV1alpha1.GameServer) []Listener {
+	var listeners []Listener
+	for _, address := range gs.Status.NetworkStatus.ExternalAddresses {
+		for _, port := range address.Ports {
+			if port.Port == nil {
+				continue
+			}
+			listeners = append(listeners, Listener{
+				Namespace:      gs.GetNamespace(),
+				Name:           gs.GetName(),
+				IP:             address.IP,
+				Port:           port.Port.String(),
+				Protocol:       string(port.Protocol),
+				LoadBalancerId: address.LoadBalancerId,
+			})
+		}
+	}
+	return listeners
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connection
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+type fakeQuerier struct {
+	connections map[string]float64
+}
+
+func (q *fakeQuerier) Query(ctx context.Context, query string) (float64, error) {
+	connections, ok := q.connections[query]
+	if !ok {
+		return 0, fmt.Errorf("unexpected query %s", query)
+	}
+	return connections, nil
+}
+
+func newTestGameServer(addresses []gameKruiseV1alpha1.NetworkAddress) *gameKruiseV1alpha1.GameServer {
+	return &gameKruiseV1alpha1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0"},
+		Status: gameKruiseV1alpha1.GameServerStatus{
+			NetworkStatus: gameKruiseV1alpha1.NetworkStatus{ExternalAddresses: addresses},
+		},
+	}
+}
+
+func TestGetListeners(t *testing.T) {
+	gs := newTestGameServer([]gameKruiseV1alpha1.NetworkAddress{
+		{
+			IP:             "47.0.0.1",
+			LoadBalancerId: "lb-1",
+			Ports: []gameKruiseV1alpha1.NetworkPort{
+				{Name: "game", Protocol: corev1.ProtocolUDP, Port: ptr.To(intstr.FromInt(7000))},
+				{Name: "unready"},
+			},
+		},
+		{
+			IP:        "47.0.0.2",
+			PortRange: &gameKruiseV1alpha1.NetworkPortRange{PortRange: "8000-8003"},
+		},
+	})
+	expect := []Listener{
+		{Namespace: "xxx", Name: "case-0", IP: "47.0.0.1", Port: "7000", Protocol: "UDP", LoadBalancerId: "lb-1"},
+	}
+	if actual := getListeners(gs); !reflect.DeepEqual(actual, expect) {
+		t.Errorf("expect listeners %v, but actually got %v", expect, actual)
+	}
+}
+
+func TestSyncConnections(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gameKruiseV1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	addresses := []gameKruiseV1alpha1.NetworkAddress{
+		{
+			IP:             "47.0.0.1",
+			LoadBalancerId: "lb-1",
+			Ports: []gameKruiseV1alpha1.NetworkPort{
+				{Name: "game", Protocol: corev1.ProtocolUDP, Port: ptr.To(intstr.FromInt(7000))},
+				{Name: "chat", Protocol: corev1.ProtocolTCP, Port: ptr.To(intstr.FromInt(7001))},
+			},
+		},
+	}
+	query := `sum(active_connection{instance="{{.LoadBalancerId}}",port="{{.Port}}"})`
+	before := metav1.NewTime(time.Date(2024, 6, 15, 4, 0, 0, 0, time.UTC))
+	now := metav1.NewTime(before.Add(time.Minute))
+
+	tests := []struct {
+		addresses   []gameKruiseV1alpha1.NetworkAddress
+		old         *gameKruiseV1alpha1.ConnectionStatus
+		connections map[string]float64
+		expect      *gameKruiseV1alpha1.ConnectionStatus
+	}{
+		{
+			addresses: nil,
+			expect:    nil,
+		},
+		{
+			addresses: addresses,
+			connections: map[string]float64{
+				`sum(active_connection{instance="lb-1",port="7000"})`: 3,
+				`sum(active_connection{instance="lb-1",port="7001"})`: 2,
+			},
+			expect: &gameKruiseV1alpha1.ConnectionStatus{Count: 5, LastTransitionTime: now},
+		},
+		{
+			addresses: addresses,
+			old:       &gameKruiseV1alpha1.ConnectionStatus{Count: 0, LastTransitionTime: before},
+			connections: map[string]float64{
+				`sum(active_connection{instance="lb-1",port="7000"})`: 0,
+				`sum(active_connection{instance="lb-1",port="7001"})`: 0,
+			},
+			expect: &gameKruiseV1alpha1.ConnectionStatus{Count: 0, LastTransitionTime: before},
+		},
+		{
+			addresses: addresses,
+			old:       &gameKruiseV1alpha1.ConnectionStatus{Count: 5, LastTransitionTime: before},
+			connections: map[string]float64{
+				`sum(active_connection{instance="lb-1",port="7000"})`: 0,
+				`sum(active_connection{instance="lb-1",port="7001"})`: 0,
+			},
+			expect: &gameKruiseV1alpha1.ConnectionStatus{Count: 0, LastTransitionTime: now},
+		},
+	}
+
+	for i, test := range tests {
+		gs := newTestGameServer(test.addresses)
+		gs.Status.Connections = test.old
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gs).Build()
+		p, err := NewPoller(c, &fakeQuerier{connections: test.connections}, query, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := p.syncConnections(context.TODO(), gs, now); err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		actual := &gameKruiseV1alpha1.GameServer{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: "case-0"}, actual); err != nil {
+			t.Fatal(err)
+		}
+		connections := actual.Status.Connections
+		if (connections == nil) != (test.expect == nil) ||
+			(connections != nil && (connections.Count != test.expect.Count || !connections.LastTransitionTime.Equal(&test.expect.LastTransitionTime))) {
+			t.Errorf("case %d: expect connections %v, but actually got %v", i, test.expect, connections)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	utildiscovery "github.com/openkruise/kruise-game/pkg/util/discovery"
+)
+
+const (
+	ScaledUpReason   = "IdleBufferScaledUp"
+	ScaledDownReason = "IdleBufferScaledDown"
+)
+
+var (
+	controllerKind       = gamekruiseiov1alpha1.SchemeGroupVersion.WithKind("GameServerSet")
+	concurrentReconciles = 2
+)
+
+func Add(mgr manager.Manager) error {
+	if !utildiscovery.DiscoverGVK(controllerKind) {
+		return nil
+	}
+	return add(mgr, newReconciler(mgr))
+}
+
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	recorder := mgr.GetEventRecorderFor("autoscaler-controller")
+	return &AutoscalerReconciler{
+		Client:   mgr.GetClient(),
+		recorder: recorder,
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	klog.Info("Starting Autoscaler Controller")
+	c, err := controller.New("autoscaler-controller", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: concurrentReconciles})
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	if err = c.Watch(&source.Kind{Type: &gamekruiseiov1alpha1.GameServerSet{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	// watch the pods whose opsState changes when the GameServers are allocated or released
+	if err = c.Watch(&source.Kind{Type: &corev1.Pod{}}, handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		gssName, exist := obj.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]
+		if !exist {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: gssName}}}
+	})); err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// AutoscalerReconciler resizes the GameServerSets with ScalingPolicy to keep a buffer of idle GameServers.
+type AutoscalerReconciler struct {
+	client.Client
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=game.kruise.io,resources=gameserversets,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+
+// Reconcile scales the GameServerSet up once the idle GameServers are fewer than MinIdle, and scales it down once
+// they are more than MaxIdle and the cooldown since the last scaling is over. The active schedule overrides MinReplicas.
+func (r *AutoscalerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	gss := &gamekruiseiov1alpha1.GameServerSet{}
+	err := r.Get(ctx, req.NamespacedName, gss)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		klog.Errorf("failed to find GameServerSet %s in %s, because of %s.", req.Name, req.Namespace, err.Error())
+		return reconcile.Result{}, err
+	}
+	policy := gss.Spec.ScalingPolicy
+	if policy == nil || gss.GetDeletionTimestamp() != nil || gss.Spec.Replicas == nil {
+		return reconcile.Result{}, nil
+	}
+
+	podList := &corev1.PodList{}
+	err = r.List(ctx, podList, client.InNamespace(gss.GetNamespace()), client.MatchingLabels{gamekruiseiov1alpha1.GameServerOwnerGssKey: gss.GetName()})
+	if err != nil {
+		klog.Errorf("failed to list pods of GameServerSet %s in %s, because of %s.", gss.GetName(), gss.GetNamespace(), err.Error())
+		return reconcile.Result{}, err
+	}
+
+	now := time.Now()
+	var minReplicas int32
+	if policy.MinReplicas != nil {
+		minReplicas = *policy.MinReplicas
+	}
+	// requeue when the next schedule takes effect
+	active, nextSchedule := getActiveSchedule(policy.Schedules, now)
+	if active != nil {
+		minReplicas = active.replicas
+	}
+
+	current := *gss.Spec.Replicas
+	desired, idle := computeReplicas(policy, minReplicas, current, podList.Items)
+	if desired == current {
+		return reconcile.Result{RequeueAfter: nextSchedule}, nil
+	}
+
+	if desired < current {
+		if remaining := cooldownRemaining(gss, now); remaining > 0 {
+			return reconcile.Result{RequeueAfter: remaining}, nil
+		}
+	}
+
+	// the resourceVersion rejects the patch if the replicas are changed since the GameServerSet is read
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": gss.GetResourceVersion(),
+			"annotations":     map[string]string{gamekruiseiov1alpha1.LastAutoscaleTimeKey: now.Format(time.RFC3339)},
+		},
+		"spec": map[string]interface{}{"replicas": desired},
+	}
+	patchBytes, _ := json.Marshal(patch)
+	if err := r.Patch(ctx, gss, client.RawPatch(types.MergePatchType, patchBytes)); err != nil {
+		if errors.IsConflict(err) {
+			return reconcile.Result{Requeue: true}, nil
+		}
+		klog.Errorf("failed to scale GameServerSet %s in %s, because of %s.", gss.GetName(), gss.GetNamespace(), err.Error())
+		return reconcile.Result{}, err
+	}
+
+	reason := ScaledUpReason
+	if desired < current {
+		reason = ScaledDownReason
+	}
+	msg := fmt.Sprintf("scaled from %d to %d replicas with %d idle GameServers", current, desired, idle)
+	if active != nil {
+		msg += fmt.Sprintf(", while schedule %s of at least %d replicas is active", active.name, active.replicas)
+	}
+	klog.Infof("GameServerSet %s in %s is %s", gss.GetName(), gss.GetNamespace(), msg)
+	r.recorder.Event(gss, corev1.EventTypeNormal, reason, msg)
+	return reconcile.Result{RequeueAfter: nextSchedule}, nil
+}
+
+// computeReplicas returns the replicas keeping the idle GameServers between MinIdle and MaxIdle within minReplicas
+// and MaxReplicas, and the current number of idle GameServers. The GameServers which are not created yet are regarded
+// as idle, and the ones whose opsState is not None are regarded as busy.
+func computeReplicas(policy *gamekruiseiov1alpha1.ScalingPolicy, minReplicas, replicas int32, pods []corev1.Pod) (int32, int32) {
+	var busy int32
+	for _, pod := range pods {
+		if pod.GetDeletionTimestamp() != nil {
+			continue
+		}
+		opsState := pod.GetLabels()[gamekruiseiov1alpha1.GameServerOpsStateKey]
+		if opsState != "" && opsState != string(gamekruiseiov1alpha1.None) {
+			busy++
+		}
+	}
+	idle := replicas - busy
+	if idle < 0 {
+		idle = 0
+	}
+
+	maxIdle := policy.MinIdle
+	if policy.MaxIdle != nil {
+		maxIdle = *policy.MaxIdle
+	}
+	desired := replicas
+	if idle < policy.MinIdle {
+		desired = busy + policy.MinIdle
+	} else if idle > maxIdle {
+		desired = busy + maxIdle
+	}
+
+	if desired < minReplicas {
+		desired = minReplicas
+	}
+	if desired > policy.MaxReplicas {
+		desired = policy.MaxReplicas
+	}
+	return desired, idle
+}
+
+// cooldownRemaining returns how long the GameServerSet is not allowed to be scaled down.
+func cooldownRemaining(gss *gamekruiseiov1alpha1.GameServerSet, now time.Time) time.Duration {
+	lastTime, err := time.Parse(time.RFC3339, gss.GetAnnotations()[gamekruiseiov1alpha1.LastAutoscaleTimeKey])
+	if err != nil {
+		return 0
+	}
+	cooldown := time.Duration(gamekruiseiov1alpha1.DefaultScalingCooldownSeconds) * time.Second
+	if seconds := gss.Spec.ScalingPolicy.CooldownSeconds; seconds != nil {
+		cooldown = time.Duration(*seconds) * time.Second
+	}
+	return lastTime.Add(cooldown).Sub(now)
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+var (
+	scheme = runtime.NewScheme()
+)
+
+func init() {
+	utilruntime.Must(gameKruiseV1alpha1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+}
+
+func newPods(busy, idle int) []corev1.Pod {
+	var pods []corev1.Pod
+	for i := 0; i < busy+idle; i++ {
+		opsState := gameKruiseV1alpha1.None
+		if i < busy {
+			opsState = gameKruiseV1alpha1.Allocated
+		}
+		pods = append(pods, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      "foo-" + strconv.Itoa(i),
+				Labels: map[string]string{
+					gameKruiseV1alpha1.GameServerOwnerGssKey: "foo",
+					gameKruiseV1alpha1.GameServerOpsStateKey: string(opsState),
+				},
+			},
+		})
+	}
+	return pods
+}
+
+func TestComputeReplicas(t *testing.T) {
+	tests := []struct {
+		policy      *gameKruiseV1alpha1.ScalingPolicy
+		minReplicas int32
+		replicas    int32
+		pods        []corev1.Pod
+		desired     int32
+		idle        int32
+	}{
+		// scale up to keep 3 idle
+		{
+			policy:   &gameKruiseV1alpha1.ScalingPolicy{MinIdle: 3, MaxReplicas: 100},
+			replicas: 5,
+			pods:     newPods(4, 1),
+			desired:  7,
+			idle:     1,
+		},
+		// the pods not created yet are idle
+		{
+			policy:   &gameKruiseV1alpha1.ScalingPolicy{MinIdle: 3, MaxReplicas: 100},
+			replicas: 7,
+			pods:     newPods(4, 1),
+			desired:  7,
+			idle:     3,
+		},
+		// within minIdle and maxIdle
+		{
+			policy:   &gameKruiseV1alpha1.ScalingPolicy{MinIdle: 3, MaxIdle: ptr.To[int32](6), MaxReplicas: 100},
+			replicas: 10,
+			pods:     newPods(5, 5),
+			desired:  10,
+			idle:     5,
+		},
+		// scale down to maxIdle
+		{
+			policy:   &gameKruiseV1alpha1.ScalingPolicy{MinIdle: 3, MaxIdle: ptr.To[int32](6), MaxReplicas: 100},
+			replicas: 12,
+			pods:     newPods(2, 10),
+			desired:  8,
+			idle:     10,
+		},
+		// capped by maxReplicas
+		{
+			policy:   &gameKruiseV1alpha1.ScalingPolicy{MinIdle: 3, MaxReplicas: 6},
+			replicas: 5,
+			pods:     newPods(5, 0),
+			desired:  6,
+			idle:     0,
+		},
+		// capped by minReplicas
+		{
+			policy:      &gameKruiseV1alpha1.ScalingPolicy{MinIdle: 1, MaxReplicas: 10},
+			minReplicas: 4,
+			replicas:    5,
+			pods:        newPods(0, 5),
+			desired:     4,
+			idle:        5,
+		},
+	}
+
+	for i, test := range tests {
+		desired, idle := computeReplicas(test.policy, test.minReplicas, test.replicas, test.pods)
+		if desired != test.desired || idle != test.idle {
+			t.Errorf("case %d: expect desired %d with %d idle, but got %d with %d idle", i, test.desired, test.idle, desired, idle)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		replicas     int32
+		pods         []corev1.Pod
+		lastScale    time.Duration
+		expected     int32
+		requeueAfter bool
+	}{
+		// scale up regardless of the cooldown
+		{
+			replicas:  5,
+			pods:      newPods(5, 0),
+			lastScale: 10 * time.Second,
+			expected:  7,
+		},
+		// scale down is delayed by the cooldown
+		{
+			replicas:     10,
+			pods:         newPods(2, 8),
+			lastScale:    10 * time.Second,
+			expected:     10,
+			requeueAfter: true,
+		},
+		// scale down after the cooldown
+		{
+			replicas:  10,
+			pods:      newPods(2, 8),
+			lastScale: 2 * time.Minute,
+			expected:  6,
+		},
+	}
+
+	for i, test := range tests {
+		gss := &gameKruiseV1alpha1.GameServerSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "xxx",
+				Name:        "foo",
+				Annotations: map[string]string{gameKruiseV1alpha1.LastAutoscaleTimeKey: time.Now().Add(-test.lastScale).Format(time.RFC3339)},
+			},
+			Spec: gameKruiseV1alpha1.GameServerSetSpec{
+				Replicas:      ptr.To(test.replicas),
+				ScalingPolicy: &gameKruiseV1alpha1.ScalingPolicy{MinIdle: 2, MaxIdle: ptr.To[int32](4), MaxReplicas: 20},
+			},
+		}
+		objs := []client.Object{gss}
+		for j := range test.pods {
+			objs = append(objs, &test.pods[j])
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+		r := &AutoscalerReconciler{Client: c, recorder: record.NewFakeRecorder(10)}
+
+		result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "xxx", Name: "foo"}})
+		if err != nil {
+			t.Errorf("case %d: unexpected error %s", i, err.Error())
+			continue
+		}
+		if (result.RequeueAfter > 0) != test.requeueAfter {
+			t.Errorf("case %d: expect requeueAfter %v, but got %v", i, test.requeueAfter, result.RequeueAfter)
+		}
+		newGss := &gameKruiseV1alpha1.GameServerSet{}
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "xxx", Name: "foo"}, newGss); err != nil {
+			t.Fatal(err)
+		}
+		if *newGss.Spec.Replicas != test.expected {
+			t.Errorf("case %d: expect replicas %d, but got %d", i, test.expected, *newGss.Spec.Replicas)
+		}
+	}
+}
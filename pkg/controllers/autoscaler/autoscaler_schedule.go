@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"time"
+
+	"k8s.io/klog/v2"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+// scheduleLookback is how long a schedule stays active at most, if no other schedule takes effect after it.
+const scheduleLookback = 31 * 24 * time.Hour
+
+// activeSchedule is the schedule which took effect last.
+type activeSchedule struct {
+	name     string
+	replicas int32
+	time     time.Time
+}
+
+// getActiveSchedule returns the schedule which took effect last within the lookback, or nil if none, and the
+// duration until the next schedule takes effect, which is 0 if none. The schedule with more replicas wins if several
+// take effect at the same time. The schedules with invalid cron or time zone are skipped.
+func getActiveSchedule(schedules []gamekruiseiov1alpha1.ScalingSchedule, now time.Time) (*activeSchedule, time.Duration) {
+	var active *activeSchedule
+	var requeueAfter time.Duration
+	for _, s := range schedules {
+		schedule, err := util.ParseCron(s.Cron)
+		if err != nil {
+			klog.Warningf("skip scaling schedule %s, because of %s", s.Name, err.Error())
+			continue
+		}
+		loc, err := time.LoadLocation(s.TimeZone)
+		if err != nil {
+			klog.Warningf("skip scaling schedule %s, because of invalid time zone %s", s.Name, s.TimeZone)
+			continue
+		}
+
+		localNow := now.In(loc)
+		if next := schedule.Next(localNow); !next.IsZero() {
+			if d := next.Sub(localNow); requeueAfter == 0 || d < requeueAfter {
+				requeueAfter = d
+			}
+		}
+		latest := schedule.Latest(localNow, scheduleLookback)
+		if latest.IsZero() {
+			continue
+		}
+		if active == nil || latest.After(active.time) || (latest.Equal(active.time) && s.Replicas > active.replicas) {
+			active = &activeSchedule{name: s.Name, replicas: s.Replicas, time: latest}
+		}
+	}
+	return active, requeueAfter
+}
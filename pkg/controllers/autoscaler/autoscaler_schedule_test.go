@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autoscaler
+
+import (
+	"testing"
+	"time"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestGetActiveSchedule(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	peak := gameKruiseV1alpha1.ScalingSchedule{Name: "peak", Cron: "0 18 * * 5", TimeZone: "Asia/Shanghai", Replicas: 500}
+	offPeak := gameKruiseV1alpha1.ScalingSchedule{Name: "off-peak", Cron: "0 2 * * *", TimeZone: "Asia/Shanghai", Replicas: 100}
+
+	tests := []struct {
+		schedules    []gameKruiseV1alpha1.ScalingSchedule
+		now          time.Time
+		expected     string
+		requeueAfter time.Duration
+	}{
+		// no schedules
+		{
+			now: time.Date(2024, 6, 7, 19, 0, 0, 0, shanghai),
+		},
+		// the peak takes effect after the off-peak on Friday
+		{
+			schedules:    []gameKruiseV1alpha1.ScalingSchedule{peak, offPeak},
+			now:          time.Date(2024, 6, 7, 19, 0, 0, 0, shanghai),
+			expected:     "peak",
+			requeueAfter: 7 * time.Hour,
+		},
+		// back to the off-peak on Saturday
+		{
+			schedules:    []gameKruiseV1alpha1.ScalingSchedule{peak, offPeak},
+			now:          time.Date(2024, 6, 8, 3, 0, 0, 0, shanghai),
+			expected:     "off-peak",
+			requeueAfter: 23 * time.Hour,
+		},
+		// the peak in UTC has not taken effect this Friday
+		{
+			schedules: []gameKruiseV1alpha1.ScalingSchedule{
+				{Name: "peak", Cron: "0 18 * * 5", Replicas: 500},
+				{Name: "off-peak", Cron: "0 2 * * *", Replicas: 100},
+			},
+			now:          time.Date(2024, 6, 7, 19, 0, 0, 0, shanghai),
+			expected:     "off-peak",
+			requeueAfter: 7 * time.Hour,
+		},
+		// the schedule with more replicas wins at the same time, and the invalid one is skipped
+		{
+			schedules: []gameKruiseV1alpha1.ScalingSchedule{
+				offPeak,
+				{Name: "larger", Cron: "0 2 * * *", TimeZone: "Asia/Shanghai", Replicas: 300},
+				{Name: "invalid", Cron: "0 2 * *", Replicas: 1000},
+			},
+			now:          time.Date(2024, 6, 8, 3, 0, 0, 0, shanghai),
+			expected:     "larger",
+			requeueAfter: 23 * time.Hour,
+		},
+	}
+
+	for i, test := range tests {
+		active, requeueAfter := getActiveSchedule(test.schedules, test.now)
+		name := ""
+		if active != nil {
+			name = active.name
+		}
+		if name != test.expected {
+			t.Errorf("case %d: expect active schedule %q, but got %q", i, test.expected, name)
+		}
+		if requeueAfter != test.requeueAfter {
+			t.Errorf("case %d: expect requeue after %v, but got %v", i, test.requeueAfter, requeueAfter)
+		}
+	}
+}
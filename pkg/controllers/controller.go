@@ -18,8 +18,13 @@ package controller
 
 import (
 	"context"
+	"github.com/openkruise/kruise-game/pkg/client/helpers"
+	"github.com/openkruise/kruise-game/pkg/controllers/autoscaler"
+	"github.com/openkruise/kruise-game/pkg/controllers/externalloadbalancer"
+	"github.com/openkruise/kruise-game/pkg/controllers/gameserverallocation"
 	"github.com/openkruise/kruise-game/pkg/controllers/gameserver"
 	"github.com/openkruise/kruise-game/pkg/controllers/gameserverset"
+	"github.com/openkruise/kruise-game/pkg/controllers/nodepoolupgrade"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/klog/v2"
@@ -32,6 +37,10 @@ var controllerAddFuncs []func(manager.Manager) error
 func init() {
 	controllerAddFuncs = append(controllerAddFuncs, gameserver.Add)
 	controllerAddFuncs = append(controllerAddFuncs, gameserverset.Add)
+	controllerAddFuncs = append(controllerAddFuncs, externalloadbalancer.Add)
+	controllerAddFuncs = append(controllerAddFuncs, gameserverallocation.Add)
+	controllerAddFuncs = append(controllerAddFuncs, autoscaler.Add)
+	controllerAddFuncs = append(controllerAddFuncs, nodepoolupgrade.Add)
 }
 
 func SetupWithManager(m manager.Manager) error {
@@ -41,6 +50,9 @@ func SetupWithManager(m manager.Manager) error {
 	}); err != nil {
 		return err
 	}
+	if err := helpers.IndexGameServers(context.Background(), m.GetFieldIndexer()); err != nil {
+		return err
+	}
 
 	for _, f := range controllerAddFuncs {
 		if err := f(m); err != nil {
@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalloadbalancer
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	utildiscovery "github.com/openkruise/kruise-game/pkg/util/discovery"
+)
+
+var (
+	controllerKind       = gamekruiseiov1alpha1.SchemeGroupVersion.WithKind("ExternalLoadBalancer")
+	concurrentReconciles = 2
+)
+
+func Add(mgr manager.Manager) error {
+	if !utildiscovery.DiscoverGVK(controllerKind) {
+		return nil
+	}
+	return add(mgr, newReconciler(mgr))
+}
+
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ExternalLoadBalancerReconciler{
+		Client: mgr.GetClient(),
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	klog.Info("Starting ExternalLoadBalancer Controller")
+	c, err := controller.New("externalloadbalancer-controller", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: concurrentReconciles})
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	if err = c.Watch(&source.Kind{Type: &gamekruiseiov1alpha1.ExternalLoadBalancer{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	// watch the services allocating listeners from ExternalLoadBalancers
+	if err = c.Watch(&source.Kind{Type: &corev1.Service{}}, handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		elbName, exist := obj.GetLabels()[gamekruiseiov1alpha1.ExternalLoadBalancerLabelKey]
+		if !exist {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: elbName}}}
+	})); err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ExternalLoadBalancerReconciler reconciles an ExternalLoadBalancer object
+type ExternalLoadBalancerReconciler struct {
+	client.Client
+}
+
+//+kubebuilder:rbac:groups=game.kruise.io,resources=externalloadbalancers,verbs=get;list;watch
+//+kubebuilder:rbac:groups=game.kruise.io,resources=externalloadbalancers/status,verbs=get;update;patch
+
+// Reconcile reports the remaining port capacity of the ExternalLoadBalancer,
+// which is computed by the listener ports of services labeled with its name.
+func (r *ExternalLoadBalancerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	elb := &gamekruiseiov1alpha1.ExternalLoadBalancer{}
+	err := r.Get(ctx, req.NamespacedName, elb)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		klog.Errorf("failed to find ExternalLoadBalancer %s, because of %s.", req.Name, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	svcList := &corev1.ServiceList{}
+	err = r.List(ctx, svcList, client.MatchingLabels{gamekruiseiov1alpha1.ExternalLoadBalancerLabelKey: elb.GetName()})
+	if err != nil {
+		klog.Errorf("failed to list services of ExternalLoadBalancer %s, because of %s.", elb.GetName(), err.Error())
+		return reconcile.Result{}, err
+	}
+
+	newStatus := computeStatus(elb, svcList.Items)
+	if newStatus == elb.Status {
+		return reconcile.Result{}, nil
+	}
+	elb.Status = newStatus
+	if err := r.Status().Update(ctx, elb); err != nil {
+		klog.Errorf("failed to update status of ExternalLoadBalancer %s, because of %s.", elb.GetName(), err.Error())
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+func computeStatus(elb *gamekruiseiov1alpha1.ExternalLoadBalancer, svcs []corev1.Service) gamekruiseiov1alpha1.ExternalLoadBalancerStatus {
+	var allocated int32
+	for _, svc := range svcs {
+		if svc.Spec.Type != corev1.ServiceTypeLoadBalancer || svc.GetDeletionTimestamp() != nil {
+			continue
+		}
+		// a TCPUDP port is served by a TCP port and a UDP port of the same number, and allocated once
+		ports := make(map[int32]struct{}, len(svc.Spec.Ports))
+		for _, port := range svc.Spec.Ports {
+			ports[port.Port] = struct{}{}
+		}
+		allocated += int32(len(ports))
+	}
+	remaining := elb.Spec.PortBudget - allocated
+	if remaining < 0 {
+		remaining = 0
+	}
+	return gamekruiseiov1alpha1.ExternalLoadBalancerStatus{
+		ObservedGeneration: elb.GetGeneration(),
+		AllocatedPorts:     allocated,
+		RemainingPorts:     remaining,
+	}
+}
@@ -0,0 +1,175 @@
+package externalloadbalancer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var (
+	scheme = runtime.NewScheme()
+)
+
+func init() {
+	utilruntime.Must(gameKruiseV1alpha1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+}
+
+func TestComputeStatus(t *testing.T) {
+	elb := &gameKruiseV1alpha1.ExternalLoadBalancer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "elb-a",
+			Generation: 2,
+		},
+		Spec: gameKruiseV1alpha1.ExternalLoadBalancerSpec{
+			ID:         "lb-a",
+			PortBudget: 5,
+		},
+	}
+	deletionTime := metav1.NewTime(time.Now())
+
+	tests := []struct {
+		svcs   []corev1.Service
+		status gameKruiseV1alpha1.ExternalLoadBalancerStatus
+	}{
+		{
+			svcs: nil,
+			status: gameKruiseV1alpha1.ExternalLoadBalancerStatus{
+				ObservedGeneration: 2,
+				AllocatedPorts:     0,
+				RemainingPorts:     5,
+			},
+		},
+		{
+			svcs: []corev1.Service{
+				{
+					Spec: corev1.ServiceSpec{
+						Type:  corev1.ServiceTypeLoadBalancer,
+						Ports: []corev1.ServicePort{{Port: 501}, {Port: 502}},
+					},
+				},
+				{
+					Spec: corev1.ServiceSpec{
+						Type:  corev1.ServiceTypeClusterIP,
+						Ports: []corev1.ServicePort{{Port: 503}},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						DeletionTimestamp: &deletionTime,
+					},
+					Spec: corev1.ServiceSpec{
+						Type:  corev1.ServiceTypeLoadBalancer,
+						Ports: []corev1.ServicePort{{Port: 504}},
+					},
+				},
+			},
+			status: gameKruiseV1alpha1.ExternalLoadBalancerStatus{
+				ObservedGeneration: 2,
+				AllocatedPorts:     2,
+				RemainingPorts:     3,
+			},
+		},
+		{
+			svcs: []corev1.Service{
+				{
+					Spec: corev1.ServiceSpec{
+						Type:  corev1.ServiceTypeLoadBalancer,
+						Ports: []corev1.ServicePort{{Port: 501}, {Port: 502}, {Port: 503}, {Port: 504}, {Port: 505}, {Port: 506}},
+					},
+				},
+			},
+			status: gameKruiseV1alpha1.ExternalLoadBalancerStatus{
+				ObservedGeneration: 2,
+				AllocatedPorts:     6,
+				RemainingPorts:     0,
+			},
+		},
+		// the TCP and UDP ports of a TCPUDP port
+		{
+			svcs: []corev1.Service{
+				{
+					Spec: corev1.ServiceSpec{
+						Type: corev1.ServiceTypeLoadBalancer,
+						Ports: []corev1.ServicePort{
+							{Port: 501, Protocol: corev1.ProtocolTCP},
+							{Port: 501, Protocol: corev1.ProtocolUDP},
+							{Port: 502, Protocol: corev1.ProtocolUDP},
+						},
+					},
+				},
+			},
+			status: gameKruiseV1alpha1.ExternalLoadBalancerStatus{
+				ObservedGeneration: 2,
+				AllocatedPorts:     2,
+				RemainingPorts:     3,
+			},
+		},
+	}
+
+	for i, test := range tests {
+		actual := computeStatus(elb, test.svcs)
+		if actual != test.status {
+			t.Errorf("case %d: expect status %v, but actually got %v", i, test.status, actual)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	elb := &gameKruiseV1alpha1.ExternalLoadBalancer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "elb-a",
+		},
+		Spec: gameKruiseV1alpha1.ExternalLoadBalancerSpec{
+			ID:         "lb-a",
+			PortBudget: 10,
+		},
+	}
+	svcs := []*corev1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      "svc-0",
+				Labels:    map[string]string{gameKruiseV1alpha1.ExternalLoadBalancerLabelKey: "elb-a"},
+			},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Port: 501}, {Port: 502}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      "svc-1",
+				Labels:    map[string]string{gameKruiseV1alpha1.ExternalLoadBalancerLabelKey: "elb-b"},
+			},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{{Port: 501}},
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(elb, svcs[0], svcs[1]).Build()
+	r := &ExternalLoadBalancerReconciler{Client: c}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "elb-a"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	actual := &gameKruiseV1alpha1.ExternalLoadBalancer{}
+	if err := c.Get(context.Background(), types.NamespacedName{Name: "elb-a"}, actual); err != nil {
+		t.Fatal(err)
+	}
+	if actual.Status.AllocatedPorts != 2 || actual.Status.RemainingPorts != 8 {
+		t.Errorf("expect allocated 2 and remaining 8, but actually got %v", actual.Status)
+	}
+}
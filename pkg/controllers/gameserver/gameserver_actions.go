@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserver
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+// applyActionItems applies the items of a ServiceQualityAction executed to the spec and the metadata. The items
+// whose values cannot be rendered, such as a field missing from the result, are skipped.
+func applyActionItems(items []gameKruiseV1alpha1.ServiceQualityActionItem, data util.ServiceQualityActionData, spec *gameKruiseV1alpha1.GameServerSpec, metadata *metav1.ObjectMeta) {
+	for _, item := range items {
+		if err := applyActionItem(item, data, spec, metadata); err != nil {
+			klog.Warningf("skip the %s action of result %q, because of %s", item.Type, data.Result, err.Error())
+		}
+	}
+}
+
+func applyActionItem(item gameKruiseV1alpha1.ServiceQualityActionItem, data util.ServiceQualityActionData, spec *gameKruiseV1alpha1.GameServerSpec, metadata *metav1.ObjectMeta) error {
+	switch item.Type {
+	case gameKruiseV1alpha1.SetOpsStateActionType:
+		value, err := util.RenderServiceQualityActionValue(item.Value, data)
+		if err != nil {
+			return err
+		}
+		spec.OpsState = gameKruiseV1alpha1.OpsState(value)
+	case gameKruiseV1alpha1.SetUpdatePriorityActionType, gameKruiseV1alpha1.SetDeletionPriorityActionType:
+		value, err := util.RenderServiceQualityActionValue(item.Value, data)
+		if err != nil {
+			return err
+		}
+		priority := intstr.Parse(value)
+		if priority.Type != intstr.Int {
+			return fmt.Errorf("priority %q is not an integer", value)
+		}
+		if item.Type == gameKruiseV1alpha1.SetUpdatePriorityActionType {
+			spec.UpdatePriority = &priority
+		} else {
+			spec.DeletionPriority = &priority
+		}
+	case gameKruiseV1alpha1.PatchLabelsActionType:
+		values, err := renderActionValues(item.Values, data, true)
+		if err != nil {
+			return err
+		}
+		metadata.Labels = mergeStringMap(metadata.Labels, values)
+	case gameKruiseV1alpha1.PatchAnnotationsActionType:
+		values, err := renderActionValues(item.Values, data, false)
+		if err != nil {
+			return err
+		}
+		metadata.Annotations = mergeStringMap(metadata.Annotations, values)
+	default:
+		return fmt.Errorf("unknown action type")
+	}
+	return nil
+}
+
+// renderActionValues renders all the values, or none of them if any of them fails.
+func renderActionValues(values map[string]string, data util.ServiceQualityActionData, isLabel bool) (map[string]string, error) {
+	rendered := make(map[string]string, len(values))
+	for key, value := range values {
+		v, err := util.RenderServiceQualityActionValue(value, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", key, err.Error())
+		}
+		if isLabel {
+			if errs := validation.IsValidLabelValue(v); len(errs) != 0 {
+				return nil, fmt.Errorf("%s: %s", key, strings.Join(errs, ", "))
+			}
+		}
+		rendered[key] = v
+	}
+	return rendered, nil
+}
+
+func mergeStringMap(dst, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]string, len(src))
+	}
+	for key, value := range src {
+		dst[key] = value
+	}
+	return dst
+}
+
+// isActionMetadataChanged returns true if the labels or annotations set by the actions differ from the GameServer.
+func isActionMetadataChanged(metadata metav1.ObjectMeta, gs *gameKruiseV1alpha1.GameServer) bool {
+	for key, value := range metadata.Labels {
+		if current, exist := gs.GetLabels()[key]; !exist || current != value {
+			return true
+		}
+	}
+	for key, value := range metadata.Annotations {
+		if current, exist := gs.GetAnnotations()[key]; !exist || current != value {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeActionMetadata returns the metadata synced from the GameServerSet with the labels and annotations set by the
+// actions, without changing the maps of the GameServerSet.
+func mergeActionMetadata(gsMetadata, actionMetadata metav1.ObjectMeta) metav1.ObjectMeta {
+	if len(actionMetadata.Labels) != 0 {
+		gsMetadata.Labels = mergeStringMap(mergeStringMap(nil, gsMetadata.Labels), actionMetadata.Labels)
+	}
+	if len(actionMetadata.Annotations) != 0 {
+		gsMetadata.Annotations = mergeStringMap(mergeStringMap(nil, gsMetadata.Annotations), actionMetadata.Annotations)
+	}
+	return gsMetadata
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserver
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+func TestSyncServiceQualitiesWithActionItems(t *testing.T) {
+	sq := gameKruiseV1alpha1.ServiceQuality{
+		Name: "players",
+		ServiceQualityAction: []gameKruiseV1alpha1.ServiceQualityAction{
+			{
+				State: true,
+				Actions: []gameKruiseV1alpha1.ServiceQualityActionItem{
+					{Type: gameKruiseV1alpha1.SetOpsStateActionType, Value: "Allocated"},
+					{Type: gameKruiseV1alpha1.SetDeletionPriorityActionType, Value: "{{ .Fields.players }}"},
+					{Type: gameKruiseV1alpha1.PatchLabelsActionType, Values: map[string]string{"map": "{{ .Fields.map }}"}},
+					{Type: gameKruiseV1alpha1.PatchAnnotationsActionType, Values: map[string]string{"example.com/probe": "{{ .Result }}"}},
+				},
+			},
+		},
+	}
+	probe := func(message string) []corev1.PodCondition {
+		return []corev1.PodCondition{{
+			Type:    corev1.PodConditionType(util.AddPrefixGameKruise("players")),
+			Status:  corev1.ConditionTrue,
+			Message: message,
+		}}
+	}
+	three := intstr.FromInt(3)
+
+	tests := []struct {
+		message  string
+		spec     gameKruiseV1alpha1.GameServerSpec
+		metadata metav1.ObjectMeta
+	}{
+		{
+			message: "players=3\nmap=desert",
+			spec:    gameKruiseV1alpha1.GameServerSpec{OpsState: gameKruiseV1alpha1.Allocated, DeletionPriority: &three},
+			metadata: metav1.ObjectMeta{
+				Labels:      map[string]string{"map": "desert"},
+				Annotations: map[string]string{"example.com/probe": "players=3map=desert"},
+			},
+		},
+		// the actions of the fields missing are skipped
+		{
+			message:  "players=3",
+			spec:     gameKruiseV1alpha1.GameServerSpec{OpsState: gameKruiseV1alpha1.Allocated, DeletionPriority: &three},
+			metadata: metav1.ObjectMeta{Annotations: map[string]string{"example.com/probe": "players=3"}},
+		},
+		// the invalid priorities and label values are skipped
+		{
+			message:  "players=many,map=desert island",
+			spec:     gameKruiseV1alpha1.GameServerSpec{OpsState: gameKruiseV1alpha1.Allocated},
+			metadata: metav1.ObjectMeta{Labels: map[string]string{"map": "desert"}, Annotations: map[string]string{"example.com/probe": "players=many,map=desert island"}},
+		},
+	}
+	for i, test := range tests {
+		spec, metadata, _ := syncServiceQualities([]gameKruiseV1alpha1.ServiceQuality{sq}, probe(test.message), nil)
+		if !reflect.DeepEqual(spec, test.spec) {
+			t.Errorf("case %d: expect spec %v, but actually got %v", i, test.spec, spec)
+		}
+		if !reflect.DeepEqual(metadata, test.metadata) {
+			t.Errorf("case %d: expect metadata %v, but actually got %v", i, test.metadata, metadata)
+		}
+	}
+}
+
+func TestMergeActionMetadata(t *testing.T) {
+	gssLabels := map[string]string{"app": "game"}
+	gsMetadata := metav1.ObjectMeta{Labels: gssLabels}
+	actionMetadata := metav1.ObjectMeta{Labels: map[string]string{"map": "desert"}, Annotations: map[string]string{"example.com/probe": "ok"}}
+
+	merged := mergeActionMetadata(gsMetadata, actionMetadata)
+	expect := metav1.ObjectMeta{
+		Labels:      map[string]string{"app": "game", "map": "desert"},
+		Annotations: map[string]string{"example.com/probe": "ok"},
+	}
+	if !reflect.DeepEqual(merged, expect) {
+		t.Errorf("expect metadata %v, but actually got %v", expect, merged)
+	}
+	if len(gssLabels) != 1 {
+		t.Errorf("expect the labels of GameServerSet not changed, but actually got %v", gssLabels)
+	}
+
+	gs := &gameKruiseV1alpha1.GameServer{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"map": "desert"}}}
+	if !isActionMetadataChanged(actionMetadata, gs) {
+		t.Errorf("expect the annotations changed")
+	}
+	gs.Annotations = map[string]string{"example.com/probe": "ok"}
+	if isActionMetadataChanged(actionMetadata, gs) {
+		t.Errorf("expect the metadata not changed")
+	}
+}
@@ -18,6 +18,7 @@ package gameserver
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
 	"github.com/openkruise/kruise-game/pkg/util"
@@ -35,8 +36,23 @@ import (
 const (
 	pvNotFoundReason  string = "PersistentVolume Not Found"
 	pvcNotFoundReason string = "PersistentVolumeClaim Not Found"
+	// networkNotReadyReason is the reason of the network not ready, if the plugin does not tell the reason.
+	networkNotReadyReason string = "NetworkNotReady"
+	// networkSyncFailedReason is the reason of the NetworkSynced condition False, with the error as the message.
+	networkSyncFailedReason string = "NetworkSyncFailed"
+	// lifecycleSyncFailedReason is the reason of the LifecycleSynced condition False, with the error as the message.
+	lifecycleSyncFailedReason string = "LifecycleSyncFailed"
 )
 
+// lifecycleConditionTypes are the conditions reported by the lifecycle controller in getConditions, and the other
+// conditions of the GameServer are kept as they are when they are patched.
+var lifecycleConditionTypes = []gamekruiseiov1alpha1.GameServerConditionType{
+	gamekruiseiov1alpha1.PodNormal,
+	gamekruiseiov1alpha1.NodeNormal,
+	gamekruiseiov1alpha1.PersistentVolumeNormal,
+	gamekruiseiov1alpha1.ShuttingDown,
+}
+
 func getConditions(ctx context.Context, c client.Client, gs *gamekruiseiov1alpha1.GameServer, eventRecorder record.EventRecorder) ([]gamekruiseiov1alpha1.GameServerCondition, error) {
 	var gsConditions []gamekruiseiov1alpha1.GameServerCondition
 	now := metav1.Now()
@@ -133,9 +149,80 @@ func getConditions(ctx context.Context, c client.Client, gs *gamekruiseiov1alpha
 	}
 	gsConditions = append(gsConditions, pvCondition)
 
+	if shutdownCondition, ok := getShutdownCondition(pod); ok {
+		oldShutdownCondition := getGsCondition(oldConditions, gamekruiseiov1alpha1.ShuttingDown)
+		if !isConditionEqual(shutdownCondition, oldShutdownCondition) {
+			shutdownCondition.LastTransitionTime = now
+			eventRecorder.Event(gs, corev1.EventTypeNormal, shutdownCondition.Reason, shutdownCondition.Message)
+		} else {
+			shutdownCondition.LastTransitionTime = oldShutdownCondition.LastTransitionTime
+		}
+		gsConditions = append(gsConditions, shutdownCondition)
+	}
+
 	return gsConditions, nil
 }
 
+// getNetworkCondition returns the NetworkNormal condition if the pod has the network status set by the plugin.
+// The condition is False with the reason told by the plugin, if the enabled network is not ready.
+func getNetworkCondition(pod *corev1.Pod) (gamekruiseiov1alpha1.GameServerCondition, bool) {
+	networkStatusStr := pod.GetAnnotations()[gamekruiseiov1alpha1.GameServerNetworkStatus]
+	if networkStatusStr == "" {
+		return gamekruiseiov1alpha1.GameServerCondition{}, false
+	}
+	networkStatus := gamekruiseiov1alpha1.NetworkStatus{}
+	if err := json.Unmarshal([]byte(networkStatusStr), &networkStatus); err != nil {
+		return gamekruiseiov1alpha1.GameServerCondition{}, false
+	}
+	if networkStatus.CurrentNetworkState == gamekruiseiov1alpha1.NetworkReady ||
+		pod.GetLabels()[gamekruiseiov1alpha1.GameServerNetworkDisabled] == "true" {
+		return gamekruiseiov1alpha1.GameServerCondition{
+			Type:   gamekruiseiov1alpha1.NetworkNormal,
+			Status: corev1.ConditionTrue,
+		}, true
+	}
+	reason := string(networkStatus.Reason)
+	if reason == "" {
+		reason = networkNotReadyReason
+	}
+	message := networkStatus.Message
+	if message == "" {
+		message = fmt.Sprintf("network is %s", networkStatus.CurrentNetworkState)
+	}
+	return gamekruiseiov1alpha1.GameServerCondition{
+		Type:    gamekruiseiov1alpha1.NetworkNormal,
+		Status:  corev1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	}, true
+}
+
+// getShutdownCondition returns the ShuttingDown condition if the shutdown state of the pod is set by the SDK.
+func getShutdownCondition(pod *corev1.Pod) (gamekruiseiov1alpha1.GameServerCondition, bool) {
+	state := pod.GetAnnotations()[gamekruiseiov1alpha1.GameServerShutdownStateKey]
+	if state == "" {
+		return gamekruiseiov1alpha1.GameServerCondition{}, false
+	}
+	var message string
+	switch gamekruiseiov1alpha1.ShutdownState(state) {
+	case gamekruiseiov1alpha1.ShutdownDraining:
+		message = "game server is draining sessions"
+		if deadline := pod.GetAnnotations()[gamekruiseiov1alpha1.GameServerShutdownDeadlineKey]; deadline != "" {
+			message = fmt.Sprintf("%s until %s", message, deadline)
+		}
+	case gamekruiseiov1alpha1.ShutdownDrained:
+		message = "game server has acknowledged the shutdown"
+	default:
+		message = fmt.Sprintf("unknown shutdown state %s", state)
+	}
+	return gamekruiseiov1alpha1.GameServerCondition{
+		Type:    gamekruiseiov1alpha1.ShuttingDown,
+		Status:  corev1.ConditionTrue,
+		Reason:  state,
+		Message: message,
+	}, true
+}
+
 func getPodConditions(pod *corev1.Pod) gamekruiseiov1alpha1.GameServerCondition {
 	var message string
 	var reason string
@@ -336,6 +423,53 @@ func polyMessageReason(message, reason string, newMessage, newReason string) (st
 	return retMessage, retReason
 }
 
+// getSyncedCondition returns the condition reporting whether a controller syncs the GameServer, which is False with
+// the reason and the error as the message if it fails.
+func getSyncedCondition(conditionType gamekruiseiov1alpha1.GameServerConditionType, reason string, err error) gamekruiseiov1alpha1.GameServerCondition {
+	if err == nil {
+		return gamekruiseiov1alpha1.GameServerCondition{
+			Type:   conditionType,
+			Status: corev1.ConditionTrue,
+		}
+	}
+	return gamekruiseiov1alpha1.GameServerCondition{
+		Type:    conditionType,
+		Status:  corev1.ConditionFalse,
+		Reason:  reason,
+		Message: err.Error(),
+	}
+}
+
+// setGsConditions returns the conditions with the ones of conditionTypes replaced by newConditions, while the others
+// reported by the other controller are kept. The order of the conditions is kept, and the new types are appended.
+func setGsConditions(conditions []gamekruiseiov1alpha1.GameServerCondition, conditionTypes []gamekruiseiov1alpha1.GameServerConditionType, newConditions ...gamekruiseiov1alpha1.GameServerCondition) []gamekruiseiov1alpha1.GameServerCondition {
+	owned := make(map[gamekruiseiov1alpha1.GameServerConditionType]bool)
+	for _, conditionType := range conditionTypes {
+		owned[conditionType] = true
+	}
+	newConditionsMap := make(map[gamekruiseiov1alpha1.GameServerConditionType]gamekruiseiov1alpha1.GameServerCondition)
+	for _, condition := range newConditions {
+		newConditionsMap[condition.Type] = condition
+	}
+	var result []gamekruiseiov1alpha1.GameServerCondition
+	for _, condition := range conditions {
+		if !owned[condition.Type] {
+			result = append(result, condition)
+			continue
+		}
+		if newCondition, ok := newConditionsMap[condition.Type]; ok {
+			result = append(result, newCondition)
+			delete(newConditionsMap, condition.Type)
+		}
+	}
+	for _, condition := range newConditions {
+		if _, ok := newConditionsMap[condition.Type]; ok {
+			result = append(result, condition)
+		}
+	}
+	return result
+}
+
 func getGsCondition(conditions []gamekruiseiov1alpha1.GameServerCondition, conditionType gamekruiseiov1alpha1.GameServerConditionType) gamekruiseiov1alpha1.GameServerCondition {
 	if conditions == nil {
 		return gamekruiseiov1alpha1.GameServerCondition{}
@@ -748,3 +748,118 @@ func TestGetGsCondition(t *testing.T) {
 		}
 	}
 }
+
+func TestGetShutdownCondition(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		exist       bool
+		condition   gamekruiseiov1alpha1.GameServerCondition
+	}{
+		{
+			annotations: nil,
+			exist:       false,
+		},
+		{
+			annotations: map[string]string{
+				gamekruiseiov1alpha1.GameServerShutdownStateKey:    string(gamekruiseiov1alpha1.ShutdownDraining),
+				gamekruiseiov1alpha1.GameServerShutdownDeadlineKey: "2024-01-01T00:00:30Z",
+			},
+			exist: true,
+			condition: gamekruiseiov1alpha1.GameServerCondition{
+				Type:    gamekruiseiov1alpha1.ShuttingDown,
+				Status:  corev1.ConditionTrue,
+				Reason:  string(gamekruiseiov1alpha1.ShutdownDraining),
+				Message: "game server is draining sessions until 2024-01-01T00:00:30Z",
+			},
+		},
+		{
+			annotations: map[string]string{
+				gamekruiseiov1alpha1.GameServerShutdownStateKey: string(gamekruiseiov1alpha1.ShutdownDrained),
+			},
+			exist: true,
+			condition: gamekruiseiov1alpha1.GameServerCondition{
+				Type:    gamekruiseiov1alpha1.ShuttingDown,
+				Status:  corev1.ConditionTrue,
+				Reason:  string(gamekruiseiov1alpha1.ShutdownDrained),
+				Message: "game server has acknowledged the shutdown",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+		actual, exist := getShutdownCondition(pod)
+		if exist != test.exist {
+			t.Errorf("case %d: expect exist %v, but actually got %v", i, test.exist, exist)
+		}
+		if !reflect.DeepEqual(test.condition, actual) {
+			t.Errorf("case %d: expect condition is %v ,but actually is %v", i, test.condition, actual)
+		}
+	}
+}
+
+func TestGetNetworkCondition(t *testing.T) {
+	tests := []struct {
+		networkStatus string
+		labels        map[string]string
+		exist         bool
+		condition     gamekruiseiov1alpha1.GameServerCondition
+	}{
+		{
+			exist: false,
+		},
+		{
+			networkStatus: `{"currentNetworkState":"Ready"}`,
+			exist:         true,
+			condition: gamekruiseiov1alpha1.GameServerCondition{
+				Type:   gamekruiseiov1alpha1.NetworkNormal,
+				Status: corev1.ConditionTrue,
+			},
+		},
+		{
+			networkStatus: `{"currentNetworkState":"NotReady","reason":"PortExhausted","message":"there are no available ports for [lb-xxx]"}`,
+			exist:         true,
+			condition: gamekruiseiov1alpha1.GameServerCondition{
+				Type:    gamekruiseiov1alpha1.NetworkNormal,
+				Status:  corev1.ConditionFalse,
+				Reason:  string(gamekruiseiov1alpha1.NetworkPortExhausted),
+				Message: "there are no available ports for [lb-xxx]",
+			},
+		},
+		// the reason is not told by the plugin
+		{
+			networkStatus: `{"currentNetworkState":"Waiting"}`,
+			exist:         true,
+			condition: gamekruiseiov1alpha1.GameServerCondition{
+				Type:    gamekruiseiov1alpha1.NetworkNormal,
+				Status:  corev1.ConditionFalse,
+				Reason:  "NetworkNotReady",
+				Message: "network is Waiting",
+			},
+		},
+		// the network is disabled
+		{
+			networkStatus: `{"currentNetworkState":"NotReady"}`,
+			labels:        map[string]string{gamekruiseiov1alpha1.GameServerNetworkDisabled: "true"},
+			exist:         true,
+			condition: gamekruiseiov1alpha1.GameServerCondition{
+				Type:   gamekruiseiov1alpha1.NetworkNormal,
+				Status: corev1.ConditionTrue,
+			},
+		},
+	}
+
+	for i, test := range tests {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: test.labels}}
+		if test.networkStatus != "" {
+			pod.Annotations = map[string]string{gamekruiseiov1alpha1.GameServerNetworkStatus: test.networkStatus}
+		}
+		actual, exist := getNetworkCondition(pod)
+		if exist != test.exist {
+			t.Errorf("case %d: expect exist %v, but actually got %v", i, test.exist, exist)
+		}
+		if !reflect.DeepEqual(test.condition, actual) {
+			t.Errorf("case %d: expect condition is %v ,but actually is %v", i, test.condition, actual)
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"reflect"
 	"time"
 
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -58,15 +59,22 @@ func Add(mgr manager.Manager) error {
 	if !utildiscovery.DiscoverGVK(controllerKind) {
 		return nil
 	}
-	return add(mgr, newReconciler(mgr))
+	if err := mgr.Add(newServiceQualityProber(mgr.GetClient())); err != nil {
+		return err
+	}
+	if err := add(mgr, newReconciler(mgr)); err != nil {
+		return err
+	}
+	return addNetwork(mgr, newNetworkReconciler(mgr))
 }
 
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 	recorder := mgr.GetEventRecorderFor("gameserver-controller")
 	return &GameServerReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		recorder: recorder,
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		recorder:      recorder,
+		memoryTracker: newMemoryTracker(),
 	}
 }
 
@@ -90,6 +98,13 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		klog.Error(err)
 		return err
 	}
+	if err = c.Watch(&source.Kind{Type: &batchv1.Job{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &gamekruiseiov1alpha1.GameServer{},
+	}); err != nil {
+		klog.Error(err)
+		return err
+	}
 
 	return nil
 }
@@ -97,8 +112,9 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // GameServerReconciler reconciles a GameServer object
 type GameServerReconciler struct {
 	client.Client
-	Scheme   *runtime.Scheme
-	recorder record.EventRecorder
+	Scheme        *runtime.Scheme
+	recorder      record.EventRecorder
+	memoryTracker *memoryTracker
 }
 
 func watchPod(c controller.Controller) error {
@@ -232,6 +248,7 @@ func (r *GameServerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	if !podFound {
+		r.memoryTracker.forget(namespacedName)
 		if gsFound && gs.GetLabels()[gamekruiseiov1alpha1.GameServerDeletingKey] == "true" {
 			err := r.Client.Delete(context.Background(), gs)
 			if err != nil && !errors.IsNotFound(err) {
@@ -253,7 +270,27 @@ func (r *GameServerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return reconcile.Result{}, err
 	}
 
-	err = gsm.SyncGsToPod()
+	result, err := r.syncLifecycle(ctx, gsm, gss, gs, pod)
+	// the conflicts are not reported, since the GameServer changed is synced again soon
+	if !errors.IsConflict(err) {
+		if conditionErr := gsm.SyncSyncedCondition(gamekruiseiov1alpha1.LifecycleSynced, lifecycleSyncFailedReason, err); conditionErr != nil && err == nil {
+			return reconcile.Result{}, conditionErr
+		}
+	}
+	return result, err
+}
+
+// syncLifecycle syncs the GameServer and its pod with each other, and returns the result of the reconcile.
+func (r *GameServerReconciler) syncLifecycle(ctx context.Context, gsm Control, gss *gamekruiseiov1alpha1.GameServerSet, gs *gamekruiseiov1alpha1.GameServer, pod *corev1.Pod) (ctrl.Result, error) {
+	repaired, err := r.syncRepair(ctx, gss, gs, pod)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if repaired {
+		return reconcile.Result{}, nil
+	}
+
+	err = gsm.SyncGsToPod(gss)
 	if err != nil {
 		return reconcile.Result{RequeueAfter: 3 * time.Second}, err
 	}
@@ -263,11 +300,30 @@ func (r *GameServerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return reconcile.Result{}, err
 	}
 
+	sampleAfter, err := r.syncMemoryLeakPolicy(ctx, gss, gs, pod)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	err = r.syncResourceProfile(ctx, gss, gs, pod)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
 	if gsm.WaitOrNot() {
 		return ctrl.Result{RequeueAfter: NetworkIntervalTime}, nil
 	}
 
-	return ctrl.Result{}, nil
+	// execute the actions once the results have matched them for the consecutive probes
+	if actionAfter := getMatchedActionsRequeueAfter(gs.Status.ServiceQualitiesCondition, time.Now()); actionAfter > 0 && (sampleAfter == 0 || actionAfter < sampleAfter) {
+		sampleAfter = actionAfter
+	}
+	// release the pod once its pre-delete hook times out
+	if hookAfter := getPreDeleteHookRequeueAfter(gss.Spec.PreDeleteHook, pod, time.Now()); hookAfter > 0 && (sampleAfter == 0 || hookAfter < sampleAfter) {
+		sampleAfter = hookAfter
+	}
+
+	return ctrl.Result{RequeueAfter: sampleAfter}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
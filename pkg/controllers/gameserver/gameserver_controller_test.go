@@ -173,7 +173,7 @@ func TestGameServerReconcile(t *testing.T) {
 			objs = append(objs, gs)
 		}
 		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
-		recon := GameServerReconciler{Client: c}
+		recon := GameServerReconciler{Client: c, memoryTracker: newMemoryTracker()}
 		if _, err := recon.Reconcile(context.TODO(), test.req); err != nil {
 			t.Error(err)
 		}
@@ -21,15 +21,18 @@ import (
 	kruisePub "github.com/openkruise/kruise-api/apps/pub"
 	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
 	"github.com/openkruise/kruise-game/cloudprovider/utils"
+	"github.com/openkruise/kruise-game/pkg/readonly"
 	"github.com/openkruise/kruise-game/pkg/util"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
+	"math"
 	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"strconv"
@@ -53,11 +56,17 @@ const (
 type Control interface {
 	// SyncGsToPod compares the pod with GameServer, and decide whether to update the pod based on the results.
 	// When the fields of the pod is different from that of GameServer, pod will be updated.
-	SyncGsToPod() error
+	SyncGsToPod(*gameKruiseV1alpha1.GameServerSet) error
 	// SyncPodToGs compares the GameServer with pod, and update the GameServer.
 	SyncPodToGs(*gameKruiseV1alpha1.GameServerSet) error
-	// WaitOrNot compare the current game server network status to decide whether to re-queue.
+	// WaitOrNot decides whether to re-queue the lifecycle of the GameServer, such as waiting for the pre-delete hook.
 	WaitOrNot() bool
+	// SyncNetwork syncs the network status of the GameServer from the pod, and triggers the network plugin of the pod.
+	SyncNetwork() error
+	// WaitForNetwork compares the current game server network status to decide whether to re-queue the network.
+	WaitForNetwork() bool
+	// SyncSyncedCondition reports whether the network or the lifecycle controller syncs the GameServer by the condition.
+	SyncSyncedCondition(gameKruiseV1alpha1.GameServerConditionType, string, error) error
 }
 
 type GameServerManager struct {
@@ -84,7 +93,7 @@ func syncMetadataFromGss(gss *gameKruiseV1alpha1.GameServerSet) metav1.ObjectMet
 	}
 }
 
-func (manager GameServerManager) SyncGsToPod() error {
+func (manager GameServerManager) SyncGsToPod(gss *gameKruiseV1alpha1.GameServerSet) error {
 	pod := manager.pod
 	gs := manager.gameServer
 	podLabels := pod.GetLabels()
@@ -118,12 +127,18 @@ func (manager GameServerManager) SyncGsToPod() error {
 			manager.eventRecorder.Eventf(gs, eventType, StateReason, "OpsState turn from %s to %s ", podGsOpsState, string(gs.Spec.OpsState))
 		}
 	}
-	if podNetworkDisabled != strconv.FormatBool(gs.Spec.NetworkDisabled) {
-		newLabels[gameKruiseV1alpha1.GameServerNetworkDisabled] = strconv.FormatBool(gs.Spec.NetworkDisabled)
+	// the network of all GameServers is disabled when the GameServerSet disables it
+	networkDisabled := strconv.FormatBool(gs.Spec.NetworkDisabled || gss.Spec.NetworkDisabled)
+	if podNetworkDisabled != networkDisabled {
+		newLabels[gameKruiseV1alpha1.GameServerNetworkDisabled] = networkDisabled
 		if podNetworkDisabled != "" {
-			manager.eventRecorder.Eventf(gs, corev1.EventTypeNormal, StateReason, "NetworkDisabled turn from %s to %s ", podNetworkDisabled, strconv.FormatBool(gs.Spec.NetworkDisabled))
+			manager.eventRecorder.Eventf(gs, corev1.EventTypeNormal, StateReason, "NetworkDisabled turn from %s to %s ", podNetworkDisabled, networkDisabled)
 		}
 	}
+	// the GameServerSet reads the label to reserve the id after the GameServer is killed
+	if (podLabels[gameKruiseV1alpha1.GameServerReserveWhenKilledKey] == "true") != gs.Spec.ReserveWhenKilled {
+		newLabels[gameKruiseV1alpha1.GameServerReserveWhenKilledKey] = strconv.FormatBool(gs.Spec.ReserveWhenKilled)
+	}
 
 	var gsState gameKruiseV1alpha1.GameServerState
 	switch pod.Status.Phase {
@@ -139,10 +154,10 @@ func (manager GameServerManager) SyncGsToPod() error {
 			gsState = gameKruiseV1alpha1.Deleting
 			break
 		}
-		// GameServer Ready / NotReady
+		// GameServer Ready / NotReady, which follows the game container if it is designated
 		_, condition := util.GetPodConditionFromList(pod.Status.Conditions, corev1.PodReady)
 		if condition != nil {
-			if condition.Status == corev1.ConditionTrue {
+			if condition.Status == corev1.ConditionTrue || util.IsGameContainerReady(pod) {
 				gsState = gameKruiseV1alpha1.Ready
 			} else {
 				gsState = gameKruiseV1alpha1.NotReady
@@ -167,11 +182,8 @@ func (manager GameServerManager) SyncGsToPod() error {
 		}
 	}
 
-	if pod.Annotations[gameKruiseV1alpha1.GameServerNetworkType] != "" {
-		oldTime, err := time.Parse(TimeFormat, pod.Annotations[gameKruiseV1alpha1.GameServerNetworkTriggerTime])
-		if (err == nil && time.Since(oldTime) > NetworkIntervalTime && time.Since(gs.Status.NetworkStatus.LastTransitionTime.Time) < NetworkTotalWaitTime) || (pod.Annotations[gameKruiseV1alpha1.GameServerNetworkTriggerTime] == "") {
-			newAnnotations[gameKruiseV1alpha1.GameServerNetworkTriggerTime] = time.Now().Format(TimeFormat)
-		}
+	if readyTime := getNetworkReadyTime(pod); readyTime != "" {
+		newAnnotations[gameKruiseV1alpha1.GameServerNetworkReadyTime] = readyTime
 	}
 
 	// sync annotations from gs to pod
@@ -210,6 +222,26 @@ func (manager GameServerManager) SyncGsToPod() error {
 	return nil
 }
 
+// getNetworkReadyTime returns the time when the network of the pod became Ready, if it is Ready for the first time.
+func getNetworkReadyTime(pod *corev1.Pod) string {
+	annotations := pod.GetAnnotations()
+	if _, exist := annotations[gameKruiseV1alpha1.GameServerNetworkReadyTime]; exist {
+		return ""
+	}
+	networkStatus := gameKruiseV1alpha1.NetworkStatus{}
+	if err := json.Unmarshal([]byte(annotations[gameKruiseV1alpha1.GameServerNetworkStatus]), &networkStatus); err != nil {
+		return ""
+	}
+	if networkStatus.CurrentNetworkState != gameKruiseV1alpha1.NetworkReady {
+		return ""
+	}
+	readyTime := networkStatus.LastTransitionTime.Time
+	if readyTime.IsZero() {
+		readyTime = time.Now()
+	}
+	return readyTime.Format(time.RFC3339)
+}
+
 func (manager GameServerManager) SyncPodToGs(gss *gameKruiseV1alpha1.GameServerSet) error {
 	gs := manager.gameServer
 	pod := manager.pod
@@ -221,11 +253,23 @@ func (manager GameServerManager) SyncPodToGs(gss *gameKruiseV1alpha1.GameServerS
 	podGsState := gameKruiseV1alpha1.GameServerState(podLabels[gameKruiseV1alpha1.GameServerStateKey])
 
 	// sync Service Qualities
-	spec, sqConditions := syncServiceQualities(gss.Spec.ServiceQualities, pod.Status.Conditions, gs.Status.ServiceQualitiesCondition)
+	sqs, err := util.GetServiceQualities(gss, manager.client, context.TODO())
+	if err != nil {
+		klog.Errorf("failed to get ServiceQualities of GameServer %s in %s, because of %s.", gs.GetName(), gs.GetNamespace(), err.Error())
+		return err
+	}
+	spec, actionMetadata, sqConditions := syncServiceQualities(sqs, pod.Status.Conditions, gs.Status.ServiceQualitiesCondition)
+
+	// delete the GameServers with the fewest players first
+	if gss.Spec.ScaleStrategy.ScaleDownStrategyType == gameKruiseV1alpha1.LeastPlayersScaleDownStrategyType {
+		if deletionPriority := getLeastPlayersDeletionPriority(gss.Spec.ScaleStrategy.PlayersSource, sqConditions, pod.GetAnnotations()); deletionPriority != nil {
+			spec.DeletionPriority = deletionPriority
+		}
+	}
 
-	if isNeedToSyncMetadata(gss, gs) || !reflect.DeepEqual(spec, gs.Spec) {
+	if isNeedToSyncMetadata(gss, gs) || !reflect.DeepEqual(spec, gs.Spec) || isActionMetadataChanged(actionMetadata, gs) {
 		// sync metadata
-		gsMetadata := syncMetadataFromGss(gss)
+		gsMetadata := mergeActionMetadata(syncMetadataFromGss(gss), actionMetadata)
 
 		// patch gs spec & metadata
 		patchSpec := map[string]interface{}{"spec": spec, "metadata": gsMetadata}
@@ -233,7 +277,8 @@ func (manager GameServerManager) SyncPodToGs(gss *gameKruiseV1alpha1.GameServerS
 		if err != nil {
 			return err
 		}
-		err = manager.client.Patch(context.TODO(), gs, client.RawPatch(types.MergePatchType, jsonPatchSpec))
+		// the GameServer keeps up with its pod in read-only mode, so that it is not retried on every reconcile
+		err = manager.client.Patch(readonly.WithInternalWrites(context.TODO()), gs, client.RawPatch(types.MergePatchType, jsonPatchSpec))
 		if err != nil && !errors.IsNotFound(err) {
 			klog.Errorf("failed to patch GameServer spec %s in %s,because of %s.", gs.GetName(), gs.GetNamespace(), err.Error())
 			return err
@@ -247,6 +292,29 @@ func (manager GameServerManager) SyncPodToGs(gss *gameKruiseV1alpha1.GameServerS
 		return err
 	}
 
+	// the lifecycle of the pod is not changed in read-only mode, and the status is still synced
+	preUpdateJob := gs.Status.PreUpdateJob
+	if !readonly.Enabled() {
+		// hold the pod back from being updated until the GameServer passes the update gate
+		if err := manager.syncUpdateGate(gss); err != nil {
+			klog.Errorf("failed to sync update gate of GameServer %s in %s, because of %s.", gs.GetName(), gs.GetNamespace(), err.Error())
+			return err
+		}
+
+		// run pre-update Job before the pod is updated
+		preUpdateJob, err = manager.syncPreUpdateJob(gss)
+		if err != nil {
+			klog.Errorf("failed to sync pre-update Job of GameServer %s in %s, because of %s.", gs.GetName(), gs.GetNamespace(), err.Error())
+			return err
+		}
+
+		// call the pre-delete hook and wait for the acknowledgment before the pod is deleted
+		if err := manager.syncPreDeleteHook(gss); err != nil {
+			klog.Errorf("failed to sync pre-delete hook of GameServer %s in %s, because of %s.", gs.GetName(), gs.GetNamespace(), err.Error())
+			return err
+		}
+	}
+
 	// patch gs status
 	oldStatus := *gs.Status.DeepCopy()
 	newStatus := gameKruiseV1alpha1.GameServerStatus{
@@ -256,13 +324,26 @@ func (manager GameServerManager) SyncPodToGs(gss *gameKruiseV1alpha1.GameServerS
 		UpdatePriority:            &podUpdatePriority,
 		DeletionPriority:          &podDeletePriority,
 		ServiceQualitiesCondition: sqConditions,
-		NetworkStatus:             manager.syncNetworkStatus(),
+		NetworkStatus:             oldStatus.NetworkStatus,
 		LastTransitionTime:        oldStatus.LastTransitionTime,
-		Conditions:                conditions,
+		Conditions:                setGsConditions(oldStatus.Conditions, lifecycleConditionTypes, conditions...),
+		PreUpdateJob:              preUpdateJob,
+		CustomStatus:              syncCustomStatus(gss.Spec.CustomStatusFields, pod.GetAnnotations()),
+		Connections:               oldStatus.Connections,
 	}
 	if !reflect.DeepEqual(oldStatus, newStatus) {
 		newStatus.LastTransitionTime = metav1.Now()
-		patchStatus := map[string]interface{}{"status": newStatus}
+		statusMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&newStatus)
+		if err != nil {
+			return err
+		}
+		// the network status is synced by the network queue, which is not overwritten by the stale one here, and the
+		// resourceVersion rejects the patch if the conditions of the network queue are changed since it is read
+		delete(statusMap, "networkStatus")
+		patchStatus := map[string]interface{}{
+			"metadata": map[string]interface{}{"resourceVersion": gs.GetResourceVersion()},
+			"status":   statusMap,
+		}
 		jsonPatchStatus, err := json.Marshal(patchStatus)
 		if err != nil {
 			return err
@@ -277,15 +358,36 @@ func (manager GameServerManager) SyncPodToGs(gss *gameKruiseV1alpha1.GameServerS
 	return nil
 }
 
-func (manager GameServerManager) WaitOrNot() bool {
-	networkStatus := manager.gameServer.Status.NetworkStatus
-	alreadyWait := time.Since(networkStatus.LastTransitionTime.Time)
-	if networkStatus.DesiredNetworkState != networkStatus.CurrentNetworkState && alreadyWait < NetworkTotalWaitTime {
-		klog.Infof("GameServer %s/%s DesiredNetworkState: %s CurrentNetworkState: %s. %v remaining",
-			manager.gameServer.GetNamespace(), manager.gameServer.GetName(), networkStatus.DesiredNetworkState, networkStatus.CurrentNetworkState, NetworkTotalWaitTime-alreadyWait)
-		return true
+// SyncSyncedCondition patches the condition reporting whether the controller syncs the GameServer, which is False
+// with the reason and the error if it fails, so that the network and the lifecycle report their status separately.
+func (manager GameServerManager) SyncSyncedCondition(conditionType gameKruiseV1alpha1.GameServerConditionType, reason string, syncErr error) error {
+	gs := manager.gameServer
+	condition := getSyncedCondition(conditionType, reason, syncErr)
+	oldCondition := getGsCondition(gs.Status.Conditions, conditionType)
+	if oldCondition.Type != "" && isConditionEqual(condition, oldCondition) {
+		return nil
+	}
+	condition.LastTransitionTime = metav1.Now()
+	conditions := setGsConditions(gs.Status.Conditions, []gameKruiseV1alpha1.GameServerConditionType{conditionType}, condition)
+	patchStatus := map[string]interface{}{
+		"metadata": map[string]interface{}{"resourceVersion": gs.GetResourceVersion()},
+		"status":   map[string]interface{}{"conditions": conditions},
 	}
-	return false
+	jsonPatchStatus, err := json.Marshal(patchStatus)
+	if err != nil {
+		return err
+	}
+	err = manager.client.Status().Patch(context.TODO(), gs, client.RawPatch(types.MergePatchType, jsonPatchStatus))
+	if err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("failed to patch GameServer %s condition %s in %s, because of %s.", gs.GetName(), conditionType, gs.GetNamespace(), err.Error())
+		return err
+	}
+	return nil
+}
+
+func (manager GameServerManager) WaitOrNot() bool {
+	// the pre-delete hook times out without any event, check it again later
+	return isWaitingForPreDeleteHook(manager.pod)
 }
 
 func (manager GameServerManager) syncNetworkStatus() gameKruiseV1alpha1.NetworkStatus {
@@ -317,6 +419,8 @@ func (manager GameServerManager) syncNetworkStatus() gameKruiseV1alpha1.NetworkS
 	gsNetworkStatus.InternalAddresses = podNetworkStatus.InternalAddresses
 	gsNetworkStatus.ExternalAddresses = podNetworkStatus.ExternalAddresses
 	gsNetworkStatus.CurrentNetworkState = podNetworkStatus.CurrentNetworkState
+	gsNetworkStatus.Reason = podNetworkStatus.Reason
+	gsNetworkStatus.Message = podNetworkStatus.Message
 
 	if gsNetworkStatus.DesiredNetworkState != desiredNetworkState(nm.GetNetworkDisabled()) {
 		gsNetworkStatus.DesiredNetworkState = desiredNetworkState(nm.GetNetworkDisabled())
@@ -333,8 +437,62 @@ func desiredNetworkState(disabled bool) gameKruiseV1alpha1.NetworkState {
 	return gameKruiseV1alpha1.NetworkReady
 }
 
-func syncServiceQualities(serviceQualities []gameKruiseV1alpha1.ServiceQuality, podConditions []corev1.PodCondition, sqConditions []gameKruiseV1alpha1.ServiceQualityCondition) (gameKruiseV1alpha1.GameServerSpec, []gameKruiseV1alpha1.ServiceQualityCondition) {
+// syncCustomStatus collects the values of the declared custom status fields from the pod annotations set by the SDK.
+// The undeclared fields and the Number fields with invalid values are ignored.
+func syncCustomStatus(fields []gameKruiseV1alpha1.CustomStatusField, podAnnotations map[string]string) map[string]string {
+	var customStatus map[string]string
+	for _, field := range fields {
+		value, ok := podAnnotations[gameKruiseV1alpha1.GameServerCustomStatusPrefix+field.Name]
+		if !ok {
+			continue
+		}
+		if field.Type == gameKruiseV1alpha1.NumberCustomStatusFieldType {
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				klog.Warningf("ignore the custom status field %s, because %s is not a number", field.Name, value)
+				continue
+			}
+		}
+		if customStatus == nil {
+			customStatus = make(map[string]string)
+		}
+		customStatus[field.Name] = value
+	}
+	return customStatus
+}
+
+// getLeastPlayersDeletionPriority returns the negative number of players of the GameServer as its DeletionPriority,
+// or nil if the number of players is not reported.
+func getLeastPlayersDeletionPriority(source *gameKruiseV1alpha1.PlayersSource, sqConditions []gameKruiseV1alpha1.ServiceQualityCondition, podAnnotations map[string]string) *intstr.IntOrString {
+	if source == nil {
+		return nil
+	}
+	var values []string
+	if source.ServiceQualityName != "" {
+		for _, sqCondition := range sqConditions {
+			if sqCondition.Name == source.ServiceQualityName {
+				values = append(values, sqCondition.Result)
+			}
+		}
+	}
+	if value, ok := podAnnotations[source.Annotation]; ok && source.Annotation != "" {
+		values = append(values, value)
+	}
+	for _, value := range values {
+		players, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || players < 0 {
+			continue
+		}
+		deletionPriority := intstr.FromInt(-int(math.Round(players)))
+		return &deletionPriority
+	}
+	return nil
+}
+
+// syncServiceQualities returns the GameServerSpec and the labels and annotations set by the ServiceQualityActions
+// executed, and the new ServiceQualityConditions.
+func syncServiceQualities(serviceQualities []gameKruiseV1alpha1.ServiceQuality, podConditions []corev1.PodCondition, sqConditions []gameKruiseV1alpha1.ServiceQualityCondition) (gameKruiseV1alpha1.GameServerSpec, metav1.ObjectMeta, []gameKruiseV1alpha1.ServiceQualityCondition) {
 	var spec gameKruiseV1alpha1.GameServerSpec
+	var metadata metav1.ObjectMeta
 	var newGsConditions []gameKruiseV1alpha1.ServiceQualityCondition
 	sqConditionsMap := make(map[string]gameKruiseV1alpha1.ServiceQualityCondition)
 	for _, sqc := range sqConditions {
@@ -352,23 +510,54 @@ func syncServiceQualities(serviceQualities []gameKruiseV1alpha1.ServiceQuality,
 			newSqCondition.Result = podConditionMessage
 			newSqCondition.LastProbeTime = podCondition.LastProbeTime
 			var lastActionTransitionTime metav1.Time
+			var executedAction *gameKruiseV1alpha1.GameServerSpec
 			sqCondition, exist := sqConditionsMap[sq.Name]
-			if !exist || ((sqCondition.Status != string(podCondition.Status) || (sqCondition.Result != podConditionMessage)) && (sqCondition.LastActionTransitionTime.IsZero() || !sq.Permanent)) {
+			matchedActions, dueActions := syncMatchedActions(sq, sqCondition.MatchedActions, podCondition, podConditionMessage, timeNow)
+			changed := !exist || sqCondition.Status != string(podCondition.Status) || sqCondition.Result != podConditionMessage
+			if (changed || len(dueActions) != 0) && (sqCondition.LastActionTransitionTime.IsZero() || !sq.Permanent) {
 				// exec action
-				for _, action := range sq.ServiceQualityAction {
-					state, err := strconv.ParseBool(string(podCondition.Status))
-					if err == nil && state == action.State && (action.Result == "" || podConditionMessage == action.Result) {
-						spec.DeletionPriority = action.DeletionPriority
-						spec.UpdatePriority = action.UpdatePriority
-						spec.OpsState = action.OpsState
-						spec.NetworkDisabled = action.NetworkDisabled
-						lastActionTransitionTime = timeNow
+				if sq.ReArmAfterSeconds != nil || !changed {
+					// keep the time of the last action to re-arm edge-triggered actions
+					lastActionTransitionTime = sqCondition.LastActionTransitionTime
+				}
+				for i, action := range sq.ServiceQualityAction {
+					if isConsecutiveAction(action) {
+						// executed once the results have matched it for the consecutive probes
+						if !dueActions[i] {
+							continue
+						}
+						markMatchedActionExecuted(matchedActions, i)
+					} else {
+						if !changed || !isActionMatched(action, string(podCondition.Status), podConditionMessage, podCondition.Message) {
+							continue
+						}
+						if action.EdgeTriggered && exist && (isActionMatched(action, sqCondition.Status, sqCondition.Result, sqCondition.Result) || !isReArmed(sq, sqCondition, timeNow)) {
+							continue
+						}
 					}
+					spec.DeletionPriority = action.DeletionPriority
+					spec.UpdatePriority = action.UpdatePriority
+					spec.OpsState = action.OpsState
+					spec.NetworkDisabled = action.NetworkDisabled
+					spec.ResourceProfile = action.ResourceProfile
+					state, _ := strconv.ParseBool(string(podCondition.Status))
+					applyActionItems(action.Actions, util.NewServiceQualityActionData(state, podConditionMessage, podCondition.Message), &spec, &metadata)
+					lastActionTransitionTime = timeNow
+					executedAction = spec.DeepCopy()
 				}
 			} else {
 				lastActionTransitionTime = sqCondition.LastActionTransitionTime
 			}
 			newSqCondition.LastActionTransitionTime = lastActionTransitionTime
+			newSqCondition.MatchedActions = matchedActions
+			newSqCondition.History = appendServiceQualityResult(sqCondition.History, gameKruiseV1alpha1.ServiceQualityResult{
+				ProbeTime: podCondition.LastProbeTime,
+				Status:    newSqCondition.Status,
+				Result:    newSqCondition.Result,
+				Action:    executedAction,
+			}, getResultHistoryLimit(sq))
+		} else {
+			newSqCondition.History = trimServiceQualityResults(sqConditionsMap[sq.Name].History, getResultHistoryLimit(sq))
 		}
 
 		// Set LastTransitionTime, which depends on which value, the LastActionTransitionTime or LastProbeTime, is closer to the current time.
@@ -379,7 +568,126 @@ func syncServiceQualities(serviceQualities []gameKruiseV1alpha1.ServiceQuality,
 		}
 		newGsConditions = append(newGsConditions, newSqCondition)
 	}
-	return spec, newGsConditions
+	return spec, metadata, newGsConditions
+}
+
+// isActionMatched returns whether the probe status and result match the ServiceQualityAction. The fields of
+// ResultRange are parsed from the message, which is the result before the separators are removed.
+func isActionMatched(action gameKruiseV1alpha1.ServiceQualityAction, status, result, message string) bool {
+	state, err := strconv.ParseBool(status)
+	if err != nil || state != action.State {
+		return false
+	}
+	if action.ResultRange != nil {
+		return util.IsResultInRange(action.ResultRange, result, message)
+	}
+	return action.Result == "" || result == action.Result
+}
+
+// isConsecutiveAction returns whether the ServiceQualityAction is executed only after the consecutive probes.
+func isConsecutiveAction(action gameKruiseV1alpha1.ServiceQualityAction) bool {
+	return action.ConsecutiveProbes != nil && *action.ConsecutiveProbes > 1
+}
+
+// syncMatchedActions tracks the actions with ConsecutiveProbes matched by the probe result, and returns the indexes
+// of the actions due but not executed yet. Since the probe results unchanged are not reported, an action is due when
+// the results have kept matching it for ConsecutiveProbes-1 periods of the probe.
+func syncMatchedActions(sq gameKruiseV1alpha1.ServiceQuality, matchedActions []gameKruiseV1alpha1.ServiceQualityMatchedAction, podCondition *corev1.PodCondition, result string, now metav1.Time) ([]gameKruiseV1alpha1.ServiceQualityMatchedAction, map[int]bool) {
+	previous := make(map[int32]gameKruiseV1alpha1.ServiceQualityMatchedAction, len(matchedActions))
+	for _, matchedAction := range matchedActions {
+		previous[matchedAction.Index] = matchedAction
+	}
+	matchedSince := podCondition.LastProbeTime
+	if matchedSince.IsZero() {
+		matchedSince = now
+	}
+	period := time.Duration(defaultIfZero(sq.PeriodSeconds, defaultProbePeriodSeconds)) * time.Second
+
+	var newMatchedActions []gameKruiseV1alpha1.ServiceQualityMatchedAction
+	dueActions := make(map[int]bool)
+	for i, action := range sq.ServiceQualityAction {
+		if !isConsecutiveAction(action) || !isActionMatched(action, string(podCondition.Status), result, podCondition.Message) {
+			continue
+		}
+		matchedAction, exist := previous[int32(i)]
+		if !exist {
+			matchedAction = gameKruiseV1alpha1.ServiceQualityMatchedAction{
+				Index:   int32(i),
+				DueTime: metav1.NewTime(matchedSince.Add(time.Duration(*action.ConsecutiveProbes-1) * period)),
+			}
+		}
+		if !matchedAction.Executed && !now.Before(&matchedAction.DueTime) {
+			dueActions[i] = true
+		}
+		newMatchedActions = append(newMatchedActions, matchedAction)
+	}
+	return newMatchedActions, dueActions
+}
+
+func markMatchedActionExecuted(matchedActions []gameKruiseV1alpha1.ServiceQualityMatchedAction, index int) {
+	for i := range matchedActions {
+		if matchedActions[i].Index == int32(index) {
+			matchedActions[i].Executed = true
+		}
+	}
+}
+
+// getMatchedActionsRequeueAfter returns the duration after which the first matched action not executed is due, or
+// zero if there is none in the future.
+func getMatchedActionsRequeueAfter(sqConditions []gameKruiseV1alpha1.ServiceQualityCondition, now time.Time) time.Duration {
+	var requeueAfter time.Duration
+	for _, sqCondition := range sqConditions {
+		for _, matchedAction := range sqCondition.MatchedActions {
+			if matchedAction.Executed {
+				continue
+			}
+			after := matchedAction.DueTime.Sub(now)
+			if after > 0 && (requeueAfter == 0 || after < requeueAfter) {
+				requeueAfter = after
+			}
+		}
+	}
+	return requeueAfter
+}
+
+// isReArmed returns whether ReArmAfterSeconds has passed since the last action of the ServiceQuality.
+func isReArmed(sq gameKruiseV1alpha1.ServiceQuality, sqCondition gameKruiseV1alpha1.ServiceQualityCondition, now metav1.Time) bool {
+	if sq.ReArmAfterSeconds == nil || sqCondition.LastActionTransitionTime.IsZero() {
+		return true
+	}
+	return now.Sub(sqCondition.LastActionTransitionTime.Time) >= time.Duration(*sq.ReArmAfterSeconds)*time.Second
+}
+
+func getResultHistoryLimit(sq gameKruiseV1alpha1.ServiceQuality) int {
+	if sq.ResultHistoryLimit == nil {
+		return gameKruiseV1alpha1.DefaultServiceQualityResultHistoryLimit
+	}
+	return int(*sq.ResultHistoryLimit)
+}
+
+// appendServiceQualityResult appends the probe result to the history, unless it is the same probe result as the latest one
+// and no action is executed for it.
+func appendServiceQualityResult(history []gameKruiseV1alpha1.ServiceQualityResult, result gameKruiseV1alpha1.ServiceQualityResult, limit int) []gameKruiseV1alpha1.ServiceQualityResult {
+	if len(history) != 0 && result.Action == nil {
+		last := history[len(history)-1]
+		if last.ProbeTime.Equal(&result.ProbeTime) && last.Status == result.Status && last.Result == result.Result {
+			return trimServiceQualityResults(history, limit)
+		}
+	}
+	newHistory := make([]gameKruiseV1alpha1.ServiceQualityResult, 0, len(history)+1)
+	newHistory = append(newHistory, history...)
+	return trimServiceQualityResults(append(newHistory, result), limit)
+}
+
+// trimServiceQualityResults keeps the latest limit results of the history.
+func trimServiceQualityResults(history []gameKruiseV1alpha1.ServiceQualityResult, limit int) []gameKruiseV1alpha1.ServiceQualityResult {
+	if limit <= 0 {
+		return nil
+	}
+	if len(history) > limit {
+		return history[len(history)-limit:]
+	}
+	return history
 }
 
 func (manager GameServerManager) syncPodContainers(gsContainers []gameKruiseV1alpha1.GameServerContainer, podContainers []corev1.Container) []corev1.Container {
@@ -2,22 +2,27 @@ package gameserver
 
 import (
 	"context"
+	"encoding/json"
 	kruiseV1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
 	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
 	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
 	"github.com/openkruise/kruise-game/pkg/util"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"strconv"
 	"testing"
+	"time"
 )
 
 var (
@@ -385,10 +390,48 @@ func TestSyncServiceQualities(t *testing.T) {
 				},
 			},
 		},
+		// case 7
+		{
+			serviceQualities: []gameKruiseV1alpha1.ServiceQuality{
+				{
+					Name: "players",
+					ServiceQualityAction: []gameKruiseV1alpha1.ServiceQualityAction{
+						{
+							State:  true,
+							Result: "crowded",
+							GameServerSpec: gameKruiseV1alpha1.GameServerSpec{
+								ResourceProfile: "large",
+							},
+						},
+					},
+				},
+			},
+			podConditions: []corev1.PodCondition{
+				{
+					Type:          "game.kruise.io/players",
+					Status:        corev1.ConditionTrue,
+					Message:       "crowded",
+					LastProbeTime: fakeProbeTime,
+				},
+			},
+			sqConditions: nil,
+			spec: gameKruiseV1alpha1.GameServerSpec{
+				ResourceProfile: "large",
+			},
+			newSqConditions: []gameKruiseV1alpha1.ServiceQualityCondition{
+				{
+					Name:                     "players",
+					Result:                   "crowded",
+					Status:                   string(corev1.ConditionTrue),
+					LastProbeTime:            fakeProbeTime,
+					LastActionTransitionTime: fakeActionTime,
+				},
+			},
+		},
 	}
 
 	for i, test := range tests {
-		actualSpec, actualNewSqConditions := syncServiceQualities(test.serviceQualities, test.podConditions, test.sqConditions)
+		actualSpec, _, actualNewSqConditions := syncServiceQualities(test.serviceQualities, test.podConditions, test.sqConditions)
 		expectSpec := test.spec
 		expectNewSqConditions := test.newSqConditions
 		if !reflect.DeepEqual(actualSpec, expectSpec) {
@@ -425,10 +468,12 @@ func TestSyncGsToPod(t *testing.T) {
 	up := intstr.FromInt(20)
 	dp := intstr.FromInt(10)
 	tests := []struct {
+		gss *gameKruiseV1alpha1.GameServerSet
 		gs  *gameKruiseV1alpha1.GameServer
 		pod *corev1.Pod
 	}{
 		{
+			gss: &gameKruiseV1alpha1.GameServerSet{},
 			gs: &gameKruiseV1alpha1.GameServer{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "xxx",
@@ -458,6 +503,7 @@ func TestSyncGsToPod(t *testing.T) {
 		},
 
 		{
+			gss: &gameKruiseV1alpha1.GameServerSet{},
 			gs: &gameKruiseV1alpha1.GameServer{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: "xxx",
@@ -498,18 +544,51 @@ func TestSyncGsToPod(t *testing.T) {
 				},
 			},
 		},
+		// the network is disabled by the GameServerSet
+		{
+			gss: &gameKruiseV1alpha1.GameServerSet{
+				Spec: gameKruiseV1alpha1.GameServerSetSpec{NetworkDisabled: true},
+			},
+			gs: &gameKruiseV1alpha1.GameServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "xxx",
+					Name:      "xxx-0",
+					Labels: map[string]string{
+						gameKruiseV1alpha1.GameServerOwnerGssKey: "xxx",
+					},
+				},
+				Spec: gameKruiseV1alpha1.GameServerSpec{
+					UpdatePriority:   &up,
+					DeletionPriority: &dp,
+					OpsState:         gameKruiseV1alpha1.None,
+				},
+			},
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "xxx",
+					Name:      "xxx-0",
+					Labels: map[string]string{
+						gameKruiseV1alpha1.GameServerNetworkDisabled: "false",
+					},
+				},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodRunning,
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
 		objs := []client.Object{test.gs, test.pod}
 		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
 		manager := &GameServerManager{
-			client:     c,
-			gameServer: test.gs,
-			pod:        test.pod,
+			client:        c,
+			gameServer:    test.gs,
+			pod:           test.pod,
+			eventRecorder: record.NewFakeRecorder(10),
 		}
 
-		if err := manager.SyncGsToPod(); err != nil {
+		if err := manager.SyncGsToPod(test.gss); err != nil {
 			t.Error(err)
 		}
 
@@ -533,8 +612,9 @@ func TestSyncGsToPod(t *testing.T) {
 			t.Errorf("expect DeletionPriority is %s ,but actually is %s", test.gs.Spec.DeletionPriority.String(), pod.Labels[gameKruiseV1alpha1.GameServerDeletePriorityKey])
 		}
 
-		if pod.Labels[gameKruiseV1alpha1.GameServerNetworkDisabled] != strconv.FormatBool(test.gs.Spec.NetworkDisabled) {
-			t.Errorf("expect NetworkDisabled is %s ,but actually is %s", strconv.FormatBool(test.gs.Spec.NetworkDisabled), pod.Labels[gameKruiseV1alpha1.GameServerNetworkDisabled])
+		networkDisabled := strconv.FormatBool(test.gs.Spec.NetworkDisabled || test.gss.Spec.NetworkDisabled)
+		if pod.Labels[gameKruiseV1alpha1.GameServerNetworkDisabled] != networkDisabled {
+			t.Errorf("expect NetworkDisabled is %s ,but actually is %s", networkDisabled, pod.Labels[gameKruiseV1alpha1.GameServerNetworkDisabled])
 		}
 
 		for gsKey, gsValue := range test.gs.GetAnnotations() {
@@ -911,3 +991,341 @@ func TestSyncPodToGs(t *testing.T) {
 		}
 	}
 }
+
+func TestServiceQualityHistory(t *testing.T) {
+	opsState := gameKruiseV1alpha1.OpsState("WaitToBeDeleted")
+	sq := gameKruiseV1alpha1.ServiceQuality{
+		Name:               "healthy",
+		ResultHistoryLimit: ptr.To[int32](2),
+		ServiceQualityAction: []gameKruiseV1alpha1.ServiceQualityAction{
+			{
+				State:          true,
+				Result:         "idle",
+				GameServerSpec: gameKruiseV1alpha1.GameServerSpec{OpsState: opsState},
+			},
+		},
+	}
+	probe := func(result string, probeTime metav1.Time) []corev1.PodCondition {
+		return []corev1.PodCondition{{
+			Type:          corev1.PodConditionType(util.AddPrefixGameKruise("healthy")),
+			Status:        corev1.ConditionTrue,
+			Message:       result,
+			LastProbeTime: probeTime,
+		}}
+	}
+	t1 := metav1.NewTime(time.Unix(100, 0))
+	t2 := metav1.NewTime(time.Unix(200, 0))
+	t3 := metav1.NewTime(time.Unix(300, 0))
+
+	_, _, conditions := syncServiceQualities([]gameKruiseV1alpha1.ServiceQuality{sq}, probe("busy", t1), nil)
+	if len(conditions[0].History) != 1 || conditions[0].History[0].Result != "busy" || conditions[0].History[0].Action != nil {
+		t.Errorf("expect history of busy without action, but actually got %v", conditions[0].History)
+	}
+
+	// the same probe result is not recorded again
+	_, _, conditions = syncServiceQualities([]gameKruiseV1alpha1.ServiceQuality{sq}, probe("busy", t1), conditions)
+	if len(conditions[0].History) != 1 {
+		t.Errorf("expect 1 history, but actually got %v", conditions[0].History)
+	}
+
+	_, _, conditions = syncServiceQualities([]gameKruiseV1alpha1.ServiceQuality{sq}, probe("idle", t2), conditions)
+	if len(conditions[0].History) != 2 || conditions[0].History[1].Action == nil || conditions[0].History[1].Action.OpsState != opsState {
+		t.Errorf("expect history of idle with action, but actually got %v", conditions[0].History)
+	}
+
+	// the oldest result is dropped beyond the limit
+	_, _, conditions = syncServiceQualities([]gameKruiseV1alpha1.ServiceQuality{sq}, probe("busy", t3), conditions)
+	if len(conditions[0].History) != 2 || conditions[0].History[0].Result != "idle" || !conditions[0].History[1].ProbeTime.Equal(&t3) {
+		t.Errorf("expect history of idle and busy, but actually got %v", conditions[0].History)
+	}
+
+	sq.ResultHistoryLimit = ptr.To[int32](0)
+	_, _, conditions = syncServiceQualities([]gameKruiseV1alpha1.ServiceQuality{sq}, probe("busy", t3), conditions)
+	if conditions[0].History != nil {
+		t.Errorf("expect no history, but actually got %v", conditions[0].History)
+	}
+}
+
+func TestEdgeTriggeredServiceQualityAction(t *testing.T) {
+	dp := intstr.FromInt(100)
+	sq := gameKruiseV1alpha1.ServiceQuality{
+		Name: "idle",
+		ServiceQualityAction: []gameKruiseV1alpha1.ServiceQualityAction{
+			{
+				State:          true,
+				EdgeTriggered:  true,
+				GameServerSpec: gameKruiseV1alpha1.GameServerSpec{DeletionPriority: &dp},
+			},
+		},
+	}
+	probe := func(status corev1.ConditionStatus, result string) []corev1.PodCondition {
+		return []corev1.PodCondition{{
+			Type:    corev1.PodConditionType(util.AddPrefixGameKruise("idle")),
+			Status:  status,
+			Message: result,
+		}}
+	}
+	recent := metav1.NewTime(time.Now().Add(-10 * time.Second))
+
+	tests := []struct {
+		reArmAfterSeconds *int32
+		podConditions     []corev1.PodCondition
+		sqConditions      []gameKruiseV1alpha1.ServiceQualityCondition
+		executed          bool
+	}{
+		// the first matching result
+		{
+			podConditions: probe(corev1.ConditionTrue, "players:0"),
+			executed:      true,
+		},
+		// the result changes but keeps matching
+		{
+			podConditions: probe(corev1.ConditionTrue, "players:1"),
+			sqConditions:  []gameKruiseV1alpha1.ServiceQualityCondition{{Name: "idle", Status: "True", Result: "players:0", LastActionTransitionTime: recent}},
+			executed:      false,
+		},
+		// the result changes from not matching to matching
+		{
+			podConditions: probe(corev1.ConditionTrue, "players:0"),
+			sqConditions:  []gameKruiseV1alpha1.ServiceQualityCondition{{Name: "idle", Status: "False", LastActionTransitionTime: recent}},
+			executed:      true,
+		},
+		// not re-armed yet
+		{
+			reArmAfterSeconds: ptr.To[int32](60),
+			podConditions:     probe(corev1.ConditionTrue, "players:0"),
+			sqConditions:      []gameKruiseV1alpha1.ServiceQualityCondition{{Name: "idle", Status: "False", LastActionTransitionTime: recent}},
+			executed:          false,
+		},
+		{
+			reArmAfterSeconds: ptr.To[int32](5),
+			podConditions:     probe(corev1.ConditionTrue, "players:0"),
+			sqConditions:      []gameKruiseV1alpha1.ServiceQualityCondition{{Name: "idle", Status: "False", LastActionTransitionTime: recent}},
+			executed:          true,
+		},
+	}
+
+	for i, test := range tests {
+		sq.ReArmAfterSeconds = test.reArmAfterSeconds
+		spec, _, conditions := syncServiceQualities([]gameKruiseV1alpha1.ServiceQuality{sq}, test.podConditions, test.sqConditions)
+		if executed := spec.DeletionPriority != nil; executed != test.executed {
+			t.Errorf("case %d: expect executed %v, but actually got %v", i, test.executed, executed)
+		}
+		if test.reArmAfterSeconds != nil && !test.executed && !conditions[0].LastActionTransitionTime.Equal(&recent) {
+			t.Errorf("case %d: expect the time of last action to be kept, but actually got %v", i, conditions[0].LastActionTransitionTime)
+		}
+	}
+}
+
+func TestConsecutiveServiceQualityAction(t *testing.T) {
+	one := resource.MustParse("1")
+	sq := gameKruiseV1alpha1.ServiceQuality{
+		Name:  "players",
+		Probe: corev1.Probe{PeriodSeconds: 10},
+		ServiceQualityAction: []gameKruiseV1alpha1.ServiceQualityAction{
+			{
+				State:             true,
+				ResultRange:       &gameKruiseV1alpha1.ServiceQualityResultRange{Field: "players", Lt: &one},
+				ConsecutiveProbes: ptr.To[int32](3),
+				GameServerSpec:    gameKruiseV1alpha1.GameServerSpec{OpsState: gameKruiseV1alpha1.WaitToDelete},
+			},
+		},
+	}
+	start := time.Now().Add(-time.Minute)
+	probe := func(message string) []corev1.PodCondition {
+		return []corev1.PodCondition{{
+			Type:          corev1.PodConditionType(util.AddPrefixGameKruise("players")),
+			Status:        corev1.ConditionTrue,
+			Message:       message,
+			LastProbeTime: metav1.NewTime(start),
+		}}
+	}
+	due := metav1.NewTime(start.Add(20 * time.Second))
+	future := metav1.NewTime(time.Now().Add(time.Minute))
+
+	tests := []struct {
+		podConditions  []corev1.PodCondition
+		sqConditions   []gameKruiseV1alpha1.ServiceQualityCondition
+		executed       bool
+		matchedActions []gameKruiseV1alpha1.ServiceQualityMatchedAction
+	}{
+		// the results not matching
+		{
+			podConditions: probe("players=2"),
+			executed:      false,
+		},
+		{
+			podConditions: probe("idle"),
+			executed:      false,
+		},
+		// the first matching result is due after two periods of the probe
+		{
+			podConditions:  probe("players=0"),
+			sqConditions:   []gameKruiseV1alpha1.ServiceQualityCondition{{Name: "players", Status: "True", Result: "players=2"}},
+			executed:       true,
+			matchedActions: []gameKruiseV1alpha1.ServiceQualityMatchedAction{{Index: 0, DueTime: due, Executed: true}},
+		},
+		// the result unchanged but not due yet
+		{
+			podConditions: probe("players=0"),
+			sqConditions: []gameKruiseV1alpha1.ServiceQualityCondition{{Name: "players", Status: "True", Result: "players=0",
+				MatchedActions: []gameKruiseV1alpha1.ServiceQualityMatchedAction{{Index: 0, DueTime: future}}}},
+			executed:       false,
+			matchedActions: []gameKruiseV1alpha1.ServiceQualityMatchedAction{{Index: 0, DueTime: future}},
+		},
+		// the result changes but keeps matching after the action executed
+		{
+			podConditions: probe("players=0.5"),
+			sqConditions: []gameKruiseV1alpha1.ServiceQualityCondition{{Name: "players", Status: "True", Result: "players=0",
+				MatchedActions: []gameKruiseV1alpha1.ServiceQualityMatchedAction{{Index: 0, DueTime: due, Executed: true}}}},
+			executed:       false,
+			matchedActions: []gameKruiseV1alpha1.ServiceQualityMatchedAction{{Index: 0, DueTime: due, Executed: true}},
+		},
+		// the result stops matching
+		{
+			podConditions: probe("players=1"),
+			sqConditions: []gameKruiseV1alpha1.ServiceQualityCondition{{Name: "players", Status: "True", Result: "players=0",
+				MatchedActions: []gameKruiseV1alpha1.ServiceQualityMatchedAction{{Index: 0, DueTime: due, Executed: true}}}},
+			executed: false,
+		},
+	}
+
+	for i, test := range tests {
+		spec, _, conditions := syncServiceQualities([]gameKruiseV1alpha1.ServiceQuality{sq}, test.podConditions, test.sqConditions)
+		if executed := spec.OpsState == gameKruiseV1alpha1.WaitToDelete; executed != test.executed {
+			t.Errorf("case %d: expect executed %v, but actually got %v", i, test.executed, executed)
+		}
+		if !reflect.DeepEqual(conditions[0].MatchedActions, test.matchedActions) {
+			t.Errorf("case %d: expect matched actions %v, but actually got %v", i, test.matchedActions, conditions[0].MatchedActions)
+		}
+	}
+
+	after := getMatchedActionsRequeueAfter([]gameKruiseV1alpha1.ServiceQualityCondition{{
+		MatchedActions: []gameKruiseV1alpha1.ServiceQualityMatchedAction{{DueTime: due, Executed: true}, {DueTime: future}},
+	}}, time.Now())
+	if after <= 0 || after > time.Minute {
+		t.Errorf("expect requeue within a minute, but actually got %v", after)
+	}
+}
+
+func TestSyncCustomStatus(t *testing.T) {
+	fields := []gameKruiseV1alpha1.CustomStatusField{
+		{Name: "map"},
+		{Name: "players", Type: gameKruiseV1alpha1.NumberCustomStatusFieldType},
+	}
+	tests := []struct {
+		annotations map[string]string
+		expected    map[string]string
+	}{
+		{
+			annotations: nil,
+			expected:    nil,
+		},
+		{
+			annotations: map[string]string{
+				gameKruiseV1alpha1.GameServerCustomStatusPrefix + "map":     "desert",
+				gameKruiseV1alpha1.GameServerCustomStatusPrefix + "players": "12",
+				gameKruiseV1alpha1.GameServerCustomStatusPrefix + "mode":    "ranked",
+			},
+			expected: map[string]string{"map": "desert", "players": "12"},
+		},
+		{
+			annotations: map[string]string{
+				gameKruiseV1alpha1.GameServerCustomStatusPrefix + "map":     "desert",
+				gameKruiseV1alpha1.GameServerCustomStatusPrefix + "players": "many",
+			},
+			expected: map[string]string{"map": "desert"},
+		},
+	}
+	for i, test := range tests {
+		actual := syncCustomStatus(fields, test.annotations)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.expected, actual)
+		}
+	}
+}
+
+func TestGetLeastPlayersDeletionPriority(t *testing.T) {
+	source := &gameKruiseV1alpha1.PlayersSource{
+		ServiceQualityName: "players",
+		Annotation:         gameKruiseV1alpha1.GameServerCustomStatusPrefix + "players",
+	}
+	tests := []struct {
+		source       *gameKruiseV1alpha1.PlayersSource
+		sqConditions []gameKruiseV1alpha1.ServiceQualityCondition
+		annotations  map[string]string
+		expected     *intstr.IntOrString
+	}{
+		{
+			source:       nil,
+			sqConditions: []gameKruiseV1alpha1.ServiceQualityCondition{{Name: "players", Result: "3"}},
+			expected:     nil,
+		},
+		{
+			source:       source,
+			sqConditions: []gameKruiseV1alpha1.ServiceQualityCondition{{Name: "healthy", Result: "1"}, {Name: "players", Result: "3"}},
+			annotations:  map[string]string{gameKruiseV1alpha1.GameServerCustomStatusPrefix + "players": "7"},
+			expected:     ptr.To(intstr.FromInt(-3)),
+		},
+		{
+			source:       source,
+			sqConditions: []gameKruiseV1alpha1.ServiceQualityCondition{{Name: "players", Result: "unknown"}},
+			annotations:  map[string]string{gameKruiseV1alpha1.GameServerCustomStatusPrefix + "players": "7"},
+			expected:     ptr.To(intstr.FromInt(-7)),
+		},
+		{
+			source:   source,
+			expected: nil,
+		},
+		{
+			source:      &gameKruiseV1alpha1.PlayersSource{Annotation: "players"},
+			annotations: map[string]string{"players": "0"},
+			expected:    ptr.To(intstr.FromInt(0)),
+		},
+	}
+	for i, test := range tests {
+		actual := getLeastPlayersDeletionPriority(test.source, test.sqConditions, test.annotations)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.expected, actual)
+		}
+	}
+}
+
+func TestGetNetworkReadyTime(t *testing.T) {
+	transitionTime := metav1.NewTime(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	networkStatus := func(state gameKruiseV1alpha1.NetworkState) string {
+		status, _ := json.Marshal(gameKruiseV1alpha1.NetworkStatus{CurrentNetworkState: state, LastTransitionTime: transitionTime})
+		return string(status)
+	}
+	tests := []struct {
+		annotations map[string]string
+		expected    string
+	}{
+		{
+			annotations: map[string]string{gameKruiseV1alpha1.GameServerNetworkStatus: networkStatus(gameKruiseV1alpha1.NetworkReady)},
+			expected:    "2024-01-01T12:00:00Z",
+		},
+		{
+			annotations: map[string]string{gameKruiseV1alpha1.GameServerNetworkStatus: networkStatus(gameKruiseV1alpha1.NetworkNotReady)},
+			expected:    "",
+		},
+		{
+			annotations: map[string]string{
+				gameKruiseV1alpha1.GameServerNetworkStatus:    networkStatus(gameKruiseV1alpha1.NetworkReady),
+				gameKruiseV1alpha1.GameServerNetworkReadyTime: "2023-12-31T12:00:00Z",
+			},
+			expected: "",
+		},
+		{
+			annotations: nil,
+			expected:    "",
+		},
+	}
+
+	for i, test := range tests {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+		if actual := getNetworkReadyTime(pod); actual != test.expected {
+			t.Errorf("case %d: expect %s, but got %s", i, test.expected, actual)
+		}
+	}
+}
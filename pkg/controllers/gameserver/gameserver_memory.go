@@ -0,0 +1,238 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+const (
+	MemoryLeakReason = "MemoryLeak"
+
+	// MemorySampleInterval is the interval of sampling the memory usage of GameServers with MemoryLeakPolicy.
+	MemorySampleInterval = 30 * time.Second
+)
+
+//+kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get
+
+var podMetricsGVK = schema.GroupVersionKind{Group: "metrics.k8s.io", Version: "v1beta1", Kind: "PodMetrics"}
+
+type memorySample struct {
+	Time  time.Time
+	Bytes int64
+}
+
+type memoryHistory struct {
+	uid     types.UID
+	samples []memorySample
+}
+
+// memoryTracker keeps the memory usage samples of the last window of pods in memory.
+// The samples are lost when the controller restarts, and are collected again from then on.
+type memoryTracker struct {
+	lock      sync.Mutex
+	histories map[types.NamespacedName]*memoryHistory
+}
+
+func newMemoryTracker() *memoryTracker {
+	return &memoryTracker{histories: make(map[types.NamespacedName]*memoryHistory)}
+}
+
+// record appends the sample of the pod unless it is not newer than the latest one, and returns the samples
+// of the last window, together with the latest sample before the window if any.
+func (t *memoryTracker) record(key types.NamespacedName, uid types.UID, sample memorySample, window time.Duration) []memorySample {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	history, ok := t.histories[key]
+	if !ok || history.uid != uid {
+		history = &memoryHistory{uid: uid}
+		t.histories[key] = history
+	}
+	if n := len(history.samples); n == 0 || sample.Time.After(history.samples[n-1].Time) {
+		history.samples = append(history.samples, sample)
+	}
+	latest := history.samples[len(history.samples)-1].Time
+	for len(history.samples) > 1 && !history.samples[1].Time.After(latest.Add(-window)) {
+		history.samples = history.samples[1:]
+	}
+	return append([]memorySample(nil), history.samples...)
+}
+
+func (t *memoryTracker) forget(key types.NamespacedName) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.histories, key)
+}
+
+// getMemoryUsage returns the memory usage of the container in the PodMetrics of the pod.
+func getMemoryUsage(ctx context.Context, c client.Reader, pod *corev1.Pod, containerName string) (memorySample, error) {
+	podMetrics := &unstructured.Unstructured{}
+	podMetrics.SetGroupVersionKind(podMetricsGVK)
+	if err := c.Get(ctx, types.NamespacedName{Namespace: pod.GetNamespace(), Name: pod.GetName()}, podMetrics); err != nil {
+		return memorySample{}, err
+	}
+	timestamp, _, _ := unstructured.NestedString(podMetrics.Object, "timestamp")
+	sampleTime, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return memorySample{}, fmt.Errorf("invalid timestamp %q of PodMetrics", timestamp)
+	}
+	containers, _, _ := unstructured.NestedSlice(podMetrics.Object, "containers")
+	for _, container := range containers {
+		c, ok := container.(map[string]interface{})
+		if !ok || c["name"] != containerName {
+			continue
+		}
+		memory, _, _ := unstructured.NestedString(c, "usage", "memory")
+		usage, err := resource.ParseQuantity(memory)
+		if err != nil {
+			return memorySample{}, fmt.Errorf("invalid memory usage %q of container %s", memory, containerName)
+		}
+		return memorySample{Time: sampleTime, Bytes: usage.Value()}, nil
+	}
+	return memorySample{}, fmt.Errorf("no metrics of container %s", containerName)
+}
+
+// isMemoryLeaking returns whether the samples, which must cover the window, cross the thresholds of the policy,
+// and the description of the crossed threshold.
+func isMemoryLeaking(policy *gameKruiseV1alpha1.MemoryLeakPolicy, samples []memorySample, limit int64, window time.Duration) (bool, string) {
+	if len(samples) < 2 || samples[len(samples)-1].Time.Sub(samples[0].Time) < window {
+		return false, ""
+	}
+	if policy.LimitPercent != nil && limit > 0 {
+		threshold := limit * int64(*policy.LimitPercent) / 100
+		above := true
+		for _, sample := range samples {
+			if sample.Bytes < threshold {
+				above = false
+				break
+			}
+		}
+		if above {
+			return true, fmt.Sprintf("stays at or above %d%% of the limit for %v", *policy.LimitPercent, window)
+		}
+	}
+	if policy.GrowthPerMinute != nil {
+		if slope := memoryGrowthPerMinute(samples); slope >= float64(policy.GrowthPerMinute.Value()) {
+			growth := resource.NewQuantity(int64(slope), resource.BinarySI)
+			return true, fmt.Sprintf("grows by %s per minute over %v", growth.String(), window)
+		}
+	}
+	return false, ""
+}
+
+// memoryGrowthPerMinute returns the slope of the samples in bytes per minute by the least squares fitting.
+func memoryGrowthPerMinute(samples []memorySample) float64 {
+	n := float64(len(samples))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range samples {
+		x := sample.Time.Sub(samples[0].Time).Minutes()
+		y := float64(sample.Bytes)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
+
+// syncMemoryLeakPolicy samples the memory usage of the GameServer, and sets the opsState of the GameServer when
+// its memory usage crosses the thresholds of MemoryLeakPolicy. It returns the interval to sample again, or 0
+// if no more samples are needed.
+func (r *GameServerReconciler) syncMemoryLeakPolicy(ctx context.Context, gss *gameKruiseV1alpha1.GameServerSet, gs *gameKruiseV1alpha1.GameServer, pod *corev1.Pod) (time.Duration, error) {
+	key := types.NamespacedName{Namespace: pod.GetNamespace(), Name: pod.GetName()}
+	policy := gss.Spec.MemoryLeakPolicy
+	if policy == nil || pod.GetDeletionTimestamp() != nil || len(pod.Spec.Containers) == 0 {
+		r.memoryTracker.forget(key)
+		return 0, nil
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return MemorySampleInterval, nil
+	}
+
+	containerName := policy.ContainerName
+	if containerName == "" {
+		containerName = util.GetGameContainerName(pod)
+	}
+	container := pod.Spec.Containers[0]
+	for _, c := range pod.Spec.Containers {
+		if c.Name == containerName {
+			container = c
+			break
+		}
+	}
+	sample, err := getMemoryUsage(ctx, r.Client, pod, container.Name)
+	if err != nil {
+		// the metrics are not ready yet, or metrics.k8s.io is not served
+		klog.V(4).Infof("failed to get memory usage of GameServer %s in %s, because of %s.", gs.GetName(), gs.GetNamespace(), err.Error())
+		return MemorySampleInterval, nil
+	}
+	durationMinutes := int32(gameKruiseV1alpha1.DefaultMemoryLeakDurationMinutes)
+	if policy.DurationMinutes != nil {
+		durationMinutes = *policy.DurationMinutes
+	}
+	window := time.Duration(durationMinutes) * time.Minute
+	samples := r.memoryTracker.record(key, pod.GetUID(), sample, window)
+
+	// only the idle GameServers are drained, the others keep being sampled until they are idle
+	if gs.Spec.OpsState != gameKruiseV1alpha1.None && gs.Spec.OpsState != "" {
+		return MemorySampleInterval, nil
+	}
+	leaking, description := isMemoryLeaking(policy, samples, container.Resources.Limits.Memory().Value(), window)
+	if !leaking {
+		return MemorySampleInterval, nil
+	}
+
+	opsState := policy.OpsState
+	if opsState == "" {
+		opsState = gameKruiseV1alpha1.WaitToDelete
+	}
+	patchSpec := map[string]interface{}{"spec": map[string]interface{}{"opsState": opsState}}
+	jsonPatchSpec, err := json.Marshal(patchSpec)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.Client.Patch(ctx, gs, client.RawPatch(types.MergePatchType, jsonPatchSpec)); err != nil {
+		if errors.IsNotFound(err) {
+			return 0, nil
+		}
+		klog.Errorf("failed to patch GameServer spec %s in %s,because of %s.", gs.GetName(), gs.GetNamespace(), err.Error())
+		return 0, err
+	}
+	r.recorder.Eventf(gs, corev1.EventTypeWarning, MemoryLeakReason, "memory usage of container %s %s, opsState is set to %s", container.Name, description, opsState)
+	r.memoryTracker.forget(key)
+	return 0, nil
+}
@@ -0,0 +1,192 @@
+package gameserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestMemoryTrackerRecord(t *testing.T) {
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	key := types.NamespacedName{Namespace: "xxx", Name: "case-0"}
+	tracker := newMemoryTracker()
+	for i := 0; i <= 10; i++ {
+		tracker.record(key, "uid-1", memorySample{Time: base.Add(time.Duration(i) * time.Minute), Bytes: int64(i)}, 5*time.Minute)
+	}
+	// the duplicated sample is ignored
+	samples := tracker.record(key, "uid-1", memorySample{Time: base.Add(10 * time.Minute), Bytes: 100}, 5*time.Minute)
+	if len(samples) != 6 || samples[0].Bytes != 5 || samples[5].Bytes != 10 {
+		t.Errorf("expect samples from 5 to 10, but actually got %v", samples)
+	}
+
+	// the samples of the old pod are dropped when the pod is recreated
+	samples = tracker.record(key, "uid-2", memorySample{Time: base.Add(11 * time.Minute), Bytes: 1}, 5*time.Minute)
+	if len(samples) != 1 || samples[0].Bytes != 1 {
+		t.Errorf("expect samples of the new pod only, but actually got %v", samples)
+	}
+}
+
+func TestIsMemoryLeaking(t *testing.T) {
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	mi := int64(1024 * 1024)
+	newSamples := func(minutes int, bytes func(i int) int64) []memorySample {
+		var samples []memorySample
+		for i := 0; i <= minutes; i++ {
+			samples = append(samples, memorySample{Time: base.Add(time.Duration(i) * time.Minute), Bytes: bytes(i)})
+		}
+		return samples
+	}
+	growth := resource.MustParse("10Mi")
+	tests := []struct {
+		policy  *gameKruiseV1alpha1.MemoryLeakPolicy
+		samples []memorySample
+		limit   int64
+		leaking bool
+	}{
+		// the samples do not cover the window
+		{
+			policy:  &gameKruiseV1alpha1.MemoryLeakPolicy{GrowthPerMinute: &growth},
+			samples: newSamples(5, func(i int) int64 { return int64(i) * 100 * mi }),
+			leaking: false,
+		},
+		// grows faster than the threshold
+		{
+			policy:  &gameKruiseV1alpha1.MemoryLeakPolicy{GrowthPerMinute: &growth},
+			samples: newSamples(10, func(i int) int64 { return 500*mi + int64(i)*20*mi }),
+			leaking: true,
+		},
+		// grows slower than the threshold
+		{
+			policy:  &gameKruiseV1alpha1.MemoryLeakPolicy{GrowthPerMinute: &growth},
+			samples: newSamples(10, func(i int) int64 { return 500*mi + int64(i%2)*50*mi }),
+			leaking: false,
+		},
+		// stays above the percentage of the limit
+		{
+			policy:  &gameKruiseV1alpha1.MemoryLeakPolicy{LimitPercent: ptr.To[int32](90)},
+			samples: newSamples(10, func(i int) int64 { return 950 * mi }),
+			limit:   1000 * mi,
+			leaking: true,
+		},
+		// drops below the percentage of the limit once
+		{
+			policy: &gameKruiseV1alpha1.MemoryLeakPolicy{LimitPercent: ptr.To[int32](90)},
+			samples: newSamples(10, func(i int) int64 {
+				if i == 5 {
+					return 800 * mi
+				}
+				return 950 * mi
+			}),
+			limit:   1000 * mi,
+			leaking: false,
+		},
+		// no memory limit
+		{
+			policy:  &gameKruiseV1alpha1.MemoryLeakPolicy{LimitPercent: ptr.To[int32](90)},
+			samples: newSamples(10, func(i int) int64 { return 950 * mi }),
+			leaking: false,
+		},
+	}
+
+	for i, test := range tests {
+		leaking, _ := isMemoryLeaking(test.policy, test.samples, test.limit, 10*time.Minute)
+		if leaking != test.leaking {
+			t.Errorf("case %d: expect leaking %v, but actually got %v", i, test.leaking, leaking)
+		}
+	}
+}
+
+func newPodMetrics(namespace, name, container, memory string, timestamp time.Time) *unstructured.Unstructured {
+	podMetrics := &unstructured.Unstructured{Object: map[string]interface{}{
+		"timestamp": timestamp.Format(time.RFC3339),
+		"containers": []interface{}{
+			map[string]interface{}{
+				"name":  container,
+				"usage": map[string]interface{}{"cpu": "100m", "memory": memory},
+			},
+		},
+	}}
+	podMetrics.SetGroupVersionKind(podMetricsGVK)
+	podMetrics.SetNamespace(namespace)
+	podMetrics.SetName(name)
+	return podMetrics
+}
+
+func TestSyncMemoryLeakPolicy(t *testing.T) {
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			MemoryLeakPolicy: &gameKruiseV1alpha1.MemoryLeakPolicy{
+				ContainerName:   "game",
+				LimitPercent:    ptr.To[int32](90),
+				DurationMinutes: ptr.To[int32](2),
+			},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0", UID: "uid-0"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "sidecar"},
+				{
+					Name: "game",
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	gs := &gameKruiseV1alpha1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0"},
+		Spec:       gameKruiseV1alpha1.GameServerSpec{OpsState: gameKruiseV1alpha1.None},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gs).Build()
+	r := &GameServerReconciler{Client: c, recorder: record.NewFakeRecorder(10), memoryTracker: newMemoryTracker()}
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i := 0; i <= 2; i++ {
+		podMetrics := newPodMetrics("xxx", "case-0", "game", "1000Mi", base.Add(time.Duration(i)*time.Minute))
+		if i > 0 {
+			if err := c.Delete(context.TODO(), podMetrics); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := c.Create(context.TODO(), podMetrics); err != nil {
+			t.Fatal(err)
+		}
+		sampleAfter, err := r.syncMemoryLeakPolicy(context.TODO(), gss, gs, pod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectOpsState := gameKruiseV1alpha1.None
+		expectSampleAfter := MemorySampleInterval
+		if i == 2 {
+			expectOpsState = gameKruiseV1alpha1.WaitToDelete
+			expectSampleAfter = 0
+		}
+		if sampleAfter != expectSampleAfter {
+			t.Errorf("sample %d: expect to sample again after %v, but actually got %v", i, expectSampleAfter, sampleAfter)
+		}
+		actualGs := &gameKruiseV1alpha1.GameServer{}
+		if err := c.Get(context.TODO(), client.ObjectKeyFromObject(gs), actualGs); err != nil {
+			t.Fatal(err)
+		}
+		if actualGs.Spec.OpsState != expectOpsState {
+			t.Errorf("sample %d: expect opsState %s, but actually got %s", i, expectOpsState, actualGs.Spec.OpsState)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserver
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+var (
+	// concurrentNetworkReconciles is the number of workers of the network queue, which is separated from the
+	// lifecycle queue so that the network plugins waiting for the cloud do not hold back the opsState changes.
+	concurrentNetworkReconciles = 10
+)
+
+// GameServerNetworkReconciler reconciles the network of GameServers, that is, it syncs the network status and
+// triggers the network plugins of the pods, while the GameServerReconciler handles the lifecycle.
+type GameServerNetworkReconciler struct {
+	client.Client
+	recorder record.EventRecorder
+}
+
+func newNetworkReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &GameServerNetworkReconciler{
+		Client:   mgr.GetClient(),
+		recorder: mgr.GetEventRecorderFor("gameserver-network-controller"),
+	}
+}
+
+func addNetwork(mgr manager.Manager, r reconcile.Reconciler) error {
+	klog.Info("Starting GameServer Network Controller")
+	c, err := controller.New("gameserver-network-controller", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: concurrentNetworkReconciles})
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	if err = c.Watch(&source.Kind{Type: &gamekruiseiov1alpha1.GameServer{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		klog.Error(err)
+		return err
+	}
+	if err = c.Watch(&source.Kind{Type: &corev1.Pod{}}, &handler.EnqueueRequestForObject{}, predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return isNetworkPod(e.Object) },
+		UpdateFunc:  func(e event.UpdateEvent) bool { return isNetworkPod(e.ObjectNew) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return false },
+	}); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+// isNetworkPod returns whether the pod belongs to a GameServerSet and has the network handled by a plugin.
+func isNetworkPod(obj client.Object) bool {
+	_, owned := obj.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]
+	return owned && obj.GetAnnotations()[gamekruiseiov1alpha1.GameServerNetworkType] != ""
+}
+
+func (r *GameServerNetworkReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	namespacedName := req.NamespacedName
+
+	// the GameServers are created and deleted by the lifecycle queue
+	pod := &corev1.Pod{}
+	if err := r.Get(ctx, namespacedName, pod); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		klog.Errorf("failed to find pod %s in %s, because of %s.", namespacedName.Name, namespacedName.Namespace, err.Error())
+		return reconcile.Result{}, err
+	}
+	gs := &gamekruiseiov1alpha1.GameServer{}
+	if err := r.Get(ctx, namespacedName, gs); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		klog.Errorf("failed to find GameServer %s in %s, because of %s.", namespacedName.Name, namespacedName.Namespace, err.Error())
+		return reconcile.Result{}, err
+	}
+	if pod.GetDeletionTimestamp() != nil {
+		return reconcile.Result{}, nil
+	}
+
+	gsm := NewGameServerManager(gs, pod, r.Client, r.recorder)
+	err := gsm.SyncNetwork()
+	// the conflicts are not reported, since the GameServer changed is synced again soon
+	if isNetworkPod(pod) && !errors.IsConflict(err) {
+		if conditionErr := gsm.SyncSyncedCondition(gamekruiseiov1alpha1.NetworkSynced, networkSyncFailedReason, err); conditionErr != nil && err == nil {
+			return reconcile.Result{}, conditionErr
+		}
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if gsm.WaitForNetwork() {
+		return ctrl.Result{RequeueAfter: NetworkIntervalTime}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+func (manager GameServerManager) SyncNetwork() error {
+	gs := manager.gameServer
+	pod := manager.pod
+
+	networkStatus := manager.syncNetworkStatus()
+	conditions := manager.syncNetworkCondition()
+	if !reflect.DeepEqual(networkStatus, gs.Status.NetworkStatus) || !reflect.DeepEqual(conditions, gs.Status.Conditions) {
+		// the resourceVersion rejects the patch if the conditions of the lifecycle queue are changed since it is read
+		patchStatus := map[string]interface{}{
+			"metadata": map[string]interface{}{"resourceVersion": gs.GetResourceVersion()},
+			"status":   map[string]interface{}{"networkStatus": networkStatus, "conditions": conditions},
+		}
+		jsonPatchStatus, err := json.Marshal(patchStatus)
+		if err != nil {
+			return err
+		}
+		err = manager.client.Status().Patch(context.TODO(), gs, client.RawPatch(types.MergePatchType, jsonPatchStatus))
+		if err != nil && !errors.IsNotFound(err) {
+			klog.Errorf("failed to patch GameServer network status %s in %s, because of %s.", gs.GetName(), gs.GetNamespace(), err.Error())
+			return err
+		}
+		gs.Status.NetworkStatus = networkStatus
+		gs.Status.Conditions = conditions
+	}
+
+	// the pod update passes the network plugin, which may wait for the cloud until the webhook times out
+	if isNeedToTriggerNetwork(pod, gs) {
+		patchPod := map[string]interface{}{
+			"metadata": map[string]interface{}{"annotations": map[string]string{gamekruiseiov1alpha1.GameServerNetworkTriggerTime: time.Now().Format(TimeFormat)}},
+		}
+		patchPodBytes, err := json.Marshal(patchPod)
+		if err != nil {
+			return err
+		}
+		err = manager.client.Patch(context.TODO(), pod, client.RawPatch(types.MergePatchType, patchPodBytes))
+		if err != nil && !errors.IsNotFound(err) {
+			klog.Errorf("failed to trigger the network of Pod %s in %s, because of %s.", pod.GetName(), pod.GetNamespace(), err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// syncNetworkCondition returns the conditions of the GameServer with the NetworkNormal condition of the pod, which is
+// reported by the network queue instead of the lifecycle queue.
+func (manager GameServerManager) syncNetworkCondition() []gamekruiseiov1alpha1.GameServerCondition {
+	gs := manager.gameServer
+	networkCondition, ok := getNetworkCondition(manager.pod)
+	if !ok {
+		return setGsConditions(gs.Status.Conditions, []gamekruiseiov1alpha1.GameServerConditionType{gamekruiseiov1alpha1.NetworkNormal})
+	}
+	oldNetworkCondition := getGsCondition(gs.Status.Conditions, gamekruiseiov1alpha1.NetworkNormal)
+	if !isConditionEqual(networkCondition, oldNetworkCondition) {
+		networkCondition.LastTransitionTime = metav1.Now()
+		if networkCondition.Status == corev1.ConditionFalse {
+			manager.eventRecorder.Event(gs, corev1.EventTypeWarning, networkCondition.Reason, networkCondition.Message)
+		}
+	} else {
+		networkCondition.LastTransitionTime = oldNetworkCondition.LastTransitionTime
+	}
+	return setGsConditions(gs.Status.Conditions, []gamekruiseiov1alpha1.GameServerConditionType{gamekruiseiov1alpha1.NetworkNormal}, networkCondition)
+}
+
+// isNeedToTriggerNetwork returns whether to trigger the network plugin of the pod again, which is done every
+// NetworkIntervalTime until NetworkTotalWaitTime passes since the network status changes, or while the network
+// turned NotReady is stabilizing.
+func isNeedToTriggerNetwork(pod *corev1.Pod, gs *gamekruiseiov1alpha1.GameServer) bool {
+	annotations := pod.GetAnnotations()
+	if annotations[gamekruiseiov1alpha1.GameServerNetworkType] == "" {
+		return false
+	}
+	if annotations[gamekruiseiov1alpha1.GameServerNetworkTriggerTime] == "" {
+		return true
+	}
+	oldTime, err := time.Parse(TimeFormat, annotations[gamekruiseiov1alpha1.GameServerNetworkTriggerTime])
+	_, stabilizing := annotations[gamekruiseiov1alpha1.GameServerNetworkNotReadySince]
+	return err == nil && time.Since(oldTime) > NetworkIntervalTime && (time.Since(gs.Status.NetworkStatus.LastTransitionTime.Time) < NetworkTotalWaitTime || stabilizing || isNetworkDraining(pod))
+}
+
+// isNetworkDraining returns true when the disabled network of the pod is draining, the deadline of which is
+// extended by NetworkTotalWaitTime for the plugin to complete it, so that a stale deadline stops triggering.
+func isNetworkDraining(pod *corev1.Pod) bool {
+	until, err := time.Parse(time.RFC3339, pod.GetAnnotations()[gamekruiseiov1alpha1.GameServerNetworkDrainUntil])
+	return err == nil && time.Now().Before(until.Add(NetworkTotalWaitTime))
+}
+
+func (manager GameServerManager) WaitForNetwork() bool {
+	networkStatus := manager.gameServer.Status.NetworkStatus
+	alreadyWait := time.Since(networkStatus.LastTransitionTime.Time)
+	if networkStatus.DesiredNetworkState != networkStatus.CurrentNetworkState && alreadyWait < NetworkTotalWaitTime {
+		klog.Infof("GameServer %s/%s DesiredNetworkState: %s CurrentNetworkState: %s. %v remaining",
+			manager.gameServer.GetNamespace(), manager.gameServer.GetName(), networkStatus.DesiredNetworkState, networkStatus.CurrentNetworkState, NetworkTotalWaitTime-alreadyWait)
+		return true
+	}
+	// the network turned NotReady is kept Ready within the stabilization window, check it again later
+	_, stabilizing := manager.pod.GetAnnotations()[gamekruiseiov1alpha1.GameServerNetworkNotReadySince]
+	// the disabled network is draining, check it again until the draining is completed
+	return stabilizing || isNetworkDraining(manager.pod)
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserver
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestIsNeedToTriggerNetwork(t *testing.T) {
+	recent := time.Now().Add(-NetworkIntervalTime / 2).Format(TimeFormat)
+	past := time.Now().Add(-2 * NetworkIntervalTime).Format(TimeFormat)
+	tests := []struct {
+		annotations    map[string]string
+		transitionTime time.Time
+		expect         bool
+	}{
+		// no network
+		{annotations: nil, expect: false},
+		{annotations: map[string]string{gameKruiseV1alpha1.GameServerNetworkType: "Kubernetes-HostPort"}, expect: true},
+		// triggered recently
+		{
+			annotations:    map[string]string{gameKruiseV1alpha1.GameServerNetworkType: "Kubernetes-HostPort", gameKruiseV1alpha1.GameServerNetworkTriggerTime: recent},
+			transitionTime: time.Now(),
+			expect:         false,
+		},
+		{
+			annotations:    map[string]string{gameKruiseV1alpha1.GameServerNetworkType: "Kubernetes-HostPort", gameKruiseV1alpha1.GameServerNetworkTriggerTime: past},
+			transitionTime: time.Now(),
+			expect:         true,
+		},
+		// waited too long
+		{
+			annotations:    map[string]string{gameKruiseV1alpha1.GameServerNetworkType: "Kubernetes-HostPort", gameKruiseV1alpha1.GameServerNetworkTriggerTime: past},
+			transitionTime: time.Now().Add(-2 * NetworkTotalWaitTime),
+			expect:         false,
+		},
+		// the network turned NotReady is stabilizing
+		{
+			annotations: map[string]string{
+				gameKruiseV1alpha1.GameServerNetworkType:          "Kubernetes-HostPort",
+				gameKruiseV1alpha1.GameServerNetworkTriggerTime:   past,
+				gameKruiseV1alpha1.GameServerNetworkNotReadySince: time.Now().Format(time.RFC3339),
+			},
+			transitionTime: time.Now().Add(-2 * NetworkTotalWaitTime),
+			expect:         true,
+		},
+		// the disabled network is draining longer than the total wait time
+		{
+			annotations: map[string]string{
+				gameKruiseV1alpha1.GameServerNetworkType:        "Kubernetes-HostPort",
+				gameKruiseV1alpha1.GameServerNetworkTriggerTime: past,
+				gameKruiseV1alpha1.GameServerNetworkDrainUntil:  time.Now().Add(time.Hour).Format(time.RFC3339),
+			},
+			transitionTime: time.Now().Add(-2 * NetworkTotalWaitTime),
+			expect:         true,
+		},
+		// the draining deadline is stale
+		{
+			annotations: map[string]string{
+				gameKruiseV1alpha1.GameServerNetworkType:        "Kubernetes-HostPort",
+				gameKruiseV1alpha1.GameServerNetworkTriggerTime: past,
+				gameKruiseV1alpha1.GameServerNetworkDrainUntil:  time.Now().Add(-2 * NetworkTotalWaitTime).Format(time.RFC3339),
+			},
+			transitionTime: time.Now().Add(-2 * NetworkTotalWaitTime),
+			expect:         false,
+		},
+	}
+	for i, test := range tests {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+		gs := &gameKruiseV1alpha1.GameServer{Status: gameKruiseV1alpha1.GameServerStatus{
+			NetworkStatus: gameKruiseV1alpha1.NetworkStatus{LastTransitionTime: metav1.NewTime(test.transitionTime)},
+		}}
+		if actual := isNeedToTriggerNetwork(pod, gs); actual != test.expect {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.expect, actual)
+		}
+	}
+}
+
+func TestSyncNetwork(t *testing.T) {
+	gss := &gameKruiseV1alpha1.GameServerSet{ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "foo"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "xxx",
+			Name:        "foo-0",
+			Labels:      map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "foo"},
+			Annotations: map[string]string{gameKruiseV1alpha1.GameServerNetworkType: "Kubernetes-HostPort"},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	gs := &gameKruiseV1alpha1.GameServer{ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "foo-0"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gss, pod, gs).Build()
+	key := types.NamespacedName{Namespace: "xxx", Name: "foo-0"}
+
+	networkManager := NewGameServerManager(gs.DeepCopy(), pod.DeepCopy(), c, record.NewFakeRecorder(10))
+	if err := networkManager.SyncNetwork(); err != nil {
+		t.Fatal(err)
+	}
+	if !networkManager.WaitForNetwork() {
+		t.Errorf("expect to wait for the network")
+	}
+	actualPod := &corev1.Pod{}
+	if err := c.Get(context.TODO(), key, actualPod); err != nil {
+		t.Fatal(err)
+	}
+	if actualPod.GetAnnotations()[gameKruiseV1alpha1.GameServerNetworkTriggerTime] == "" {
+		t.Errorf("expect the network of the pod triggered")
+	}
+
+	// the lifecycle with the stale GameServer keeps the network status
+	lifecycleManager := NewGameServerManager(gs.DeepCopy(), actualPod, c, record.NewFakeRecorder(10))
+	if err := lifecycleManager.SyncPodToGs(gss); err != nil {
+		t.Fatal(err)
+	}
+	if lifecycleManager.WaitOrNot() {
+		t.Errorf("expect the lifecycle not to wait for the network")
+	}
+	actualGs := &gameKruiseV1alpha1.GameServer{}
+	if err := c.Get(context.TODO(), key, actualGs); err != nil {
+		t.Fatal(err)
+	}
+	if actualGs.Status.NetworkStatus.DesiredNetworkState != gameKruiseV1alpha1.NetworkReady || actualGs.Status.DesiredState != gameKruiseV1alpha1.Ready {
+		t.Errorf("expect the network status and the state both synced, but actually got %v", actualGs.Status)
+	}
+}
+
+func TestSetGsConditions(t *testing.T) {
+	podNormal := gameKruiseV1alpha1.GameServerCondition{Type: gameKruiseV1alpha1.PodNormal, Status: corev1.ConditionTrue}
+	podAbnormal := gameKruiseV1alpha1.GameServerCondition{Type: gameKruiseV1alpha1.PodNormal, Status: corev1.ConditionFalse}
+	networkNormal := gameKruiseV1alpha1.GameServerCondition{Type: gameKruiseV1alpha1.NetworkNormal, Status: corev1.ConditionTrue}
+	shuttingDown := gameKruiseV1alpha1.GameServerCondition{Type: gameKruiseV1alpha1.ShuttingDown, Status: corev1.ConditionTrue}
+	tests := []struct {
+		conditions    []gameKruiseV1alpha1.GameServerCondition
+		newConditions []gameKruiseV1alpha1.GameServerCondition
+		expect        []gameKruiseV1alpha1.GameServerCondition
+	}{
+		{
+			newConditions: []gameKruiseV1alpha1.GameServerCondition{podNormal},
+			expect:        []gameKruiseV1alpha1.GameServerCondition{podNormal},
+		},
+		// the conditions of the network are kept, and the order is kept
+		{
+			conditions:    []gameKruiseV1alpha1.GameServerCondition{podNormal, networkNormal},
+			newConditions: []gameKruiseV1alpha1.GameServerCondition{podAbnormal},
+			expect:        []gameKruiseV1alpha1.GameServerCondition{podAbnormal, networkNormal},
+		},
+		// the new types are appended, and the ones not reported any more are removed
+		{
+			conditions:    []gameKruiseV1alpha1.GameServerCondition{shuttingDown, networkNormal},
+			newConditions: []gameKruiseV1alpha1.GameServerCondition{podNormal},
+			expect:        []gameKruiseV1alpha1.GameServerCondition{networkNormal, podNormal},
+		},
+	}
+	for i, test := range tests {
+		if actual := setGsConditions(test.conditions, lifecycleConditionTypes, test.newConditions...); !reflect.DeepEqual(actual, test.expect) {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.expect, actual)
+		}
+	}
+}
+
+func TestSyncedConditions(t *testing.T) {
+	gss := &gameKruiseV1alpha1.GameServerSet{ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "foo"}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "xxx",
+			Name:      "foo-0",
+			Labels:    map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "foo"},
+			Annotations: map[string]string{
+				gameKruiseV1alpha1.GameServerNetworkType:   "Kubernetes-HostPort",
+				gameKruiseV1alpha1.GameServerNetworkStatus: `{"currentNetworkState":"NotReady","reason":"LoadBalancerNotReady"}`,
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	gs := &gameKruiseV1alpha1.GameServer{ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "foo-0"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gss, pod, gs).Build()
+	key := types.NamespacedName{Namespace: "xxx", Name: "foo-0"}
+	getGs := func() *gameKruiseV1alpha1.GameServer {
+		actual := &gameKruiseV1alpha1.GameServer{}
+		if err := c.Get(context.TODO(), key, actual); err != nil {
+			t.Fatal(err)
+		}
+		return actual
+	}
+
+	// the lifecycle reports its conditions
+	staleGs := getGs()
+	lifecycleManager := NewGameServerManager(getGs(), pod.DeepCopy(), c, record.NewFakeRecorder(10))
+	if err := lifecycleManager.SyncPodToGs(gss); err != nil {
+		t.Fatal(err)
+	}
+	if err := lifecycleManager.SyncSyncedCondition(gameKruiseV1alpha1.LifecycleSynced, lifecycleSyncFailedReason, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the network with the stale GameServer does not overwrite the conditions of the lifecycle
+	networkManager := NewGameServerManager(staleGs, pod.DeepCopy(), c, record.NewFakeRecorder(10))
+	if err := networkManager.SyncNetwork(); !errors.IsConflict(err) {
+		t.Errorf("expect the conflict, but actually got %v", err)
+	}
+	networkManager = NewGameServerManager(getGs(), pod.DeepCopy(), c, record.NewFakeRecorder(10))
+	if err := networkManager.SyncNetwork(); err != nil {
+		t.Fatal(err)
+	}
+	if err := networkManager.SyncSyncedCondition(gameKruiseV1alpha1.NetworkSynced, networkSyncFailedReason, fmt.Errorf("failed to patch pod")); err != nil {
+		t.Fatal(err)
+	}
+
+	conditions := getGs().Status.Conditions
+	expect := map[gameKruiseV1alpha1.GameServerConditionType]corev1.ConditionStatus{
+		gameKruiseV1alpha1.PodNormal:              corev1.ConditionTrue,
+		gameKruiseV1alpha1.PersistentVolumeNormal: corev1.ConditionTrue,
+		gameKruiseV1alpha1.LifecycleSynced:        corev1.ConditionTrue,
+		gameKruiseV1alpha1.NetworkNormal:          corev1.ConditionFalse,
+		gameKruiseV1alpha1.NetworkSynced:          corev1.ConditionFalse,
+	}
+	if len(conditions) != len(expect) {
+		t.Errorf("expect conditions %v, but actually got %v", expect, conditions)
+	}
+	for conditionType, status := range expect {
+		if actual := getGsCondition(conditions, conditionType); actual.Status != status {
+			t.Errorf("expect condition %s %s, but actually got %v", conditionType, status, actual)
+		}
+	}
+	if actual := getGsCondition(conditions, gameKruiseV1alpha1.NetworkSynced); actual.Reason != networkSyncFailedReason || actual.Message != "failed to patch pod" {
+		t.Errorf("expect NetworkSynced failed to patch pod, but actually got %v", actual)
+	}
+
+	// the lifecycle keeps the conditions of the network
+	lifecycleManager = NewGameServerManager(getGs(), pod.DeepCopy(), c, record.NewFakeRecorder(10))
+	if err := lifecycleManager.SyncPodToGs(gss); err != nil {
+		t.Fatal(err)
+	}
+	if actual := getGsCondition(getGs().Status.Conditions, gameKruiseV1alpha1.NetworkSynced); actual.Status != corev1.ConditionFalse {
+		t.Errorf("expect NetworkSynced kept, but actually got %v", actual)
+	}
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserver
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	kruisePub "github.com/openkruise/kruise-api/apps/pub"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+const (
+	PreDeleteHookReason = "PreDeleteHook"
+)
+
+const (
+	DefaultPreDeleteHookGraceSeconds = 300
+	// preDeleteHookExtraTimeoutSeconds is added to GraceSeconds as the default TimeoutSeconds.
+	preDeleteHookExtraTimeoutSeconds = 60
+	// preDeleteHookCallTimeout is the timeout of calling the endpoint of the hook.
+	preDeleteHookCallTimeout = 10 * time.Second
+	// preDeleteHookGRPCMethod is the method called for the GRPC scheme, see proto/predeletehook/predeletehook.proto.
+	preDeleteHookGRPCMethod = "/predeletehook.PreDeleteHook/PreDelete"
+)
+
+// preDeleteHookClient calls the endpoints of game servers. Like the HTTPS probes of kubelet,
+// the certificates of game servers are not verified.
+var preDeleteHookClient = &http.Client{
+	Timeout:   preDeleteHookCallTimeout,
+	Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+}
+
+// preDeleteHookCalls records the UIDs of the pods whose hooks are being called, so that a hook is not called
+// twice at the same time by the reconciles based on a stale cache.
+var preDeleteHookCalls sync.Map
+
+// goPreDeleteHook runs the call of the hook in the background, so that the workers are not blocked by slow
+// game servers. It is replaced in tests.
+var goPreDeleteHook = func(call func()) {
+	go call()
+}
+
+// syncPreDeleteHook calls the PreDeleteHook of the game server when its pod is going to be deleted, and releases
+// the pod to be deleted after the game server acknowledges the shutdown through the SDK or the hook times out.
+func (manager GameServerManager) syncPreDeleteHook(gss *gameKruiseV1alpha1.GameServerSet) error {
+	gs := manager.gameServer
+	pod := manager.pod
+	hook := gss.Spec.PreDeleteHook
+
+	blocker, hooked := pod.GetLabels()[gameKruiseV1alpha1.PreDeleteHookBlocker]
+	if !hooked {
+		return nil
+	}
+	if hook == nil {
+		// the PreDeleteHook is disabled, and the pod is no longer hooked by Advanced StatefulSet
+		return manager.removePodLabel(gameKruiseV1alpha1.PreDeleteHookBlocker)
+	}
+	_, started := pod.GetAnnotations()[gameKruiseV1alpha1.GameServerPreDeleteHookStartedKey]
+	if pod.GetLabels()[kruisePub.LifecycleStateKey] != string(kruisePub.LifecycleStatePreparingDelete) {
+		if blocker != "true" || started {
+			// the deletion is cancelled, so that the hook is re-armed for the next deletion
+			return manager.patchPod(map[string]interface{}{
+				"labels":      map[string]string{gameKruiseV1alpha1.PreDeleteHookBlocker: "true"},
+				"annotations": map[string]interface{}{gameKruiseV1alpha1.GameServerPreDeleteHookStartedKey: nil},
+			})
+		}
+		return nil
+	}
+	if blocker != "true" {
+		return nil
+	}
+	if pod.GetLabels()[gameKruiseV1alpha1.UpdateGateBlocker] == "true" || pod.GetLabels()[gameKruiseV1alpha1.PreUpdateJobBlocker] == "true" {
+		// the hook is called only after the GameServer passes the update gate and the pre-update Job
+		return nil
+	}
+
+	if !started {
+		if err := manager.patchPod(map[string]interface{}{"annotations": map[string]string{
+			gameKruiseV1alpha1.GameServerPreDeleteHookStartedKey: time.Now().UTC().Format(time.RFC3339),
+		}}); err != nil {
+			return err
+		}
+		manager.callPreDeleteHookAsync(hook)
+		return nil
+	}
+
+	if pod.GetAnnotations()[gameKruiseV1alpha1.GameServerShutdownStateKey] == string(gameKruiseV1alpha1.ShutdownDrained) {
+		manager.eventRecorder.Eventf(gs, corev1.EventTypeNormal, PreDeleteHookReason, "GameServer acknowledged the shutdown, it will be deleted")
+		return manager.patchPreDeleteHookBlocker("false")
+	}
+	start, err := time.Parse(time.RFC3339, pod.GetAnnotations()[gameKruiseV1alpha1.GameServerPreDeleteHookStartedKey])
+	if err != nil || time.Since(start) >= time.Duration(getPreDeleteHookTimeoutSeconds(hook))*time.Second {
+		manager.eventRecorder.Eventf(gs, corev1.EventTypeWarning, PreDeleteHookReason, "GameServer did not acknowledge the shutdown in %ds, it will be deleted", getPreDeleteHookTimeoutSeconds(hook))
+		return manager.patchPreDeleteHookBlocker("false")
+	}
+	return nil
+}
+
+// isWaitingForPreDeleteHook returns true if the pod is held back from being deleted by the PreDeleteHook.
+func isWaitingForPreDeleteHook(pod *corev1.Pod) bool {
+	return pod.GetLabels()[gameKruiseV1alpha1.PreDeleteHookBlocker] == "true" &&
+		pod.GetLabels()[kruisePub.LifecycleStateKey] == string(kruisePub.LifecycleStatePreparingDelete)
+}
+
+// getPreDeleteHookRequeueAfter returns the duration after which the hook of the pod waiting for the acknowledgment
+// times out, so that the pod is released without other events, or 0 if the pod is not waiting.
+func getPreDeleteHookRequeueAfter(hook *gameKruiseV1alpha1.PreDeleteHook, pod *corev1.Pod, now time.Time) time.Duration {
+	if hook == nil || !isWaitingForPreDeleteHook(pod) {
+		return 0
+	}
+	timeout := time.Duration(getPreDeleteHookTimeoutSeconds(hook)) * time.Second
+	startedAt, started := pod.GetAnnotations()[gameKruiseV1alpha1.GameServerPreDeleteHookStartedKey]
+	if !started {
+		// the hook is started by this reconcile
+		return timeout
+	}
+	start, err := time.Parse(time.RFC3339, startedAt)
+	if err != nil {
+		return 0
+	}
+	after := start.Add(timeout).Sub(now)
+	if after < time.Second {
+		after = time.Second
+	}
+	return after
+}
+
+// callPreDeleteHookAsync calls the hook in the background. If the call fails, the game server is unable to
+// acknowledge the shutdown, so that the pod is released to be deleted without waiting.
+func (manager GameServerManager) callPreDeleteHookAsync(hook *gameKruiseV1alpha1.PreDeleteHook) {
+	gs := manager.gameServer
+	pod := manager.pod
+	if _, calling := preDeleteHookCalls.LoadOrStore(pod.GetUID(), true); calling {
+		return
+	}
+	hook = hook.DeepCopy()
+	podIP := pod.Status.PodIP
+	goPreDeleteHook(func() {
+		defer preDeleteHookCalls.Delete(pod.GetUID())
+		if err := callPreDeleteHook(hook, podIP); err != nil {
+			manager.eventRecorder.Eventf(gs, corev1.EventTypeWarning, PreDeleteHookReason, "failed to call the pre-delete hook, the GameServer will be deleted: %s", err.Error())
+			if err := manager.patchPreDeleteHookBlocker("false"); err != nil {
+				klog.Errorf("failed to release Pod %s in %s after the pre-delete hook failed, because of %s.", pod.GetName(), pod.GetNamespace(), err.Error())
+			}
+			return
+		}
+		manager.eventRecorder.Eventf(gs, corev1.EventTypeNormal, PreDeleteHookReason, "called the pre-delete hook with grace %ds, waiting for the acknowledgment", getPreDeleteHookGraceSeconds(hook))
+	})
+}
+
+// callPreDeleteHook calls the endpoint of the hook on the pod IP with GraceSeconds. For HTTP and HTTPS, the query
+// grace=<GraceSeconds> is added and any 2xx response is regarded as success. For GRPC, the PreDelete method is
+// called with GraceSeconds and any OK response is regarded as success.
+func callPreDeleteHook(hook *gameKruiseV1alpha1.PreDeleteHook, podIP string) error {
+	if podIP == "" {
+		return fmt.Errorf("pod IP is not allocated")
+	}
+	if hook.Scheme == gameKruiseV1alpha1.PreDeleteHookSchemeGRPC {
+		return callPreDeleteHookGRPC(net.JoinHostPort(podIP, strconv.Itoa(int(hook.Port))), getPreDeleteHookGraceSeconds(hook))
+	}
+	scheme := strings.ToLower(string(hook.Scheme))
+	if scheme == "" {
+		scheme = "http"
+	}
+	u := url.URL{
+		Scheme:   scheme,
+		Host:     net.JoinHostPort(podIP, strconv.Itoa(int(hook.Port))),
+		Path:     hook.Path,
+		RawQuery: url.Values{"grace": []string{strconv.Itoa(int(getPreDeleteHookGraceSeconds(hook)))}}.Encode(),
+	}
+	resp, err := preDeleteHookClient.Post(u.String(), "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s responded %s", u.String(), resp.Status)
+	}
+	return nil
+}
+
+func callPreDeleteHookGRPC(address string, graceSeconds int32) error {
+	ctx, cancel := context.WithTimeout(context.Background(), preDeleteHookCallTimeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Invoke(ctx, preDeleteHookGRPCMethod, wrapperspb.Int32(graceSeconds), &emptypb.Empty{})
+}
+
+func getPreDeleteHookGraceSeconds(hook *gameKruiseV1alpha1.PreDeleteHook) int32 {
+	if hook.GraceSeconds == nil {
+		return DefaultPreDeleteHookGraceSeconds
+	}
+	return *hook.GraceSeconds
+}
+
+func getPreDeleteHookTimeoutSeconds(hook *gameKruiseV1alpha1.PreDeleteHook) int32 {
+	if hook.TimeoutSeconds == nil {
+		return getPreDeleteHookGraceSeconds(hook) + preDeleteHookExtraTimeoutSeconds
+	}
+	return *hook.TimeoutSeconds
+}
+
+func (manager GameServerManager) patchPreDeleteHookBlocker(value string) error {
+	return manager.patchPod(map[string]interface{}{"labels": map[string]string{gameKruiseV1alpha1.PreDeleteHookBlocker: value}})
+}
+
+func (manager GameServerManager) patchPod(metadata map[string]interface{}) error {
+	pod := manager.pod
+	patchPodBytes, err := json.Marshal(map[string]interface{}{"metadata": metadata})
+	if err != nil {
+		return err
+	}
+	if err := manager.client.Patch(context.TODO(), pod, client.RawPatch(types.MergePatchType, patchPodBytes)); err != nil {
+		klog.Errorf("failed to patch Pod %s in %s,because of %s.", pod.GetName(), pod.GetNamespace(), err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package gameserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	kruisePub "github.com/openkruise/kruise-api/apps/pub"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestSyncPreDeleteHook(t *testing.T) {
+	var grace string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		grace = r.URL.Query().Get("grace")
+		if r.URL.Path != "/shutdown" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcServer := grpc.NewServer()
+	grpcServer.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "predeletehook.PreDeleteHook",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "PreDelete",
+			Handler: func(_ interface{}, _ context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := &wrapperspb.Int32Value{}
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				grace = strconv.Itoa(int(in.GetValue()))
+				return &emptypb.Empty{}, nil
+			},
+		}},
+	}, struct{}{})
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	_, grpcPort, _ := net.SplitHostPort(lis.Addr().String())
+	grpcPortNum, _ := strconv.Atoi(grpcPort)
+
+	// call the hook synchronously, so that its result is checked
+	goPreDeleteHook = func(call func()) { call() }
+	defer func() {
+		goPreDeleteHook = func(call func()) { go call() }
+	}()
+
+	now := time.Now().UTC()
+	tests := []struct {
+		path           string
+		grpc           bool
+		lifecycleState kruisePub.LifecycleStateType
+		labels         map[string]string
+		annotations    map[string]string
+		disabled       bool
+		expectBlocker  string
+		expectStarted  bool
+		expectGrace    string
+	}{
+		// the hook is called when the pod starts to be deleted
+		{
+			path:           "/shutdown",
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			expectBlocker:  "true",
+			expectStarted:  true,
+			expectGrace:    "120",
+		},
+		// the gRPC hook is called when the pod starts to be deleted
+		{
+			grpc:           true,
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			expectBlocker:  "true",
+			expectStarted:  true,
+			expectGrace:    "120",
+		},
+		// the pod is released when the hook fails
+		{
+			path:           "/unknown",
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			expectBlocker:  "false",
+			expectStarted:  true,
+			expectGrace:    "120",
+		},
+		// the pod is released after the game server acknowledges the shutdown
+		{
+			path:           "/shutdown",
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			annotations: map[string]string{
+				gameKruiseV1alpha1.GameServerPreDeleteHookStartedKey: now.Format(time.RFC3339),
+				gameKruiseV1alpha1.GameServerShutdownStateKey:        string(gameKruiseV1alpha1.ShutdownDrained),
+			},
+			expectBlocker: "false",
+			expectStarted: true,
+		},
+		// the pod is held back until the acknowledgment
+		{
+			path:           "/shutdown",
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			annotations: map[string]string{
+				gameKruiseV1alpha1.GameServerPreDeleteHookStartedKey: now.Format(time.RFC3339),
+				gameKruiseV1alpha1.GameServerShutdownStateKey:        string(gameKruiseV1alpha1.ShutdownDraining),
+			},
+			expectBlocker: "true",
+			expectStarted: true,
+		},
+		// the pod is released after the hook times out
+		{
+			path:           "/shutdown",
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			annotations: map[string]string{
+				gameKruiseV1alpha1.GameServerPreDeleteHookStartedKey: now.Add(-200 * time.Second).Format(time.RFC3339),
+			},
+			expectBlocker: "false",
+			expectStarted: true,
+		},
+		// the hook is called after the GameServer passes the update gate
+		{
+			path:           "/shutdown",
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			labels:         map[string]string{gameKruiseV1alpha1.UpdateGateBlocker: "true"},
+			expectBlocker:  "true",
+			expectStarted:  false,
+		},
+		// the pod is not being deleted
+		{
+			path:           "/shutdown",
+			lifecycleState: kruisePub.LifecycleStateNormal,
+			expectBlocker:  "true",
+			expectStarted:  false,
+		},
+		// the deletion is cancelled after the pod is released, so that the hook is re-armed
+		{
+			path:           "/shutdown",
+			lifecycleState: kruisePub.LifecycleStateNormal,
+			labels:         map[string]string{gameKruiseV1alpha1.PreDeleteHookBlocker: "false"},
+			annotations: map[string]string{
+				gameKruiseV1alpha1.GameServerPreDeleteHookStartedKey: now.Format(time.RFC3339),
+			},
+			expectBlocker: "true",
+			expectStarted: false,
+		},
+		// the blocker is removed after the PreDeleteHook is disabled
+		{
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			disabled:       true,
+			expectBlocker:  "",
+			expectStarted:  false,
+		},
+	}
+
+	for i, test := range tests {
+		grace = ""
+		gss := &gameKruiseV1alpha1.GameServerSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+			Spec: gameKruiseV1alpha1.GameServerSetSpec{
+				PreDeleteHook: &gameKruiseV1alpha1.PreDeleteHook{
+					Path:           test.path,
+					Port:           int32(portNum),
+					GraceSeconds:   ptr.To[int32](120),
+					TimeoutSeconds: ptr.To[int32](180),
+				},
+			},
+		}
+		if test.grpc {
+			gss.Spec.PreDeleteHook.Port = int32(grpcPortNum)
+			gss.Spec.PreDeleteHook.Scheme = gameKruiseV1alpha1.PreDeleteHookSchemeGRPC
+		}
+		if test.disabled {
+			gss.Spec.PreDeleteHook = nil
+		}
+		labels := map[string]string{
+			kruisePub.LifecycleStateKey:             string(test.lifecycleState),
+			gameKruiseV1alpha1.PreDeleteHookBlocker: "true",
+		}
+		for k, v := range test.labels {
+			labels[k] = v
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "xxx",
+				Name:        "case-0",
+				Labels:      labels,
+				Annotations: test.annotations,
+			},
+			Status: corev1.PodStatus{PodIP: host},
+		}
+		gs := &gameKruiseV1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0"},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects([]client.Object{gss, pod, gs}...).Build()
+		manager := &GameServerManager{
+			gameServer:    gs,
+			pod:           pod,
+			client:        c,
+			eventRecorder: record.NewFakeRecorder(10),
+		}
+
+		if err := manager.syncPreDeleteHook(gss); err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		newPod := &corev1.Pod{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: "case-0"}, newPod); err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		if blocker, ok := newPod.GetLabels()[gameKruiseV1alpha1.PreDeleteHookBlocker]; blocker != test.expectBlocker || ok != (test.expectBlocker != "") {
+			t.Errorf("case %d: expect blocker %s, but actually got %s", i, test.expectBlocker, blocker)
+		}
+		if _, started := newPod.GetAnnotations()[gameKruiseV1alpha1.GameServerPreDeleteHookStartedKey]; started != test.expectStarted {
+			t.Errorf("case %d: expect started %v, but actually got %v", i, test.expectStarted, started)
+		}
+		if grace != test.expectGrace {
+			t.Errorf("case %d: expect grace %s, but actually got %s", i, test.expectGrace, grace)
+		}
+	}
+}
+
+func TestGetPreDeleteHookRequeueAfter(t *testing.T) {
+	now := time.Now()
+	hook := &gameKruiseV1alpha1.PreDeleteHook{Path: "/shutdown", Port: 8080, TimeoutSeconds: ptr.To[int32](180)}
+	tests := []struct {
+		hook           *gameKruiseV1alpha1.PreDeleteHook
+		lifecycleState kruisePub.LifecycleStateType
+		blocker        string
+		startedAt      *time.Time
+		expect         time.Duration
+	}{
+		// the hook is started by this reconcile
+		{
+			hook:           hook,
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			blocker:        "true",
+			expect:         180 * time.Second,
+		},
+		// the hook has been started for 60 seconds
+		{
+			hook:           hook,
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			blocker:        "true",
+			startedAt:      ptr.To(now.Add(-60 * time.Second)),
+			expect:         120 * time.Second,
+		},
+		// the hook has timed out
+		{
+			hook:           hook,
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			blocker:        "true",
+			startedAt:      ptr.To(now.Add(-200 * time.Second)),
+			expect:         time.Second,
+		},
+		// the pod has been released
+		{
+			hook:           hook,
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			blocker:        "false",
+			expect:         0,
+		},
+		// the pod is not being deleted
+		{
+			hook:           hook,
+			lifecycleState: kruisePub.LifecycleStateNormal,
+			blocker:        "true",
+			expect:         0,
+		},
+		// the hook is disabled
+		{
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			blocker:        "true",
+			expect:         0,
+		},
+	}
+	for i, test := range tests {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					kruisePub.LifecycleStateKey:             string(test.lifecycleState),
+					gameKruiseV1alpha1.PreDeleteHookBlocker: test.blocker,
+				},
+			},
+		}
+		if test.startedAt != nil {
+			pod.Annotations = map[string]string{gameKruiseV1alpha1.GameServerPreDeleteHookStartedKey: test.startedAt.UTC().Format(time.RFC3339)}
+		}
+		actual := getPreDeleteHookRequeueAfter(test.hook, pod, now)
+		if actual < test.expect-time.Second || actual > test.expect {
+			t.Errorf("case %d: expect requeue after %v, but actually got %v", i, test.expect, actual)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	kruisePub "github.com/openkruise/kruise-api/apps/pub"
+	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+const (
+	PreUpdateJobReason = "PreUpdateJob"
+)
+
+// The environment variables injected into the containers of pre-update Jobs.
+const (
+	PreUpdateJobGameServerNameEnv = "GAMESERVER_NAME"
+	PreUpdateJobGameServerIdEnv   = "GAMESERVER_ID"
+	PreUpdateJobRevisionEnv       = "GAMESERVER_UPDATE_REVISION"
+)
+
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create
+
+// syncPreUpdateJob runs the pre-update Job of the GameServer when its pod is going to be updated,
+// and releases the pod to be updated after the Job succeeds. It returns the status of the Job to record.
+func (manager GameServerManager) syncPreUpdateJob(gss *gameKruiseV1alpha1.GameServerSet) (*gameKruiseV1alpha1.PreUpdateJobStatus, error) {
+	gs := manager.gameServer
+	pod := manager.pod
+	ctx := context.TODO()
+
+	blocker, hooked := pod.GetLabels()[gameKruiseV1alpha1.PreUpdateJobBlocker]
+	if !hooked {
+		return gs.Status.PreUpdateJob, nil
+	}
+	if gss.Spec.UpdateStrategy.PreUpdateJob == nil {
+		// the PreUpdateJob is disabled, and the pod is no longer hooked by Advanced StatefulSet
+		return gs.Status.PreUpdateJob, manager.removePodLabel(gameKruiseV1alpha1.PreUpdateJobBlocker)
+	}
+
+	lifecycleState := pod.GetLabels()[kruisePub.LifecycleStateKey]
+	if lifecycleState != string(kruisePub.LifecycleStatePreparingUpdate) && lifecycleState != string(kruisePub.LifecycleStatePreparingDelete) {
+		// hook the pod again after it is updated, so that the next update will be blocked
+		if blocker != "true" {
+			return gs.Status.PreUpdateJob, manager.patchPreUpdateJobBlocker("true")
+		}
+		return gs.Status.PreUpdateJob, nil
+	}
+	if blocker != "true" {
+		// the pod has been released
+		return gs.Status.PreUpdateJob, nil
+	}
+	if pod.GetLabels()[gameKruiseV1alpha1.UpdateGateBlocker] == "true" {
+		// the Job runs only after the GameServer passes the update gate
+		return gs.Status.PreUpdateJob, nil
+	}
+
+	asts := &kruiseV1beta1.StatefulSet{}
+	if err := manager.client.Get(ctx, types.NamespacedName{Namespace: gss.GetNamespace(), Name: gss.GetName()}, asts); err != nil {
+		return nil, err
+	}
+	updateRevision := asts.Status.UpdateRevision
+	if updateRevision == "" || pod.GetLabels()[apps.ControllerRevisionHashLabelKey] == updateRevision {
+		// the pod is deleted for scaling down rather than updating
+		return gs.Status.PreUpdateJob, manager.patchPreUpdateJobBlocker("false")
+	}
+
+	jobName := preUpdateJobName(gs.GetName(), updateRevision)
+	status := gs.Status.PreUpdateJob
+	if status != nil && status.Name == jobName && status.Phase == gameKruiseV1alpha1.PreUpdateJobSucceeded {
+		return status, manager.patchPreUpdateJobBlocker("false")
+	}
+
+	job := &batchv1.Job{}
+	err := manager.client.Get(ctx, types.NamespacedName{Namespace: gs.GetNamespace(), Name: jobName}, job)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		job = newPreUpdateJob(gss.Spec.UpdateStrategy.PreUpdateJob, gs, jobName, updateRevision)
+		if err := manager.client.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+			return nil, err
+		}
+		manager.eventRecorder.Eventf(gs, corev1.EventTypeNormal, PreUpdateJobReason, "created pre-update Job %s for revision %s", jobName, updateRevision)
+	}
+
+	newStatus := &gameKruiseV1alpha1.PreUpdateJobStatus{
+		Name:     jobName,
+		Revision: updateRevision,
+		Phase:    getPreUpdateJobPhase(job),
+	}
+	switch newStatus.Phase {
+	case gameKruiseV1alpha1.PreUpdateJobSucceeded:
+		manager.eventRecorder.Eventf(gs, corev1.EventTypeNormal, PreUpdateJobReason, "pre-update Job %s succeeded, the GameServer will be updated", jobName)
+		return newStatus, manager.patchPreUpdateJobBlocker("false")
+	case gameKruiseV1alpha1.PreUpdateJobFailed:
+		if status == nil || status.Name != jobName || status.Phase != gameKruiseV1alpha1.PreUpdateJobFailed {
+			manager.eventRecorder.Eventf(gs, corev1.EventTypeWarning, PreUpdateJobReason, "pre-update Job %s failed, the update of GameServer is blocked", jobName)
+		}
+	}
+	return newStatus, nil
+}
+
+func (manager GameServerManager) patchPreUpdateJobBlocker(value string) error {
+	pod := manager.pod
+	patchPod := map[string]interface{}{"metadata": map[string]map[string]string{"labels": {gameKruiseV1alpha1.PreUpdateJobBlocker: value}}}
+	patchPodBytes, err := json.Marshal(patchPod)
+	if err != nil {
+		return err
+	}
+	if err := manager.client.Patch(context.TODO(), pod, client.RawPatch(types.MergePatchType, patchPodBytes)); err != nil {
+		klog.Errorf("failed to patch Pod %s in %s,because of %s.", pod.GetName(), pod.GetNamespace(), err.Error())
+		return err
+	}
+	return nil
+}
+
+// removePodLabel removes the label from the pod of the GameServer.
+func (manager GameServerManager) removePodLabel(key string) error {
+	return manager.patchPod(map[string]interface{}{"labels": map[string]interface{}{key: nil}})
+}
+
+// preUpdateJobName returns the name of the Job for the GameServer and the revision,
+// so that the Job runs only once for each update.
+func preUpdateJobName(gsName, revision string) string {
+	hash := revision[strings.LastIndex(revision, "-")+1:]
+	return fmt.Sprintf("%s-pre-update-%s", gsName, hash)
+}
+
+func newPreUpdateJob(template *batchv1.JobTemplateSpec, gs *gameKruiseV1alpha1.GameServer, name, revision string) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: *template.ObjectMeta.DeepCopy(),
+		Spec:       *template.Spec.DeepCopy(),
+	}
+	job.SetName(name)
+	job.SetNamespace(gs.GetNamespace())
+	job.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion:         gameKruiseV1alpha1.GroupVersion.String(),
+			Kind:               "GameServer",
+			Name:               gs.GetName(),
+			UID:                gs.GetUID(),
+			Controller:         ptr.To[bool](true),
+			BlockOwnerDeletion: ptr.To[bool](true),
+		},
+	})
+
+	env := []corev1.EnvVar{
+		{Name: PreUpdateJobGameServerNameEnv, Value: gs.GetName()},
+		{Name: PreUpdateJobGameServerIdEnv, Value: strconv.Itoa(util.GetIndexFromGsName(gs.GetName()))},
+		{Name: PreUpdateJobRevisionEnv, Value: revision},
+	}
+	for i := range job.Spec.Template.Spec.InitContainers {
+		job.Spec.Template.Spec.InitContainers[i].Env = append(job.Spec.Template.Spec.InitContainers[i].Env, env...)
+	}
+	for i := range job.Spec.Template.Spec.Containers {
+		job.Spec.Template.Spec.Containers[i].Env = append(job.Spec.Template.Spec.Containers[i].Env, env...)
+	}
+	return job
+}
+
+func getPreUpdateJobPhase(job *batchv1.Job) gameKruiseV1alpha1.PreUpdateJobPhase {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return gameKruiseV1alpha1.PreUpdateJobSucceeded
+		case batchv1.JobFailed:
+			return gameKruiseV1alpha1.PreUpdateJobFailed
+		}
+	}
+	return gameKruiseV1alpha1.PreUpdateJobRunning
+}
@@ -0,0 +1,175 @@
+package gameserver
+
+import (
+	"context"
+	"testing"
+
+	kruisePub "github.com/openkruise/kruise-api/apps/pub"
+	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestSyncPreUpdateJob(t *testing.T) {
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			UpdateStrategy: gameKruiseV1alpha1.UpdateStrategy{
+				PreUpdateJob: &batchv1.JobTemplateSpec{
+					Spec: batchv1.JobSpec{
+						Template: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{{Name: "migrate", Image: "migrate:v2"}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	asts := &kruiseV1beta1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+		Status:     kruiseV1beta1.StatefulSetStatus{UpdateRevision: "case-new"},
+	}
+	jobName := "case-2-pre-update-new"
+	succeededJob := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: jobName},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+		},
+	}
+	failedJob := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: jobName},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}},
+		},
+	}
+
+	tests := []struct {
+		lifecycleState kruisePub.LifecycleStateType
+		revision       string
+		blocker        string
+		job            *batchv1.Job
+		expectBlocker  string
+		expectPhase    gameKruiseV1alpha1.PreUpdateJobPhase
+		expectJob      bool
+	}{
+		// the Job is created when the pod is preparing update
+		{
+			lifecycleState: kruisePub.LifecycleStatePreparingUpdate,
+			revision:       "case-old",
+			blocker:        "true",
+			expectBlocker:  "true",
+			expectPhase:    gameKruiseV1alpha1.PreUpdateJobRunning,
+			expectJob:      true,
+		},
+		// the pod is released after the Job succeeds
+		{
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			revision:       "case-old",
+			blocker:        "true",
+			job:            succeededJob,
+			expectBlocker:  "false",
+			expectPhase:    gameKruiseV1alpha1.PreUpdateJobSucceeded,
+			expectJob:      true,
+		},
+		{
+			lifecycleState: kruisePub.LifecycleStatePreparingUpdate,
+			revision:       "case-old",
+			blocker:        "true",
+			job:            failedJob,
+			expectBlocker:  "true",
+			expectPhase:    gameKruiseV1alpha1.PreUpdateJobFailed,
+			expectJob:      true,
+		},
+		// the pod is deleted for scaling down
+		{
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			revision:       "case-new",
+			blocker:        "true",
+			expectBlocker:  "false",
+		},
+		// the pod is hooked again after updated
+		{
+			lifecycleState: kruisePub.LifecycleStateUpdated,
+			revision:       "case-new",
+			blocker:        "false",
+			expectBlocker:  "true",
+		},
+	}
+
+	for i, test := range tests {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      "case-2",
+				Labels: map[string]string{
+					kruisePub.LifecycleStateKey:            string(test.lifecycleState),
+					apps.ControllerRevisionHashLabelKey:    test.revision,
+					gameKruiseV1alpha1.PreUpdateJobBlocker: test.blocker,
+				},
+			},
+		}
+		gs := &gameKruiseV1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-2"},
+		}
+		objs := []client.Object{gss, asts, pod, gs}
+		if test.job != nil {
+			objs = append(objs, test.job.DeepCopy())
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+		manager := &GameServerManager{
+			gameServer:    gs,
+			pod:           pod,
+			client:        c,
+			eventRecorder: record.NewFakeRecorder(10),
+		}
+
+		status, err := manager.syncPreUpdateJob(gss)
+		if err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		if test.expectPhase == "" {
+			if status != nil {
+				t.Errorf("case %d: expect no status, but actually got %v", i, status)
+			}
+		} else if status == nil || status.Phase != test.expectPhase || status.Name != jobName || status.Revision != "case-new" {
+			t.Errorf("case %d: expect phase %s, but actually got %v", i, test.expectPhase, status)
+		}
+
+		newPod := &corev1.Pod{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: "case-2"}, newPod); err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		if newPod.GetLabels()[gameKruiseV1alpha1.PreUpdateJobBlocker] != test.expectBlocker {
+			t.Errorf("case %d: expect blocker %s, but actually got %s", i, test.expectBlocker, newPod.GetLabels()[gameKruiseV1alpha1.PreUpdateJobBlocker])
+		}
+
+		job := &batchv1.Job{}
+		err = c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: jobName}, job)
+		if test.expectJob != (err == nil) {
+			t.Errorf("case %d: expect job existing %v, but actually got error %v", i, test.expectJob, err)
+			continue
+		}
+		if err != nil && !errors.IsNotFound(err) {
+			t.Errorf("case %d: %s", i, err.Error())
+		}
+		if test.job == nil && test.expectJob {
+			env := job.Spec.Template.Spec.Containers[0].Env
+			if len(env) != 3 || env[1].Name != PreUpdateJobGameServerIdEnv || env[1].Value != "2" {
+				t.Errorf("case %d: unexpected env of job %v", i, env)
+			}
+		}
+	}
+}
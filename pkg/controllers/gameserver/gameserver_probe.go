@@ -0,0 +1,341 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserver
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+const (
+	// ServiceQualityResultMetadataKey is the key of the gRPC header or trailer metadata, whose value is taken as the
+	// result of a gRPC service quality instead of the serving status.
+	ServiceQualityResultMetadataKey = "game-kruise-result"
+
+	// probeTickInterval is how often the prober checks which service qualities are due.
+	probeTickInterval = time.Second
+	// maxConcurrentProbes is the maximum number of gRPC and TCP probes running at a time.
+	maxConcurrentProbes = 50
+	// maxProbeResultLength is the maximum length of the result read from a TCP connection.
+	maxProbeResultLength = 1024
+
+	defaultProbePeriodSeconds    = 10
+	defaultProbeTimeoutSeconds   = 1
+	defaultProbeSuccessThreshold = 1
+	defaultProbeFailureThreshold = 3
+)
+
+// probeFunc probes the service quality of the pod, and returns whether it succeeds and the result.
+type probeFunc func(ctx context.Context, pod *corev1.Pod, containerName string, sq gamekruiseiov1alpha1.ServiceQuality) (bool, string)
+
+// probeState is the consecutive results of a service quality of a pod.
+type probeState struct {
+	lastProbeTime time.Time
+	succeeded     bool
+	consecutive   int32
+}
+
+// serviceQualityProber probes the gRPC and TCP service qualities of the GameServers from kruise-game-manager, and
+// writes the results to the pod conditions the same as the PodProbeMarker does, from which the ServiceQualityActions
+// are executed by the GameServer controller.
+type serviceQualityProber struct {
+	client client.Client
+	probe  probeFunc
+
+	lock   sync.Mutex
+	states map[string]*probeState
+}
+
+func newServiceQualityProber(c client.Client) *serviceQualityProber {
+	return &serviceQualityProber{
+		client: c,
+		probe:  probeServiceQuality,
+		states: make(map[string]*probeState),
+	}
+}
+
+// Start implements manager.Runnable.
+func (p *serviceQualityProber) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		p.probeAll(ctx, time.Now())
+	}, probeTickInterval)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that only the leader probes the pods.
+func (p *serviceQualityProber) NeedLeaderElection() bool {
+	return true
+}
+
+// probeAll probes the service qualities which are due, and waits for them to finish.
+func (p *serviceQualityProber) probeAll(ctx context.Context, now time.Time) {
+	gssList := &gamekruiseiov1alpha1.GameServerSetList{}
+	if err := p.client.List(ctx, gssList); err != nil {
+		klog.Errorf("failed to list GameServerSets to probe service qualities, because of %s.", err.Error())
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentProbes)
+	keys := make(map[string]bool)
+	for i := range gssList.Items {
+		gss := &gssList.Items[i]
+		sqs, err := util.GetServiceQualities(gss, p.client, ctx)
+		if err != nil {
+			klog.Errorf("failed to get service qualities of GameServerSet %s in %s, because of %s.", gss.GetName(), gss.GetNamespace(), err.Error())
+			continue
+		}
+		var networkSqs []gamekruiseiov1alpha1.ServiceQuality
+		for _, sq := range sqs {
+			if util.IsNetworkServiceQuality(sq) {
+				networkSqs = append(networkSqs, sq)
+			}
+		}
+		if len(networkSqs) == 0 {
+			continue
+		}
+		podList := &corev1.PodList{}
+		if err := p.client.List(ctx, podList, client.InNamespace(gss.GetNamespace()), client.MatchingLabels{gamekruiseiov1alpha1.GameServerOwnerGssKey: gss.GetName()}); err != nil {
+			klog.Errorf("failed to list pods of GameServerSet %s in %s, because of %s.", gss.GetName(), gss.GetNamespace(), err.Error())
+			continue
+		}
+		for j := range podList.Items {
+			pod := &podList.Items[j]
+			if pod.Status.PodIP == "" || pod.Status.Phase != corev1.PodRunning || pod.GetDeletionTimestamp() != nil {
+				continue
+			}
+			for _, sq := range networkSqs {
+				key := pod.GetNamespace() + "/" + pod.GetName() + "/" + sq.Name
+				keys[key] = true
+				if !p.isDue(key, pod, sq, now) {
+					continue
+				}
+				containerName := sq.ContainerName
+				if containerName == "" {
+					containerName = gss.Spec.GameContainerName
+				}
+				wg.Add(1)
+				sem <- struct{}{}
+				go func(pod *corev1.Pod, sq gamekruiseiov1alpha1.ServiceQuality, key, containerName string) {
+					defer func() {
+						<-sem
+						wg.Done()
+					}()
+					succeeded, result := p.probe(ctx, pod, containerName, sq)
+					if err := p.syncCondition(ctx, key, pod, sq, succeeded, result, now); err != nil {
+						klog.Errorf("failed to sync service quality %s of pod %s in %s, because of %s.", sq.Name, pod.GetName(), pod.GetNamespace(), err.Error())
+					}
+				}(pod, sq, key, containerName)
+			}
+		}
+	}
+	wg.Wait()
+
+	// forget the pods deleted and the service qualities removed
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for key := range p.states {
+		if !keys[key] {
+			delete(p.states, key)
+		}
+	}
+}
+
+// isDue returns true if InitialDelaySeconds has passed since the pod starts, and PeriodSeconds has passed since the
+// last probe of the service quality.
+func (p *serviceQualityProber) isDue(key string, pod *corev1.Pod, sq gamekruiseiov1alpha1.ServiceQuality, now time.Time) bool {
+	if pod.Status.StartTime != nil && now.Before(pod.Status.StartTime.Add(time.Duration(sq.InitialDelaySeconds)*time.Second)) {
+		return false
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	state, exist := p.states[key]
+	if !exist {
+		p.states[key] = &probeState{lastProbeTime: now}
+		return true
+	}
+	if now.Before(state.lastProbeTime.Add(time.Duration(defaultIfZero(sq.PeriodSeconds, defaultProbePeriodSeconds)) * time.Second)) {
+		return false
+	}
+	state.lastProbeTime = now
+	return true
+}
+
+// syncCondition records the result of a probe, and patches the pod condition of the service quality if it changes.
+// The status of the condition flips once SuccessThreshold or FailureThreshold consecutive probes agree, and the
+// result is updated only with the probes agreeing with the status.
+func (p *serviceQualityProber) syncCondition(ctx context.Context, key string, pod *corev1.Pod, sq gamekruiseiov1alpha1.ServiceQuality, succeeded bool, result string, now time.Time) error {
+	p.lock.Lock()
+	state, exist := p.states[key]
+	if !exist {
+		state = &probeState{lastProbeTime: now}
+		p.states[key] = state
+	}
+	if state.consecutive > 0 && state.succeeded == succeeded {
+		state.consecutive++
+	} else {
+		state.succeeded = succeeded
+		state.consecutive = 1
+	}
+	consecutive := state.consecutive
+	p.lock.Unlock()
+
+	conditionStatus := corev1.ConditionFalse
+	threshold := defaultIfZero(sq.FailureThreshold, defaultProbeFailureThreshold)
+	if succeeded {
+		conditionStatus = corev1.ConditionTrue
+		threshold = defaultIfZero(sq.SuccessThreshold, defaultProbeSuccessThreshold)
+	}
+	conditionType := corev1.PodConditionType(util.AddPrefixGameKruise(sq.Name))
+	_, old := util.GetPodConditionFromList(pod.Status.Conditions, conditionType)
+	if old != nil && old.Status != conditionStatus && consecutive < threshold {
+		return nil
+	}
+	if old != nil && old.Status == conditionStatus && old.Message == result {
+		return nil
+	}
+
+	condition := corev1.PodCondition{
+		Type:               conditionType,
+		Status:             conditionStatus,
+		Message:            result,
+		LastProbeTime:      metav1.NewTime(now),
+		LastTransitionTime: metav1.NewTime(now),
+	}
+	if old != nil && old.Status == conditionStatus {
+		condition.LastTransitionTime = old.LastTransitionTime
+	}
+	newPod := pod.DeepCopy()
+	setPodCondition(newPod, condition)
+	return p.client.Status().Patch(ctx, newPod, client.StrategicMergeFrom(pod))
+}
+
+func setPodCondition(pod *corev1.Pod, condition corev1.PodCondition) {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == condition.Type {
+			pod.Status.Conditions[i] = condition
+			return
+		}
+	}
+	pod.Status.Conditions = append(pod.Status.Conditions, condition)
+}
+
+func defaultIfZero(value, defaultValue int32) int32 {
+	if value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// probeServiceQuality probes the gRPC or TCP service quality of the pod by its IP.
+func probeServiceQuality(ctx context.Context, pod *corev1.Pod, containerName string, sq gamekruiseiov1alpha1.ServiceQuality) (bool, string) {
+	timeout := time.Duration(defaultIfZero(sq.TimeoutSeconds, defaultProbeTimeoutSeconds)) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if sq.GRPC != nil {
+		address := net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(sq.GRPC.Port)))
+		service := ""
+		if sq.GRPC.Service != nil {
+			service = *sq.GRPC.Service
+		}
+		return probeGRPC(ctx, address, service)
+	}
+	port, err := resolveProbePort(pod, containerName, sq.TCPSocket.Port)
+	if err != nil {
+		return false, err.Error()
+	}
+	return probeTCP(ctx, net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(port)))
+}
+
+// probeGRPC calls the standard health checking service at the address. It succeeds if the status is SERVING, and
+// the result is the serving status, or the code of the error if the call fails, e.g. Unavailable. The result is
+// overridden by the ServiceQualityResultMetadataKey metadata returned by the server.
+func probeGRPC(ctx context.Context, address, service string) (bool, string) {
+	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return false, status.Code(err).String()
+	}
+	defer conn.Close()
+
+	var header, trailer metadata.MD
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service}, grpc.Header(&header), grpc.Trailer(&trailer))
+	result := status.Code(err).String()
+	if err == nil {
+		result = resp.GetStatus().String()
+	}
+	if values := append(header.Get(ServiceQualityResultMetadataKey), trailer.Get(ServiceQualityResultMetadataKey)...); len(values) != 0 {
+		result = values[len(values)-1]
+	}
+	return err == nil && resp.GetStatus() == healthpb.HealthCheckResponse_SERVING, result
+}
+
+// probeTCP connects to the address. It succeeds if the connection is established, and the result is the first line
+// the server writes before the timeout, which is empty if the server writes nothing. Servers writing nothing keep
+// the probe waiting until the timeout.
+func probeTCP(ctx context.Context, address string) (bool, string) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return false, ""
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetReadDeadline(deadline)
+	}
+	line, _ := bufio.NewReaderSize(conn, maxProbeResultLength).ReadSlice('\n')
+	return true, strings.TrimSpace(string(line))
+}
+
+// resolveProbePort returns the number of the port, which is looked up in the container if it is named. All the
+// containers are looked up if the container name is empty.
+func resolveProbePort(pod *corev1.Pod, containerName string, port intstr.IntOrString) (int, error) {
+	if port.Type == intstr.Int {
+		return port.IntValue(), nil
+	}
+	for _, container := range pod.Spec.Containers {
+		if containerName != "" && container.Name != containerName {
+			continue
+		}
+		for _, containerPort := range container.Ports {
+			if containerPort.Name == port.StrVal {
+				return int(containerPort.ContainerPort), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("port %s is not found", port.StrVal)
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+type playersHealthServer struct {
+	healthpb.UnimplementedHealthServer
+}
+
+func (s *playersHealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	_ = grpc.SetTrailer(ctx, metadata.Pairs(ServiceQualityResultMetadataKey, "players=3"))
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func serveGRPC(t *testing.T, healthServer healthpb.HealthServer) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestProbeGRPC(t *testing.T) {
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("game", healthpb.HealthCheckResponse_NOT_SERVING)
+	address := serveGRPC(t, healthServer)
+	playersAddress := serveGRPC(t, &playersHealthServer{})
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddress := closed.Addr().String()
+	closed.Close()
+
+	tests := []struct {
+		address   string
+		service   string
+		succeeded bool
+		result    string
+	}{
+		{address: address, service: "", succeeded: true, result: "SERVING"},
+		{address: address, service: "game", succeeded: false, result: "NOT_SERVING"},
+		{address: address, service: "unknown", succeeded: false, result: "NotFound"},
+		{address: playersAddress, service: "", succeeded: true, result: "players=3"},
+		{address: closedAddress, service: "", succeeded: false, result: "Unavailable"},
+	}
+	for i, test := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		succeeded, result := probeGRPC(ctx, test.address, test.service)
+		cancel()
+		if succeeded != test.succeeded || result != test.result {
+			t.Errorf("case %d: expect %v %s, but actually got %v %s", i, test.succeeded, test.result, succeeded, result)
+		}
+	}
+}
+
+func TestProbeTCP(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte("players=3\nignored\n"))
+			conn.Close()
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if succeeded, result := probeTCP(ctx, lis.Addr().String()); !succeeded || result != "players=3" {
+		t.Errorf("expect succeeded with players=3, but actually got %v %s", succeeded, result)
+	}
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddress := closed.Addr().String()
+	closed.Close()
+	if succeeded, _ := probeTCP(ctx, closedAddress); succeeded {
+		t.Errorf("expect failed to connect to %s", closedAddress)
+	}
+}
+
+func TestResolveProbePort(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "sidecar", Ports: []corev1.ContainerPort{{Name: "game", ContainerPort: 9000}}},
+				{Name: "game", Ports: []corev1.ContainerPort{{Name: "game", ContainerPort: 7777}}},
+			},
+		},
+	}
+	tests := []struct {
+		containerName string
+		port          intstr.IntOrString
+		expect        int
+		valid         bool
+	}{
+		{port: intstr.FromInt(8080), expect: 8080, valid: true},
+		{containerName: "game", port: intstr.FromString("game"), expect: 7777, valid: true},
+		{port: intstr.FromString("game"), expect: 9000, valid: true},
+		{containerName: "game", port: intstr.FromString("admin"), valid: false},
+	}
+	for i, test := range tests {
+		port, err := resolveProbePort(pod, test.containerName, test.port)
+		if (err == nil) != test.valid || port != test.expect {
+			t.Errorf("case %d: expect port %d and valid %v, but actually got %d %v", i, test.expect, test.valid, port, err)
+		}
+	}
+}
+
+func TestProbeAll(t *testing.T) {
+	start := time.Now()
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "foo"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			ServiceQualities: []gameKruiseV1alpha1.ServiceQuality{
+				{
+					Name: "healthy",
+					Probe: corev1.Probe{
+						ProbeHandler:        corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: 9090}},
+						InitialDelaySeconds: 5,
+						PeriodSeconds:       10,
+						FailureThreshold:    2,
+					},
+				},
+				{
+					Name:  "script",
+					Probe: corev1.Probe{ProbeHandler: corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"/healthy.sh"}}}},
+				},
+			},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "xxx",
+			Name:      "foo-0",
+			Labels:    map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "foo"},
+		},
+		Status: corev1.PodStatus{
+			Phase:     corev1.PodRunning,
+			PodIP:     "10.0.0.1",
+			StartTime: &metav1.Time{Time: start},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gss, pod).Build()
+	p := newServiceQualityProber(c)
+	succeeded, result := true, "SERVING"
+	probed := 0
+	p.probe = func(ctx context.Context, pod *corev1.Pod, containerName string, sq gameKruiseV1alpha1.ServiceQuality) (bool, string) {
+		if sq.Name != "healthy" {
+			t.Errorf("expect only the gRPC service quality probed, but actually got %s", sq.Name)
+		}
+		probed++
+		return succeeded, result
+	}
+	getCondition := func() *corev1.PodCondition {
+		actual := &corev1.Pod{}
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "xxx", Name: "foo-0"}, actual); err != nil {
+			t.Fatal(err)
+		}
+		_, condition := util.GetPodConditionFromList(actual.Status.Conditions, corev1.PodConditionType(util.AddPrefixGameKruise("healthy")))
+		return condition
+	}
+
+	steps := []struct {
+		after     time.Duration
+		succeeded bool
+		result    string
+		probed    int
+		status    corev1.ConditionStatus
+		message   string
+	}{
+		// before the initial delay
+		{after: time.Second, succeeded: true, result: "SERVING", probed: 0},
+		{after: 5 * time.Second, succeeded: true, result: "SERVING", probed: 1, status: corev1.ConditionTrue, message: "SERVING"},
+		// within the period
+		{after: 10 * time.Second, succeeded: false, result: "NOT_SERVING", probed: 1, status: corev1.ConditionTrue, message: "SERVING"},
+		// below the failure threshold
+		{after: 15 * time.Second, succeeded: false, result: "NOT_SERVING", probed: 2, status: corev1.ConditionTrue, message: "SERVING"},
+		{after: 25 * time.Second, succeeded: false, result: "NOT_SERVING", probed: 3, status: corev1.ConditionFalse, message: "NOT_SERVING"},
+		// the result changes with the status kept
+		{after: 35 * time.Second, succeeded: false, result: "Unavailable", probed: 4, status: corev1.ConditionFalse, message: "Unavailable"},
+		{after: 45 * time.Second, succeeded: true, result: "SERVING", probed: 5, status: corev1.ConditionTrue, message: "SERVING"},
+	}
+	for i, step := range steps {
+		succeeded, result = step.succeeded, step.result
+		p.probeAll(context.Background(), start.Add(step.after))
+		if probed != step.probed {
+			t.Errorf("step %d: expect probed %d times, but actually got %d", i, step.probed, probed)
+		}
+		condition := getCondition()
+		if step.status == "" {
+			if condition != nil {
+				t.Errorf("step %d: expect no condition, but actually got %v", i, condition)
+			}
+			continue
+		}
+		if condition == nil || condition.Status != step.status || condition.Message != step.message {
+			t.Errorf("step %d: expect condition %s %s, but actually got %v", i, step.status, step.message, condition)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+const (
+	RepairReason        = "Repaired"
+	InvalidRepairReason = "InvalidRepair"
+)
+
+// syncRepair handles the repair annotation of the GameServer. The network objects are deleted before the pod,
+// so that a recreated pod never binds to the stale ones. It returns true if the GameServer has been repaired.
+func (r *GameServerReconciler) syncRepair(ctx context.Context, gss *gameKruiseV1alpha1.GameServerSet, gs *gameKruiseV1alpha1.GameServer, pod *corev1.Pod) (bool, error) {
+	target, ok := gs.GetAnnotations()[gameKruiseV1alpha1.GameServerRepairKey]
+	if !ok {
+		return false, nil
+	}
+
+	switch gameKruiseV1alpha1.RepairTarget(target) {
+	case gameKruiseV1alpha1.NetworkRepairTarget:
+		if err := r.deleteNetworkObjects(ctx, gss, pod); err != nil {
+			return false, err
+		}
+		// trigger the network plugin to recreate the objects
+		if err := r.triggerNetwork(ctx, pod); err != nil {
+			return false, err
+		}
+	case gameKruiseV1alpha1.PodRepairTarget:
+		if err := r.deletePod(ctx, pod); err != nil {
+			return false, err
+		}
+	case gameKruiseV1alpha1.AllRepairTarget:
+		if err := r.deleteNetworkObjects(ctx, gss, pod); err != nil {
+			return false, err
+		}
+		if err := r.deletePod(ctx, pod); err != nil {
+			return false, err
+		}
+	default:
+		r.recorder.Eventf(gs, corev1.EventTypeWarning, InvalidRepairReason, "repair target %s is invalid, which should be one of %s, %s and %s",
+			target, gameKruiseV1alpha1.NetworkRepairTarget, gameKruiseV1alpha1.PodRepairTarget, gameKruiseV1alpha1.AllRepairTarget)
+		return false, r.removeRepairAnnotation(ctx, gs)
+	}
+
+	if err := r.removeRepairAnnotation(ctx, gs); err != nil {
+		return false, err
+	}
+	r.recorder.Eventf(gs, corev1.EventTypeNormal, RepairReason, "%s of the GameServer is rebuilt", target)
+	return true, nil
+}
+
+// deleteNetworkObjects deletes the Services and Ingresses named after the pod, which are owned by the pod or
+// by its GameServerSet, as the network plugins create them.
+func (r *GameServerReconciler) deleteNetworkObjects(ctx context.Context, gss *gameKruiseV1alpha1.GameServerSet, pod *corev1.Pod) error {
+	key := types.NamespacedName{Namespace: pod.GetNamespace(), Name: pod.GetName()}
+	for _, obj := range []client.Object{&corev1.Service{}, &networkingv1.Ingress{}} {
+		if err := r.Client.Get(ctx, key, obj); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if !isOwnedBy(obj, pod.GetUID(), gss.GetUID()) {
+			continue
+		}
+		if err := r.Client.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+			klog.Errorf("failed to delete %T %s in %s, because of %s.", obj, key.Name, key.Namespace, err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+func isOwnedBy(obj client.Object, uids ...types.UID) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		for _, uid := range uids {
+			if ref.UID == uid {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *GameServerReconciler) triggerNetwork(ctx context.Context, pod *corev1.Pod) error {
+	patchPod := map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]string{gameKruiseV1alpha1.GameServerNetworkTriggerTime: time.Now().Format(TimeFormat)}},
+	}
+	patchPodBytes, err := json.Marshal(patchPod)
+	if err != nil {
+		return err
+	}
+	if err := r.Client.Patch(ctx, pod, client.RawPatch(types.MergePatchType, patchPodBytes)); err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("failed to trigger the network of Pod %s in %s, because of %s.", pod.GetName(), pod.GetNamespace(), err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *GameServerReconciler) deletePod(ctx context.Context, pod *corev1.Pod) error {
+	if pod.GetDeletionTimestamp() != nil {
+		return nil
+	}
+	if err := r.Client.Delete(ctx, pod); err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("failed to delete Pod %s in %s, because of %s.", pod.GetName(), pod.GetNamespace(), err.Error())
+		return err
+	}
+	return nil
+}
+
+func (r *GameServerReconciler) removeRepairAnnotation(ctx context.Context, gs *gameKruiseV1alpha1.GameServer) error {
+	patchGs := fmt.Sprintf(`{"metadata":{"annotations":{%q:null}}}`, gameKruiseV1alpha1.GameServerRepairKey)
+	if err := r.Client.Patch(ctx, gs, client.RawPatch(types.MergePatchType, []byte(patchGs))); err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("failed to remove the repair annotation of GameServer %s in %s, because of %s.", gs.GetName(), gs.GetNamespace(), err.Error())
+		return err
+	}
+	return nil
+}
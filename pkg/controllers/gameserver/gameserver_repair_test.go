@@ -0,0 +1,147 @@
+package gameserver
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestSyncRepair(t *testing.T) {
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case", UID: "gss-uid"},
+	}
+	ownedBy := func(uid types.UID) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{Name: "owner", UID: uid}}
+	}
+	tests := []struct {
+		target          string
+		svcOwner        types.UID
+		expectRepaired  bool
+		expectPodGone   bool
+		expectSvcGone   bool
+		expectIngGone   bool
+		expectTriggered bool
+	}{
+		// no repair
+		{
+			target:   "",
+			svcOwner: "pod-uid",
+		},
+		// the network objects are deleted and the pod is triggered
+		{
+			target:          "network",
+			svcOwner:        "pod-uid",
+			expectRepaired:  true,
+			expectSvcGone:   true,
+			expectIngGone:   true,
+			expectTriggered: true,
+		},
+		// the service of fixed network is owned by the GameServerSet
+		{
+			target:          "network",
+			svcOwner:        "gss-uid",
+			expectRepaired:  true,
+			expectSvcGone:   true,
+			expectIngGone:   true,
+			expectTriggered: true,
+		},
+		// the service not created by the network plugins is kept
+		{
+			target:          "network",
+			svcOwner:        "other-uid",
+			expectRepaired:  true,
+			expectIngGone:   true,
+			expectTriggered: true,
+		},
+		// only the pod is deleted
+		{
+			target:         "pod",
+			svcOwner:       "pod-uid",
+			expectRepaired: true,
+			expectPodGone:  true,
+		},
+		// both are deleted
+		{
+			target:         "all",
+			svcOwner:       "pod-uid",
+			expectRepaired: true,
+			expectPodGone:  true,
+			expectSvcGone:  true,
+			expectIngGone:  true,
+		},
+		// invalid target
+		{
+			target:   "node",
+			svcOwner: "pod-uid",
+		},
+	}
+
+	for i, test := range tests {
+		gs := &gameKruiseV1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0"},
+		}
+		if test.target != "" {
+			gs.Annotations = map[string]string{gameKruiseV1alpha1.GameServerRepairKey: test.target}
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0", UID: "pod-uid"},
+		}
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0", OwnerReferences: ownedBy(test.svcOwner)},
+		}
+		ing := &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0", OwnerReferences: ownedBy("pod-uid")},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gs, pod, svc, ing).Build()
+		r := &GameServerReconciler{Client: c, recorder: record.NewFakeRecorder(10)}
+		repaired, err := r.syncRepair(context.TODO(), gss, gs, pod)
+		if err != nil {
+			t.Fatalf("case %d: %s", i, err.Error())
+		}
+		if repaired != test.expectRepaired {
+			t.Errorf("case %d: expect repaired %v, but actually got %v", i, test.expectRepaired, repaired)
+		}
+
+		for _, expect := range []struct {
+			obj  client.Object
+			gone bool
+		}{
+			{obj: &corev1.Pod{}, gone: test.expectPodGone},
+			{obj: &corev1.Service{}, gone: test.expectSvcGone},
+			{obj: &networkingv1.Ingress{}, gone: test.expectIngGone},
+		} {
+			err := c.Get(context.TODO(), client.ObjectKeyFromObject(pod), expect.obj)
+			if errors.IsNotFound(err) != expect.gone {
+				t.Errorf("case %d: expect %T deleted %v, but actually got %v", i, expect.obj, expect.gone, err)
+			}
+		}
+
+		if test.expectTriggered {
+			actualPod := &corev1.Pod{}
+			if err := c.Get(context.TODO(), client.ObjectKeyFromObject(pod), actualPod); err != nil {
+				t.Fatal(err)
+			}
+			if actualPod.GetAnnotations()[gameKruiseV1alpha1.GameServerNetworkTriggerTime] == "" {
+				t.Errorf("case %d: expect the network of pod triggered", i)
+			}
+		}
+
+		actualGs := &gameKruiseV1alpha1.GameServer{}
+		if err := c.Get(context.TODO(), client.ObjectKeyFromObject(gs), actualGs); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := actualGs.GetAnnotations()[gameKruiseV1alpha1.GameServerRepairKey]; ok {
+			t.Errorf("case %d: expect the repair annotation removed", i)
+		}
+	}
+}
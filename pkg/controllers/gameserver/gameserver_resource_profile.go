@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserver
+
+import (
+	"context"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+const (
+	ResourceProfileReason        = "ResourceProfileChanged"
+	InvalidResourceProfileReason = "InvalidResourceProfile"
+)
+
+// syncResourceProfile applies the ResourceProfile of the GameServer to its pod, which is resized in place,
+// or deleted to be recreated with the resources of the profile once the GameServer is idle.
+func (r *GameServerReconciler) syncResourceProfile(ctx context.Context, gss *gameKruiseV1alpha1.GameServerSet, gs *gameKruiseV1alpha1.GameServer, pod *corev1.Pod) error {
+	name := gs.Spec.ResourceProfile
+	if name == "" || pod.GetDeletionTimestamp() != nil || pod.GetAnnotations()[gameKruiseV1alpha1.GameServerResourceProfileKey] == name {
+		return nil
+	}
+	profile := util.GetResourceProfile(gss.Spec.ResourceProfiles, name)
+	if profile == nil {
+		r.recorder.Eventf(gs, corev1.EventTypeWarning, InvalidResourceProfileReason, "resource profile %s is not found in GameServerSet %s", name, gss.GetName())
+		return nil
+	}
+
+	if profile.ResizePolicy == gameKruiseV1alpha1.RecreateAtIdleResizePolicy {
+		// the GameServers in use keep their resources until they are idle
+		if gs.Spec.OpsState != gameKruiseV1alpha1.None && gs.Spec.OpsState != "" {
+			return nil
+		}
+		if err := r.Client.Delete(ctx, pod); err != nil && !errors.IsNotFound(err) {
+			klog.Errorf("failed to delete Pod %s in %s, because of %s.", pod.GetName(), pod.GetNamespace(), err.Error())
+			return err
+		}
+		r.recorder.Eventf(gs, corev1.EventTypeNormal, ResourceProfileReason, "pod is recreated with resource profile %s", name)
+		return nil
+	}
+
+	var containers []map[string]interface{}
+	for i, container := range util.ApplyResourceProfile(pod.Spec.Containers, profile, util.GetGameContainerName(pod)) {
+		if !reflect.DeepEqual(container.Resources, pod.Spec.Containers[i].Resources) {
+			containers = append(containers, map[string]interface{}{"name": container.Name, "resources": container.Resources})
+		}
+	}
+	patchPod := map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]string{gameKruiseV1alpha1.GameServerResourceProfileKey: name}},
+	}
+	if len(containers) != 0 {
+		patchPod["spec"] = map[string]interface{}{"containers": containers}
+	}
+	patchPodBytes, err := json.Marshal(patchPod)
+	if err != nil {
+		return err
+	}
+	if err := r.Client.Patch(ctx, pod, client.RawPatch(types.StrategicMergePatchType, patchPodBytes)); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		klog.Errorf("failed to resize Pod %s in %s, because of %s.", pod.GetName(), pod.GetNamespace(), err.Error())
+		r.recorder.Eventf(gs, corev1.EventTypeWarning, InvalidResourceProfileReason, "failed to resize pod with resource profile %s, because of %s", name, err.Error())
+		return err
+	}
+	r.recorder.Eventf(gs, corev1.EventTypeNormal, ResourceProfileReason, "pod is resized in place with resource profile %s", name)
+	return nil
+}
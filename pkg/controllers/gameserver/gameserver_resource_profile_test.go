@@ -0,0 +1,121 @@
+package gameserver
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestSyncResourceProfile(t *testing.T) {
+	large := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("4Gi")},
+	}
+	newGss := func(policy gameKruiseV1alpha1.ResizePolicyType) *gameKruiseV1alpha1.GameServerSet {
+		return &gameKruiseV1alpha1.GameServerSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+			Spec: gameKruiseV1alpha1.GameServerSetSpec{
+				ResourceProfiles: []gameKruiseV1alpha1.ResourceProfile{
+					{
+						Name:         "large",
+						Containers:   []gameKruiseV1alpha1.ResourceProfileContainer{{Resources: large}},
+						ResizePolicy: policy,
+					},
+				},
+			},
+		}
+	}
+	newPod := func(profile string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "xxx",
+				Name:        "case-0",
+				Annotations: map[string]string{gameKruiseV1alpha1.GameServerResourceProfileKey: profile},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "game"}, {Name: "sidecar"}},
+			},
+		}
+	}
+	tests := []struct {
+		gss             *gameKruiseV1alpha1.GameServerSet
+		pod             *corev1.Pod
+		profile         string
+		opsState        gameKruiseV1alpha1.OpsState
+		expectDeleted   bool
+		expectResources corev1.ResourceRequirements
+	}{
+		// resized in place
+		{
+			gss:             newGss(""),
+			pod:             newPod(""),
+			profile:         "large",
+			expectResources: large,
+		},
+		// already applied
+		{
+			gss:     newGss(""),
+			pod:     newPod("large"),
+			profile: "large",
+		},
+		// the profile is not found
+		{
+			gss:     newGss(""),
+			pod:     newPod(""),
+			profile: "medium",
+		},
+		// recreated at idle
+		{
+			gss:           newGss(gameKruiseV1alpha1.RecreateAtIdleResizePolicy),
+			pod:           newPod(""),
+			profile:       "large",
+			opsState:      gameKruiseV1alpha1.None,
+			expectDeleted: true,
+		},
+		// not recreated while in use
+		{
+			gss:      newGss(gameKruiseV1alpha1.RecreateAtIdleResizePolicy),
+			pod:      newPod(""),
+			profile:  "large",
+			opsState: gameKruiseV1alpha1.Allocated,
+		},
+	}
+
+	for i, test := range tests {
+		gs := &gameKruiseV1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0"},
+			Spec:       gameKruiseV1alpha1.GameServerSpec{OpsState: test.opsState, ResourceProfile: test.profile},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gs, test.pod).Build()
+		r := &GameServerReconciler{Client: c, recorder: record.NewFakeRecorder(10)}
+		if err := r.syncResourceProfile(context.TODO(), test.gss, gs, test.pod); err != nil {
+			t.Fatalf("case %d: %s", i, err.Error())
+		}
+
+		actualPod := &corev1.Pod{}
+		err := c.Get(context.TODO(), client.ObjectKeyFromObject(test.pod), actualPod)
+		if test.expectDeleted {
+			if !errors.IsNotFound(err) {
+				t.Errorf("case %d: expect pod deleted, but actually got %v", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !test.expectResources.Limits.Cpu().Equal(*actualPod.Spec.Containers[0].Resources.Limits.Cpu()) {
+			t.Errorf("case %d: expect cpu limit %v, but actually got %v", i, test.expectResources.Limits.Cpu(), actualPod.Spec.Containers[0].Resources.Limits.Cpu())
+		}
+		if len(actualPod.Spec.Containers[1].Resources.Limits) != 0 {
+			t.Errorf("case %d: expect the sidecar not resized, but actually got %v", i, actualPod.Spec.Containers[1].Resources)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserver
+
+import (
+	"context"
+	"strconv"
+
+	kruisePub "github.com/openkruise/kruise-api/apps/pub"
+	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+const (
+	UpdateGateReason = "UpdateGate"
+)
+
+// syncUpdateGate releases the pod of the GameServer to be updated after the GameServer passes the UpdateGate,
+// i.e. its opsState is allowed to be updated or it has no active players.
+func (manager GameServerManager) syncUpdateGate(gss *gameKruiseV1alpha1.GameServerSet) error {
+	gs := manager.gameServer
+	pod := manager.pod
+	gate := gss.Spec.UpdateStrategy.UpdateGate
+
+	blocker, hooked := pod.GetLabels()[gameKruiseV1alpha1.UpdateGateBlocker]
+	if !hooked {
+		return nil
+	}
+	if gate == nil {
+		// the UpdateGate is disabled, and the pod is no longer hooked by Advanced StatefulSet
+		return manager.removePodLabel(gameKruiseV1alpha1.UpdateGateBlocker)
+	}
+
+	lifecycleState := pod.GetLabels()[kruisePub.LifecycleStateKey]
+	if lifecycleState != string(kruisePub.LifecycleStatePreparingUpdate) && lifecycleState != string(kruisePub.LifecycleStatePreparingDelete) {
+		// hook the pod again after it is updated, so that the next update will be blocked
+		if blocker != "true" {
+			return manager.patchUpdateGateBlocker("true")
+		}
+		return nil
+	}
+	if blocker != "true" {
+		// the pod has been released
+		return nil
+	}
+
+	asts := &kruiseV1beta1.StatefulSet{}
+	if err := manager.client.Get(context.TODO(), types.NamespacedName{Namespace: gss.GetNamespace(), Name: gss.GetName()}, asts); err != nil {
+		return err
+	}
+	updateRevision := asts.Status.UpdateRevision
+	if updateRevision == "" || pod.GetLabels()[apps.ControllerRevisionHashLabelKey] == updateRevision ||
+		util.IsNumInList(util.GetIndexFromGsName(gs.GetName()), asts.Spec.ReserveOrdinals) {
+		// the pod is deleted for scaling down rather than updating
+		return manager.patchUpdateGateBlocker("false")
+	}
+
+	if !isUpdateGatePassed(gate, gs.Spec.OpsState, pod.GetAnnotations()) {
+		return nil
+	}
+	manager.eventRecorder.Eventf(gs, corev1.EventTypeNormal, UpdateGateReason, "GameServer passed the update gate, it will be updated to revision %s", updateRevision)
+	return manager.patchUpdateGateBlocker("false")
+}
+
+// isUpdateGatePassed returns true if the opsState of the GameServer is allowed to be updated by the gate,
+// or the number of active players recorded in the custom status is zero.
+func isUpdateGatePassed(gate *gameKruiseV1alpha1.UpdateGate, opsState gameKruiseV1alpha1.OpsState, podAnnotations map[string]string) bool {
+	opsStates := gate.OpsStates
+	if len(opsStates) == 0 {
+		opsStates = []gameKruiseV1alpha1.OpsState{gameKruiseV1alpha1.WaitToDelete}
+	}
+	for _, state := range opsStates {
+		if state == opsState {
+			return true
+		}
+	}
+	if gate.PlayersField == "" {
+		return false
+	}
+	players, err := strconv.ParseFloat(podAnnotations[gameKruiseV1alpha1.GameServerCustomStatusPrefix+gate.PlayersField], 64)
+	return err == nil && players <= 0
+}
+
+func (manager GameServerManager) patchUpdateGateBlocker(value string) error {
+	pod := manager.pod
+	patchPod := map[string]interface{}{"metadata": map[string]map[string]string{"labels": {gameKruiseV1alpha1.UpdateGateBlocker: value}}}
+	patchPodBytes, err := json.Marshal(patchPod)
+	if err != nil {
+		return err
+	}
+	if err := manager.client.Patch(context.TODO(), pod, client.RawPatch(types.MergePatchType, patchPodBytes)); err != nil {
+		klog.Errorf("failed to patch Pod %s in %s,because of %s.", pod.GetName(), pod.GetNamespace(), err.Error())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package gameserver
+
+import (
+	"context"
+	"testing"
+
+	kruisePub "github.com/openkruise/kruise-api/apps/pub"
+	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestSyncUpdateGate(t *testing.T) {
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			UpdateStrategy: gameKruiseV1alpha1.UpdateStrategy{
+				UpdateGate: &gameKruiseV1alpha1.UpdateGate{PlayersField: "players"},
+			},
+		},
+	}
+
+	tests := []struct {
+		lifecycleState kruisePub.LifecycleStateType
+		revision       string
+		blocker        string
+		opsState       gameKruiseV1alpha1.OpsState
+		players        string
+		reserveIds     []int
+		disabled       bool
+		expectBlocker  string
+	}{
+		// the pod of the GameServer serving players is held back
+		{
+			lifecycleState: kruisePub.LifecycleStatePreparingUpdate,
+			revision:       "case-old",
+			blocker:        "true",
+			opsState:       gameKruiseV1alpha1.None,
+			players:        "3",
+			expectBlocker:  "true",
+		},
+		// the pod is released when the GameServer is WaitToBeDeleted
+		{
+			lifecycleState: kruisePub.LifecycleStatePreparingUpdate,
+			revision:       "case-old",
+			blocker:        "true",
+			opsState:       gameKruiseV1alpha1.WaitToDelete,
+			players:        "3",
+			expectBlocker:  "false",
+		},
+		// the pod is released when the GameServer has no active players
+		{
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			revision:       "case-old",
+			blocker:        "true",
+			opsState:       gameKruiseV1alpha1.None,
+			players:        "0",
+			expectBlocker:  "false",
+		},
+		// the pod is deleted for scaling down
+		{
+			lifecycleState: kruisePub.LifecycleStatePreparingDelete,
+			revision:       "case-old",
+			blocker:        "true",
+			opsState:       gameKruiseV1alpha1.None,
+			players:        "3",
+			reserveIds:     []int{2},
+			expectBlocker:  "false",
+		},
+		// the pod is hooked again after updated
+		{
+			lifecycleState: kruisePub.LifecycleStateUpdated,
+			revision:       "case-new",
+			blocker:        "false",
+			opsState:       gameKruiseV1alpha1.None,
+			expectBlocker:  "true",
+		},
+		// the blocker is removed after the UpdateGate is disabled
+		{
+			lifecycleState: kruisePub.LifecycleStatePreparingUpdate,
+			revision:       "case-old",
+			blocker:        "true",
+			opsState:       gameKruiseV1alpha1.None,
+			players:        "3",
+			disabled:       true,
+			expectBlocker:  "",
+		},
+	}
+
+	for i, test := range tests {
+		asts := &kruiseV1beta1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+			Spec:       kruiseV1beta1.StatefulSetSpec{ReserveOrdinals: test.reserveIds},
+			Status:     kruiseV1beta1.StatefulSetStatus{UpdateRevision: "case-new"},
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      "case-2",
+				Labels: map[string]string{
+					kruisePub.LifecycleStateKey:          string(test.lifecycleState),
+					apps.ControllerRevisionHashLabelKey:  test.revision,
+					gameKruiseV1alpha1.UpdateGateBlocker: test.blocker,
+				},
+				Annotations: map[string]string{
+					gameKruiseV1alpha1.GameServerCustomStatusPrefix + "players": test.players,
+				},
+			},
+		}
+		gs := &gameKruiseV1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-2"},
+			Spec:       gameKruiseV1alpha1.GameServerSpec{OpsState: test.opsState},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects([]client.Object{gss, asts, pod, gs}...).Build()
+		manager := &GameServerManager{
+			gameServer:    gs,
+			pod:           pod,
+			client:        c,
+			eventRecorder: record.NewFakeRecorder(10),
+		}
+
+		syncGss := gss
+		if test.disabled {
+			syncGss = gss.DeepCopy()
+			syncGss.Spec.UpdateStrategy.UpdateGate = nil
+		}
+		if err := manager.syncUpdateGate(syncGss); err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		newPod := &corev1.Pod{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: "case-2"}, newPod); err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		if blocker, ok := newPod.GetLabels()[gameKruiseV1alpha1.UpdateGateBlocker]; blocker != test.expectBlocker || ok != (test.expectBlocker != "") {
+			t.Errorf("case %d: expect blocker %s, but actually got %s", i, test.expectBlocker, newPod.GetLabels()[gameKruiseV1alpha1.UpdateGateBlocker])
+		}
+	}
+}
+
+func TestIsUpdateGatePassed(t *testing.T) {
+	tests := []struct {
+		gate        *gameKruiseV1alpha1.UpdateGate
+		opsState    gameKruiseV1alpha1.OpsState
+		annotations map[string]string
+		expect      bool
+	}{
+		{
+			gate:     &gameKruiseV1alpha1.UpdateGate{},
+			opsState: gameKruiseV1alpha1.WaitToDelete,
+			expect:   true,
+		},
+		{
+			gate:     &gameKruiseV1alpha1.UpdateGate{},
+			opsState: gameKruiseV1alpha1.Maintaining,
+			expect:   false,
+		},
+		{
+			gate:     &gameKruiseV1alpha1.UpdateGate{OpsStates: []gameKruiseV1alpha1.OpsState{gameKruiseV1alpha1.Maintaining}},
+			opsState: gameKruiseV1alpha1.Maintaining,
+			expect:   true,
+		},
+		{
+			gate:        &gameKruiseV1alpha1.UpdateGate{PlayersField: "players"},
+			opsState:    gameKruiseV1alpha1.None,
+			annotations: map[string]string{gameKruiseV1alpha1.GameServerCustomStatusPrefix + "players": "0"},
+			expect:      true,
+		},
+		{
+			gate:     &gameKruiseV1alpha1.UpdateGate{PlayersField: "players"},
+			opsState: gameKruiseV1alpha1.None,
+			expect:   false,
+		},
+	}
+
+	for i, test := range tests {
+		if actual := isUpdateGatePassed(test.gate, test.opsState, test.annotations); actual != test.expect {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.expect, actual)
+		}
+	}
+}
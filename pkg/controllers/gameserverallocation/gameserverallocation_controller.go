@@ -0,0 +1,430 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverallocation
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/client/helpers"
+	"github.com/openkruise/kruise-game/pkg/features"
+	"github.com/openkruise/kruise-game/pkg/lease"
+	utildiscovery "github.com/openkruise/kruise-game/pkg/util/discovery"
+	"github.com/openkruise/kruise-game/pkg/util/scoring"
+)
+
+const (
+	AllocatedReason   = "Allocated"
+	UnAllocatedReason = "UnAllocated"
+)
+
+const (
+	// RedisPasswordEnv is the environment variable of the password of the Redis lease backend.
+	RedisPasswordEnv = "ALLOCATION_REDIS_PASSWORD"
+	// leaseTTL is long enough for the informer cache to observe the allocated GameServer, during which
+	// the other allocations skip it.
+	leaseTTL = 30 * time.Second
+)
+
+var (
+	controllerKind = gamekruiseiov1alpha1.SchemeGroupVersion.WithKind("GameServerAllocation")
+	// allocations are serialized to avoid allocating the same GameServer repeatedly
+	concurrentReconciles = 1
+
+	leaseConfig lease.Config
+	// serviceAddr is the address of the allocation service, which is disabled if empty.
+	serviceAddr string
+	// openMatchBackend is the address of the HTTP API of the Open Match backend, and the director is disabled if empty.
+	openMatchBackend  string
+	openMatchInterval time.Duration
+)
+
+func init() {
+	flag.StringVar((*string)(&leaseConfig.Backend), "allocation-lease-backend", string(lease.BackendCRD), "The backend of the leases of the GameServers being allocated, CRD or Redis.")
+	flag.StringVar(&leaseConfig.RedisAddress, "allocation-redis-address", "", "The address of the Redis server holding the leases, required by the Redis lease backend.")
+	flag.IntVar(&leaseConfig.RedisDB, "allocation-redis-db", 0, "The database of the Redis server holding the leases.")
+	flag.StringVar(&leaseConfig.RedisKeyPrefix, "allocation-redis-key-prefix", lease.DefaultRedisKeyPrefix, "The prefix of the keys of the leases in Redis.")
+	flag.StringVar(&serviceAddr, "allocation-service-bind-address", "", "The address the allocation service binds to, which allocates GameServers for the HTTPS requests of matchmakers. Disabled if empty.")
+	flag.StringVar(&openMatchBackend, "open-match-backend-address", "", "The address of the HTTP API of the Open Match backend, from which the director fetches matches and allocates GameServers for them. Disabled if empty.")
+	flag.DurationVar(&openMatchInterval, "open-match-fetch-interval", 5*time.Second, "The interval of fetching matches from the Open Match backend.")
+}
+
+func Add(mgr manager.Manager) error {
+	if !features.DefaultFeatureGate.Enabled(features.Allocator) {
+		return nil
+	}
+	leaseConfig.RedisPassword = os.Getenv(RedisPasswordEnv)
+	leases, err := lease.New(&leaseConfig)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	service := NewService(mgr.GetClient(), mgr.GetAPIReader(), leases, serviceAddr)
+	if serviceAddr != "" {
+		if err := mgr.Add(service); err != nil {
+			klog.Error(err)
+			return err
+		}
+	}
+	if openMatchBackend != "" {
+		if err := mgr.Add(NewDirector(mgr.GetClient(), service, openMatchBackend, openMatchInterval)); err != nil {
+			klog.Error(err)
+			return err
+		}
+	}
+	if !utildiscovery.DiscoverGVK(controllerKind) {
+		return nil
+	}
+	return add(mgr, newReconciler(mgr, leases))
+}
+
+func newReconciler(mgr manager.Manager, leases lease.Backend) reconcile.Reconciler {
+	recorder := mgr.GetEventRecorderFor("gameserverallocation-controller")
+	return &GameServerAllocationReconciler{
+		Client:   mgr.GetClient(),
+		recorder: recorder,
+		leases:   leases,
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	klog.Info("Starting GameServerAllocation Controller")
+	c, err := controller.New("gameserverallocation-controller", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: concurrentReconciles})
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	if err = c.Watch(&source.Kind{Type: &gamekruiseiov1alpha1.GameServerAllocation{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// GameServerAllocationReconciler reconciles a GameServerAllocation object
+type GameServerAllocationReconciler struct {
+	client.Client
+	recorder record.EventRecorder
+	leases   lease.Backend
+}
+
+//+kubebuilder:rbac:groups=game.kruise.io,resources=gameserverallocations,verbs=get;list;watch;update;patch;delete
+//+kubebuilder:rbac:groups=game.kruise.io,resources=gameserverallocations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=game.kruise.io,resources=preemptionpolicies,verbs=get;list;watch
+
+// Reconcile allocates an idle GameServer for a new GameServerAllocation and records the result in its status.
+// The GameServerAllocation is deleted once its TTL after finished expires.
+func (r *GameServerAllocationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	gsa := &gamekruiseiov1alpha1.GameServerAllocation{}
+	err := r.Get(ctx, req.NamespacedName, gsa)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		klog.Errorf("failed to find GameServerAllocation %s in %s, because of %s.", req.Name, req.Namespace, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	if gsa.GetDeletionTimestamp() != nil {
+		return reconcile.Result{}, nil
+	}
+
+	if gsa.Status.State == "" {
+		status, err := r.allocate(ctx, gsa)
+		if err != nil {
+			klog.Errorf("GameServerAllocation %s failed to allocate in %s, because of %s.", gsa.GetName(), gsa.GetNamespace(), err.Error())
+			return reconcile.Result{}, err
+		}
+		gsa.Status = status
+		if err := r.Status().Update(ctx, gsa); err != nil {
+			klog.Errorf("failed to update status of GameServerAllocation %s in %s, because of %s.", gsa.GetName(), gsa.GetNamespace(), err.Error())
+			return reconcile.Result{}, err
+		}
+		if status.State == gamekruiseiov1alpha1.GameServerAllocationAllocated {
+			r.recorder.Event(gsa, corev1.EventTypeNormal, AllocatedReason, status.Message)
+		} else {
+			r.recorder.Event(gsa, corev1.EventTypeWarning, UnAllocatedReason, status.Message)
+		}
+	}
+
+	expired, remaining := isExpired(gsa, time.Now())
+	if !expired {
+		return reconcile.Result{RequeueAfter: remaining}, nil
+	}
+	if err := r.Delete(ctx, gsa); err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("failed to delete expired GameServerAllocation %s in %s, because of %s.", gsa.GetName(), gsa.GetNamespace(), err.Error())
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// allocate picks an idle GameServer matching the selector and marks it as Allocated.
+func (r *GameServerAllocationReconciler) allocate(ctx context.Context, gsa *gamekruiseiov1alpha1.GameServerAllocation) (gamekruiseiov1alpha1.GameServerAllocationStatus, error) {
+	now := metav1.Now()
+	selector, err := metav1.LabelSelectorAsSelector(gsa.Spec.Selector)
+	if err != nil {
+		return gamekruiseiov1alpha1.GameServerAllocationStatus{
+			State:          gamekruiseiov1alpha1.GameServerAllocationUnAllocated,
+			Message:        fmt.Sprintf("invalid selector: %s", err.Error()),
+			CompletionTime: &now,
+		}, nil
+	}
+
+	// the GameServer chosen before may have been marked as Allocated, while the status failed to be updated
+	chosen := gsa.GetAnnotations()[gamekruiseiov1alpha1.GameServerAllocationChosenKey]
+	if chosen != "" {
+		gs := &gamekruiseiov1alpha1.GameServer{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: gsa.GetNamespace(), Name: chosen}, gs)
+		if err != nil && !errors.IsNotFound(err) {
+			return gamekruiseiov1alpha1.GameServerAllocationStatus{}, err
+		}
+		if err == nil && gs.Spec.OpsState == gamekruiseiov1alpha1.Allocated && gs.GetAnnotations()[gamekruiseiov1alpha1.GameServerAllocationKey] == gsa.GetName() {
+			return allocatedStatus(gs, now), nil
+		}
+	}
+
+	gsList := &gamekruiseiov1alpha1.GameServerList{}
+	// only the GameServers whose opsState is None can be idle
+	if err := r.List(ctx, gsList, client.InNamespace(gsa.GetNamespace()), client.MatchingLabelsSelector{Selector: selector},
+		client.MatchingFields{helpers.IndexOpsState: string(gamekruiseiov1alpha1.None)}); err != nil {
+		return gamekruiseiov1alpha1.GameServerAllocationStatus{}, err
+	}
+
+	scores, err := r.scoreGameServers(ctx, gsList.Items)
+	if err != nil {
+		return gamekruiseiov1alpha1.GameServerAllocationStatus{}, err
+	}
+	candidates := rankGameServers(gsList.Items, scores)
+	// try the GameServer chosen before first, so that a stale cache results in conflicts rather than another allocation
+	for i := range candidates {
+		if candidates[i].GetName() == chosen {
+			gs := candidates[i]
+			copy(candidates[1:i+1], candidates[:i])
+			candidates[0] = gs
+			break
+		}
+	}
+	gs, err := r.leaseGameServer(ctx, gsa, candidates)
+	if err != nil {
+		return gamekruiseiov1alpha1.GameServerAllocationStatus{}, err
+	}
+	if gs == nil {
+		message := fmt.Sprintf("there is no idle GameServer matching selector %s", selector.String())
+		preempted, err := r.preempt(ctx, gsa)
+		if err != nil {
+			return gamekruiseiov1alpha1.GameServerAllocationStatus{}, err
+		}
+		if preempted != "" {
+			message = fmt.Sprintf("%s, GameServer %s is preempted and the allocation can be retried later", message, preempted)
+		}
+		return gamekruiseiov1alpha1.GameServerAllocationStatus{
+			State:                   gamekruiseiov1alpha1.GameServerAllocationUnAllocated,
+			PreemptedGameServerName: preempted,
+			Message:                 message,
+			CompletionTime:          &now,
+		}, nil
+	}
+
+	// record the chosen GameServer before marking it, so that the allocation is resumed rather than repeated on retry
+	if gs.GetName() != chosen {
+		patch := map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]string{gamekruiseiov1alpha1.GameServerAllocationChosenKey: gs.GetName()}}}
+		patchBytes, _ := json.Marshal(patch)
+		if err := r.Patch(ctx, gsa, client.RawPatch(types.MergePatchType, patchBytes)); err != nil {
+			r.releaseLease(ctx, gsa, gs)
+			return gamekruiseiov1alpha1.GameServerAllocationStatus{}, err
+		}
+	}
+
+	gs.Spec.OpsState = gamekruiseiov1alpha1.Allocated
+	if gs.Annotations == nil {
+		gs.Annotations = make(map[string]string)
+	}
+	gs.Annotations[gamekruiseiov1alpha1.GameServerAllocationKey] = gsa.GetName()
+	// the update fails with conflict if the GameServer has been changed by others, and it will be retried
+	if err := r.Update(ctx, gs); err != nil {
+		r.releaseLease(ctx, gsa, gs)
+		return gamekruiseiov1alpha1.GameServerAllocationStatus{}, err
+	}
+
+	return allocatedStatus(gs, now), nil
+}
+
+func allocatedStatus(gs *gamekruiseiov1alpha1.GameServer, now metav1.Time) gamekruiseiov1alpha1.GameServerAllocationStatus {
+	return gamekruiseiov1alpha1.GameServerAllocationStatus{
+		State:             gamekruiseiov1alpha1.GameServerAllocationAllocated,
+		GameServerName:    gs.GetName(),
+		ExternalAddresses: gs.Status.NetworkStatus.ExternalAddresses,
+		Message:           fmt.Sprintf("GameServer %s is allocated", gs.GetName()),
+		CompletionTime:    &now,
+	}
+}
+
+func (r *GameServerAllocationReconciler) releaseLease(ctx context.Context, gsa *gamekruiseiov1alpha1.GameServerAllocation, gs *gamekruiseiov1alpha1.GameServer) {
+	if err := r.leases.Release(ctx, client.ObjectKeyFromObject(gs), leaseHolder(gsa)); err != nil {
+		klog.Errorf("failed to release the lease of GameServer %s in %s, because of %s.", gs.GetName(), gs.GetNamespace(), err.Error())
+	}
+}
+
+// leaseGameServer returns the first candidate leased to the GameServerAllocation, or nil if all of them are
+// leased to others.
+func (r *GameServerAllocationReconciler) leaseGameServer(ctx context.Context, gsa *gamekruiseiov1alpha1.GameServerAllocation, candidates []*gamekruiseiov1alpha1.GameServer) (*gamekruiseiov1alpha1.GameServer, error) {
+	for _, gs := range candidates {
+		acquired, err := r.leases.Acquire(ctx, client.ObjectKeyFromObject(gs), leaseHolder(gsa), leaseTTL)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			return gs, nil
+		}
+	}
+	return nil, nil
+}
+
+func leaseHolder(gsa *gamekruiseiov1alpha1.GameServerAllocation) string {
+	return gsa.GetNamespace() + "/" + gsa.GetName()
+}
+
+// pickGameServer returns the first idle GameServer ranked by rankGameServers, or nil if there is none.
+func pickGameServer(gss []gamekruiseiov1alpha1.GameServer, scores map[string]float64) *gamekruiseiov1alpha1.GameServer {
+	idle := rankGameServers(gss, scores)
+	if len(idle) == 0 {
+		return nil
+	}
+	return idle[0]
+}
+
+// rankGameServers returns the idle GameServers grouped by GameServerSets, which are ordered by the smallest name
+// of their idle GameServers. Within a GameServerSet, GameServers are in the descending order of scores. Scores are
+// only compared within a GameServerSet, since each GameServerSet scores its GameServers by its own ScoringPolicy.
+// GameServers without scores are scored 0, and those with the same score are ordered by name.
+func rankGameServers(gss []gamekruiseiov1alpha1.GameServer, scores map[string]float64) []*gamekruiseiov1alpha1.GameServer {
+	var idle []*gamekruiseiov1alpha1.GameServer
+	for i := range gss {
+		if gss[i].GetDeletionTimestamp() == nil && helpers.IsIdle(&gss[i]) {
+			idle = append(idle, &gss[i])
+		}
+	}
+	sort.Slice(idle, func(i, j int) bool { return idle[i].GetName() < idle[j].GetName() })
+
+	gssOrder := make(map[string]int)
+	for _, gs := range idle {
+		gssName := gs.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]
+		if _, ok := gssOrder[gssName]; !ok {
+			gssOrder[gssName] = len(gssOrder)
+		}
+	}
+	sort.SliceStable(idle, func(i, j int) bool {
+		iOrder := gssOrder[idle[i].GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]]
+		jOrder := gssOrder[idle[j].GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]]
+		if iOrder != jOrder {
+			return iOrder < jOrder
+		}
+		return scores[idle[i].GetName()] > scores[idle[j].GetName()]
+	})
+	return idle
+}
+
+// scoreGameServers scores the idle GameServers by the ScoringPolicy of their GameServerSets.
+// GameServers of all GameServerSets are counted on the nodes, since the nodes are shared by them.
+func (r *GameServerAllocationReconciler) scoreGameServers(ctx context.Context, gsList []gamekruiseiov1alpha1.GameServer) (map[string]float64, error) {
+	candidates := make(map[string]sets.String)
+	for i := range gsList {
+		gs := &gsList[i]
+		gssName := gs.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]
+		if gssName == "" || gs.GetDeletionTimestamp() != nil || !helpers.IsIdle(gs) {
+			continue
+		}
+		if _, ok := candidates[gssName]; !ok {
+			candidates[gssName] = sets.NewString()
+		}
+		candidates[gssName].Insert(gs.GetName())
+	}
+
+	scores := make(map[string]float64)
+	var podList *corev1.PodList
+	for gssName, names := range candidates {
+		gss := &gamekruiseiov1alpha1.GameServerSet{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: gsList[0].GetNamespace(), Name: gssName}, gss); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if gss.Spec.ScoringPolicy == nil {
+			continue
+		}
+		if podList == nil {
+			podList = &corev1.PodList{}
+			if err := r.List(ctx, podList, client.HasLabels{gamekruiseiov1alpha1.GameServerOwnerGssKey}); err != nil {
+				return nil, err
+			}
+		}
+		scorer, err := scoring.NewScorer(gss.Spec.ScoringPolicy, podList.Items)
+		if err != nil {
+			return nil, err
+		}
+		for i := range podList.Items {
+			pod := &podList.Items[i]
+			if pod.GetNamespace() != gss.GetNamespace() || pod.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey] != gssName || !names.Has(pod.GetName()) {
+				continue
+			}
+			score, err := scorer.Score(pod)
+			if err != nil {
+				return nil, err
+			}
+			scores[pod.GetName()] = score
+		}
+	}
+	return scores, nil
+}
+
+// isExpired returns whether the finished GameServerAllocation should be deleted.
+// If not, the duration to wait is returned, zero means no need to wait.
+func isExpired(gsa *gamekruiseiov1alpha1.GameServerAllocation, now time.Time) (bool, time.Duration) {
+	ttl := gsa.Spec.TTLSecondsAfterFinished
+	if ttl == nil || gsa.Status.CompletionTime == nil {
+		return false, 0
+	}
+	expireAt := gsa.Status.CompletionTime.Add(time.Duration(*ttl) * time.Second)
+	if !now.Before(expireAt) {
+		return true, 0
+	}
+	return false, expireAt.Sub(now)
+}
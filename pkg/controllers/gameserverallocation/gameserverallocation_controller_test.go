@@ -0,0 +1,349 @@
+package gameserverallocation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openkruise/kruise-game/pkg/lease"
+)
+
+var (
+	scheme = runtime.NewScheme()
+)
+
+func init() {
+	utilruntime.Must(gameKruiseV1alpha1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+}
+
+func newGs(name string, state gameKruiseV1alpha1.GameServerState, opsState gameKruiseV1alpha1.OpsState) *gameKruiseV1alpha1.GameServer {
+	return &gameKruiseV1alpha1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "xxx",
+			Name:      name,
+			Labels:    map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "foo"},
+		},
+		Spec: gameKruiseV1alpha1.GameServerSpec{
+			OpsState: opsState,
+		},
+		Status: gameKruiseV1alpha1.GameServerStatus{
+			CurrentState: state,
+		},
+	}
+}
+
+func newPod(name, nodeName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "xxx",
+			Name:      name,
+			Labels:    map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "foo"},
+		},
+		Spec: corev1.PodSpec{NodeName: nodeName},
+	}
+}
+
+func newOwnedPod(gssName, name, nodeName string) *corev1.Pod {
+	pod := newPod(name, nodeName)
+	pod.Labels[gameKruiseV1alpha1.GameServerOwnerGssKey] = gssName
+	return pod
+}
+
+// fakeLeases holds the leases in memory by the names of GameServers.
+type fakeLeases map[string]string
+
+func (l fakeLeases) Acquire(_ context.Context, key types.NamespacedName, holder string, _ time.Duration) (bool, error) {
+	if h, ok := l[key.Name]; ok && h != holder {
+		return false, nil
+	}
+	l[key.Name] = holder
+	return true, nil
+}
+
+func (l fakeLeases) Release(_ context.Context, key types.NamespacedName, holder string) error {
+	if l[key.Name] == holder {
+		delete(l, key.Name)
+	}
+	return nil
+}
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		gss            []*gameKruiseV1alpha1.GameServer
+		gameServerSet  *gameKruiseV1alpha1.GameServerSet
+		pods           []*corev1.Pod
+		leases         fakeLeases
+		chosen         string
+		state          gameKruiseV1alpha1.GameServerAllocationState
+		gameServerName string
+	}{
+		// the GameServer chosen and marked before the status failed to be updated is reused
+		{
+			gss: []*gameKruiseV1alpha1.GameServer{
+				newGs("foo-2", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+				func() *gameKruiseV1alpha1.GameServer {
+					gs := newGs("foo-3", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated)
+					gs.Annotations = map[string]string{gameKruiseV1alpha1.GameServerAllocationKey: "gsa-0"}
+					return gs
+				}(),
+			},
+			chosen:         "foo-3",
+			state:          gameKruiseV1alpha1.GameServerAllocationAllocated,
+			gameServerName: "foo-3",
+		},
+		{
+			gss: []*gameKruiseV1alpha1.GameServer{
+				newGs("foo-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated),
+				newGs("foo-1", gameKruiseV1alpha1.NotReady, gameKruiseV1alpha1.None),
+				newGs("foo-3", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+				newGs("foo-2", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			},
+			state:          gameKruiseV1alpha1.GameServerAllocationAllocated,
+			gameServerName: "foo-2",
+		},
+		{
+			gss: []*gameKruiseV1alpha1.GameServer{
+				newGs("foo-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated),
+				newGs("foo-1", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Maintaining),
+			},
+			state: gameKruiseV1alpha1.GameServerAllocationUnAllocated,
+		},
+		// the GameServer on the node with more GameServers is allocated by Packed strategy
+		{
+			gss: []*gameKruiseV1alpha1.GameServer{
+				newGs("foo-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated),
+				newGs("foo-2", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+				newGs("foo-3", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			},
+			gameServerSet: &gameKruiseV1alpha1.GameServerSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "foo"},
+				Spec: gameKruiseV1alpha1.GameServerSetSpec{
+					ScoringPolicy: &gameKruiseV1alpha1.ScoringPolicy{Strategy: gameKruiseV1alpha1.PackedScoringStrategyType},
+				},
+			},
+			pods: []*corev1.Pod{
+				newPod("foo-0", "node-b"),
+				newPod("foo-2", "node-a"),
+				newPod("foo-3", "node-b"),
+			},
+			state:          gameKruiseV1alpha1.GameServerAllocationAllocated,
+			gameServerName: "foo-3",
+		},
+		// the GameServers of other GameServerSets are counted on the nodes
+		{
+			gss: []*gameKruiseV1alpha1.GameServer{
+				newGs("foo-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated),
+				newGs("foo-2", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+				newGs("foo-3", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			},
+			gameServerSet: &gameKruiseV1alpha1.GameServerSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "foo"},
+				Spec: gameKruiseV1alpha1.GameServerSetSpec{
+					ScoringPolicy: &gameKruiseV1alpha1.ScoringPolicy{Strategy: gameKruiseV1alpha1.PackedScoringStrategyType},
+				},
+			},
+			pods: []*corev1.Pod{
+				newPod("foo-0", "node-b"),
+				newPod("foo-2", "node-a"),
+				newPod("foo-3", "node-b"),
+				newOwnedPod("bar", "bar-0", "node-a"),
+				newOwnedPod("bar", "bar-1", "node-a"),
+			},
+			state:          gameKruiseV1alpha1.GameServerAllocationAllocated,
+			gameServerName: "foo-2",
+		},
+		// the GameServer leased to another allocation is skipped
+		{
+			gss: []*gameKruiseV1alpha1.GameServer{
+				newGs("foo-2", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+				newGs("foo-3", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			},
+			leases:         fakeLeases{"foo-2": "xxx/gsa-1"},
+			state:          gameKruiseV1alpha1.GameServerAllocationAllocated,
+			gameServerName: "foo-3",
+		},
+		{
+			gss: []*gameKruiseV1alpha1.GameServer{
+				newGs("foo-2", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			},
+			leases: fakeLeases{"foo-2": "xxx/gsa-1"},
+			state:  gameKruiseV1alpha1.GameServerAllocationUnAllocated,
+		},
+	}
+
+	for i, test := range tests {
+		gsa := &gameKruiseV1alpha1.GameServerAllocation{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      "gsa-0",
+			},
+			Spec: gameKruiseV1alpha1.GameServerAllocationSpec{
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "foo"},
+				},
+			},
+		}
+		if test.chosen != "" {
+			gsa.Annotations = map[string]string{gameKruiseV1alpha1.GameServerAllocationChosenKey: test.chosen}
+		}
+		builder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gsa)
+		for _, gs := range test.gss {
+			builder.WithObjects(gs)
+		}
+		for _, pod := range test.pods {
+			builder.WithObjects(pod)
+		}
+		if test.gameServerSet != nil {
+			builder.WithObjects(test.gameServerSet)
+		}
+		c := builder.Build()
+		var leases lease.Backend = lease.NewCRDBackend()
+		if test.leases != nil {
+			leases = test.leases
+		}
+		r := &GameServerAllocationReconciler{Client: c, recorder: record.NewFakeRecorder(10), leases: leases}
+
+		if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "xxx", Name: "gsa-0"}}); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		actual := &gameKruiseV1alpha1.GameServerAllocation{}
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "xxx", Name: "gsa-0"}, actual); err != nil {
+			t.Error(err)
+			continue
+		}
+		if actual.Status.State != test.state || actual.Status.GameServerName != test.gameServerName {
+			t.Errorf("case %d: expect state %s and GameServer %s, but actually got %s and %s", i, test.state, test.gameServerName, actual.Status.State, actual.Status.GameServerName)
+		}
+		if test.gameServerName == "" {
+			continue
+		}
+		gs := &gameKruiseV1alpha1.GameServer{}
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "xxx", Name: test.gameServerName}, gs); err != nil {
+			t.Error(err)
+			continue
+		}
+		if gs.Spec.OpsState != gameKruiseV1alpha1.Allocated || gs.Annotations[gameKruiseV1alpha1.GameServerAllocationKey] != "gsa-0" {
+			t.Errorf("case %d: GameServer %s is not marked as allocated", i, gs.GetName())
+		}
+		if actual.Annotations[gameKruiseV1alpha1.GameServerAllocationChosenKey] != test.gameServerName {
+			t.Errorf("case %d: expect GameServer %s recorded as chosen, but actually got %s", i, test.gameServerName, actual.Annotations[gameKruiseV1alpha1.GameServerAllocationChosenKey])
+		}
+		allocated := &gameKruiseV1alpha1.GameServerList{}
+		if err := c.List(context.Background(), allocated); err != nil {
+			t.Error(err)
+			continue
+		}
+		for _, other := range allocated.Items {
+			if other.GetName() != test.gameServerName && other.Annotations[gameKruiseV1alpha1.GameServerAllocationKey] == "gsa-0" {
+				t.Errorf("case %d: GameServer %s is allocated twice", i, other.GetName())
+			}
+		}
+	}
+}
+
+func TestRankGameServers(t *testing.T) {
+	newOwnedGs := func(gssName, name string) gameKruiseV1alpha1.GameServer {
+		gs := newGs(name, gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None)
+		gs.Labels[gameKruiseV1alpha1.GameServerOwnerGssKey] = gssName
+		return *gs
+	}
+	gsList := []gameKruiseV1alpha1.GameServer{
+		newOwnedGs("bar", "bar-1"),
+		newOwnedGs("bar", "bar-0"),
+		newOwnedGs("foo", "foo-0"),
+		newOwnedGs("foo", "foo-1"),
+	}
+	tests := []struct {
+		scores   map[string]float64
+		expected []string
+	}{
+		{
+			expected: []string{"bar-0", "bar-1", "foo-0", "foo-1"},
+		},
+		// scores are compared within a GameServerSet
+		{
+			scores:   map[string]float64{"bar-1": 1, "foo-1": 100},
+			expected: []string{"bar-1", "bar-0", "foo-1", "foo-0"},
+		},
+		// a GameServerSet without ScoringPolicy is not ranked behind the others
+		{
+			scores:   map[string]float64{"foo-0": 100, "foo-1": 200},
+			expected: []string{"bar-0", "bar-1", "foo-1", "foo-0"},
+		},
+	}
+
+	for i, test := range tests {
+		var actual []string
+		for _, gs := range rankGameServers(gsList, test.scores) {
+			actual = append(actual, gs.GetName())
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.expected, actual)
+		}
+	}
+}
+
+func TestIsExpired(t *testing.T) {
+	now := time.Now()
+	completionTime := metav1.NewTime(now.Add(-10 * time.Second))
+	tests := []struct {
+		gsa       *gameKruiseV1alpha1.GameServerAllocation
+		expired   bool
+		remaining time.Duration
+	}{
+		// no ttl
+		{
+			gsa: &gameKruiseV1alpha1.GameServerAllocation{
+				Status: gameKruiseV1alpha1.GameServerAllocationStatus{CompletionTime: &completionTime},
+			},
+			expired:   false,
+			remaining: 0,
+		},
+		// not finished
+		{
+			gsa: &gameKruiseV1alpha1.GameServerAllocation{
+				Spec: gameKruiseV1alpha1.GameServerAllocationSpec{TTLSecondsAfterFinished: ptr.To[int32](5)},
+			},
+			expired:   false,
+			remaining: 0,
+		},
+		{
+			gsa: &gameKruiseV1alpha1.GameServerAllocation{
+				Spec:   gameKruiseV1alpha1.GameServerAllocationSpec{TTLSecondsAfterFinished: ptr.To[int32](5)},
+				Status: gameKruiseV1alpha1.GameServerAllocationStatus{CompletionTime: &completionTime},
+			},
+			expired:   true,
+			remaining: 0,
+		},
+		{
+			gsa: &gameKruiseV1alpha1.GameServerAllocation{
+				Spec:   gameKruiseV1alpha1.GameServerAllocationSpec{TTLSecondsAfterFinished: ptr.To[int32](30)},
+				Status: gameKruiseV1alpha1.GameServerAllocationStatus{CompletionTime: &completionTime},
+			},
+			expired:   false,
+			remaining: 20 * time.Second,
+		},
+	}
+
+	for i, test := range tests {
+		expired, remaining := isExpired(test.gsa, now)
+		if expired != test.expired || remaining != test.remaining {
+			t.Errorf("case %d: expect %v %v, but actually got %v %v", i, test.expired, test.remaining, expired, remaining)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverallocation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/readonly"
+)
+
+// The paths of the HTTP API of the Open Match backend.
+const (
+	openMatchFetchMatchesPath   = "/v1/backendservice/matches:fetch"
+	openMatchAssignTicketsPath  = "/v1/backendservice/tickets:assign"
+	openMatchReleaseTicketsPath = "/v1/backendservice/tickets:release"
+)
+
+// The messages of the Open Match backend API in JSON, with the fields used by the director only.
+type omFunctionConfig struct {
+	Host string `json:"host"`
+	Port int32  `json:"port"`
+	Type string `json:"type"`
+}
+
+type omFetchMatchesRequest struct {
+	Config  omFunctionConfig `json:"config"`
+	Profile json.RawMessage  `json:"profile"`
+}
+
+type omFetchMatchesResponse struct {
+	Result *struct {
+		Match omMatch `json:"match"`
+	} `json:"result,omitempty"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+type omMatch struct {
+	MatchID string     `json:"match_id"`
+	Tickets []omTicket `json:"tickets"`
+}
+
+type omTicket struct {
+	ID string `json:"id"`
+}
+
+type omAssignTicketsRequest struct {
+	Assignments []omAssignmentGroup `json:"assignments"`
+}
+
+type omAssignmentGroup struct {
+	TicketIDs  []string     `json:"ticket_ids"`
+	Assignment omAssignment `json:"assignment"`
+}
+
+type omAssignment struct {
+	Connection string `json:"connection"`
+}
+
+type omAssignTicketsResponse struct {
+	Failures []struct {
+		TicketID string `json:"ticket_id"`
+		Cause    string `json:"cause"`
+	} `json:"failures"`
+}
+
+type omReleaseTicketsRequest struct {
+	TicketIDs []string `json:"ticket_ids"`
+}
+
+// openMatchConf is the Open Match configuration of a GameServerSet, parsed from its annotations.
+type openMatchConf struct {
+	profile  json.RawMessage
+	function omFunctionConfig
+}
+
+// Director fetches the matches of the GameServerSets annotated with the Open Match profiles from the Open Match
+// backend, allocates a GameServer for each match through the allocation service, and assigns the connection of the
+// GameServer to the tickets of the match. The tickets of the matches without GameServers are released, so that they
+// are matched again.
+type Director struct {
+	client.Client
+	service    *Service
+	backend    string
+	interval   time.Duration
+	httpClient *http.Client
+}
+
+// NewDirector returns the director calling the HTTP API of the Open Match backend at the address every interval.
+func NewDirector(c client.Client, service *Service, backend string, interval time.Duration) *Director {
+	if !strings.Contains(backend, "://") {
+		backend = "http://" + backend
+	}
+	return &Director{
+		Client:     c,
+		service:    service,
+		backend:    strings.TrimSuffix(backend, "/"),
+		interval:   interval,
+		httpClient: &http.Client{Timeout: time.Minute},
+	}
+}
+
+// Start implements manager.Runnable.
+func (d *Director) Start(ctx context.Context) error {
+	klog.Infof("Open Match director is fetching matches from %s", d.backend)
+	wait.UntilWithContext(ctx, d.direct, d.interval)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Only the leader fetches the matches, so that each
+// match is allocated once.
+func (d *Director) NeedLeaderElection() bool {
+	return true
+}
+
+func (d *Director) direct(ctx context.Context) {
+	// the allocations are rejected in read-only mode, and the matches are left in Open Match
+	if readonly.Enabled() {
+		return
+	}
+	gssList := &gamekruiseiov1alpha1.GameServerSetList{}
+	if err := d.List(ctx, gssList); err != nil {
+		klog.Errorf("failed to list GameServerSets for Open Match, because of %s.", err.Error())
+		return
+	}
+	for i := range gssList.Items {
+		gss := &gssList.Items[i]
+		if _, ok := gss.GetAnnotations()[gamekruiseiov1alpha1.OpenMatchProfileKey]; !ok {
+			continue
+		}
+		if err := d.directGameServerSet(ctx, gss); err != nil {
+			klog.Errorf("failed to direct the matches of GameServerSet %s in %s, because of %s.", gss.GetName(), gss.GetNamespace(), err.Error())
+		}
+	}
+}
+
+// directGameServerSet allocates a GameServer of the GameServerSet for each match fetched by its profile.
+func (d *Director) directGameServerSet(ctx context.Context, gss *gamekruiseiov1alpha1.GameServerSet) error {
+	conf, err := parseOpenMatchConf(gss)
+	if err != nil {
+		return err
+	}
+	matches, err := d.fetchMatches(ctx, conf)
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		ticketIDs := make([]string, 0, len(match.Tickets))
+		for _, ticket := range match.Tickets {
+			ticketIDs = append(ticketIDs, ticket.ID)
+		}
+		if len(ticketIDs) == 0 {
+			continue
+		}
+
+		req := &AllocationRequest{Namespace: gss.GetNamespace(), GameServerSetName: gss.GetName()}
+		selector, err := validateAllocationRequest(req)
+		if err != nil {
+			return err
+		}
+		resp, err := d.service.Allocate(ctx, req, selector)
+		if err != nil || resp.State != gamekruiseiov1alpha1.GameServerAllocationAllocated {
+			if releaseErr := d.releaseTickets(ctx, ticketIDs); releaseErr != nil {
+				klog.Errorf("failed to release the tickets of match %s, because of %s.", match.MatchID, releaseErr.Error())
+			}
+			if err != nil {
+				return err
+			}
+			klog.Warningf("match %s of GameServerSet %s in %s is released, because %s.", match.MatchID, gss.GetName(), gss.GetNamespace(), resp.Message)
+			continue
+		}
+
+		if err := d.assignTickets(ctx, ticketIDs, openMatchConnection(resp.ExternalAddresses)); err != nil {
+			klog.Errorf("failed to assign GameServer %s in %s to match %s, because of %s.", resp.GameServerName, gss.GetNamespace(), match.MatchID, err.Error())
+			continue
+		}
+		klog.Infof("GameServer %s in %s is assigned to match %s.", resp.GameServerName, gss.GetNamespace(), match.MatchID)
+	}
+	return nil
+}
+
+// parseOpenMatchConf parses the Open Match annotations of the GameServerSet.
+func parseOpenMatchConf(gss *gamekruiseiov1alpha1.GameServerSet) (*openMatchConf, error) {
+	annotations := gss.GetAnnotations()
+	profile := json.RawMessage(annotations[gamekruiseiov1alpha1.OpenMatchProfileKey])
+	var fields map[string]interface{}
+	if err := json.Unmarshal(profile, &fields); err != nil || fields == nil {
+		return nil, fmt.Errorf("invalid %s, which should be the match profile in JSON", gamekruiseiov1alpha1.OpenMatchProfileKey)
+	}
+
+	function := annotations[gamekruiseiov1alpha1.OpenMatchFunctionKey]
+	host, portStr, err := net.SplitHostPort(function)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %s, which should be host:port", gamekruiseiov1alpha1.OpenMatchFunctionKey, function)
+	}
+	port, err := strconv.ParseInt(portStr, 10, 32)
+	if err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid port of %s %s", gamekruiseiov1alpha1.OpenMatchFunctionKey, function)
+	}
+
+	return &openMatchConf{
+		profile:  profile,
+		function: omFunctionConfig{Host: host, Port: int32(port), Type: "GRPC"},
+	}, nil
+}
+
+// openMatchConnection returns the connection of the GameServer assigned to the tickets, which is the host:port of the
+// first external address, or the host only if it has no ports.
+func openMatchConnection(addresses []gamekruiseiov1alpha1.NetworkAddress) string {
+	if len(addresses) == 0 {
+		return ""
+	}
+	address := addresses[0]
+	host := address.IP
+	if host == "" {
+		host = address.EndPoint
+	}
+	if len(address.Ports) != 0 && address.Ports[0].Port != nil {
+		return net.JoinHostPort(host, address.Ports[0].Port.String())
+	}
+	return host
+}
+
+// fetchMatches returns the matches generated by the match function for the profile, which are streamed in JSON
+// objects one after another.
+func (d *Director) fetchMatches(ctx context.Context, conf *openMatchConf) ([]omMatch, error) {
+	body, err := d.post(ctx, openMatchFetchMatchesPath, &omFetchMatchesRequest{Config: conf.function, Profile: conf.profile})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var matches []omMatch
+	decoder := json.NewDecoder(body)
+	for {
+		resp := &omFetchMatchesResponse{}
+		if err := decoder.Decode(resp); err == io.EOF {
+			return matches, nil
+		} else if err != nil {
+			return matches, fmt.Errorf("invalid matches from Open Match: %s", err.Error())
+		}
+		if resp.Error != nil {
+			return matches, fmt.Errorf("failed to fetch matches from Open Match: %s", resp.Error.Message)
+		}
+		if resp.Result != nil {
+			matches = append(matches, resp.Result.Match)
+		}
+	}
+}
+
+// assignTickets assigns the connection to the tickets.
+func (d *Director) assignTickets(ctx context.Context, ticketIDs []string, connection string) error {
+	body, err := d.post(ctx, openMatchAssignTicketsPath, &omAssignTicketsRequest{
+		Assignments: []omAssignmentGroup{{TicketIDs: ticketIDs, Assignment: omAssignment{Connection: connection}}},
+	})
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	resp := &omAssignTicketsResponse{}
+	if err := json.NewDecoder(body).Decode(resp); err != nil && err != io.EOF {
+		return fmt.Errorf("invalid assignment result from Open Match: %s", err.Error())
+	}
+	if len(resp.Failures) != 0 {
+		return fmt.Errorf("ticket %s is not assigned: %s", resp.Failures[0].TicketID, resp.Failures[0].Cause)
+	}
+	return nil
+}
+
+// releaseTickets releases the tickets, so that they are matched again.
+func (d *Director) releaseTickets(ctx context.Context, ticketIDs []string) error {
+	body, err := d.post(ctx, openMatchReleaseTicketsPath, &omReleaseTicketsRequest{TicketIDs: ticketIDs})
+	if err != nil {
+		return err
+	}
+	return body.Close()
+}
+
+// post sends the request in JSON to the Open Match backend, and returns the body of the successful response.
+func (d *Director) post(ctx context.Context, path string, req interface{}) (io.ReadCloser, error) {
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, d.backend+path, bytes.NewReader(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := d.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("Open Match backend %s returned %s: %s", path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp.Body, nil
+}
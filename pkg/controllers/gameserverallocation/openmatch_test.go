@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverallocation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/lease"
+)
+
+func TestDirector(t *testing.T) {
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "xxx",
+			Name:      "foo",
+			Annotations: map[string]string{
+				gameKruiseV1alpha1.OpenMatchProfileKey:  `{"name":"1v1","pools":[{"name":"all"}]}`,
+				gameKruiseV1alpha1.OpenMatchFunctionKey: "om-function.open-match:50502",
+			},
+		},
+	}
+	gs := newGs("foo-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None)
+	port := intstr.FromInt(7777)
+	gs.Status.NetworkStatus.ExternalAddresses = []gameKruiseV1alpha1.NetworkAddress{
+		{IP: "1.2.3.4", Ports: []gameKruiseV1alpha1.NetworkPort{{Name: "7777", Port: &port}}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gss, gs).Build()
+
+	var fetch omFetchMatchesRequest
+	var assign omAssignTicketsRequest
+	var release omReleaseTicketsRequest
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case openMatchFetchMatchesPath:
+			_ = json.NewDecoder(r.Body).Decode(&fetch)
+			fmt.Fprintln(w, `{"result":{"match":{"match_id":"m-1","tickets":[{"id":"t-1"},{"id":"t-2"}]}}}`)
+			fmt.Fprintln(w, `{"result":{"match":{"match_id":"m-2","tickets":[{"id":"t-3"},{"id":"t-4"}]}}}`)
+		case openMatchAssignTicketsPath:
+			_ = json.NewDecoder(r.Body).Decode(&assign)
+			fmt.Fprintln(w, `{}`)
+		case openMatchReleaseTicketsPath:
+			_ = json.NewDecoder(r.Body).Decode(&release)
+			fmt.Fprintln(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer backend.Close()
+
+	d := NewDirector(c, NewService(c, c, lease.NewCRDBackend(), ""), backend.URL, time.Second)
+	d.direct(context.Background())
+
+	if fetch.Config.Host != "om-function.open-match" || fetch.Config.Port != 50502 || string(fetch.Profile) != gss.Annotations[gameKruiseV1alpha1.OpenMatchProfileKey] {
+		t.Errorf("expect matches fetched by the profile and function of GameServerSet, but actually got %+v", fetch)
+	}
+	expectAssign := omAssignTicketsRequest{Assignments: []omAssignmentGroup{{TicketIDs: []string{"t-1", "t-2"}, Assignment: omAssignment{Connection: "1.2.3.4:7777"}}}}
+	if !reflect.DeepEqual(assign, expectAssign) {
+		t.Errorf("expect assignment %+v, but actually got %+v", expectAssign, assign)
+	}
+	// there is no GameServer left for the second match
+	if !reflect.DeepEqual(release.TicketIDs, []string{"t-3", "t-4"}) {
+		t.Errorf("expect tickets [t-3 t-4] released, but actually got %v", release.TicketIDs)
+	}
+}
+
+func TestParseOpenMatchConf(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		valid       bool
+	}{
+		{
+			annotations: map[string]string{
+				gameKruiseV1alpha1.OpenMatchProfileKey:  `{"name":"1v1"}`,
+				gameKruiseV1alpha1.OpenMatchFunctionKey: "om-function:50502",
+			},
+			valid: true,
+		},
+		{
+			annotations: map[string]string{
+				gameKruiseV1alpha1.OpenMatchProfileKey:  `name: 1v1`,
+				gameKruiseV1alpha1.OpenMatchFunctionKey: "om-function:50502",
+			},
+		},
+		{
+			annotations: map[string]string{
+				gameKruiseV1alpha1.OpenMatchProfileKey:  `{"name":"1v1"}`,
+				gameKruiseV1alpha1.OpenMatchFunctionKey: "om-function",
+			},
+		},
+	}
+	for i, test := range tests {
+		_, err := parseOpenMatchConf(&gameKruiseV1alpha1.GameServerSet{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}})
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverallocation
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+// preempt frees a replica of the pool for the fleet requested by the GameServerAllocation
+// by preempting an idle GameServer of a lower priority fleet, and scales up the requested fleet.
+// It returns the name of the preempted GameServer, or empty if no preemption happened.
+func (r *GameServerAllocationReconciler) preempt(ctx context.Context, gsa *gamekruiseiov1alpha1.GameServerAllocation) (string, error) {
+	if gsa.Spec.PreemptionPolicyName == "" || gsa.Spec.Selector == nil {
+		return "", nil
+	}
+	targetGssName := gsa.Spec.Selector.MatchLabels[gamekruiseiov1alpha1.GameServerOwnerGssKey]
+	if targetGssName == "" {
+		return "", nil
+	}
+
+	policy := &gamekruiseiov1alpha1.PreemptionPolicy{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: gsa.GetNamespace(), Name: gsa.Spec.PreemptionPolicyName}, policy); err != nil {
+		if errors.IsNotFound(err) {
+			klog.Warningf("PreemptionPolicy %s of GameServerAllocation %s/%s is not found", gsa.Spec.PreemptionPolicyName, gsa.GetNamespace(), gsa.GetName())
+			return "", nil
+		}
+		return "", err
+	}
+
+	gssMap := make(map[string]*gamekruiseiov1alpha1.GameServerSet)
+	for _, p := range policy.Spec.GameServerSets {
+		gss := &gamekruiseiov1alpha1.GameServerSet{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: gsa.GetNamespace(), Name: p.Name}, gss); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return "", err
+		}
+		gssMap[p.Name] = gss
+	}
+	targetGss, ok := gssMap[targetGssName]
+	if !ok {
+		return "", nil
+	}
+
+	record, err := r.recordPreemption(ctx, gsa, policy, targetGssName, gssMap)
+	if err != nil || record == nil {
+		return "", err
+	}
+
+	victim := &gamekruiseiov1alpha1.GameServer{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: gsa.GetNamespace(), Name: record.Victim}, victim); err != nil {
+		if !errors.IsNotFound(err) {
+			return "", err
+		}
+		victim = nil
+	}
+	victimGss := gssMap[record.VictimGameServerSet]
+
+	// every step checks the record, so that a retry never preempts or scales twice
+	switch policy.Spec.Action {
+	case gamekruiseiov1alpha1.PreemptionActionDelete:
+		// the GameServerSet will reduce its replicas when killing the GameServer
+		if victim != nil && victim.Spec.OpsState != gamekruiseiov1alpha1.Kill {
+			victim.Spec.OpsState = gamekruiseiov1alpha1.Kill
+			if err := r.Update(ctx, victim); err != nil {
+				return "", err
+			}
+		}
+	default:
+		if victim != nil && victim.Spec.OpsState != gamekruiseiov1alpha1.WaitToDelete {
+			victim.Spec.OpsState = gamekruiseiov1alpha1.WaitToDelete
+			if err := r.Update(ctx, victim); err != nil {
+				return "", err
+			}
+		}
+		if victimGss != nil && getReplicas(victimGss) == record.VictimReplicas {
+			victimGss.Spec.Replicas = ptr.To[int32](record.VictimReplicas - 1)
+			if err := r.Update(ctx, victimGss); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if getReplicas(targetGss) == record.TargetReplicas {
+		targetGss.Spec.Replicas = ptr.To[int32](record.TargetReplicas + 1)
+		if err := r.Update(ctx, targetGss); err != nil {
+			return "", err
+		}
+	}
+	return record.Victim, nil
+}
+
+// preemptionRecord is recorded on the GameServerAllocation before the preemption changes anything.
+// The replicas are the ones observed before the preemption, and a GameServerSet whose replicas
+// no longer equal them has been scaled already.
+type preemptionRecord struct {
+	Victim              string `json:"victim"`
+	VictimGameServerSet string `json:"victimGameServerSet"`
+	VictimReplicas      int32  `json:"victimReplicas"`
+	TargetReplicas      int32  `json:"targetReplicas"`
+}
+
+// recordPreemption returns the preemption recorded on the GameServerAllocation, or selects a victim
+// and records a new one if the quota is hit. It returns nil if there is nothing to preempt.
+func (r *GameServerAllocationReconciler) recordPreemption(ctx context.Context, gsa *gamekruiseiov1alpha1.GameServerAllocation, policy *gamekruiseiov1alpha1.PreemptionPolicy, targetGssName string, gssMap map[string]*gamekruiseiov1alpha1.GameServerSet) (*preemptionRecord, error) {
+	if value, ok := gsa.GetAnnotations()[gamekruiseiov1alpha1.GameServerAllocationPreemptionKey]; ok {
+		record := &preemptionRecord{}
+		if err := json.Unmarshal([]byte(value), record); err != nil {
+			klog.Warningf("GameServerAllocation %s/%s has an invalid preemption record %s: %s", gsa.GetNamespace(), gsa.GetName(), value, err.Error())
+			return nil, nil
+		}
+		return record, nil
+	}
+
+	if !isQuotaHit(policy, gssMap) {
+		return nil, nil
+	}
+	gsList := &gamekruiseiov1alpha1.GameServerList{}
+	if err := r.List(ctx, gsList, client.InNamespace(gsa.GetNamespace())); err != nil {
+		return nil, err
+	}
+	victim := selectVictim(policy, targetGssName, gsList.Items)
+	if victim == nil {
+		return nil, nil
+	}
+	victimGss, ok := gssMap[victim.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]]
+	if !ok {
+		return nil, nil
+	}
+
+	record := &preemptionRecord{
+		Victim:              victim.GetName(),
+		VictimGameServerSet: victimGss.GetName(),
+		VictimReplicas:      getReplicas(victimGss),
+		TargetReplicas:      getReplicas(gssMap[targetGssName]),
+	}
+	recordBytes, _ := json.Marshal(record)
+	patch := map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]string{gamekruiseiov1alpha1.GameServerAllocationPreemptionKey: string(recordBytes)}}}
+	patchBytes, _ := json.Marshal(patch)
+	if err := r.Patch(ctx, gsa, client.RawPatch(types.MergePatchType, patchBytes)); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// isQuotaHit returns whether the sum of replicas of the fleets in the pool reaches the quota.
+func isQuotaHit(policy *gamekruiseiov1alpha1.PreemptionPolicy, gssMap map[string]*gamekruiseiov1alpha1.GameServerSet) bool {
+	var total int32
+	for _, gss := range gssMap {
+		total += getReplicas(gss)
+	}
+	return total >= policy.Spec.MaxReplicas
+}
+
+func getReplicas(gss *gamekruiseiov1alpha1.GameServerSet) int32 {
+	if gss.Spec.Replicas == nil {
+		return 0
+	}
+	return *gss.Spec.Replicas
+}
+
+// selectVictim returns an idle GameServer of the fleet with the lowest priority,
+// which must be lower than the priority of the target fleet.
+func selectVictim(policy *gamekruiseiov1alpha1.PreemptionPolicy, targetGssName string, gsList []gamekruiseiov1alpha1.GameServer) *gamekruiseiov1alpha1.GameServer {
+	priorities := make(map[string]int32)
+	for _, p := range policy.Spec.GameServerSets {
+		priorities[p.Name] = p.Priority
+	}
+	targetPriority, ok := priorities[targetGssName]
+	if !ok {
+		return nil
+	}
+
+	candidates := make([]gamekruiseiov1alpha1.PreemptionGameServerSet, 0)
+	for _, p := range policy.Spec.GameServerSets {
+		if p.Priority < targetPriority {
+			candidates = append(candidates, p)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Priority < candidates[j].Priority })
+
+	for _, candidate := range candidates {
+		var gss []gamekruiseiov1alpha1.GameServer
+		for _, gs := range gsList {
+			if gs.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey] == candidate.Name {
+				gss = append(gss, gs)
+			}
+		}
+		if victim := pickGameServer(gss, nil); victim != nil {
+			return victim
+		}
+	}
+	return nil
+}
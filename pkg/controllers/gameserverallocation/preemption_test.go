@@ -0,0 +1,204 @@
+package gameserverallocation
+
+import (
+	"context"
+	"testing"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/lease"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newPoolGs(gssName, name string, state gameKruiseV1alpha1.GameServerState, opsState gameKruiseV1alpha1.OpsState) gameKruiseV1alpha1.GameServer {
+	gs := newGs(name, state, opsState)
+	gs.Labels[gameKruiseV1alpha1.GameServerOwnerGssKey] = gssName
+	return *gs
+}
+
+func TestSelectVictim(t *testing.T) {
+	policy := &gameKruiseV1alpha1.PreemptionPolicy{
+		Spec: gameKruiseV1alpha1.PreemptionPolicySpec{
+			GameServerSets: []gameKruiseV1alpha1.PreemptionGameServerSet{
+				{Name: "high", Priority: 100},
+				{Name: "middle", Priority: 50},
+				{Name: "low", Priority: 10},
+			},
+		},
+	}
+	tests := []struct {
+		target string
+		gsList []gameKruiseV1alpha1.GameServer
+		victim string
+	}{
+		// preempt the fleet with the lowest priority first
+		{
+			target: "high",
+			gsList: []gameKruiseV1alpha1.GameServer{
+				newPoolGs("middle", "middle-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+				newPoolGs("low", "low-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated),
+				newPoolGs("low", "low-1", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			},
+			victim: "low-1",
+		},
+		// fall back to the fleet with higher priority when there is no idle GameServer
+		{
+			target: "high",
+			gsList: []gameKruiseV1alpha1.GameServer{
+				newPoolGs("middle", "middle-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+				newPoolGs("low", "low-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated),
+			},
+			victim: "middle-0",
+		},
+		// never preempt the fleet with the same or higher priority
+		{
+			target: "middle",
+			gsList: []gameKruiseV1alpha1.GameServer{
+				newPoolGs("high", "high-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+				newPoolGs("middle", "middle-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			},
+			victim: "",
+		},
+		// target is not in the pool
+		{
+			target: "other",
+			gsList: []gameKruiseV1alpha1.GameServer{
+				newPoolGs("low", "low-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			},
+			victim: "",
+		},
+	}
+
+	for i, test := range tests {
+		victim := selectVictim(policy, test.target, test.gsList)
+		actual := ""
+		if victim != nil {
+			actual = victim.GetName()
+		}
+		if actual != test.victim {
+			t.Errorf("case %d: expect victim %s, but actually got %s", i, test.victim, actual)
+		}
+	}
+}
+
+func TestPreempt(t *testing.T) {
+	tests := []struct {
+		action          gameKruiseV1alpha1.PreemptionAction
+		maxReplicas     int32
+		victim          string
+		victimOpsState  gameKruiseV1alpha1.OpsState
+		lowReplicas     int32
+		highReplicas    int32
+		preemptedGsName string
+	}{
+		{
+			action:          gameKruiseV1alpha1.PreemptionActionDrain,
+			maxReplicas:     2,
+			victim:          "low-0",
+			victimOpsState:  gameKruiseV1alpha1.WaitToDelete,
+			lowReplicas:     0,
+			highReplicas:    2,
+			preemptedGsName: "low-0",
+		},
+		{
+			action:          gameKruiseV1alpha1.PreemptionActionDelete,
+			maxReplicas:     2,
+			victim:          "low-0",
+			victimOpsState:  gameKruiseV1alpha1.Kill,
+			lowReplicas:     1,
+			highReplicas:    2,
+			preemptedGsName: "low-0",
+		},
+		// quota is not hit
+		{
+			action:          gameKruiseV1alpha1.PreemptionActionDrain,
+			maxReplicas:     3,
+			victim:          "low-0",
+			victimOpsState:  gameKruiseV1alpha1.None,
+			lowReplicas:     1,
+			highReplicas:    1,
+			preemptedGsName: "",
+		},
+	}
+
+	for i, test := range tests {
+		policy := &gameKruiseV1alpha1.PreemptionPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "pool"},
+			Spec: gameKruiseV1alpha1.PreemptionPolicySpec{
+				GameServerSets: []gameKruiseV1alpha1.PreemptionGameServerSet{
+					{Name: "high", Priority: 100},
+					{Name: "low", Priority: 10},
+				},
+				MaxReplicas: test.maxReplicas,
+				Action:      test.action,
+			},
+		}
+		highGss := &gameKruiseV1alpha1.GameServerSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "high"},
+			Spec:       gameKruiseV1alpha1.GameServerSetSpec{Replicas: ptr.To[int32](1)},
+		}
+		lowGss := &gameKruiseV1alpha1.GameServerSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "low"},
+			Spec:       gameKruiseV1alpha1.GameServerSetSpec{Replicas: ptr.To[int32](1)},
+		}
+		highGs := newPoolGs("high", "high-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated)
+		lowGs := newPoolGs("low", "low-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None)
+		gsa := &gameKruiseV1alpha1.GameServerAllocation{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "gsa-0"},
+			Spec: gameKruiseV1alpha1.GameServerAllocationSpec{
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "high"},
+				},
+				PreemptionPolicyName: "pool",
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(policy, highGss, lowGss, &highGs, &lowGs, gsa).Build()
+		r := &GameServerAllocationReconciler{Client: c, recorder: record.NewFakeRecorder(10), leases: lease.NewCRDBackend()}
+
+		status, err := r.allocate(context.Background(), gsa)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if status.State != gameKruiseV1alpha1.GameServerAllocationUnAllocated || status.PreemptedGameServerName != test.preemptedGsName {
+			t.Errorf("case %d: expect preempted %s, but actually got %s", i, test.preemptedGsName, status.PreemptedGameServerName)
+		}
+
+		// a retried preemption must not preempt or scale again
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "xxx", Name: "gsa-0"}, gsa); err != nil {
+			t.Error(err)
+			continue
+		}
+		preempted, err := r.preempt(context.Background(), gsa)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if preempted != test.preemptedGsName {
+			t.Errorf("case %d: expect retried preemption %s, but actually got %s", i, test.preemptedGsName, preempted)
+		}
+
+		victim := &gameKruiseV1alpha1.GameServer{}
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "xxx", Name: test.victim}, victim); err != nil {
+			t.Error(err)
+			continue
+		}
+		if victim.Spec.OpsState != test.victimOpsState {
+			t.Errorf("case %d: expect victim opsState %s, but actually got %s", i, test.victimOpsState, victim.Spec.OpsState)
+		}
+		for name, replicas := range map[string]int32{"low": test.lowReplicas, "high": test.highReplicas} {
+			gss := &gameKruiseV1alpha1.GameServerSet{}
+			if err := c.Get(context.Background(), types.NamespacedName{Namespace: "xxx", Name: name}, gss); err != nil {
+				t.Error(err)
+				continue
+			}
+			if *gss.Spec.Replicas != replicas {
+				t.Errorf("case %d: expect GameServerSet %s replicas %d, but actually got %d", i, name, replicas, *gss.Spec.Replicas)
+			}
+		}
+	}
+}
@@ -0,0 +1,482 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverallocation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/client/helpers"
+	"github.com/openkruise/kruise-game/pkg/httpauth"
+	"github.com/openkruise/kruise-game/pkg/lease"
+	"github.com/openkruise/kruise-game/pkg/readonly"
+)
+
+const (
+	// ServicePath is the path of the allocation service, which accepts AllocationRequest in JSON by POST.
+	ServicePath = "/allocate"
+	// ReleasePath is the path of the release endpoint, which accepts ReleaseRequest in JSON by POST.
+	ReleasePath = "/release"
+	// maxRequestBytes limits the size of the allocation requests.
+	maxRequestBytes = 1 << 20
+)
+
+// AllocationRequest is the request of the allocation service from matchmakers.
+type AllocationRequest struct {
+	// Namespace is the namespace of the GameServers.
+	Namespace string `json:"namespace"`
+	// GameServerSetName limits the allocation to the GameServers of the GameServerSet, if set.
+	GameServerSetName string `json:"gameServerSetName,omitempty"`
+	// Selector is a label query over the GameServers, the required labels of which are in matchLabels.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// AllocationResponse is the result of the allocation.
+type AllocationResponse struct {
+	State gamekruiseiov1alpha1.GameServerAllocationState `json:"state"`
+	// GameServerName is the name of the allocated GameServer.
+	GameServerName string `json:"gameServerName,omitempty"`
+	// ExternalAddresses are the external addresses of the allocated GameServer.
+	ExternalAddresses []gamekruiseiov1alpha1.NetworkAddress `json:"externalAddresses,omitempty"`
+	// AllocatedSlots is the number of the allocated slots of the GameServer, including this allocation.
+	AllocatedSlots int32 `json:"allocatedSlots,omitempty"`
+	// Message indicates the details of the allocation result.
+	Message string `json:"message,omitempty"`
+}
+
+// ReleaseRequest is the request of the release endpoint, which frees a slot of the GameServer allocated by the service.
+type ReleaseRequest struct {
+	// Namespace is the namespace of the GameServer.
+	Namespace string `json:"namespace"`
+	// GameServerName is the name of the GameServer.
+	GameServerName string `json:"gameServerName"`
+}
+
+// ReleaseResponse is the result of the release.
+type ReleaseResponse struct {
+	// Released is whether a slot of the GameServer is freed.
+	Released bool `json:"released"`
+	// GameServerName is the name of the GameServer.
+	GameServerName string `json:"gameServerName"`
+	// AllocatedSlots is the number of the slots of the GameServer left allocated, and the GameServer is set back to
+	// None once it is 0.
+	AllocatedSlots int32 `json:"allocatedSlots"`
+	// Message indicates the details of the release result.
+	Message string `json:"message,omitempty"`
+}
+
+// Service allocates GameServers for the requests of matchmakers synchronously. The allocations of a replica are
+// serialized, and the GameServers being allocated are leased to keep the other replicas and the
+// GameServerAllocation controller off them. The conflicts left are resolved by the optimistic concurrency of updates.
+type Service struct {
+	client.Client
+	// reader reads the latest GameServers from the API server when the cached ones are stale.
+	reader client.Reader
+	leases lease.Backend
+	addr   string
+	mutex  sync.Mutex
+}
+
+// NewService returns the allocation service serving on addr.
+func NewService(c client.Client, reader client.Reader, leases lease.Backend, addr string) *Service {
+	return &Service{
+		Client: c,
+		reader: reader,
+		leases: leases,
+		addr:   addr,
+	}
+}
+
+// Start implements manager.Runnable. The service is served over TLS, for the bearer tokens of the requests.
+func (s *Service) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle(ServicePath, s)
+	mux.Handle(ReleasePath, s)
+	server := &httpauth.Server{Name: "allocation service", Addr: s.addr, Handler: mux}
+	return server.Start(ctx)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every replica serves the allocations.
+func (s *Service) NeedLeaderElection() bool {
+	return false
+}
+
+// ServeHTTP handles the allocation and release requests of the users allowed to create GameServerAllocations in the
+// namespace. The result is returned with 200 whether a GameServer is allocated or released or not.
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if readonly.Enabled() {
+		http.Error(w, readonly.ErrReadOnly.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	var resp interface{}
+	switch r.URL.Path {
+	case ServicePath:
+		req := &AllocationRequest{}
+		if !s.decodeRequest(w, r, req, func() string { return req.Namespace }) {
+			return
+		}
+		selector, err := validateAllocationRequest(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if resp, err = s.Allocate(r.Context(), req, selector); err != nil {
+			klog.Errorf("failed to allocate GameServer in %s, because of %s.", req.Namespace, err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	case ReleasePath:
+		req := &ReleaseRequest{}
+		if !s.decodeRequest(w, r, req, func() string { return req.Namespace }) {
+			return
+		}
+		if req.GameServerName == "" {
+			http.Error(w, "gameServerName is required", http.StatusBadRequest)
+			return
+		}
+		var err error
+		if resp, err = s.Release(r.Context(), req); err != nil {
+			klog.Errorf("failed to release GameServer %s in %s, because of %s.", req.GameServerName, req.Namespace, err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// decodeRequest decodes the request in JSON, and authorizes it in the namespace of the request. It writes the error
+// and returns false if the request is invalid or not allowed.
+func (s *Service) decodeRequest(w http.ResponseWriter, r *http.Request, req interface{}, namespace func() string) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %s", err.Error()), http.StatusBadRequest)
+		return false
+	}
+	if namespace() == "" {
+		http.Error(w, "namespace is required", http.StatusBadRequest)
+		return false
+	}
+	if code, err := s.authorize(r, namespace()); err != nil {
+		http.Error(w, err.Error(), code)
+		return false
+	}
+	return true
+}
+
+// authorize checks whether the user of the request is allowed to create GameServerAllocations in the namespace, so
+// that a matchmaker allocates the GameServers of the namespaces it is granted only. It returns the HTTP status code
+// with the error if the request is not allowed.
+func (s *Service) authorize(r *http.Request, namespace string) (int, error) {
+	return httpauth.Authorize(s.Client, r, &authorizationv1.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      "create",
+		Group:     gamekruiseiov1alpha1.GroupVersion.Group,
+		Resource:  "gameserverallocations",
+	})
+}
+
+// validateAllocationRequest returns the selector of the GameServers of the request.
+func validateAllocationRequest(req *AllocationRequest) (labels.Selector, error) {
+	if req.Namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	selector, err := metav1.LabelSelectorAsSelector(req.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector: %s", err.Error())
+	}
+	if req.Selector == nil {
+		selector = labels.Everything()
+	}
+	if req.GameServerSetName != "" {
+		requirement, err := labels.NewRequirement(gamekruiseiov1alpha1.GameServerOwnerGssKey, selection.Equals, []string{req.GameServerSetName})
+		if err != nil {
+			return nil, fmt.Errorf("invalid gameServerSetName: %s", err.Error())
+		}
+		selector = selector.Add(*requirement)
+	}
+	return selector, nil
+}
+
+// getAllocationCapacity returns the number of slots of each GameServer of the GameServerSet by AllocationCapacityKey,
+// which is 1 if not set.
+func getAllocationCapacity(gss *gamekruiseiov1alpha1.GameServerSet) (int32, error) {
+	v, ok := gss.GetAnnotations()[gamekruiseiov1alpha1.AllocationCapacityKey]
+	if !ok {
+		return 1, nil
+	}
+	capacity, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || capacity < 1 {
+		return 0, fmt.Errorf("invalid %s %s, which should be a positive integer", gamekruiseiov1alpha1.AllocationCapacityKey, v)
+	}
+	return int32(capacity), nil
+}
+
+// getCapacities returns the number of slots of the GameServers of each GameServerSet in the namespace. The
+// GameServerSets with an invalid capacity are left out, so that their GameServers are not allocated.
+func (s *Service) getCapacities(ctx context.Context, namespace string) (map[string]int32, error) {
+	gssList := &gamekruiseiov1alpha1.GameServerSetList{}
+	if err := s.List(ctx, gssList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	capacities := make(map[string]int32, len(gssList.Items))
+	for i := range gssList.Items {
+		gss := &gssList.Items[i]
+		capacity, err := getAllocationCapacity(gss)
+		if err != nil {
+			klog.Warningf("GameServers of GameServerSet %s in %s are not allocated, because of %s.", gss.GetName(), gss.GetNamespace(), err.Error())
+			continue
+		}
+		capacities[gss.GetName()] = capacity
+	}
+	return capacities, nil
+}
+
+// Allocate takes a slot of an allocated GameServer with free slots, or of an idle GameServer picked by the
+// ScoringPolicy, and marks the GameServer as Allocated. The number of slots of a GameServer is the capacity of its
+// GameServerSet.
+func (s *Service) Allocate(ctx context.Context, req *AllocationRequest, selector labels.Selector) (*AllocationResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	gsList := &gamekruiseiov1alpha1.GameServerList{}
+	if err := s.List(ctx, gsList, client.InNamespace(req.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	capacities, err := s.getCapacities(ctx, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// the allocated GameServers are filled up first, so that the idle ones are kept for the other requests
+	for _, gs := range rankPartiallyAllocated(gsList.Items, capacities) {
+		allocated, err := s.allocateSlot(ctx, gs, capacities)
+		if err != nil {
+			return nil, err
+		}
+		if allocated != nil {
+			return allocatedResponse(allocated), nil
+		}
+	}
+
+	r := &GameServerAllocationReconciler{Client: s.Client}
+	scores, err := r.scoreGameServers(ctx, gsList.Items)
+	if err != nil {
+		return nil, err
+	}
+	holder := "allocation-service/" + string(uuid.NewUUID())
+	for _, gs := range rankGameServers(gsList.Items, scores) {
+		if _, ok := capacities[gs.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]]; !ok {
+			continue
+		}
+		key := client.ObjectKeyFromObject(gs)
+		acquired, err := s.leases.Acquire(ctx, key, holder, leaseTTL)
+		if err != nil {
+			return nil, err
+		}
+		if !acquired {
+			continue
+		}
+		// the lease is kept until it expires, so that the cache can observe the allocated GameServer
+		allocated, err := s.allocateSlot(ctx, gs, capacities)
+		if err != nil || allocated == nil {
+			if releaseErr := s.leases.Release(ctx, key, holder); releaseErr != nil {
+				klog.Errorf("failed to release the lease of GameServer %s in %s, because of %s.", gs.GetName(), gs.GetNamespace(), releaseErr.Error())
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if allocated != nil {
+			return allocatedResponse(allocated), nil
+		}
+	}
+
+	return &AllocationResponse{
+		State:   gamekruiseiov1alpha1.GameServerAllocationUnAllocated,
+		Message: fmt.Sprintf("there is no idle GameServer matching selector %s", selector.String()),
+	}, nil
+}
+
+// allocateSlot marks the GameServer as Allocated with one more allocated slot. The latest GameServer is read
+// and checked again on conflicts. It returns nil if the GameServer is no longer available.
+func (s *Service) allocateSlot(ctx context.Context, gs *gamekruiseiov1alpha1.GameServer, capacities map[string]int32) (*gamekruiseiov1alpha1.GameServer, error) {
+	gs = gs.DeepCopy()
+	available := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		slots, ok := allocatedSlots(gs)
+		available = ok && slots < capacities[gs.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]] && isAvailable(gs)
+		if !available {
+			return nil
+		}
+		gs.Spec.OpsState = gamekruiseiov1alpha1.Allocated
+		if gs.Annotations == nil {
+			gs.Annotations = make(map[string]string)
+		}
+		gs.Annotations[gamekruiseiov1alpha1.GameServerAllocatedSlotsKey] = strconv.Itoa(int(slots + 1))
+		err := s.Update(ctx, gs)
+		if errors.IsConflict(err) {
+			latest := &gamekruiseiov1alpha1.GameServer{}
+			if getErr := s.reader.Get(ctx, client.ObjectKeyFromObject(gs), latest); getErr != nil {
+				return getErr
+			}
+			gs = latest
+		}
+		return err
+	})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !available {
+		return nil, nil
+	}
+	return gs, nil
+}
+
+// Release frees a slot of the GameServer allocated by the service, and sets it back to None once all of its slots
+// are freed. The GameServers allocated without the slots recorded, such as by GameServerAllocation, are not released.
+func (s *Service) Release(ctx context.Context, req *ReleaseRequest) (*ReleaseResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	resp := &ReleaseResponse{GameServerName: req.GameServerName}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		gs := &gamekruiseiov1alpha1.GameServer{}
+		if err := s.reader.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: req.GameServerName}, gs); err != nil {
+			return err
+		}
+		slots, ok := allocatedSlots(gs)
+		if !ok || slots == 0 {
+			resp.Message = fmt.Sprintf("GameServer %s has no slot allocated by the allocation service", gs.GetName())
+			return nil
+		}
+		slots--
+		if slots == 0 {
+			gs.Spec.OpsState = gamekruiseiov1alpha1.None
+			delete(gs.Annotations, gamekruiseiov1alpha1.GameServerAllocatedSlotsKey)
+		} else {
+			gs.Annotations[gamekruiseiov1alpha1.GameServerAllocatedSlotsKey] = strconv.Itoa(int(slots))
+		}
+		if err := s.Update(ctx, gs); err != nil {
+			return err
+		}
+		resp.Released = true
+		resp.AllocatedSlots = slots
+		resp.Message = fmt.Sprintf("a slot of GameServer %s is released", gs.GetName())
+		return nil
+	})
+	if errors.IsNotFound(err) {
+		resp.Message = fmt.Sprintf("GameServer %s is not found", req.GameServerName)
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// allocatedSlots returns the number of the allocated slots of the GameServer, which is 0 for the idle ones. It returns
+// false if the GameServer is allocated without the slots recorded, such as by GameServerAllocation.
+func allocatedSlots(gs *gamekruiseiov1alpha1.GameServer) (int32, bool) {
+	switch gs.Spec.OpsState {
+	case gamekruiseiov1alpha1.None, "":
+		return 0, true
+	case gamekruiseiov1alpha1.Allocated:
+		slots, err := strconv.ParseInt(gs.GetAnnotations()[gamekruiseiov1alpha1.GameServerAllocatedSlotsKey], 10, 32)
+		if err != nil || slots < 1 {
+			return 0, false
+		}
+		return int32(slots), true
+	default:
+		return 0, false
+	}
+}
+
+// isAvailable returns whether the GameServer is ready to serve players, regardless of whether it is allocated.
+func isAvailable(gs *gamekruiseiov1alpha1.GameServer) bool {
+	if gs.GetDeletionTimestamp() != nil {
+		return false
+	}
+	idle := gs.DeepCopy()
+	idle.Spec.OpsState = gamekruiseiov1alpha1.None
+	return helpers.IsIdle(idle)
+}
+
+// rankPartiallyAllocated returns the available allocated GameServers with free slots according to the capacities of
+// their GameServerSets, in the descending order of the allocated slots, and those with the same slots are ordered by name.
+func rankPartiallyAllocated(gss []gamekruiseiov1alpha1.GameServer, capacities map[string]int32) []*gamekruiseiov1alpha1.GameServer {
+	var partial []*gamekruiseiov1alpha1.GameServer
+	slots := make(map[string]int32)
+	for i := range gss {
+		gs := &gss[i]
+		if gs.Spec.OpsState != gamekruiseiov1alpha1.Allocated {
+			continue
+		}
+		n, ok := allocatedSlots(gs)
+		if !ok || n >= capacities[gs.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]] || !isAvailable(gs) {
+			continue
+		}
+		slots[gs.GetName()] = n
+		partial = append(partial, gs)
+	}
+	sort.Slice(partial, func(i, j int) bool {
+		iSlots := slots[partial[i].GetName()]
+		jSlots := slots[partial[j].GetName()]
+		if iSlots != jSlots {
+			return iSlots > jSlots
+		}
+		return partial[i].GetName() < partial[j].GetName()
+	})
+	return partial
+}
+
+func allocatedResponse(gs *gamekruiseiov1alpha1.GameServer) *AllocationResponse {
+	slots, _ := allocatedSlots(gs)
+	return &AllocationResponse{
+		State:             gamekruiseiov1alpha1.GameServerAllocationAllocated,
+		GameServerName:    gs.GetName(),
+		ExternalAddresses: gs.Status.NetworkStatus.ExternalAddresses,
+		AllocatedSlots:    slots,
+		Message:           fmt.Sprintf("GameServer %s is allocated", gs.GetName()),
+	}
+}
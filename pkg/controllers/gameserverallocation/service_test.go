@@ -0,0 +1,449 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverallocation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/lease"
+	"github.com/openkruise/kruise-game/pkg/readonly"
+)
+
+// reviewClient authenticates the tokens in namespaces as the users of the same names, and allows each of them to
+// create GameServerAllocations in its namespace.
+type reviewClient struct {
+	client.Client
+	namespaces map[string]string
+}
+
+func (c *reviewClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	switch review := obj.(type) {
+	case *authenticationv1.TokenReview:
+		if _, ok := c.namespaces[review.Spec.Token]; ok {
+			review.Status.Authenticated = true
+			review.Status.User.Username = review.Spec.Token
+		}
+		return nil
+	case *authorizationv1.SubjectAccessReview:
+		attributes := review.Spec.ResourceAttributes
+		review.Status.Allowed = attributes != nil && attributes.Verb == "create" && attributes.Resource == "gameserverallocations" &&
+			attributes.Group == gameKruiseV1alpha1.GroupVersion.Group && c.namespaces[review.Spec.User] == attributes.Namespace
+		return nil
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func newCapacityGss(name string, capacity string) *gameKruiseV1alpha1.GameServerSet {
+	gss := &gameKruiseV1alpha1.GameServerSet{ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: name}}
+	if capacity != "" {
+		gss.Annotations = map[string]string{gameKruiseV1alpha1.AllocationCapacityKey: capacity}
+	}
+	return gss
+}
+
+func newAllocatedGs(name string, slots string) *gameKruiseV1alpha1.GameServer {
+	gs := newGs(name, gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated)
+	if slots != "" {
+		gs.Annotations = map[string]string{gameKruiseV1alpha1.GameServerAllocatedSlotsKey: slots}
+	}
+	return gs
+}
+
+func TestServiceAllocate(t *testing.T) {
+	tests := []struct {
+		gss            []*gameKruiseV1alpha1.GameServer
+		capacity       string
+		req            AllocationRequest
+		leases         fakeLeases
+		state          gameKruiseV1alpha1.GameServerAllocationState
+		gameServerName string
+		allocatedSlots int32
+	}{
+		{
+			gss: []*gameKruiseV1alpha1.GameServer{
+				newAllocatedGs("foo-0", ""),
+				newGs("foo-1", gameKruiseV1alpha1.NotReady, gameKruiseV1alpha1.None),
+				newGs("foo-2", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			},
+			req:            AllocationRequest{Namespace: "xxx", GameServerSetName: "foo"},
+			state:          gameKruiseV1alpha1.GameServerAllocationAllocated,
+			gameServerName: "foo-2",
+			allocatedSlots: 1,
+		},
+		// the allocated GameServer with the most allocated slots is filled up first
+		{
+			gss: []*gameKruiseV1alpha1.GameServer{
+				newAllocatedGs("foo-0", "1"),
+				newAllocatedGs("foo-1", "2"),
+				newGs("foo-2", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			},
+			capacity:       "3",
+			req:            AllocationRequest{Namespace: "xxx"},
+			state:          gameKruiseV1alpha1.GameServerAllocationAllocated,
+			gameServerName: "foo-1",
+			allocatedSlots: 3,
+		},
+		// the full GameServer and the one allocated by GameServerAllocation are skipped
+		{
+			gss: []*gameKruiseV1alpha1.GameServer{
+				newAllocatedGs("foo-0", ""),
+				newAllocatedGs("foo-1", "2"),
+				newGs("foo-2", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			},
+			capacity:       "2",
+			req:            AllocationRequest{Namespace: "xxx"},
+			state:          gameKruiseV1alpha1.GameServerAllocationAllocated,
+			gameServerName: "foo-2",
+			allocatedSlots: 1,
+		},
+		// the GameServer leased to another allocation is skipped
+		{
+			gss: []*gameKruiseV1alpha1.GameServer{
+				newGs("foo-2", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			},
+			req:    AllocationRequest{Namespace: "xxx"},
+			leases: fakeLeases{"foo-2": "xxx/gsa-1"},
+			state:  gameKruiseV1alpha1.GameServerAllocationUnAllocated,
+		},
+		// the GameServers of other GameServerSets are not allocated
+		{
+			gss: []*gameKruiseV1alpha1.GameServer{
+				newGs("foo-2", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			},
+			req:   AllocationRequest{Namespace: "xxx", GameServerSetName: "bar"},
+			state: gameKruiseV1alpha1.GameServerAllocationUnAllocated,
+		},
+		// the GameServers of the GameServerSet with an invalid capacity are not allocated
+		{
+			gss: []*gameKruiseV1alpha1.GameServer{
+				newGs("foo-2", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			},
+			capacity: "0",
+			req:      AllocationRequest{Namespace: "xxx"},
+			state:    gameKruiseV1alpha1.GameServerAllocationUnAllocated,
+		},
+	}
+
+	for i, test := range tests {
+		builder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newCapacityGss("foo", test.capacity))
+		for _, gs := range test.gss {
+			builder.WithObjects(gs)
+		}
+		c := builder.Build()
+		var leases lease.Backend = lease.NewCRDBackend()
+		if test.leases != nil {
+			leases = test.leases
+		}
+		s := NewService(c, c, leases, "")
+
+		req := test.req
+		selector, err := validateAllocationRequest(&req)
+		if err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		resp, err := s.Allocate(context.Background(), &req, selector)
+		if err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		if resp.State != test.state || resp.GameServerName != test.gameServerName || resp.AllocatedSlots != test.allocatedSlots {
+			t.Errorf("case %d: expect state %s, GameServer %s and slots %d, but actually got %s, %s and %d", i,
+				test.state, test.gameServerName, test.allocatedSlots, resp.State, resp.GameServerName, resp.AllocatedSlots)
+		}
+		if test.gameServerName == "" {
+			continue
+		}
+		gs := &gameKruiseV1alpha1.GameServer{}
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "xxx", Name: test.gameServerName}, gs); err != nil {
+			t.Error(err)
+			continue
+		}
+		if slots, _ := allocatedSlots(gs); gs.Spec.OpsState != gameKruiseV1alpha1.Allocated || slots != test.allocatedSlots {
+			t.Errorf("case %d: GameServer %s is not marked as allocated with %d slots", i, gs.GetName(), test.allocatedSlots)
+		}
+	}
+}
+
+func TestServiceRelease(t *testing.T) {
+	tests := []struct {
+		gs             *gameKruiseV1alpha1.GameServer
+		released       bool
+		allocatedSlots int32
+		opsState       gameKruiseV1alpha1.OpsState
+	}{
+		{
+			gs:             newAllocatedGs("foo-0", "2"),
+			released:       true,
+			allocatedSlots: 1,
+			opsState:       gameKruiseV1alpha1.Allocated,
+		},
+		// the GameServer is set back to None once all of its slots are freed
+		{
+			gs:       newAllocatedGs("foo-0", "1"),
+			released: true,
+			opsState: gameKruiseV1alpha1.None,
+		},
+		// the GameServer allocated by GameServerAllocation is not released
+		{
+			gs:       newAllocatedGs("foo-0", ""),
+			opsState: gameKruiseV1alpha1.Allocated,
+		},
+		{
+			gs:       newGs("foo-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+			opsState: gameKruiseV1alpha1.None,
+		},
+		// the GameServer not found is not released
+		{},
+	}
+
+	for i, test := range tests {
+		builder := fake.NewClientBuilder().WithScheme(scheme)
+		if test.gs != nil {
+			builder.WithObjects(test.gs)
+		}
+		c := builder.Build()
+		s := NewService(c, c, lease.NewCRDBackend(), "")
+
+		resp, err := s.Release(context.Background(), &ReleaseRequest{Namespace: "xxx", GameServerName: "foo-0"})
+		if err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		if resp.Released != test.released || resp.AllocatedSlots != test.allocatedSlots {
+			t.Errorf("case %d: expect released %v with %d slots left, but actually got %v and %d: %s", i,
+				test.released, test.allocatedSlots, resp.Released, resp.AllocatedSlots, resp.Message)
+		}
+		if test.gs == nil {
+			continue
+		}
+		gs := &gameKruiseV1alpha1.GameServer{}
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "xxx", Name: "foo-0"}, gs); err != nil {
+			t.Error(err)
+			continue
+		}
+		if gs.Spec.OpsState != test.opsState {
+			t.Errorf("case %d: expect opsState %s, but actually got %s", i, test.opsState, gs.Spec.OpsState)
+		}
+		if slots, _ := allocatedSlots(gs); test.released && slots != test.allocatedSlots {
+			t.Errorf("case %d: expect %d slots recorded, but actually got %d", i, test.allocatedSlots, slots)
+		}
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	defer readonly.Set(false)
+	gs := newGs("foo-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None)
+	gs.Status.NetworkStatus = gameKruiseV1alpha1.NetworkStatus{
+		NetworkType:         "Kubernetes-HostPort",
+		CurrentNetworkState: gameKruiseV1alpha1.NetworkReady,
+		ExternalAddresses:   []gameKruiseV1alpha1.NetworkAddress{{IP: "1.2.3.4"}},
+	}
+	c := &reviewClient{
+		Client:     fake.NewClientBuilder().WithScheme(scheme).WithObjects(newCapacityGss("foo", "2"), gs).Build(),
+		namespaces: map[string]string{"matchmaker": "xxx", "other": "yyy"},
+	}
+	s := NewService(c, c, lease.NewCRDBackend(), "")
+
+	tests := []struct {
+		method   string
+		path     string
+		token    string
+		body     string
+		readOnly bool
+		code     int
+		slots    int32
+	}{
+		{
+			method: http.MethodGet,
+			path:   ServicePath,
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			method: http.MethodPost,
+			path:   ServicePath,
+			token:  "matchmaker",
+			body:   `{"gameServerSetName":"foo"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			method: http.MethodPost,
+			path:   ServicePath,
+			token:  "matchmaker",
+			body:   `{"namespace":"xxx","selector":{"matchExpressions":[{"key":"a","operator":"Bad"}]}}`,
+			code:   http.StatusBadRequest,
+		},
+		// the request without the token is not authenticated
+		{
+			method: http.MethodPost,
+			path:   ServicePath,
+			body:   `{"namespace":"xxx"}`,
+			code:   http.StatusUnauthorized,
+		},
+		{
+			method: http.MethodPost,
+			path:   ServicePath,
+			token:  "unknown",
+			body:   `{"namespace":"xxx"}`,
+			code:   http.StatusUnauthorized,
+		},
+		// the user is not allowed to allocate the GameServers of other namespaces
+		{
+			method: http.MethodPost,
+			path:   ServicePath,
+			token:  "other",
+			body:   `{"namespace":"xxx"}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			method:   http.MethodPost,
+			path:     ServicePath,
+			token:    "matchmaker",
+			body:     `{"namespace":"xxx"}`,
+			readOnly: true,
+			code:     http.StatusServiceUnavailable,
+		},
+		{
+			method: http.MethodPost,
+			path:   ServicePath,
+			token:  "matchmaker",
+			body:   `{"namespace":"xxx","selector":{"matchLabels":{"game.kruise.io/owner-gss":"foo"}}}`,
+			code:   http.StatusOK,
+			slots:  1,
+		},
+		{
+			method: http.MethodPost,
+			path:   ServicePath,
+			token:  "matchmaker",
+			body:   `{"namespace":"xxx","gameServerSetName":"foo"}`,
+			code:   http.StatusOK,
+			slots:  2,
+		},
+		{
+			method: http.MethodPost,
+			path:   ReleasePath,
+			token:  "matchmaker",
+			body:   `{"namespace":"xxx"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			method: http.MethodPost,
+			path:   ReleasePath,
+			token:  "other",
+			body:   `{"namespace":"xxx","gameServerName":"foo-0"}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			method: http.MethodPost,
+			path:   ReleasePath,
+			token:  "matchmaker",
+			body:   `{"namespace":"xxx","gameServerName":"foo-0"}`,
+			code:   http.StatusOK,
+			slots:  1,
+		},
+		{
+			method: http.MethodPost,
+			path:   "/unknown",
+			token:  "matchmaker",
+			body:   `{"namespace":"xxx"}`,
+			code:   http.StatusNotFound,
+		},
+	}
+
+	for i, test := range tests {
+		readonly.Set(test.readOnly)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		if test.token != "" {
+			r.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		s.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("case %d: expect code %d, but got %d: %s", i, test.code, w.Code, w.Body.String())
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		if test.path == ReleasePath {
+			resp := &ReleaseResponse{}
+			if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+				t.Errorf("case %d: %v", i, err)
+				continue
+			}
+			if !resp.Released || resp.AllocatedSlots != test.slots {
+				t.Errorf("case %d: expect a slot released with %d slots left, but got %+v", i, test.slots, resp)
+			}
+			continue
+		}
+		resp := &AllocationResponse{}
+		if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		if resp.GameServerName != "foo-0" || resp.AllocatedSlots != test.slots || len(resp.ExternalAddresses) != 1 || resp.ExternalAddresses[0].IP != "1.2.3.4" {
+			t.Errorf("case %d: expect GameServer foo-0 with %d slots and its address, but got %+v", i, test.slots, resp)
+		}
+	}
+}
+
+func TestGetAllocationCapacity(t *testing.T) {
+	tests := []struct {
+		capacity string
+		expected int32
+		valid    bool
+	}{
+		{
+			expected: 1,
+			valid:    true,
+		},
+		{
+			capacity: "4",
+			expected: 4,
+			valid:    true,
+		},
+		{
+			capacity: "0",
+		},
+		{
+			capacity: "a",
+		},
+	}
+	for i, test := range tests {
+		capacity, err := getAllocationCapacity(newCapacityGss("foo", test.capacity))
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+			continue
+		}
+		if capacity != test.expected {
+			t.Errorf("case %d: expect capacity %d, but actually got %d", i, test.expected, capacity)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverset
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+const (
+	GameServersUpdatingReason = "GameServersUpdating"
+	GameServersScalingReason  = "GameServersScaling"
+	RolloutCompletedReason    = "RolloutCompleted"
+
+	ImagePullFailedReason   = "ImagePullFailed"
+	CrashLoopBackOffReason  = "CrashLoopBackOff"
+	UnschedulableReason     = "Unschedulable"
+	NetworkNotReadyReason   = "NetworkNotReady"
+	GameServersNormalReason = "GameServersNormal"
+
+	MinimumReplicasAvailableReason   = "MinimumReplicasAvailable"
+	MinimumReplicasUnavailableReason = "MinimumReplicasUnavailable"
+)
+
+// networkNotReadyTolerance is how long the network of a GameServer can stay not ready
+// before it is regarded as degraded, since it takes time for cloud providers to get the network ready.
+const networkNotReadyTolerance = 3 * time.Minute
+
+// maxDegradedNames is the max number of GameServers listed for each reason in the message of Degraded condition,
+// to keep the status of a large GameServerSet small.
+const maxDegradedNames = 5
+
+// degradedReasons is ordered by severity, the first reason found is used as the reason of Degraded condition.
+var degradedReasons = []string{ImagePullFailedReason, CrashLoopBackOffReason, UnschedulableReason, NetworkNotReadyReason}
+
+// getGssConditions computes the conditions of GameServerSet from the new status and the GameServers.
+// The last transition time is kept if the status of a condition does not change.
+func getGssConditions(gss *gameKruiseV1alpha1.GameServerSet, asts *kruiseV1beta1.StatefulSet, status *gameKruiseV1alpha1.GameServerSetStatus, podList []corev1.Pod, updateFrozen bool, now metav1.Time) []gameKruiseV1alpha1.GameServerSetCondition {
+	conditions := []gameKruiseV1alpha1.GameServerSetCondition{
+		getProgressingCondition(gss, status, updateFrozen),
+		getDegradedCondition(podList, now.Time),
+		getAvailableCondition(asts, status),
+	}
+	for i := range conditions {
+		oldCondition := getGssCondition(gss.Status.Conditions, conditions[i].Type)
+		if oldCondition != nil && oldCondition.Status == conditions[i].Status {
+			conditions[i].LastTransitionTime = oldCondition.LastTransitionTime
+		} else {
+			conditions[i].LastTransitionTime = now
+		}
+	}
+	return conditions
+}
+
+func getProgressingCondition(gss *gameKruiseV1alpha1.GameServerSet, status *gameKruiseV1alpha1.GameServerSetStatus, updateFrozen bool) gameKruiseV1alpha1.GameServerSetCondition {
+	// GameServers with ordinal less than partition are not expected to be updated
+	expectedUpdated := status.Replicas
+	if gss.Spec.UpdateStrategy.RollingUpdate != nil && gss.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
+		expectedUpdated -= *gss.Spec.UpdateStrategy.RollingUpdate.Partition
+		if expectedUpdated < 0 {
+			expectedUpdated = 0
+		}
+	}
+
+	if status.CurrentReplicas != status.Replicas {
+		return gameKruiseV1alpha1.GameServerSetCondition{
+			Type:    gameKruiseV1alpha1.GameServerSetProgressing,
+			Status:  corev1.ConditionTrue,
+			Reason:  GameServersScalingReason,
+			Message: fmt.Sprintf("%d of %d GameServers exist", status.CurrentReplicas, status.Replicas),
+		}
+	}
+	if updateFrozen {
+		return gameKruiseV1alpha1.GameServerSetCondition{
+			Type:    gameKruiseV1alpha1.GameServerSetProgressing,
+			Status:  corev1.ConditionFalse,
+			Reason:  UpdateFrozenReason,
+			Message: "template rollout is blocked by freeze windows",
+		}
+	}
+	if status.UpdatedReplicas < expectedUpdated {
+		return gameKruiseV1alpha1.GameServerSetCondition{
+			Type:    gameKruiseV1alpha1.GameServerSetProgressing,
+			Status:  corev1.ConditionTrue,
+			Reason:  GameServersUpdatingReason,
+			Message: fmt.Sprintf("%d of %d GameServers are updated", status.UpdatedReplicas, expectedUpdated),
+		}
+	}
+	return gameKruiseV1alpha1.GameServerSetCondition{
+		Type:   gameKruiseV1alpha1.GameServerSetProgressing,
+		Status: corev1.ConditionFalse,
+		Reason: RolloutCompletedReason,
+	}
+}
+
+func getDegradedCondition(podList []corev1.Pod, now time.Time) gameKruiseV1alpha1.GameServerSetCondition {
+	problems := make(map[string][]string)
+	for _, pod := range podList {
+		if pod.GetDeletionTimestamp() != nil {
+			continue
+		}
+		for _, reason := range getPodProblems(&pod, now) {
+			problems[reason] = append(problems[reason], pod.GetName())
+		}
+	}
+
+	var reason string
+	var messages []string
+	for _, r := range degradedReasons {
+		names, ok := problems[r]
+		if !ok {
+			continue
+		}
+		if reason == "" {
+			reason = r
+		}
+		if len(names) > maxDegradedNames {
+			messages = append(messages, fmt.Sprintf("%s: %s and %d more", r, strings.Join(names[:maxDegradedNames], ","), len(names)-maxDegradedNames))
+		} else {
+			messages = append(messages, fmt.Sprintf("%s: %s", r, strings.Join(names, ",")))
+		}
+	}
+
+	if reason == "" {
+		return gameKruiseV1alpha1.GameServerSetCondition{
+			Type:   gameKruiseV1alpha1.GameServerSetDegraded,
+			Status: corev1.ConditionFalse,
+			Reason: GameServersNormalReason,
+		}
+	}
+	return gameKruiseV1alpha1.GameServerSetCondition{
+		Type:    gameKruiseV1alpha1.GameServerSetDegraded,
+		Status:  corev1.ConditionTrue,
+		Reason:  reason,
+		Message: strings.Join(messages, "; "),
+	}
+}
+
+// getPodProblems returns the degraded reasons of a GameServer, without duplicates.
+func getPodProblems(pod *corev1.Pod, now time.Time) []string {
+	found := make(map[string]bool)
+	containerStatuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range containerStatuses {
+		if status.State.Waiting == nil {
+			continue
+		}
+		switch status.State.Waiting.Reason {
+		case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "ErrImageNeverPull":
+			found[ImagePullFailedReason] = true
+		case "CrashLoopBackOff":
+			found[CrashLoopBackOffReason] = true
+		}
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse && condition.Reason == corev1.PodReasonUnschedulable {
+			found[UnschedulableReason] = true
+		}
+	}
+
+	// port exhaustion and load balancer errors keep the network not ready
+	if since, ok := getNetworkNotReadySince(pod); ok && now.Sub(since) > networkNotReadyTolerance {
+		found[NetworkNotReadyReason] = true
+	}
+
+	var reasons []string
+	for _, r := range degradedReasons {
+		if found[r] {
+			reasons = append(reasons, r)
+		}
+	}
+	return reasons
+}
+
+// getNetworkNotReadySince returns since when the network of a GameServer is expected to be ready but not.
+func getNetworkNotReadySince(pod *corev1.Pod) (time.Time, bool) {
+	networkStatusStr, ok := pod.GetAnnotations()[gameKruiseV1alpha1.GameServerNetworkStatus]
+	if !ok {
+		return time.Time{}, false
+	}
+	networkStatus := gameKruiseV1alpha1.NetworkStatus{}
+	if err := json.Unmarshal([]byte(networkStatusStr), &networkStatus); err != nil {
+		return time.Time{}, false
+	}
+	if networkStatus.DesiredNetworkState != gameKruiseV1alpha1.NetworkReady || networkStatus.CurrentNetworkState == gameKruiseV1alpha1.NetworkReady {
+		return time.Time{}, false
+	}
+	since := networkStatus.LastTransitionTime.Time
+	if since.IsZero() {
+		since = networkStatus.CreateTime.Time
+	}
+	return since, true
+}
+
+// getNetworkNotReadyRequeueAfter returns when the earliest GameServer whose network is not ready exceeds
+// the tolerance, so that Degraded condition is updated even if nothing else triggers a reconcile.
+// It returns 0 if there is no such GameServer.
+func getNetworkNotReadyRequeueAfter(podList []corev1.Pod, now time.Time) time.Duration {
+	var requeueAfter time.Duration
+	for i := range podList {
+		if podList[i].GetDeletionTimestamp() != nil {
+			continue
+		}
+		since, ok := getNetworkNotReadySince(&podList[i])
+		if !ok {
+			continue
+		}
+		remaining := since.Add(networkNotReadyTolerance).Sub(now)
+		if remaining < 0 {
+			continue
+		}
+		// requeue right after the tolerance is exceeded
+		remaining += time.Second
+		if requeueAfter == 0 || remaining < requeueAfter {
+			requeueAfter = remaining
+		}
+	}
+	return requeueAfter
+}
+
+func getAvailableCondition(asts *kruiseV1beta1.StatefulSet, status *gameKruiseV1alpha1.GameServerSetStatus) gameKruiseV1alpha1.GameServerSetCondition {
+	maxUnavailable := 0
+	if asts.Spec.UpdateStrategy.RollingUpdate != nil && asts.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable != nil {
+		maxUnavailable, _ = intstrutil.GetScaledValueFromIntOrPercent(asts.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable, int(status.Replicas), false)
+	}
+	minAvailable := int(status.Replicas) - maxUnavailable
+	if int(status.AvailableReplicas) >= minAvailable {
+		return gameKruiseV1alpha1.GameServerSetCondition{
+			Type:    gameKruiseV1alpha1.GameServerSetAvailable,
+			Status:  corev1.ConditionTrue,
+			Reason:  MinimumReplicasAvailableReason,
+			Message: fmt.Sprintf("%d of %d GameServers are available", status.AvailableReplicas, status.Replicas),
+		}
+	}
+	return gameKruiseV1alpha1.GameServerSetCondition{
+		Type:    gameKruiseV1alpha1.GameServerSetAvailable,
+		Status:  corev1.ConditionFalse,
+		Reason:  MinimumReplicasUnavailableReason,
+		Message: fmt.Sprintf("%d of %d GameServers are available, at least %d are required", status.AvailableReplicas, status.Replicas, minAvailable),
+	}
+}
+
+func getGssCondition(conditions []gameKruiseV1alpha1.GameServerSetCondition, conditionType gameKruiseV1alpha1.GameServerSetConditionType) *gameKruiseV1alpha1.GameServerSetCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// isUpdateFrozenCondition returns whether the conditions show the rollout of GameServerTemplate blocked by freeze windows.
+func isUpdateFrozenCondition(conditions []gameKruiseV1alpha1.GameServerSetCondition) bool {
+	condition := getGssCondition(conditions, gameKruiseV1alpha1.GameServerSetProgressing)
+	return condition != nil && condition.Reason == UpdateFrozenReason
+}
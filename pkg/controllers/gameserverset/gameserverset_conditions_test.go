@@ -0,0 +1,325 @@
+package gameserverset
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestGetGssConditions(t *testing.T) {
+	now := metav1.Now()
+	before := metav1.NewTime(now.Add(-time.Hour))
+	asts := &kruiseV1beta1.StatefulSet{
+		Spec: kruiseV1beta1.StatefulSetSpec{
+			UpdateStrategy: kruiseV1beta1.StatefulSetUpdateStrategy{
+				RollingUpdate: &kruiseV1beta1.RollingUpdateStatefulSetStrategy{
+					MaxUnavailable: ptr.To(intstr.FromInt(1)),
+				},
+			},
+		},
+	}
+	tests := []struct {
+		gss          *gameKruiseV1alpha1.GameServerSet
+		status       *gameKruiseV1alpha1.GameServerSetStatus
+		updateFrozen bool
+		expected     map[gameKruiseV1alpha1.GameServerSetConditionType]corev1.ConditionStatus
+		reasons      map[gameKruiseV1alpha1.GameServerSetConditionType]string
+		keepTimes    map[gameKruiseV1alpha1.GameServerSetConditionType]bool
+	}{
+		// rolling update is blocked by freeze windows
+		{
+			gss: &gameKruiseV1alpha1.GameServerSet{},
+			status: &gameKruiseV1alpha1.GameServerSetStatus{
+				Replicas:          4,
+				CurrentReplicas:   4,
+				UpdatedReplicas:   4,
+				AvailableReplicas: 4,
+			},
+			updateFrozen: true,
+			expected: map[gameKruiseV1alpha1.GameServerSetConditionType]corev1.ConditionStatus{
+				gameKruiseV1alpha1.GameServerSetProgressing: corev1.ConditionFalse,
+			},
+			reasons: map[gameKruiseV1alpha1.GameServerSetConditionType]string{
+				gameKruiseV1alpha1.GameServerSetProgressing: UpdateFrozenReason,
+			},
+		},
+		// rolling update is in progress
+		{
+			gss: &gameKruiseV1alpha1.GameServerSet{},
+			status: &gameKruiseV1alpha1.GameServerSetStatus{
+				Replicas:          4,
+				CurrentReplicas:   4,
+				UpdatedReplicas:   2,
+				AvailableReplicas: 3,
+			},
+			expected: map[gameKruiseV1alpha1.GameServerSetConditionType]corev1.ConditionStatus{
+				gameKruiseV1alpha1.GameServerSetProgressing: corev1.ConditionTrue,
+				gameKruiseV1alpha1.GameServerSetDegraded:    corev1.ConditionFalse,
+				gameKruiseV1alpha1.GameServerSetAvailable:   corev1.ConditionTrue,
+			},
+			reasons: map[gameKruiseV1alpha1.GameServerSetConditionType]string{
+				gameKruiseV1alpha1.GameServerSetProgressing: GameServersUpdatingReason,
+				gameKruiseV1alpha1.GameServerSetAvailable:   MinimumReplicasAvailableReason,
+			},
+		},
+		// the rollout is completed when GameServers before partition are not updated
+		{
+			gss: &gameKruiseV1alpha1.GameServerSet{
+				Spec: gameKruiseV1alpha1.GameServerSetSpec{
+					UpdateStrategy: gameKruiseV1alpha1.UpdateStrategy{
+						RollingUpdate: &gameKruiseV1alpha1.RollingUpdateStatefulSetStrategy{
+							Partition: ptr.To[int32](2),
+						},
+					},
+				},
+				Status: gameKruiseV1alpha1.GameServerSetStatus{
+					Conditions: []gameKruiseV1alpha1.GameServerSetCondition{
+						{
+							Type:               gameKruiseV1alpha1.GameServerSetProgressing,
+							Status:             corev1.ConditionFalse,
+							LastTransitionTime: before,
+						},
+						{
+							Type:               gameKruiseV1alpha1.GameServerSetAvailable,
+							Status:             corev1.ConditionTrue,
+							LastTransitionTime: before,
+						},
+					},
+				},
+			},
+			status: &gameKruiseV1alpha1.GameServerSetStatus{
+				Replicas:          4,
+				CurrentReplicas:   4,
+				UpdatedReplicas:   2,
+				AvailableReplicas: 2,
+			},
+			expected: map[gameKruiseV1alpha1.GameServerSetConditionType]corev1.ConditionStatus{
+				gameKruiseV1alpha1.GameServerSetProgressing: corev1.ConditionFalse,
+				gameKruiseV1alpha1.GameServerSetDegraded:    corev1.ConditionFalse,
+				gameKruiseV1alpha1.GameServerSetAvailable:   corev1.ConditionFalse,
+			},
+			reasons: map[gameKruiseV1alpha1.GameServerSetConditionType]string{
+				gameKruiseV1alpha1.GameServerSetProgressing: RolloutCompletedReason,
+				gameKruiseV1alpha1.GameServerSetAvailable:   MinimumReplicasUnavailableReason,
+			},
+			keepTimes: map[gameKruiseV1alpha1.GameServerSetConditionType]bool{
+				gameKruiseV1alpha1.GameServerSetProgressing: true,
+			},
+		},
+		// scaling
+		{
+			gss: &gameKruiseV1alpha1.GameServerSet{},
+			status: &gameKruiseV1alpha1.GameServerSetStatus{
+				Replicas:          4,
+				CurrentReplicas:   2,
+				UpdatedReplicas:   2,
+				AvailableReplicas: 2,
+			},
+			expected: map[gameKruiseV1alpha1.GameServerSetConditionType]corev1.ConditionStatus{
+				gameKruiseV1alpha1.GameServerSetProgressing: corev1.ConditionTrue,
+				gameKruiseV1alpha1.GameServerSetDegraded:    corev1.ConditionFalse,
+				gameKruiseV1alpha1.GameServerSetAvailable:   corev1.ConditionFalse,
+			},
+			reasons: map[gameKruiseV1alpha1.GameServerSetConditionType]string{
+				gameKruiseV1alpha1.GameServerSetProgressing: GameServersScalingReason,
+			},
+		},
+	}
+
+	for i, test := range tests {
+		conditions := getGssConditions(test.gss, asts, test.status, nil, test.updateFrozen, now)
+		for conditionType, status := range test.expected {
+			condition := getGssCondition(conditions, conditionType)
+			if condition == nil {
+				t.Errorf("case %d: condition %s is not found", i, conditionType)
+				continue
+			}
+			if condition.Status != status {
+				t.Errorf("case %d: expect condition %s status %s, but actually got %s", i, conditionType, status, condition.Status)
+			}
+			if reason, ok := test.reasons[conditionType]; ok && condition.Reason != reason {
+				t.Errorf("case %d: expect condition %s reason %s, but actually got %s", i, conditionType, reason, condition.Reason)
+			}
+			expectedTime := now
+			if test.keepTimes[conditionType] {
+				expectedTime = before
+			}
+			if !condition.LastTransitionTime.Equal(&expectedTime) {
+				t.Errorf("case %d: expect condition %s last transition time %v, but actually got %v", i, conditionType, expectedTime, condition.LastTransitionTime)
+			}
+		}
+	}
+}
+
+func TestGetDegradedCondition(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		pods    []corev1.Pod
+		status  corev1.ConditionStatus
+		reason  string
+		message string
+	}{
+		{
+			pods: []corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "xxx-0"},
+					Status: corev1.PodStatus{
+						ContainerStatuses: []corev1.ContainerStatus{
+							{State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+						},
+					},
+				},
+			},
+			status: corev1.ConditionFalse,
+			reason: GameServersNormalReason,
+		},
+		{
+			pods: []corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "xxx-0"},
+					Status: corev1.PodStatus{
+						ContainerStatuses: []corev1.ContainerStatus{
+							{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "xxx-1"},
+					Status: corev1.PodStatus{
+						ContainerStatuses: []corev1.ContainerStatus{
+							{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "xxx-2"},
+					Status: corev1.PodStatus{
+						ContainerStatuses: []corev1.ContainerStatus{
+							{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ErrImagePull"}}},
+						},
+					},
+				},
+			},
+			status:  corev1.ConditionTrue,
+			reason:  ImagePullFailedReason,
+			message: "ImagePullFailed: xxx-1,xxx-2; CrashLoopBackOff: xxx-0",
+		},
+		// network is not ready beyond the tolerance
+		{
+			pods: []corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "xxx-0",
+						Annotations: map[string]string{
+							gameKruiseV1alpha1.GameServerNetworkStatus: `{"desiredNetworkState":"Ready","currentNetworkState":"NotReady","createTime":"` + now.Add(-time.Hour).UTC().Format(time.RFC3339) + `"}`,
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "xxx-1",
+						Annotations: map[string]string{
+							gameKruiseV1alpha1.GameServerNetworkStatus: `{"desiredNetworkState":"Ready","currentNetworkState":"NotReady","createTime":"` + now.UTC().Format(time.RFC3339) + `"}`,
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "xxx-2"},
+					Status: corev1.PodStatus{
+						Conditions: []corev1.PodCondition{
+							{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable},
+						},
+					},
+				},
+			},
+			status:  corev1.ConditionTrue,
+			reason:  UnschedulableReason,
+			message: "Unschedulable: xxx-2; NetworkNotReady: xxx-0",
+		},
+	}
+
+	// the names of GameServers are capped for each reason
+	var crashPods []corev1.Pod
+	for i := 0; i < maxDegradedNames+2; i++ {
+		crashPods = append(crashPods, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("xxx-%d", i)},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+				},
+			},
+		})
+	}
+	tests = append(tests, struct {
+		pods    []corev1.Pod
+		status  corev1.ConditionStatus
+		reason  string
+		message string
+	}{
+		pods:    crashPods,
+		status:  corev1.ConditionTrue,
+		reason:  CrashLoopBackOffReason,
+		message: "CrashLoopBackOff: xxx-0,xxx-1,xxx-2,xxx-3,xxx-4 and 2 more",
+	})
+
+	for i, test := range tests {
+		condition := getDegradedCondition(test.pods, now)
+		if condition.Status != test.status || condition.Reason != test.reason || condition.Message != test.message {
+			t.Errorf("case %d: expect %s %s %s, but actually got %s %s %s", i, test.status, test.reason, test.message, condition.Status, condition.Reason, condition.Message)
+		}
+	}
+}
+
+func TestGetNetworkNotReadyRequeueAfter(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	networkPod := func(name string, createTime time.Time, current gameKruiseV1alpha1.NetworkState) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Annotations: map[string]string{
+					gameKruiseV1alpha1.GameServerNetworkStatus: `{"desiredNetworkState":"Ready","currentNetworkState":"` + string(current) + `","createTime":"` + createTime.UTC().Format(time.RFC3339) + `"}`,
+				},
+			},
+		}
+	}
+	tests := []struct {
+		pods         []corev1.Pod
+		requeueAfter time.Duration
+	}{
+		{
+			pods:         []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "xxx-0"}}},
+			requeueAfter: 0,
+		},
+		// the earliest GameServer within the tolerance wins
+		{
+			pods: []corev1.Pod{
+				networkPod("xxx-0", now.Add(-time.Minute), gameKruiseV1alpha1.NetworkNotReady),
+				networkPod("xxx-1", now.Add(-2*time.Minute), gameKruiseV1alpha1.NetworkNotReady),
+				networkPod("xxx-2", now.Add(-2*time.Minute-30*time.Second), gameKruiseV1alpha1.NetworkReady),
+			},
+			requeueAfter: time.Minute + time.Second,
+		},
+		// the GameServer beyond the tolerance is already degraded
+		{
+			pods: []corev1.Pod{
+				networkPod("xxx-0", now.Add(-time.Hour), gameKruiseV1alpha1.NetworkNotReady),
+			},
+			requeueAfter: 0,
+		},
+	}
+
+	for i, test := range tests {
+		actual := getNetworkNotReadyRequeueAfter(test.pods, now)
+		if actual != test.requeueAfter {
+			t.Errorf("case %d: expect requeue after %v, but actually got %v", i, test.requeueAfter, actual)
+		}
+	}
+}
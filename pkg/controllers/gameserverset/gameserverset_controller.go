@@ -18,6 +18,7 @@ package gameserverset
 
 import (
 	"context"
+	"time"
 
 	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
 	corev1 "k8s.io/api/core/v1"
@@ -33,6 +34,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -43,6 +45,7 @@ import (
 	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
 	"github.com/openkruise/kruise-game/pkg/util"
 	utildiscovery "github.com/openkruise/kruise-game/pkg/util/discovery"
+	"github.com/openkruise/kruise-game/pkg/util/image"
 )
 
 var (
@@ -61,9 +64,11 @@ func Add(mgr manager.Manager) error {
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 	recorder := mgr.GetEventRecorderFor("gameserverset-controller")
 	return &GameServerSetReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		recorder: recorder,
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		recorder:      recorder,
+		apiReader:     mgr.GetAPIReader(),
+		imageResolver: image.NewResolver(),
 	}
 }
 
@@ -91,9 +96,37 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	if utildiscovery.DiscoverGVK(gamekruiseiov1alpha1.SchemeGroupVersion.WithKind("ServiceQualityTemplate")) {
+		if err = watchServiceQualityTemplates(c, mgr.GetClient()); err != nil {
+			klog.Error(err)
+			return err
+		}
+	}
+
 	return nil
 }
 
+// watch ServiceQualityTemplates, and enqueue the GameServerSets which reference them
+func watchServiceQualityTemplates(c controller.Controller, cli client.Client) error {
+	return c.Watch(&source.Kind{Type: &gamekruiseiov1alpha1.ServiceQualityTemplate{}}, handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		gssList := &gamekruiseiov1alpha1.GameServerSetList{}
+		if err := cli.List(context.Background(), gssList); err != nil {
+			klog.Errorf("failed to list GameServerSets for ServiceQualityTemplate %s, because of %s.", obj.GetName(), err.Error())
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, gss := range gssList.Items {
+			if gss.Spec.ServiceQualityTemplateName == obj.GetName() {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+					Name:      gss.GetName(),
+					Namespace: gss.GetNamespace(),
+				}})
+			}
+		}
+		return requests
+	}))
+}
+
 // watch pod
 func watchPod(c controller.Controller) (err error) {
 
@@ -147,11 +180,15 @@ type GameServerSetReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	recorder record.EventRecorder
+	// apiReader reads objects which are not worth caching, such as secrets
+	apiReader     client.Reader
+	imageResolver image.Resolver
 }
 
 //+kubebuilder:rbac:groups=game.kruise.io,resources=gameserversets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=game.kruise.io,resources=gameserversets/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=game.kruise.io,resources=gameserversets/finalizers,verbs=update
+//+kubebuilder:rbac:groups=game.kruise.io,resources=servicequalitytemplates,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -177,17 +214,48 @@ func (r *GameServerSetReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return reconcile.Result{}, err
 	}
 
+	// retain identity of GameServers
+	if gss.GetDeletionTimestamp() != nil {
+		if controllerutil.ContainsFinalizer(gss, gamekruiseiov1alpha1.IdentityRetentionFinalizer) {
+			if err := r.retainIdentity(ctx, gss); err != nil {
+				klog.Errorf("GameServerSet %s failed to retain identity in %s,because of %s.", namespacedName.Name, namespacedName.Namespace, err.Error())
+				return reconcile.Result{}, err
+			}
+		}
+		return reconcile.Result{}, nil
+	}
+	if updated, err := r.syncIdentityFinalizer(ctx, gss); err != nil || updated {
+		return reconcile.Result{}, err
+	}
+
 	// get advanced statefulset
 	asts := &kruiseV1beta1.StatefulSet{}
 	err = r.Get(ctx, namespacedName, asts)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			err = r.initAsts(gss)
+			pinnedImages, err := resolveImages(ctx, r.apiReader, r.imageResolver, gss)
+			if err != nil {
+				klog.Errorf("GameServerSet %s failed to resolve images in %s,because of %s.", namespacedName.Name, namespacedName.Namespace, err.Error())
+				r.recorder.Event(gss, corev1.EventTypeWarning, ImagePolicyFailedReason, err.Error())
+				return reconcile.Result{}, err
+			}
+			identity, err := r.restoreIdentity(ctx, gss)
+			if err != nil {
+				klog.Errorf("GameServerSet %s failed to restore identity in %s,because of %s.", namespacedName.Name, namespacedName.Namespace, err.Error())
+				return reconcile.Result{}, err
+			}
+			err = r.initAsts(gss, pinnedImages, getRetainedReserveOrdinals(identity))
 			if err != nil {
 				klog.Errorf("failed to create advanced statefulset %s in %s,because of %s.", namespacedName.Name, namespacedName.Namespace, err.Error())
 				return reconcile.Result{}, err
 			}
 			r.recorder.Event(gss, corev1.EventTypeNormal, CreateWorkloadReason, "created Advanced StatefulSet")
+			if identity != nil {
+				if err := r.Delete(ctx, identity); err != nil && !errors.IsNotFound(err) {
+					return reconcile.Result{}, err
+				}
+				r.recorder.Eventf(gss, corev1.EventTypeNormal, IdentityRestoredReason, "reattached identity of GameServers retained in ConfigMap %s", identity.GetName())
+			}
 			return reconcile.Result{}, nil
 		}
 		klog.Errorf("failed to find advanced statefulset %s in %s,because of %s.", namespacedName.Name, namespacedName.Namespace, err.Error())
@@ -208,6 +276,13 @@ func (r *GameServerSetReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 	gsm := NewGameServerSetManager(gss, asts, podList.Items, r.Client, r.recorder)
 
+	// cut or restore the traffic of the fleet before anything else
+	err = gsm.SyncNetworkDisabled()
+	if err != nil {
+		klog.Errorf("GameServerSet %s failed to synchronize networkDisabled in %s,because of %s.", namespacedName.Name, namespacedName.Namespace, err.Error())
+		return reconcile.Result{}, err
+	}
+
 	// kill game servers
 	newReplicas := gsm.GetReplicasAfterKilling()
 	if *gss.Spec.Replicas != *newReplicas {
@@ -231,14 +306,31 @@ func (r *GameServerSetReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 
 	// update workload
+	result := ctrl.Result{}
 	if gsm.IsNeedToUpdateWorkload() {
-		err = gsm.UpdateWorkload()
-		if err != nil {
-			klog.Errorf("GameServerSet %s failed to synchronize workload in %s,because of %s.", namespacedName.Name, namespacedName.Namespace, err.Error())
-			return reconcile.Result{}, err
+		if frozen, remaining := gsm.IsUpdateFrozen(); frozen && gsm.IsTemplateChanged() {
+			// only the rollout of GameServerTemplate is blocked by freeze windows
+			err = gsm.UpdateWorkloadExceptTemplate()
+			if err != nil {
+				klog.Errorf("GameServerSet %s failed to synchronize workload in %s,because of %s.", namespacedName.Name, namespacedName.Namespace, err.Error())
+				return reconcile.Result{}, err
+			}
+			result.RequeueAfter = remaining
+		} else {
+			pinnedImages, err := resolveImages(ctx, r.apiReader, r.imageResolver, gss)
+			if err != nil {
+				klog.Errorf("GameServerSet %s failed to resolve images in %s,because of %s.", namespacedName.Name, namespacedName.Namespace, err.Error())
+				r.recorder.Event(gss, corev1.EventTypeWarning, ImagePolicyFailedReason, err.Error())
+				return reconcile.Result{}, err
+			}
+			err = gsm.UpdateWorkload(pinnedImages)
+			if err != nil {
+				klog.Errorf("GameServerSet %s failed to synchronize workload in %s,because of %s.", namespacedName.Name, namespacedName.Namespace, err.Error())
+				return reconcile.Result{}, err
+			}
+			r.recorder.Event(gss, corev1.EventTypeNormal, UpdateWorkloadReason, "updated Advanced StatefulSet")
+			return reconcile.Result{}, nil
 		}
-		r.recorder.Event(gss, corev1.EventTypeNormal, UpdateWorkloadReason, "updated Advanced StatefulSet")
-		return reconcile.Result{}, nil
 	}
 
 	err = gsm.SyncPodProbeMarker()
@@ -247,6 +339,30 @@ func (r *GameServerSetReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return reconcile.Result{}, err
 	}
 
+	err = r.syncEgressPolicy(ctx, gss)
+	if err != nil {
+		klog.Errorf("GameServerSet %s failed to synchronize egress NetworkPolicy in %s,because of %s.", namespacedName.Name, namespacedName.Namespace, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	// restart game servers as scheduled
+	requeueAfter, err := r.restartGameServers(ctx, gss, podList.Items, time.Now())
+	if err != nil {
+		klog.Errorf("GameServerSet %s failed to restart GameServers in %s,because of %s.", namespacedName.Name, namespacedName.Namespace, err.Error())
+		return reconcile.Result{}, err
+	}
+	if requeueAfter > 0 && (result.RequeueAfter == 0 || requeueAfter < result.RequeueAfter) {
+		result.RequeueAfter = requeueAfter
+	}
+	// the network of GameServers exceeds the tolerance of Degraded condition
+	if requeueAfter := getNetworkNotReadyRequeueAfter(podList.Items, time.Now()); requeueAfter > 0 && (result.RequeueAfter == 0 || requeueAfter < result.RequeueAfter) {
+		result.RequeueAfter = requeueAfter
+	}
+	// the GameServers leave the rolling window of ProvisioningSLO, and exceed its threshold without events
+	if gss.Spec.ProvisioningSLO != nil && (result.RequeueAfter == 0 || provisioningSLOResyncInterval < result.RequeueAfter) {
+		result.RequeueAfter = provisioningSLOResyncInterval
+	}
+
 	// sync GameServerSet Status
 	err = gsm.SyncStatus()
 	if err != nil {
@@ -254,7 +370,7 @@ func (r *GameServerSetReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return reconcile.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	return result, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -264,7 +380,7 @@ func (r *GameServerSetReconciler) SetupWithManager(mgr ctrl.Manager) (c controll
 	return c, err
 }
 
-func (r *GameServerSetReconciler) initAsts(gss *gamekruiseiov1alpha1.GameServerSet) error {
+func (r *GameServerSetReconciler) initAsts(gss *gamekruiseiov1alpha1.GameServerSet, pinnedImages map[string]string, reserveOrdinals []int) error {
 	asts := &kruiseV1beta1.StatefulSet{}
 	asts.Namespace = gss.GetNamespace()
 	asts.Name = gss.GetName()
@@ -285,6 +401,10 @@ func (r *GameServerSetReconciler) initAsts(gss *gamekruiseiov1alpha1.GameServerS
 	// set annotations
 	astsAns := make(map[string]string)
 	astsAns[gamekruiseiov1alpha1.AstsHashKey] = util.GetAstsHash(gss)
+	astsAns[gamekruiseiov1alpha1.GsTemplateHashKey] = util.GetGsTemplateHash(gss)
+	if base, _ := util.GetHotUpdateHashes(gss); base != "" {
+		astsAns[gamekruiseiov1alpha1.HotUpdateBaseHashKey] = base
+	}
 	asts.SetAnnotations(astsAns)
 
 	// set label selector
@@ -294,7 +414,8 @@ func (r *GameServerSetReconciler) initAsts(gss *gamekruiseiov1alpha1.GameServerS
 
 	// set replicas
 	asts.Spec.Replicas = gss.Spec.Replicas
-	asts.Spec.ReserveOrdinals = gss.Spec.ReserveGameServerIds
+	gssReserveIds := util.GetReserveIds(gss)
+	asts.Spec.ReserveOrdinals = append(gssReserveIds, util.GetSliceInANotInB(reserveOrdinals, gssReserveIds)...)
 
 	// set ServiceName
 	asts.Spec.ServiceName = gss.Spec.ServiceName
@@ -304,6 +425,7 @@ func (r *GameServerSetReconciler) initAsts(gss *gamekruiseiov1alpha1.GameServerS
 	}
 
 	asts = util.GetNewAstsFromGss(gss.DeepCopy(), asts)
+	pinImages(&asts.Spec.Template.Spec, pinnedImages)
 
 	return r.Client.Create(context.Background(), asts)
 }
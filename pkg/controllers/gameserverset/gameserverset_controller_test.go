@@ -109,7 +109,7 @@ func TestInitAsts(t *testing.T) {
 			Client: c,
 			Scheme: scheme,
 		}
-		if err := reconcile.initAsts(test.gss); err != nil {
+		if err := reconcile.initAsts(test.gss, nil, nil); err != nil {
 			t.Errorf("case %d: %s", i, err.Error())
 		}
 		initAsts := &kruiseV1beta1.StatefulSet{}
@@ -123,6 +123,7 @@ func TestInitAsts(t *testing.T) {
 			test.asts.Annotations = make(map[string]string)
 		}
 		test.asts.Annotations[gameKruiseV1alpha1.AstsHashKey] = util.GetAstsHash(test.gss)
+		test.asts.Annotations[gameKruiseV1alpha1.GsTemplateHashKey] = util.GetGsTemplateHash(test.gss)
 		if !reflect.DeepEqual(initAsts, test.asts) {
 			t.Errorf("expect asts %v but got %v", test.asts, initAsts)
 		}
@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverset
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+const (
+	CreateEgressPolicyReason = "CreateEgressPolicy"
+	UpdateEgressPolicyReason = "UpdateEgressPolicy"
+)
+
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;delete
+
+// syncEgressPolicy creates, updates or deletes the NetworkPolicy of the GameServerSet, which has the same name as
+// the GameServerSet, according to its EgressPolicy.
+func (r *GameServerSetReconciler) syncEgressPolicy(ctx context.Context, gss *gameKruiseV1alpha1.GameServerSet) error {
+	policy := gss.Spec.EgressPolicy
+	np := &networkingv1.NetworkPolicy{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: gss.GetNamespace(), Name: gss.GetName()}, np)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		if policy == nil {
+			return nil
+		}
+		r.recorder.Event(gss, corev1.EventTypeNormal, CreateEgressPolicyReason, "create NetworkPolicy of egress")
+		return r.Create(ctx, newEgressNetworkPolicy(gss))
+	}
+
+	// the NetworkPolicy is not managed by the GameServerSet
+	if !metav1.IsControlledBy(np, gss) {
+		return nil
+	}
+	if policy == nil {
+		return r.Delete(ctx, np)
+	}
+	hash := util.GetHash(policy)
+	if np.GetAnnotations()[gameKruiseV1alpha1.EgressPolicyHashKey] != hash {
+		desired := newEgressNetworkPolicy(gss)
+		np.SetAnnotations(desired.GetAnnotations())
+		np.Spec = desired.Spec
+		r.recorder.Event(gss, corev1.EventTypeNormal, UpdateEgressPolicyReason, "update NetworkPolicy of egress")
+		return r.Update(ctx, np)
+	}
+	return nil
+}
+
+// newEgressNetworkPolicy returns the NetworkPolicy selecting the pods of the GameServerSet, which denies the outbound
+// traffic except to the CIDRs of EgressPolicy, and the DNS queries unless DenyDNS is true.
+func newEgressNetworkPolicy(gss *gameKruiseV1alpha1.GameServerSet) *networkingv1.NetworkPolicy {
+	policy := gss.Spec.EgressPolicy
+	var egress []networkingv1.NetworkPolicyEgressRule
+	if len(policy.CIDRs) != 0 {
+		rule := networkingv1.NetworkPolicyEgressRule{Ports: policy.Ports}
+		for _, cidr := range policy.CIDRs {
+			rule.To = append(rule.To, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+		}
+		egress = append(egress, rule)
+	}
+	if !policy.DenyDNS {
+		dnsPort := intstr.FromInt(53)
+		egress = append(egress, networkingv1.NetworkPolicyEgressRule{
+			Ports: []networkingv1.NetworkPolicyPort{
+				{Protocol: ptr.To(corev1.ProtocolUDP), Port: &dnsPort},
+				{Protocol: ptr.To(corev1.ProtocolTCP), Port: &dnsPort},
+			},
+		})
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gss.GetName(),
+			Namespace: gss.GetNamespace(),
+			Annotations: map[string]string{
+				gameKruiseV1alpha1.EgressPolicyHashKey: util.GetHash(policy),
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         gss.APIVersion,
+					Kind:               gss.Kind,
+					Name:               gss.GetName(),
+					UID:                gss.GetUID(),
+					Controller:         ptr.To[bool](true),
+					BlockOwnerDeletion: ptr.To[bool](true),
+				},
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: gss.GetName()},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+			Egress:      egress,
+		},
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverset
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestNewEgressNetworkPolicy(t *testing.T) {
+	port := intstr.FromInt(6379)
+	dnsPort := intstr.FromInt(53)
+	tests := []struct {
+		policy *gameKruiseV1alpha1.EgressPolicy
+		egress []networkingv1.NetworkPolicyEgressRule
+	}{
+		{
+			policy: &gameKruiseV1alpha1.EgressPolicy{
+				CIDRs: []string{"10.0.0.0/16", "172.16.1.0/24"},
+				Ports: []networkingv1.NetworkPolicyPort{{Port: &port}},
+			},
+			egress: []networkingv1.NetworkPolicyEgressRule{
+				{
+					Ports: []networkingv1.NetworkPolicyPort{{Port: &port}},
+					To: []networkingv1.NetworkPolicyPeer{
+						{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/16"}},
+						{IPBlock: &networkingv1.IPBlock{CIDR: "172.16.1.0/24"}},
+					},
+				},
+				{
+					Ports: []networkingv1.NetworkPolicyPort{
+						{Protocol: ptr.To(corev1.ProtocolUDP), Port: &dnsPort},
+						{Protocol: ptr.To(corev1.ProtocolTCP), Port: &dnsPort},
+					},
+				},
+			},
+		},
+		{
+			policy: &gameKruiseV1alpha1.EgressPolicy{DenyDNS: true},
+			egress: nil,
+		},
+	}
+
+	for i, test := range tests {
+		gss := &gameKruiseV1alpha1.GameServerSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+			Spec:       gameKruiseV1alpha1.GameServerSetSpec{EgressPolicy: test.policy},
+		}
+		np := newEgressNetworkPolicy(gss)
+		if !reflect.DeepEqual(np.Spec.Egress, test.egress) {
+			t.Errorf("case %d: expect egress %v, but actually got %v", i, test.egress, np.Spec.Egress)
+		}
+		if !reflect.DeepEqual(np.Spec.PolicyTypes, []networkingv1.PolicyType{networkingv1.PolicyTypeEgress}) {
+			t.Errorf("case %d: expect policy types [Egress], but actually got %v", i, np.Spec.PolicyTypes)
+		}
+		if np.Spec.PodSelector.MatchLabels[gameKruiseV1alpha1.GameServerOwnerGssKey] != "case" {
+			t.Errorf("case %d: expect pods of GameServerSet case selected, but actually got %v", i, np.Spec.PodSelector)
+		}
+	}
+}
+
+func TestSyncEgressPolicy(t *testing.T) {
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "game.kruise.io/v1alpha1", Kind: "GameServerSet"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case", UID: "uid-1"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			EgressPolicy: &gameKruiseV1alpha1.EgressPolicy{CIDRs: []string{"10.0.0.0/16"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &GameServerSetReconciler{Client: c, recorder: record.NewFakeRecorder(10)}
+	key := types.NamespacedName{Namespace: "xxx", Name: "case"}
+
+	// create
+	if err := r.syncEgressPolicy(context.TODO(), gss); err != nil {
+		t.Fatal(err)
+	}
+	np := &networkingv1.NetworkPolicy{}
+	if err := c.Get(context.TODO(), key, np); err != nil {
+		t.Fatal(err)
+	}
+
+	// update
+	gss.Spec.EgressPolicy.CIDRs = []string{"10.1.0.0/16"}
+	if err := r.syncEgressPolicy(context.TODO(), gss); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(context.TODO(), key, np); err != nil {
+		t.Fatal(err)
+	}
+	if cidr := np.Spec.Egress[0].To[0].IPBlock.CIDR; cidr != "10.1.0.0/16" {
+		t.Errorf("expect CIDR 10.1.0.0/16, but actually got %s", cidr)
+	}
+
+	// delete
+	gss.Spec.EgressPolicy = nil
+	if err := r.syncEgressPolicy(context.TODO(), gss); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(context.TODO(), key, np); !errors.IsNotFound(err) {
+		t.Errorf("expect NetworkPolicy deleted, but actually got %v", err)
+	}
+
+	// the NetworkPolicy not controlled by the GameServerSet is left alone
+	other := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"}}
+	if err := c.Create(context.TODO(), other); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.syncEgressPolicy(context.TODO(), gss); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(context.TODO(), key, np); err != nil {
+		t.Errorf("expect NetworkPolicy not controlled by the GameServerSet kept, but actually got %v", err)
+	}
+}
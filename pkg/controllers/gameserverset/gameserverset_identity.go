@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+const (
+	IdentityRetainedReason = "IdentityRetained"
+	IdentityRestoredReason = "IdentityRestored"
+)
+
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;create;update;delete
+
+// syncIdentityFinalizer adds or removes the finalizer of GameServerSet according to IdentityRetentionPolicy.
+// It returns whether the GameServerSet is updated.
+func (r *GameServerSetReconciler) syncIdentityFinalizer(ctx context.Context, gss *gamekruiseiov1alpha1.GameServerSet) (bool, error) {
+	retain := gss.Spec.IdentityRetentionPolicy == gamekruiseiov1alpha1.RetainIdentityRetentionPolicyType
+	if retain == controllerutil.ContainsFinalizer(gss, gamekruiseiov1alpha1.IdentityRetentionFinalizer) {
+		return false, nil
+	}
+	if retain {
+		controllerutil.AddFinalizer(gss, gamekruiseiov1alpha1.IdentityRetentionFinalizer)
+	} else {
+		controllerutil.RemoveFinalizer(gss, gamekruiseiov1alpha1.IdentityRetentionFinalizer)
+	}
+	return true, r.Update(ctx, gss)
+}
+
+// retainIdentity orphans the Services owned by the deleting GameServerSet, records its reserved IDs,
+// and then removes the finalizer so that the GameServerSet can be deleted.
+func (r *GameServerSetReconciler) retainIdentity(ctx context.Context, gss *gamekruiseiov1alpha1.GameServerSet) error {
+	var reserveOrdinals []int
+	asts := &kruiseV1beta1.StatefulSet{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: gss.GetNamespace(), Name: gss.GetName()}, asts)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		reserveOrdinals = asts.Spec.ReserveOrdinals
+	}
+
+	svcList := &corev1.ServiceList{}
+	if err := r.List(ctx, svcList, client.InNamespace(gss.GetNamespace())); err != nil {
+		return err
+	}
+	for i := range svcList.Items {
+		svc := &svcList.Items[i]
+		var ownerReferences []metav1.OwnerReference
+		for _, or := range svc.GetOwnerReferences() {
+			if or.UID != gss.GetUID() {
+				ownerReferences = append(ownerReferences, or)
+			}
+		}
+		if len(ownerReferences) == len(svc.GetOwnerReferences()) {
+			continue
+		}
+		svc.SetOwnerReferences(ownerReferences)
+		if svc.Annotations == nil {
+			svc.Annotations = make(map[string]string)
+		}
+		svc.Annotations[gamekruiseiov1alpha1.IdentityRetainedFromKey] = gss.GetName()
+		if err := r.Update(ctx, svc); err != nil {
+			klog.Errorf("failed to retain Service %s in %s,because of %s.", svc.GetName(), svc.GetNamespace(), err.Error())
+			return err
+		}
+	}
+
+	identity := util.NewIdentityRecord(gss.GetNamespace(), gss.GetName(), util.GetReserveIds(gss), reserveOrdinals)
+	cm := &corev1.ConfigMap{}
+	err = r.apiReader.Get(ctx, types.NamespacedName{Namespace: identity.GetNamespace(), Name: identity.GetName()}, cm)
+	switch {
+	case errors.IsNotFound(err):
+		cm = identity
+		if err := r.Create(ctx, cm); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case cm.GetAnnotations()[gamekruiseiov1alpha1.IdentityRetainedFromKey] != gss.GetName():
+		// the ConfigMap is not the identity record of the GameServerSet, and must not be overwritten
+		r.recorder.Eventf(gss, corev1.EventTypeWarning, IdentityRetainedReason, "failed to retain identity of GameServers, ConfigMap %s already exists", cm.GetName())
+		return fmt.Errorf("ConfigMap %s/%s already exists and is not the identity record of GameServerSet %s", cm.GetNamespace(), cm.GetName(), gss.GetName())
+	default:
+		cm.Data = identity.Data
+		if err := r.Update(ctx, cm); err != nil {
+			return err
+		}
+	}
+	r.recorder.Eventf(gss, corev1.EventTypeNormal, IdentityRetainedReason, "retained identity of GameServers in ConfigMap %s", cm.GetName())
+
+	controllerutil.RemoveFinalizer(gss, gamekruiseiov1alpha1.IdentityRetentionFinalizer)
+	return r.Update(ctx, gss)
+}
+
+// restoreIdentity reattaches the retained Services and reserved IDs to the GameServerSet created again.
+// It returns the ConfigMap which records the retained identity, or nil if there is none.
+func (r *GameServerSetReconciler) restoreIdentity(ctx context.Context, gss *gamekruiseiov1alpha1.GameServerSet) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{}
+	err := r.apiReader.Get(ctx, types.NamespacedName{Namespace: gss.GetNamespace(), Name: util.GetIdentityRecordName(gss.GetName())}, cm)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if cm.GetAnnotations()[gamekruiseiov1alpha1.IdentityRetainedFromKey] != gss.GetName() {
+		return nil, nil
+	}
+
+	svcList := &corev1.ServiceList{}
+	if err := r.List(ctx, svcList, client.InNamespace(gss.GetNamespace())); err != nil {
+		return nil, err
+	}
+	for i := range svcList.Items {
+		svc := &svcList.Items[i]
+		if svc.GetAnnotations()[gamekruiseiov1alpha1.IdentityRetainedFromKey] != gss.GetName() {
+			continue
+		}
+		delete(svc.Annotations, gamekruiseiov1alpha1.IdentityRetainedFromKey)
+		svc.SetOwnerReferences(append(svc.GetOwnerReferences(), *metav1.NewControllerRef(gss, controllerKind)))
+		if err := r.Update(ctx, svc); err != nil {
+			klog.Errorf("failed to reattach Service %s in %s,because of %s.", svc.GetName(), svc.GetNamespace(), err.Error())
+			return nil, err
+		}
+	}
+
+	reserveIds, _ := util.ParseIdentityRecord(cm)
+	gssReserveIds := util.GetReserveIds(gss)
+	toReserve := util.GetSliceInANotInB(reserveIds, gssReserveIds)
+	if len(toReserve) != 0 {
+		gssReserveIds = append(gssReserveIds, toReserve...)
+		gssAnnotations := map[string]string{gamekruiseiov1alpha1.GameServerSetReserveIdsKey: util.IntSliceToString(gssReserveIds, ",")}
+		patchGss := map[string]interface{}{"spec": map[string]interface{}{"reserveGameServerIds": util.GetSpecReserveIds(gss, gssReserveIds)}, "metadata": map[string]map[string]string{"annotations": gssAnnotations}}
+		patchGssBytes, _ := json.Marshal(patchGss)
+		if err := r.Patch(ctx, gss, client.RawPatch(types.MergePatchType, patchGssBytes)); err != nil {
+			klog.Errorf("failed to patch GameServerSet %s in %s,because of %s.", gss.GetName(), gss.GetNamespace(), err.Error())
+			return nil, err
+		}
+	}
+	return cm, nil
+}
+
+// getRetainedReserveOrdinals returns the reserve ordinals of the workload recorded in the ConfigMap.
+func getRetainedReserveOrdinals(cm *corev1.ConfigMap) []int {
+	_, reserveOrdinals := util.ParseIdentityRecord(cm)
+	return reserveOrdinals
+}
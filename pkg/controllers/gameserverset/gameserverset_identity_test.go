@@ -0,0 +1,238 @@
+package gameserverset
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+func TestSyncIdentityFinalizer(t *testing.T) {
+	tests := []struct {
+		policy     gameKruiseV1alpha1.IdentityRetentionPolicyType
+		finalizers []string
+		updated    bool
+		expected   bool
+	}{
+		{
+			policy:   gameKruiseV1alpha1.RetainIdentityRetentionPolicyType,
+			updated:  true,
+			expected: true,
+		},
+		{
+			policy:     gameKruiseV1alpha1.RetainIdentityRetentionPolicyType,
+			finalizers: []string{gameKruiseV1alpha1.IdentityRetentionFinalizer},
+			updated:    false,
+			expected:   true,
+		},
+		{
+			policy:     gameKruiseV1alpha1.DeleteIdentityRetentionPolicyType,
+			finalizers: []string{gameKruiseV1alpha1.IdentityRetentionFinalizer},
+			updated:    true,
+			expected:   false,
+		},
+		{
+			updated:  false,
+			expected: false,
+		},
+	}
+
+	for i, test := range tests {
+		gss := &gameKruiseV1alpha1.GameServerSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case", Finalizers: test.finalizers},
+			Spec:       gameKruiseV1alpha1.GameServerSetSpec{IdentityRetentionPolicy: test.policy},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gss).Build()
+		r := &GameServerSetReconciler{Client: c}
+		updated, err := r.syncIdentityFinalizer(context.TODO(), gss)
+		if err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		if updated != test.updated {
+			t.Errorf("case %d: expect updated %v, but actually got %v", i, test.updated, updated)
+		}
+		newGss := &gameKruiseV1alpha1.GameServerSet{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: "case"}, newGss); err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		if controllerutil.ContainsFinalizer(newGss, gameKruiseV1alpha1.IdentityRetentionFinalizer) != test.expected {
+			t.Errorf("case %d: expect finalizer %v, but actually got %v", i, test.expected, newGss.GetFinalizers())
+		}
+	}
+}
+
+func TestRetainAndRestoreIdentity(t *testing.T) {
+	now := metav1.Now()
+	oldGss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "xxx",
+			Name:              "case",
+			UID:               "uid-old",
+			DeletionTimestamp: &now,
+			Finalizers:        []string{gameKruiseV1alpha1.IdentityRetentionFinalizer},
+		},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			ReserveGameServerIds:    []int{3},
+			IdentityRetentionPolicy: gameKruiseV1alpha1.RetainIdentityRetentionPolicyType,
+		},
+	}
+	asts := &kruiseV1beta1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+		Spec:       kruiseV1beta1.StatefulSetSpec{ReserveOrdinals: []int{2, 3}},
+	}
+	fixedSvc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "xxx",
+			Name:      "case-0",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "game.kruise.io/v1alpha1", Kind: "GameServerSet", Name: "case", UID: "uid-old", Controller: ptr.To[bool](true)},
+			},
+		},
+	}
+	podSvc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "xxx",
+			Name:      "case-1",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "v1", Kind: "Pod", Name: "case-1", UID: "uid-pod", Controller: ptr.To[bool](true)},
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(oldGss, asts, fixedSvc, podSvc).Build()
+	r := &GameServerSetReconciler{Client: c, apiReader: c, recorder: record.NewFakeRecorder(10)}
+	if err := r.retainIdentity(context.TODO(), oldGss); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := &corev1.Service{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: "case-0"}, svc); err != nil {
+		t.Fatal(err)
+	}
+	if len(svc.GetOwnerReferences()) != 0 || svc.GetAnnotations()[gameKruiseV1alpha1.IdentityRetainedFromKey] != "case" {
+		t.Errorf("expect Service case-0 to be retained, but actually got %v", svc.ObjectMeta)
+	}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: "case-1"}, svc); err != nil {
+		t.Fatal(err)
+	}
+	if len(svc.GetOwnerReferences()) != 1 || svc.GetAnnotations()[gameKruiseV1alpha1.IdentityRetainedFromKey] != "" {
+		t.Errorf("expect Service case-1 not to be retained, but actually got %v", svc.ObjectMeta)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: "case-identity"}, cm); err != nil {
+		t.Fatal(err)
+	}
+	if reserveIds, reserveOrdinals := util.ParseIdentityRecord(cm); !reflect.DeepEqual(reserveIds, []int{3}) || !reflect.DeepEqual(reserveOrdinals, []int{2, 3}) {
+		t.Errorf("unexpected identity record %v", cm.Data)
+	}
+
+	// the GameServerSet is created again with the same name
+	newGss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case", UID: "uid-new"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			IdentityRetentionPolicy: gameKruiseV1alpha1.RetainIdentityRetentionPolicyType,
+		},
+	}
+	retainedSvc := &corev1.Service{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: "case-0"}, retainedSvc); err != nil {
+		t.Fatal(err)
+	}
+	retainedSvc.ResourceVersion = ""
+	cm.ResourceVersion = ""
+	c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(newGss, retainedSvc, cm).Build()
+	r = &GameServerSetReconciler{Client: c, apiReader: c, recorder: record.NewFakeRecorder(10)}
+	identity, err := r.restoreIdentity(context.TODO(), newGss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(getRetainedReserveOrdinals(identity), []int{2, 3}) {
+		t.Errorf("expect reserve ordinals [2 3], but actually got %v", getRetainedReserveOrdinals(identity))
+	}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: "case-0"}, svc); err != nil {
+		t.Fatal(err)
+	}
+	if len(svc.GetOwnerReferences()) != 1 || svc.GetOwnerReferences()[0].UID != "uid-new" || svc.GetAnnotations()[gameKruiseV1alpha1.IdentityRetainedFromKey] != "" {
+		t.Errorf("expect Service case-0 to be reattached, but actually got %v", svc.ObjectMeta)
+	}
+	gss := &gameKruiseV1alpha1.GameServerSet{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: "case"}, gss); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gss.Spec.ReserveGameServerIds, []int{3}) || gss.GetAnnotations()[gameKruiseV1alpha1.GameServerSetReserveIdsKey] != "3" {
+		t.Errorf("expect reserveGameServerIds [3], but actually got %v", gss.Spec.ReserveGameServerIds)
+	}
+}
+
+func TestRetainIdentityExistingConfigMap(t *testing.T) {
+	now := metav1.Now()
+	tests := []struct {
+		cm        *corev1.ConfigMap
+		expectErr bool
+		expectIds []int
+	}{
+		// the identity record retained before is updated
+		{
+			cm:        util.NewIdentityRecord("xxx", "case", []int{1}, nil),
+			expectIds: []int{3},
+		},
+		// the ConfigMap not recording the identity is never overwritten
+		{
+			cm: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-identity"},
+				Data:       map[string]string{"foo": "bar"},
+			},
+			expectErr: true,
+		},
+	}
+
+	for i, test := range tests {
+		gss := &gameKruiseV1alpha1.GameServerSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         "xxx",
+				Name:              "case",
+				UID:               "uid-old",
+				DeletionTimestamp: &now,
+				Finalizers:        []string{gameKruiseV1alpha1.IdentityRetentionFinalizer},
+			},
+			Spec: gameKruiseV1alpha1.GameServerSetSpec{
+				ReserveGameServerIds:    []int{3},
+				IdentityRetentionPolicy: gameKruiseV1alpha1.RetainIdentityRetentionPolicyType,
+			},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gss, test.cm).Build()
+		r := &GameServerSetReconciler{Client: c, apiReader: c, recorder: record.NewFakeRecorder(10)}
+		err := r.retainIdentity(context.TODO(), gss)
+		if (err != nil) != test.expectErr {
+			t.Errorf("case %d: expect error %v, but actually got %v", i, test.expectErr, err)
+			continue
+		}
+
+		cm := &corev1.ConfigMap{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: "case-identity"}, cm); err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		if test.expectErr {
+			if !reflect.DeepEqual(cm.Data, test.cm.Data) {
+				t.Errorf("case %d: expect ConfigMap not to be overwritten, but actually got %v", i, cm.Data)
+			}
+			continue
+		}
+		if reserveIds, _ := util.ParseIdentityRecord(cm); !reflect.DeepEqual(reserveIds, test.expectIds) {
+			t.Errorf("case %d: expect reserved ids %v, but actually got %v", i, test.expectIds, reserveIds)
+		}
+	}
+}
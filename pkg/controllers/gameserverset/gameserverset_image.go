@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverset
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util/image"
+)
+
+const ImagePolicyFailedReason = "ImagePolicyFailed"
+
+// PolicyControllerIncludeLabel is the namespace label which opts the namespace in the sigstore policy controller.
+const PolicyControllerIncludeLabel = "policy.sigstore.dev/include"
+
+var clusterImagePolicyGVK = schema.GroupVersionKind{Group: "policy.sigstore.dev", Version: "v1beta1", Kind: "ClusterImagePolicy"}
+
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
+//+kubebuilder:rbac:groups=policy.sigstore.dev,resources=clusterimagepolicies,verbs=get
+
+// resolveImages checks the image policy of GameServerSet and resolves the images of the template to digests.
+// It returns the pinned images keyed by the original images, which is nil if digests are not required.
+func resolveImages(ctx context.Context, reader client.Reader, resolver image.Resolver, gss *gameKruiseV1alpha1.GameServerSet) (map[string]string, error) {
+	policy := gss.Spec.ImagePolicy
+	if policy == nil || (!policy.PinDigest && policy.CosignPolicyRef == "") {
+		return nil, nil
+	}
+	if policy.CosignPolicyRef != "" {
+		if err := checkCosignPolicy(ctx, reader, gss.GetNamespace(), policy.CosignPolicyRef); err != nil {
+			return nil, err
+		}
+	}
+
+	podSpec := gss.Spec.GameServerTemplate.Spec
+	credentials, err := getRegistryCredentials(ctx, reader, gss.GetNamespace(), podSpec.ImagePullSecrets)
+	if err != nil {
+		return nil, err
+	}
+	pinnedImages := make(map[string]string)
+	for _, c := range append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...) {
+		if _, ok := pinnedImages[c.Image]; ok {
+			continue
+		}
+		digest, err := resolver.Resolve(ctx, c.Image, credentials)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve digest of image %s, because of %s", c.Image, err.Error())
+		}
+		pinnedImage, err := image.WithDigest(c.Image, digest)
+		if err != nil {
+			return nil, err
+		}
+		pinnedImages[c.Image] = pinnedImage
+	}
+	return pinnedImages, nil
+}
+
+// checkCosignPolicy makes sure that the pods in the namespace will be verified by the ClusterImagePolicy
+// of the sigstore policy controller before they are created.
+func checkCosignPolicy(ctx context.Context, reader client.Reader, namespace, policyName string) error {
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(clusterImagePolicyGVK)
+	if err := reader.Get(ctx, types.NamespacedName{Name: policyName}, policy); err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("ClusterImagePolicy %s is not found", policyName)
+		}
+		return err
+	}
+
+	ns := &corev1.Namespace{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return err
+	}
+	if ns.GetLabels()[PolicyControllerIncludeLabel] != "true" {
+		return fmt.Errorf("namespace %s is not enforced by the sigstore policy controller, label %s=true is required", namespace, PolicyControllerIncludeLabel)
+	}
+	return nil
+}
+
+// getRegistryCredentials reads the credentials from image pull secrets, the former secret wins for the same registry.
+func getRegistryCredentials(ctx context.Context, reader client.Reader, namespace string, pullSecrets []corev1.LocalObjectReference) (map[string]image.Credential, error) {
+	credentials := make(map[string]image.Credential)
+	for _, ps := range pullSecrets {
+		secret := &corev1.Secret{}
+		if err := reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ps.Name}, secret); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		data, ok := secret.Data[corev1.DockerConfigJsonKey]
+		if !ok {
+			data, ok = secret.Data[corev1.DockerConfigKey]
+		}
+		if !ok {
+			continue
+		}
+		parsed, err := image.ParseDockerConfig(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse image pull secret %s, because of %s", ps.Name, err.Error())
+		}
+		for registry, credential := range parsed {
+			if _, exist := credentials[registry]; !exist {
+				credentials[registry] = credential
+			}
+		}
+	}
+	return credentials, nil
+}
+
+// pinImages replaces the images of the pod spec with the pinned ones.
+func pinImages(podSpec *corev1.PodSpec, pinnedImages map[string]string) {
+	for i := range podSpec.InitContainers {
+		if pinned, ok := pinnedImages[podSpec.InitContainers[i].Image]; ok {
+			podSpec.InitContainers[i].Image = pinned
+		}
+	}
+	for i := range podSpec.Containers {
+		if pinned, ok := pinnedImages[podSpec.Containers[i].Image]; ok {
+			podSpec.Containers[i].Image = pinned
+		}
+	}
+}
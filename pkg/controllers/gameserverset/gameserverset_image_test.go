@@ -0,0 +1,124 @@
+package gameserverset
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util/image"
+)
+
+type fakeResolver struct {
+	digests map[string]string
+}
+
+func (f *fakeResolver) Resolve(ctx context.Context, img string, credentials map[string]image.Credential) (string, error) {
+	if _, ok := credentials["registry.example.com"]; !ok {
+		return "", fmt.Errorf("no credential")
+	}
+	digest, ok := f.digests[img]
+	if !ok {
+		return "", fmt.Errorf("image %s not found", img)
+	}
+	return digest, nil
+}
+
+func TestResolveImages(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "pull-secret"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths":{"registry.example.com":{"username":"foo","password":"bar"}}}`),
+		},
+	}
+	resolver := &fakeResolver{
+		digests: map[string]string{
+			"registry.example.com/game/server:v1":  "sha256:abc",
+			"registry.example.com/game/sidecar:v1": "sha256:def",
+		},
+	}
+	template := gameKruiseV1alpha1.GameServerTemplate{
+		PodTemplateSpec: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "pull-secret"}},
+				InitContainers: []corev1.Container{
+					{Name: "init", Image: "registry.example.com/game/server:v1"},
+				},
+				Containers: []corev1.Container{
+					{Name: "server", Image: "registry.example.com/game/server:v1"},
+					{Name: "sidecar", Image: "registry.example.com/game/sidecar:v1"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		namespace *corev1.Namespace
+		policy    *gameKruiseV1alpha1.ImagePolicy
+		expected  map[string]string
+		hasError  bool
+	}{
+		{
+			policy:   nil,
+			expected: nil,
+		},
+		{
+			policy: &gameKruiseV1alpha1.ImagePolicy{PinDigest: true},
+			expected: map[string]string{
+				"registry.example.com/game/server:v1":  "registry.example.com/game/server@sha256:abc",
+				"registry.example.com/game/sidecar:v1": "registry.example.com/game/sidecar@sha256:def",
+			},
+		},
+		// the ClusterImagePolicy does not exist
+		{
+			namespace: &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "xxx", Labels: map[string]string{PolicyControllerIncludeLabel: "true"}},
+			},
+			policy:   &gameKruiseV1alpha1.ImagePolicy{CosignPolicyRef: "game-builds"},
+			hasError: true,
+		},
+	}
+
+	for i, test := range tests {
+		builder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret)
+		if test.namespace != nil {
+			builder.WithObjects(test.namespace)
+		}
+		c := builder.Build()
+		gss := &gameKruiseV1alpha1.GameServerSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+			Spec: gameKruiseV1alpha1.GameServerSetSpec{
+				GameServerTemplate: template,
+				ImagePolicy:        test.policy,
+			},
+		}
+		actual, err := resolveImages(context.Background(), c, resolver, gss)
+		if (err != nil) != test.hasError {
+			t.Errorf("case %d: expect error %v, but actually got %v", i, test.hasError, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.expected, actual)
+		}
+	}
+}
+
+func TestPinImages(t *testing.T) {
+	podSpec := &corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "init", Image: "server:v1"}},
+		Containers: []corev1.Container{
+			{Name: "server", Image: "server:v1"},
+			{Name: "other", Image: "other:v1"},
+		},
+	}
+	pinImages(podSpec, map[string]string{"server:v1": "server@sha256:abc"})
+	if podSpec.InitContainers[0].Image != "server@sha256:abc" || podSpec.Containers[0].Image != "server@sha256:abc" || podSpec.Containers[1].Image != "other:v1" {
+		t.Errorf("unexpected images after pinning: %v", podSpec)
+	}
+}
@@ -18,8 +18,10 @@ package gameserverset
 
 import (
 	"context"
+	"fmt"
 	kruiseV1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
 	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -34,18 +36,24 @@ import (
 	"sort"
 	"strconv"
 	"sync"
+	"time"
 
 	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
 	"github.com/openkruise/kruise-game/pkg/util"
+	"github.com/openkruise/kruise-game/pkg/util/scoring"
 )
 
 type Control interface {
 	GameServerScale() error
-	UpdateWorkload() error
+	UpdateWorkload(pinnedImages map[string]string) error
+	UpdateWorkloadExceptTemplate() error
 	SyncStatus() error
 	IsNeedToScale() bool
 	IsNeedToUpdateWorkload() bool
+	IsUpdateFrozen() (bool, time.Duration)
+	IsTemplateChanged() bool
 	SyncPodProbeMarker() error
+	SyncNetworkDisabled() error
 	GetReplicasAfterKilling() *int32
 }
 
@@ -55,6 +63,8 @@ const (
 	UpdatePPMReason      = "UpdatePpm"
 	CreateWorkloadReason = "CreateWorkload"
 	UpdateWorkloadReason = "UpdateWorkload"
+	UpdateFrozenReason   = "UpdateFrozen"
+	HotUpdateReason      = "HotUpdate"
 )
 
 type GameServerSetManager struct {
@@ -102,7 +112,7 @@ func (manager *GameServerSetManager) IsNeedToScale() bool {
 
 	// no need to scale
 	return !(*gss.Spec.Replicas == *asts.Spec.Replicas &&
-		util.IsSliceEqual(util.StringToIntSlice(gss.GetAnnotations()[gameKruiseV1alpha1.GameServerSetReserveIdsKey], ","), gss.Spec.ReserveGameServerIds))
+		util.IsSliceEqual(util.StringToIntSlice(gss.GetAnnotations()[gameKruiseV1alpha1.GameServerSetReserveIdsKey], ","), util.GetReserveIds(gss)))
 }
 
 func (manager *GameServerSetManager) GameServerScale() error {
@@ -122,12 +132,34 @@ func (manager *GameServerSetManager) GameServerScale() error {
 	as := gss.GetAnnotations()
 	reserveIds := util.StringToIntSlice(as[gameKruiseV1alpha1.GameServerSetReserveIdsKey], ",")
 	notExistIds := util.GetSliceInANotInB(asts.Spec.ReserveOrdinals, reserveIds)
-	gssReserveIds := gss.Spec.ReserveGameServerIds
+	gssReserveIds := util.GetReserveIds(gss)
 
 	klog.Infof("GameServers %s/%s already has %d replicas, expect to have %d replicas.", gss.GetNamespace(), gss.GetName(), currentReplicas, expectedReplicas)
 	manager.eventRecorder.Eventf(gss, corev1.EventTypeNormal, ScaleReason, "scale from %d to %d", currentReplicas, expectedReplicas)
 
-	newManageIds, newReserveIds := computeToScaleGs(gssReserveIds, reserveIds, notExistIds, expectedReplicas, podList, gss.Spec.ScaleStrategy.ScaleDownStrategyType)
+	var scores map[string]float64
+	if expectedReplicas < currentReplicas && gss.Spec.ScoringPolicy != nil {
+		// GameServers of all GameServerSets are counted on the nodes, since the nodes are shared by them
+		nodePodList := &corev1.PodList{}
+		if err := c.List(ctx, nodePodList, client.HasLabels{gameKruiseV1alpha1.GameServerOwnerGssKey}); err != nil {
+			klog.Errorf("failed to list GameServers to score %s in %s,because of %s.", gss.GetName(), gss.GetNamespace(), err.Error())
+			return err
+		}
+		scorer, err := scoring.NewScorer(gss.Spec.ScoringPolicy, nodePodList.Items)
+		if err != nil {
+			klog.Errorf("failed to score GameServers of %s in %s,because of %s.", gss.GetName(), gss.GetNamespace(), err.Error())
+			return err
+		}
+		if scorer != nil {
+			if scores, err = scorer.ScorePods(podList); err != nil {
+				klog.Errorf("failed to score GameServers of %s in %s,because of %s.", gss.GetName(), gss.GetNamespace(), err.Error())
+				return err
+			}
+		}
+	}
+
+	newManageIds, newReserveIds := computeToScaleGs(gssReserveIds, reserveIds, notExistIds, expectedReplicas, podList, gss.Spec.ScaleStrategy.ScaleDownStrategyType, scores)
+	decision := newScaleDownDecision(podList, newManageIds, gssReserveIds, expectedReplicas, scores, metav1.Now())
 
 	if gss.Spec.GameServerTemplate.ReclaimPolicy == gameKruiseV1alpha1.DeleteGameServerReclaimPolicy {
 		err := SyncGameServer(gss, c, newManageIds, util.GetIndexListFromPodList(podList))
@@ -149,10 +181,15 @@ func (manager *GameServerSetManager) GameServerScale() error {
 
 	if gss.Spec.ScaleStrategy.ScaleDownStrategyType == gameKruiseV1alpha1.ReserveIdsScaleDownStrategyType {
 		gssReserveIds = newReserveIds
+	} else if killedIds := getReservedKilledIds(podList, newManageIds, gssReserveIds); len(killedIds) != 0 {
+		klog.Infof("GameServerSet %s/%s reserves the ids %v of the killed GameServers", gss.GetNamespace(), gss.GetName(), killedIds)
+		gssReserveIds = append(gssReserveIds, killedIds...)
 	}
+	// the ids reserved only by ReserveGameServerIdRanges are not written back to ReserveGameServerIds
+	specReserveIds := util.GetSpecReserveIds(gss, gssReserveIds)
 	gssAnnotations := make(map[string]string)
 	gssAnnotations[gameKruiseV1alpha1.GameServerSetReserveIdsKey] = util.IntSliceToString(gssReserveIds, ",")
-	patchGss := map[string]interface{}{"spec": map[string]interface{}{"reserveGameServerIds": gssReserveIds}, "metadata": map[string]map[string]string{"annotations": gssAnnotations}}
+	patchGss := map[string]interface{}{"spec": map[string]interface{}{"reserveGameServerIds": specReserveIds}, "metadata": map[string]map[string]string{"annotations": gssAnnotations}}
 	patchGssBytes, _ := json.Marshal(patchGss)
 	err = c.Patch(ctx, gss, client.RawPatch(types.MergePatchType, patchGssBytes))
 	if err != nil {
@@ -160,10 +197,86 @@ func (manager *GameServerSetManager) GameServerScale() error {
 		return err
 	}
 
+	if decision != nil {
+		klog.Infof("GameServerSet %s/%s scaled down from %d to %d, deleting GameServers %v", gss.GetNamespace(), gss.GetName(), currentReplicas, expectedReplicas, decision.GameServers)
+		patchStatus := map[string]interface{}{"status": map[string]interface{}{"lastScaleDownDecision": decision}}
+		patchStatusBytes, _ := json.Marshal(patchStatus)
+		if err := c.Status().Patch(ctx, gss, client.RawPatch(types.MergePatchType, patchStatusBytes)); err != nil {
+			klog.Errorf("failed to record scale down decision of GameServerSet %s in %s,because of %s.", gss.GetName(), gss.GetNamespace(), err.Error())
+			return err
+		}
+	}
+
 	return nil
 }
 
-func computeToScaleGs(gssReserveIds, reserveIds, notExistIds []int, expectedReplicas int, pods []corev1.Pod, scaleDownType gameKruiseV1alpha1.ScaleDownStrategyType) ([]int, []int) {
+// getReservedKilledIds returns the ids of the GameServers killed by the opsState Kill with ReserveWhenKilled,
+// which are removed from the workload and not reserved yet.
+func getReservedKilledIds(pods []corev1.Pod, newManageIds, gssReserveIds []int) []int {
+	var ids []int
+	for _, pod := range pods {
+		podLabels := pod.GetLabels()
+		if podLabels[gameKruiseV1alpha1.GameServerOpsStateKey] != string(gameKruiseV1alpha1.Kill) || podLabels[gameKruiseV1alpha1.GameServerReserveWhenKilledKey] != "true" {
+			continue
+		}
+		id := util.GetIndexFromGsName(pod.GetName())
+		if !util.IsNumInList(id, newManageIds) && !util.IsNumInList(id, gssReserveIds) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// newScaleDownDecision records the GameServers removed from the workload by the scaling, and why they were chosen.
+// It returns nil if no GameServer is removed.
+func newScaleDownDecision(pods []corev1.Pod, newManageIds, gssReserveIds []int, expectedReplicas int, scores map[string]float64, now metav1.Time) *gameKruiseV1alpha1.ScaleDownDecision {
+	var gameServers []gameKruiseV1alpha1.ScaleDownGameServer
+	for _, pod := range pods {
+		id := util.GetIndexFromGsName(pod.GetName())
+		if util.IsNumInList(id, newManageIds) {
+			continue
+		}
+		if util.IsNumInList(id, gssReserveIds) {
+			gameServers = append(gameServers, gameKruiseV1alpha1.ScaleDownGameServer{
+				Id:      id,
+				Reason:  gameKruiseV1alpha1.ScaleDownReasonReserved,
+				Message: "id is in reserveGameServerIds",
+			})
+			continue
+		}
+		podLabels := pod.GetLabels()
+		opsState := podLabels[gameKruiseV1alpha1.GameServerOpsStateKey]
+		if opsState == "" {
+			opsState = string(gameKruiseV1alpha1.None)
+		}
+		deletionPriority := podLabels[gameKruiseV1alpha1.GameServerDeletePriorityKey]
+		if deletionPriority == "" {
+			deletionPriority = "0"
+		}
+		message := fmt.Sprintf("opsState %s, deletionPriority %s", opsState, deletionPriority)
+		if score, ok := scores[pod.GetName()]; ok {
+			message = fmt.Sprintf("%s, score %v", message, score)
+		}
+		gameServers = append(gameServers, gameKruiseV1alpha1.ScaleDownGameServer{
+			Id:      id,
+			Reason:  gameKruiseV1alpha1.ScaleDownReasonDeleteSequence,
+			Message: message,
+		})
+	}
+	if len(gameServers) == 0 {
+		return nil
+	}
+	sort.Slice(gameServers, func(i, j int) bool { return gameServers[i].Id < gameServers[j].Id })
+	return &gameKruiseV1alpha1.ScaleDownDecision{
+		Time:             now,
+		CurrentReplicas:  int32(len(pods)),
+		ExpectedReplicas: int32(expectedReplicas),
+		GameServers:      gameServers,
+	}
+}
+
+func computeToScaleGs(gssReserveIds, reserveIds, notExistIds []int, expectedReplicas int, pods []corev1.Pod, scaleDownType gameKruiseV1alpha1.ScaleDownStrategyType, scores map[string]float64) ([]int, []int) {
 	workloadManageIds := util.GetIndexListFromPodList(pods)
 
 	var toAdd []int
@@ -199,9 +312,13 @@ func computeToScaleGs(gssReserveIds, reserveIds, notExistIds []int, expectedRepl
 	numToAdd := expectedReplicas - len(pods) + len(toDelete) - len(toAdd)
 	if numToAdd < 0 {
 
-		// 2.a to delete GameServers according to DeleteSequence
+		// 2.a to delete GameServers according to DeleteSequence, and scores if ScoringPolicy is set
 		sortedGs := util.DeleteSequenceGs(pods)
-		sort.Sort(sortedGs)
+		if scores != nil {
+			sort.Sort(util.ScoredDeleteSequenceGs{DeleteSequenceGs: sortedGs, Scores: scores})
+		} else {
+			sort.Sort(sortedGs)
+		}
 		toDelete = append(toDelete, util.GetIndexListFromPodList(sortedGs[:-numToAdd])...)
 	} else {
 
@@ -319,35 +436,120 @@ func SyncGameServer(gss *gameKruiseV1alpha1.GameServerSet, c client.Client, newM
 }
 
 func (manager *GameServerSetManager) IsNeedToUpdateWorkload() bool {
-	return manager.asts.GetAnnotations()[gameKruiseV1alpha1.AstsHashKey] != util.GetAstsHash(manager.gameServerSet)
+	astsAns := manager.asts.GetAnnotations()
+	if astsAns[gameKruiseV1alpha1.AstsHashKey] != util.GetAstsHash(manager.gameServerSet) {
+		return true
+	}
+	// the rollout of GameServerTemplate has been blocked by freeze windows
+	templateHash := astsAns[gameKruiseV1alpha1.GsTemplateHashKey]
+	return templateHash != "" && templateHash != util.GetGsTemplateHash(manager.gameServerSet)
+}
+
+// IsTemplateChanged returns whether GameServerTemplate differs from the one that the pod template of workload is updated with.
+// It is regarded as changed when the workload is created before GsTemplateHashKey is recorded.
+func (manager *GameServerSetManager) IsTemplateChanged() bool {
+	templateHash := manager.asts.GetAnnotations()[gameKruiseV1alpha1.GsTemplateHashKey]
+	return templateHash == "" || templateHash != util.GetGsTemplateHash(manager.gameServerSet)
+}
+
+// isTemplateRolloutFrozen returns whether the rollout of GameServerTemplate is blocked by freeze windows now.
+func (manager *GameServerSetManager) isTemplateRolloutFrozen() bool {
+	if !manager.IsNeedToUpdateWorkload() || !manager.IsTemplateChanged() {
+		return false
+	}
+	frozen, _ := manager.IsUpdateFrozen()
+	return frozen
+}
+
+// IsUpdateFrozen returns whether template rollouts are blocked by freeze windows now,
+// and the duration to wait until the freeze windows end.
+func (manager *GameServerSetManager) IsUpdateFrozen() (bool, time.Duration) {
+	gss := manager.gameServerSet
+	if gss.GetAnnotations()[gameKruiseV1alpha1.UpdateFreezeOverrideKey] == "true" {
+		return false, 0
+	}
+	return util.IsInFreezeWindows(gss.Spec.UpdateStrategy.FreezeWindows, time.Now())
 }
 
-func (manager *GameServerSetManager) UpdateWorkload() error {
+// UpdateWorkload syncs the template to Advanced StatefulSet, and replaces images with the pinned ones if given.
+func (manager *GameServerSetManager) UpdateWorkload(pinnedImages map[string]string) error {
 	gss := manager.gameServerSet
 	asts := manager.asts
 
 	// sync with Advanced StatefulSet
+	hotUpdated := false
 	retryErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		base, revision := util.GetHotUpdateHashes(gss)
+		// the template is hot updated if nothing but the containers and annotations of HotUpdate are changed
+		isHotUpdate := base != "" && base == asts.GetAnnotations()[gameKruiseV1alpha1.HotUpdateBaseHashKey]
+		hotUpdated = isHotUpdate && revision != asts.Spec.Template.GetAnnotations()[gameKruiseV1alpha1.HotUpdateRevisionKey]
+
 		asts = util.GetNewAstsFromGss(gss.DeepCopy(), asts)
+		pinImages(&asts.Spec.Template.Spec, pinnedImages)
+		if isHotUpdate {
+			setInPlaceIfPossible(asts)
+		}
 		astsAns := asts.GetAnnotations()
 		astsAns[gameKruiseV1alpha1.AstsHashKey] = util.GetAstsHash(manager.gameServerSet)
+		astsAns[gameKruiseV1alpha1.GsTemplateHashKey] = util.GetGsTemplateHash(manager.gameServerSet)
+		if base != "" {
+			astsAns[gameKruiseV1alpha1.HotUpdateBaseHashKey] = base
+		} else {
+			delete(astsAns, gameKruiseV1alpha1.HotUpdateBaseHashKey)
+		}
 		asts.SetAnnotations(astsAns)
 
 		return manager.client.Update(context.TODO(), asts)
 	})
+	if retryErr == nil && hotUpdated {
+		manager.eventRecorder.Event(gss, corev1.EventTypeNormal, HotUpdateReason, "hot update GameServers in place")
+	}
 
 	return retryErr
 }
 
+// UpdateWorkloadExceptTemplate updates the workload but keeps its pod template, when the rollout of GameServerTemplate
+// is blocked by freeze windows. The whole workload is left as it is if the GameServerTemplate it was updated with is unknown.
+func (manager *GameServerSetManager) UpdateWorkloadExceptTemplate() error {
+	gss := manager.gameServerSet
+	oldAsts := manager.asts
+	astsHash := util.GetAstsHash(gss)
+	if oldAsts.GetAnnotations()[gameKruiseV1alpha1.GsTemplateHashKey] == "" || oldAsts.GetAnnotations()[gameKruiseV1alpha1.AstsHashKey] == astsHash {
+		return nil
+	}
+
+	asts := util.GetNewAstsFromGss(gss.DeepCopy(), oldAsts.DeepCopy())
+	asts.Spec.Template = *oldAsts.Spec.Template.DeepCopy()
+	asts.Spec.VolumeClaimTemplates = oldAsts.Spec.VolumeClaimTemplates
+	astsAns := asts.GetAnnotations()
+	astsAns[gameKruiseV1alpha1.AstsHashKey] = astsHash
+	asts.SetAnnotations(astsAns)
+	return manager.client.Update(context.TODO(), asts)
+}
+
+// setInPlaceIfPossible updates the pods of the asts in place, so that the game containers are not restarted.
+func setInPlaceIfPossible(asts *kruiseV1beta1.StatefulSet) {
+	asts.Spec.UpdateStrategy.Type = apps.RollingUpdateStatefulSetStrategyType
+	if asts.Spec.UpdateStrategy.RollingUpdate == nil {
+		asts.Spec.UpdateStrategy.RollingUpdate = &kruiseV1beta1.RollingUpdateStatefulSetStrategy{}
+	}
+	asts.Spec.UpdateStrategy.RollingUpdate.PodUpdatePolicy = kruiseV1beta1.InPlaceIfPossiblePodUpdateStrategyType
+}
+
 func (manager *GameServerSetManager) SyncPodProbeMarker() error {
 	gss := manager.gameServerSet
-	sqs := gss.Spec.ServiceQualities
 	c := manager.client
 	ctx := context.Background()
+	sqs, err := util.GetServiceQualities(gss, c, ctx)
+	if err != nil {
+		return err
+	}
+	// the gRPC and TCP service qualities are probed by the GameServer controller
+	sqs = util.GetExecServiceQualities(sqs)
 
 	// get ppm
 	ppm := &kruiseV1alpha1.PodProbeMarker{}
-	err := c.Get(ctx, types.NamespacedName{
+	err = c.Get(ctx, types.NamespacedName{
 		Namespace: gss.GetNamespace(),
 		Name:      gss.GetName(),
 	}, ppm)
@@ -358,7 +560,7 @@ func (manager *GameServerSetManager) SyncPodProbeMarker() error {
 			}
 			// create ppm
 			manager.eventRecorder.Event(gss, corev1.EventTypeNormal, CreatePPMReason, "create PodProbeMarker")
-			return c.Create(ctx, createPpm(gss))
+			return c.Create(ctx, createPpm(gss, sqs))
 		}
 		return err
 	}
@@ -369,20 +571,33 @@ func (manager *GameServerSetManager) SyncPodProbeMarker() error {
 	}
 
 	// update ppm
-	if util.GetHash(gss.Spec.ServiceQualities) != ppm.GetAnnotations()[gameKruiseV1alpha1.PpmHashKey] {
-		ppm.Spec.Probes = constructProbes(gss)
+	if getPpmHash(gss, sqs) != ppm.GetAnnotations()[gameKruiseV1alpha1.PpmHashKey] {
+		ppm.Spec.Probes = constructProbes(gss, sqs)
 		manager.eventRecorder.Event(gss, corev1.EventTypeNormal, UpdatePPMReason, "update PodProbeMarker")
 		return c.Update(ctx, ppm)
 	}
 	return nil
 }
 
-func constructProbes(gss *gameKruiseV1alpha1.GameServerSet) []kruiseV1alpha1.PodContainerProbe {
+// getPpmHash returns the hash of the probes of the PodProbeMarker. GameContainerName is hashed only if it is set,
+// since it is the default container of the service qualities, so that the hash of the others is kept.
+func getPpmHash(gss *gameKruiseV1alpha1.GameServerSet, sqs []gameKruiseV1alpha1.ServiceQuality) string {
+	if gss.Spec.GameContainerName == "" {
+		return util.GetHash(sqs)
+	}
+	return util.GetHash([]interface{}{sqs, gss.Spec.GameContainerName})
+}
+
+func constructProbes(gss *gameKruiseV1alpha1.GameServerSet, sqs []gameKruiseV1alpha1.ServiceQuality) []kruiseV1alpha1.PodContainerProbe {
 	var probes []kruiseV1alpha1.PodContainerProbe
-	for _, sq := range gss.Spec.ServiceQualities {
+	for _, sq := range sqs {
+		containerName := sq.ContainerName
+		if containerName == "" {
+			containerName = gss.Spec.GameContainerName
+		}
 		probe := kruiseV1alpha1.PodContainerProbe{
 			Name:          sq.Name,
-			ContainerName: sq.ContainerName,
+			ContainerName: containerName,
 			Probe: kruiseV1alpha1.ContainerProbeSpec{
 				Probe: sq.Probe,
 			},
@@ -393,7 +608,7 @@ func constructProbes(gss *gameKruiseV1alpha1.GameServerSet) []kruiseV1alpha1.Pod
 	return probes
 }
 
-func createPpm(gss *gameKruiseV1alpha1.GameServerSet) *kruiseV1alpha1.PodProbeMarker {
+func createPpm(gss *gameKruiseV1alpha1.GameServerSet, sqs []gameKruiseV1alpha1.ServiceQuality) *kruiseV1alpha1.PodProbeMarker {
 	// set owner reference
 	ors := make([]metav1.OwnerReference, 0)
 	or := metav1.OwnerReference{
@@ -410,7 +625,7 @@ func createPpm(gss *gameKruiseV1alpha1.GameServerSet) *kruiseV1alpha1.PodProbeMa
 			Name:      gss.GetName(),
 			Namespace: gss.GetNamespace(),
 			Annotations: map[string]string{
-				gameKruiseV1alpha1.PpmHashKey: util.GetHash(gss.Spec.ServiceQualities),
+				gameKruiseV1alpha1.PpmHashKey: getPpmHash(gss, sqs),
 			},
 			OwnerReferences: ors,
 		},
@@ -418,11 +633,22 @@ func createPpm(gss *gameKruiseV1alpha1.GameServerSet) *kruiseV1alpha1.PodProbeMa
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: gss.GetName()},
 			},
-			Probes: constructProbes(gss),
+			Probes: constructProbes(gss, sqs),
 		},
 	}
 }
 
+// getLabelSelector returns the selector of the pods of the GameServerSet for the scale subresource, which is
+// taken from the spec of the workload, so that it is set before the workload reports its status.
+func getLabelSelector(asts *kruiseV1beta1.StatefulSet) string {
+	if asts.Spec.Selector != nil {
+		if selector, err := metav1.LabelSelectorAsSelector(asts.Spec.Selector); err == nil {
+			return selector.String()
+		}
+	}
+	return asts.Status.LabelSelector
+}
+
 func (manager *GameServerSetManager) SyncStatus() error {
 	gss := manager.gameServerSet
 	asts := manager.asts
@@ -432,12 +658,31 @@ func (manager *GameServerSetManager) SyncStatus() error {
 
 	maintainingGs := 0
 	waitToBeDeletedGs := 0
+	var archReplicas map[string]int32
+	var stateReplicas map[gameKruiseV1alpha1.GameServerState]int32
+	var opsStateReplicas map[gameKruiseV1alpha1.OpsState]int32
 
 	for _, pod := range podList {
 
 		podLabels := pod.GetLabels()
 		opsState := podLabels[gameKruiseV1alpha1.GameServerOpsStateKey]
 
+		// the GameServers per phase
+		if stateReplicas == nil {
+			stateReplicas = make(map[gameKruiseV1alpha1.GameServerState]int32)
+			opsStateReplicas = make(map[gameKruiseV1alpha1.OpsState]int32)
+		}
+		state := gameKruiseV1alpha1.GameServerState(podLabels[gameKruiseV1alpha1.GameServerStateKey])
+		if state == "" {
+			state = gameKruiseV1alpha1.Unknown
+		}
+		stateReplicas[state]++
+		if opsState == "" {
+			opsStateReplicas[gameKruiseV1alpha1.None]++
+		} else {
+			opsStateReplicas[gameKruiseV1alpha1.OpsState(opsState)]++
+		}
+
 		// ops state
 		switch opsState {
 		case string(gameKruiseV1alpha1.WaitToDelete):
@@ -445,6 +690,14 @@ func (manager *GameServerSetManager) SyncStatus() error {
 		case string(gameKruiseV1alpha1.Maintaining):
 			maintainingGs++
 		}
+
+		// architecture sub-pool
+		if arch, ok := podLabels[gameKruiseV1alpha1.GameServerArchKey]; ok {
+			if archReplicas == nil {
+				archReplicas = make(map[string]int32)
+			}
+			archReplicas[arch]++
+		}
 	}
 
 	status := gameKruiseV1alpha1.GameServerSetStatus{
@@ -456,8 +709,20 @@ func (manager *GameServerSetManager) SyncStatus() error {
 		UpdatedReadyReplicas:    asts.Status.UpdatedReadyReplicas,
 		MaintainingReplicas:     ptr.To[int32](int32(maintainingGs)),
 		WaitToBeDeletedReplicas: ptr.To[int32](int32(waitToBeDeletedGs)),
-		LabelSelector:           asts.Status.LabelSelector,
+		LabelSelector:           getLabelSelector(asts),
 		ObservedGeneration:      gss.GetGeneration(),
+		ArchitectureReplicas:    archReplicas,
+		StateReplicas:           stateReplicas,
+		OpsStateReplicas:        opsStateReplicas,
+		LastScaleDownDecision:   gss.Status.LastScaleDownDecision,
+	}
+	now := metav1.Now()
+	status.ProvisioningSLO = computeProvisioningSLO(gss, podList, now.Time)
+	updateFrozen := manager.isTemplateRolloutFrozen()
+	status.Conditions = getGssConditions(gss, asts, &status, podList, updateFrozen, now)
+	if updateFrozen && !isUpdateFrozenCondition(gss.Status.Conditions) {
+		_, remaining := manager.IsUpdateFrozen()
+		manager.eventRecorder.Eventf(gss, corev1.EventTypeWarning, UpdateFrozenReason, "template rollout is blocked by freeze windows, %v remaining", remaining)
 	}
 	if equality.Semantic.DeepEqual(gss.Status, status) {
 		return nil
@@ -11,9 +11,11 @@ import (
 	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
@@ -33,6 +35,7 @@ func init() {
 	utilruntime.Must(kruiseV1beta1.AddToScheme(scheme))
 	utilruntime.Must(kruiseV1alpha1.AddToScheme(scheme))
 	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(networkingv1.AddToScheme(scheme))
 }
 
 func TestComputeToScaleGs(t *testing.T) {
@@ -43,6 +46,7 @@ func TestComputeToScaleGs(t *testing.T) {
 		expectedReplicas      int
 		scaleDownStrategyType gameKruiseV1alpha1.ScaleDownStrategyType
 		pods                  []corev1.Pod
+		scores                map[string]float64
 		newReserveIds         []int
 		newManageIds          []int
 	}{
@@ -357,10 +361,47 @@ func TestComputeToScaleGs(t *testing.T) {
 			newReserveIds:         []int{1, 2},
 			newManageIds:          []int{0, 3, 4},
 		},
+		// GameServers with lower scores are deleted first
+		{
+			newGssReserveIds:      []int{},
+			oldGssreserveIds:      []int{},
+			notExistIds:           []int{},
+			expectedReplicas:      2,
+			scaleDownStrategyType: gameKruiseV1alpha1.GeneralScaleDownStrategyType,
+			pods: []corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "xxx-0",
+						Labels: map[string]string{
+							gameKruiseV1alpha1.GameServerOpsStateKey: string(gameKruiseV1alpha1.None),
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "xxx-1",
+						Labels: map[string]string{
+							gameKruiseV1alpha1.GameServerOpsStateKey: string(gameKruiseV1alpha1.None),
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "xxx-2",
+						Labels: map[string]string{
+							gameKruiseV1alpha1.GameServerOpsStateKey: string(gameKruiseV1alpha1.None),
+						},
+					},
+				},
+			},
+			scores:        map[string]float64{"xxx-0": 1, "xxx-1": 3, "xxx-2": 2},
+			newReserveIds: []int{0},
+			newManageIds:  []int{1, 2},
+		},
 	}
 
 	for i, test := range tests {
-		newManageIds, newReserveIds := computeToScaleGs(test.newGssReserveIds, test.oldGssreserveIds, test.notExistIds, test.expectedReplicas, test.pods, test.scaleDownStrategyType)
+		newManageIds, newReserveIds := computeToScaleGs(test.newGssReserveIds, test.oldGssreserveIds, test.notExistIds, test.expectedReplicas, test.pods, test.scaleDownStrategyType, test.scores)
 		if !util.IsSliceEqual(newReserveIds, test.newReserveIds) {
 			t.Errorf("case %d: expect newNotExistIds %v but got %v", i, test.newReserveIds, newReserveIds)
 		}
@@ -425,6 +466,62 @@ func TestIsNeedToScale(t *testing.T) {
 	}
 }
 
+func TestIsUpdateFrozen(t *testing.T) {
+	allDay := []gameKruiseV1alpha1.UpdateFreezeWindow{
+		{
+			Start: "00:00",
+			End:   "00:00",
+		},
+	}
+	tests := []struct {
+		gss    *gameKruiseV1alpha1.GameServerSet
+		frozen bool
+	}{
+		{
+			gss: &gameKruiseV1alpha1.GameServerSet{
+				Spec: gameKruiseV1alpha1.GameServerSetSpec{
+					Replicas: ptr.To[int32](5),
+				},
+			},
+			frozen: false,
+		},
+		{
+			gss: &gameKruiseV1alpha1.GameServerSet{
+				Spec: gameKruiseV1alpha1.GameServerSetSpec{
+					Replicas: ptr.To[int32](5),
+					UpdateStrategy: gameKruiseV1alpha1.UpdateStrategy{
+						FreezeWindows: allDay,
+					},
+				},
+			},
+			frozen: true,
+		},
+		{
+			gss: &gameKruiseV1alpha1.GameServerSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{gameKruiseV1alpha1.UpdateFreezeOverrideKey: "true"},
+				},
+				Spec: gameKruiseV1alpha1.GameServerSetSpec{
+					Replicas: ptr.To[int32](5),
+					UpdateStrategy: gameKruiseV1alpha1.UpdateStrategy{
+						FreezeWindows: allDay,
+					},
+				},
+			},
+			frozen: false,
+		},
+	}
+	for i, test := range tests {
+		manager := &GameServerSetManager{
+			gameServerSet: test.gss,
+		}
+		frozen, _ := manager.IsUpdateFrozen()
+		if frozen != test.frozen {
+			t.Errorf("case %d: expect frozen %v but got %v", i, test.frozen, frozen)
+		}
+	}
+}
+
 func TestGameServerScale(t *testing.T) {
 	recorder := record.NewFakeRecorder(100)
 
@@ -876,6 +973,81 @@ func TestSyncGameServer(t *testing.T) {
 	}
 }
 
+func TestNewScaleDownDecision(t *testing.T) {
+	now := metav1.Now()
+	newPod := func(name, opsState, deletionPriority string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					gameKruiseV1alpha1.GameServerOpsStateKey:       opsState,
+					gameKruiseV1alpha1.GameServerDeletePriorityKey: deletionPriority,
+				},
+			},
+		}
+	}
+	pods := []corev1.Pod{
+		newPod("xxx-0", string(gameKruiseV1alpha1.None), "0"),
+		newPod("xxx-1", string(gameKruiseV1alpha1.None), "0"),
+		newPod("xxx-2", string(gameKruiseV1alpha1.WaitToDelete), "10"),
+		newPod("xxx-3", "", ""),
+	}
+
+	decision := newScaleDownDecision(pods, []int{0, 3}, []int{1}, 2, map[string]float64{"xxx-2": 0.5}, now)
+	expected := &gameKruiseV1alpha1.ScaleDownDecision{
+		Time:             now,
+		CurrentReplicas:  4,
+		ExpectedReplicas: 2,
+		GameServers: []gameKruiseV1alpha1.ScaleDownGameServer{
+			{
+				Id:      1,
+				Reason:  gameKruiseV1alpha1.ScaleDownReasonReserved,
+				Message: "id is in reserveGameServerIds",
+			},
+			{
+				Id:      2,
+				Reason:  gameKruiseV1alpha1.ScaleDownReasonDeleteSequence,
+				Message: "opsState WaitToBeDeleted, deletionPriority 10, score 0.5",
+			},
+		},
+	}
+	if !reflect.DeepEqual(decision, expected) {
+		t.Errorf("expect decision %v but got %v", expected, decision)
+	}
+
+	// nothing removed when scaling up
+	if decision := newScaleDownDecision(pods, []int{0, 1, 2, 3, 4}, nil, 5, nil, now); decision != nil {
+		t.Errorf("expect no decision but got %v", decision)
+	}
+}
+
+func TestGetReservedKilledIds(t *testing.T) {
+	newPod := func(name, opsState, reserveWhenKilled string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					gameKruiseV1alpha1.GameServerOpsStateKey:          opsState,
+					gameKruiseV1alpha1.GameServerReserveWhenKilledKey: reserveWhenKilled,
+				},
+			},
+		}
+	}
+	pods := []corev1.Pod{
+		newPod("xxx-0", string(gameKruiseV1alpha1.None), "true"),
+		newPod("xxx-1", string(gameKruiseV1alpha1.Kill), "true"),
+		newPod("xxx-2", string(gameKruiseV1alpha1.Kill), ""),
+		newPod("xxx-3", string(gameKruiseV1alpha1.Kill), "true"),
+		newPod("xxx-4", string(gameKruiseV1alpha1.Kill), "true"),
+	}
+
+	// xxx-3 is still managed, and xxx-4 is already reserved
+	ids := getReservedKilledIds(pods, []int{0, 3, 5}, []int{4})
+	if !reflect.DeepEqual(ids, []int{1}) {
+		t.Errorf("expect ids [1] but got %v", ids)
+	}
+}
+
 func TestNumberToKill(t *testing.T) {
 	now := metav1.Now()
 	tests := []struct {
@@ -1152,7 +1324,7 @@ func TestGameServerSetManager_UpdateWorkload(t *testing.T) {
 			client:        c,
 		}
 
-		if err := manager.UpdateWorkload(); err != nil {
+		if err := manager.UpdateWorkload(nil); err != nil {
 			t.Error(err)
 		}
 
@@ -1169,3 +1341,205 @@ func TestGameServerSetManager_UpdateWorkload(t *testing.T) {
 		}
 	}
 }
+
+func TestGameServerSetManager_UpdateWorkloadExceptTemplate(t *testing.T) {
+	oldGss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case0"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			GameServerTemplate: gameKruiseV1alpha1.GameServerTemplate{
+				PodTemplateSpec: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "game", Image: "game:v1"}}},
+				},
+			},
+		},
+	}
+	asts := util.GetNewAstsFromGss(oldGss.DeepCopy(), &kruiseV1beta1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "xxx",
+			Name:      "case0",
+			Annotations: map[string]string{
+				gameKruiseV1alpha1.AstsHashKey:       util.GetAstsHash(oldGss),
+				gameKruiseV1alpha1.GsTemplateHashKey: util.GetGsTemplateHash(oldGss),
+			},
+		},
+	})
+	// both the template and the scaling strategy are changed during freeze windows
+	gss := oldGss.DeepCopy()
+	gss.Spec.GameServerTemplate.Spec.Containers[0].Image = "game:v2"
+	gss.Spec.ScaleStrategy.MaxUnavailable = ptr.To(intstr.FromInt(2))
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(asts, gss).Build()
+	manager := &GameServerSetManager{
+		gameServerSet: gss,
+		asts:          asts,
+		eventRecorder: record.NewFakeRecorder(100),
+		client:        c,
+	}
+	if !manager.IsNeedToUpdateWorkload() || !manager.IsTemplateChanged() {
+		t.Fatalf("expect the template changed")
+	}
+	if err := manager.UpdateWorkloadExceptTemplate(); err != nil {
+		t.Fatal(err)
+	}
+
+	updateAsts := &kruiseV1beta1.StatefulSet{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: "case0"}, updateAsts); err != nil {
+		t.Fatal(err)
+	}
+	if image := updateAsts.Spec.Template.Spec.Containers[0].Image; image != "game:v1" {
+		t.Errorf("expect the template kept, but actually got image %s", image)
+	}
+	if updateAsts.Spec.ScaleStrategy.MaxUnavailable == nil || updateAsts.Spec.ScaleStrategy.MaxUnavailable.IntValue() != 2 {
+		t.Errorf("expect the scaling strategy updated, but actually got %v", updateAsts.Spec.ScaleStrategy)
+	}
+
+	// the template is rolled out after freeze windows
+	manager.asts = updateAsts
+	if !manager.IsNeedToUpdateWorkload() || !manager.IsTemplateChanged() {
+		t.Errorf("expect the template still to be rolled out")
+	}
+}
+
+func TestGameServerSetManager_HotUpdateWorkload(t *testing.T) {
+	newGss := func(gameImage, configImage string) *gameKruiseV1alpha1.GameServerSet {
+		return &gameKruiseV1alpha1.GameServerSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      "case0",
+			},
+			Spec: gameKruiseV1alpha1.GameServerSetSpec{
+				GameServerTemplate: gameKruiseV1alpha1.GameServerTemplate{
+					PodTemplateSpec: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{Name: "game", Image: gameImage},
+								{Name: "config", Image: configImage},
+							},
+						},
+					},
+				},
+				UpdateStrategy: gameKruiseV1alpha1.UpdateStrategy{
+					RollingUpdate: &gameKruiseV1alpha1.RollingUpdateStatefulSetStrategy{
+						PodUpdatePolicy: kruiseV1beta1.RecreatePodUpdateStrategyType,
+					},
+					HotUpdate: &gameKruiseV1alpha1.HotUpdate{
+						Containers: []string{"config"},
+					},
+				},
+			},
+		}
+	}
+	oldGss := newGss("game:v1", "config:v1")
+	base, _ := util.GetHotUpdateHashes(oldGss)
+
+	tests := []struct {
+		gss          *gameKruiseV1alpha1.GameServerSet
+		updatePolicy kruiseV1beta1.PodUpdateStrategyType
+		hotUpdated   bool
+	}{
+		{
+			gss:          newGss("game:v1", "config:v2"),
+			updatePolicy: kruiseV1beta1.InPlaceIfPossiblePodUpdateStrategyType,
+			hotUpdated:   true,
+		},
+		{
+			gss:          newGss("game:v2", "config:v2"),
+			updatePolicy: kruiseV1beta1.RecreatePodUpdateStrategyType,
+		},
+	}
+
+	for i, test := range tests {
+		asts := util.GetNewAstsFromGss(oldGss.DeepCopy(), &kruiseV1beta1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      "case0",
+				Annotations: map[string]string{
+					gameKruiseV1alpha1.AstsHashKey:          util.GetAstsHash(oldGss),
+					gameKruiseV1alpha1.HotUpdateBaseHashKey: base,
+				},
+			},
+		})
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(asts, test.gss).Build()
+		recorder := record.NewFakeRecorder(100)
+		manager := &GameServerSetManager{
+			gameServerSet: test.gss,
+			asts:          asts,
+			eventRecorder: recorder,
+			client:        c,
+		}
+
+		if err := manager.UpdateWorkload(nil); err != nil {
+			t.Error(err)
+		}
+
+		updateAsts := &kruiseV1beta1.StatefulSet{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: "case0"}, updateAsts); err != nil {
+			t.Error(err)
+		}
+		if updateAsts.Spec.UpdateStrategy.RollingUpdate.PodUpdatePolicy != test.updatePolicy {
+			t.Errorf("case %d: expect pod update policy %s but got %s", i, test.updatePolicy, updateAsts.Spec.UpdateStrategy.RollingUpdate.PodUpdatePolicy)
+		}
+		newBase, revision := util.GetHotUpdateHashes(test.gss)
+		if updateAsts.GetAnnotations()[gameKruiseV1alpha1.HotUpdateBaseHashKey] != newBase {
+			t.Errorf("case %d: expect hot update base hash %s but got %s", i, newBase, updateAsts.GetAnnotations()[gameKruiseV1alpha1.HotUpdateBaseHashKey])
+		}
+		if updateAsts.Spec.Template.GetAnnotations()[gameKruiseV1alpha1.HotUpdateRevisionKey] != revision {
+			t.Errorf("case %d: expect hot update revision %s but got %s", i, revision, updateAsts.Spec.Template.GetAnnotations()[gameKruiseV1alpha1.HotUpdateRevisionKey])
+		}
+		if hotUpdated := len(recorder.Events) != 0; hotUpdated != test.hotUpdated {
+			t.Errorf("case %d: expect hot updated %v but got %v", i, test.hotUpdated, hotUpdated)
+		}
+	}
+}
+
+func TestSyncStatus(t *testing.T) {
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "xxx"},
+		Spec:       gameKruiseV1alpha1.GameServerSetSpec{Replicas: ptr.To[int32](3)},
+	}
+	asts := &kruiseV1beta1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "xxx"},
+		Spec: kruiseV1beta1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "xxx"}},
+		},
+	}
+	newPod := func(name string, state gameKruiseV1alpha1.GameServerState, opsState gameKruiseV1alpha1.OpsState) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      name,
+				Labels: map[string]string{
+					gameKruiseV1alpha1.GameServerStateKey:    string(state),
+					gameKruiseV1alpha1.GameServerOpsStateKey: string(opsState),
+				},
+			},
+		}
+	}
+	pods := []corev1.Pod{
+		newPod("xxx-0", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.Allocated),
+		newPod("xxx-1", gameKruiseV1alpha1.Ready, gameKruiseV1alpha1.None),
+		newPod("xxx-2", gameKruiseV1alpha1.Crash, ""),
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gss).Build()
+	manager := NewGameServerSetManager(gss, asts, pods, c, record.NewFakeRecorder(10))
+	if err := manager.SyncStatus(); err != nil {
+		t.Fatal(err)
+	}
+
+	actual := &gameKruiseV1alpha1.GameServerSet{}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(gss), actual); err != nil {
+		t.Fatal(err)
+	}
+	// the selector is set before the workload reports its status
+	if actual.Status.LabelSelector != gameKruiseV1alpha1.GameServerOwnerGssKey+"=xxx" {
+		t.Errorf("expect label selector %s=xxx but got %s", gameKruiseV1alpha1.GameServerOwnerGssKey, actual.Status.LabelSelector)
+	}
+	expectStates := map[gameKruiseV1alpha1.GameServerState]int32{gameKruiseV1alpha1.Ready: 2, gameKruiseV1alpha1.Crash: 1}
+	if !reflect.DeepEqual(actual.Status.StateReplicas, expectStates) {
+		t.Errorf("expect state replicas %v but got %v", expectStates, actual.Status.StateReplicas)
+	}
+	expectOpsStates := map[gameKruiseV1alpha1.OpsState]int32{gameKruiseV1alpha1.Allocated: 1, gameKruiseV1alpha1.None: 2}
+	if !reflect.DeepEqual(actual.Status.OpsStateReplicas, expectOpsStates) {
+		t.Errorf("expect opsState replicas %v but got %v", expectOpsStates, actual.Status.OpsStateReplicas)
+	}
+}
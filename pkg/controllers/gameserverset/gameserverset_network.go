@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverset
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+const (
+	NetworkDisabledReason = "NetworkDisabled"
+)
+
+// networkDisabledWorkers is the number of pods patched concurrently when the network of the fleet is toggled.
+const networkDisabledWorkers = 16
+
+// SyncNetworkDisabled propagates networkDisabled of the GameServerSet to the pods of all its GameServers at once,
+// so that the traffic of the whole fleet is cut or restored in one reconciliation instead of one GameServer after another.
+// The network plugins then update the Services of the pods concurrently.
+func (manager *GameServerSetManager) SyncNetworkDisabled() error {
+	gss := manager.gameServerSet
+	fleetDisabled := strconv.FormatBool(gss.Spec.NetworkDisabled)
+
+	var toSync []*corev1.Pod
+	for i := range manager.podList {
+		pod := &manager.podList[i]
+		if pod.GetDeletionTimestamp() == nil && pod.GetLabels()[gameKruiseV1alpha1.GameServerNetworkDisabled] != fleetDisabled {
+			toSync = append(toSync, pod)
+		}
+	}
+	if len(toSync) == 0 {
+		return nil
+	}
+
+	// the GameServers disabled by themselves are kept disabled when the network of the fleet is restored
+	gsDisabled := make(map[string]bool)
+	if !gss.Spec.NetworkDisabled {
+		gsList := &gameKruiseV1alpha1.GameServerList{}
+		if err := manager.client.List(context.Background(), gsList, client.InNamespace(gss.GetNamespace()), client.MatchingLabels{
+			gameKruiseV1alpha1.GameServerOwnerGssKey: gss.GetName(),
+		}); err != nil {
+			return err
+		}
+		for _, gs := range gsList.Items {
+			gsDisabled[gs.GetName()] = gs.Spec.NetworkDisabled
+		}
+	}
+
+	var mu sync.Mutex
+	var errs []error
+	synced := 0
+	workqueue.ParallelizeUntil(context.Background(), networkDisabledWorkers, len(toSync), func(i int) {
+		pod := toSync[i]
+		value := strconv.FormatBool(gss.Spec.NetworkDisabled || gsDisabled[pod.GetName()])
+		if pod.GetLabels()[gameKruiseV1alpha1.GameServerNetworkDisabled] == value {
+			return
+		}
+		patch := fmt.Sprintf(`{"metadata":{"labels":{"%s":"%s"}}}`, gameKruiseV1alpha1.GameServerNetworkDisabled, value)
+		err := manager.client.Patch(context.Background(), pod, client.RawPatch(types.MergePatchType, []byte(patch)))
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil && !errors.IsNotFound(err) {
+			klog.Errorf("failed to patch Pod %s in %s,because of %s.", pod.GetName(), pod.GetNamespace(), err.Error())
+			errs = append(errs, err)
+			return
+		}
+		synced++
+	})
+
+	if synced > 0 {
+		action := "enabled"
+		if gss.Spec.NetworkDisabled {
+			action = "disabled"
+		}
+		manager.eventRecorder.Eventf(gss, corev1.EventTypeNormal, NetworkDisabledReason, "network of %d GameServers %s", synced, action)
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverset
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestSyncNetworkDisabled(t *testing.T) {
+	tests := []struct {
+		fleetDisabled bool
+		podLabels     map[string]string
+		gsDisabled    map[string]bool
+		expect        map[string]string
+	}{
+		// cut the traffic of the fleet
+		{
+			fleetDisabled: true,
+			podLabels:     map[string]string{"case-0": "false", "case-1": "", "case-2": "true"},
+			expect:        map[string]string{"case-0": "true", "case-1": "true", "case-2": "true"},
+		},
+		// restore the traffic of the fleet, except the GameServer disabled by itself
+		{
+			fleetDisabled: false,
+			podLabels:     map[string]string{"case-0": "true", "case-1": "true", "case-2": "true"},
+			gsDisabled:    map[string]bool{"case-1": true},
+			expect:        map[string]string{"case-0": "false", "case-1": "true", "case-2": "false"},
+		},
+	}
+
+	for i, test := range tests {
+		gss := &gameKruiseV1alpha1.GameServerSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+			Spec:       gameKruiseV1alpha1.GameServerSetSpec{NetworkDisabled: test.fleetDisabled},
+		}
+		var objs []client.Object
+		var pods []corev1.Pod
+		for name, value := range test.podLabels {
+			pod := corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "xxx",
+					Name:      name,
+					Labels: map[string]string{
+						gameKruiseV1alpha1.GameServerOwnerGssKey:     "case",
+						gameKruiseV1alpha1.GameServerNetworkDisabled: value,
+					},
+				},
+			}
+			gs := &gameKruiseV1alpha1.GameServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "xxx",
+					Name:      name,
+					Labels:    map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "case"},
+				},
+				Spec: gameKruiseV1alpha1.GameServerSpec{NetworkDisabled: test.gsDisabled[name]},
+			}
+			pods = append(pods, pod)
+			objs = append(objs, pod.DeepCopy(), gs)
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+		manager := &GameServerSetManager{
+			gameServerSet: gss,
+			podList:       pods,
+			client:        c,
+			eventRecorder: record.NewFakeRecorder(10),
+		}
+
+		if err := manager.SyncNetworkDisabled(); err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		for name, expect := range test.expect {
+			pod := &corev1.Pod{}
+			if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: name}, pod); err != nil {
+				t.Errorf("case %d: %s", i, err.Error())
+				continue
+			}
+			if actual := pod.GetLabels()[gameKruiseV1alpha1.GameServerNetworkDisabled]; actual != expect {
+				t.Errorf("case %d: expect pod %s networkDisabled %s, but actually got %s", i, name, expect, actual)
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverset
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+const (
+	ScheduledRestartReason       = "ScheduledRestart"
+	InvalidRestartPolicyReason   = "InvalidRestartPolicy"
+	defaultRestartMaxUnavailable = 1
+)
+
+// restartGameServers restarts the GameServers created before the latest scheduled time of RestartPolicy, if the
+// restart window of the scheduled time is not over, by deleting their pods. The pods drain in the preStop hook,
+// and are recreated by Advanced StatefulSet with the same identities. It returns the duration until the next
+// scheduled time.
+func (r *GameServerSetReconciler) restartGameServers(ctx context.Context, gss *gameKruiseV1alpha1.GameServerSet, pods []corev1.Pod, now time.Time) (time.Duration, error) {
+	policy := gss.Spec.RestartPolicy
+	if policy == nil {
+		return 0, nil
+	}
+	schedule, err := util.ParseCron(policy.Cron)
+	if err != nil {
+		r.recorder.Event(gss, corev1.EventTypeWarning, InvalidRestartPolicyReason, err.Error())
+		return 0, nil
+	}
+	loc, err := time.LoadLocation(policy.TimeZone)
+	if err != nil {
+		r.recorder.Eventf(gss, corev1.EventTypeWarning, InvalidRestartPolicyReason, "invalid time zone %s", policy.TimeZone)
+		return 0, nil
+	}
+
+	now = now.In(loc)
+	var requeueAfter time.Duration
+	if next := schedule.Next(now); !next.IsZero() {
+		requeueAfter = next.Sub(now)
+	}
+	window := time.Duration(gameKruiseV1alpha1.DefaultRestartWindowSeconds) * time.Second
+	if policy.WindowSeconds != nil {
+		window = time.Duration(*policy.WindowSeconds) * time.Second
+	}
+	scheduledTime := schedule.Latest(now, window)
+	if scheduledTime.IsZero() {
+		return requeueAfter, nil
+	}
+
+	maxUnavailable := defaultRestartMaxUnavailable
+	if policy.MaxUnavailable != nil {
+		maxUnavailable, _ = intstr.GetScaledValueFromIntOrPercent(policy.MaxUnavailable, int(*gss.Spec.Replicas), false)
+		if maxUnavailable < 1 {
+			maxUnavailable = 1
+		}
+	}
+	toRestart := getPodsToRestart(pods, scheduledTime, maxUnavailable)
+	for i := range toRestart {
+		pod := &toRestart[i]
+		if err := r.Delete(ctx, pod); err != nil && !errors.IsNotFound(err) {
+			return 0, err
+		}
+		klog.Infof("GameServer %s/%s is restarted as scheduled at %s", pod.GetNamespace(), pod.GetName(), scheduledTime.Format(time.RFC3339))
+		r.recorder.Eventf(gss, corev1.EventTypeNormal, ScheduledRestartReason, "restarted GameServer %s as scheduled at %s", pod.GetName(), scheduledTime.Format(time.RFC3339))
+	}
+	return requeueAfter, nil
+}
+
+// getPodsToRestart returns the pods created before the scheduled time to restart, in the order of their ids. Only the
+// GameServers whose opsState is None are restarted, and the number of the pods being deleted or not ready, including
+// the restarted ones, does not exceed maxUnavailable.
+func getPodsToRestart(pods []corev1.Pod, scheduledTime time.Time, maxUnavailable int) []corev1.Pod {
+	unavailable := 0
+	var candidates []corev1.Pod
+	for _, pod := range pods {
+		_, condition := util.GetPodConditionFromList(pod.Status.Conditions, corev1.PodReady)
+		if pod.GetDeletionTimestamp() != nil || condition == nil || condition.Status != corev1.ConditionTrue {
+			unavailable++
+			continue
+		}
+		if !pod.GetCreationTimestamp().Time.Before(scheduledTime) {
+			continue
+		}
+		opsState := pod.GetLabels()[gameKruiseV1alpha1.GameServerOpsStateKey]
+		if opsState != "" && opsState != string(gameKruiseV1alpha1.None) {
+			continue
+		}
+		candidates = append(candidates, pod)
+	}
+	if unavailable >= maxUnavailable {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return util.GetIndexFromGsName(candidates[i].GetName()) < util.GetIndexFromGsName(candidates[j].GetName())
+	})
+	if len(candidates) > maxUnavailable-unavailable {
+		candidates = candidates[:maxUnavailable-unavailable]
+	}
+	return candidates
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverset
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func newRestartTestPod(name string, created time.Time, opsState gameKruiseV1alpha1.OpsState, ready bool) corev1.Pod {
+	status := corev1.ConditionTrue
+	if !ready {
+		status = corev1.ConditionFalse
+	}
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "xxx",
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+			Labels: map[string]string{
+				gameKruiseV1alpha1.GameServerOwnerGssKey: "case",
+				gameKruiseV1alpha1.GameServerOpsStateKey: string(opsState),
+			},
+		},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+		},
+	}
+}
+
+func TestGetPodsToRestart(t *testing.T) {
+	scheduledTime := time.Date(2024, 6, 15, 4, 0, 0, 0, time.UTC)
+	before := scheduledTime.Add(-24 * time.Hour)
+	after := scheduledTime.Add(time.Minute)
+
+	tests := []struct {
+		pods           []corev1.Pod
+		maxUnavailable int
+		toRestart      []string
+	}{
+		{
+			pods: []corev1.Pod{
+				newRestartTestPod("case-10", before, gameKruiseV1alpha1.None, true),
+				newRestartTestPod("case-2", before, gameKruiseV1alpha1.None, true),
+				newRestartTestPod("case-1", before, gameKruiseV1alpha1.Allocated, true),
+				newRestartTestPod("case-0", after, gameKruiseV1alpha1.None, true),
+				newRestartTestPod("case-3", before, gameKruiseV1alpha1.None, true),
+			},
+			maxUnavailable: 2,
+			toRestart:      []string{"case-2", "case-3"},
+		},
+		{
+			pods: []corev1.Pod{
+				newRestartTestPod("case-0", after, gameKruiseV1alpha1.None, false),
+				newRestartTestPod("case-1", before, gameKruiseV1alpha1.None, true),
+				newRestartTestPod("case-2", before, gameKruiseV1alpha1.None, true),
+			},
+			maxUnavailable: 2,
+			toRestart:      []string{"case-1"},
+		},
+		{
+			pods: []corev1.Pod{
+				newRestartTestPod("case-0", after, gameKruiseV1alpha1.None, false),
+				newRestartTestPod("case-1", before, gameKruiseV1alpha1.None, true),
+			},
+			maxUnavailable: 1,
+			toRestart:      nil,
+		},
+	}
+
+	for i, test := range tests {
+		var actual []string
+		for _, pod := range getPodsToRestart(test.pods, scheduledTime, test.maxUnavailable) {
+			actual = append(actual, pod.GetName())
+		}
+		if !reflect.DeepEqual(actual, test.toRestart) {
+			t.Errorf("case %d: expect to restart %v, but actually got %v", i, test.toRestart, actual)
+		}
+	}
+}
+
+func TestRestartGameServers(t *testing.T) {
+	// 04:30 of the day in Asia/Shanghai
+	now := time.Date(2024, 6, 14, 20, 30, 0, 0, time.UTC)
+	before := now.Add(-time.Hour)
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			Replicas: ptr.To[int32](2),
+			RestartPolicy: &gameKruiseV1alpha1.RestartPolicy{
+				Cron:           "0 4 * * *",
+				TimeZone:       "Asia/Shanghai",
+				MaxUnavailable: ptr.To(intstr.FromString("50%")),
+			},
+		},
+	}
+
+	tests := []struct {
+		windowSeconds *int32
+		requeueAfter  time.Duration
+		restarted     bool
+	}{
+		{
+			windowSeconds: nil,
+			requeueAfter:  23*time.Hour + 30*time.Minute,
+			restarted:     true,
+		},
+		{
+			windowSeconds: ptr.To[int32](600),
+			requeueAfter:  23*time.Hour + 30*time.Minute,
+			restarted:     false,
+		},
+	}
+
+	for i, test := range tests {
+		gss := gss.DeepCopy()
+		gss.Spec.RestartPolicy.WindowSeconds = test.windowSeconds
+		pods := []corev1.Pod{
+			newRestartTestPod("case-0", before, gameKruiseV1alpha1.None, true),
+			newRestartTestPod("case-1", before, gameKruiseV1alpha1.None, true),
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&pods[0], &pods[1]).Build()
+		r := &GameServerSetReconciler{Client: c, recorder: record.NewFakeRecorder(10)}
+		requeueAfter, err := r.restartGameServers(context.TODO(), gss, pods, now)
+		if err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		if requeueAfter != test.requeueAfter {
+			t.Errorf("case %d: expect requeue after %v, but actually got %v", i, test.requeueAfter, requeueAfter)
+		}
+		err = c.Get(context.TODO(), types.NamespacedName{Namespace: "xxx", Name: "case-0"}, &corev1.Pod{})
+		if restarted := errors.IsNotFound(err); restarted != test.restarted {
+			t.Errorf("case %d: expect case-0 restarted %v, but actually got %v", i, test.restarted, restarted)
+		}
+		// only one of the two GameServers is restarted at a time
+		podList := &corev1.PodList{}
+		if err := c.List(context.TODO(), podList, client.InNamespace("xxx")); err != nil {
+			t.Fatal(err)
+		}
+		if len(podList.Items) == 0 {
+			t.Errorf("case %d: expect case-1 not restarted", i)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverset
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+// provisioningSLOResyncInterval is the interval of recomputing ProvisioningSLO of the GameServerSet.
+const provisioningSLOResyncInterval = time.Minute
+
+// computeProvisioningSLO measures the provisioning latency of the GameServers created within the rolling window of
+// ProvisioningSLO, i.e. the duration from the creation of the pod to the time when its network became Ready.
+// The GameServers without network, and the ones whose network is not Ready but still within the threshold, are
+// not counted.
+func computeProvisioningSLO(gss *gameKruiseV1alpha1.GameServerSet, pods []corev1.Pod, now time.Time) *gameKruiseV1alpha1.ProvisioningSLOStatus {
+	slo := gss.Spec.ProvisioningSLO
+	if slo == nil {
+		return nil
+	}
+	threshold := time.Duration(slo.ThresholdSeconds) * time.Second
+	window := time.Duration(gameKruiseV1alpha1.DefaultProvisioningSLOWindowSeconds) * time.Second
+	if slo.WindowSeconds != nil {
+		window = time.Duration(*slo.WindowSeconds) * time.Second
+	}
+
+	status := &gameKruiseV1alpha1.ProvisioningSLOStatus{}
+	for _, pod := range pods {
+		createTime := pod.GetCreationTimestamp().Time
+		annotations := pod.GetAnnotations()
+		if now.Sub(createTime) > window || annotations[gameKruiseV1alpha1.GameServerNetworkType] == "" {
+			continue
+		}
+		readyTime, err := time.Parse(time.RFC3339, annotations[gameKruiseV1alpha1.GameServerNetworkReadyTime])
+		if err != nil {
+			// the network is not Ready yet
+			if now.Sub(createTime) > threshold {
+				status.Total++
+			}
+			continue
+		}
+		status.Total++
+		if readyTime.Sub(createTime) <= threshold {
+			status.WithinThreshold++
+		}
+	}
+	if status.Total > 0 {
+		status.Percentage = fmt.Sprintf("%.2f", float64(status.WithinThreshold)*100/float64(status.Total))
+	}
+	return status
+}
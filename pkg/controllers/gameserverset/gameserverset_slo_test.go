@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gameserverset
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestComputeProvisioningSLO(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	newPod := func(age, latency time.Duration, networkType string) corev1.Pod {
+		pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			Annotations:       map[string]string{},
+		}}
+		if networkType != "" {
+			pod.Annotations[gameKruiseV1alpha1.GameServerNetworkType] = networkType
+		}
+		if latency >= 0 {
+			pod.Annotations[gameKruiseV1alpha1.GameServerNetworkReadyTime] = now.Add(-age + latency).Format(time.RFC3339)
+		}
+		return pod
+	}
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			ProvisioningSLO: &gameKruiseV1alpha1.ProvisioningSLO{ThresholdSeconds: 30, WindowSeconds: ptr.To[int32](600)},
+		},
+	}
+
+	tests := []struct {
+		gss      *gameKruiseV1alpha1.GameServerSet
+		pods     []corev1.Pod
+		expected *gameKruiseV1alpha1.ProvisioningSLOStatus
+	}{
+		{
+			gss:      &gameKruiseV1alpha1.GameServerSet{},
+			pods:     []corev1.Pod{newPod(time.Minute, 10*time.Second, "Kubernetes-HostPort")},
+			expected: nil,
+		},
+		{
+			gss: gss,
+			pods: []corev1.Pod{
+				// ready within the threshold
+				newPod(time.Minute, 10*time.Second, "Kubernetes-HostPort"),
+				newPod(2*time.Minute, 30*time.Second, "Kubernetes-HostPort"),
+				// ready after the threshold
+				newPod(3*time.Minute, time.Minute, "Kubernetes-HostPort"),
+				// not ready after the threshold
+				newPod(time.Minute, -1, "Kubernetes-HostPort"),
+				// not ready but still within the threshold
+				newPod(10*time.Second, -1, "Kubernetes-HostPort"),
+				// out of the window
+				newPod(time.Hour, time.Minute, "Kubernetes-HostPort"),
+				// without network
+				newPod(time.Minute, -1, ""),
+			},
+			expected: &gameKruiseV1alpha1.ProvisioningSLOStatus{Total: 4, WithinThreshold: 2, Percentage: "50.00"},
+		},
+		{
+			gss:      gss,
+			pods:     []corev1.Pod{newPod(10*time.Second, -1, "Kubernetes-HostPort")},
+			expected: &gameKruiseV1alpha1.ProvisioningSLOStatus{},
+		},
+	}
+
+	for i, test := range tests {
+		actual := computeProvisioningSLO(test.gss, test.pods, now)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("case %d: expect %v, but got %v", i, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,493 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepoolupgrade
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	kruiseV1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/readonly"
+	"github.com/openkruise/kruise-game/pkg/util"
+	utildiscovery "github.com/openkruise/kruise-game/pkg/util/discovery"
+)
+
+const (
+	CordonedNodeReason      = "CordonedNode"
+	DrainedGameServerReason = "DrainedGameServer"
+	DrainedFleetReason      = "DrainedFleet"
+
+	defaultUpgradeMaxUnavailable = 1
+	// requeueInterval is how often the progress of the upgrade is checked.
+	requeueInterval = 10 * time.Second
+)
+
+var (
+	controllerKind       = gamekruiseiov1alpha1.SchemeGroupVersion.WithKind("NodePoolUpgrade")
+	concurrentReconciles = 1
+)
+
+func Add(mgr manager.Manager) error {
+	if !utildiscovery.DiscoverGVK(controllerKind) {
+		return nil
+	}
+	return add(mgr, newReconciler(mgr))
+}
+
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &NodePoolUpgradeReconciler{
+		Client:   mgr.GetClient(),
+		recorder: mgr.GetEventRecorderFor("nodepoolupgrade-controller"),
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	klog.Info("Starting NodePoolUpgrade Controller")
+	c, err := controller.New("nodepoolupgrade-controller", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: concurrentReconciles})
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	if err = c.Watch(&source.Kind{Type: &gamekruiseiov1alpha1.NodePoolUpgrade{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+// NodePoolUpgradeReconciler reconciles a NodePoolUpgrade object
+type NodePoolUpgradeReconciler struct {
+	client.Client
+	recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=game.kruise.io,resources=nodepoolupgrades,verbs=get;list;watch
+//+kubebuilder:rbac:groups=game.kruise.io,resources=nodepoolupgrades/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=game.kruise.io,resources=gameservers,verbs=get;list;watch;update
+
+// Reconcile cordons the nodes selected by the NodePoolUpgrade, and moves the GameServers off them fleet by fleet.
+// The GameServers of a fleet are drained by their workloads, which recreate the pods on the other nodes, and the
+// next fleet starts once the replacements are all ready.
+func (r *NodePoolUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	npu := &gamekruiseiov1alpha1.NodePoolUpgrade{}
+	err := r.Get(ctx, req.NamespacedName, npu)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		klog.Errorf("failed to find NodePoolUpgrade %s, because of %s.", req.Name, err.Error())
+		return reconcile.Result{}, err
+	}
+	if npu.GetDeletionTimestamp() != nil || npu.Status.Phase == gamekruiseiov1alpha1.NodePoolUpgradeCompleted || readonly.Enabled() {
+		return reconcile.Result{}, nil
+	}
+
+	newStatus, err := r.upgrade(ctx, npu, time.Now())
+	if err != nil {
+		klog.Errorf("failed to upgrade node pool of NodePoolUpgrade %s, because of %s.", npu.GetName(), err.Error())
+		return reconcile.Result{}, err
+	}
+	newStatus.ObservedGeneration = npu.GetGeneration()
+	if !reflect.DeepEqual(newStatus, npu.Status) {
+		npu.Status = newStatus
+		if err := r.Status().Update(ctx, npu); err != nil {
+			klog.Errorf("failed to update status of NodePoolUpgrade %s, because of %s.", npu.GetName(), err.Error())
+			return reconcile.Result{}, err
+		}
+	}
+	if newStatus.Phase == gamekruiseiov1alpha1.NodePoolUpgradeCompleted || newStatus.Phase == gamekruiseiov1alpha1.NodePoolUpgradePaused {
+		return reconcile.Result{}, nil
+	}
+	return reconcile.Result{RequeueAfter: requeueInterval}, nil
+}
+
+// upgrade makes one step of the upgrade and returns the new status.
+func (r *NodePoolUpgradeReconciler) upgrade(ctx context.Context, npu *gamekruiseiov1alpha1.NodePoolUpgrade, now time.Time) (gamekruiseiov1alpha1.NodePoolUpgradeStatus, error) {
+	status := *npu.Status.DeepCopy()
+
+	nodeNames, err := r.cordonNodes(ctx, npu)
+	if err != nil {
+		return status, err
+	}
+	status.Nodes = int32(len(nodeNames))
+
+	// the GameServers on the nodes, grouped by fleets
+	podList := &corev1.PodList{}
+	ownerGss, _ := labels.NewRequirement(gamekruiseiov1alpha1.GameServerOwnerGssKey, selection.Exists, []string{})
+	if err := r.List(ctx, podList, &client.ListOptions{LabelSelector: labels.NewSelector().Add(*ownerGss)}); err != nil {
+		return status, err
+	}
+	fleetPods := make(map[string][]corev1.Pod)
+	remaining := 0
+	for _, pod := range podList.Items {
+		if !nodeNames[pod.Spec.NodeName] {
+			continue
+		}
+		fleet := pod.GetNamespace() + "/" + pod.GetLabels()[gamekruiseiov1alpha1.GameServerOwnerGssKey]
+		fleetPods[fleet] = append(fleetPods[fleet], pod)
+		remaining++
+	}
+	status.RemainingGameServers = int32(remaining)
+
+	if npu.Spec.Paused {
+		status.Phase = gamekruiseiov1alpha1.NodePoolUpgradePaused
+		status.Message = "the upgrade is paused"
+		return status, nil
+	}
+
+	// the current fleet is kept until its replacements are ready, though it has no GameServers on the nodes
+	fleet := status.CurrentGameServerSet
+	if fleet == "" {
+		fleet = nextFleet(npu.Spec.GameServerSets, fleetPods, status.CompletedGameServerSets)
+	}
+	if fleet == "" {
+		status.Phase = gamekruiseiov1alpha1.NodePoolUpgradeCompleted
+		status.CurrentGameServerSet = ""
+		status.CurrentStartTime = nil
+		status.Message = fmt.Sprintf("all GameServers have left the %d nodes", len(nodeNames))
+		return status, nil
+	}
+	if fleet != status.CurrentGameServerSet {
+		status.CurrentGameServerSet = fleet
+		status.CurrentStartTime = &metav1.Time{Time: now}
+	}
+
+	gss := &gamekruiseiov1alpha1.GameServerSet{}
+	namespace, name := splitFleet(fleet)
+	err = r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, gss)
+	if err != nil && !errors.IsNotFound(err) {
+		return status, err
+	}
+	if errors.IsNotFound(err) {
+		// the pods are deleted along with the GameServerSet
+		if len(fleetPods[fleet]) == 0 {
+			r.completeFleet(npu, &status, fleet)
+			return status, nil
+		}
+		status.Phase = gamekruiseiov1alpha1.NodePoolUpgradeDraining
+		status.Message = fmt.Sprintf("waiting for the GameServers of the deleted GameServerSet %s to leave the nodes", fleet)
+		return status, nil
+	}
+
+	allPods := &corev1.PodList{}
+	if err := r.List(ctx, allPods, client.InNamespace(namespace), client.MatchingLabels{gamekruiseiov1alpha1.GameServerOwnerGssKey: name}); err != nil {
+		return status, err
+	}
+	if err := r.restoreGameServers(ctx, npu, allPods.Items, nodeNames); err != nil {
+		return status, err
+	}
+
+	if len(fleetPods[fleet]) == 0 {
+		// verify the replacement capacity before the next fleet
+		replicas := int32(0)
+		if gss.Spec.Replicas != nil {
+			replicas = *gss.Spec.Replicas
+		}
+		if gss.Status.ReadyReplicas < replicas {
+			status.Phase = gamekruiseiov1alpha1.NodePoolUpgradeWaitingForCapacity
+			status.Message = fmt.Sprintf("waiting for GameServerSet %s to be ready, %d/%d ready", fleet, gss.Status.ReadyReplicas, replicas)
+			return status, nil
+		}
+		r.completeFleet(npu, &status, fleet)
+		return status, nil
+	}
+
+	status.Phase = gamekruiseiov1alpha1.NodePoolUpgradeDraining
+	forced := isDrainTimeout(npu.Spec.DrainPolicy, status.CurrentStartTime.Time, now)
+	toDrain, waiting := getPodsToDrain(allPods.Items, nodeNames, getMaxUnavailable(npu.Spec.MaxUnavailable, gss), forced)
+	for i := range toDrain {
+		pod := &toDrain[i]
+		drained, err := r.drainGameServer(ctx, npu, pod, forced)
+		if err != nil {
+			return status, err
+		}
+		if !drained {
+			continue
+		}
+		klog.Infof("GameServer %s/%s is drained from node %s for NodePoolUpgrade %s", pod.GetNamespace(), pod.GetName(), pod.Spec.NodeName, npu.GetName())
+		r.recorder.Eventf(npu, corev1.EventTypeNormal, DrainedGameServerReason, "drained GameServer %s/%s from node %s", pod.GetNamespace(), pod.GetName(), pod.Spec.NodeName)
+	}
+	status.Message = fmt.Sprintf("draining %d GameServers of GameServerSet %s from the nodes", len(fleetPods[fleet]), fleet)
+	if waiting > 0 {
+		status.Message += fmt.Sprintf(", %d of which are not idle and waited for", waiting)
+	}
+	return status, nil
+}
+
+// drainGameServer moves the GameServer of the pod off the nodes through its workload. The opsState of the GameServer
+// is set to WaitToBeDeleted first, so that it is no longer allocated, and the update fails on conflicts if it is
+// allocated meanwhile. Once the state is confirmed on the pod, the pod is marked to be deleted by the workload, which
+// respects the PreDelete lifecycle hooks and recreates the pod with the same identity. It returns true if the pod
+// is marked to be deleted.
+func (r *NodePoolUpgradeReconciler) drainGameServer(ctx context.Context, npu *gamekruiseiov1alpha1.NodePoolUpgrade, pod *corev1.Pod, forced bool) (bool, error) {
+	gs := &gamekruiseiov1alpha1.GameServer{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(pod), gs); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	switch gs.Spec.OpsState {
+	case gamekruiseiov1alpha1.WaitToDelete:
+		// re-check the state on the pod, which is synced from the GameServer
+		if pod.GetLabels()[gamekruiseiov1alpha1.GameServerOpsStateKey] != string(gamekruiseiov1alpha1.WaitToDelete) {
+			return false, nil
+		}
+		patch := client.RawPatch(types.MergePatchType, []byte(fmt.Sprintf(`{"metadata":{"labels":{"%s":"true"}}}`, kruiseV1alpha1.SpecifiedDeleteKey)))
+		if err := r.Patch(ctx, pod, patch); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		return true, nil
+	case gamekruiseiov1alpha1.None, "":
+	case gamekruiseiov1alpha1.Kill:
+		// the GameServer is deleted by its GameServerSet
+		return false, nil
+	default:
+		if !forced {
+			return false, nil
+		}
+	}
+
+	gs.Spec.OpsState = gamekruiseiov1alpha1.WaitToDelete
+	if gs.Annotations == nil {
+		gs.Annotations = make(map[string]string)
+	}
+	gs.Annotations[gamekruiseiov1alpha1.NodePoolUpgradeDrainKey] = npu.GetName()
+	if err := r.Update(ctx, gs); err != nil {
+		if errors.IsConflict(err) || errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return false, nil
+}
+
+// restoreGameServers sets the GameServers drained by the NodePoolUpgrade back to None, once their pods are recreated
+// on the other nodes.
+func (r *NodePoolUpgradeReconciler) restoreGameServers(ctx context.Context, npu *gamekruiseiov1alpha1.NodePoolUpgrade, pods []corev1.Pod, nodeNames map[string]bool) error {
+	for i := range pods {
+		pod := &pods[i]
+		if pod.GetDeletionTimestamp() != nil || pod.Spec.NodeName == "" || nodeNames[pod.Spec.NodeName] {
+			continue
+		}
+		gs := &gamekruiseiov1alpha1.GameServer{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(pod), gs); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if gs.GetAnnotations()[gamekruiseiov1alpha1.NodePoolUpgradeDrainKey] != npu.GetName() {
+			continue
+		}
+		if gs.Spec.OpsState == gamekruiseiov1alpha1.WaitToDelete {
+			gs.Spec.OpsState = gamekruiseiov1alpha1.None
+		}
+		delete(gs.Annotations, gamekruiseiov1alpha1.NodePoolUpgradeDrainKey)
+		if err := r.Update(ctx, gs); err != nil && !errors.IsConflict(err) && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// cordonNodes marks the nodes selected by the NodePoolUpgrade unschedulable, and returns their names.
+func (r *NodePoolUpgradeReconciler) cordonNodes(ctx context.Context, npu *gamekruiseiov1alpha1.NodePoolUpgrade) (map[string]bool, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&npu.Spec.NodeSelector)
+	if err != nil {
+		return nil, err
+	}
+	nodeList := &corev1.NodeList{}
+	if err := r.List(ctx, nodeList, &client.ListOptions{LabelSelector: selector}); err != nil {
+		return nil, err
+	}
+	nodeNames := make(map[string]bool, len(nodeList.Items))
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		nodeNames[node.GetName()] = true
+		if node.Spec.Unschedulable {
+			continue
+		}
+		patch := client.RawPatch(types.MergePatchType, []byte(`{"spec":{"unschedulable":true}}`))
+		if err := r.Patch(ctx, node, patch); err != nil {
+			return nil, err
+		}
+		klog.Infof("Node %s is cordoned for NodePoolUpgrade %s", node.GetName(), npu.GetName())
+		r.recorder.Eventf(npu, corev1.EventTypeNormal, CordonedNodeReason, "cordoned node %s", node.GetName())
+	}
+	return nodeNames, nil
+}
+
+func (r *NodePoolUpgradeReconciler) completeFleet(npu *gamekruiseiov1alpha1.NodePoolUpgrade, status *gamekruiseiov1alpha1.NodePoolUpgradeStatus, fleet string) {
+	status.CompletedGameServerSets = append(status.CompletedGameServerSets, fleet)
+	status.CurrentGameServerSet = ""
+	status.CurrentStartTime = nil
+	status.Message = fmt.Sprintf("GameServerSet %s has left the nodes", fleet)
+	klog.Infof("GameServerSet %s has left the nodes of NodePoolUpgrade %s", fleet, npu.GetName())
+	r.recorder.Eventf(npu, corev1.EventTypeNormal, DrainedFleetReason, "GameServerSet %s has left the nodes and is ready", fleet)
+}
+
+// nextFleet returns the fleet to drain, which is the first of the ordered fleets not completed, or else the first
+// of the other fleets with GameServers on the nodes in the order of namespace and name.
+func nextFleet(ordered []string, fleetPods map[string][]corev1.Pod, completed []string) string {
+	for _, fleet := range ordered {
+		if !util.IsStringInList(fleet, completed) {
+			return fleet
+		}
+	}
+	var others []string
+	for fleet := range fleetPods {
+		if !util.IsStringInList(fleet, completed) && !util.IsStringInList(fleet, ordered) {
+			others = append(others, fleet)
+		}
+	}
+	sort.Strings(others)
+	if len(others) == 0 {
+		return ""
+	}
+	return others[0]
+}
+
+func splitFleet(fleet string) (string, string) {
+	namespace, name, _ := strings.Cut(fleet, "/")
+	return namespace, name
+}
+
+func getMaxUnavailable(maxUnavailable *intstr.IntOrString, gss *gamekruiseiov1alpha1.GameServerSet) int {
+	if maxUnavailable == nil {
+		return defaultUpgradeMaxUnavailable
+	}
+	replicas := 0
+	if gss.Spec.Replicas != nil {
+		replicas = int(*gss.Spec.Replicas)
+	}
+	ret, _ := intstr.GetScaledValueFromIntOrPercent(maxUnavailable, replicas, false)
+	if ret < 1 {
+		ret = 1
+	}
+	return ret
+}
+
+// isDrainTimeout returns true if the GameServers not idle should be deleted by the drain policy.
+func isDrainTimeout(policy *gamekruiseiov1alpha1.UpgradeDrainPolicy, startTime, now time.Time) bool {
+	if policy == nil || policy.Type != gamekruiseiov1alpha1.ForceUpgradeDrainPolicyType {
+		return false
+	}
+	timeout := time.Duration(gamekruiseiov1alpha1.DefaultUpgradeDrainTimeoutSeconds) * time.Second
+	if policy.TimeoutSeconds != nil {
+		timeout = time.Duration(*policy.TimeoutSeconds) * time.Second
+	}
+	return !now.Before(startTime.Add(timeout))
+}
+
+// isIdle returns true if the GameServer of the pod is not serving players, i.e. its opsState is None,
+// WaitToBeDeleted or Kill.
+func isIdle(pod *corev1.Pod) bool {
+	switch gamekruiseiov1alpha1.OpsState(pod.GetLabels()[gamekruiseiov1alpha1.GameServerOpsStateKey]) {
+	case "", gamekruiseiov1alpha1.None, gamekruiseiov1alpha1.WaitToDelete, gamekruiseiov1alpha1.Kill:
+		return true
+	}
+	return false
+}
+
+// getPodsToDrain returns the pods of a fleet on the nodes to drain, idle ones first in the order of their ids, and
+// the number of the pods on the nodes waited for. The pods not idle are only drained if forced and no idle pods are
+// left on the nodes. The number of the pods of the fleet being deleted or not ready, including the drained ones,
+// does not exceed maxUnavailable. The pods being killed are left to their GameServerSet.
+func getPodsToDrain(pods []corev1.Pod, nodeNames map[string]bool, maxUnavailable int, forced bool) ([]corev1.Pod, int) {
+	unavailable := 0
+	var idle, busy []corev1.Pod
+	for _, pod := range pods {
+		if !isAvailable(&pod) {
+			unavailable++
+		}
+		if pod.GetDeletionTimestamp() != nil || !nodeNames[pod.Spec.NodeName] || isSpecifiedDelete(&pod) {
+			continue
+		}
+		if pod.GetLabels()[gamekruiseiov1alpha1.GameServerOpsStateKey] == string(gamekruiseiov1alpha1.Kill) {
+			continue
+		}
+		if isIdle(&pod) {
+			idle = append(idle, pod)
+		} else {
+			busy = append(busy, pod)
+		}
+	}
+	byId := func(pods []corev1.Pod) {
+		sort.Slice(pods, func(i, j int) bool {
+			return util.GetIndexFromGsName(pods[i].GetName()) < util.GetIndexFromGsName(pods[j].GetName())
+		})
+	}
+	byId(idle)
+	byId(busy)
+
+	candidates := idle
+	waiting := len(busy)
+	if len(idle) == 0 && forced {
+		candidates = busy
+		waiting = 0
+	}
+	// the pods not ready are drained regardless of maxUnavailable, since they serve no players
+	var ret []corev1.Pod
+	budget := maxUnavailable - unavailable
+	for _, pod := range candidates {
+		if !isAvailable(&pod) {
+			ret = append(ret, pod)
+			continue
+		}
+		if budget <= 0 {
+			continue
+		}
+		ret = append(ret, pod)
+		budget--
+	}
+	return ret, waiting
+}
+
+// isAvailable returns true if the pod is ready and not being deleted.
+func isAvailable(pod *corev1.Pod) bool {
+	_, condition := util.GetPodConditionFromList(pod.Status.Conditions, corev1.PodReady)
+	return pod.GetDeletionTimestamp() == nil && !isSpecifiedDelete(pod) && condition != nil && condition.Status == corev1.ConditionTrue
+}
+
+// isSpecifiedDelete returns true if the pod is marked to be deleted by its workload.
+func isSpecifiedDelete(pod *corev1.Pod) bool {
+	_, ok := pod.GetLabels()[kruiseV1alpha1.SpecifiedDeleteKey]
+	return ok
+}
@@ -0,0 +1,410 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepoolupgrade
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	kruiseV1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+var (
+	scheme = runtime.NewScheme()
+)
+
+func init() {
+	utilruntime.Must(gameKruiseV1alpha1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+}
+
+func newPod(name, nodeName string, opsState gameKruiseV1alpha1.OpsState, ready bool) corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "xxx",
+			Name:      name,
+			Labels: map[string]string{
+				gameKruiseV1alpha1.GameServerOwnerGssKey: "foo",
+				gameKruiseV1alpha1.GameServerOpsStateKey: string(opsState),
+			},
+		},
+		Spec:   corev1.PodSpec{NodeName: nodeName},
+		Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+	}
+}
+
+func podNames(pods []corev1.Pod) []string {
+	var names []string
+	for _, pod := range pods {
+		names = append(names, pod.GetName())
+	}
+	return names
+}
+
+func TestGetPodsToDrain(t *testing.T) {
+	nodeNames := map[string]bool{"old-0": true, "old-1": true}
+	tests := []struct {
+		pods           []corev1.Pod
+		maxUnavailable int
+		forced         bool
+		toDrain        []string
+		waiting        int
+	}{
+		// idle first, in the order of ids
+		{
+			pods: []corev1.Pod{
+				newPod("foo-2", "old-0", gameKruiseV1alpha1.None, true),
+				newPod("foo-1", "old-1", gameKruiseV1alpha1.Allocated, true),
+				newPod("foo-0", "old-0", gameKruiseV1alpha1.WaitToDelete, true),
+				newPod("foo-3", "new-0", gameKruiseV1alpha1.None, true),
+			},
+			maxUnavailable: 1,
+			toDrain:        []string{"foo-0"},
+			waiting:        1,
+		},
+		// the pods not ready count against maxUnavailable, and are drained if on the nodes
+		{
+			pods: []corev1.Pod{
+				newPod("foo-0", "old-0", gameKruiseV1alpha1.None, true),
+				newPod("foo-1", "old-0", gameKruiseV1alpha1.None, false),
+				newPod("foo-2", "new-0", gameKruiseV1alpha1.None, false),
+			},
+			maxUnavailable: 2,
+			toDrain:        []string{"foo-1"},
+		},
+		// allocated ones are waited for
+		{
+			pods: []corev1.Pod{
+				newPod("foo-0", "old-0", gameKruiseV1alpha1.Allocated, true),
+				newPod("foo-1", "old-1", gameKruiseV1alpha1.Maintaining, true),
+			},
+			maxUnavailable: 2,
+			waiting:        2,
+		},
+		// allocated ones are deleted if forced
+		{
+			pods: []corev1.Pod{
+				newPod("foo-0", "old-0", gameKruiseV1alpha1.Allocated, true),
+				newPod("foo-1", "old-1", gameKruiseV1alpha1.Maintaining, true),
+			},
+			maxUnavailable: 2,
+			forced:         true,
+			toDrain:        []string{"foo-0", "foo-1"},
+		},
+		// idle ones first even if forced
+		{
+			pods: []corev1.Pod{
+				newPod("foo-0", "old-0", gameKruiseV1alpha1.Allocated, true),
+				newPod("foo-1", "old-1", gameKruiseV1alpha1.None, true),
+			},
+			maxUnavailable: 2,
+			forced:         true,
+			toDrain:        []string{"foo-1"},
+			waiting:        1,
+		},
+		// the pods marked to be deleted count against maxUnavailable, and the ones being killed are left to the
+		// GameServerSet
+		{
+			pods: []corev1.Pod{
+				newPod("foo-0", "old-0", gameKruiseV1alpha1.WaitToDelete, true),
+				newPod("foo-1", "old-0", gameKruiseV1alpha1.Kill, true),
+				newPod("foo-2", "old-0", gameKruiseV1alpha1.None, true),
+			},
+			maxUnavailable: 1,
+		},
+	}
+	tests[len(tests)-1].pods[0].Labels[kruiseV1alpha1.SpecifiedDeleteKey] = "true"
+	for i, test := range tests {
+		toDrain, waiting := getPodsToDrain(test.pods, nodeNames, test.maxUnavailable, test.forced)
+		if !reflect.DeepEqual(podNames(toDrain), test.toDrain) || waiting != test.waiting {
+			t.Errorf("case %d: expect %v drained and %d waiting, but actually got %v and %d", i, test.toDrain, test.waiting, podNames(toDrain), waiting)
+		}
+	}
+}
+
+func TestNextFleet(t *testing.T) {
+	fleetPods := map[string][]corev1.Pod{"b/bar": nil, "a/foo": nil, "c/baz": nil}
+	tests := []struct {
+		ordered   []string
+		completed []string
+		fleet     string
+	}{
+		{ordered: []string{"c/baz"}, fleet: "c/baz"},
+		{ordered: []string{"c/baz"}, completed: []string{"c/baz"}, fleet: "a/foo"},
+		{ordered: []string{"d/qux"}, completed: []string{"a/foo"}, fleet: "d/qux"},
+		{completed: []string{"a/foo", "b/bar", "c/baz"}, fleet: ""},
+	}
+	for i, test := range tests {
+		if fleet := nextFleet(test.ordered, fleetPods, test.completed); fleet != test.fleet {
+			t.Errorf("case %d: expect fleet %s, but actually got %s", i, test.fleet, fleet)
+		}
+	}
+}
+
+func newGs(name string, opsState gameKruiseV1alpha1.OpsState) *gameKruiseV1alpha1.GameServer {
+	return &gameKruiseV1alpha1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: name},
+		Spec:       gameKruiseV1alpha1.GameServerSpec{OpsState: opsState},
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	ctx := context.Background()
+	npu := &gameKruiseV1alpha1.NodePoolUpgrade{
+		ObjectMeta: metav1.ObjectMeta{Name: "upgrade"},
+		Spec: gameKruiseV1alpha1.NodePoolUpgradeSpec{
+			NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"pool": "old"}},
+			DrainPolicy: &gameKruiseV1alpha1.UpgradeDrainPolicy{
+				Type:           gameKruiseV1alpha1.ForceUpgradeDrainPolicyType,
+				TimeoutSeconds: ptr.To[int32](60),
+			},
+		},
+	}
+	oldNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "old-0", Labels: map[string]string{"pool": "old"}}}
+	newNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "new-0", Labels: map[string]string{"pool": "new"}}}
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "foo"},
+		Spec:       gameKruiseV1alpha1.GameServerSetSpec{Replicas: ptr.To[int32](2)},
+		Status:     gameKruiseV1alpha1.GameServerSetStatus{ReadyReplicas: 2},
+	}
+	pod0 := newPod("foo-0", "old-0", gameKruiseV1alpha1.None, true)
+	pod1 := newPod("foo-1", "old-0", gameKruiseV1alpha1.Allocated, true)
+	gs0 := newGs("foo-0", gameKruiseV1alpha1.None)
+	gs1 := newGs("foo-1", gameKruiseV1alpha1.Allocated)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(npu, oldNode, newNode, gss, &pod0, &pod1, gs0, gs1).Build()
+	r := &NodePoolUpgradeReconciler{Client: c, recorder: record.NewFakeRecorder(10)}
+
+	// step makes one step of the upgrade, and then syncs the opsState of the GameServers to their pods as the
+	// GameServer controller does, and recreates the pods marked to be deleted on the new node as the workload does.
+	step := func(now time.Time) gameKruiseV1alpha1.NodePoolUpgradeStatus {
+		status, err := r.upgrade(ctx, npu, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		npu.Status = status
+		podList := &corev1.PodList{}
+		if err := c.List(ctx, podList); err != nil {
+			t.Fatal(err)
+		}
+		for i := range podList.Items {
+			pod := &podList.Items[i]
+			gs := &gameKruiseV1alpha1.GameServer{}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(pod), gs); err != nil {
+				t.Fatal(err)
+			}
+			if isSpecifiedDelete(pod) {
+				if err := c.Delete(ctx, pod); err != nil {
+					t.Fatal(err)
+				}
+				recreated := newPod(pod.GetName(), "new-0", gs.Spec.OpsState, true)
+				if err := c.Create(ctx, &recreated); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			pod.Labels[gameKruiseV1alpha1.GameServerOpsStateKey] = string(gs.Spec.OpsState)
+			if err := c.Update(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return status
+	}
+
+	start := time.Now()
+	status := step(start)
+	if status.Phase != gameKruiseV1alpha1.NodePoolUpgradeDraining || status.CurrentGameServerSet != "xxx/foo" || status.Nodes != 1 || status.RemainingGameServers != 2 {
+		t.Errorf("expect draining xxx/foo from 1 node with 2 GameServers, but actually got %+v", status)
+	}
+	node := &corev1.Node{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "old-0"}, node); err != nil || !node.Spec.Unschedulable {
+		t.Errorf("expect node old-0 cordoned, but actually got %v %v", node.Spec.Unschedulable, err)
+	}
+	if err := c.Get(ctx, types.NamespacedName{Name: "new-0"}, node); err != nil || node.Spec.Unschedulable {
+		t.Errorf("expect node new-0 not cordoned, but actually got %v %v", node.Spec.Unschedulable, err)
+	}
+	// the idle GameServer is set to WaitToBeDeleted first, and its pod is kept until the state is confirmed
+	if opsState := getOpsState(t, c, "foo-0"); opsState != gameKruiseV1alpha1.WaitToDelete {
+		t.Errorf("expect GameServer foo-0 WaitToBeDeleted, but actually got %s", opsState)
+	}
+	expectNodes := map[string]string{"foo-0": "old-0", "foo-1": "old-0"}
+	if nodes := listPodNodes(t, c); !reflect.DeepEqual(nodes, expectNodes) {
+		t.Errorf("expect pods on nodes %v, but actually got %v", expectNodes, nodes)
+	}
+
+	// the idle GameServer is drained by the workload, and set back to None on the new node
+	step(start)
+	expectNodes = map[string]string{"foo-0": "new-0", "foo-1": "old-0"}
+	if nodes := listPodNodes(t, c); !reflect.DeepEqual(nodes, expectNodes) {
+		t.Errorf("expect the idle GameServer drained first, and pods on nodes %v, but actually got %v", expectNodes, nodes)
+	}
+	step(start)
+	if opsState := getOpsState(t, c, "foo-0"); opsState != gameKruiseV1alpha1.None {
+		t.Errorf("expect GameServer foo-0 set back to None on the new node, but actually got %s", opsState)
+	}
+
+	// the allocated GameServer is waited for until the timeout
+	step(start.Add(30 * time.Second))
+	if opsState := getOpsState(t, c, "foo-1"); opsState != gameKruiseV1alpha1.Allocated {
+		t.Errorf("expect GameServer foo-1 kept Allocated before the timeout, but actually got %s", opsState)
+	}
+	step(start.Add(time.Minute))
+	step(start.Add(time.Minute))
+	expectNodes = map[string]string{"foo-0": "new-0", "foo-1": "new-0"}
+	if nodes := listPodNodes(t, c); !reflect.DeepEqual(nodes, expectNodes) {
+		t.Errorf("expect the allocated GameServer drained after the timeout, and pods on nodes %v, but actually got %v", expectNodes, nodes)
+	}
+
+	// the replacements are not ready
+	gss.Status.ReadyReplicas = 1
+	if err := c.Status().Update(ctx, gss); err != nil {
+		t.Fatal(err)
+	}
+	status = step(start.Add(time.Minute))
+	if status.Phase != gameKruiseV1alpha1.NodePoolUpgradeWaitingForCapacity {
+		t.Errorf("expect waiting for capacity, but actually got %+v", status)
+	}
+	if opsState := getOpsState(t, c, "foo-1"); opsState != gameKruiseV1alpha1.None {
+		t.Errorf("expect GameServer foo-1 set back to None on the new node, but actually got %s", opsState)
+	}
+
+	gss.Status.ReadyReplicas = 2
+	if err := c.Status().Update(ctx, gss); err != nil {
+		t.Fatal(err)
+	}
+	status = step(start.Add(time.Minute))
+	if !reflect.DeepEqual(status.CompletedGameServerSets, []string{"xxx/foo"}) || status.CurrentGameServerSet != "" {
+		t.Errorf("expect xxx/foo completed, but actually got %+v", status)
+	}
+	status = step(start.Add(time.Minute))
+	if status.Phase != gameKruiseV1alpha1.NodePoolUpgradeCompleted || status.RemainingGameServers != 0 {
+		t.Errorf("expect the upgrade completed, but actually got %+v", status)
+	}
+}
+
+func TestDrainGameServer(t *testing.T) {
+	ctx := context.Background()
+	npu := &gameKruiseV1alpha1.NodePoolUpgrade{ObjectMeta: metav1.ObjectMeta{Name: "upgrade"}}
+	tests := []struct {
+		podOpsState gameKruiseV1alpha1.OpsState
+		gs          *gameKruiseV1alpha1.GameServer
+		forced      bool
+		drained     bool
+		opsState    gameKruiseV1alpha1.OpsState
+	}{
+		{
+			podOpsState: gameKruiseV1alpha1.None,
+			gs:          newGs("foo-0", gameKruiseV1alpha1.None),
+			opsState:    gameKruiseV1alpha1.WaitToDelete,
+		},
+		// the GameServer allocated after the pods are listed is not drained
+		{
+			podOpsState: gameKruiseV1alpha1.None,
+			gs:          newGs("foo-0", gameKruiseV1alpha1.Allocated),
+			opsState:    gameKruiseV1alpha1.Allocated,
+		},
+		{
+			podOpsState: gameKruiseV1alpha1.None,
+			gs:          newGs("foo-0", gameKruiseV1alpha1.Allocated),
+			forced:      true,
+			opsState:    gameKruiseV1alpha1.WaitToDelete,
+		},
+		// the pod is drained once the state is confirmed on it
+		{
+			podOpsState: gameKruiseV1alpha1.None,
+			gs:          newGs("foo-0", gameKruiseV1alpha1.WaitToDelete),
+			opsState:    gameKruiseV1alpha1.WaitToDelete,
+		},
+		{
+			podOpsState: gameKruiseV1alpha1.WaitToDelete,
+			gs:          newGs("foo-0", gameKruiseV1alpha1.WaitToDelete),
+			drained:     true,
+			opsState:    gameKruiseV1alpha1.WaitToDelete,
+		},
+		{
+			podOpsState: gameKruiseV1alpha1.Kill,
+			gs:          newGs("foo-0", gameKruiseV1alpha1.Kill),
+			forced:      true,
+			opsState:    gameKruiseV1alpha1.Kill,
+		},
+		{
+			podOpsState: gameKruiseV1alpha1.None,
+		},
+	}
+	for i, test := range tests {
+		pod := newPod("foo-0", "old-0", test.podOpsState, true)
+		builder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&pod)
+		if test.gs != nil {
+			builder.WithObjects(test.gs)
+		}
+		c := builder.Build()
+		r := &NodePoolUpgradeReconciler{Client: c, recorder: record.NewFakeRecorder(10)}
+		drained, err := r.drainGameServer(ctx, npu, &pod, test.forced)
+		if err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		if err := c.Get(ctx, client.ObjectKeyFromObject(&pod), &pod); err != nil {
+			t.Fatal(err)
+		}
+		if drained != test.drained || isSpecifiedDelete(&pod) != test.drained {
+			t.Errorf("case %d: expect drained %v, but actually got %v with pod labels %v", i, test.drained, drained, pod.GetLabels())
+		}
+		if test.gs == nil {
+			continue
+		}
+		if opsState := getOpsState(t, c, "foo-0"); opsState != test.opsState {
+			t.Errorf("case %d: expect opsState %s, but actually got %s", i, test.opsState, opsState)
+		}
+	}
+}
+
+func getOpsState(t *testing.T, c client.Client, name string) gameKruiseV1alpha1.OpsState {
+	gs := &gameKruiseV1alpha1.GameServer{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "xxx", Name: name}, gs); err != nil {
+		t.Fatal(err)
+	}
+	return gs.Spec.OpsState
+}
+
+func listPodNodes(t *testing.T, c client.Client) map[string]string {
+	podList := &corev1.PodList{}
+	if err := c.List(context.Background(), podList); err != nil {
+		t.Fatal(err)
+	}
+	nodes := make(map[string]string)
+	for _, pod := range podList.Items {
+		nodes[pod.GetName()] = pod.Spec.NodeName
+	}
+	return nodes
+}
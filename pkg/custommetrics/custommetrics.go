@@ -0,0 +1,220 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package custommetrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+const (
+	// GroupVersion is the group version of the custom metrics API served by the manager.
+	GroupVersion = "custom.metrics.k8s.io/v1beta1"
+	// Path is the path of the custom metrics API, to which the APIService of GroupVersion forwards requests.
+	Path = "/apis/" + GroupVersion
+	// Resource is the resource of GameServerSet in the paths of the custom metrics API.
+	Resource = "gameserversets.game.kruise.io"
+)
+
+// The metrics of GameServerSet served by the custom metrics API.
+const (
+	// IdleCountMetric is the number of GameServers whose opsState is None.
+	IdleCountMetric = "gameserver_idle_count"
+	// AllocatedCountMetric is the number of GameServers whose opsState is Allocated.
+	AllocatedCountMetric = "gameserver_allocated_count"
+	// UtilizationMetric is the ratio of Allocated GameServers.
+	UtilizationMetric = "gameserver_utilization"
+)
+
+var metricNames = []string{IdleCountMetric, AllocatedCountMetric, UtilizationMetric}
+
+// MetricValue mirrors the MetricValue of custom.metrics.k8s.io/v1beta1.
+type MetricValue struct {
+	DescribedObject corev1.ObjectReference `json:"describedObject"`
+	MetricName      string                 `json:"metricName"`
+	Timestamp       metav1.Time            `json:"timestamp"`
+	Value           resource.Quantity      `json:"value"`
+	Selector        *metav1.LabelSelector  `json:"selector"`
+}
+
+// MetricValueList mirrors the MetricValueList of custom.metrics.k8s.io/v1beta1.
+type MetricValueList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MetricValue `json:"items"`
+}
+
+// APIResources returns the discovery document of the custom metrics API.
+func APIResources() *metav1.APIResourceList {
+	list := &metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: GroupVersion,
+	}
+	for _, metricName := range metricNames {
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name:       Resource + "/" + metricName,
+			Namespaced: true,
+			Kind:       "MetricValueList",
+			Verbs:      []string{"get"},
+		})
+	}
+	return list
+}
+
+// GetMetrics returns the metric of the GameServerSet name in namespace, or of the GameServerSets matching
+// selector in namespace if name is "*".
+func GetMetrics(ctx context.Context, c client.Reader, namespace, name, metricName string, selector labels.Selector) (*MetricValueList, error) {
+	if !isMetric(metricName) {
+		return nil, errors.NewNotFound(gameKruiseV1alpha1.Resource("gameserversets/"+metricName), name)
+	}
+	var gssList []gameKruiseV1alpha1.GameServerSet
+	if name == "*" {
+		list := &gameKruiseV1alpha1.GameServerSetList{}
+		if err := c.List(ctx, list, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, err
+		}
+		gssList = list.Items
+	} else {
+		gss := &gameKruiseV1alpha1.GameServerSet{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, gss); err != nil {
+			return nil, err
+		}
+		gssList = append(gssList, *gss)
+	}
+
+	list := &MetricValueList{
+		TypeMeta: metav1.TypeMeta{Kind: "MetricValueList", APIVersion: GroupVersion},
+		Items:    make([]MetricValue, 0, len(gssList)),
+	}
+	for _, gss := range gssList {
+		value, err := getMetric(ctx, c, &gss, metricName)
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, MetricValue{
+			DescribedObject: corev1.ObjectReference{
+				Kind:       "GameServerSet",
+				APIVersion: gameKruiseV1alpha1.GroupVersion.String(),
+				Namespace:  gss.GetNamespace(),
+				Name:       gss.GetName(),
+			},
+			MetricName: metricName,
+			Timestamp:  metav1.Now(),
+			Value:      value,
+		})
+	}
+	return list, nil
+}
+
+func getMetric(ctx context.Context, c client.Reader, gss *gameKruiseV1alpha1.GameServerSet, metricName string) (resource.Quantity, error) {
+	podList := &corev1.PodList{}
+	if err := c.List(ctx, podList, client.InNamespace(gss.GetNamespace()), client.MatchingLabels{gameKruiseV1alpha1.GameServerOwnerGssKey: gss.GetName()}); err != nil {
+		return resource.Quantity{}, err
+	}
+	total, idle, allocated := 0, 0, 0
+	for _, pod := range podList.Items {
+		if pod.GetDeletionTimestamp() != nil {
+			continue
+		}
+		total++
+		switch gameKruiseV1alpha1.OpsState(pod.GetLabels()[gameKruiseV1alpha1.GameServerOpsStateKey]) {
+		case gameKruiseV1alpha1.None:
+			idle++
+		case gameKruiseV1alpha1.Allocated:
+			allocated++
+		}
+	}
+
+	switch metricName {
+	case IdleCountMetric:
+		return *resource.NewQuantity(int64(idle), resource.DecimalSI), nil
+	case AllocatedCountMetric:
+		return *resource.NewQuantity(int64(allocated), resource.DecimalSI), nil
+	default:
+		if total == 0 {
+			return *resource.NewQuantity(0, resource.DecimalSI), nil
+		}
+		return *resource.NewMilliQuantity(int64(allocated)*1000/int64(total), resource.DecimalSI), nil
+	}
+}
+
+func isMetric(metricName string) bool {
+	for _, name := range metricNames {
+		if name == metricName {
+			return true
+		}
+	}
+	return false
+}
+
+// NewHandler returns the handler of the custom metrics API, which reads objects from the cache of the client.
+// It serves the discovery document at Path, and the metrics of GameServerSets at
+// Path/namespaces/{namespace}/gameserversets.game.kruise.io/{name}/{metric}.
+func NewHandler(c client.Reader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		subPath := strings.Trim(strings.TrimPrefix(req.URL.Path, Path), "/")
+		if subPath == "" {
+			writeJSON(w, APIResources())
+			return
+		}
+		parts := strings.Split(subPath, "/")
+		if len(parts) != 5 || parts[0] != "namespaces" || parts[2] != Resource {
+			http.Error(w, "the path is not found", http.StatusNotFound)
+			return
+		}
+		selector, err := labels.Parse(req.URL.Query().Get("labelSelector"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		list, err := GetMetrics(req.Context(), c, parts[1], parts[3], parts[4], selector)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			klog.Errorf("failed to get metric %s of GameServerSet %s/%s, because of %s", parts[4], parts[1], parts[3], err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, list)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		klog.Errorf("failed to write custom metrics, because of %s", err.Error())
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package custommetrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+var (
+	scheme = runtime.NewScheme()
+)
+
+func init() {
+	utilruntime.Must(gameKruiseV1alpha1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+}
+
+func TestHandler(t *testing.T) {
+	newGss := func(name string, labels map[string]string) *gameKruiseV1alpha1.GameServerSet {
+		return &gameKruiseV1alpha1.GameServerSet{ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: name, Labels: labels}}
+	}
+	newPod := func(name, gss string, opsState gameKruiseV1alpha1.OpsState) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "xxx",
+			Name:      name,
+			Labels: map[string]string{
+				gameKruiseV1alpha1.GameServerOwnerGssKey: gss,
+				gameKruiseV1alpha1.GameServerOpsStateKey: string(opsState),
+			},
+		}}
+	}
+	objs := []client.Object{
+		newGss("case0", map[string]string{"app": "foo"}),
+		newGss("case1", map[string]string{"app": "bar"}),
+		newPod("case0-0", "case0", gameKruiseV1alpha1.Allocated),
+		newPod("case0-1", "case0", gameKruiseV1alpha1.Allocated),
+		newPod("case0-2", "case0", gameKruiseV1alpha1.Allocated),
+		newPod("case0-3", "case0", gameKruiseV1alpha1.None),
+		newPod("case1-0", "case1", gameKruiseV1alpha1.Maintaining),
+	}
+	h := NewHandler(fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build())
+
+	tests := []struct {
+		path     string
+		status   int
+		expected map[string]string
+	}{
+		{
+			path:     Path + "/namespaces/xxx/gameserversets.game.kruise.io/case0/gameserver_utilization",
+			status:   http.StatusOK,
+			expected: map[string]string{"case0": "750m"},
+		},
+		{
+			path:     Path + "/namespaces/xxx/gameserversets.game.kruise.io/case0/gameserver_idle_count",
+			status:   http.StatusOK,
+			expected: map[string]string{"case0": "1"},
+		},
+		{
+			path:     Path + "/namespaces/xxx/gameserversets.game.kruise.io/*/gameserver_allocated_count",
+			status:   http.StatusOK,
+			expected: map[string]string{"case0": "3", "case1": "0"},
+		},
+		{
+			path:     Path + "/namespaces/xxx/gameserversets.game.kruise.io/*/gameserver_idle_count?labelSelector=app%3Dbar",
+			status:   http.StatusOK,
+			expected: map[string]string{"case1": "0"},
+		},
+		{
+			path:   Path + "/namespaces/xxx/gameserversets.game.kruise.io/case2/gameserver_idle_count",
+			status: http.StatusNotFound,
+		},
+		{
+			path:   Path + "/namespaces/xxx/gameserversets.game.kruise.io/case0/players",
+			status: http.StatusNotFound,
+		},
+		{
+			path:   Path + "/namespaces/xxx/pods/case0-0/gameserver_idle_count",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for i, test := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if w.Code != test.status {
+			t.Errorf("case %d: expect status %d, but got %d", i, test.status, w.Code)
+			continue
+		}
+		if test.status != http.StatusOK {
+			continue
+		}
+		list := &MetricValueList{}
+		if err := json.Unmarshal(w.Body.Bytes(), list); err != nil {
+			t.Errorf("case %d: unexpected error %s", i, err.Error())
+			continue
+		}
+		actual := make(map[string]string, len(list.Items))
+		for _, item := range list.Items {
+			actual[item.DescribedObject.Name] = item.Value.String()
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("case %d: expect %v, but got %v", i, test.expected, actual)
+		}
+	}
+}
+
+func TestHandlerDiscovery(t *testing.T) {
+	h := NewHandler(fake.NewClientBuilder().WithScheme(scheme).Build())
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, Path, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expect status %d, but got %d", http.StatusOK, w.Code)
+	}
+	list := &metav1.APIResourceList{}
+	if err := json.Unmarshal(w.Body.Bytes(), list); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(list, APIResources()) {
+		t.Errorf("expect %v, but got %v", APIResources(), list)
+	}
+}
@@ -15,6 +15,19 @@ import (
 
 const (
 	NoneGameServerMinNumberKey = "minAvailable"
+	// MetricNameKey is the scaler metadata key choosing the metric reported for the GameServerSet.
+	MetricNameKey = "metricName"
+	// TargetSizeKey is the scaler metadata key setting the target value of the metric, default is 1.
+	TargetSizeKey = "targetSize"
+)
+
+const (
+	// GssReplicasMetricName is the desired replicas of the GameServerSet, which is the default metric.
+	GssReplicasMetricName = "gssReplicas"
+	// IdleGameServersMetricName is the number of GameServers whose opsState is None.
+	IdleGameServersMetricName = "idleGameServers"
+	// AllocatedGameServersMetricName is the number of GameServers whose opsState is Allocated.
+	AllocatedGameServersMetricName = "allocatedGameServers"
 )
 
 type ExternalScaler struct {
@@ -49,19 +62,43 @@ func (e *ExternalScaler) StreamIsActive(scaledObject *ScaledObjectRef, epsServer
 }
 
 func (e *ExternalScaler) GetMetricSpec(ctx context.Context, scaledObjectRef *ScaledObjectRef) (*GetMetricSpecResponse, error) {
+	metadata := scaledObjectRef.GetScalerMetadata()
+	metricName, err := parseMetricName(metadata)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	targetSize, err := parseTargetSize(metadata)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
 	return &GetMetricSpecResponse{
 		MetricSpecs: []*MetricSpec{{
-			MetricName: "gssReplicas",
-			TargetSize: int64(1),
+			MetricName: metricName,
+			TargetSize: targetSize,
 		}},
 	}, nil
 }
 
 func (e *ExternalScaler) GetMetrics(ctx context.Context, metricRequest *GetMetricsRequest) (*GetMetricsResponse, error) {
+	metricName, err := parseMetricName(metricRequest.ScaledObjectRef.GetScalerMetadata())
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
 	name := metricRequest.ScaledObjectRef.GetName()
 	ns := metricRequest.ScaledObjectRef.GetNamespace()
+
+	switch metricName {
+	case IdleGameServersMetricName:
+		return e.getOpsStateMetric(ctx, ns, name, metricName, gamekruiseiov1alpha1.None)
+	case AllocatedGameServersMetricName:
+		return e.getOpsStateMetric(ctx, ns, name, metricName, gamekruiseiov1alpha1.Allocated)
+	}
+
 	gss := &gamekruiseiov1alpha1.GameServerSet{}
-	err := e.client.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, gss)
+	err = e.client.Get(ctx, types.NamespacedName{Namespace: ns, Name: name}, gss)
 	if err != nil {
 		klog.Error(err)
 		return nil, err
@@ -93,7 +130,7 @@ func (e *ExternalScaler) GetMetrics(ctx context.Context, metricRequest *GetMetri
 		klog.Infof("GameServerSet %s/%s desire replicas is %d", ns, name, desireReplicas)
 		return &GetMetricsResponse{
 			MetricValues: []*MetricValue{{
-				MetricName:  "gssReplicas",
+				MetricName:  GssReplicasMetricName,
 				MetricValue: int64(desireReplicas),
 			}},
 		}, nil
@@ -122,12 +159,68 @@ func (e *ExternalScaler) GetMetrics(ctx context.Context, metricRequest *GetMetri
 	klog.Infof("GameServerSet %s/%s desire replicas is %d", ns, name, desireReplicas-numWaitToBeDeleted)
 	return &GetMetricsResponse{
 		MetricValues: []*MetricValue{{
-			MetricName:  "gssReplicas",
+			MetricName:  GssReplicasMetricName,
 			MetricValue: int64(desireReplicas - numWaitToBeDeleted),
 		}},
 	}, nil
 }
 
+// getOpsStateMetric reports the number of GameServers of the GameServerSet with the opsState.
+func (e *ExternalScaler) getOpsStateMetric(ctx context.Context, ns, name, metricName string, opsState gamekruiseiov1alpha1.OpsState) (*GetMetricsResponse, error) {
+	isGssOwner, _ := labels.NewRequirement(gamekruiseiov1alpha1.GameServerOwnerGssKey, selection.Equals, []string{name})
+	isOpsState, _ := labels.NewRequirement(gamekruiseiov1alpha1.GameServerOpsStateKey, selection.Equals, []string{string(opsState)})
+	podList := &corev1.PodList{}
+	err := e.client.List(ctx, podList, &client.ListOptions{
+		Namespace: ns,
+		LabelSelector: labels.NewSelector().Add(
+			*isOpsState,
+			*isGssOwner,
+		),
+	})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	num := 0
+	for _, pod := range podList.Items {
+		if pod.GetDeletionTimestamp() == nil {
+			num++
+		}
+	}
+	klog.Infof("GameServerSet %s/%s %s is %d", ns, name, metricName, num)
+	return &GetMetricsResponse{
+		MetricValues: []*MetricValue{{
+			MetricName:  metricName,
+			MetricValue: int64(num),
+		}},
+	}, nil
+}
+
+func parseMetricName(metadata map[string]string) (string, error) {
+	switch metricName := metadata[MetricNameKey]; metricName {
+	case "":
+		return GssReplicasMetricName, nil
+	case GssReplicasMetricName, IdleGameServersMetricName, AllocatedGameServersMetricName:
+		return metricName, nil
+	default:
+		return "", fmt.Errorf("unsupported %s %s, should be one of %s, %s and %s", MetricNameKey, metricName,
+			GssReplicasMetricName, IdleGameServersMetricName, AllocatedGameServersMetricName)
+	}
+}
+
+func parseTargetSize(metadata map[string]string) (int64, error) {
+	value, ok := metadata[TargetSizeKey]
+	if !ok {
+		return 1, nil
+	}
+	targetSize, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || targetSize <= 0 {
+		return 0, fmt.Errorf("%s should be a positive integer, now it is %s", TargetSizeKey, value)
+	}
+	return targetSize, nil
+}
+
 func NewExternalScaler(client client.Client) *ExternalScaler {
 	return &ExternalScaler{
 		client: client,
@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalscaler
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+var (
+	scheme = runtime.NewScheme()
+)
+
+func init() {
+	utilruntime.Must(gameKruiseV1alpha1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+}
+
+func TestGetMetricSpec(t *testing.T) {
+	tests := []struct {
+		metadata   map[string]string
+		metricName string
+		targetSize int64
+		err        bool
+	}{
+		{
+			metadata:   map[string]string{},
+			metricName: GssReplicasMetricName,
+			targetSize: 1,
+		},
+		{
+			metadata:   map[string]string{MetricNameKey: AllocatedGameServersMetricName, TargetSizeKey: "8"},
+			metricName: AllocatedGameServersMetricName,
+			targetSize: 8,
+		},
+		{
+			metadata: map[string]string{MetricNameKey: "players"},
+			err:      true,
+		},
+		{
+			metadata: map[string]string{MetricNameKey: IdleGameServersMetricName, TargetSizeKey: "0"},
+			err:      true,
+		},
+	}
+
+	e := NewExternalScaler(fake.NewClientBuilder().WithScheme(scheme).Build())
+	for i, test := range tests {
+		resp, err := e.GetMetricSpec(context.Background(), &ScaledObjectRef{Name: "foo", Namespace: "xxx", ScalerMetadata: test.metadata})
+		if (err != nil) != test.err {
+			t.Errorf("case %d: expect err %v, but got %v", i, test.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		spec := resp.GetMetricSpecs()[0]
+		if spec.GetMetricName() != test.metricName || spec.GetTargetSize() != test.targetSize {
+			t.Errorf("case %d: expect metric %s with target %d, but got %s with target %d", i, test.metricName, test.targetSize, spec.GetMetricName(), spec.GetTargetSize())
+		}
+	}
+}
+
+func TestGetMetrics(t *testing.T) {
+	var objs []client.Object
+	objs = append(objs, &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "foo"},
+		Spec:       gameKruiseV1alpha1.GameServerSetSpec{Replicas: ptr.To[int32](6)},
+	})
+	opsStates := []gameKruiseV1alpha1.OpsState{
+		gameKruiseV1alpha1.None,
+		gameKruiseV1alpha1.None,
+		gameKruiseV1alpha1.Allocated,
+		gameKruiseV1alpha1.Allocated,
+		gameKruiseV1alpha1.Allocated,
+		gameKruiseV1alpha1.WaitToDelete,
+	}
+	for i, opsState := range opsStates {
+		objs = append(objs, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      "foo-" + strconv.Itoa(i),
+				Labels: map[string]string{
+					gameKruiseV1alpha1.GameServerOwnerGssKey: "foo",
+					gameKruiseV1alpha1.GameServerOpsStateKey: string(opsState),
+				},
+			},
+		})
+	}
+
+	tests := []struct {
+		metadata map[string]string
+		expected int64
+	}{
+		{
+			metadata: map[string]string{},
+			expected: 5,
+		},
+		{
+			metadata: map[string]string{NoneGameServerMinNumberKey: "3"},
+			expected: 7,
+		},
+		{
+			metadata: map[string]string{MetricNameKey: IdleGameServersMetricName},
+			expected: 2,
+		},
+		{
+			metadata: map[string]string{MetricNameKey: AllocatedGameServersMetricName},
+			expected: 3,
+		},
+	}
+
+	e := NewExternalScaler(fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build())
+	for i, test := range tests {
+		resp, err := e.GetMetrics(context.Background(), &GetMetricsRequest{
+			ScaledObjectRef: &ScaledObjectRef{Name: "foo", Namespace: "xxx", ScalerMetadata: test.metadata},
+		})
+		if err != nil {
+			t.Errorf("case %d: unexpected error %s", i, err.Error())
+			continue
+		}
+		if value := resp.GetMetricValues()[0].GetMetricValue(); value != test.expected {
+			t.Errorf("case %d: expect metric value %d, but got %d", i, test.expected, value)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/component-base/featuregate"
+)
+
+const (
+	// Allocator enables the GameServerAllocation controller, which allocates idle GameServers to the requesters.
+	Allocator featuregate.Feature = "Allocator"
+
+	// AutoScaler enables the external scaler server, through which KEDA scales GameServerSets.
+	AutoScaler featuregate.Feature = "AutoScaler"
+
+	// ConnectionPoller enables the poller which reads the active connections of GameServers on their load balancer
+	// listeners from the cloud monitoring service, and writes them to the status of GameServers.
+	ConnectionPoller featuregate.Feature = "ConnectionPoller"
+)
+
+var defaultFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
+	Allocator:        {Default: true, PreRelease: featuregate.Beta},
+	AutoScaler:       {Default: true, PreRelease: featuregate.Beta},
+	ConnectionPoller: {Default: false, PreRelease: featuregate.Alpha},
+}
+
+var (
+	// DefaultMutableFeatureGate is set by the flag --feature-gates of the manager.
+	DefaultMutableFeatureGate = featuregate.NewFeatureGate()
+	// DefaultFeatureGate is used to check whether a feature is enabled.
+	DefaultFeatureGate featuregate.FeatureGate = DefaultMutableFeatureGate
+)
+
+func init() {
+	utilruntime.Must(DefaultMutableFeatureGate.Add(defaultFeatureGates))
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"testing"
+
+	"k8s.io/component-base/featuregate"
+)
+
+func TestFeatureGates(t *testing.T) {
+	tests := []struct {
+		featureGates string
+		expected     map[featuregate.Feature]bool
+		wantErr      bool
+	}{
+		{
+			featureGates: "",
+			expected: map[featuregate.Feature]bool{
+				Allocator:        true,
+				AutoScaler:       true,
+				ConnectionPoller: false,
+			},
+		},
+		{
+			featureGates: "ConnectionPoller=true",
+			expected: map[featuregate.Feature]bool{
+				ConnectionPoller: true,
+			},
+		},
+		{
+			featureGates: "Allocator=true,AutoScaler=false",
+			expected: map[featuregate.Feature]bool{
+				Allocator:  true,
+				AutoScaler: false,
+			},
+		},
+		{
+			featureGates: "Allocator=false",
+			expected: map[featuregate.Feature]bool{
+				Allocator:  false,
+				AutoScaler: true,
+			},
+		},
+		{
+			featureGates: "Unknown=true",
+			wantErr:      true,
+		},
+	}
+
+	for i, test := range tests {
+		gate := featuregate.NewFeatureGate()
+		if err := gate.Add(defaultFeatureGates); err != nil {
+			t.Fatal(err)
+		}
+		err := gate.Set(test.featureGates)
+		if (err != nil) != test.wantErr {
+			t.Errorf("case %d: expect error %v but got %v", i, test.wantErr, err)
+		}
+		for feature, enabled := range test.expected {
+			if gate.Enabled(feature) != enabled {
+				t.Errorf("case %d: expect %s enabled %v but got %v", i, feature, enabled, gate.Enabled(feature))
+			}
+		}
+	}
+}
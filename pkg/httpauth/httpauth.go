@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpauth
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// certFile and keyFile are the certificate and the key serving the HTTP APIs over TLS, which are the ones of the
+	// webhook server in certDir if not set.
+	certFile string
+	keyFile  string
+	certDir  string
+)
+
+func init() {
+	flag.StringVar(&certFile, "api-server-cert-file", "", "The certificate serving the allocation service and the admin APIs over TLS. The certificate of the webhook server is used if empty.")
+	flag.StringVar(&keyFile, "api-server-key-file", "", "The key of the certificate serving the allocation service and the admin APIs over TLS. The key of the webhook server is used if empty.")
+}
+
+// SetDefaultCertDir sets the directory of the webhook certificate, which serves the HTTP APIs if no certificate is set.
+func SetDefaultCertDir(dir string) {
+	certDir = dir
+}
+
+func getCertFiles() (string, string) {
+	if certFile != "" && keyFile != "" {
+		return certFile, keyFile
+	}
+	return filepath.Join(certDir, "tls.crt"), filepath.Join(certDir, "tls.key")
+}
+
+//+kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// Authorize authenticates the bearer token of the request by TokenReview, and checks by SubjectAccessReview whether
+// the user is allowed to do the action of attributes, so that the HTTP APIs are granted by the RBAC of Kubernetes.
+// It returns the HTTP status code with the error if the request is not allowed.
+func Authorize(c client.Client, r *http.Request, attributes *authorizationv1.ResourceAttributes) (int, error) {
+	authorization := r.Header.Get("Authorization")
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	if token == "" || token == authorization {
+		return http.StatusUnauthorized, fmt.Errorf("bearer token is required")
+	}
+	tokenReview := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+	if err := c.Create(r.Context(), tokenReview); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to review the token, because of %s", err.Error())
+	}
+	if !tokenReview.Status.Authenticated {
+		return http.StatusUnauthorized, fmt.Errorf("the token is not authenticated: %s", tokenReview.Status.Error)
+	}
+
+	user := tokenReview.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	accessReview := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:               user.Username,
+			UID:                user.UID,
+			Groups:             user.Groups,
+			Extra:              extra,
+			ResourceAttributes: attributes,
+		},
+	}
+	if err := c.Create(r.Context(), accessReview); err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("failed to review the access, because of %s", err.Error())
+	}
+	if !accessReview.Status.Allowed {
+		scope := "all namespaces"
+		if attributes.Namespace != "" {
+			scope = attributes.Namespace
+		}
+		return http.StatusForbidden, fmt.Errorf("user %s is not allowed to %s %s in %s", user.Username, attributes.Verb, attributes.Resource, scope)
+	}
+	return http.StatusOK, nil
+}
+
+// Authorized returns the handler serving the requests whose users are allowed to do the action of the attributes
+// returned for the request.
+func Authorized(c client.Client, attributes func(r *http.Request) *authorizationv1.ResourceAttributes, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if code, err := Authorize(c, r, attributes(r)); err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Server serves the handler over TLS, with the certificate reloaded when it is renewed, so that the bearer tokens
+// of the requests are not sent in cleartext. It implements manager.Runnable.
+type Server struct {
+	// Name is the name of the APIs in the logs.
+	Name    string
+	Addr    string
+	Handler http.Handler
+	// LeaderElection serves the handler on the elected leader only.
+	LeaderElection bool
+}
+
+// Start implements manager.Runnable.
+func (s *Server) Start(ctx context.Context) error {
+	watcher, err := certwatcher.New(getCertFiles())
+	if err != nil {
+		return fmt.Errorf("failed to load the certificate of %s, because of %s", s.Name, err.Error())
+	}
+	go func() {
+		if err := watcher.Start(ctx); err != nil {
+			klog.Errorf("failed to watch the certificate of %s, because of %s", s.Name, err.Error())
+		}
+	}()
+	server := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s.Handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig: &tls.Config{
+			GetCertificate: watcher.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
+		},
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	klog.Infof("%s is serving on %s", s.Name, s.Addr)
+	if err := server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (s *Server) NeedLeaderElection() bool {
+	return s.LeaderElection
+}
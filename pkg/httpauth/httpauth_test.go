@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpauth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openkruise/kruise-game/pkg/webhook/util/generator"
+	"github.com/openkruise/kruise-game/pkg/webhook/util/writer"
+)
+
+// reviewClient authenticates the tokens as the users of the same names, and allows the user admin only.
+type reviewClient struct {
+	client.Client
+}
+
+func (c *reviewClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	switch review := obj.(type) {
+	case *authenticationv1.TokenReview:
+		review.Status.Authenticated = review.Spec.Token != "invalid"
+		review.Status.User.Username = review.Spec.Token
+	case *authorizationv1.SubjectAccessReview:
+		review.Status.Allowed = review.Spec.User == "admin" && review.Spec.ResourceAttributes.Verb == "list"
+	}
+	return nil
+}
+
+func TestAuthorized(t *testing.T) {
+	handler := Authorized(&reviewClient{}, func(r *http.Request) *authorizationv1.ResourceAttributes {
+		return &authorizationv1.ResourceAttributes{Namespace: r.URL.Query().Get("namespace"), Verb: "list", Resource: "gameservers"}
+	}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	tests := []struct {
+		authorization string
+		code          int
+	}{
+		{authorization: "Bearer admin", code: http.StatusOK},
+		{authorization: "", code: http.StatusUnauthorized},
+		{authorization: "admin", code: http.StatusUnauthorized},
+		{authorization: "Bearer invalid", code: http.StatusUnauthorized},
+		{authorization: "Bearer guest", code: http.StatusForbidden},
+	}
+	for i, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/?namespace=xxx", nil)
+		if test.authorization != "" {
+			req.Header.Set("Authorization", test.authorization)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("case %d: expect code %d, but actually got %d: %s", i, test.code, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	certs, err := (&generator.SelfSignedCertGenerator{}).Generate("localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.WriteCertsToDir(dir, certs); err != nil {
+		t.Fatal(err)
+	}
+	SetDefaultCertDir(dir)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	_ = lis.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := &Server{Name: "test", Addr: addr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})}
+	go func() {
+		if err := s.Start(ctx); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(certs.CACert)
+	httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, ServerName: "localhost"}}}
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = httpClient.Get("https://" + addr); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "ok" {
+		t.Errorf("expect ok, but actually got %s", body)
+	}
+
+	// the plain HTTP requests are not served
+	if resp, err := http.Get("http://" + addr); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			t.Errorf("expect the plain HTTP request rejected")
+		}
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lease leases the GameServers picked by the allocator for a short time, so that the GameServers being
+// allocated are skipped by the other allocations instead of being updated with conflicts. The leases are held
+// by an external backend such as Redis, which takes the writes off the API server during matchmaking storms.
+package lease
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Backend holds the leases of the GameServers.
+type Backend interface {
+	// Acquire leases the GameServer to the holder for ttl. It returns false if the GameServer is leased to
+	// another holder, and renews the lease if the GameServer is already leased to the holder.
+	Acquire(ctx context.Context, key types.NamespacedName, holder string, ttl time.Duration) (bool, error)
+	// Release releases the lease of the GameServer if it is held by the holder.
+	Release(ctx context.Context, key types.NamespacedName, holder string) error
+}
+
+type BackendType string
+
+const (
+	// BackendCRD relies on the optimistic concurrency of the GameServer objects, which means every lease is
+	// acquired, and the allocations picking the same GameServer are resolved by the conflicts of the updates.
+	BackendCRD BackendType = "CRD"
+	// BackendRedis holds the leases in Redis.
+	BackendRedis BackendType = "Redis"
+)
+
+// DefaultRedisKeyPrefix is the default prefix of the keys of the leases in Redis.
+const DefaultRedisKeyPrefix = "kruise-game:lease:"
+
+// Config is the configuration of the backend.
+type Config struct {
+	Backend BackendType
+	// RedisAddress is the host:port of the Redis server.
+	RedisAddress  string
+	RedisPassword string
+	RedisDB       int
+	// RedisKeyPrefix is prepended to the keys of the leases, so that a Redis server can be shared.
+	RedisKeyPrefix string
+}
+
+// New returns the backend configured by the config.
+func New(config *Config) (Backend, error) {
+	switch config.Backend {
+	case "", BackendCRD:
+		return NewCRDBackend(), nil
+	case BackendRedis:
+		if config.RedisAddress == "" {
+			return nil, fmt.Errorf("address of redis lease backend is required")
+		}
+		return newRedisBackend(config), nil
+	default:
+		return nil, fmt.Errorf("lease backend %q is not supported, it should be one of %s and %s", config.Backend, BackendCRD, BackendRedis)
+	}
+}
+
+type crdBackend struct{}
+
+// NewCRDBackend returns the default backend, which keeps no leases.
+func NewCRDBackend() Backend {
+	return crdBackend{}
+}
+
+func (crdBackend) Acquire(context.Context, types.NamespacedName, string, time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (crdBackend) Release(context.Context, types.NamespacedName, string) error {
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lease
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		config *Config
+		valid  bool
+	}{
+		{config: &Config{}, valid: true},
+		{config: &Config{Backend: BackendCRD}, valid: true},
+		{config: &Config{Backend: BackendRedis, RedisAddress: "127.0.0.1:6379"}, valid: true},
+		// address is required by redis
+		{config: &Config{Backend: BackendRedis}, valid: false},
+		{config: &Config{Backend: "Etcd"}, valid: false},
+	}
+	for i, test := range tests {
+		_, err := New(test.config)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got error %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestRedisBackend(t *testing.T) {
+	s := miniredis.RunT(t)
+	b, err := New(&Config{Backend: BackendRedis, RedisAddress: s.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: "xxx", Name: "foo-0"}
+
+	steps := []struct {
+		action string
+		holder string
+		expect bool
+	}{
+		{action: "acquire", holder: "gsa-0", expect: true},
+		// renewed by the same holder
+		{action: "acquire", holder: "gsa-0", expect: true},
+		{action: "acquire", holder: "gsa-1", expect: false},
+		// released only by the holder
+		{action: "release", holder: "gsa-1"},
+		{action: "acquire", holder: "gsa-1", expect: false},
+		{action: "release", holder: "gsa-0"},
+		{action: "acquire", holder: "gsa-1", expect: true},
+		// expired
+		{action: "expire"},
+		{action: "acquire", holder: "gsa-0", expect: true},
+	}
+	for i, step := range steps {
+		switch step.action {
+		case "acquire":
+			acquired, err := b.Acquire(ctx, key, step.holder, time.Minute)
+			if err != nil {
+				t.Fatalf("step %d: %v", i, err)
+			}
+			if acquired != step.expect {
+				t.Errorf("step %d: expect acquired %v, but actually got %v", i, step.expect, acquired)
+			}
+		case "release":
+			if err := b.Release(ctx, key, step.holder); err != nil {
+				t.Fatalf("step %d: %v", i, err)
+			}
+		case "expire":
+			s.FastForward(2 * time.Minute)
+		}
+	}
+	if !s.Exists(DefaultRedisKeyPrefix + "xxx/foo-0") {
+		t.Errorf("expect the lease stored with the default prefix")
+	}
+}
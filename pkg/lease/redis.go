@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lease
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// acquireScript sets the lease if it is absent or held by the holder, and returns 1 if the lease is acquired.
+var acquireScript = redis.NewScript(`
+local holder = redis.call("GET", KEYS[1])
+if holder == false or holder == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// releaseScript deletes the lease only if it is held by the holder.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type redisBackend struct {
+	client *redis.Client
+	prefix string
+}
+
+func newRedisBackend(config *Config) *redisBackend {
+	prefix := config.RedisKeyPrefix
+	if prefix == "" {
+		prefix = DefaultRedisKeyPrefix
+	}
+	return &redisBackend{
+		client: redis.NewClient(&redis.Options{
+			Addr:     config.RedisAddress,
+			Password: config.RedisPassword,
+			DB:       config.RedisDB,
+		}),
+		prefix: prefix,
+	}
+}
+
+func (b *redisBackend) key(key types.NamespacedName) string {
+	return b.prefix + key.Namespace + "/" + key.Name
+}
+
+func (b *redisBackend) Acquire(ctx context.Context, key types.NamespacedName, holder string, ttl time.Duration) (bool, error) {
+	acquired, err := acquireScript.Run(ctx, b.client, []string{b.key(key)}, holder, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return acquired == 1, nil
+}
+
+func (b *redisBackend) Release(ctx context.Context, key types.NamespacedName, holder string) error {
+	return releaseScript.Run(ctx, b.client, []string{b.key(key)}, holder).Err()
+}
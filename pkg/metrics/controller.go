@@ -22,8 +22,11 @@ import (
 	gamekruisev1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
 	kruisegamevisions "github.com/openkruise/kruise-game/pkg/client/informers/externalversions"
 	kruisegamelister "github.com/openkruise/kruise-game/pkg/client/listers/apis/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
+	"reflect"
+	"strconv"
 	"sync"
 )
 
@@ -95,6 +98,8 @@ func (c *Controller) recordGsWhenAdd(obj interface{}) {
 	}
 	GameServerDeletionPriority.WithLabelValues(gs.Name, gs.Namespace).Set(float64(dp))
 	GameServerUpdatePriority.WithLabelValues(gs.Name, gs.Namespace).Set(float64(up))
+	c.recordCustomStatus(gs)
+	recordConnections(gs)
 }
 
 func (c *Controller) recordGsWhenUpdate(oldObj, newObj interface{}) {
@@ -131,6 +136,12 @@ func (c *Controller) recordGsWhenUpdate(oldObj, newObj interface{}) {
 	}
 	GameServerDeletionPriority.WithLabelValues(newGs.Name, newGs.Namespace).Set(float64(newDp))
 	GameServerUpdatePriority.WithLabelValues(newGs.Name, newGs.Namespace).Set(float64(newUp))
+	if !reflect.DeepEqual(oldGs.Status.CustomStatus, newGs.Status.CustomStatus) {
+		c.recordCustomStatus(newGs)
+	}
+	if !reflect.DeepEqual(oldGs.Status.Connections, newGs.Status.Connections) {
+		recordConnections(newGs)
+	}
 }
 
 func (c *Controller) recordGsWhenDelete(obj interface{}) {
@@ -146,6 +157,73 @@ func (c *Controller) recordGsWhenDelete(obj interface{}) {
 	GameServersOpsStateCount.WithLabelValues(opsState).Dec()
 	GameServerDeletionPriority.DeleteLabelValues(gs.Name, gs.Namespace)
 	GameServerUpdatePriority.DeleteLabelValues(gs.Name, gs.Namespace)
+	deleteCustomStatus(gs)
+	GameServerConnections.DeleteLabelValues(gs.Name, gs.Namespace)
+}
+
+// recordCustomStatus replaces the series of the custom status fields of the GameServer.
+// The Number fields are exported as the values of the gauge, and only the String fields carry their values as labels,
+// so that the cardinality is bounded by the values of the String fields.
+func (c *Controller) recordCustomStatus(gs *gamekruisev1alpha1.GameServer) {
+	deleteCustomStatus(gs)
+	if len(gs.Status.CustomStatus) == 0 {
+		return
+	}
+	fieldTypes := c.getCustomStatusFieldTypes(gs)
+	for field, value := range gs.Status.CustomStatus {
+		fieldType, ok := fieldTypes[field]
+		if !ok {
+			// the GameServerSet is not in the cache yet, the values which are numbers are treated as Number fields.
+			fieldType = gamekruisev1alpha1.StringCustomStatusFieldType
+			if _, err := strconv.ParseFloat(value, 64); err == nil {
+				fieldType = gamekruisev1alpha1.NumberCustomStatusFieldType
+			}
+		}
+		if fieldType == gamekruisev1alpha1.NumberCustomStatusFieldType {
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			GameServerCustomStatusNumber.WithLabelValues(gs.Name, gs.Namespace, field).Set(number)
+			continue
+		}
+		GameServerCustomStatus.WithLabelValues(gs.Name, gs.Namespace, field, value).Set(1)
+	}
+}
+
+// getCustomStatusFieldTypes returns the types of the custom status fields declared in the GameServerSet of the GameServer.
+func (c *Controller) getCustomStatusFieldTypes(gs *gamekruisev1alpha1.GameServer) map[string]gamekruisev1alpha1.CustomStatusFieldType {
+	fieldTypes := make(map[string]gamekruisev1alpha1.CustomStatusFieldType)
+	gssName := gs.GetLabels()[gamekruisev1alpha1.GameServerOwnerGssKey]
+	if gssName == "" || c.gameServerSetLister == nil {
+		return fieldTypes
+	}
+	gss, err := c.gameServerSetLister.GameServerSets(gs.Namespace).Get(gssName)
+	if err != nil {
+		return fieldTypes
+	}
+	for _, field := range gss.Spec.CustomStatusFields {
+		fieldType := field.Type
+		if fieldType == "" {
+			fieldType = gamekruisev1alpha1.StringCustomStatusFieldType
+		}
+		fieldTypes[field.Name] = fieldType
+	}
+	return fieldTypes
+}
+
+func deleteCustomStatus(gs *gamekruisev1alpha1.GameServer) {
+	GameServerCustomStatus.DeletePartialMatch(prometheus.Labels{"gsName": gs.Name, "gsNs": gs.Namespace})
+	GameServerCustomStatusNumber.DeletePartialMatch(prometheus.Labels{"gsName": gs.Name, "gsNs": gs.Namespace})
+}
+
+// recordConnections records the connections of the GameServer polled by the connection poller, if any.
+func recordConnections(gs *gamekruisev1alpha1.GameServer) {
+	if gs.Status.Connections == nil {
+		GameServerConnections.DeleteLabelValues(gs.Name, gs.Namespace)
+		return
+	}
+	GameServerConnections.WithLabelValues(gs.Name, gs.Namespace).Set(float64(gs.Status.Connections.Count))
 }
 
 func (c *Controller) recordGssWhenChange(obj interface{}) {
@@ -163,6 +241,30 @@ func (c *Controller) recordGssWhenChange(obj interface{}) {
 	if gss.Status.WaitToBeDeletedReplicas != nil {
 		GameServerSetsReplicasCount.WithLabelValues(gss.Name, gss.Namespace, "waitToBeDeleted").Set(float64(*gss.Status.WaitToBeDeletedReplicas))
 	}
+	recordProvisioningSLO(gss)
+}
+
+// recordProvisioningSLO records the result of the provisioning SLO of the GameServerSet, if any.
+func recordProvisioningSLO(gss *gamekruisev1alpha1.GameServerSet) {
+	slo := gss.Status.ProvisioningSLO
+	if slo == nil {
+		deleteProvisioningSLO(gss)
+		return
+	}
+	GameServerSetProvisioningSLOCount.WithLabelValues(gss.Name, gss.Namespace, "total").Set(float64(slo.Total))
+	GameServerSetProvisioningSLOCount.WithLabelValues(gss.Name, gss.Namespace, "withinThreshold").Set(float64(slo.WithinThreshold))
+	percentage, err := strconv.ParseFloat(slo.Percentage, 64)
+	if err != nil {
+		GameServerSetProvisioningSLOPercentage.DeleteLabelValues(gss.Name, gss.Namespace)
+		return
+	}
+	GameServerSetProvisioningSLOPercentage.WithLabelValues(gss.Name, gss.Namespace).Set(percentage)
+}
+
+func deleteProvisioningSLO(gss *gamekruisev1alpha1.GameServerSet) {
+	GameServerSetProvisioningSLOCount.DeleteLabelValues(gss.Name, gss.Namespace, "total")
+	GameServerSetProvisioningSLOCount.DeleteLabelValues(gss.Name, gss.Namespace, "withinThreshold")
+	GameServerSetProvisioningSLOPercentage.DeleteLabelValues(gss.Name, gss.Namespace)
 }
 
 func (c *Controller) recordGssWhenDelete(obj interface{}) {
@@ -176,6 +278,7 @@ func (c *Controller) recordGssWhenDelete(obj interface{}) {
 	GameServerSetsReplicasCount.DeleteLabelValues(gss.Name, gss.Namespace, "available")
 	GameServerSetsReplicasCount.DeleteLabelValues(gss.Name, gss.Namespace, "maintaining")
 	GameServerSetsReplicasCount.DeleteLabelValues(gss.Name, gss.Namespace, "waitToBeDeleted")
+	deleteProvisioningSLO(gss)
 }
 
 func (c *Controller) Run(ctx context.Context) error {
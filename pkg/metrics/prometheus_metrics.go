@@ -26,8 +26,17 @@ func init() {
 	metrics.Registry.MustRegister(GameServersOpsStateCount)
 	metrics.Registry.MustRegister(GameServersTotal)
 	metrics.Registry.MustRegister(GameServerSetsReplicasCount)
+	metrics.Registry.MustRegister(GameServerSetProvisioningSLOCount)
+	metrics.Registry.MustRegister(GameServerSetProvisioningSLOPercentage)
 	metrics.Registry.MustRegister(GameServerDeletionPriority)
 	metrics.Registry.MustRegister(GameServerUpdatePriority)
+	metrics.Registry.MustRegister(GameServerCustomStatus)
+	metrics.Registry.MustRegister(GameServerCustomStatusNumber)
+	metrics.Registry.MustRegister(GameServerConnections)
+	metrics.Registry.MustRegister(ReconcileStuckSeconds)
+	metrics.Registry.MustRegister(ReconcileStuckTotal)
+	metrics.Registry.MustRegister(CloudAPIThrottledTotal)
+	metrics.Registry.MustRegister(CloudAPIRateLimitWaitSeconds)
 }
 
 var (
@@ -59,6 +68,20 @@ var (
 		},
 		[]string{"gssName", "gssNs", "gsStatus"},
 	)
+	GameServerSetProvisioningSLOCount = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "okg_gameserverset_provisioning_slo_count",
+			Help: "The number of gameservers measured by the provisioning SLO of gameserverset over its window, total or withinThreshold.",
+		},
+		[]string{"gssName", "gssNs", "result"},
+	)
+	GameServerSetProvisioningSLOPercentage = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "okg_gameserverset_provisioning_slo_percentage",
+			Help: "The percentage of gameservers whose network became ready within the threshold of the provisioning SLO of gameserverset.",
+		},
+		[]string{"gssName", "gssNs"},
+	)
 	GameServerDeletionPriority = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "okg_gameserver_deletion_priority",
@@ -73,4 +96,54 @@ var (
 		},
 		[]string{"gsName", "gsNs"},
 	)
+	GameServerCustomStatus = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "okg_gameserver_custom_status",
+			Help: "The custom String status fields of gameserver set by the SDK, with the value as a label.",
+		},
+		[]string{"gsName", "gsNs", "field", "value"},
+	)
+	GameServerCustomStatusNumber = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "okg_gameserver_custom_status_number",
+			Help: "The custom Number status fields of gameserver set by the SDK.",
+		},
+		[]string{"gsName", "gsNs", "field"},
+	)
+	GameServerConnections = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "okg_gameserver_connections",
+			Help: "The active connections of gameserver on its load balancer listeners.",
+		},
+		[]string{"gsName", "gsNs"},
+	)
+	ReconcileStuckSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "okg_reconcile_stuck_seconds",
+			Help: "The seconds for which the stuck object has not converged, with the blocking condition as the reason.",
+		},
+		[]string{"kind", "name", "namespace", "reason"},
+	)
+	ReconcileStuckTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "okg_reconcile_stuck_total",
+			Help: "The total of the objects found stuck by the watchdog.",
+		},
+		[]string{"kind", "reason"},
+	)
+	CloudAPIThrottledTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "okg_cloud_api_throttled_total",
+			Help: "The total of the calls of network plugins throttled by the flow control of the cloud.",
+		},
+		[]string{"plugin", "key"},
+	)
+	CloudAPIRateLimitWaitSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "okg_cloud_api_rate_limit_wait_seconds",
+			Help:    "The seconds for which the calls of network plugins wait for the rate limiter.",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 2, 5},
+		},
+		[]string{"plugin"},
+	)
 )
@@ -0,0 +1,203 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package networkplugin defines the gRPC protocol between kruise-game-manager and the out-of-tree network plugins,
+// which run as sidecars of kruise-game-manager, and the server used to implement the plugins.
+package networkplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/cloudprovider/errors"
+)
+
+const (
+	// CorrelationIDMetadataKey is the key of the gRPC metadata carrying the correlation ID of the call, which is
+	// put into the context passed to the Handler, and read by errors.CorrelationIDFromContext.
+	CorrelationIDMetadataKey = "x-correlation-id"
+	// OperationMetadataKey is the key of the gRPC trailer carrying the failing API operation of the error returned.
+	OperationMetadataKey = "x-plugin-operation"
+)
+
+// NewOutgoingContext returns the context of the call to the plugin, carrying the correlation ID of ctx if any.
+func NewOutgoingContext(ctx context.Context) context.Context {
+	if correlationID := errors.CorrelationIDFromContext(ctx); correlationID != "" {
+		return metadata.AppendToOutgoingContext(ctx, CorrelationIDMetadataKey, correlationID)
+	}
+	return ctx
+}
+
+// newIncomingContext returns the context passed to the Handler, carrying the correlation ID of the call if any.
+func newIncomingContext(ctx context.Context) context.Context {
+	if values := metadata.ValueFromIncomingContext(ctx, CorrelationIDMetadataKey); len(values) != 0 {
+		return errors.NewContextWithCorrelationID(ctx, values[0])
+	}
+	return ctx
+}
+
+// setOperationTrailer sends the failing API operation of the error of the Handler in the trailer.
+func setOperationTrailer(ctx context.Context, err errors.PluginError) {
+	if operation := errors.OperationOf(err); operation != "" {
+		_ = grpc.SetTrailer(ctx, metadata.Pairs(OperationMetadataKey, operation))
+	}
+}
+
+// OperationFromTrailer returns the failing API operation sent in the trailer, or empty if there is none.
+func OperationFromTrailer(trailer metadata.MD) string {
+	if values := trailer.Get(OperationMetadataKey); len(values) != 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// SupportedVersions are the protocol versions supported by this package, from the oldest to the newest.
+var SupportedVersions = []uint32{1}
+
+// NegotiateVersion returns the newest version in both offered and supported.
+func NegotiateVersion(offered, supported []uint32) (uint32, error) {
+	var version uint32
+	for _, o := range offered {
+		for _, s := range supported {
+			if o == s && o > version {
+				version = o
+			}
+		}
+	}
+	if version == 0 {
+		return 0, fmt.Errorf("no protocol version in common, offered %v, supported %v", offered, supported)
+	}
+	return version, nil
+}
+
+// EncodePod encodes the pod in JSON.
+func EncodePod(pod *corev1.Pod) ([]byte, error) {
+	return json.Marshal(pod)
+}
+
+// DecodePod decodes the pod from JSON.
+func DecodePod(data []byte) (*corev1.Pod, error) {
+	pod := &corev1.Pod{}
+	if err := json.Unmarshal(data, pod); err != nil {
+		return nil, err
+	}
+	return pod, nil
+}
+
+// NewError converts the error of the plugin to the message of the protocol.
+func NewError(err errors.PluginError) *Error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Type: string(err.Type()), Message: err.Error()}
+}
+
+// ToPluginError converts the message of the protocol to the error of the plugin.
+func ToPluginError(err *Error) errors.PluginError {
+	if err == nil {
+		return nil
+	}
+	return errors.NewPluginError(errors.PluginErrorType(err.GetType()), "%s", err.GetMessage())
+}
+
+// Handler is implemented by the out-of-tree network plugins, in the same way as the in-tree ones,
+// except that the plugins create their own clients of Kubernetes API.
+type Handler interface {
+	Name() string
+	Alias() string
+	Init(options map[string]string) error
+	OnPodAdded(ctx context.Context, pod *corev1.Pod) (*corev1.Pod, errors.PluginError)
+	OnPodUpdated(ctx context.Context, pod *corev1.Pod) (*corev1.Pod, errors.PluginError)
+	OnPodDeleted(ctx context.Context, pod *corev1.Pod) errors.PluginError
+	// ValidateNetworkConf returns the error parsing the network conf, or nil if it is valid.
+	ValidateNetworkConf(conf []v1alpha1.NetworkConfParams) error
+}
+
+// Server serves the Handler with the protocol. It is registered by RegisterNetworkPluginServer.
+type Server struct {
+	UnimplementedNetworkPluginServer
+	handler Handler
+}
+
+func NewServer(handler Handler) *Server {
+	return &Server{handler: handler}
+}
+
+func (s *Server) Handshake(ctx context.Context, req *HandshakeRequest) (*HandshakeResponse, error) {
+	if req.GetName() != s.handler.Name() {
+		return nil, fmt.Errorf("plugin %s is configured as %s", s.handler.Name(), req.GetName())
+	}
+	version, err := NegotiateVersion(req.GetVersions(), SupportedVersions)
+	if err != nil {
+		return nil, err
+	}
+	return &HandshakeResponse{Version: version, Name: s.handler.Name(), Alias: s.handler.Alias()}, nil
+}
+
+func (s *Server) Init(ctx context.Context, req *InitRequest) (*InitResponse, error) {
+	if err := s.handler.Init(req.GetOptions()); err != nil {
+		return nil, err
+	}
+	return &InitResponse{}, nil
+}
+
+func (s *Server) OnPodAdded(ctx context.Context, req *PodRequest) (*PodResponse, error) {
+	return s.onPod(ctx, req, s.handler.OnPodAdded)
+}
+
+func (s *Server) OnPodUpdated(ctx context.Context, req *PodRequest) (*PodResponse, error) {
+	return s.onPod(ctx, req, s.handler.OnPodUpdated)
+}
+
+func (s *Server) OnPodDeleted(ctx context.Context, req *PodRequest) (*PodResponse, error) {
+	pod, err := DecodePod(req.GetPod())
+	if err != nil {
+		return &PodResponse{Error: NewError(errors.ToPluginError(err, errors.ParameterError))}, nil
+	}
+	pluginErr := s.handler.OnPodDeleted(newIncomingContext(ctx), pod)
+	setOperationTrailer(ctx, pluginErr)
+	return &PodResponse{Error: NewError(pluginErr)}, nil
+}
+
+func (s *Server) onPod(ctx context.Context, req *PodRequest, fn func(context.Context, *corev1.Pod) (*corev1.Pod, errors.PluginError)) (*PodResponse, error) {
+	pod, err := DecodePod(req.GetPod())
+	if err != nil {
+		return &PodResponse{Error: NewError(errors.ToPluginError(err, errors.ParameterError))}, nil
+	}
+	pod, pluginErr := fn(newIncomingContext(ctx), pod)
+	setOperationTrailer(ctx, pluginErr)
+	resp := &PodResponse{Error: NewError(pluginErr)}
+	if pod != nil {
+		if resp.Pod, err = EncodePod(pod); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+func (s *Server) ValidateNetworkConf(ctx context.Context, req *ValidateNetworkConfRequest) (*ValidateNetworkConfResponse, error) {
+	var conf []v1alpha1.NetworkConfParams
+	for _, param := range req.GetNetworkConf() {
+		conf = append(conf, v1alpha1.NetworkConfParams{Name: param.GetName(), Value: param.GetValue()})
+	}
+	return &ValidateNetworkConfResponse{Error: NewError(errors.ToPluginError(s.handler.ValidateNetworkConf(conf), errors.ParameterError))}, nil
+}
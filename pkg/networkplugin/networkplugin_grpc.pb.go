@@ -0,0 +1,295 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v4.22.0--rc2
+// source: networkplugin.proto
+
+package networkplugin
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// NetworkPluginClient is the client API for NetworkPlugin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type NetworkPluginClient interface {
+	// Handshake negotiates the protocol version, and returns the name and alias of the plugin.
+	// It is called before any other method, and again when the connection is re-established.
+	Handshake(ctx context.Context, in *HandshakeRequest, opts ...grpc.CallOption) (*HandshakeResponse, error)
+	// Init is called with the options of the plugin in the config file, after the handshake succeeds
+	// and each time the options change.
+	Init(ctx context.Context, in *InitRequest, opts ...grpc.CallOption) (*InitResponse, error)
+	OnPodAdded(ctx context.Context, in *PodRequest, opts ...grpc.CallOption) (*PodResponse, error)
+	OnPodUpdated(ctx context.Context, in *PodRequest, opts ...grpc.CallOption) (*PodResponse, error)
+	OnPodDeleted(ctx context.Context, in *PodRequest, opts ...grpc.CallOption) (*PodResponse, error)
+	// ValidateNetworkConf is called at the admission of GameServerSets, to reject the invalid network conf.
+	ValidateNetworkConf(ctx context.Context, in *ValidateNetworkConfRequest, opts ...grpc.CallOption) (*ValidateNetworkConfResponse, error)
+}
+
+type networkPluginClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNetworkPluginClient(cc grpc.ClientConnInterface) NetworkPluginClient {
+	return &networkPluginClient{cc}
+}
+
+func (c *networkPluginClient) Handshake(ctx context.Context, in *HandshakeRequest, opts ...grpc.CallOption) (*HandshakeResponse, error) {
+	out := new(HandshakeResponse)
+	err := c.cc.Invoke(ctx, "/networkplugin.NetworkPlugin/Handshake", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *networkPluginClient) Init(ctx context.Context, in *InitRequest, opts ...grpc.CallOption) (*InitResponse, error) {
+	out := new(InitResponse)
+	err := c.cc.Invoke(ctx, "/networkplugin.NetworkPlugin/Init", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *networkPluginClient) OnPodAdded(ctx context.Context, in *PodRequest, opts ...grpc.CallOption) (*PodResponse, error) {
+	out := new(PodResponse)
+	err := c.cc.Invoke(ctx, "/networkplugin.NetworkPlugin/OnPodAdded", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *networkPluginClient) OnPodUpdated(ctx context.Context, in *PodRequest, opts ...grpc.CallOption) (*PodResponse, error) {
+	out := new(PodResponse)
+	err := c.cc.Invoke(ctx, "/networkplugin.NetworkPlugin/OnPodUpdated", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *networkPluginClient) OnPodDeleted(ctx context.Context, in *PodRequest, opts ...grpc.CallOption) (*PodResponse, error) {
+	out := new(PodResponse)
+	err := c.cc.Invoke(ctx, "/networkplugin.NetworkPlugin/OnPodDeleted", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *networkPluginClient) ValidateNetworkConf(ctx context.Context, in *ValidateNetworkConfRequest, opts ...grpc.CallOption) (*ValidateNetworkConfResponse, error) {
+	out := new(ValidateNetworkConfResponse)
+	err := c.cc.Invoke(ctx, "/networkplugin.NetworkPlugin/ValidateNetworkConf", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NetworkPluginServer is the server API for NetworkPlugin service.
+// All implementations must embed UnimplementedNetworkPluginServer
+// for forward compatibility
+type NetworkPluginServer interface {
+	// Handshake negotiates the protocol version, and returns the name and alias of the plugin.
+	// It is called before any other method, and again when the connection is re-established.
+	Handshake(context.Context, *HandshakeRequest) (*HandshakeResponse, error)
+	// Init is called with the options of the plugin in the config file, after the handshake succeeds
+	// and each time the options change.
+	Init(context.Context, *InitRequest) (*InitResponse, error)
+	OnPodAdded(context.Context, *PodRequest) (*PodResponse, error)
+	OnPodUpdated(context.Context, *PodRequest) (*PodResponse, error)
+	OnPodDeleted(context.Context, *PodRequest) (*PodResponse, error)
+	// ValidateNetworkConf is called at the admission of GameServerSets, to reject the invalid network conf.
+	ValidateNetworkConf(context.Context, *ValidateNetworkConfRequest) (*ValidateNetworkConfResponse, error)
+	mustEmbedUnimplementedNetworkPluginServer()
+}
+
+// UnimplementedNetworkPluginServer must be embedded to have forward compatible implementations.
+type UnimplementedNetworkPluginServer struct {
+}
+
+func (UnimplementedNetworkPluginServer) Handshake(context.Context, *HandshakeRequest) (*HandshakeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Handshake not implemented")
+}
+func (UnimplementedNetworkPluginServer) Init(context.Context, *InitRequest) (*InitResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Init not implemented")
+}
+func (UnimplementedNetworkPluginServer) OnPodAdded(context.Context, *PodRequest) (*PodResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OnPodAdded not implemented")
+}
+func (UnimplementedNetworkPluginServer) OnPodUpdated(context.Context, *PodRequest) (*PodResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OnPodUpdated not implemented")
+}
+func (UnimplementedNetworkPluginServer) OnPodDeleted(context.Context, *PodRequest) (*PodResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OnPodDeleted not implemented")
+}
+func (UnimplementedNetworkPluginServer) ValidateNetworkConf(context.Context, *ValidateNetworkConfRequest) (*ValidateNetworkConfResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateNetworkConf not implemented")
+}
+func (UnimplementedNetworkPluginServer) mustEmbedUnimplementedNetworkPluginServer() {}
+
+// UnsafeNetworkPluginServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NetworkPluginServer will
+// result in compilation errors.
+type UnsafeNetworkPluginServer interface {
+	mustEmbedUnimplementedNetworkPluginServer()
+}
+
+func RegisterNetworkPluginServer(s grpc.ServiceRegistrar, srv NetworkPluginServer) {
+	s.RegisterService(&NetworkPlugin_ServiceDesc, srv)
+}
+
+func _NetworkPlugin_Handshake_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HandshakeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkPluginServer).Handshake(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/networkplugin.NetworkPlugin/Handshake",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkPluginServer).Handshake(ctx, req.(*HandshakeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NetworkPlugin_Init_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkPluginServer).Init(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/networkplugin.NetworkPlugin/Init",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkPluginServer).Init(ctx, req.(*InitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NetworkPlugin_OnPodAdded_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkPluginServer).OnPodAdded(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/networkplugin.NetworkPlugin/OnPodAdded",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkPluginServer).OnPodAdded(ctx, req.(*PodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NetworkPlugin_OnPodUpdated_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkPluginServer).OnPodUpdated(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/networkplugin.NetworkPlugin/OnPodUpdated",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkPluginServer).OnPodUpdated(ctx, req.(*PodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NetworkPlugin_OnPodDeleted_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkPluginServer).OnPodDeleted(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/networkplugin.NetworkPlugin/OnPodDeleted",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkPluginServer).OnPodDeleted(ctx, req.(*PodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NetworkPlugin_ValidateNetworkConf_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateNetworkConfRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NetworkPluginServer).ValidateNetworkConf(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/networkplugin.NetworkPlugin/ValidateNetworkConf",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NetworkPluginServer).ValidateNetworkConf(ctx, req.(*ValidateNetworkConfRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// NetworkPlugin_ServiceDesc is the grpc.ServiceDesc for NetworkPlugin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var NetworkPlugin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "networkplugin.NetworkPlugin",
+	HandlerType: (*NetworkPluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Handshake",
+			Handler:    _NetworkPlugin_Handshake_Handler,
+		},
+		{
+			MethodName: "Init",
+			Handler:    _NetworkPlugin_Init_Handler,
+		},
+		{
+			MethodName: "OnPodAdded",
+			Handler:    _NetworkPlugin_OnPodAdded_Handler,
+		},
+		{
+			MethodName: "OnPodUpdated",
+			Handler:    _NetworkPlugin_OnPodUpdated_Handler,
+		},
+		{
+			MethodName: "OnPodDeleted",
+			Handler:    _NetworkPlugin_OnPodDeleted_Handler,
+		},
+		{
+			MethodName: "ValidateNetworkConf",
+			Handler:    _NetworkPlugin_ValidateNetworkConf_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "networkplugin.proto",
+}
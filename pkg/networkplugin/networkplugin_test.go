@@ -0,0 +1,54 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networkplugin
+
+import (
+	"testing"
+
+	"github.com/openkruise/kruise-game/cloudprovider/errors"
+)
+
+func TestNegotiateVersion(t *testing.T) {
+	tests := []struct {
+		offered   []uint32
+		supported []uint32
+		expected  uint32
+		hasError  bool
+	}{
+		{offered: []uint32{1}, supported: []uint32{1}, expected: 1},
+		{offered: []uint32{1, 2}, supported: []uint32{1, 2, 3}, expected: 2},
+		{offered: []uint32{2, 3}, supported: []uint32{1, 2}, expected: 2},
+		{offered: []uint32{2}, supported: []uint32{1}, hasError: true},
+		{offered: nil, supported: []uint32{1}, hasError: true},
+	}
+	for i, test := range tests {
+		actual, err := NegotiateVersion(test.offered, test.supported)
+		if (err != nil) != test.hasError || actual != test.expected {
+			t.Errorf("case %d: expect %d and error %v, but actually got %d and %v", i, test.expected, test.hasError, actual, err)
+		}
+	}
+}
+
+func TestPluginErrorConversion(t *testing.T) {
+	if NewError(nil) != nil || ToPluginError(nil) != nil {
+		t.Errorf("expect nil errors to be converted to nil")
+	}
+	err := ToPluginError(NewError(errors.NewPluginError(errors.ParameterError, "invalid port %s", "70000%")))
+	if err.Type() != errors.ParameterError || err.Error() != "invalid port 70000%" {
+		t.Errorf("unexpected error %v of type %s", err, err.Type())
+	}
+}
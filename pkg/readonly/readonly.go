@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readonly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	log "k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ConfigMapKey is the key of the switch ConfigMap, whose value "true" puts the manager into read-only mode.
+	ConfigMapKey = "readOnly"
+	// DefaultInterval is the default interval of checking the switch ConfigMap.
+	DefaultInterval = 10 * time.Second
+)
+
+// ErrReadOnly is returned by the writes rejected in read-only mode.
+var ErrReadOnly = errors.New("kruise-game-manager is in read-only mode")
+
+var enabled atomic.Bool
+
+type internalWritesKey struct{}
+
+// WithInternalWrites returns the context whose writes are let through in read-only mode. It is used by the writes
+// which keep up with the pods instead of changing them, i.e. syncing GameServers from their pods, and setting up
+// the network of the pods created during the maintenance.
+func WithInternalWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, internalWritesKey{}, true)
+}
+
+func isInternalWrite(ctx context.Context) bool {
+	internal, _ := ctx.Value(internalWritesKey{}).(bool)
+	return internal
+}
+
+// Enabled returns whether the manager is in read-only mode, in which it observes the cluster and updates the status
+// of objects only, without writing Services, deleting pods or other changes.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Set puts the manager into or out of read-only mode.
+func Set(readOnly bool) {
+	if enabled.Swap(readOnly) != readOnly {
+		log.Infof("kruise-game-manager read-only mode is set to %v", readOnly)
+	}
+}
+
+// writer rejects the writes other than the status subresource in read-only mode.
+type writer struct {
+	client.Writer
+}
+
+// NewWriter returns the writer which rejects all writes with ErrReadOnly in read-only mode, except the ones made
+// with the context of WithInternalWrites. The writes of the status subresource go through the StatusClient,
+// which is not affected.
+func NewWriter(w client.Writer) client.Writer {
+	return &writer{Writer: w}
+}
+
+func (w *writer) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if Enabled() && !isInternalWrite(ctx) {
+		return rejected("create", obj)
+	}
+	return w.Writer.Create(ctx, obj, opts...)
+}
+
+func (w *writer) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if Enabled() && !isInternalWrite(ctx) {
+		return rejected("delete", obj)
+	}
+	return w.Writer.Delete(ctx, obj, opts...)
+}
+
+func (w *writer) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if Enabled() && !isInternalWrite(ctx) {
+		return rejected("update", obj)
+	}
+	return w.Writer.Update(ctx, obj, opts...)
+}
+
+func (w *writer) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if Enabled() && !isInternalWrite(ctx) {
+		return rejected("patch", obj)
+	}
+	return w.Writer.Patch(ctx, obj, patch, opts...)
+}
+
+func (w *writer) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	if Enabled() && !isInternalWrite(ctx) {
+		return rejected("delete all of", obj)
+	}
+	return w.Writer.DeleteAllOf(ctx, obj, opts...)
+}
+
+func rejected(verb string, obj client.Object) error {
+	log.V(4).Infof("%s %T %s/%s is rejected in read-only mode", verb, obj, obj.GetNamespace(), obj.GetName())
+	return fmt.Errorf("failed to %s %s/%s: %w", verb, obj.GetNamespace(), obj.GetName(), ErrReadOnly)
+}
+
+// Watcher checks the switch ConfigMap periodically, and puts the manager into read-only mode if the flag is set or
+// the ConfigMap has readOnly: "true".
+type Watcher struct {
+	reader    client.Reader
+	configMap types.NamespacedName
+	flag      bool
+	interval  time.Duration
+}
+
+// NewWatcher returns the Watcher of the switch ConfigMap in the form of {namespace}/{name}.
+func NewWatcher(reader client.Reader, configMap string, flag bool, interval time.Duration) (*Watcher, error) {
+	namespace, name, found := strings.Cut(configMap, "/")
+	if !found || namespace == "" || name == "" {
+		return nil, fmt.Errorf("read-only ConfigMap %s should be in the form of {namespace}/{name}", configMap)
+	}
+	return &Watcher{
+		reader:    reader,
+		configMap: types.NamespacedName{Namespace: namespace, Name: name},
+		flag:      flag,
+		interval:  interval,
+	}, nil
+}
+
+// Start implements manager.Runnable.
+func (w *Watcher) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, w.check, w.interval)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. The webhooks of every replica write, so that all
+// of them follow the switch.
+func (w *Watcher) NeedLeaderElection() bool {
+	return false
+}
+
+func (w *Watcher) check(ctx context.Context) {
+	cm := &corev1.ConfigMap{}
+	err := w.reader.Get(ctx, w.configMap, cm)
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.Warningf("failed to get read-only ConfigMap %s, the read-only mode is kept, because of %s", w.configMap, err.Error())
+		return
+	}
+	readOnly := false
+	if err == nil {
+		if value, ok := cm.Data[ConfigMapKey]; ok {
+			readOnly, err = strconv.ParseBool(value)
+			if err != nil {
+				log.Warningf("read-only ConfigMap %s has invalid %s %s, the read-only mode is kept", w.configMap, ConfigMapKey, value)
+				return
+			}
+		}
+	}
+	Set(w.flag || readOnly)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readonly
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var (
+	scheme = runtime.NewScheme()
+)
+
+func init() {
+	utilruntime.Must(corev1.AddToScheme(scheme))
+}
+
+func TestWriter(t *testing.T) {
+	defer Set(false)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	w := NewWriter(c)
+	ctx := context.Background()
+
+	Set(true)
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0"}}
+	if err := w.Create(ctx, svc); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expect create rejected in read-only mode, but got %v", err)
+	}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "xxx", Name: "case-0"}, &corev1.Service{}); err == nil {
+		t.Errorf("expect service not created in read-only mode")
+	}
+
+	Set(false)
+	if err := w.Create(ctx, svc); err != nil {
+		t.Errorf("expect create allowed, but got %v", err)
+	}
+	Set(true)
+	if err := w.Delete(ctx, svc); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expect delete rejected in read-only mode, but got %v", err)
+	}
+	svc.Labels = map[string]string{"synced": "true"}
+	if err := w.Update(WithInternalWrites(ctx), svc); err != nil {
+		t.Errorf("expect internal update allowed in read-only mode, but got %v", err)
+	}
+	if err := w.Update(ctx, svc); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expect update rejected in read-only mode, but got %v", err)
+	}
+}
+
+func TestWatcherCheck(t *testing.T) {
+	defer Set(false)
+	newConfigMap := func(value string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kruise-game-system", Name: "read-only"},
+			Data:       map[string]string{ConfigMapKey: value},
+		}
+	}
+	tests := []struct {
+		configMap *corev1.ConfigMap
+		flag      bool
+		before    bool
+		expected  bool
+	}{
+		{
+			configMap: nil,
+			expected:  false,
+		},
+		{
+			configMap: nil,
+			flag:      true,
+			expected:  true,
+		},
+		{
+			configMap: newConfigMap("true"),
+			expected:  true,
+		},
+		{
+			configMap: newConfigMap("false"),
+			before:    true,
+			expected:  false,
+		},
+		{
+			configMap: newConfigMap("false"),
+			flag:      true,
+			expected:  true,
+		},
+		// the mode is kept if the value is invalid
+		{
+			configMap: newConfigMap("yes"),
+			before:    true,
+			expected:  true,
+		},
+	}
+
+	for i, test := range tests {
+		var objs []client.Object
+		if test.configMap != nil {
+			objs = append(objs, test.configMap)
+		}
+		w, err := NewWatcher(fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(), "kruise-game-system/read-only", test.flag, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Set(test.before)
+		w.check(context.Background())
+		if Enabled() != test.expected {
+			t.Errorf("case %d: expect read-only %v, but got %v", i, test.expected, Enabled())
+		}
+	}
+
+	if _, err := NewWatcher(nil, "read-only", false, time.Second); err == nil {
+		t.Errorf("expect error of the ConfigMap without namespace")
+	}
+}
@@ -0,0 +1,325 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+// AgonesMetadataPrefix is the prefix of the labels and annotations set by SetLabel and SetAnnotation of the Agones
+// SDK, which is the same as Agones, so that the selectors of the allocations keep working after the migration.
+const AgonesMetadataPrefix = "agones.dev/sdk-"
+
+// The states of the Agones GameServer reported to the game process.
+const (
+	agonesStateScheduled = "Scheduled"
+	agonesStateReady     = "Ready"
+	agonesStateReserved  = "Reserved"
+	agonesStateAllocated = "Allocated"
+	agonesStateUnhealthy = "Unhealthy"
+	agonesStateShutdown  = "Shutdown"
+)
+
+type agonesKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type agonesDuration struct {
+	Seconds json.Number `json:"seconds"`
+}
+
+// agonesGameServer is the GameServer message of the Agones SDK in JSON, with the fields kruise-game has.
+type agonesGameServer struct {
+	ObjectMeta agonesObjectMeta `json:"object_meta"`
+	Status     agonesStatus     `json:"status"`
+}
+
+type agonesObjectMeta struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace"`
+	UID               string            `json:"uid"`
+	ResourceVersion   string            `json:"resource_version"`
+	CreationTimestamp int64             `json:"creation_timestamp,string"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+}
+
+type agonesStatus struct {
+	State   string       `json:"state"`
+	Address string       `json:"address,omitempty"`
+	Ports   []agonesPort `json:"ports,omitempty"`
+}
+
+type agonesPort struct {
+	Name string `json:"name"`
+	Port int32  `json:"port"`
+}
+
+// AgonesServer serves the HTTP API of the Agones local SDK server, so that the game servers integrated with the
+// Agones SDK run on kruise-game without changes. The calls are translated into the opsState, labels and annotations
+// of the GameServer:
+//
+//	Ready                     opsState None
+//	Allocate                  opsState Allocated
+//	Reserve(seconds)          opsState Maintaining, back to None after the seconds if not 0
+//	Shutdown                  opsState Kill, which deletes the GameServer first
+//	SetLabel/SetAnnotation    the label/annotation agones.dev/sdk-<key> of the GameServer
+//	Health                    accepted without effect, since the health is checked by the probes of the pod
+//
+// The service account of the pod needs the permission to patch GameServers.
+type AgonesServer struct {
+	server *Server
+
+	mutex        sync.Mutex
+	reserveTimer *time.Timer
+}
+
+// NewAgonesServer returns the AgonesServer of the GameServer of the server.
+func NewAgonesServer(server *Server) *AgonesServer {
+	return &AgonesServer{server: server}
+}
+
+// Handler returns the HTTP handler of the Agones SDK API.
+func (a *AgonesServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ready", a.handle(http.MethodPost, func(r *http.Request) error {
+		return a.setOpsState(r.Context(), gameKruiseV1alpha1.None)
+	}))
+	mux.HandleFunc("/allocate", a.handle(http.MethodPost, func(r *http.Request) error {
+		return a.setOpsState(r.Context(), gameKruiseV1alpha1.Allocated)
+	}))
+	mux.HandleFunc("/shutdown", a.handle(http.MethodPost, func(r *http.Request) error {
+		return a.setOpsState(r.Context(), gameKruiseV1alpha1.Kill)
+	}))
+	mux.HandleFunc("/reserve", a.handle(http.MethodPost, a.reserve))
+	mux.HandleFunc("/health", a.handle(http.MethodPost, func(*http.Request) error {
+		return nil
+	}))
+	mux.HandleFunc("/metadata/label", a.handle(http.MethodPut, func(r *http.Request) error {
+		return a.setMetadata(r, "labels")
+	}))
+	mux.HandleFunc("/metadata/annotation", a.handle(http.MethodPut, func(r *http.Request) error {
+		return a.setMetadata(r, "annotations")
+	}))
+	mux.HandleFunc("/gameserver", a.getGameServer)
+	mux.HandleFunc("/watch/gameserver", a.watchGameServer)
+	return mux
+}
+
+// handle returns the handler of the calls replying the empty message.
+func (a *AgonesServer) handle(method string, call func(r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			http.Error(w, fmt.Sprintf("only %s is allowed", method), http.StatusMethodNotAllowed)
+			return
+		}
+		if err := call(r); err != nil {
+			klog.Errorf("failed to handle Agones SDK call %s, because of %s", r.URL.Path, err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+	}
+}
+
+// setOpsState sets the opsState of the GameServer, which stops the pending Reserve from resetting it.
+func (a *AgonesServer) setOpsState(ctx context.Context, opsState gameKruiseV1alpha1.OpsState) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.reserveTimer != nil {
+		a.reserveTimer.Stop()
+		a.reserveTimer = nil
+	}
+	return a.patch(ctx, map[string]interface{}{"spec": map[string]interface{}{"opsState": opsState}})
+}
+
+// reserve sets the opsState of the GameServer to Maintaining, which is neither allocated nor scaled down, and back
+// to None after the seconds if they are not 0.
+func (a *AgonesServer) reserve(r *http.Request) error {
+	duration := &agonesDuration{}
+	if err := json.NewDecoder(r.Body).Decode(duration); err != nil {
+		return fmt.Errorf("invalid duration: %s", err.Error())
+	}
+	seconds := int64(0)
+	if duration.Seconds != "" {
+		var err error
+		if seconds, err = duration.Seconds.Int64(); err != nil || seconds < 0 {
+			return fmt.Errorf("invalid seconds %s", duration.Seconds)
+		}
+	}
+	if err := a.setOpsState(r.Context(), gameKruiseV1alpha1.Maintaining); err != nil {
+		return err
+	}
+	if seconds == 0 {
+		return nil
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Duration(seconds)*time.Second, func() {
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+		if a.reserveTimer != timer {
+			return
+		}
+		a.reserveTimer = nil
+		ctx := context.Background()
+		gs, err := a.server.client.GameV1alpha1().GameServers(a.server.namespace).Get(ctx, a.server.name, metav1.GetOptions{})
+		if err != nil {
+			klog.Errorf("failed to end the reservation of GameServer %s/%s, because of %s", a.server.namespace, a.server.name, err.Error())
+			return
+		}
+		if gs.Spec.OpsState != gameKruiseV1alpha1.Maintaining {
+			return
+		}
+		if err := a.patch(ctx, map[string]interface{}{"spec": map[string]interface{}{"opsState": gameKruiseV1alpha1.None}}); err != nil {
+			klog.Errorf("failed to end the reservation of GameServer %s/%s, because of %s", a.server.namespace, a.server.name, err.Error())
+		}
+	})
+	a.reserveTimer = timer
+	return nil
+}
+
+// setMetadata sets the label or annotation of the GameServer with the Agones prefix.
+func (a *AgonesServer) setMetadata(r *http.Request, field string) error {
+	kv := &agonesKeyValue{}
+	if err := json.NewDecoder(r.Body).Decode(kv); err != nil {
+		return fmt.Errorf("invalid key value: %s", err.Error())
+	}
+	if kv.Key == "" {
+		return fmt.Errorf("key is required")
+	}
+	return a.patch(r.Context(), map[string]interface{}{
+		"metadata": map[string]interface{}{field: map[string]string{AgonesMetadataPrefix + kv.Key: kv.Value}},
+	})
+}
+
+func (a *AgonesServer) patch(ctx context.Context, patch map[string]interface{}) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = a.server.client.GameV1alpha1().GameServers(a.server.namespace).Patch(ctx, a.server.name, types.MergePatchType, data, metav1.PatchOptions{})
+	return err
+}
+
+func (a *AgonesServer) getGameServer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	gs, err := a.server.client.GameV1alpha1().GameServers(a.server.namespace).Get(r.Context(), a.server.name, metav1.GetOptions{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(toAgonesGameServer(gs))
+}
+
+// watchGameServer streams the GameServer each time it changes, wrapped in {"result": ...} as the Agones SDK does.
+func (a *AgonesServer) watchGameServer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	var last *agonesGameServer
+	err := a.server.watch(r.Context(), func(gs *gameKruiseV1alpha1.GameServer) error {
+		current := toAgonesGameServer(gs)
+		if last != nil && reflect.DeepEqual(current, last) {
+			return nil
+		}
+		if err := encoder.Encode(map[string]interface{}{"result": current}); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		last = current
+		return nil
+	})
+	if err != nil {
+		klog.Errorf("failed to watch GameServer for Agones SDK, because of %s", err.Error())
+	}
+}
+
+func toAgonesGameServer(gs *gameKruiseV1alpha1.GameServer) *agonesGameServer {
+	agonesGs := &agonesGameServer{
+		ObjectMeta: agonesObjectMeta{
+			Name:              gs.GetName(),
+			Namespace:         gs.GetNamespace(),
+			UID:               string(gs.GetUID()),
+			ResourceVersion:   gs.GetResourceVersion(),
+			CreationTimestamp: gs.GetCreationTimestamp().Unix(),
+			Annotations:       gs.GetAnnotations(),
+			Labels:            gs.GetLabels(),
+		},
+		Status: agonesStatus{State: agonesState(gs)},
+	}
+	if addresses := gs.Status.NetworkStatus.ExternalAddresses; len(addresses) != 0 {
+		agonesGs.Status.Address = addresses[0].IP
+		if agonesGs.Status.Address == "" {
+			agonesGs.Status.Address = addresses[0].EndPoint
+		}
+		for _, port := range addresses[0].Ports {
+			if port.Port != nil {
+				agonesGs.Status.Ports = append(agonesGs.Status.Ports, agonesPort{Name: port.Name, Port: port.Port.IntVal})
+			}
+		}
+	}
+	return agonesGs
+}
+
+// agonesState returns the Agones state of the GameServer, by its opsState first and then its current state.
+func agonesState(gs *gameKruiseV1alpha1.GameServer) string {
+	if gs.GetDeletionTimestamp() != nil || gs.Status.CurrentState == gameKruiseV1alpha1.Deleting {
+		return agonesStateShutdown
+	}
+	switch gs.Spec.OpsState {
+	case gameKruiseV1alpha1.Kill, gameKruiseV1alpha1.WaitToDelete:
+		return agonesStateShutdown
+	case gameKruiseV1alpha1.Allocated:
+		return agonesStateAllocated
+	case gameKruiseV1alpha1.Maintaining:
+		return agonesStateReserved
+	}
+	switch gs.Status.CurrentState {
+	case gameKruiseV1alpha1.Ready:
+		return agonesStateReady
+	case gameKruiseV1alpha1.NotReady, gameKruiseV1alpha1.Crash:
+		return agonesStateUnhealthy
+	}
+	return agonesStateScheduled
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/client/clientset/versioned/fake"
+)
+
+func TestAgonesServer(t *testing.T) {
+	tests := []struct {
+		method      string
+		path        string
+		body        string
+		code        int
+		opsState    gameKruiseV1alpha1.OpsState
+		label       string
+		annotation  string
+		agonesState string
+	}{
+		{
+			method:      http.MethodPost,
+			path:        "/allocate",
+			body:        "{}",
+			code:        http.StatusOK,
+			opsState:    gameKruiseV1alpha1.Allocated,
+			agonesState: agonesStateAllocated,
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/ready",
+			body:        "{}",
+			code:        http.StatusOK,
+			opsState:    gameKruiseV1alpha1.None,
+			agonesState: agonesStateReady,
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/reserve",
+			body:        `{"seconds":"0"}`,
+			code:        http.StatusOK,
+			opsState:    gameKruiseV1alpha1.Maintaining,
+			agonesState: agonesStateReserved,
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/shutdown",
+			body:        "{}",
+			code:        http.StatusOK,
+			opsState:    gameKruiseV1alpha1.Kill,
+			agonesState: agonesStateShutdown,
+		},
+		{
+			method:      http.MethodPut,
+			path:        "/metadata/label",
+			body:        `{"key":"mode","value":"ranked"}`,
+			code:        http.StatusOK,
+			opsState:    gameKruiseV1alpha1.None,
+			label:       "ranked",
+			agonesState: agonesStateReady,
+		},
+		{
+			method:      http.MethodPut,
+			path:        "/metadata/annotation",
+			body:        `{"key":"map","value":"dust"}`,
+			code:        http.StatusOK,
+			opsState:    gameKruiseV1alpha1.None,
+			annotation:  "dust",
+			agonesState: agonesStateReady,
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/health",
+			body:        "{}",
+			code:        http.StatusOK,
+			opsState:    gameKruiseV1alpha1.None,
+			agonesState: agonesStateReady,
+		},
+		{
+			method: http.MethodGet,
+			path:   "/ready",
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			method: http.MethodPost,
+			path:   "/reserve",
+			body:   `{"seconds":"-1"}`,
+			code:   http.StatusInternalServerError,
+		},
+	}
+
+	for i, test := range tests {
+		port := intstr.FromInt(7777)
+		gs := &gameKruiseV1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0"},
+			Spec:       gameKruiseV1alpha1.GameServerSpec{OpsState: gameKruiseV1alpha1.None},
+			Status: gameKruiseV1alpha1.GameServerStatus{
+				CurrentState: gameKruiseV1alpha1.Ready,
+				NetworkStatus: gameKruiseV1alpha1.NetworkStatus{
+					ExternalAddresses: []gameKruiseV1alpha1.NetworkAddress{
+						{IP: "1.2.3.4", Ports: []gameKruiseV1alpha1.NetworkPort{{Name: "game", Port: &port}}},
+					},
+				},
+			},
+		}
+		client := fake.NewSimpleClientset(gs)
+		handler := NewAgonesServer(NewServerForPod(client, "xxx", "case-0")).Handler()
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(test.method, test.path, strings.NewReader(test.body)))
+		if w.Code != test.code {
+			t.Errorf("case %d: expect code %d, but actually got %d: %s", i, test.code, w.Code, w.Body.String())
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		actual, err := client.GameV1alpha1().GameServers("xxx").Get(context.TODO(), "case-0", metav1.GetOptions{})
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if actual.Spec.OpsState != test.opsState {
+			t.Errorf("case %d: expect opsState %s, but actually got %s", i, test.opsState, actual.Spec.OpsState)
+		}
+		if test.label != "" && actual.GetLabels()[AgonesMetadataPrefix+"mode"] != test.label {
+			t.Errorf("case %d: expect label %s, but actually got %v", i, test.label, actual.GetLabels())
+		}
+		if test.annotation != "" && actual.GetAnnotations()[AgonesMetadataPrefix+"map"] != test.annotation {
+			t.Errorf("case %d: expect annotation %s, but actually got %v", i, test.annotation, actual.GetAnnotations())
+		}
+
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/gameserver", nil))
+		agonesGs := &agonesGameServer{}
+		if err := json.Unmarshal(w.Body.Bytes(), agonesGs); err != nil {
+			t.Errorf("case %d: %v", i, err)
+			continue
+		}
+		if agonesGs.Status.State != test.agonesState || agonesGs.Status.Address != "1.2.3.4" ||
+			len(agonesGs.Status.Ports) != 1 || agonesGs.Status.Ports[0].Port != 7777 {
+			t.Errorf("case %d: expect GameServer %s at 1.2.3.4:7777, but actually got %+v", i, test.agonesState, agonesGs)
+		}
+	}
+}
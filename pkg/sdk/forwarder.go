@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+// MetadataForwarder delivers the runtime metadata of the GameServer to the game process. Forward is called
+// with all the runtime metadata each time any of them changes.
+type MetadataForwarder interface {
+	Forward(ctx context.Context, metadata map[string]string) error
+}
+
+// RuntimeMetadata returns the labels and annotations of the GameServer with the prefix
+// GameServerRuntimeMetadataPrefix, keyed by the names following the prefix.
+func RuntimeMetadata(gs *GameServer) map[string]string {
+	metadata := make(map[string]string)
+	for _, kvs := range []map[string]string{gs.GetLabels(), gs.GetAnnotations()} {
+		for k, v := range kvs {
+			if name := strings.TrimPrefix(k, gameKruiseV1alpha1.GameServerRuntimeMetadataPrefix); name != k && name != "" {
+				metadata[name] = v
+			}
+		}
+	}
+	return metadata
+}
+
+// ForwardRuntimeMetadata watches the GameServer of the server, and forwards its runtime metadata to the
+// forwarders when they change, until ctx is done or the watch ends. The metadata are forwarded once the
+// watch starts as well, so that the game process catches up with the changes missed while it is restarted.
+func ForwardRuntimeMetadata(ctx context.Context, server *Server, forwarders ...MetadataForwarder) error {
+	var last map[string]string
+	return server.Watch(ctx, func(gs *GameServer) error {
+		metadata := RuntimeMetadata(gs)
+		if last != nil && reflect.DeepEqual(metadata, last) {
+			return nil
+		}
+		for _, forwarder := range forwarders {
+			if err := forwarder.Forward(ctx, metadata); err != nil {
+				return err
+			}
+		}
+		last = metadata
+		return nil
+	})
+}
+
+type fileForwarder struct {
+	dir string
+}
+
+// NewFileForwarder returns the MetadataForwarder writing each runtime metadata to the file of its name in dir,
+// such as an emptyDir volume shared with the game container, and removing the files of the metadata removed.
+// The files are replaced atomically, so that the game process may watch or read them at any time. The dir
+// must not be used for other files.
+func NewFileForwarder(dir string) MetadataForwarder {
+	return &fileForwarder{dir: dir}
+}
+
+func (f *fileForwarder) Forward(_ context.Context, metadata map[string]string) error {
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return err
+	}
+	for name, value := range metadata {
+		tmp := filepath.Join(f.dir, "."+name+".tmp")
+		if err := os.WriteFile(tmp, []byte(value), 0644); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, filepath.Join(f.dir, name)); err != nil {
+			return err
+		}
+	}
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if _, ok := metadata[entry.Name()]; ok || entry.IsDir() {
+			continue
+		}
+		if err := os.Remove(filepath.Join(f.dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+type callbackForwarder struct {
+	url    string
+	client *http.Client
+}
+
+// NewCallbackForwarder returns the MetadataForwarder posting the runtime metadata as a JSON object to the url,
+// such as an HTTP endpoint of the game process on the loopback address. Responses other than 2xx are errors.
+func NewCallbackForwarder(url string, timeout time.Duration) MetadataForwarder {
+	return &callbackForwarder{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *callbackForwarder) Forward(ctx context.Context, metadata map[string]string) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback %s responded %s", c.url, resp.Status)
+	}
+	return nil
+}
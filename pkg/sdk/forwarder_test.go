@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestRuntimeMetadata(t *testing.T) {
+	gs := &GameServer{
+		Labels: map[string]string{
+			"foo": "bar",
+			gameKruiseV1alpha1.GameServerRuntimeMetadataPrefix + "event-mode": "off",
+			gameKruiseV1alpha1.GameServerRuntimeMetadataPrefix + "region":     "eu",
+		},
+		Annotations: map[string]string{
+			gameKruiseV1alpha1.GameServerRuntimeMetadataPrefix:                "ignored",
+			gameKruiseV1alpha1.GameServerRuntimeMetadataPrefix + "event-mode": "on",
+			gameKruiseV1alpha1.GameServerRuntimeMetadataPrefix + "broadcast":  "maintenance in 10 minutes",
+		},
+	}
+	expected := map[string]string{
+		"event-mode": "on",
+		"region":     "eu",
+		"broadcast":  "maintenance in 10 minutes",
+	}
+	if actual := RuntimeMetadata(gs); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expect runtime metadata %v, but actually got %v", expected, actual)
+	}
+}
+
+func TestFileForwarder(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "metadata")
+	f := NewFileForwarder(dir)
+	if err := f.Forward(context.TODO(), map[string]string{"broadcast": "hello", "event-mode": "on"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Forward(context.TODO(), map[string]string{"broadcast": "bye"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"broadcast"}) {
+		t.Errorf("expect files [broadcast], but actually got %v", names)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "broadcast"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "bye" {
+		t.Errorf("expect broadcast bye, but actually got %s", string(data))
+	}
+}
+
+func TestCallbackForwarder(t *testing.T) {
+	var received map[string]string
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	c := NewCallbackForwarder(ts.URL, time.Second)
+	metadata := map[string]string{"broadcast": "hello"}
+	if err := c.Forward(context.TODO(), metadata); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(received, metadata) {
+		t.Errorf("expect callback with %v, but actually got %v", metadata, received)
+	}
+
+	status = http.StatusInternalServerError
+	if err := c.Forward(context.TODO(), metadata); err == nil {
+		t.Error("expect error when the callback fails, but actually got nil")
+	}
+}
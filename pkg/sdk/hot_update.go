@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HotUpdateNotifier notifies the game process of the hot updates of its pod, such as the config sidecar
+// updated in place, so that the game process reloads them instead of being restarted.
+type HotUpdateNotifier interface {
+	Notify(ctx context.Context, revision string) error
+}
+
+// HotUpdateWatcher watches the file of the game.kruise.io/hot-update-revision annotation of the pod,
+// which is projected by a downwardAPI volume, and notifies the game process when the revision changes.
+type HotUpdateWatcher struct {
+	file      string
+	notifiers []HotUpdateNotifier
+	last      *string
+}
+
+func NewHotUpdateWatcher(file string, notifiers ...HotUpdateNotifier) *HotUpdateWatcher {
+	return &HotUpdateWatcher{
+		file:      file,
+		notifiers: notifiers,
+	}
+}
+
+// Sync reads the revision and notifies the notifiers if it is changed since the last successful Sync, so it
+// is called periodically. The revision read first is not notified, since the game process starts with it.
+func (w *HotUpdateWatcher) Sync(ctx context.Context) error {
+	data, err := os.ReadFile(w.file)
+	if err != nil {
+		return err
+	}
+	revision := strings.TrimSpace(string(data))
+	if w.last != nil && *w.last != revision {
+		for _, notifier := range w.notifiers {
+			if err := notifier.Notify(ctx, revision); err != nil {
+				return err
+			}
+		}
+	}
+	w.last = &revision
+	return nil
+}
+
+type signalNotifier struct {
+	procDir string
+	process string
+	signal  os.Signal
+}
+
+// NewSignalNotifier returns the HotUpdateNotifier sending the signal, such as SIGHUP, to the processes whose
+// name is process. The processes of the game container are visible only if shareProcessNamespace of the pod is true.
+func NewSignalNotifier(process string, signal os.Signal) HotUpdateNotifier {
+	return &signalNotifier{
+		procDir: "/proc",
+		process: process,
+		signal:  signal,
+	}
+}
+
+func (s *signalNotifier) Notify(_ context.Context, _ string) error {
+	entries, err := os.ReadDir(s.procDir)
+	if err != nil {
+		return err
+	}
+	signalled := false
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		comm, err := os.ReadFile(filepath.Join(s.procDir, entry.Name(), "comm"))
+		if err != nil || strings.TrimSpace(string(comm)) != s.process {
+			continue
+		}
+		p, err := os.FindProcess(pid)
+		if err != nil {
+			return err
+		}
+		if err := p.Signal(s.signal); err != nil {
+			return err
+		}
+		signalled = true
+	}
+	if !signalled {
+		return fmt.Errorf("process %s is not found", s.process)
+	}
+	return nil
+}
+
+type hotUpdateCallbackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewHotUpdateCallbackNotifier returns the HotUpdateNotifier posting the revision as the JSON object
+// {"revision": "<revision>"} to the url, such as an HTTP endpoint of the game process on the loopback address.
+// Responses other than 2xx are errors.
+func NewHotUpdateCallbackNotifier(url string, timeout time.Duration) HotUpdateNotifier {
+	return &hotUpdateCallbackNotifier{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *hotUpdateCallbackNotifier) Notify(ctx context.Context, revision string) error {
+	data, err := json.Marshal(map[string]string{"revision": revision})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback %s responded %s", c.url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type fakeHotUpdateNotifier struct {
+	revisions []string
+	err       error
+}
+
+func (f *fakeHotUpdateNotifier) Notify(_ context.Context, revision string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.revisions = append(f.revisions, revision)
+	return nil
+}
+
+func TestHotUpdateWatcher(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "hot-update-revision")
+	notifier := &fakeHotUpdateNotifier{}
+	watcher := NewHotUpdateWatcher(file, notifier)
+
+	if err := watcher.Sync(context.TODO()); err == nil {
+		t.Errorf("expect error when the file does not exist")
+	}
+	writeRevision := func(revision string) {
+		if err := os.WriteFile(file, []byte(revision), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeRevision("1")
+	if err := watcher.Sync(context.TODO()); err != nil {
+		t.Error(err)
+	}
+	if err := watcher.Sync(context.TODO()); err != nil {
+		t.Error(err)
+	}
+	if len(notifier.revisions) != 0 {
+		t.Errorf("expect the revision read first not notified, but actually got %v", notifier.revisions)
+	}
+
+	// the revision is notified again after the notifier fails
+	writeRevision("2")
+	notifier.err = errors.New("game process is busy")
+	if err := watcher.Sync(context.TODO()); err == nil {
+		t.Errorf("expect error when the notifier fails")
+	}
+	notifier.err = nil
+	if err := watcher.Sync(context.TODO()); err != nil {
+		t.Error(err)
+	}
+	if err := watcher.Sync(context.TODO()); err != nil {
+		t.Error(err)
+	}
+	if expected := []string{"2"}; !reflect.DeepEqual(notifier.revisions, expected) {
+		t.Errorf("expect revisions %v notified, but actually got %v", expected, notifier.revisions)
+	}
+}
+
+func TestSignalNotifier(t *testing.T) {
+	procDir := t.TempDir()
+	for pid, comm := range map[string]string{strconv.Itoa(os.Getpid()): "game\n", "1": "pause\n", "self": "game\n"} {
+		if err := os.MkdirAll(filepath.Join(procDir, pid), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(procDir, pid, "comm"), []byte(comm), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1)
+	defer signal.Stop(ch)
+
+	notifier := &signalNotifier{procDir: procDir, process: "game", signal: syscall.SIGUSR1}
+	if err := notifier.Notify(context.TODO(), "2"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Errorf("expect SIGUSR1 received")
+	}
+
+	notifier = &signalNotifier{procDir: procDir, process: "unknown", signal: syscall.SIGUSR1}
+	if err := notifier.Notify(context.TODO(), "2"); err == nil {
+		t.Errorf("expect error when the process is not found")
+	}
+}
+
+func TestHotUpdateCallbackNotifier(t *testing.T) {
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	if err := NewHotUpdateCallbackNotifier(server.URL, time.Second).Notify(context.TODO(), "2"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string]string{"revision": "2"}; !reflect.DeepEqual(received, expected) {
+		t.Errorf("expect %v posted, but actually got %v", expected, received)
+	}
+
+	failed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failed.Close()
+	if err := NewHotUpdateCallbackNotifier(failed.URL, time.Second).Notify(context.TODO(), "2"); err == nil {
+		t.Errorf("expect error when the callback responds 503")
+	}
+}
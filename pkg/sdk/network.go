@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+// The environment variables of the network of the game server, written by okg-network-init. The port of the
+// network port named NAME is OKG_EXTERNAL_PORT_NAME, where NAME is in uppercase with the characters other than
+// letters and digits replaced by underscores.
+const (
+	NetworkStateEnv       = "OKG_NETWORK_STATE"
+	ExternalIPEnv         = "OKG_EXTERNAL_IP"
+	ExternalEndpointEnv   = "OKG_EXTERNAL_ENDPOINT"
+	ExternalPortRangeEnv  = "OKG_EXTERNAL_PORT_RANGE"
+	ExternalPortEnvPrefix = "OKG_EXTERNAL_PORT_"
+	InternalIPEnv         = "OKG_INTERNAL_IP"
+	InternalPortEnvPrefix = "OKG_INTERNAL_PORT_"
+)
+
+// The files of okg-network-init. It reads the network status from DefaultNetworkStatusFile, which is projected
+// from the pod annotation by the downward API, and writes the environment variables and the network status in
+// JSON to NetworkEnvFileName and NetworkStatusFileName in DefaultNetworkDir, which is shared with the other
+// containers.
+const (
+	NetworkEnvFileName       = "network.env"
+	NetworkStatusFileName    = "network.json"
+	DefaultNetworkDir        = "/etc/okg/network"
+	DefaultNetworkStatusFile = "/etc/okg/network-status/status"
+)
+
+// NetworkEnv returns the environment variables of the network status. Only the first external and internal
+// addresses are used, which are the IPv4 ones of dual-stack networks in general.
+func NetworkEnv(status *gameKruiseV1alpha1.NetworkStatus) map[string]string {
+	env := map[string]string{
+		NetworkStateEnv: string(status.CurrentNetworkState),
+	}
+	if len(status.ExternalAddresses) > 0 {
+		address := status.ExternalAddresses[0]
+		env[ExternalIPEnv] = address.IP
+		if address.EndPoint != "" {
+			env[ExternalEndpointEnv] = address.EndPoint
+		}
+		if address.PortRange != nil {
+			env[ExternalPortRangeEnv] = address.PortRange.PortRange
+		}
+		addPortEnv(env, ExternalPortEnvPrefix, address.Ports)
+	}
+	if len(status.InternalAddresses) > 0 {
+		address := status.InternalAddresses[0]
+		env[InternalIPEnv] = address.IP
+		addPortEnv(env, InternalPortEnvPrefix, address.Ports)
+	}
+	return env
+}
+
+// ExternalPortEnv returns the environment variable of the external port of the network port named name.
+func ExternalPortEnv(name string) string {
+	return ExternalPortEnvPrefix + envName(name)
+}
+
+func addPortEnv(env map[string]string, prefix string, ports []gameKruiseV1alpha1.NetworkPort) {
+	for _, port := range ports {
+		if port.Port == nil {
+			continue
+		}
+		env[prefix+envName(port.Name)] = port.Port.String()
+	}
+}
+
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// FormatEnvFile formats the environment variables as the lines of KEY='value' sorted by the keys, which can be
+// sourced by shells.
+func FormatEnvFile(env map[string]string) string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s='%s'\n", k, strings.ReplaceAll(env[k], "'", `'\''`))
+	}
+	return b.String()
+}
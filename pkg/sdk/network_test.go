@@ -0,0 +1,67 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestNetworkEnv(t *testing.T) {
+	port := intstr.FromInt(8080)
+	internalPort := intstr.FromInt(80)
+	status := &gameKruiseV1alpha1.NetworkStatus{
+		CurrentNetworkState: gameKruiseV1alpha1.NetworkReady,
+		ExternalAddresses: []gameKruiseV1alpha1.NetworkAddress{
+			{
+				IP:       "47.0.0.1",
+				EndPoint: "lb.example.com",
+				Ports:    []gameKruiseV1alpha1.NetworkPort{{Name: "game-tcp", Port: &port}, {Name: "no-port"}},
+			},
+			{IP: "2408::1"},
+		},
+		InternalAddresses: []gameKruiseV1alpha1.NetworkAddress{
+			{IP: "10.0.0.1", Ports: []gameKruiseV1alpha1.NetworkPort{{Name: "80", Port: &internalPort}}},
+		},
+	}
+	expect := map[string]string{
+		NetworkStateEnv:                    "Ready",
+		ExternalIPEnv:                      "47.0.0.1",
+		ExternalEndpointEnv:                "lb.example.com",
+		ExternalPortEnvPrefix + "GAME_TCP": "8080",
+		InternalIPEnv:                      "10.0.0.1",
+		InternalPortEnvPrefix + "80":       "80",
+	}
+	if actual := NetworkEnv(status); !reflect.DeepEqual(expect, actual) {
+		t.Errorf("expect %v, but actually got %v", expect, actual)
+	}
+}
+
+func TestFormatEnvFile(t *testing.T) {
+	env := map[string]string{
+		"B": "it's",
+		"A": "1",
+	}
+	expect := "A='1'\nB='it'\\''s'\n"
+	if actual := FormatEnvFile(env); actual != expect {
+		t.Errorf("expect %q, but actually got %q", expect, actual)
+	}
+}
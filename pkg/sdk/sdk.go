@@ -0,0 +1,193 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sdk is used by game servers running in the pods of GameServerSets to report their
+// custom status fields, which are surfaced in the status of GameServers and in metrics,
+// to coordinate the graceful shutdown with the allocators, and to watch their GameServers through the
+// gRPC API served by the SDK sidecar.
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+// The environment variables of the pod name and namespace, which are expected to be set by the downward API.
+// They are injected into the game container when GameContainerName of the GameServerSet is set.
+const (
+	PodNameEnv      = gameKruiseV1alpha1.GameServerPodNameEnv
+	PodNamespaceEnv = gameKruiseV1alpha1.GameServerPodNamespaceEnv
+)
+
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+type SDK struct {
+	client    kubernetes.Interface
+	namespace string
+	podName   string
+}
+
+// NewSDK returns the SDK of the pod it is running in, with the in-cluster config. The pod name and
+// namespace are read from POD_NAME and POD_NAMESPACE, falling back to the hostname and the namespace
+// of the service account.
+func NewSDK() (*SDK, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, podName, err := podIdentity()
+	if err != nil {
+		return nil, err
+	}
+	return NewSDKForPod(client, namespace, podName), nil
+}
+
+func podIdentity() (string, string, error) {
+	var err error
+	podName := os.Getenv(PodNameEnv)
+	if podName == "" {
+		if podName, err = os.Hostname(); err != nil {
+			return "", "", err
+		}
+	}
+	namespace := os.Getenv(PodNamespaceEnv)
+	if namespace == "" {
+		data, err := os.ReadFile(serviceAccountNamespaceFile)
+		if err != nil {
+			return "", "", err
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+	return namespace, podName, nil
+}
+
+// NewSDKForPod returns the SDK of the given pod.
+func NewSDKForPod(client kubernetes.Interface, namespace, podName string) *SDK {
+	return &SDK{
+		client:    client,
+		namespace: namespace,
+		podName:   podName,
+	}
+}
+
+// SetStatus sets the value of the custom status field declared in the customStatusFields of the GameServerSet.
+// The values of the undeclared fields are ignored by kruise-game-manager.
+func (s *SDK) SetStatus(name, value string) error {
+	return s.SetStatusWithContext(context.TODO(), name, value)
+}
+
+// SetStatusNumber sets the value of the custom status field of type Number.
+func (s *SDK) SetStatusNumber(name string, value float64) error {
+	return s.SetStatusWithContext(context.TODO(), name, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// SetStatusWithContext is SetStatus with the given context.
+func (s *SDK) SetStatusWithContext(ctx context.Context, name, value string) error {
+	if err := s.patchAnnotations(ctx, map[string]string{gameKruiseV1alpha1.GameServerCustomStatusPrefix + name: value}); err != nil {
+		return fmt.Errorf("failed to set custom status %s of pod %s/%s: %w", name, s.namespace, s.podName, err)
+	}
+	return nil
+}
+
+// GetStatus returns the value of the custom status field set on the pod.
+func (s *SDK) GetStatus(ctx context.Context, name string) (string, bool, error) {
+	pod, err := s.client.CoreV1().Pods(s.namespace).Get(ctx, s.podName, metav1.GetOptions{})
+	if err != nil {
+		return "", false, err
+	}
+	value, ok := pod.GetAnnotations()[gameKruiseV1alpha1.GameServerCustomStatusPrefix+name]
+	return value, ok, nil
+}
+
+// StartShutdown marks the game server as Draining until the deadline, which stops the GameServer from being
+// allocated. It is expected to be called in the preStop hook of the pod.
+func (s *SDK) StartShutdown(ctx context.Context, deadline time.Time) error {
+	if err := s.patchAnnotations(ctx, map[string]string{
+		gameKruiseV1alpha1.GameServerShutdownStateKey:    string(gameKruiseV1alpha1.ShutdownDraining),
+		gameKruiseV1alpha1.GameServerShutdownDeadlineKey: deadline.UTC().Format(time.RFC3339),
+	}); err != nil {
+		return fmt.Errorf("failed to start shutdown of pod %s/%s: %w", s.namespace, s.podName, err)
+	}
+	return nil
+}
+
+// AckShutdown marks the game server as Drained, acknowledging that its sessions have ended.
+func (s *SDK) AckShutdown(ctx context.Context) error {
+	if err := s.patchAnnotations(ctx, map[string]string{
+		gameKruiseV1alpha1.GameServerShutdownStateKey: string(gameKruiseV1alpha1.ShutdownDrained),
+	}); err != nil {
+		return fmt.Errorf("failed to acknowledge shutdown of pod %s/%s: %w", s.namespace, s.podName, err)
+	}
+	return nil
+}
+
+// WaitForDrained blocks until the shutdown is acknowledged by AckShutdown, the custom status field sessionsField
+// (if not empty) becomes 0, or ctx is done.
+func (s *SDK) WaitForDrained(ctx context.Context, sessionsField string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		pod, err := s.client.CoreV1().Pods(s.namespace).Get(ctx, s.podName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		annotations := pod.GetAnnotations()
+		if annotations[gameKruiseV1alpha1.GameServerShutdownStateKey] == string(gameKruiseV1alpha1.ShutdownDrained) {
+			return nil
+		}
+		if sessionsField != "" {
+			if sessions, err := strconv.ParseFloat(annotations[gameKruiseV1alpha1.GameServerCustomStatusPrefix+sessionsField], 64); err == nil && sessions <= 0 {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *SDK) patchAnnotations(ctx context.Context, annotations map[string]string) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.CoreV1().Pods(s.namespace).Patch(ctx, s.podName, types.MergePatchType, data, metav1.PatchOptions{})
+	return err
+}
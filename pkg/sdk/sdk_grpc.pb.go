@@ -0,0 +1,136 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v4.22.0--rc2
+// source: sdk.proto
+
+package sdk
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// SDKClient is the client API for SDK service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SDKClient interface {
+	// WatchGameServer sends the current GameServer of the pod, and then pushes it again each time its opsState,
+	// state, network state, labels or annotations change. The stream ends when the GameServer is deleted.
+	WatchGameServer(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (SDK_WatchGameServerClient, error)
+}
+
+type sDKClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSDKClient(cc grpc.ClientConnInterface) SDKClient {
+	return &sDKClient{cc}
+}
+
+func (c *sDKClient) WatchGameServer(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (SDK_WatchGameServerClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SDK_ServiceDesc.Streams[0], "/sdk.SDK/WatchGameServer", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &sDKWatchGameServerClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SDK_WatchGameServerClient interface {
+	Recv() (*GameServer, error)
+	grpc.ClientStream
+}
+
+type sDKWatchGameServerClient struct {
+	grpc.ClientStream
+}
+
+func (x *sDKWatchGameServerClient) Recv() (*GameServer, error) {
+	m := new(GameServer)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SDKServer is the server API for SDK service.
+// All implementations must embed UnimplementedSDKServer
+// for forward compatibility
+type SDKServer interface {
+	// WatchGameServer sends the current GameServer of the pod, and then pushes it again each time its opsState,
+	// state, network state, labels or annotations change. The stream ends when the GameServer is deleted.
+	WatchGameServer(*WatchRequest, SDK_WatchGameServerServer) error
+	mustEmbedUnimplementedSDKServer()
+}
+
+// UnimplementedSDKServer must be embedded to have forward compatible implementations.
+type UnimplementedSDKServer struct {
+}
+
+func (UnimplementedSDKServer) WatchGameServer(*WatchRequest, SDK_WatchGameServerServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchGameServer not implemented")
+}
+func (UnimplementedSDKServer) mustEmbedUnimplementedSDKServer() {}
+
+// UnsafeSDKServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SDKServer will
+// result in compilation errors.
+type UnsafeSDKServer interface {
+	mustEmbedUnimplementedSDKServer()
+}
+
+func RegisterSDKServer(s grpc.ServiceRegistrar, srv SDKServer) {
+	s.RegisterService(&SDK_ServiceDesc, srv)
+}
+
+func _SDK_WatchGameServer_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SDKServer).WatchGameServer(m, &sDKWatchGameServerServer{stream})
+}
+
+type SDK_WatchGameServerServer interface {
+	Send(*GameServer) error
+	grpc.ServerStream
+}
+
+type sDKWatchGameServerServer struct {
+	grpc.ServerStream
+}
+
+func (x *sDKWatchGameServerServer) Send(m *GameServer) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// SDK_ServiceDesc is the grpc.ServiceDesc for SDK service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SDK_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sdk.SDK",
+	HandlerType: (*SDKServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchGameServer",
+			Handler:       _SDK_WatchGameServer_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "sdk.proto",
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestSetStatus(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "xxx",
+			Name:        "case-0",
+			Annotations: map[string]string{"foo": "bar"},
+		},
+	}
+	client := fake.NewSimpleClientset(pod)
+	s := NewSDKForPod(client, "xxx", "case-0")
+	if err := s.SetStatus("map", "desert"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetStatusNumber("players", 12); err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := client.CoreV1().Pods("xxx").Get(context.TODO(), "case-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"foo": "bar",
+		gameKruiseV1alpha1.GameServerCustomStatusPrefix + "map":     "desert",
+		gameKruiseV1alpha1.GameServerCustomStatusPrefix + "players": "12",
+	}
+	if !reflect.DeepEqual(actual.GetAnnotations(), expected) {
+		t.Errorf("expect annotations %v, but actually got %v", expected, actual.GetAnnotations())
+	}
+
+	if err := NewSDKForPod(client, "xxx", "case-1").SetStatus("map", "desert"); err == nil {
+		t.Errorf("expect error when the pod is not found")
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0"}}
+	client := fake.NewSimpleClientset(pod)
+	s := NewSDKForPod(client, "xxx", "case-0")
+
+	deadline := time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC)
+	if err := s.StartShutdown(context.TODO(), deadline); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := client.CoreV1().Pods("xxx").Get(context.TODO(), "case-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.GetAnnotations()[gameKruiseV1alpha1.GameServerShutdownStateKey] != string(gameKruiseV1alpha1.ShutdownDraining) ||
+		actual.GetAnnotations()[gameKruiseV1alpha1.GameServerShutdownDeadlineKey] != "2024-01-01T00:00:30Z" {
+		t.Errorf("unexpected annotations %v", actual.GetAnnotations())
+	}
+
+	// sessions have not ended before the deadline
+	if err := s.SetStatusNumber("sessions", 2); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.WaitForDrained(ctx, "sessions", time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("expect deadline exceeded, but actually got %v", err)
+	}
+
+	// sessions have ended
+	if err := s.SetStatusNumber("sessions", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WaitForDrained(context.TODO(), "sessions", time.Millisecond); err != nil {
+		t.Errorf("expect drained, but actually got %v", err)
+	}
+
+	// acknowledged by the game server
+	if err := s.AckShutdown(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WaitForDrained(context.TODO(), "", time.Millisecond); err != nil {
+		t.Errorf("expect drained, but actually got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	kruisegameclientset "github.com/openkruise/kruise-game/pkg/client/clientset/versioned"
+)
+
+// Server serves the SDK gRPC API to the game process in the same pod. It is run by the sidecar of the game
+// server, whose service account needs the permission to get and watch GameServers.
+type Server struct {
+	UnimplementedSDKServer
+
+	client    kruisegameclientset.Interface
+	namespace string
+	name      string
+}
+
+// NewServer returns the Server of the pod it is running in, with the in-cluster config. The pod name and
+// namespace are resolved in the same way as NewSDK.
+func NewServer() (*Server, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kruisegameclientset.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	namespace, podName, err := podIdentity()
+	if err != nil {
+		return nil, err
+	}
+	return NewServerForPod(client, namespace, podName), nil
+}
+
+// NewServerForPod returns the Server of the given pod, whose GameServer has the same name.
+func NewServerForPod(client kruisegameclientset.Interface, namespace, podName string) *Server {
+	return &Server{
+		client:    client,
+		namespace: namespace,
+		name:      podName,
+	}
+}
+
+// WatchGameServer sends the current GameServer, and then pushes it each time the fields of the GameServer
+// message change, so that the game process is notified when, for example, the opsState is set to
+// WaitToBeDeleted. Changes of the other fields, such as the status conditions, are not pushed.
+func (s *Server) WatchGameServer(_ *WatchRequest, stream SDK_WatchGameServerServer) error {
+	return s.Watch(stream.Context(), stream.Send)
+}
+
+// Watch calls handler with the current GameServer, and then each time the fields of the GameServer message
+// change, until ctx is done or the GameServer is deleted. The errors are gRPC status errors.
+func (s *Server) Watch(ctx context.Context, handler func(*GameServer) error) error {
+	var last *GameServer
+	return s.watch(ctx, func(gs *gameKruiseV1alpha1.GameServer) error {
+		current := toGameServerMessage(gs)
+		if last != nil && proto.Equal(current, last) {
+			return nil
+		}
+		if err := handler(current); err != nil {
+			return err
+		}
+		last = current
+		return nil
+	})
+}
+
+// watch calls handler with the current GameServer, and then each time it is updated, until ctx is done or the
+// GameServer is deleted.
+func (s *Server) watch(ctx context.Context, handler func(*gameKruiseV1alpha1.GameServer) error) error {
+	gs, err := s.client.GameV1alpha1().GameServers(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return status.Errorf(codes.NotFound, "GameServer %s/%s not found", s.namespace, s.name)
+		}
+		return status.Errorf(codes.Unavailable, "failed to get GameServer %s/%s: %s", s.namespace, s.name, err.Error())
+	}
+	if err := handler(gs); err != nil {
+		return err
+	}
+
+	w, err := watchtools.NewRetryWatcher(gs.GetResourceVersion(), &cache.ListWatch{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", s.name).String()
+			return s.client.GameV1alpha1().GameServers(s.namespace).Watch(ctx, options)
+		},
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to watch GameServer %s/%s: %s", s.namespace, s.name, err.Error())
+	}
+	defer w.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return status.Errorf(codes.Unavailable, "watch of GameServer %s/%s closed", s.namespace, s.name)
+			}
+			switch event.Type {
+			case watch.Error:
+				return status.Errorf(codes.Unavailable, "failed to watch GameServer %s/%s: %s", s.namespace, s.name, apierrors.FromObject(event.Object).Error())
+			case watch.Added, watch.Modified, watch.Deleted:
+				gs, ok := event.Object.(*gameKruiseV1alpha1.GameServer)
+				if !ok || gs.GetName() != s.name {
+					continue
+				}
+				if event.Type == watch.Deleted {
+					return nil
+				}
+				if err := handler(gs); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func toGameServerMessage(gs *gameKruiseV1alpha1.GameServer) *GameServer {
+	return &GameServer{
+		Name:         gs.GetName(),
+		Namespace:    gs.GetNamespace(),
+		OpsState:     string(gs.Spec.OpsState),
+		CurrentState: string(gs.Status.CurrentState),
+		NetworkState: string(gs.Status.NetworkStatus.CurrentNetworkState),
+		Labels:       gs.GetLabels(),
+		Annotations:  gs.GetAnnotations(),
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	k8stesting "k8s.io/client-go/testing"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/client/clientset/versioned/fake"
+)
+
+type fakeWatchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *GameServer
+}
+
+func (f *fakeWatchStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeWatchStream) Send(gs *GameServer) error {
+	f.sent <- gs
+	return nil
+}
+
+func TestWatchGameServer(t *testing.T) {
+	gs := &gameKruiseV1alpha1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "xxx",
+			Name:            "case-0",
+			ResourceVersion: "1",
+			Labels:          map[string]string{"foo": "bar"},
+		},
+		Spec: gameKruiseV1alpha1.GameServerSpec{
+			OpsState: gameKruiseV1alpha1.None,
+		},
+		Status: gameKruiseV1alpha1.GameServerStatus{
+			CurrentState: gameKruiseV1alpha1.Ready,
+		},
+	}
+	client := fake.NewSimpleClientset(gs)
+	fakeWatch := watch.NewFake()
+	client.PrependWatchReactor("gameservers", k8stesting.DefaultWatchReactor(fakeWatch, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &fakeWatchStream{ctx: ctx, sent: make(chan *GameServer, 10)}
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServerForPod(client, "xxx", "case-0").WatchGameServer(&WatchRequest{}, stream)
+	}()
+
+	receive := func() *GameServer {
+		select {
+		case msg := <-stream.sent:
+			return msg
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the GameServer")
+			return nil
+		}
+	}
+
+	if msg := receive(); msg.GetOpsState() != string(gameKruiseV1alpha1.None) || msg.GetCurrentState() != string(gameKruiseV1alpha1.Ready) || msg.GetLabels()["foo"] != "bar" {
+		t.Errorf("unexpected initial GameServer %v", msg)
+	}
+
+	// the status conditions are not pushed
+	unrelated := gs.DeepCopy()
+	unrelated.ResourceVersion = "2"
+	unrelated.Status.Conditions = []gameKruiseV1alpha1.GameServerCondition{{Type: gameKruiseV1alpha1.PodNormal}}
+	fakeWatch.Modify(unrelated)
+
+	other := gs.DeepCopy()
+	other.Name = "case-1"
+	other.ResourceVersion = "3"
+	other.Spec.OpsState = gameKruiseV1alpha1.Maintaining
+	fakeWatch.Modify(other)
+
+	deleting := gs.DeepCopy()
+	deleting.ResourceVersion = "4"
+	deleting.Spec.OpsState = gameKruiseV1alpha1.WaitToDelete
+	deleting.Labels["foo"] = "baz"
+	fakeWatch.Modify(deleting)
+	if msg := receive(); msg.GetOpsState() != string(gameKruiseV1alpha1.WaitToDelete) || msg.GetLabels()["foo"] != "baz" {
+		t.Errorf("expect the GameServer waiting to be deleted, but actually got %v", msg)
+	}
+
+	deleted := deleting.DeepCopy()
+	deleted.ResourceVersion = "5"
+	fakeWatch.Delete(deleted)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expect the stream to end without error, but actually got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the stream to end")
+	}
+	if len(stream.sent) != 0 {
+		t.Errorf("expect no more GameServer pushed, but actually got %v", <-stream.sent)
+	}
+}
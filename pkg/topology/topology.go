@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/httpauth"
+)
+
+// Path is the path of the topology endpoint.
+const Path = "/topology"
+
+// The query parameters of the topology endpoint.
+const (
+	NamespaceParam     = "namespace"
+	GameServerSetParam = "gameServerSet"
+	SummaryParam       = "summary"
+)
+
+type GameServerView struct {
+	Namespace     string                             `json:"namespace"`
+	Name          string                             `json:"name"`
+	GameServerSet string                             `json:"gameServerSet"`
+	OpsState      gameKruiseV1alpha1.OpsState        `json:"opsState"`
+	CurrentState  gameKruiseV1alpha1.GameServerState `json:"currentState"`
+}
+
+// Summary counts the GameServers of a node or a zone.
+type Summary struct {
+	GameServers int `json:"gameServers"`
+	// OpsStates is the number of GameServers of each opsState.
+	OpsStates map[gameKruiseV1alpha1.OpsState]int `json:"opsStates"`
+	// Utilization is the ratio of Allocated GameServers.
+	Utilization float64 `json:"utilization"`
+}
+
+type NodeView struct {
+	Name string `json:"name"`
+	Summary
+	GameServers []GameServerView `json:"gameServerList,omitempty"`
+}
+
+type ZoneView struct {
+	Name string `json:"name"`
+	Summary
+	Nodes []*NodeView `json:"nodes"`
+}
+
+// Topology is the GameServers grouped by zones and nodes. The GameServers whose pods are not scheduled
+// are grouped into the node and the zone with empty names.
+type Topology struct {
+	Summary
+	Zones []*ZoneView `json:"zones"`
+}
+
+type Options struct {
+	Namespace     string
+	GameServerSet string
+	// Summary omits the GameServer list of each node.
+	Summary bool
+}
+
+func (s *Summary) add(opsState gameKruiseV1alpha1.OpsState) {
+	if s.OpsStates == nil {
+		s.OpsStates = make(map[gameKruiseV1alpha1.OpsState]int)
+	}
+	s.GameServers++
+	s.OpsStates[opsState]++
+	s.Utilization = float64(s.OpsStates[gameKruiseV1alpha1.Allocated]) / float64(s.GameServers)
+}
+
+// Aggregate groups the GameServers by the zones and the nodes which their pods are running on.
+func Aggregate(ctx context.Context, c client.Reader, opts Options) (*Topology, error) {
+	selector := labels.NewSelector()
+	var r *labels.Requirement
+	var err error
+	if opts.GameServerSet != "" {
+		r, err = labels.NewRequirement(gameKruiseV1alpha1.GameServerOwnerGssKey, selection.Equals, []string{opts.GameServerSet})
+	} else {
+		r, err = labels.NewRequirement(gameKruiseV1alpha1.GameServerOwnerGssKey, selection.Exists, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	selector = selector.Add(*r)
+	listOpts := []client.ListOption{client.MatchingLabelsSelector{Selector: selector}}
+	if opts.Namespace != "" {
+		listOpts = append(listOpts, client.InNamespace(opts.Namespace))
+	}
+
+	gsList := &gameKruiseV1alpha1.GameServerList{}
+	if err := c.List(ctx, gsList, listOpts...); err != nil {
+		return nil, err
+	}
+	podList := &corev1.PodList{}
+	if err := c.List(ctx, podList, listOpts...); err != nil {
+		return nil, err
+	}
+	nodeList := &corev1.NodeList{}
+	if err := c.List(ctx, nodeList); err != nil {
+		return nil, err
+	}
+
+	podNodes := make(map[string]string, len(podList.Items))
+	for _, pod := range podList.Items {
+		podNodes[pod.GetNamespace()+"/"+pod.GetName()] = pod.Spec.NodeName
+	}
+	nodeZones := make(map[string]string, len(nodeList.Items))
+	for _, node := range nodeList.Items {
+		nodeZones[node.GetName()] = node.GetLabels()[corev1.LabelTopologyZone]
+	}
+
+	t := &Topology{}
+	zones := make(map[string]*ZoneView)
+	nodes := make(map[string]*NodeView)
+	for _, gs := range gsList.Items {
+		opsState := gs.Spec.OpsState
+		if opsState == "" {
+			opsState = gameKruiseV1alpha1.None
+		}
+		nodeName := podNodes[gs.GetNamespace()+"/"+gs.GetName()]
+		zoneName := nodeZones[nodeName]
+
+		zone, ok := zones[zoneName]
+		if !ok {
+			zone = &ZoneView{Name: zoneName}
+			zones[zoneName] = zone
+			t.Zones = append(t.Zones, zone)
+		}
+		node, ok := nodes[nodeName]
+		if !ok {
+			node = &NodeView{Name: nodeName}
+			nodes[nodeName] = node
+			zone.Nodes = append(zone.Nodes, node)
+		}
+
+		t.add(opsState)
+		zone.add(opsState)
+		node.add(opsState)
+		if !opts.Summary {
+			node.GameServers = append(node.GameServers, GameServerView{
+				Namespace:     gs.GetNamespace(),
+				Name:          gs.GetName(),
+				GameServerSet: gs.GetLabels()[gameKruiseV1alpha1.GameServerOwnerGssKey],
+				OpsState:      opsState,
+				CurrentState:  gs.Status.CurrentState,
+			})
+		}
+	}
+
+	sort.Slice(t.Zones, func(i, j int) bool { return t.Zones[i].Name < t.Zones[j].Name })
+	for _, zone := range t.Zones {
+		sort.Slice(zone.Nodes, func(i, j int) bool { return zone.Nodes[i].Name < zone.Nodes[j].Name })
+	}
+	return t, nil
+}
+
+// NewHandler returns the handler of the topology endpoint, which reads objects from the cache of the client.
+// The requests are served for the users allowed to list the GameServers of the namespace queried.
+func NewHandler(c client.Client) http.Handler {
+	return httpauth.Authorized(c, getTopologyAttributes, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		query := req.URL.Query()
+		t, err := Aggregate(req.Context(), c, Options{
+			Namespace:     query.Get(NamespaceParam),
+			GameServerSet: query.Get(GameServerSetParam),
+			Summary:       query.Get(SummaryParam) == "true",
+		})
+		if err != nil {
+			klog.Errorf("failed to aggregate topology of GameServers, because of %s", err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(t); err != nil {
+			klog.Errorf("failed to write topology of GameServers, because of %s", err.Error())
+		}
+	}))
+}
+
+func getTopologyAttributes(req *http.Request) *authorizationv1.ResourceAttributes {
+	return &authorizationv1.ResourceAttributes{
+		Namespace: req.URL.Query().Get(NamespaceParam),
+		Verb:      "list",
+		Group:     gameKruiseV1alpha1.GroupVersion.Group,
+		Resource:  "gameservers",
+	}
+}
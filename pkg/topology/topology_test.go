@@ -0,0 +1,199 @@
+package topology
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+var (
+	scheme = runtime.NewScheme()
+)
+
+func init() {
+	utilruntime.Must(gameKruiseV1alpha1.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+}
+
+func TestAggregate(t *testing.T) {
+	newGs := func(namespace, name, gss string, opsState gameKruiseV1alpha1.OpsState) *gameKruiseV1alpha1.GameServer {
+		return &gameKruiseV1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: gss}},
+			Spec:       gameKruiseV1alpha1.GameServerSpec{OpsState: opsState},
+		}
+	}
+	newPod := func(namespace, name, gss, nodeName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: gss}},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+		}
+	}
+	newNode := func(name, zone string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{corev1.LabelTopologyZone: zone}}}
+	}
+	objs := []client.Object{
+		newGs("xxx", "case0-0", "case0", gameKruiseV1alpha1.Allocated),
+		newGs("xxx", "case0-1", "case0", gameKruiseV1alpha1.None),
+		newGs("xxx", "case0-2", "case0", ""),
+		newGs("xxx", "case0-3", "case0", gameKruiseV1alpha1.Maintaining),
+		newGs("yyy", "case1-0", "case1", gameKruiseV1alpha1.Allocated),
+		newPod("xxx", "case0-0", "case0", "node-a"),
+		newPod("xxx", "case0-1", "case0", "node-a"),
+		newPod("xxx", "case0-2", "case0", "node-b"),
+		newPod("xxx", "case0-3", "case0", ""),
+		newPod("yyy", "case1-0", "case1", "node-c"),
+		newNode("node-a", "zone-1"),
+		newNode("node-b", "zone-1"),
+		newNode("node-c", "zone-2"),
+	}
+	c := &reviewClient{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(), namespaces: map[string]string{"admin": ""}}
+
+	tests := []struct {
+		query    string
+		expected *Topology
+	}{
+		{
+			query: "?namespace=xxx&gameServerSet=case0",
+			expected: &Topology{
+				Summary: Summary{GameServers: 4, OpsStates: map[gameKruiseV1alpha1.OpsState]int{"Allocated": 1, "None": 2, "Maintaining": 1}, Utilization: 0.25},
+				Zones: []*ZoneView{
+					{
+						Summary: Summary{GameServers: 1, OpsStates: map[gameKruiseV1alpha1.OpsState]int{"Maintaining": 1}},
+						Nodes: []*NodeView{
+							{
+								Summary:     Summary{GameServers: 1, OpsStates: map[gameKruiseV1alpha1.OpsState]int{"Maintaining": 1}},
+								GameServers: []GameServerView{{Namespace: "xxx", Name: "case0-3", GameServerSet: "case0", OpsState: "Maintaining"}},
+							},
+						},
+					},
+					{
+						Name:    "zone-1",
+						Summary: Summary{GameServers: 3, OpsStates: map[gameKruiseV1alpha1.OpsState]int{"Allocated": 1, "None": 2}, Utilization: 1.0 / 3},
+						Nodes: []*NodeView{
+							{
+								Name:    "node-a",
+								Summary: Summary{GameServers: 2, OpsStates: map[gameKruiseV1alpha1.OpsState]int{"Allocated": 1, "None": 1}, Utilization: 0.5},
+								GameServers: []GameServerView{
+									{Namespace: "xxx", Name: "case0-0", GameServerSet: "case0", OpsState: "Allocated"},
+									{Namespace: "xxx", Name: "case0-1", GameServerSet: "case0", OpsState: "None"},
+								},
+							},
+							{
+								Name:        "node-b",
+								Summary:     Summary{GameServers: 1, OpsStates: map[gameKruiseV1alpha1.OpsState]int{"None": 1}},
+								GameServers: []GameServerView{{Namespace: "xxx", Name: "case0-2", GameServerSet: "case0", OpsState: "None"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			query: "?summary=true&namespace=yyy",
+			expected: &Topology{
+				Summary: Summary{GameServers: 1, OpsStates: map[gameKruiseV1alpha1.OpsState]int{"Allocated": 1}, Utilization: 1},
+				Zones: []*ZoneView{
+					{
+						Name:    "zone-2",
+						Summary: Summary{GameServers: 1, OpsStates: map[gameKruiseV1alpha1.OpsState]int{"Allocated": 1}, Utilization: 1},
+						Nodes: []*NodeView{
+							{
+								Name:    "node-c",
+								Summary: Summary{GameServers: 1, OpsStates: map[gameKruiseV1alpha1.OpsState]int{"Allocated": 1}, Utilization: 1},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		NewHandler(c).ServeHTTP(rec, newTopologyRequest(test.query, "admin"))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expect status 200, but actually got %d", test.query, rec.Code)
+			continue
+		}
+		actual := &Topology{}
+		if err := json.Unmarshal(rec.Body.Bytes(), actual); err != nil {
+			t.Errorf("%s: %s", test.query, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			expected, _ := json.Marshal(test.expected)
+			t.Errorf("%s: expect %s, but actually got %s", test.query, expected, rec.Body.String())
+		}
+	}
+}
+
+func TestHandlerAuthorization(t *testing.T) {
+	c := &reviewClient{Client: fake.NewClientBuilder().WithScheme(scheme).Build(), namespaces: map[string]string{"admin": "", "ops": "xxx"}}
+	tests := []struct {
+		query string
+		token string
+		code  int
+	}{
+		{query: "", token: "admin", code: http.StatusOK},
+		{query: "?namespace=xxx", token: "ops", code: http.StatusOK},
+		{query: "", token: "ops", code: http.StatusForbidden},
+		{query: "?namespace=yyy", token: "ops", code: http.StatusForbidden},
+		{query: "?namespace=xxx", token: "unknown", code: http.StatusUnauthorized},
+		{query: "?namespace=xxx", token: "", code: http.StatusUnauthorized},
+	}
+
+	for i, test := range tests {
+		rec := httptest.NewRecorder()
+		NewHandler(c).ServeHTTP(rec, newTopologyRequest(test.query, test.token))
+		if rec.Code != test.code {
+			t.Errorf("case %d: expect status %d, but actually got %d", i, test.code, rec.Code)
+		}
+	}
+}
+
+// reviewClient authenticates the tokens as the users of the same names, and allows each of them to list the
+// GameServers of the namespace it is mapped to, or of all namespaces if it is mapped to empty.
+type reviewClient struct {
+	client.Client
+	namespaces map[string]string
+}
+
+func (c *reviewClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	switch review := obj.(type) {
+	case *authenticationv1.TokenReview:
+		if _, ok := c.namespaces[review.Spec.Token]; ok {
+			review.Status.Authenticated = true
+			review.Status.User.Username = review.Spec.Token
+		}
+		return nil
+	case *authorizationv1.SubjectAccessReview:
+		attributes := review.Spec.ResourceAttributes
+		namespace, ok := c.namespaces[review.Spec.User]
+		review.Status.Allowed = ok && attributes.Verb == "list" && attributes.Resource == "gameservers" &&
+			attributes.Group == gameKruiseV1alpha1.GroupVersion.Group && (namespace == "" || namespace == attributes.Namespace)
+		return nil
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func newTopologyRequest(query, token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, Path+query, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
@@ -30,6 +30,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/openkruise/kruise-game/pkg/readonly"
 )
 
 var (
@@ -66,7 +68,7 @@ func NewClient(cache cache.Cache, config *rest.Config, options client.Options, u
 			scheme:           c.Scheme(),
 			uncachedGVKs:     uncachedGVKs,
 		},
-		Writer:       c,
+		Writer:       readonly.NewWriter(c),
 		StatusClient: c,
 	}, nil
 }
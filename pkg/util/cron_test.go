@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	// 2024-06-15 is Saturday
+	now := time.Date(2024, 6, 15, 21, 7, 30, 0, time.UTC)
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+
+	tests := []struct {
+		spec string
+		now  time.Time
+		next time.Time
+	}{
+		{
+			spec: "0 4 * * *",
+			now:  now,
+			next: time.Date(2024, 6, 16, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			spec: "*/15 * * * *",
+			now:  now,
+			next: time.Date(2024, 6, 15, 21, 15, 0, 0, time.UTC),
+		},
+		{
+			spec: "30 2 * * 1-5",
+			now:  now,
+			next: time.Date(2024, 6, 17, 2, 30, 0, 0, time.UTC),
+		},
+		{
+			spec: "0 0 1 * 7",
+			now:  now,
+			next: time.Date(2024, 6, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			spec: "0 3 29 2 *",
+			now:  now,
+			next: time.Date(2028, 2, 29, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			// 05:07 of June 16 in Asia/Shanghai
+			spec: "0 4 * * *",
+			now:  now.In(shanghai),
+			next: time.Date(2024, 6, 17, 4, 0, 0, 0, shanghai),
+		},
+		{
+			spec: "0 0 30 2 *",
+			now:  now,
+			next: time.Time{},
+		},
+	}
+
+	for _, test := range tests {
+		schedule, err := ParseCron(test.spec)
+		if err != nil {
+			t.Fatalf("cron %s: %v", test.spec, err)
+		}
+		if actual := schedule.Next(test.now); !actual.Equal(test.next) {
+			t.Errorf("cron %s: expect next %v, but actually got %v", test.spec, test.next, actual)
+		}
+	}
+}
+
+func TestCronScheduleLatest(t *testing.T) {
+	schedule, err := ParseCron("0 */2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 6, 15, 5, 30, 0, 0, time.UTC)
+	if actual, expected := schedule.Latest(now, 3*time.Hour), time.Date(2024, 6, 15, 4, 0, 0, 0, time.UTC); !actual.Equal(expected) {
+		t.Errorf("expect latest %v, but actually got %v", expected, actual)
+	}
+	if actual := schedule.Latest(now, time.Hour); !actual.IsZero() {
+		t.Errorf("expect no latest time, but actually got %v", actual)
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, spec := range []string{"", "0 4 * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("expect cron %q to be invalid", spec)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+const freezeWindowTimeFormat = "15:04"
+
+// ValidateFreezeWindow checks whether the days, start, end and time zone of the window are valid.
+func ValidateFreezeWindow(window gameKruiseV1alpha1.UpdateFreezeWindow) error {
+	for _, day := range window.Days {
+		if _, err := parseWeekday(day); err != nil {
+			return err
+		}
+	}
+	if _, err := time.Parse(freezeWindowTimeFormat, window.Start); err != nil {
+		return fmt.Errorf("invalid freeze window start %s, it should be in the format of HH:MM", window.Start)
+	}
+	if _, err := time.Parse(freezeWindowTimeFormat, window.End); err != nil {
+		return fmt.Errorf("invalid freeze window end %s, it should be in the format of HH:MM", window.End)
+	}
+	if _, err := time.LoadLocation(window.TimeZone); err != nil {
+		return fmt.Errorf("invalid freeze window time zone %s", window.TimeZone)
+	}
+	return nil
+}
+
+// IsInFreezeWindows returns whether now is in any of the freeze windows,
+// and the duration to wait until all the windows covering now end.
+func IsInFreezeWindows(windows []gameKruiseV1alpha1.UpdateFreezeWindow, now time.Time) (bool, time.Duration) {
+	inWindow := false
+	var remaining time.Duration
+	for _, window := range windows {
+		in, r := isInFreezeWindow(window, now)
+		if in {
+			inWindow = true
+			if r > remaining {
+				remaining = r
+			}
+		}
+	}
+	return inWindow, remaining
+}
+
+func isInFreezeWindow(window gameKruiseV1alpha1.UpdateFreezeWindow, now time.Time) (bool, time.Duration) {
+	if ValidateFreezeWindow(window) != nil {
+		return false, 0
+	}
+	loc, _ := time.LoadLocation(window.TimeZone)
+	start, _ := time.Parse(freezeWindowTimeFormat, window.Start)
+	end, _ := time.Parse(freezeWindowTimeFormat, window.End)
+
+	t := now.In(loc)
+	// the window started yesterday may be still in effect when it ends on the next day
+	for _, offset := range []int{0, -1} {
+		day := time.Date(t.Year(), t.Month(), t.Day()+offset, 0, 0, 0, 0, loc)
+		if !isWeekdayInList(day.Weekday(), window.Days) {
+			continue
+		}
+		startTime := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		endTime := time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+		if !endTime.After(startTime) {
+			endTime = endTime.AddDate(0, 0, 1)
+		}
+		if !t.Before(startTime) && t.Before(endTime) {
+			return true, endTime.Sub(t)
+		}
+	}
+	return false, 0
+}
+
+func isWeekdayInList(weekday time.Weekday, days []string) bool {
+	if len(days) == 0 {
+		return true
+	}
+	for _, day := range days {
+		if d, err := parseWeekday(day); err == nil && d == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func parseWeekday(day string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), day) {
+			return d, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("invalid freeze window day %s, it should be one of Sunday, Monday, ..., Saturday", day)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+	"time"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestIsInFreezeWindows(t *testing.T) {
+	// 2024-06-15 is Saturday
+	saturdayNight := time.Date(2024, 6, 15, 21, 0, 0, 0, time.UTC)
+	sundayMorning := time.Date(2024, 6, 16, 1, 0, 0, 0, time.UTC)
+	mondayNight := time.Date(2024, 6, 17, 21, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		windows   []gameKruiseV1alpha1.UpdateFreezeWindow
+		now       time.Time
+		inWindow  bool
+		remaining time.Duration
+	}{
+		{
+			windows:   nil,
+			now:       saturdayNight,
+			inWindow:  false,
+			remaining: 0,
+		},
+		{
+			windows: []gameKruiseV1alpha1.UpdateFreezeWindow{
+				{
+					Days:  []string{"Saturday", "sunday"},
+					Start: "18:00",
+					End:   "23:00",
+				},
+			},
+			now:       saturdayNight,
+			inWindow:  true,
+			remaining: 2 * time.Hour,
+		},
+		{
+			windows: []gameKruiseV1alpha1.UpdateFreezeWindow{
+				{
+					Days:  []string{"Saturday", "Sunday"},
+					Start: "18:00",
+					End:   "23:00",
+				},
+			},
+			now:       mondayNight,
+			inWindow:  false,
+			remaining: 0,
+		},
+		{
+			windows: []gameKruiseV1alpha1.UpdateFreezeWindow{
+				{
+					Days:  []string{"Saturday"},
+					Start: "20:00",
+					End:   "02:00",
+				},
+			},
+			now:       sundayMorning,
+			inWindow:  true,
+			remaining: time.Hour,
+		},
+		{
+			windows: []gameKruiseV1alpha1.UpdateFreezeWindow{
+				{
+					Start:    "04:00",
+					End:      "06:00",
+					TimeZone: "Asia/Shanghai",
+				},
+				{
+					Start: "20:00",
+					End:   "22:00",
+				},
+			},
+			now:       saturdayNight,
+			inWindow:  true,
+			remaining: time.Hour,
+		},
+		{
+			windows: []gameKruiseV1alpha1.UpdateFreezeWindow{
+				{
+					Start: "25:00",
+					End:   "22:00",
+				},
+			},
+			now:       saturdayNight,
+			inWindow:  false,
+			remaining: 0,
+		},
+	}
+
+	for i, test := range tests {
+		inWindow, remaining := IsInFreezeWindows(test.windows, test.now)
+		if inWindow != test.inWindow || remaining != test.remaining {
+			t.Errorf("case %d: expect (%v, %v), but actually got (%v, %v)", i, test.inWindow, test.remaining, inWindow, remaining)
+		}
+	}
+}
+
+func TestValidateFreezeWindow(t *testing.T) {
+	tests := []struct {
+		window gameKruiseV1alpha1.UpdateFreezeWindow
+		valid  bool
+	}{
+		{
+			window: gameKruiseV1alpha1.UpdateFreezeWindow{
+				Days:     []string{"Friday"},
+				Start:    "18:00",
+				End:      "23:30",
+				TimeZone: "Asia/Shanghai",
+			},
+			valid: true,
+		},
+		{
+			window: gameKruiseV1alpha1.UpdateFreezeWindow{
+				Days:  []string{"Weekend"},
+				Start: "18:00",
+				End:   "23:30",
+			},
+			valid: false,
+		},
+		{
+			window: gameKruiseV1alpha1.UpdateFreezeWindow{
+				Start: "6pm",
+				End:   "23:30",
+			},
+			valid: false,
+		},
+		{
+			window: gameKruiseV1alpha1.UpdateFreezeWindow{
+				Start:    "18:00",
+				End:      "23:30",
+				TimeZone: "Mars/Base",
+			},
+			valid: false,
+		},
+	}
+
+	for i, test := range tests {
+		err := ValidateFreezeWindow(test.window)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got err %v", i, test.valid, err)
+		}
+	}
+}
@@ -24,6 +24,7 @@ import (
 
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -46,8 +47,40 @@ func (dg DeleteSequenceGs) Swap(i, j int) {
 }
 
 func (dg DeleteSequenceGs) Less(i, j int) bool {
-	iLabels := dg[i].GetLabels()
-	jLabels := dg[j].GetLabels()
+	if less, ok := deletePriorityLess(&dg[i], &dg[j]); ok {
+		return less
+	}
+	// Index Number
+	return GetIndexFromGsName(dg[i].GetName()) > GetIndexFromGsName(dg[j].GetName())
+}
+
+// ScoredDeleteSequenceGs sorts pods like DeleteSequenceGs, except that pods with lower scores
+// are deleted first when their OpsState and deletion priority are the same.
+type ScoredDeleteSequenceGs struct {
+	DeleteSequenceGs
+	Scores map[string]float64
+}
+
+func (sg ScoredDeleteSequenceGs) Less(i, j int) bool {
+	dg := sg.DeleteSequenceGs
+	if less, ok := deletePriorityLess(&dg[i], &dg[j]); ok {
+		return less
+	}
+	// Score
+	iScore := sg.Scores[dg[i].GetName()]
+	jScore := sg.Scores[dg[j].GetName()]
+	if iScore != jScore {
+		return iScore < jScore
+	}
+	// Index Number
+	return GetIndexFromGsName(dg[i].GetName()) > GetIndexFromGsName(dg[j].GetName())
+}
+
+// deletePriorityLess compares the pods by OpsState and deletion priority.
+// It returns false as the second value if they are the same.
+func deletePriorityLess(i, j *corev1.Pod) (bool, bool) {
+	iLabels := i.GetLabels()
+	jLabels := j.GetLabels()
 	iOpsStatePriority := opsStateDeletePrority(iLabels[gameKruiseV1alpha1.GameServerOpsStateKey])
 	jOpsStatePriority := opsStateDeletePrority(jLabels[gameKruiseV1alpha1.GameServerOpsStateKey])
 	iDeletionPriority := iLabels[gameKruiseV1alpha1.GameServerDeletePriorityKey]
@@ -55,16 +88,15 @@ func (dg DeleteSequenceGs) Less(i, j int) bool {
 
 	// OpsState
 	if iOpsStatePriority != jOpsStatePriority {
-		return iOpsStatePriority > jOpsStatePriority
+		return iOpsStatePriority > jOpsStatePriority, true
 	}
 	// Deletion Priority
 	if iDeletionPriority != jDeletionPriority {
 		iDeletionPriorityInt, _ := strconv.Atoi(iDeletionPriority)
 		jDeletionPriorityInt, _ := strconv.Atoi(jDeletionPriority)
-		return iDeletionPriorityInt > jDeletionPriorityInt
+		return iDeletionPriorityInt > jDeletionPriorityInt, true
 	}
-	// Index Number
-	return GetIndexFromGsName(dg[i].GetName()) > GetIndexFromGsName(dg[j].GetName())
+	return false, false
 }
 
 func opsStateDeletePrority(opsState string) int {
@@ -105,6 +137,48 @@ func GetIndexListFromGsList(gsList []gameKruiseV1alpha1.GameServer) []int {
 	return indexList
 }
 
+// getUpdateBlockers returns the labels of pods which block the update of pods until they are released.
+func getUpdateBlockers(gss *gameKruiseV1alpha1.GameServerSet) []string {
+	var blockers []string
+	if gss.Spec.UpdateStrategy.UpdateGate != nil {
+		blockers = append(blockers, gameKruiseV1alpha1.UpdateGateBlocker)
+	}
+	if gss.Spec.UpdateStrategy.PreUpdateJob != nil {
+		blockers = append(blockers, gameKruiseV1alpha1.PreUpdateJobBlocker)
+	}
+	return blockers
+}
+
+// removeLifecycleBlockers removes the blockers from the lifecycle hooks of Advanced StatefulSet,
+// and drops the hooks left empty.
+func removeLifecycleBlockers(asts *kruiseV1beta1.StatefulSet, blockers ...string) {
+	lifecycle := asts.Spec.Lifecycle
+	if lifecycle == nil {
+		return
+	}
+	for _, blocker := range blockers {
+		if lifecycle.InPlaceUpdate != nil {
+			delete(lifecycle.InPlaceUpdate.LabelsHandler, blocker)
+		}
+		if lifecycle.PreDelete != nil {
+			delete(lifecycle.PreDelete.LabelsHandler, blocker)
+		}
+	}
+	if isEmptyLifecycleHook(lifecycle.InPlaceUpdate) {
+		lifecycle.InPlaceUpdate = nil
+	}
+	if isEmptyLifecycleHook(lifecycle.PreDelete) {
+		lifecycle.PreDelete = nil
+	}
+	if lifecycle.InPlaceUpdate == nil && lifecycle.PreDelete == nil {
+		asts.Spec.Lifecycle = nil
+	}
+}
+
+func isEmptyLifecycleHook(hook *appspub.LifecycleHook) bool {
+	return hook != nil && len(hook.LabelsHandler) == 0 && len(hook.FinalizersHandler) == 0 && !hook.MarkPodNotReady
+}
+
 func GetNewAstsFromGss(gss *gameKruiseV1alpha1.GameServerSet, asts *kruiseV1beta1.StatefulSet) *kruiseV1beta1.StatefulSet {
 	// default: set ParallelPodManagement
 	asts.Spec.PodManagementPolicy = apps.ParallelPodManagement
@@ -115,6 +189,15 @@ func GetNewAstsFromGss(gss *gameKruiseV1alpha1.GameServerSet, asts *kruiseV1beta
 		podLabels = make(map[string]string)
 	}
 	podLabels[gameKruiseV1alpha1.GameServerOwnerGssKey] = gss.GetName()
+	for _, blocker := range getUpdateBlockers(gss) {
+		// pods are hooked from creation, and released by GameServer controller after the pre-update Job succeeds
+		// or the GameServer passes the UpdateGate
+		podLabels[blocker] = "true"
+	}
+	if gss.Spec.PreDeleteHook != nil {
+		// released by GameServer controller after the game server acknowledges the shutdown or the hook times out
+		podLabels[gameKruiseV1alpha1.PreDeleteHookBlocker] = "true"
+	}
 	asts.Spec.Template.SetLabels(podLabels)
 
 	// set pod annotations
@@ -127,10 +210,26 @@ func GetNewAstsFromGss(gss *gameKruiseV1alpha1.GameServerSet, asts *kruiseV1beta
 		podAnnotations[gameKruiseV1alpha1.GameServerNetworkConf] = string(networkConfig)
 		podAnnotations[gameKruiseV1alpha1.GameServerNetworkType] = gss.Spec.Network.NetworkType
 	}
+	if gss.Spec.GameContainerName != "" {
+		if podAnnotations == nil {
+			podAnnotations = make(map[string]string)
+		}
+		podAnnotations[gameKruiseV1alpha1.GameContainerKey] = gss.Spec.GameContainerName
+	}
+	if _, revision := GetHotUpdateHashes(gss); revision != "" {
+		if podAnnotations == nil {
+			podAnnotations = make(map[string]string)
+		}
+		// the game process is signalled by okg-sdk-sidecar when the revision is changed
+		podAnnotations[gameKruiseV1alpha1.HotUpdateRevisionKey] = revision
+	}
 	asts.Spec.Template.SetAnnotations(podAnnotations)
 
 	// set template spec
 	asts.Spec.Template.Spec = gss.Spec.GameServerTemplate.Spec
+	if gss.Spec.GameContainerName != "" {
+		asts.Spec.Template.Spec.Containers = InjectGameContainerEnv(gss.Spec.GameServerTemplate.Spec.Containers, gss.Spec.GameContainerName)
+	}
 	// default: add InPlaceUpdateReady condition
 	readinessGates := gss.Spec.GameServerTemplate.Spec.ReadinessGates
 	readinessGates = append(readinessGates, corev1.PodReadinessGate{ConditionType: appspub.InPlaceUpdateReady})
@@ -146,6 +245,44 @@ func GetNewAstsFromGss(gss *gameKruiseV1alpha1.GameServerSet, asts *kruiseV1beta
 		}
 	}
 
+	// the blockers of disabled features are removed from the lifecycle hooks of the existing workload
+	removeLifecycleBlockers(asts, gameKruiseV1alpha1.PreUpdateJobBlocker, gameKruiseV1alpha1.UpdateGateBlocker, gameKruiseV1alpha1.PreDeleteHookBlocker)
+
+	// PreUpdateJob and UpdateGate block both in-place update and recreate update, the latter deletes pods first
+	for _, blocker := range getUpdateBlockers(gss) {
+		if asts.Spec.Lifecycle == nil {
+			asts.Spec.Lifecycle = &appspub.Lifecycle{}
+		}
+		if asts.Spec.Lifecycle.InPlaceUpdate == nil {
+			asts.Spec.Lifecycle.InPlaceUpdate = &appspub.LifecycleHook{}
+		}
+		if asts.Spec.Lifecycle.InPlaceUpdate.LabelsHandler == nil {
+			asts.Spec.Lifecycle.InPlaceUpdate.LabelsHandler = make(map[string]string)
+		}
+		asts.Spec.Lifecycle.InPlaceUpdate.LabelsHandler[blocker] = "true"
+		if asts.Spec.Lifecycle.PreDelete == nil {
+			asts.Spec.Lifecycle.PreDelete = &appspub.LifecycleHook{}
+		}
+		if asts.Spec.Lifecycle.PreDelete.LabelsHandler == nil {
+			asts.Spec.Lifecycle.PreDelete.LabelsHandler = make(map[string]string)
+		}
+		asts.Spec.Lifecycle.PreDelete.LabelsHandler[blocker] = "true"
+	}
+
+	// PreDeleteHook blocks only the deletion of pods
+	if gss.Spec.PreDeleteHook != nil {
+		if asts.Spec.Lifecycle == nil {
+			asts.Spec.Lifecycle = &appspub.Lifecycle{}
+		}
+		if asts.Spec.Lifecycle.PreDelete == nil {
+			asts.Spec.Lifecycle.PreDelete = &appspub.LifecycleHook{}
+		}
+		if asts.Spec.Lifecycle.PreDelete.LabelsHandler == nil {
+			asts.Spec.Lifecycle.PreDelete.LabelsHandler = make(map[string]string)
+		}
+		asts.Spec.Lifecycle.PreDelete.LabelsHandler[gameKruiseV1alpha1.PreDeleteHookBlocker] = "true"
+	}
+
 	// set VolumeClaimTemplates
 	asts.Spec.VolumeClaimTemplates = gss.Spec.GameServerTemplate.VolumeClaimTemplates
 
@@ -193,11 +330,25 @@ func GetAstsHash(gss *gameKruiseV1alpha1.GameServerSet) string {
 	if gss.Spec.Network != nil {
 		networkConfigs = gss.Spec.Network.NetworkConf
 	}
-	return GetHash(astsToUpdate{
+	hash := GetHash(astsToUpdate{
 		UpdateStrategy: gss.Spec.UpdateStrategy,
 		Template:       gss.Spec.GameServerTemplate,
 		NetworkConfigs: networkConfigs,
 	})
+	if gss.Spec.GameContainerName != "" {
+		// the hash of GameServerSets without GameContainerName is kept, so that their asts are not updated
+		hash = GetHash([]string{hash, gss.Spec.GameContainerName})
+	}
+	if gss.Spec.PreDeleteHook != nil {
+		// only whether the hook is set affects the asts, its endpoint is read by GameServer controller
+		hash = GetHash([]string{hash, gameKruiseV1alpha1.PreDeleteHookBlocker})
+	}
+	return hash
+}
+
+// GetGsTemplateHash returns the hash of GameServerTemplate, which changes when the pod template is rolled out.
+func GetGsTemplateHash(gss *gameKruiseV1alpha1.GameServerSet) string {
+	return GetHash(gss.Spec.GameServerTemplate)
 }
 
 func GetGsTemplateMetadataHash(gss *gameKruiseV1alpha1.GameServerSet) string {
@@ -233,6 +384,59 @@ func GetGameServerSetOfPod(pod *corev1.Pod, c client.Client, ctx context.Context
 	return gss, err
 }
 
+// IsServiceRetained returns whether the Service of the pod is retained after its GameServerSet is deleted,
+// in which case the network resources allocated to the Service should not be released.
+func IsServiceRetained(pod *corev1.Pod, c client.Client, ctx context.Context) (bool, error) {
+	svc := &corev1.Service{}
+	err := c.Get(ctx, types.NamespacedName{
+		Namespace: pod.GetNamespace(),
+		Name:      pod.GetName(),
+	}, svc)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return svc.GetAnnotations()[gameKruiseV1alpha1.IdentityRetainedFromKey] != "", nil
+}
+
+// The keys of the ConfigMap which records the retained identity of GameServerSet.
+const (
+	identityReserveGameServerIdsKey = "reserveGameServerIds"
+	identityReserveOrdinalsKey      = "reserveOrdinals"
+)
+
+// GetIdentityRecordName returns the name of the ConfigMap which records the retained identity of GameServerSet.
+func GetIdentityRecordName(gssName string) string {
+	return gssName + "-identity"
+}
+
+// NewIdentityRecord returns the ConfigMap which records the reserved IDs of GameServerSet and the reserve ordinals of its workload.
+func NewIdentityRecord(namespace, gssName string, reserveIds, reserveOrdinals []int) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      GetIdentityRecordName(gssName),
+			Annotations: map[string]string{
+				gameKruiseV1alpha1.IdentityRetainedFromKey: gssName,
+			},
+		},
+		Data: map[string]string{
+			identityReserveGameServerIdsKey: IntSliceToString(reserveIds, ","),
+			identityReserveOrdinalsKey:      IntSliceToString(reserveOrdinals, ","),
+		},
+	}
+}
+
+// ParseIdentityRecord returns the reserved IDs and the reserve ordinals recorded in the ConfigMap.
+func ParseIdentityRecord(cm *corev1.ConfigMap) ([]int, []int) {
+	if cm == nil {
+		return nil, nil
+	}
+	return StringToIntSlice(cm.Data[identityReserveGameServerIdsKey], ","), StringToIntSlice(cm.Data[identityReserveOrdinalsKey], ",")
+}
+
 func IsAllowNotReadyContainers(networkConfParams []gameKruiseV1alpha1.NetworkConfParams) bool {
 	for _, networkConfParam := range networkConfParams {
 		if networkConfParam.Name == gameKruiseV1alpha1.AllowNotReadyContainersNetworkConfName {
@@ -21,6 +21,9 @@ import (
 	"sort"
 	"testing"
 
+	appspub "github.com/openkruise/kruise-api/apps/pub"
+	kruiseV1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -442,3 +445,81 @@ func TestInitGameServer(t *testing.T) {
 		}
 	}
 }
+
+func TestGetNewAstsFromGssLifecycle(t *testing.T) {
+	tests := []struct {
+		preUpdateJob     *batchv1.JobTemplateSpec
+		oldLifecycle     *appspub.Lifecycle
+		expectLifecycle  *appspub.Lifecycle
+		expectPodBlocker string
+	}{
+		// the blockers of enabled features are kept
+		{
+			preUpdateJob: &batchv1.JobTemplateSpec{},
+			oldLifecycle: &appspub.Lifecycle{
+				InPlaceUpdate: &appspub.LifecycleHook{LabelsHandler: map[string]string{gameKruiseV1alpha1.PreUpdateJobBlocker: "true"}},
+				PreDelete:     &appspub.LifecycleHook{LabelsHandler: map[string]string{gameKruiseV1alpha1.PreUpdateJobBlocker: "true"}},
+			},
+			expectLifecycle: &appspub.Lifecycle{
+				InPlaceUpdate: &appspub.LifecycleHook{LabelsHandler: map[string]string{gameKruiseV1alpha1.PreUpdateJobBlocker: "true"}},
+				PreDelete:     &appspub.LifecycleHook{LabelsHandler: map[string]string{gameKruiseV1alpha1.PreUpdateJobBlocker: "true"}},
+			},
+			expectPodBlocker: gameKruiseV1alpha1.PreUpdateJobBlocker,
+		},
+		// the blockers of disabled features are removed
+		{
+			preUpdateJob: &batchv1.JobTemplateSpec{},
+			oldLifecycle: &appspub.Lifecycle{
+				InPlaceUpdate: &appspub.LifecycleHook{LabelsHandler: map[string]string{gameKruiseV1alpha1.UpdateGateBlocker: "true"}},
+				PreDelete: &appspub.LifecycleHook{LabelsHandler: map[string]string{
+					gameKruiseV1alpha1.UpdateGateBlocker:    "true",
+					gameKruiseV1alpha1.PreDeleteHookBlocker: "true",
+				}},
+			},
+			expectLifecycle: &appspub.Lifecycle{
+				InPlaceUpdate: &appspub.LifecycleHook{LabelsHandler: map[string]string{gameKruiseV1alpha1.PreUpdateJobBlocker: "true"}},
+				PreDelete:     &appspub.LifecycleHook{LabelsHandler: map[string]string{gameKruiseV1alpha1.PreUpdateJobBlocker: "true"}},
+			},
+			expectPodBlocker: gameKruiseV1alpha1.PreUpdateJobBlocker,
+		},
+		// the lifecycle is dropped when all features are disabled
+		{
+			oldLifecycle: &appspub.Lifecycle{
+				InPlaceUpdate: &appspub.LifecycleHook{LabelsHandler: map[string]string{gameKruiseV1alpha1.PreUpdateJobBlocker: "true"}},
+				PreDelete:     &appspub.LifecycleHook{LabelsHandler: map[string]string{gameKruiseV1alpha1.PreUpdateJobBlocker: "true"}},
+			},
+			expectLifecycle: nil,
+		},
+		// the hooks not managed by blockers are kept
+		{
+			oldLifecycle: &appspub.Lifecycle{
+				PreDelete: &appspub.LifecycleHook{FinalizersHandler: []string{"xxx"}, LabelsHandler: map[string]string{gameKruiseV1alpha1.PreDeleteHookBlocker: "true"}},
+			},
+			expectLifecycle: &appspub.Lifecycle{
+				PreDelete: &appspub.LifecycleHook{FinalizersHandler: []string{"xxx"}, LabelsHandler: map[string]string{}},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		gss := &gameKruiseV1alpha1.GameServerSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+			Spec: gameKruiseV1alpha1.GameServerSetSpec{
+				UpdateStrategy: gameKruiseV1alpha1.UpdateStrategy{PreUpdateJob: test.preUpdateJob},
+			},
+		}
+		asts := &kruiseV1beta1.StatefulSet{
+			Spec: kruiseV1beta1.StatefulSetSpec{Lifecycle: test.oldLifecycle},
+		}
+		asts = GetNewAstsFromGss(gss, asts)
+		if !reflect.DeepEqual(asts.Spec.Lifecycle, test.expectLifecycle) {
+			t.Errorf("case %d: expect lifecycle %v, but actually got %v", i, test.expectLifecycle, asts.Spec.Lifecycle)
+		}
+		for _, blocker := range []string{gameKruiseV1alpha1.PreUpdateJobBlocker, gameKruiseV1alpha1.UpdateGateBlocker, gameKruiseV1alpha1.PreDeleteHookBlocker} {
+			_, ok := asts.Spec.Template.GetLabels()[blocker]
+			if ok != (blocker == test.expectPodBlocker) {
+				t.Errorf("case %d: expect pod label %s set %v, but actually got %v", i, blocker, blocker == test.expectPodBlocker, ok)
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+type hotUpdateBase struct {
+	Template          gameKruiseV1alpha1.GameServerTemplate
+	NetworkConfigs    []gameKruiseV1alpha1.NetworkConfParams
+	GameContainerName string
+}
+
+type hotUpdateRevision struct {
+	Containers  []corev1.Container
+	Annotations map[string]string
+}
+
+// GetHotUpdateHashes returns the hash of the GameServerTemplate without the containers and annotations of HotUpdate,
+// and the hash of the latter, which is the revision of hot updates.
+// The template is hot updated when the former is not changed.
+func GetHotUpdateHashes(gss *gameKruiseV1alpha1.GameServerSet) (string, string) {
+	hotUpdate := gss.Spec.UpdateStrategy.HotUpdate
+	if hotUpdate == nil {
+		return "", ""
+	}
+
+	template := gss.Spec.GameServerTemplate.DeepCopy()
+	revision := hotUpdateRevision{}
+	var containers []corev1.Container
+	for _, container := range template.Spec.Containers {
+		if IsStringInList(container.Name, hotUpdate.Containers) {
+			revision.Containers = append(revision.Containers, container)
+			continue
+		}
+		containers = append(containers, container)
+	}
+	template.Spec.Containers = containers
+
+	annotations := template.GetAnnotations()
+	for _, key := range hotUpdate.Annotations {
+		value, ok := annotations[key]
+		if !ok {
+			continue
+		}
+		if revision.Annotations == nil {
+			revision.Annotations = make(map[string]string)
+		}
+		revision.Annotations[key] = value
+		delete(annotations, key)
+	}
+	template.SetAnnotations(annotations)
+
+	base := hotUpdateBase{
+		Template:          *template,
+		GameContainerName: gss.Spec.GameContainerName,
+	}
+	if gss.Spec.Network != nil {
+		base.NetworkConfigs = gss.Spec.Network.NetworkConf
+	}
+	return GetHash(base), GetHash(revision)
+}
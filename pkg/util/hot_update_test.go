@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestGetHotUpdateHashes(t *testing.T) {
+	newGss := func(gameImage, configImage, configVersion string) *gameKruiseV1alpha1.GameServerSet {
+		return &gameKruiseV1alpha1.GameServerSet{
+			Spec: gameKruiseV1alpha1.GameServerSetSpec{
+				GameServerTemplate: gameKruiseV1alpha1.GameServerTemplate{
+					PodTemplateSpec: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Annotations: map[string]string{"config-version": configVersion},
+						},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{Name: "game", Image: gameImage},
+								{Name: "config", Image: configImage},
+							},
+						},
+					},
+				},
+				UpdateStrategy: gameKruiseV1alpha1.UpdateStrategy{
+					HotUpdate: &gameKruiseV1alpha1.HotUpdate{
+						Containers:  []string{"config"},
+						Annotations: []string{"config-version"},
+					},
+				},
+			},
+		}
+	}
+
+	base, revision := GetHotUpdateHashes(newGss("game:v1", "config:v1", "1"))
+	if base == "" || revision == "" {
+		t.Fatalf("expect hashes of hot update but got %q and %q", base, revision)
+	}
+
+	tests := []struct {
+		gss             *gameKruiseV1alpha1.GameServerSet
+		baseChanged     bool
+		revisionChanged bool
+	}{
+		{
+			gss: newGss("game:v1", "config:v1", "1"),
+		},
+		{
+			gss:             newGss("game:v1", "config:v2", "1"),
+			revisionChanged: true,
+		},
+		{
+			gss:             newGss("game:v1", "config:v1", "2"),
+			revisionChanged: true,
+		},
+		{
+			gss:         newGss("game:v2", "config:v1", "1"),
+			baseChanged: true,
+		},
+	}
+
+	for i, test := range tests {
+		newBase, newRevision := GetHotUpdateHashes(test.gss)
+		if (newBase != base) != test.baseChanged {
+			t.Errorf("case %d: expect base changed %v but got %v", i, test.baseChanged, newBase != base)
+		}
+		if (newRevision != revision) != test.revisionChanged {
+			t.Errorf("case %d: expect revision changed %v but got %v", i, test.revisionChanged, newRevision != revision)
+		}
+	}
+
+	gss := newGss("game:v1", "config:v1", "1")
+	gss.Spec.UpdateStrategy.HotUpdate = nil
+	if base, revision := GetHotUpdateHashes(gss); base != "" || revision != "" {
+		t.Errorf("expect no hashes without hot update but got %q and %q", base, revision)
+	}
+}
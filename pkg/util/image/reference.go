@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	dockerHubDomain   = "docker.io"
+	dockerHubRegistry = "registry-1.docker.io"
+	defaultTag        = "latest"
+)
+
+// Reference is a parsed image reference.
+type Reference struct {
+	// Registry is the host of the registry, e.g. registry-1.docker.io
+	Registry string
+	// Repository is the path of the repository in the registry, e.g. library/nginx
+	Repository string
+	// Tag is empty if the image is referenced by digest
+	Tag    string
+	Digest string
+	// Name is the image without tag and digest, as it is written by the user
+	Name string
+}
+
+// ParseReference parses images like nginx, nginx:1.0, registry.example.com:5000/game/server:v1 and game/server@sha256:xxx.
+func ParseReference(image string) (Reference, error) {
+	if image == "" {
+		return Reference{}, fmt.Errorf("image is empty")
+	}
+	ref := Reference{}
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+		if !strings.Contains(ref.Digest, ":") {
+			return Reference{}, fmt.Errorf("invalid digest of image %s", image)
+		}
+	}
+	// the tag is after the last colon of the last path component
+	lastSlash := strings.LastIndex(name, "/")
+	if i := strings.LastIndex(name, ":"); i > lastSlash {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+	if name == "" {
+		return Reference{}, fmt.Errorf("invalid image %s", image)
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+	ref.Name = name
+
+	// the first component is a registry if it looks like a host
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry = parts[0]
+		ref.Repository = parts[1]
+	} else {
+		ref.Registry = dockerHubDomain
+		ref.Repository = name
+	}
+	if ref.Registry == dockerHubDomain || ref.Registry == "index."+dockerHubDomain {
+		ref.Registry = dockerHubRegistry
+		if !strings.Contains(ref.Repository, "/") {
+			ref.Repository = "library/" + ref.Repository
+		}
+	}
+	return ref, nil
+}
+
+// WithDigest returns the image pinned to the digest, the tag is dropped.
+func WithDigest(image, digest string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	return ref.Name + "@" + digest, nil
+}
@@ -0,0 +1,111 @@
+package image
+
+import (
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected Reference
+	}{
+		{
+			image: "nginx",
+			expected: Reference{
+				Registry:   "registry-1.docker.io",
+				Repository: "library/nginx",
+				Tag:        "latest",
+				Name:       "nginx",
+			},
+		},
+		{
+			image: "docker.io/game/server:v1",
+			expected: Reference{
+				Registry:   "registry-1.docker.io",
+				Repository: "game/server",
+				Tag:        "v1",
+				Name:       "docker.io/game/server",
+			},
+		},
+		{
+			image: "registry.example.com:5000/game/server:v1.2",
+			expected: Reference{
+				Registry:   "registry.example.com:5000",
+				Repository: "game/server",
+				Tag:        "v1.2",
+				Name:       "registry.example.com:5000/game/server",
+			},
+		},
+		{
+			image: "localhost/server@sha256:abc",
+			expected: Reference{
+				Registry:   "localhost",
+				Repository: "server",
+				Digest:     "sha256:abc",
+				Name:       "localhost/server",
+			},
+		},
+		{
+			image: "registry.example.com/server:v1@sha256:abc",
+			expected: Reference{
+				Registry:   "registry.example.com",
+				Repository: "server",
+				Tag:        "v1",
+				Digest:     "sha256:abc",
+				Name:       "registry.example.com/server",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		actual, err := ParseReference(test.image)
+		if err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("case %d: expect %+v, but actually got %+v", i, test.expected, actual)
+		}
+	}
+
+	for _, image := range []string{"", "server@abc", ":v1"} {
+		if _, err := ParseReference(image); err == nil {
+			t.Errorf("expect error for image %q", image)
+		}
+	}
+}
+
+func TestWithDigest(t *testing.T) {
+	tests := []struct {
+		image    string
+		digest   string
+		expected string
+	}{
+		{
+			image:    "nginx:1.25",
+			digest:   "sha256:abc",
+			expected: "nginx@sha256:abc",
+		},
+		{
+			image:    "registry.example.com:5000/game/server",
+			digest:   "sha256:abc",
+			expected: "registry.example.com:5000/game/server@sha256:abc",
+		},
+		{
+			image:    "registry.example.com/server@sha256:old",
+			digest:   "sha256:abc",
+			expected: "registry.example.com/server@sha256:abc",
+		},
+	}
+
+	for i, test := range tests {
+		actual, err := WithDigest(test.image, test.digest)
+		if err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("case %d: expect %s, but actually got %s", i, test.expected, actual)
+		}
+	}
+}
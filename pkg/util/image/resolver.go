@@ -0,0 +1,258 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const digestHeader = "Docker-Content-Digest"
+
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Credential is the credential to access a registry.
+type Credential struct {
+	Username string
+	Password string
+}
+
+// Resolver resolves image tags to digests.
+type Resolver interface {
+	// Resolve returns the digest of the image, credentials are keyed by registry host.
+	Resolve(ctx context.Context, image string, credentials map[string]Credential) (string, error)
+}
+
+type registryResolver struct {
+	client *http.Client
+}
+
+// NewResolver returns a Resolver which queries the manifests by the registry HTTP API V2.
+func NewResolver() Resolver {
+	return &registryResolver{
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (r *registryResolver) Resolve(ctx context.Context, image string, credentials map[string]Credential) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" {
+		return ref.Digest, nil
+	}
+	credential, hasCredential := credentials[ref.Registry]
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.Registry, ref.Repository, ref.Tag)
+
+	var authorization string
+	resp, err := r.getManifest(ctx, http.MethodHead, manifestURL, authorization)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		authorization, err = r.authorize(ctx, challenge, ref, credential, hasCredential)
+		if err != nil {
+			return "", err
+		}
+		resp, err = r.getManifest(ctx, http.MethodHead, manifestURL, authorization)
+		if err != nil {
+			return "", err
+		}
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get manifest of image %s, status code %d", image, resp.StatusCode)
+	}
+	if digest := resp.Header.Get(digestHeader); digest != "" {
+		return digest, nil
+	}
+
+	// some registries do not return the digest for HEAD requests, so compute it from the manifest
+	resp, err = r.getManifest(ctx, http.MethodGet, manifestURL, authorization)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get manifest of image %s, status code %d", image, resp.StatusCode)
+	}
+	if digest := resp.Header.Get(digestHeader); digest != "" {
+		return digest, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+func (r *registryResolver) getManifest(ctx context.Context, method, manifestURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ","))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return r.client.Do(req)
+}
+
+// authorize returns the Authorization header according to the challenge of the registry.
+func (r *registryResolver) authorize(ctx context.Context, challenge string, ref Reference, credential Credential, hasCredential bool) (string, error) {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !hasCredential {
+			return "", fmt.Errorf("registry %s requires credentials", ref.Registry)
+		}
+		return "Basic " + basicAuth(credential), nil
+	case "bearer":
+		realm := params["realm"]
+		if realm == "" {
+			return "", fmt.Errorf("registry %s returns bearer challenge without realm", ref.Registry)
+		}
+		tokenURL, err := url.Parse(realm)
+		if err != nil {
+			return "", err
+		}
+		query := tokenURL.Query()
+		if service := params["service"]; service != "" {
+			query.Set("service", service)
+		}
+		query.Set("scope", fmt.Sprintf("repository:%s:pull", ref.Repository))
+		tokenURL.RawQuery = query.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if hasCredential {
+			req.Header.Set("Authorization", "Basic "+basicAuth(credential))
+		}
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("failed to get token of registry %s, status code %d", ref.Registry, resp.StatusCode)
+		}
+		token := struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+			return "", err
+		}
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		return "Bearer " + token.Token, nil
+	}
+	return "", fmt.Errorf("registry %s returns unsupported challenge %q", ref.Registry, challenge)
+}
+
+// parseChallenge parses WWW-Authenticate header like `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`.
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := make(map[string]string)
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	for _, kv := range strings.Split(rest, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(kv), "=")
+		if !ok {
+			continue
+		}
+		params[strings.ToLower(k)] = strings.Trim(v, `"`)
+	}
+	return scheme, params
+}
+
+func basicAuth(credential Credential) string {
+	return base64.StdEncoding.EncodeToString([]byte(credential.Username + ":" + credential.Password))
+}
+
+// ParseDockerConfig parses the data of dockerconfigjson or dockercfg secret into credentials keyed by registry host.
+func ParseDockerConfig(data []byte) (map[string]Credential, error) {
+	type authEntry struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	}
+	config := struct {
+		Auths map[string]authEntry `json:"auths"`
+	}{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	auths := config.Auths
+	if auths == nil {
+		// legacy dockercfg has no auths wrapper
+		if err := json.Unmarshal(data, &auths); err != nil {
+			return nil, err
+		}
+	}
+
+	credentials := make(map[string]Credential)
+	for server, entry := range auths {
+		credential := Credential{Username: entry.Username, Password: entry.Password}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auth of registry %s: %s", server, err.Error())
+			}
+			username, password, ok := strings.Cut(string(decoded), ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid auth of registry %s", server)
+			}
+			credential = Credential{Username: username, Password: password}
+		}
+		credentials[normalizeRegistry(server)] = credential
+	}
+	return credentials, nil
+}
+
+// normalizeRegistry turns the server of docker config like https://index.docker.io/v1/ into registry host.
+func normalizeRegistry(server string) string {
+	host := server
+	if u, err := url.Parse(server); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	host, _, _ = strings.Cut(host, "/")
+	switch host {
+	case dockerHubDomain, "index." + dockerHubDomain:
+		return dockerHubRegistry
+	}
+	return host
+}
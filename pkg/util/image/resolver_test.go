@@ -0,0 +1,127 @@
+package image
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	manifest := []byte(`{"schemaVersion":2}`)
+	// sha256 of the manifest above
+	manifestDigest := "sha256:bafebd36189ad3688b7b3915ea55d461e0bfcfbdde11e54b0a123999fb6be50f"
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if r.URL.Query().Get("scope") != "repository:game/server:pull" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, `{"token":"secret-token"}`)
+		case r.URL.Path == "/v2/game/server/manifests/v1":
+			if r.Header.Get("Authorization") != "Bearer secret-token" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set(digestHeader, "sha256:abc")
+		case r.URL.Path == "/v2/game/basic/manifests/v1":
+			if r.Header.Get("Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("user:pass")) {
+				w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			// no digest header, the digest is computed from manifest
+			if r.Method == http.MethodGet {
+				w.Write(manifest)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	registry := strings.TrimPrefix(server.URL, "https://")
+	r := &registryResolver{client: server.Client()}
+
+	tests := []struct {
+		image       string
+		credentials map[string]Credential
+		digest      string
+		hasError    bool
+	}{
+		{
+			image:  registry + "/game/server:v1",
+			digest: "sha256:abc",
+		},
+		{
+			image:       registry + "/game/basic:v1",
+			credentials: map[string]Credential{registry: {Username: "user", Password: "pass"}},
+			digest:      manifestDigest,
+		},
+		{
+			image:    registry + "/game/basic:v1",
+			hasError: true,
+		},
+		{
+			image:    registry + "/game/server:v2",
+			hasError: true,
+		},
+		// already pinned
+		{
+			image:  registry + "/game/server@sha256:def",
+			digest: "sha256:def",
+		},
+	}
+
+	for i, test := range tests {
+		digest, err := r.Resolve(context.Background(), test.image, test.credentials)
+		if (err != nil) != test.hasError {
+			t.Errorf("case %d: expect error %v, but actually got %v", i, test.hasError, err)
+			continue
+		}
+		if digest != test.digest {
+			t.Errorf("case %d: expect digest %s, but actually got %s", i, test.digest, digest)
+		}
+	}
+}
+
+func TestParseDockerConfig(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("user:pass"))
+	tests := []struct {
+		data     string
+		expected map[string]Credential
+	}{
+		{
+			data: `{"auths":{"https://index.docker.io/v1/":{"auth":"` + auth + `"},"registry.example.com":{"username":"foo","password":"bar"}}}`,
+			expected: map[string]Credential{
+				"registry-1.docker.io": {Username: "user", Password: "pass"},
+				"registry.example.com": {Username: "foo", Password: "bar"},
+			},
+		},
+		// legacy dockercfg
+		{
+			data: `{"registry.example.com:5000":{"auth":"` + auth + `"}}`,
+			expected: map[string]Credential{
+				"registry.example.com:5000": {Username: "user", Password: "pass"},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		actual, err := ParseDockerConfig([]byte(test.data))
+		if err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.expected, actual)
+		}
+	}
+}
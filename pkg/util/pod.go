@@ -51,3 +51,103 @@ func IsContainersPreInplaceUpdating(pod *corev1.Pod, gss *gameKruiseV1alpha1.Gam
 	}
 	return false
 }
+
+// IsWindowsPodSpec returns whether the pod is declared to run on Windows nodes,
+// either by spec.os or by the kubernetes.io/os node selector.
+func IsWindowsPodSpec(spec *corev1.PodSpec) bool {
+	if spec.OS != nil {
+		return spec.OS.Name == corev1.Windows
+	}
+	return spec.NodeSelector[corev1.LabelOSStable] == string(corev1.Windows)
+}
+
+// GetGameContainerName returns the name of the game container of the pod, which is GameContainerName of the
+// GameServerSet, or the first container by default.
+func GetGameContainerName(pod *corev1.Pod) string {
+	if name := pod.GetAnnotations()[gameKruiseV1alpha1.GameContainerKey]; name != "" {
+		return name
+	}
+	if len(pod.Spec.Containers) == 0 {
+		return ""
+	}
+	return pod.Spec.Containers[0].Name
+}
+
+// IsGameContainerReady returns whether the game container designated by GameContainerName is ready, together with
+// all the readiness gates of the pod, regardless of the readiness of the other containers.
+// It returns false if the game container is not designated.
+func IsGameContainerReady(pod *corev1.Pod) bool {
+	name := pod.GetAnnotations()[gameKruiseV1alpha1.GameContainerKey]
+	if name == "" {
+		return false
+	}
+	for _, gate := range pod.Spec.ReadinessGates {
+		_, condition := GetPodConditionFromList(pod.Status.Conditions, gate.ConditionType)
+		if condition == nil || condition.Status != corev1.ConditionTrue {
+			return false
+		}
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == name {
+			return status.Ready
+		}
+	}
+	return false
+}
+
+// InjectGameContainerEnv injects the environment variables read by the SDK into the game container,
+// unless they are set already. The containers are copied rather than modified.
+func InjectGameContainerEnv(containers []corev1.Container, gameContainerName string) []corev1.Container {
+	injected := make([]corev1.Container, len(containers))
+	for i := range containers {
+		containers[i].DeepCopyInto(&injected[i])
+		if injected[i].Name != gameContainerName {
+			continue
+		}
+		for _, env := range []corev1.EnvVar{
+			{Name: gameKruiseV1alpha1.GameServerPodNameEnv, ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}}},
+			{Name: gameKruiseV1alpha1.GameServerPodNamespaceEnv, ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"}}},
+		} {
+			exist := false
+			for _, e := range injected[i].Env {
+				if e.Name == env.Name {
+					exist = true
+					break
+				}
+			}
+			if !exist {
+				injected[i].Env = append(injected[i].Env, env)
+			}
+		}
+	}
+	return injected
+}
+
+// GetResourceProfile returns the ResourceProfile of the name, or nil if it is not found.
+func GetResourceProfile(profiles []gameKruiseV1alpha1.ResourceProfile, name string) *gameKruiseV1alpha1.ResourceProfile {
+	for i := range profiles {
+		if profiles[i].Name == name {
+			return &profiles[i]
+		}
+	}
+	return nil
+}
+
+// ApplyResourceProfile sets the resources of the profile on the containers, where the containers of the profile
+// without names refer to the game container. The containers are copied rather than modified.
+func ApplyResourceProfile(containers []corev1.Container, profile *gameKruiseV1alpha1.ResourceProfile, gameContainerName string) []corev1.Container {
+	applied := make([]corev1.Container, len(containers))
+	for i := range containers {
+		containers[i].DeepCopyInto(&applied[i])
+		for _, pc := range profile.Containers {
+			name := pc.Name
+			if name == "" {
+				name = gameContainerName
+			}
+			if name == applied[i].Name {
+				pc.Resources.DeepCopyInto(&applied[i].Resources)
+			}
+		}
+	}
+	return applied
+}
@@ -19,6 +19,7 @@ package util
 import (
 	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"reflect"
 	"testing"
 )
@@ -192,3 +193,157 @@ func TestIsContainersPreInplaceUpdating(t *testing.T) {
 		}
 	}
 }
+
+func TestIsWindowsPodSpec(t *testing.T) {
+	tests := []struct {
+		spec      *corev1.PodSpec
+		isWindows bool
+	}{
+		{
+			spec:      &corev1.PodSpec{},
+			isWindows: false,
+		},
+		{
+			spec: &corev1.PodSpec{
+				OS: &corev1.PodOS{Name: corev1.Windows},
+			},
+			isWindows: true,
+		},
+		{
+			spec: &corev1.PodSpec{
+				NodeSelector: map[string]string{corev1.LabelOSStable: "windows"},
+			},
+			isWindows: true,
+		},
+		{
+			spec: &corev1.PodSpec{
+				OS:           &corev1.PodOS{Name: corev1.Linux},
+				NodeSelector: map[string]string{corev1.LabelOSStable: "windows"},
+			},
+			isWindows: false,
+		},
+	}
+
+	for i, test := range tests {
+		if actual := IsWindowsPodSpec(test.spec); actual != test.isWindows {
+			t.Errorf("case %d: expect %v but got %v", i, test.isWindows, actual)
+		}
+	}
+}
+
+func TestIsGameContainerReady(t *testing.T) {
+	newPod := func(gameContainer string, gameReady, sidecarReady bool, gate corev1.ConditionStatus) *corev1.Pod {
+		pod := &corev1.Pod{
+			Spec: corev1.PodSpec{
+				Containers:     []corev1.Container{{Name: "logtail"}, {Name: "game"}},
+				ReadinessGates: []corev1.PodReadinessGate{{ConditionType: "InPlaceUpdateReady"}},
+			},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{{Type: "InPlaceUpdateReady", Status: gate}},
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "logtail", Ready: sidecarReady},
+					{Name: "game", Ready: gameReady},
+				},
+			},
+		}
+		if gameContainer != "" {
+			pod.Annotations = map[string]string{gameKruiseV1alpha1.GameContainerKey: gameContainer}
+		}
+		return pod
+	}
+	tests := []struct {
+		pod   *corev1.Pod
+		ready bool
+	}{
+		// the game container is not designated
+		{
+			pod:   newPod("", true, false, corev1.ConditionTrue),
+			ready: false,
+		},
+		// the sidecar is not ready
+		{
+			pod:   newPod("game", true, false, corev1.ConditionTrue),
+			ready: true,
+		},
+		// the game container is not ready
+		{
+			pod:   newPod("game", false, true, corev1.ConditionTrue),
+			ready: false,
+		},
+		// the readiness gate is not ready
+		{
+			pod:   newPod("game", true, true, corev1.ConditionFalse),
+			ready: false,
+		},
+	}
+
+	for i, test := range tests {
+		if actual := IsGameContainerReady(test.pod); actual != test.ready {
+			t.Errorf("case %d: expect %v but got %v", i, test.ready, actual)
+		}
+	}
+}
+
+func TestInjectGameContainerEnv(t *testing.T) {
+	containers := []corev1.Container{
+		{Name: "logtail"},
+		{Name: "game", Env: []corev1.EnvVar{{Name: gameKruiseV1alpha1.GameServerPodNameEnv, Value: "custom"}}},
+	}
+	injected := InjectGameContainerEnv(containers, "game")
+	if len(containers[1].Env) != 1 {
+		t.Errorf("expect the containers not modified, but actually got %v", containers[1].Env)
+	}
+	if len(injected[0].Env) != 0 {
+		t.Errorf("expect no env injected into the sidecar, but actually got %v", injected[0].Env)
+	}
+	expectEnv := []corev1.EnvVar{
+		{Name: gameKruiseV1alpha1.GameServerPodNameEnv, Value: "custom"},
+		{Name: gameKruiseV1alpha1.GameServerPodNamespaceEnv, ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"}}},
+	}
+	if !reflect.DeepEqual(injected[1].Env, expectEnv) {
+		t.Errorf("expect env %v of the game container, but actually got %v", expectEnv, injected[1].Env)
+	}
+}
+
+func TestApplyResourceProfile(t *testing.T) {
+	small := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+	}
+	large := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("4Gi")},
+	}
+	sidecar := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+	}
+	profiles := []gameKruiseV1alpha1.ResourceProfile{
+		{
+			Name: "large",
+			Containers: []gameKruiseV1alpha1.ResourceProfileContainer{
+				{Resources: large},
+				{Name: "logtail", Resources: sidecar},
+			},
+		},
+	}
+	if GetResourceProfile(profiles, "medium") != nil {
+		t.Errorf("expect profile medium not found")
+	}
+	profile := GetResourceProfile(profiles, "large")
+	if profile == nil {
+		t.Fatalf("expect profile large found")
+	}
+
+	containers := []corev1.Container{
+		{Name: "logtail"},
+		{Name: "game", Resources: small},
+	}
+	applied := ApplyResourceProfile(containers, profile, "game")
+	if !reflect.DeepEqual(containers[1].Resources, small) {
+		t.Errorf("expect the containers not modified, but actually got %v", containers[1].Resources)
+	}
+	if !reflect.DeepEqual(applied[0].Resources, sidecar) {
+		t.Errorf("expect resources %v of the sidecar, but actually got %v", sidecar, applied[0].Resources)
+	}
+	if !reflect.DeepEqual(applied[1].Resources, large) {
+		t.Errorf("expect resources %v of the game container, but actually got %v", large, applied[1].Resources)
+	}
+}
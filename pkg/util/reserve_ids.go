@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+// MaxReserveGameServerIds is the maximum number of ids which ReserveGameServerIdRanges expands to,
+// so that a mistyped range such as 0-99999999 is rejected rather than expanded in memory.
+const MaxReserveGameServerIds = 100000
+
+// ParseReserveIdRanges expands the ranges of ids, such as "100-150" including both ends, or "100" for a single id,
+// in the order they are declared. The repeated ids are kept.
+func ParseReserveIdRanges(ranges []string) ([]int, error) {
+	var ret []int
+	for _, idRange := range ranges {
+		start, end, err := parseReserveIdRange(idRange)
+		if err != nil {
+			return nil, err
+		}
+		if len(ret)+end-start+1 > MaxReserveGameServerIds {
+			return nil, fmt.Errorf("the number of ids should not be greater than %d", MaxReserveGameServerIds)
+		}
+		for i := start; i <= end; i++ {
+			ret = append(ret, i)
+		}
+	}
+	return ret, nil
+}
+
+// parseReserveIdRange parses a range of ids such as "100-150", or a single id in string such as "100".
+func parseReserveIdRange(str string) (int, int, error) {
+	bounds := strings.Split(strings.TrimSpace(str), "-")
+	if len(bounds) > 2 {
+		return 0, 0, fmt.Errorf("id range %q should be in the format of <start>-<end>", str)
+	}
+	start, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("id range %q should be in the format of <start>-<end>", str)
+	}
+	end := start
+	if len(bounds) == 2 {
+		if end, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil {
+			return 0, 0, fmt.Errorf("id range %q should be in the format of <start>-<end>", str)
+		}
+	}
+	if start < 0 || start > end {
+		return 0, 0, fmt.Errorf("id range %q should satisfy 0 <= start <= end", str)
+	}
+	return start, end, nil
+}
+
+// GetReserveIds returns the ids reserved by ReserveGameServerIds and ReserveGameServerIdRanges of the GameServerSet
+// without repeat, in the order they are declared. The invalid ranges, which are rejected by the webhook, are ignored.
+func GetReserveIds(gss *gameKruiseV1alpha1.GameServerSet) []int {
+	ret := append([]int{}, gss.Spec.ReserveGameServerIds...)
+	for _, idRange := range gss.Spec.ReserveGameServerIdRanges {
+		expanded, err := ParseReserveIdRanges([]string{idRange})
+		if err != nil {
+			continue
+		}
+		ret = append(ret, expanded...)
+	}
+	return RemoveRepeat(ret)
+}
+
+// GetSpecReserveIds returns the ids to be recorded in ReserveGameServerIds of the GameServerSet, i.e. the ids
+// except the ones reserved only by ReserveGameServerIdRanges, so that the ranges are never expanded into it.
+func GetSpecReserveIds(gss *gameKruiseV1alpha1.GameServerSet, ids []int) []int {
+	rangeOnlyIds := make(map[int]bool)
+	for _, idRange := range gss.Spec.ReserveGameServerIdRanges {
+		expanded, _ := ParseReserveIdRanges([]string{idRange})
+		for _, id := range expanded {
+			rangeOnlyIds[id] = true
+		}
+	}
+	for _, id := range gss.Spec.ReserveGameServerIds {
+		delete(rangeOnlyIds, id)
+	}
+	var ret []int
+	for _, id := range ids {
+		if !rangeOnlyIds[id] {
+			ret = append(ret, id)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestParseReserveIdRanges(t *testing.T) {
+	tests := []struct {
+		ranges []string
+		result []int
+		valid  bool
+	}{
+		{
+			ranges: []string{"1", "3-5", "8"},
+			result: []int{1, 3, 4, 5, 8},
+			valid:  true,
+		},
+		{
+			ranges: []string{" 2 - 3 ", "3"},
+			result: []int{2, 3, 3},
+			valid:  true,
+		},
+		{
+			ranges: []string{"-1"},
+			valid:  false,
+		},
+		{
+			ranges: []string{"3-1"},
+			valid:  false,
+		},
+		{
+			ranges: []string{"1-2-3"},
+			valid:  false,
+		},
+		{
+			ranges: []string{"0-100000"},
+			valid:  false,
+		},
+	}
+
+	for i, test := range tests {
+		actual, err := ParseReserveIdRanges(test.ranges)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+			continue
+		}
+		if test.valid && !reflect.DeepEqual(actual, test.result) {
+			t.Errorf("case %d: expect %v but got %v", i, test.result, actual)
+		}
+	}
+}
+
+func TestGetReserveIds(t *testing.T) {
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "xxx"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			ReserveGameServerIds:      []int{5, 2},
+			ReserveGameServerIdRanges: []string{"x", "1-2"},
+		},
+	}
+	expect := []int{5, 2, 1}
+	if actual := GetReserveIds(gss); !reflect.DeepEqual(actual, expect) {
+		t.Errorf("expect %v but got %v", expect, actual)
+	}
+}
+
+func TestGetSpecReserveIds(t *testing.T) {
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			ReserveGameServerIds:      []int{5, 2},
+			ReserveGameServerIdRanges: []string{"1-3"},
+		},
+	}
+	// the ids reserved only by the ranges are not written back, and the ones declared in both are kept
+	expect := []int{5, 2, 7}
+	if actual := GetSpecReserveIds(gss, []int{5, 2, 1, 3, 7}); !reflect.DeepEqual(actual, expect) {
+		t.Errorf("expect %v but got %v", expect, actual)
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scoring
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	corev1 "k8s.io/api/core/v1"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/util"
+)
+
+// UnscheduledScore is the score of GameServers whose pods are not scheduled yet,
+// so that they are deleted first and allocated last.
+const UnscheduledScore = -math.MaxFloat64
+
+// The variables which can be used in the expression of ScoringPolicy.
+const (
+	GameServerVariable = "gameServer"
+	NodeVariable       = "node"
+	ScoreVariable      = "score"
+)
+
+// CostLimit limits the cost of evaluating the expression of ScoringPolicy for a GameServer,
+// so that an expensive expression fails rather than blocking the controllers.
+const CostLimit = 10000
+
+type nodeInfo struct {
+	gameServers          int
+	allocatedGameServers int
+}
+
+// Scorer scores the GameServers of a GameServerSet by the ScoringPolicy.
+type Scorer struct {
+	strategy gameKruiseV1alpha1.ScoringStrategyType
+	program  cel.Program
+	nodes    map[string]*nodeInfo
+}
+
+// Compile compiles the expression of ScoringPolicy.
+func Compile(expression string) (cel.Program, error) {
+	env, err := cel.NewEnv(
+		cel.Variable(GameServerVariable, cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable(NodeVariable, cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable(ScoreVariable, cel.DoubleType),
+	)
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	return env.Program(ast, cel.CostLimit(CostLimit))
+}
+
+// NewScorer returns the Scorer of the ScoringPolicy, and the pods are used to count GameServers on each node.
+// The pods should be the ones of all GameServerSets, since the nodes are shared by them.
+// It returns nil if the policy is nil.
+func NewScorer(policy *gameKruiseV1alpha1.ScoringPolicy, pods []corev1.Pod) (*Scorer, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	s := &Scorer{
+		strategy: policy.Strategy,
+		nodes:    make(map[string]*nodeInfo),
+	}
+	if s.strategy == "" {
+		s.strategy = gameKruiseV1alpha1.PackedScoringStrategyType
+	}
+	if policy.Expression != "" {
+		program, err := Compile(policy.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scoring expression %q: %s", policy.Expression, err.Error())
+		}
+		s.program = program
+	}
+
+	for _, pod := range pods {
+		nodeName := pod.Spec.NodeName
+		if nodeName == "" || pod.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if _, ok := s.nodes[nodeName]; !ok {
+			s.nodes[nodeName] = &nodeInfo{}
+		}
+		s.nodes[nodeName].gameServers++
+		if pod.GetLabels()[gameKruiseV1alpha1.GameServerOpsStateKey] == string(gameKruiseV1alpha1.Allocated) {
+			s.nodes[nodeName].allocatedGameServers++
+		}
+	}
+	return s, nil
+}
+
+// Score returns the score of the GameServer corresponding to the pod.
+func (s *Scorer) Score(pod *corev1.Pod) (float64, error) {
+	nodeName := pod.Spec.NodeName
+	if nodeName == "" {
+		return UnscheduledScore, nil
+	}
+	node, ok := s.nodes[nodeName]
+	if !ok {
+		node = &nodeInfo{}
+	}
+
+	var score float64
+	switch s.strategy {
+	case gameKruiseV1alpha1.DistributedScoringStrategyType:
+		score = -float64(node.gameServers)
+	default:
+		score = float64(node.gameServers)
+	}
+	if s.program == nil {
+		return score, nil
+	}
+
+	out, _, err := s.program.Eval(map[string]interface{}{
+		GameServerVariable: map[string]interface{}{
+			"name":        pod.GetName(),
+			"id":          util.GetIndexFromGsName(pod.GetName()),
+			"opsState":    pod.GetLabels()[gameKruiseV1alpha1.GameServerOpsStateKey],
+			"labels":      pod.GetLabels(),
+			"annotations": pod.GetAnnotations(),
+		},
+		NodeVariable: map[string]interface{}{
+			"name":                 nodeName,
+			"gameServers":          node.gameServers,
+			"allocatedGameServers": node.allocatedGameServers,
+		},
+		ScoreVariable: score,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to evaluate scoring expression for %s: %s", pod.GetName(), err.Error())
+	}
+	return toFloat(out)
+}
+
+// ScorePods returns the scores of pods, keyed by their names.
+func (s *Scorer) ScorePods(pods []corev1.Pod) (map[string]float64, error) {
+	scores := make(map[string]float64, len(pods))
+	for i := range pods {
+		score, err := s.Score(&pods[i])
+		if err != nil {
+			return nil, err
+		}
+		scores[pods[i].GetName()] = score
+	}
+	return scores, nil
+}
+
+func toFloat(val ref.Val) (float64, error) {
+	switch v := val.(type) {
+	case types.Double:
+		return float64(v), nil
+	case types.Int:
+		return float64(v), nil
+	case types.Uint:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("scoring expression must return a number, but got %s", val.Type().TypeName())
+}
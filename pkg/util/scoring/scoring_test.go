@@ -0,0 +1,117 @@
+package scoring
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func newPod(name, nodeName string, opsState gameKruiseV1alpha1.OpsState) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				gameKruiseV1alpha1.GameServerOpsStateKey: string(opsState),
+			},
+		},
+		Spec: corev1.PodSpec{NodeName: nodeName},
+	}
+}
+
+func TestScorePods(t *testing.T) {
+	pods := []corev1.Pod{
+		newPod("xxx-0", "node-a", gameKruiseV1alpha1.Allocated),
+		newPod("xxx-1", "node-a", gameKruiseV1alpha1.None),
+		newPod("xxx-2", "node-b", gameKruiseV1alpha1.None),
+		newPod("xxx-3", "", gameKruiseV1alpha1.None),
+	}
+
+	tests := []struct {
+		policy   *gameKruiseV1alpha1.ScoringPolicy
+		expected map[string]float64
+		hasError bool
+	}{
+		{
+			policy: &gameKruiseV1alpha1.ScoringPolicy{},
+			expected: map[string]float64{
+				"xxx-0": 2,
+				"xxx-1": 2,
+				"xxx-2": 1,
+				"xxx-3": UnscheduledScore,
+			},
+		},
+		{
+			policy: &gameKruiseV1alpha1.ScoringPolicy{Strategy: gameKruiseV1alpha1.DistributedScoringStrategyType},
+			expected: map[string]float64{
+				"xxx-0": -2,
+				"xxx-1": -2,
+				"xxx-2": -1,
+				"xxx-3": UnscheduledScore,
+			},
+		},
+		{
+			policy: &gameKruiseV1alpha1.ScoringPolicy{
+				Expression: `gameServer.opsState == "Allocated" ? score : score * 10.0 + double(node.allocatedGameServers)`,
+			},
+			expected: map[string]float64{
+				"xxx-0": 2,
+				"xxx-1": 21,
+				"xxx-2": 10,
+				"xxx-3": UnscheduledScore,
+			},
+		},
+		{
+			policy:   &gameKruiseV1alpha1.ScoringPolicy{Expression: `gameServer.id`},
+			expected: map[string]float64{"xxx-0": 0, "xxx-1": 1, "xxx-2": 2, "xxx-3": UnscheduledScore},
+		},
+		// the expression does not return a number
+		{
+			policy:   &gameKruiseV1alpha1.ScoringPolicy{Expression: `node.name`},
+			hasError: true,
+		},
+	}
+
+	for i, test := range tests {
+		scorer, err := NewScorer(test.policy, pods)
+		if err != nil {
+			t.Errorf("case %d: %s", i, err.Error())
+			continue
+		}
+		actual, err := scorer.ScorePods(pods)
+		if (err != nil) != test.hasError {
+			t.Errorf("case %d: expect error %v, but actually got %v", i, test.hasError, err)
+			continue
+		}
+		if !test.hasError && !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.expected, actual)
+		}
+	}
+}
+
+func TestNewScorer(t *testing.T) {
+	if scorer, err := NewScorer(nil, nil); scorer != nil || err != nil {
+		t.Errorf("expect nil scorer for nil policy, but actually got %v, %v", scorer, err)
+	}
+	if _, err := NewScorer(&gameKruiseV1alpha1.ScoringPolicy{Expression: `score +`}, nil); err == nil {
+		t.Errorf("expect error for invalid expression")
+	}
+}
+
+func TestScoreCostLimit(t *testing.T) {
+	pod := newPod("xxx-0", "node-a", gameKruiseV1alpha1.None)
+	for i := 0; i < CostLimit; i++ {
+		pod.Labels[fmt.Sprintf("label-%d", i)] = "true"
+	}
+	scorer, err := NewScorer(&gameKruiseV1alpha1.ScoringPolicy{Expression: `double(gameServer.labels.filter(k, k.startsWith("label-")).size())`}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scorer.Score(&pod); err == nil {
+		t.Errorf("expect error for the expression exceeding the cost limit")
+	}
+}
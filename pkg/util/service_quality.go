@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+// GetServiceQualities returns the ServiceQualities applied to the GameServerSet, which are the ones of
+// its ServiceQualityTemplate overridden by the ones of the GameServerSet with the same name.
+func GetServiceQualities(gss *gameKruiseV1alpha1.GameServerSet, c client.Client, ctx context.Context) ([]gameKruiseV1alpha1.ServiceQuality, error) {
+	if gss.Spec.ServiceQualityTemplateName == "" {
+		return gss.Spec.ServiceQualities, nil
+	}
+	template := &gameKruiseV1alpha1.ServiceQualityTemplate{}
+	if err := c.Get(ctx, types.NamespacedName{Name: gss.Spec.ServiceQualityTemplateName}, template); err != nil {
+		return nil, err
+	}
+	return MergeServiceQualities(template.Spec.ServiceQualities, gss.Spec.ServiceQualities), nil
+}
+
+// MergeServiceQualities returns the ServiceQualities of the template, in which the ones with the same name
+// as the overrides are replaced, followed by the rest of the overrides.
+func MergeServiceQualities(template, overrides []gameKruiseV1alpha1.ServiceQuality) []gameKruiseV1alpha1.ServiceQuality {
+	if len(template) == 0 {
+		return overrides
+	}
+	overrideIndex := make(map[string]int, len(overrides))
+	for i, sq := range overrides {
+		overrideIndex[sq.Name] = i
+	}
+	merged := make([]gameKruiseV1alpha1.ServiceQuality, 0, len(template)+len(overrides))
+	used := make(map[string]bool, len(overrides))
+	for _, sq := range template {
+		if i, ok := overrideIndex[sq.Name]; ok {
+			merged = append(merged, overrides[i])
+			used[sq.Name] = true
+			continue
+		}
+		merged = append(merged, sq)
+	}
+	for _, sq := range overrides {
+		if !used[sq.Name] {
+			merged = append(merged, sq)
+		}
+	}
+	return merged
+}
+
+// IsNetworkServiceQuality returns true if the ServiceQuality is probed by kruise-game-manager over the network,
+// i.e. with a gRPC or TCP probe, instead of by the PodProbeMarker of kruise in the container.
+func IsNetworkServiceQuality(sq gameKruiseV1alpha1.ServiceQuality) bool {
+	return sq.GRPC != nil || sq.TCPSocket != nil
+}
+
+// GetExecServiceQualities returns the ServiceQualities probed by the PodProbeMarker of kruise.
+func GetExecServiceQualities(sqs []gameKruiseV1alpha1.ServiceQuality) []gameKruiseV1alpha1.ServiceQuality {
+	var ret []gameKruiseV1alpha1.ServiceQuality
+	for _, sq := range sqs {
+		if !IsNetworkServiceQuality(sq) {
+			ret = append(ret, sq)
+		}
+	}
+	return ret
+}
+
+// ServiceQualityActionData is the data with which the values of ServiceQualityActionItems are rendered.
+type ServiceQualityActionData struct {
+	State  bool
+	Result string
+	// Fields are the key=value pairs in the result separated by spaces, commas, semicolons or lines.
+	Fields map[string]string
+}
+
+// NewServiceQualityActionData returns the data of the probe state and result, whose fields are parsed from message,
+// the probe message before the separators such as lines are removed.
+func NewServiceQualityActionData(state bool, result, message string) ServiceQualityActionData {
+	fields := make(map[string]string)
+	for _, token := range strings.FieldsFunc(message, func(r rune) bool {
+		return r == ',' || r == ';' || r == '|' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	}) {
+		if key, value, found := strings.Cut(token, "="); found && key != "" {
+			fields[key] = value
+		}
+	}
+	return ServiceQualityActionData{State: state, Result: result, Fields: fields}
+}
+
+// ParseServiceQualityActionTemplate parses the value of a ServiceQualityActionItem. Missing fields are errors
+// rather than empty values when it is rendered.
+func ParseServiceQualityActionTemplate(value string) (*template.Template, error) {
+	return template.New("action").Option("missingkey=error").Parse(value)
+}
+
+// RenderServiceQualityActionValue renders the value of a ServiceQualityActionItem with the data.
+func RenderServiceQualityActionValue(value string, data ServiceQualityActionData) (string, error) {
+	tmpl, err := ParseServiceQualityActionTemplate(value)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// IsResultInRange returns whether the result, or the value of the field of the message if Field is set, is a number
+// within the range.
+func IsResultInRange(r *gameKruiseV1alpha1.ServiceQualityResultRange, result, message string) bool {
+	value := result
+	if r.Field != "" {
+		field, exist := NewServiceQualityActionData(false, result, message).Fields[r.Field]
+		if !exist {
+			return false
+		}
+		value = field
+	}
+	quantity, err := resource.ParseQuantity(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+	return (r.Min == nil || quantity.Cmp(*r.Min) >= 0) &&
+		(r.Max == nil || quantity.Cmp(*r.Max) <= 0) &&
+		(r.Gt == nil || quantity.Cmp(*r.Gt) > 0) &&
+		(r.Lt == nil || quantity.Cmp(*r.Lt) < 0)
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+)
+
+func TestMergeServiceQualities(t *testing.T) {
+	idle := gameKruiseV1alpha1.ServiceQuality{Name: "idle", Permanent: false}
+	healthy := gameKruiseV1alpha1.ServiceQuality{Name: "healthy", Permanent: false}
+	idleOverride := gameKruiseV1alpha1.ServiceQuality{Name: "idle", ContainerName: "game", Permanent: true}
+	players := gameKruiseV1alpha1.ServiceQuality{Name: "players"}
+
+	tests := []struct {
+		template  []gameKruiseV1alpha1.ServiceQuality
+		overrides []gameKruiseV1alpha1.ServiceQuality
+		expect    []gameKruiseV1alpha1.ServiceQuality
+	}{
+		{
+			template:  nil,
+			overrides: []gameKruiseV1alpha1.ServiceQuality{players},
+			expect:    []gameKruiseV1alpha1.ServiceQuality{players},
+		},
+		{
+			template:  []gameKruiseV1alpha1.ServiceQuality{idle, healthy},
+			overrides: nil,
+			expect:    []gameKruiseV1alpha1.ServiceQuality{idle, healthy},
+		},
+		{
+			template:  []gameKruiseV1alpha1.ServiceQuality{idle, healthy},
+			overrides: []gameKruiseV1alpha1.ServiceQuality{players, idleOverride},
+			expect:    []gameKruiseV1alpha1.ServiceQuality{idleOverride, healthy, players},
+		},
+	}
+
+	for i, test := range tests {
+		actual := MergeServiceQualities(test.template, test.overrides)
+		if !reflect.DeepEqual(actual, test.expect) {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.expect, actual)
+		}
+	}
+}
+
+func TestGetServiceQualities(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gameKruiseV1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	template := &gameKruiseV1alpha1.ServiceQualityTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "idle-probe"},
+		Spec: gameKruiseV1alpha1.ServiceQualityTemplateSpec{
+			ServiceQualities: []gameKruiseV1alpha1.ServiceQuality{{Name: "idle"}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(template).Build()
+
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			ServiceQualityTemplateName: "idle-probe",
+			ServiceQualities:           []gameKruiseV1alpha1.ServiceQuality{{Name: "players"}},
+		},
+	}
+	sqs, err := GetServiceQualities(gss, c, context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sqs) != 2 || sqs[0].Name != "idle" || sqs[1].Name != "players" {
+		t.Errorf("unexpected ServiceQualities %v", sqs)
+	}
+
+	gss.Spec.ServiceQualityTemplateName = "not-exist"
+	if _, err := GetServiceQualities(gss, c, context.TODO()); err == nil {
+		t.Errorf("expect error for the template not found")
+	}
+}
+
+func TestGetExecServiceQualities(t *testing.T) {
+	exec := gameKruiseV1alpha1.ServiceQuality{Name: "exec", Probe: corev1.Probe{ProbeHandler: corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"/healthy.sh"}}}}}
+	grpc := gameKruiseV1alpha1.ServiceQuality{Name: "grpc", Probe: corev1.Probe{ProbeHandler: corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: 9090}}}}
+	tcp := gameKruiseV1alpha1.ServiceQuality{Name: "tcp", Probe: corev1.Probe{ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{}}}}
+
+	tests := []struct {
+		sqs    []gameKruiseV1alpha1.ServiceQuality
+		expect []gameKruiseV1alpha1.ServiceQuality
+	}{
+		{sqs: nil, expect: nil},
+		{sqs: []gameKruiseV1alpha1.ServiceQuality{grpc, exec, tcp}, expect: []gameKruiseV1alpha1.ServiceQuality{exec}},
+		{sqs: []gameKruiseV1alpha1.ServiceQuality{grpc, tcp}, expect: nil},
+	}
+	for i, test := range tests {
+		if actual := GetExecServiceQualities(test.sqs); !reflect.DeepEqual(actual, test.expect) {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.expect, actual)
+		}
+	}
+}
+
+func TestRenderServiceQualityActionValue(t *testing.T) {
+	data := NewServiceQualityActionData(true, "players=3map=desert", "players=3\nmap=desert")
+	tests := []struct {
+		value  string
+		expect string
+		valid  bool
+	}{
+		{value: "Maintaining", expect: "Maintaining", valid: true},
+		{value: "{{ .Result }}", expect: "players=3map=desert", valid: true},
+		{value: "{{ .Fields.players }}", expect: "3", valid: true},
+		{value: "map-{{ .Fields.map }}-{{ .State }}", expect: "map-desert-true", valid: true},
+		{value: "{{ .Fields.mode }}", valid: false},
+		{value: "{{ .Fields.players", valid: false},
+	}
+	for i, test := range tests {
+		actual, err := RenderServiceQualityActionValue(test.value, data)
+		if (err == nil) != test.valid || actual != test.expect {
+			t.Errorf("case %d: expect %s and valid %v, but actually got %s %v", i, test.expect, test.valid, actual, err)
+		}
+	}
+}
+
+func TestNewServiceQualityActionData(t *testing.T) {
+	tests := []struct {
+		message string
+		fields  map[string]string
+	}{
+		{message: "idle", fields: map[string]string{}},
+		{message: "players=3, map=desert;mode=pvp", fields: map[string]string{"players": "3", "map": "desert", "mode": "pvp"}},
+		{message: "players=3\nversion=1.2=beta =x", fields: map[string]string{"players": "3", "version": "1.2=beta"}},
+	}
+	for i, test := range tests {
+		if data := NewServiceQualityActionData(true, test.message, test.message); !reflect.DeepEqual(data.Fields, test.fields) {
+			t.Errorf("case %d: expect fields %v, but actually got %v", i, test.fields, data.Fields)
+		}
+	}
+}
+
+func TestIsResultInRange(t *testing.T) {
+	zero := resource.MustParse("0")
+	ten := resource.MustParse("10")
+	one := resource.MustParse("1")
+	tests := []struct {
+		r       gameKruiseV1alpha1.ServiceQualityResultRange
+		result  string
+		message string
+		expect  bool
+	}{
+		{r: gameKruiseV1alpha1.ServiceQualityResultRange{Min: &zero, Max: &ten}, result: "0", expect: true},
+		{r: gameKruiseV1alpha1.ServiceQualityResultRange{Min: &zero, Max: &ten}, result: "10", expect: true},
+		{r: gameKruiseV1alpha1.ServiceQualityResultRange{Min: &zero, Max: &ten}, result: "10.5", expect: false},
+		{r: gameKruiseV1alpha1.ServiceQualityResultRange{Gt: &zero, Lt: &ten}, result: "0", expect: false},
+		{r: gameKruiseV1alpha1.ServiceQualityResultRange{Gt: &zero, Lt: &ten}, result: "0.5", expect: true},
+		{r: gameKruiseV1alpha1.ServiceQualityResultRange{Lt: &one}, result: "idle", expect: false},
+		{r: gameKruiseV1alpha1.ServiceQualityResultRange{Field: "players", Lt: &one}, result: "players=0map=desert", message: "players=0\nmap=desert", expect: true},
+		{r: gameKruiseV1alpha1.ServiceQualityResultRange{Field: "players", Lt: &one}, result: "players=2", message: "players=2", expect: false},
+		{r: gameKruiseV1alpha1.ServiceQualityResultRange{Field: "players", Lt: &one}, result: "map=desert", message: "map=desert", expect: false},
+	}
+	for i, test := range tests {
+		if actual := IsResultInRange(&test.r, test.result, test.message); actual != test.expect {
+			t.Errorf("case %d: expect %v, but actually got %v", i, test.expect, actual)
+		}
+	}
+}
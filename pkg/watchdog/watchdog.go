@@ -0,0 +1,238 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/metrics"
+)
+
+const (
+	// DefaultThreshold is the default time after which an object not converging is reported as stuck.
+	DefaultThreshold = 15 * time.Minute
+	// DefaultInterval is the default interval of checking the objects.
+	DefaultInterval = time.Minute
+
+	StuckReason = "ReconcileStuck"
+
+	GameServerKind    = "GameServer"
+	GameServerSetKind = "GameServerSet"
+)
+
+// Blocker is the condition which keeps an object from converging.
+type Blocker struct {
+	Reason  string
+	Message string
+	// Since is when the blocker began, or zero if the object does not record it, in which case the time the
+	// watchdog first sees the blocker is used.
+	Since time.Time
+}
+
+type objectKey struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+type stuckRecord struct {
+	reason   string
+	since    time.Time
+	reported bool
+}
+
+// Watchdog checks the GameServers and GameServerSets periodically, and reports the ones which have not converged
+// for the threshold with the metrics and a warning event carrying the blocking condition.
+type Watchdog struct {
+	client    client.Client
+	recorder  record.EventRecorder
+	threshold time.Duration
+	interval  time.Duration
+	now       func() time.Time
+	records   map[objectKey]*stuckRecord
+}
+
+// NewWatchdog returns a Watchdog. The default threshold and interval are used if they are not positive.
+func NewWatchdog(c client.Client, recorder record.EventRecorder, threshold, interval time.Duration) *Watchdog {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Watchdog{
+		client:    c,
+		recorder:  recorder,
+		threshold: threshold,
+		interval:  interval,
+		now:       time.Now,
+		records:   make(map[objectKey]*stuckRecord),
+	}
+}
+
+// Start implements manager.Runnable.
+func (w *Watchdog) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, w.check, w.interval)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that only the leader reports the stuck objects.
+func (w *Watchdog) NeedLeaderElection() bool {
+	return true
+}
+
+func (w *Watchdog) check(ctx context.Context) {
+	seen := make(map[objectKey]bool)
+
+	gsList := &gameKruiseV1alpha1.GameServerList{}
+	if err := w.client.List(ctx, gsList); err != nil {
+		klog.Errorf("failed to list GameServers to check stuck ones, because of %s.", err.Error())
+		return
+	}
+	for i := range gsList.Items {
+		gs := &gsList.Items[i]
+		key := objectKey{kind: GameServerKind, namespace: gs.GetNamespace(), name: gs.GetName()}
+		seen[key] = true
+		w.observe(key, gs, getGameServerBlocker(gs))
+	}
+
+	gssList := &gameKruiseV1alpha1.GameServerSetList{}
+	if err := w.client.List(ctx, gssList); err != nil {
+		klog.Errorf("failed to list GameServerSets to check stuck ones, because of %s.", err.Error())
+		return
+	}
+	for i := range gssList.Items {
+		gss := &gssList.Items[i]
+		key := objectKey{kind: GameServerSetKind, namespace: gss.GetNamespace(), name: gss.GetName()}
+		seen[key] = true
+		w.observe(key, gss, getGameServerSetBlocker(gss))
+	}
+
+	// the objects deleted are not stuck any more
+	for key := range w.records {
+		if !seen[key] {
+			w.forget(key)
+		}
+	}
+}
+
+// observe tracks the blocker of the object, and reports the object once the blocker lasts for the threshold.
+// A different blocker restarts the tracking.
+func (w *Watchdog) observe(key objectKey, obj client.Object, blocker *Blocker) {
+	if blocker == nil {
+		w.forget(key)
+		return
+	}
+	now := w.now()
+	r, ok := w.records[key]
+	if !ok || r.reason != blocker.Reason {
+		w.forget(key)
+		r = &stuckRecord{reason: blocker.Reason, since: now}
+		if !blocker.Since.IsZero() && blocker.Since.Before(now) {
+			r.since = blocker.Since
+		}
+		w.records[key] = r
+	}
+
+	stuckFor := now.Sub(r.since)
+	if stuckFor < w.threshold {
+		return
+	}
+	metrics.ReconcileStuckSeconds.WithLabelValues(key.kind, key.name, key.namespace, r.reason).Set(stuckFor.Seconds())
+	if r.reported {
+		return
+	}
+	r.reported = true
+	metrics.ReconcileStuckTotal.WithLabelValues(key.kind, r.reason).Inc()
+	message := fmt.Sprintf("%s has not converged for %s, blocked by %s: %s", key.kind, stuckFor.Round(time.Second), r.reason, blocker.Message)
+	klog.Warningf("%s %s in %s: %s", key.kind, key.name, key.namespace, message)
+	w.recorder.Event(obj, corev1.EventTypeWarning, StuckReason, message)
+}
+
+func (w *Watchdog) forget(key objectKey) {
+	r, ok := w.records[key]
+	if !ok {
+		return
+	}
+	if r.reported {
+		metrics.ReconcileStuckSeconds.DeleteLabelValues(key.kind, key.name, key.namespace, r.reason)
+	}
+	delete(w.records, key)
+}
+
+// getGameServerBlocker returns the first condition blocking the GameServer from converging, or nil if it has converged.
+func getGameServerBlocker(gs *gameKruiseV1alpha1.GameServer) *Blocker {
+	if ts := gs.GetDeletionTimestamp(); ts != nil {
+		return &Blocker{
+			Reason:  "Deleting",
+			Message: fmt.Sprintf("the GameServer is being deleted with finalizers %v", gs.GetFinalizers()),
+			Since:   ts.Time,
+		}
+	}
+	for _, condition := range gs.Status.Conditions {
+		if condition.Type == gameKruiseV1alpha1.ShuttingDown || condition.Status != corev1.ConditionFalse {
+			continue
+		}
+		return &Blocker{
+			Reason:  string(condition.Type),
+			Message: fmt.Sprintf("%s, %s", condition.Reason, condition.Message),
+			Since:   condition.LastTransitionTime.Time,
+		}
+	}
+	network := gs.Status.NetworkStatus
+	if network.DesiredNetworkState != "" && network.DesiredNetworkState != network.CurrentNetworkState {
+		return &Blocker{
+			Reason:  "NetworkNotConverged",
+			Message: fmt.Sprintf("the network of %s is %s, but expected %s", network.NetworkType, network.CurrentNetworkState, network.DesiredNetworkState),
+		}
+	}
+	// the GameServers under maintenance are not ready as expected
+	if gs.Spec.OpsState != gameKruiseV1alpha1.Maintaining && gs.Status.DesiredState != "" && gs.Status.CurrentState != gs.Status.DesiredState {
+		return &Blocker{
+			Reason:  "StateNotConverged",
+			Message: fmt.Sprintf("the state is %s, but expected %s", gs.Status.CurrentState, gs.Status.DesiredState),
+		}
+	}
+	return nil
+}
+
+// getGameServerSetBlocker returns the condition blocking the GameServerSet from converging, or nil if it has converged.
+func getGameServerSetBlocker(gss *gameKruiseV1alpha1.GameServerSet) *Blocker {
+	if gss.Status.ObservedGeneration < gss.GetGeneration() {
+		return &Blocker{
+			Reason:  "GenerationNotObserved",
+			Message: fmt.Sprintf("the observed generation is %d, but the generation is %d", gss.Status.ObservedGeneration, gss.GetGeneration()),
+		}
+	}
+	// the status replicas follows the spec, while the current replicas counts the pods
+	if gss.Spec.Replicas != nil && gss.Status.CurrentReplicas != *gss.Spec.Replicas {
+		return &Blocker{
+			Reason:  "ReplicasNotConverged",
+			Message: fmt.Sprintf("the current replicas is %d, but expected %d", gss.Status.CurrentReplicas, *gss.Spec.Replicas),
+		}
+	}
+	return nil
+}
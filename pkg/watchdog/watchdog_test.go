@@ -0,0 +1,218 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watchdog
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/pkg/metrics"
+)
+
+func TestGetGameServerBlocker(t *testing.T) {
+	since := metav1.NewTime(time.Date(2024, 6, 15, 4, 0, 0, 0, time.UTC))
+	tests := []struct {
+		gs           *gameKruiseV1alpha1.GameServer
+		expectReason string
+		expectSince  time.Time
+	}{
+		// converged
+		{
+			gs: &gameKruiseV1alpha1.GameServer{
+				Status: gameKruiseV1alpha1.GameServerStatus{DesiredState: gameKruiseV1alpha1.Ready, CurrentState: gameKruiseV1alpha1.Ready},
+			},
+		},
+		// being deleted
+		{
+			gs: &gameKruiseV1alpha1.GameServer{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &since, Finalizers: []string{"xxx"}},
+			},
+			expectReason: "Deleting",
+			expectSince:  since.Time,
+		},
+		// the pod is abnormal
+		{
+			gs: &gameKruiseV1alpha1.GameServer{
+				Status: gameKruiseV1alpha1.GameServerStatus{
+					DesiredState: gameKruiseV1alpha1.Ready,
+					CurrentState: gameKruiseV1alpha1.NotReady,
+					Conditions: []gameKruiseV1alpha1.GameServerCondition{
+						{Type: gameKruiseV1alpha1.ShuttingDown, Status: corev1.ConditionFalse},
+						{Type: gameKruiseV1alpha1.PodNormal, Status: corev1.ConditionFalse, Reason: "Unschedulable", LastTransitionTime: since},
+					},
+				},
+			},
+			expectReason: string(gameKruiseV1alpha1.PodNormal),
+			expectSince:  since.Time,
+		},
+		// the network is not ready
+		{
+			gs: &gameKruiseV1alpha1.GameServer{
+				Status: gameKruiseV1alpha1.GameServerStatus{
+					DesiredState: gameKruiseV1alpha1.Ready,
+					CurrentState: gameKruiseV1alpha1.Ready,
+					NetworkStatus: gameKruiseV1alpha1.NetworkStatus{
+						DesiredNetworkState: gameKruiseV1alpha1.NetworkReady,
+						CurrentNetworkState: gameKruiseV1alpha1.NetworkNotReady,
+					},
+				},
+			},
+			expectReason: "NetworkNotConverged",
+		},
+		// not ready
+		{
+			gs: &gameKruiseV1alpha1.GameServer{
+				Status: gameKruiseV1alpha1.GameServerStatus{DesiredState: gameKruiseV1alpha1.Ready, CurrentState: gameKruiseV1alpha1.Crash},
+			},
+			expectReason: "StateNotConverged",
+		},
+		// not ready under maintenance
+		{
+			gs: &gameKruiseV1alpha1.GameServer{
+				Spec:   gameKruiseV1alpha1.GameServerSpec{OpsState: gameKruiseV1alpha1.Maintaining},
+				Status: gameKruiseV1alpha1.GameServerStatus{DesiredState: gameKruiseV1alpha1.Ready, CurrentState: gameKruiseV1alpha1.NotReady},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		blocker := getGameServerBlocker(test.gs)
+		if test.expectReason == "" {
+			if blocker != nil {
+				t.Errorf("case %d: expect no blocker, but actually got %v", i, blocker)
+			}
+			continue
+		}
+		if blocker == nil {
+			t.Errorf("case %d: expect blocker %s, but actually got nil", i, test.expectReason)
+			continue
+		}
+		if blocker.Reason != test.expectReason || !blocker.Since.Equal(test.expectSince) {
+			t.Errorf("case %d: expect blocker %s since %v, but actually got %s since %v", i, test.expectReason, test.expectSince, blocker.Reason, blocker.Since)
+		}
+	}
+}
+
+func TestGetGameServerSetBlocker(t *testing.T) {
+	tests := []struct {
+		gss          *gameKruiseV1alpha1.GameServerSet
+		expectReason string
+	}{
+		{
+			gss: &gameKruiseV1alpha1.GameServerSet{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       gameKruiseV1alpha1.GameServerSetSpec{Replicas: ptr.To[int32](3)},
+				Status:     gameKruiseV1alpha1.GameServerSetStatus{ObservedGeneration: 2, Replicas: 3, CurrentReplicas: 3},
+			},
+		},
+		{
+			gss: &gameKruiseV1alpha1.GameServerSet{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       gameKruiseV1alpha1.GameServerSetSpec{Replicas: ptr.To[int32](3)},
+				Status:     gameKruiseV1alpha1.GameServerSetStatus{ObservedGeneration: 1, Replicas: 3, CurrentReplicas: 3},
+			},
+			expectReason: "GenerationNotObserved",
+		},
+		{
+			gss: &gameKruiseV1alpha1.GameServerSet{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       gameKruiseV1alpha1.GameServerSetSpec{Replicas: ptr.To[int32](3)},
+				Status:     gameKruiseV1alpha1.GameServerSetStatus{ObservedGeneration: 2, Replicas: 3, CurrentReplicas: 2},
+			},
+			expectReason: "ReplicasNotConverged",
+		},
+	}
+
+	for i, test := range tests {
+		blocker := getGameServerSetBlocker(test.gss)
+		reason := ""
+		if blocker != nil {
+			reason = blocker.Reason
+		}
+		if reason != test.expectReason {
+			t.Errorf("case %d: expect blocker %q, but actually got %q", i, test.expectReason, reason)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := gameKruiseV1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	gs := &gameKruiseV1alpha1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0"},
+		Status:     gameKruiseV1alpha1.GameServerStatus{DesiredState: gameKruiseV1alpha1.Ready, CurrentState: gameKruiseV1alpha1.Crash},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gs).Build()
+	recorder := record.NewFakeRecorder(10)
+	w := NewWatchdog(c, recorder, 15*time.Minute, time.Minute)
+	now := time.Date(2024, 6, 15, 4, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+	metrics.ReconcileStuckSeconds.Reset()
+
+	// not stuck within the threshold
+	w.check(context.TODO())
+	now = now.Add(10 * time.Minute)
+	w.check(context.TODO())
+	if len(recorder.Events) != 0 {
+		t.Errorf("expect no event within the threshold, but actually got %d", len(recorder.Events))
+	}
+	if n := testutil.CollectAndCount(metrics.ReconcileStuckSeconds); n != 0 {
+		t.Errorf("expect no stuck series within the threshold, but actually got %d", n)
+	}
+
+	// reported once
+	now = now.Add(10 * time.Minute)
+	w.check(context.TODO())
+	now = now.Add(time.Minute)
+	w.check(context.TODO())
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expect one event, but actually got %d", len(recorder.Events))
+	}
+	if event := <-recorder.Events; !strings.Contains(event, StuckReason) || !strings.Contains(event, "StateNotConverged") {
+		t.Errorf("expect the event of the blocker, but actually got %s", event)
+	}
+	gauge := metrics.ReconcileStuckSeconds.WithLabelValues(GameServerKind, "case-0", "xxx", "StateNotConverged")
+	if v := testutil.ToFloat64(gauge); v != (21 * time.Minute).Seconds() {
+		t.Errorf("expect stuck for %v seconds, but actually got %v", (21 * time.Minute).Seconds(), v)
+	}
+
+	// recovered
+	gs.Status.CurrentState = gameKruiseV1alpha1.Ready
+	if err := c.Status().Update(context.TODO(), gs); err != nil {
+		t.Fatal(err)
+	}
+	w.check(context.TODO())
+	if n := testutil.CollectAndCount(metrics.ReconcileStuckSeconds); n != 0 {
+		t.Errorf("expect the stuck series removed, but actually got %d", n)
+	}
+	if len(w.records) != 0 {
+		t.Errorf("expect the records removed, but actually got %v", w.records)
+	}
+}
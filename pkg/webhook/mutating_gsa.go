@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// GsaMutatingHandler records the identity of the requester in GameServerAllocation,
+// which can not be forged or modified by users.
+type GsaMutatingHandler struct {
+	decoder *admission.Decoder
+}
+
+func (gmh *GsaMutatingHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	gsa, err := mutateGsa(req, gmh.decoder)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	marshaledGsa, err := json.Marshal(gsa)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledGsa)
+}
+
+func mutateGsa(req admission.Request, decoder *admission.Decoder) (*gamekruiseiov1alpha1.GameServerAllocation, error) {
+	gsa := &gamekruiseiov1alpha1.GameServerAllocation{}
+	if err := decoder.Decode(req, gsa); err != nil {
+		return nil, err
+	}
+
+	switch req.Operation {
+	case admissionv1.Create:
+		gsa.Spec.Requester = getRequester(req.UserInfo)
+	case admissionv1.Update:
+		oldGsa := &gamekruiseiov1alpha1.GameServerAllocation{}
+		if err := decoder.DecodeRaw(req.OldObject, oldGsa); err != nil {
+			return nil, err
+		}
+		gsa.Spec.Requester = oldGsa.Spec.Requester
+	}
+	return gsa, nil
+}
+
+func getRequester(userInfo authenticationv1.UserInfo) *gamekruiseiov1alpha1.AllocationRequester {
+	return &gamekruiseiov1alpha1.AllocationRequester{
+		Username: userInfo.Username,
+		UID:      userInfo.UID,
+		Groups:   userInfo.Groups,
+	}
+}
@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestMutateGsa(t *testing.T) {
+	tests := []struct {
+		req       admission.Request
+		requester string
+	}{
+		// forged requester is overwritten on create
+		{
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: admissionv1.Create,
+					UserInfo:  authenticationv1.UserInfo{Username: "matchmaker"},
+					Object: runtime.RawExtension{
+						Raw: []byte(`{"apiVersion":"game.kruise.io/v1alpha1","kind":"GameServerAllocation","metadata":{"name":"foo","namespace":"default"},"spec":{"requester":{"username":"admin"}}}`),
+					},
+				},
+			},
+			requester: "matchmaker",
+		},
+		// requester is kept on update
+		{
+			req: admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: admissionv1.Update,
+					UserInfo:  authenticationv1.UserInfo{Username: "admin"},
+					Object: runtime.RawExtension{
+						Raw: []byte(`{"apiVersion":"game.kruise.io/v1alpha1","kind":"GameServerAllocation","metadata":{"name":"foo","namespace":"default"},"spec":{"requester":{"username":"admin"}}}`),
+					},
+					OldObject: runtime.RawExtension{
+						Raw: []byte(`{"apiVersion":"game.kruise.io/v1alpha1","kind":"GameServerAllocation","metadata":{"name":"foo","namespace":"default"},"spec":{"requester":{"username":"matchmaker"}}}`),
+					},
+				},
+			},
+			requester: "matchmaker",
+		},
+	}
+
+	decoder, err := admission.NewDecoder(runtime.NewScheme())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, test := range tests {
+		gsa, err := mutateGsa(test.req, decoder)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if gsa.Spec.Requester == nil || gsa.Spec.Requester.Username != test.requester {
+			t.Errorf("case %d: expect requester %s, but actually got %v", i, test.requester, gsa.Spec.Requester)
+		}
+	}
+}
@@ -23,6 +23,9 @@ import (
 	gameKruiseV1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
 	"github.com/openkruise/kruise-game/cloudprovider/errors"
 	"github.com/openkruise/kruise-game/cloudprovider/manager"
+	"github.com/openkruise/kruise-game/pkg/readonly"
+	"github.com/openkruise/kruise-game/pkg/sdk"
+	"github.com/openkruise/kruise-game/pkg/util"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -31,6 +34,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"net/http"
+	"path/filepath"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 	"time"
@@ -41,6 +45,12 @@ const (
 	mutatingTimeoutReason = "MutatingTimeout"
 )
 
+const (
+	defaultOverflowNodeSelectorKey   = "type"
+	defaultOverflowNodeSelectorValue = "virtual-kubelet"
+	defaultOverflowTolerationKey     = "virtual-kubelet.io/provider"
+)
+
 type patchResult struct {
 	pod *corev1.Pod
 	err errors.PluginError
@@ -66,6 +76,27 @@ func (pmh *PodMutatingHandler) Handle(ctx context.Context, req admission.Request
 			msg := fmt.Sprintf("Pod %s/%s patchContainers failed, because of %s", pod.Namespace, pod.Name, err.Error())
 			return admission.Denied(msg)
 		}
+		pod, err = patchResourceProfile(pmh.Client, pod, ctx)
+		if err != nil {
+			msg := fmt.Sprintf("Pod %s/%s patchResourceProfile failed, because of %s", pod.Namespace, pod.Name, err.Error())
+			return admission.Denied(msg)
+		}
+		pod, err = patchOverflow(pmh.Client, pod, ctx)
+		if err != nil {
+			msg := fmt.Sprintf("Pod %s/%s patchOverflow failed, because of %s", pod.Namespace, pod.Name, err.Error())
+			return admission.Denied(msg)
+		}
+		pod, err = patchArchitecture(pmh.Client, pod, ctx)
+		if err != nil {
+			msg := fmt.Sprintf("Pod %s/%s patchArchitecture failed, because of %s", pod.Namespace, pod.Name, err.Error())
+			return admission.Denied(msg)
+		}
+		pod = patchWindows(pod)
+		pod, err = patchNetworkEnv(pod)
+		if err != nil {
+			msg := fmt.Sprintf("Pod %s/%s patchNetworkEnv failed, because of %s", pod.Namespace, pod.Name, err.Error())
+			return admission.Denied(msg)
+		}
 	}
 
 	// get the plugin according to pod
@@ -76,8 +107,10 @@ func (pmh *PodMutatingHandler) Handle(ctx context.Context, req admission.Request
 		return getAdmissionResponse(req, patchResult{pod: pod, err: nil})
 	}
 
-	// define context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), podMutatingTimeout)
+	// define context with timeout, carrying the uid of the admission request to trace the errors of plugins
+	correlationID := string(req.UID)
+	// the writes of plugins are let through in read-only mode, so that the pods created during the maintenance get their network
+	ctx, cancel := context.WithTimeout(readonly.WithInternalWrites(errors.NewContextWithCorrelationID(context.Background(), correlationID)), podMutatingTimeout)
 	defer cancel()
 
 	// cloud provider plugin patches pod
@@ -85,18 +118,20 @@ func (pmh *PodMutatingHandler) Handle(ctx context.Context, req admission.Request
 	go func() {
 		var newPod *corev1.Pod
 		var pluginError errors.PluginError
+		recorder := errors.NewOperationRecorder(pmh.Client)
 		switch req.Operation {
 		case admissionv1.Create:
-			newPod, pluginError = plugin.OnPodAdded(pmh.Client, pod, ctx)
+			newPod, pluginError = plugin.OnPodAdded(recorder, pod, ctx)
 		case admissionv1.Update:
-			newPod, pluginError = plugin.OnPodUpdated(pmh.Client, pod, ctx)
+			newPod, pluginError = plugin.OnPodUpdated(recorder, pod, ctx)
 		case admissionv1.Delete:
-			pluginError = plugin.OnPodDeleted(pmh.Client, pod, ctx)
+			pluginError = plugin.OnPodDeleted(recorder, pod, ctx)
 		}
 		if pluginError != nil {
-			msg := fmt.Sprintf("Failed to %s pod %s/%s ,because of %s", req.Operation, pod.Namespace, pod.Name, pluginError.Error())
-			klog.Warningf(msg)
-			pmh.eventRecorder.Eventf(pod, corev1.EventTypeWarning, string(pluginError.Type()), msg)
+			pluginError = errors.WithCorrelationID(recorder.Attach(pluginError), correlationID)
+			msg := fmt.Sprintf("Failed to %s pod %s/%s ,because of %s", req.Operation, pod.Namespace, pod.Name, errors.Describe(pluginError))
+			klog.Warning(msg)
+			pmh.eventRecorder.Event(pod, corev1.EventTypeWarning, string(pluginError.Type()), msg)
 			newPod = pod.DeepCopy()
 		}
 		resultCh <- patchResult{
@@ -108,7 +143,7 @@ func (pmh *PodMutatingHandler) Handle(ctx context.Context, req admission.Request
 	select {
 	// timeout
 	case <-ctx.Done():
-		msg := fmt.Sprintf("Failed to %s pod %s/%s, because plugin %s exec timed out", req.Operation, pod.Namespace, pod.Name, plugin.Name())
+		msg := fmt.Sprintf("Failed to %s pod %s/%s, because plugin %s exec timed out (correlation ID: %s)", req.Operation, pod.Namespace, pod.Name, plugin.Name(), correlationID)
 		pmh.eventRecorder.Eventf(pod, corev1.EventTypeWarning, mutatingTimeoutReason, msg)
 		return admission.Allowed(msg)
 	// completed before timeout
@@ -129,7 +164,7 @@ func getPodFromRequest(req admission.Request, decoder *admission.Decoder) (*core
 
 func getAdmissionResponse(req admission.Request, result patchResult) admission.Response {
 	if result.err != nil {
-		return admission.Denied(result.err.Error())
+		return admission.Denied(errors.Describe(result.err))
 	}
 	if req.Operation == admissionv1.Delete {
 		return admission.Allowed("delete successfully")
@@ -209,3 +244,271 @@ func patchContainers(client client.Client, pod *corev1.Pod, ctx context.Context)
 	}
 	return pod, nil
 }
+
+// patchResourceProfile creates the pod with the resources of the ResourceProfile of its GameServer, so that
+// the pods recreated by the RecreateAtIdle resize policy, as well as the pods of the GameServers kept after
+// their pods are deleted, get the resources of their profiles.
+func patchResourceProfile(c client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, error) {
+	gssName, ok := pod.GetLabels()[gameKruiseV1alpha1.GameServerOwnerGssKey]
+	if !ok {
+		return pod, nil
+	}
+	gs := &gameKruiseV1alpha1.GameServer{}
+	err := c.Get(ctx, types.NamespacedName{
+		Namespace: pod.GetNamespace(),
+		Name:      pod.GetName(),
+	}, gs)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return pod, nil
+		}
+		return pod, err
+	}
+	if gs.Spec.ResourceProfile == "" {
+		return pod, nil
+	}
+	gss := &gameKruiseV1alpha1.GameServerSet{}
+	err = c.Get(ctx, types.NamespacedName{
+		Namespace: pod.GetNamespace(),
+		Name:      gssName,
+	}, gss)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return pod, nil
+		}
+		return pod, err
+	}
+	profile := util.GetResourceProfile(gss.Spec.ResourceProfiles, gs.Spec.ResourceProfile)
+	if profile == nil {
+		return pod, nil
+	}
+	pod.Spec.Containers = util.ApplyResourceProfile(pod.Spec.Containers, profile, util.GetGameContainerName(pod))
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[gameKruiseV1alpha1.GameServerResourceProfileKey] = profile.Name
+	return pod, nil
+}
+
+// patchOverflow schedules the pod onto the serverless nodes if its id is not less than the threshold of OverflowPolicy.
+func patchOverflow(c client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, error) {
+	gssName, ok := pod.GetLabels()[gameKruiseV1alpha1.GameServerOwnerGssKey]
+	if !ok {
+		return pod, nil
+	}
+	gss := &gameKruiseV1alpha1.GameServerSet{}
+	err := c.Get(ctx, types.NamespacedName{
+		Namespace: pod.GetNamespace(),
+		Name:      gssName,
+	}, gss)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return pod, nil
+		}
+		return pod, err
+	}
+	policy := gss.Spec.OverflowPolicy
+	if policy == nil || util.GetIndexFromGsName(pod.GetName()) < int(policy.Threshold) {
+		return pod, nil
+	}
+
+	nodeSelector := policy.NodeSelector
+	if len(nodeSelector) == 0 {
+		nodeSelector = map[string]string{defaultOverflowNodeSelectorKey: defaultOverflowNodeSelectorValue}
+	}
+	tolerations := policy.Tolerations
+	if len(tolerations) == 0 {
+		tolerations = []corev1.Toleration{{
+			Key:      defaultOverflowTolerationKey,
+			Operator: corev1.TolerationOpExists,
+		}}
+	}
+	if pod.Spec.NodeSelector == nil {
+		pod.Spec.NodeSelector = make(map[string]string)
+	}
+	for k, v := range nodeSelector {
+		pod.Spec.NodeSelector[k] = v
+	}
+	pod.Spec.Tolerations = append(pod.Spec.Tolerations, tolerations...)
+	if pod.Labels == nil {
+		pod.Labels = make(map[string]string)
+	}
+	pod.Labels[gameKruiseV1alpha1.GameServerOverflowKey] = "true"
+	return pod, nil
+}
+
+// patchWindows pins the game server pods declared to run on Windows nodes to Windows,
+// and removes the sysctls which are only supported by Linux nodes.
+func patchWindows(pod *corev1.Pod) *corev1.Pod {
+	if _, ok := pod.GetLabels()[gameKruiseV1alpha1.GameServerOwnerGssKey]; !ok {
+		return pod
+	}
+	if !util.IsWindowsPodSpec(&pod.Spec) {
+		return pod
+	}
+
+	if pod.Spec.OS == nil {
+		pod.Spec.OS = &corev1.PodOS{Name: corev1.Windows}
+	}
+	if pod.Spec.NodeSelector == nil {
+		pod.Spec.NodeSelector = make(map[string]string)
+	}
+	pod.Spec.NodeSelector[corev1.LabelOSStable] = string(corev1.Windows)
+	if pod.Spec.SecurityContext != nil && len(pod.Spec.SecurityContext.Sysctls) != 0 {
+		klog.Infof("Pod %s/%s runs on Windows nodes, drop Linux-only sysctls %v", pod.Namespace, pod.Name, pod.Spec.SecurityContext.Sysctls)
+		pod.Spec.SecurityContext.Sysctls = nil
+	}
+	return pod
+}
+
+const (
+	networkInitContainerName = "okg-network-init"
+	networkVolumeName        = "okg-network"
+	networkStatusVolumeName  = "okg-network-status"
+)
+
+// patchNetworkEnv adds the init container okg-network-init to the pod annotated with GameServerNetworkInjectEnvKey.
+// The network status annotation is projected to the init container by the downward API, and the addresses are
+// written to a volume mounted by all the containers once the network is ready.
+func patchNetworkEnv(pod *corev1.Pod) (*corev1.Pod, error) {
+	annotations := pod.GetAnnotations()
+	if annotations[gameKruiseV1alpha1.GameServerNetworkInjectEnvKey] != "true" || annotations[gameKruiseV1alpha1.GameServerNetworkType] == "" {
+		return pod, nil
+	}
+	for _, c := range pod.Spec.InitContainers {
+		if c.Name == networkInitContainerName {
+			return pod, nil
+		}
+	}
+	if networkInitImage == "" {
+		return pod, fmt.Errorf("annotation %s requires the flag --network-init-image of kruise-game-manager", gameKruiseV1alpha1.GameServerNetworkInjectEnvKey)
+	}
+
+	statusDir, statusFile := filepath.Split(sdk.DefaultNetworkStatusFile)
+	pod.Spec.Volumes = append(pod.Spec.Volumes,
+		corev1.Volume{
+			Name:         networkVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		},
+		corev1.Volume{
+			Name: networkStatusVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				DownwardAPI: &corev1.DownwardAPIVolumeSource{
+					Items: []corev1.DownwardAPIVolumeFile{{
+						Path:     statusFile,
+						FieldRef: &corev1.ObjectFieldSelector{FieldPath: fmt.Sprintf("metadata.annotations['%s']", gameKruiseV1alpha1.GameServerNetworkStatus)},
+					}},
+				},
+			},
+		},
+	)
+	networkMount := corev1.VolumeMount{Name: networkVolumeName, MountPath: sdk.DefaultNetworkDir}
+	initContainer := corev1.Container{
+		Name:    networkInitContainerName,
+		Image:   networkInitImage,
+		Command: []string{"/okg-network-init"},
+		Args: []string{
+			"--status-file=" + sdk.DefaultNetworkStatusFile,
+			"--output-dir=" + sdk.DefaultNetworkDir,
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			networkMount,
+			{Name: networkStatusVolumeName, MountPath: statusDir, ReadOnly: true},
+		},
+	}
+	// run first, so that the other init containers can read the addresses as well
+	pod.Spec.InitContainers = append([]corev1.Container{initContainer}, pod.Spec.InitContainers...)
+	networkMount.ReadOnly = true
+	for i := 1; i < len(pod.Spec.InitContainers); i++ {
+		pod.Spec.InitContainers[i].VolumeMounts = append(pod.Spec.InitContainers[i].VolumeMounts, networkMount)
+	}
+	for i := range pod.Spec.Containers {
+		pod.Spec.Containers[i].VolumeMounts = append(pod.Spec.Containers[i].VolumeMounts, networkMount)
+	}
+	return pod, nil
+}
+
+// patchArchitecture assigns the pod to the sub-pool of ArchitecturePools which is the furthest below its weighted share,
+// schedules it onto the nodes of the architecture and overrides the images of containers.
+func patchArchitecture(c client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, error) {
+	gssName, ok := pod.GetLabels()[gameKruiseV1alpha1.GameServerOwnerGssKey]
+	if !ok {
+		return pod, nil
+	}
+	gss := &gameKruiseV1alpha1.GameServerSet{}
+	err := c.Get(ctx, types.NamespacedName{
+		Namespace: pod.GetNamespace(),
+		Name:      gssName,
+	}, gss)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return pod, nil
+		}
+		return pod, err
+	}
+	if len(gss.Spec.ArchitecturePools) == 0 {
+		return pod, nil
+	}
+
+	podList := &corev1.PodList{}
+	err = c.List(ctx, podList, client.InNamespace(pod.GetNamespace()), client.MatchingLabels{
+		gameKruiseV1alpha1.GameServerOwnerGssKey: gssName,
+	})
+	if err != nil {
+		return pod, err
+	}
+	archCounts := make(map[string]int)
+	for _, p := range podList.Items {
+		if p.GetName() == pod.GetName() {
+			continue
+		}
+		if arch, ok := p.GetLabels()[gameKruiseV1alpha1.GameServerArchKey]; ok {
+			archCounts[arch]++
+		}
+	}
+	pool := selectArchitecturePool(gss.Spec.ArchitecturePools, archCounts)
+	if pool == nil {
+		return pod, fmt.Errorf("no architecture pool with positive weight")
+	}
+
+	if pod.Spec.NodeSelector == nil {
+		pod.Spec.NodeSelector = make(map[string]string)
+	}
+	pod.Spec.NodeSelector[corev1.LabelArchStable] = pool.Arch
+	for _, image := range pool.Images {
+		for i := range pod.Spec.Containers {
+			if pod.Spec.Containers[i].Name == image.Name {
+				pod.Spec.Containers[i].Image = image.Image
+			}
+		}
+	}
+	if pod.Labels == nil {
+		pod.Labels = make(map[string]string)
+	}
+	pod.Labels[gameKruiseV1alpha1.GameServerArchKey] = pool.Arch
+	return pod, nil
+}
+
+// selectArchitecturePool returns the pool whose number of pods plus one divided by its weight is the smallest,
+// and the first one of them if there are several.
+func selectArchitecturePool(pools []gameKruiseV1alpha1.ArchitecturePool, archCounts map[string]int) *gameKruiseV1alpha1.ArchitecturePool {
+	var selected *gameKruiseV1alpha1.ArchitecturePool
+	var selectedCount, selectedWeight int64
+	for i := range pools {
+		weight := int64(1)
+		if pools[i].Weight != nil {
+			weight = int64(*pools[i].Weight)
+		}
+		if weight <= 0 {
+			continue
+		}
+		count := int64(archCounts[pools[i].Arch]) + 1
+		// count/weight < selectedCount/selectedWeight
+		if selected == nil || count*selectedWeight < selectedCount*weight {
+			selected = &pools[i]
+			selectedCount = count
+			selectedWeight = weight
+		}
+	}
+	return selected
+}
@@ -9,6 +9,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/utils/ptr"
 	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -325,3 +326,361 @@ func TestGetPodFromRequest(t *testing.T) {
 		}
 	}
 }
+
+func TestPatchOverflow(t *testing.T) {
+	newGss := func(policy *gameKruiseV1alpha1.OverflowPolicy) *gameKruiseV1alpha1.GameServerSet {
+		return &gameKruiseV1alpha1.GameServerSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+			Spec:       gameKruiseV1alpha1.GameServerSetSpec{OverflowPolicy: policy},
+		}
+	}
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      name,
+				Labels:    map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "case"},
+			},
+			Spec: corev1.PodSpec{NodeSelector: map[string]string{"arch": "amd64"}},
+		}
+	}
+	customToleration := corev1.Toleration{Key: "eci", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoSchedule}
+	tests := []struct {
+		gss          *gameKruiseV1alpha1.GameServerSet
+		pod          *corev1.Pod
+		overflow     bool
+		nodeSelector map[string]string
+		tolerations  []corev1.Toleration
+	}{
+		// case 0: no overflow policy
+		{
+			gss:          newGss(nil),
+			pod:          newPod("case-5"),
+			nodeSelector: map[string]string{"arch": "amd64"},
+		},
+		// case 1: below threshold
+		{
+			gss:          newGss(&gameKruiseV1alpha1.OverflowPolicy{Threshold: 3}),
+			pod:          newPod("case-2"),
+			nodeSelector: map[string]string{"arch": "amd64"},
+		},
+		// case 2: default node selector and tolerations
+		{
+			gss:          newGss(&gameKruiseV1alpha1.OverflowPolicy{Threshold: 3}),
+			pod:          newPod("case-3"),
+			overflow:     true,
+			nodeSelector: map[string]string{"arch": "amd64", "type": "virtual-kubelet"},
+			tolerations:  []corev1.Toleration{{Key: "virtual-kubelet.io/provider", Operator: corev1.TolerationOpExists}},
+		},
+		// case 3: custom node selector and tolerations
+		{
+			gss: newGss(&gameKruiseV1alpha1.OverflowPolicy{
+				Threshold:    0,
+				NodeSelector: map[string]string{"alibabacloud.com/eci": "true"},
+				Tolerations:  []corev1.Toleration{customToleration},
+			}),
+			pod:          newPod("case-0"),
+			overflow:     true,
+			nodeSelector: map[string]string{"arch": "amd64", "alibabacloud.com/eci": "true"},
+			tolerations:  []corev1.Toleration{customToleration},
+		},
+	}
+
+	for i, test := range tests {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(test.gss).Build()
+		newPod, err := patchOverflow(c, test.pod, context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		if overflow := newPod.GetLabels()[gameKruiseV1alpha1.GameServerOverflowKey] == "true"; overflow != test.overflow {
+			t.Errorf("case %d: expect overflow %v, but actually got %v", i, test.overflow, overflow)
+		}
+		if !reflect.DeepEqual(test.nodeSelector, newPod.Spec.NodeSelector) {
+			t.Errorf("case %d: expect node selector %v, but actually got %v", i, test.nodeSelector, newPod.Spec.NodeSelector)
+		}
+		if !reflect.DeepEqual(test.tolerations, newPod.Spec.Tolerations) {
+			t.Errorf("case %d: expect tolerations %v, but actually got %v", i, test.tolerations, newPod.Spec.Tolerations)
+		}
+	}
+}
+
+func TestPatchResourceProfile(t *testing.T) {
+	large := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+	}
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			ResourceProfiles: []gameKruiseV1alpha1.ResourceProfile{
+				{Name: "large", Containers: []gameKruiseV1alpha1.ResourceProfileContainer{{Resources: large}}},
+			},
+		},
+	}
+	tests := []struct {
+		profile         string
+		expectProfile   string
+		expectResources corev1.ResourceRequirements
+	}{
+		{
+			profile: "",
+		},
+		{
+			profile: "medium",
+		},
+		{
+			profile:         "large",
+			expectProfile:   "large",
+			expectResources: large,
+		},
+	}
+
+	for i, test := range tests {
+		gs := &gameKruiseV1alpha1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case-0"},
+			Spec:       gameKruiseV1alpha1.GameServerSpec{ResourceProfile: test.profile},
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      "case-0",
+				Labels:    map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "case"},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "game"}}},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gss, gs).Build()
+		newPod, err := patchResourceProfile(c, pod, context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		if profile := newPod.GetAnnotations()[gameKruiseV1alpha1.GameServerResourceProfileKey]; profile != test.expectProfile {
+			t.Errorf("case %d: expect resource profile %s, but actually got %s", i, test.expectProfile, profile)
+		}
+		if !reflect.DeepEqual(test.expectResources, newPod.Spec.Containers[0].Resources) {
+			t.Errorf("case %d: expect resources %v, but actually got %v", i, test.expectResources, newPod.Spec.Containers[0].Resources)
+		}
+	}
+}
+
+func TestPatchWindows(t *testing.T) {
+	gssLabels := map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "gss"}
+	sysctls := []corev1.Sysctl{{Name: "net.ipv4.ip_local_port_range", Value: "1024 65535"}}
+	tests := []struct {
+		pod          *corev1.Pod
+		os           *corev1.PodOS
+		nodeSelector map[string]string
+		sysctls      []corev1.Sysctl
+	}{
+		// case 0: linux pod is not changed
+		{
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Labels: gssLabels},
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{Sysctls: sysctls},
+				},
+			},
+			sysctls: sysctls,
+		},
+		// case 1: pod not owned by GameServerSet is not changed
+		{
+			pod: &corev1.Pod{
+				Spec: corev1.PodSpec{
+					NodeSelector:    map[string]string{corev1.LabelOSStable: "windows"},
+					SecurityContext: &corev1.PodSecurityContext{Sysctls: sysctls},
+				},
+			},
+			nodeSelector: map[string]string{corev1.LabelOSStable: "windows"},
+			sysctls:      sysctls,
+		},
+		// case 2: windows pod selected by node selector
+		{
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Labels: gssLabels},
+				Spec: corev1.PodSpec{
+					NodeSelector:    map[string]string{corev1.LabelOSStable: "windows"},
+					SecurityContext: &corev1.PodSecurityContext{Sysctls: sysctls},
+				},
+			},
+			os:           &corev1.PodOS{Name: corev1.Windows},
+			nodeSelector: map[string]string{corev1.LabelOSStable: "windows"},
+		},
+		// case 3: windows pod declared by os
+		{
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Labels: gssLabels},
+				Spec: corev1.PodSpec{
+					OS: &corev1.PodOS{Name: corev1.Windows},
+				},
+			},
+			os:           &corev1.PodOS{Name: corev1.Windows},
+			nodeSelector: map[string]string{corev1.LabelOSStable: "windows"},
+		},
+	}
+
+	for i, test := range tests {
+		newPod := patchWindows(test.pod)
+		if !reflect.DeepEqual(test.os, newPod.Spec.OS) {
+			t.Errorf("case %d: expect os %v, but actually got %v", i, test.os, newPod.Spec.OS)
+		}
+		if !reflect.DeepEqual(test.nodeSelector, newPod.Spec.NodeSelector) {
+			t.Errorf("case %d: expect node selector %v, but actually got %v", i, test.nodeSelector, newPod.Spec.NodeSelector)
+		}
+		var actualSysctls []corev1.Sysctl
+		if newPod.Spec.SecurityContext != nil {
+			actualSysctls = newPod.Spec.SecurityContext.Sysctls
+		}
+		if !reflect.DeepEqual(test.sysctls, actualSysctls) {
+			t.Errorf("case %d: expect sysctls %v, but actually got %v", i, test.sysctls, actualSysctls)
+		}
+	}
+}
+
+func TestPatchNetworkEnv(t *testing.T) {
+	annotations := map[string]string{
+		gameKruiseV1alpha1.GameServerNetworkType:         "Kubernetes-HostPort",
+		gameKruiseV1alpha1.GameServerNetworkInjectEnvKey: "true",
+	}
+	tests := []struct {
+		image          string
+		annotations    map[string]string
+		initContainers []corev1.Container
+		expectInit     []string
+		expectErr      bool
+	}{
+		// case 0: not annotated
+		{
+			image:       "okg-network-init:test",
+			annotations: map[string]string{gameKruiseV1alpha1.GameServerNetworkType: "Kubernetes-HostPort"},
+		},
+		// case 1: injected before the other init containers
+		{
+			image:          "okg-network-init:test",
+			annotations:    annotations,
+			initContainers: []corev1.Container{{Name: "init"}},
+			expectInit:     []string{networkInitContainerName, "init"},
+		},
+		// case 2: injected already
+		{
+			image:          "okg-network-init:test",
+			annotations:    annotations,
+			initContainers: []corev1.Container{{Name: networkInitContainerName}},
+			expectInit:     []string{networkInitContainerName},
+		},
+		// case 3: no image
+		{
+			annotations: annotations,
+			expectErr:   true,
+		},
+	}
+
+	defer func(image string) { networkInitImage = image }(networkInitImage)
+	for i, test := range tests {
+		networkInitImage = test.image
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations},
+			Spec: corev1.PodSpec{
+				InitContainers: test.initContainers,
+				Containers:     []corev1.Container{{Name: "game"}},
+			},
+		}
+		newPod, err := patchNetworkEnv(pod)
+		if (err != nil) != test.expectErr {
+			t.Errorf("case %d: expect error %v, but actually got %v", i, test.expectErr, err)
+			continue
+		}
+		var initNames []string
+		for _, c := range newPod.Spec.InitContainers {
+			initNames = append(initNames, c.Name)
+		}
+		if !reflect.DeepEqual(test.expectInit, initNames) {
+			t.Errorf("case %d: expect init containers %v, but actually got %v", i, test.expectInit, initNames)
+		}
+		if len(test.expectInit) != 2 {
+			continue
+		}
+		if len(newPod.Spec.Volumes) != 2 {
+			t.Errorf("case %d: expect 2 volumes, but actually got %v", i, newPod.Spec.Volumes)
+		}
+		for _, c := range append(newPod.Spec.InitContainers[1:], newPod.Spec.Containers...) {
+			if len(c.VolumeMounts) != 1 || c.VolumeMounts[0].Name != networkVolumeName || !c.VolumeMounts[0].ReadOnly {
+				t.Errorf("case %d: expect container %s mounting the network volume read-only, but actually got %v", i, c.Name, c.VolumeMounts)
+			}
+		}
+	}
+}
+
+func TestPatchArchitecture(t *testing.T) {
+	gss := &gameKruiseV1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+		Spec: gameKruiseV1alpha1.GameServerSetSpec{
+			ArchitecturePools: []gameKruiseV1alpha1.ArchitecturePool{
+				{
+					Arch: "amd64",
+				},
+				{
+					Arch:   "arm64",
+					Weight: ptr.To[int32](2),
+					Images: []gameKruiseV1alpha1.ContainerImage{{Name: "game", Image: "game:v1-arm64"}},
+				},
+			},
+		},
+	}
+	newPod := func(name, arch string) *corev1.Pod {
+		labels := map[string]string{gameKruiseV1alpha1.GameServerOwnerGssKey: "case"}
+		if arch != "" {
+			labels[gameKruiseV1alpha1.GameServerArchKey] = arch
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "xxx",
+				Name:      name,
+				Labels:    labels,
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "game", Image: "game:v1"}}},
+		}
+	}
+	tests := []struct {
+		existing []*corev1.Pod
+		arch     string
+		image    string
+	}{
+		// case 0: arm64 has the most weight
+		{
+			existing: nil,
+			arch:     "arm64",
+			image:    "game:v1-arm64",
+		},
+		// case 1: arm64 reaches its share
+		{
+			existing: []*corev1.Pod{newPod("case-0", "arm64"), newPod("case-1", "arm64")},
+			arch:     "amd64",
+			image:    "game:v1",
+		},
+		// case 2: amd64 reaches its share
+		{
+			existing: []*corev1.Pod{newPod("case-0", "arm64"), newPod("case-1", "arm64"), newPod("case-2", "amd64")},
+			arch:     "arm64",
+			image:    "game:v1-arm64",
+		},
+	}
+
+	for i, test := range tests {
+		objs := []client.Object{gss.DeepCopy()}
+		for _, p := range test.existing {
+			objs = append(objs, p)
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+		newPod, err := patchArchitecture(c, newPod("case-9", ""), context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		if arch := newPod.GetLabels()[gameKruiseV1alpha1.GameServerArchKey]; arch != test.arch {
+			t.Errorf("case %d: expect arch %s, but actually got %s", i, test.arch, arch)
+		}
+		if arch := newPod.Spec.NodeSelector[corev1.LabelArchStable]; arch != test.arch {
+			t.Errorf("case %d: expect node selector arch %s, but actually got %s", i, test.arch, arch)
+		}
+		if image := newPod.Spec.Containers[0].Image; image != test.image {
+			t.Errorf("case %d: expect image %s, but actually got %s", i, test.image, image)
+		}
+	}
+}
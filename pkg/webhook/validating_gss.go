@@ -20,12 +20,30 @@ import (
 	"context"
 	"fmt"
 	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
+	"github.com/openkruise/kruise-game/cloudprovider"
+	"github.com/openkruise/kruise-game/cloudprovider/alibabacloud"
+	"github.com/openkruise/kruise-game/cloudprovider/kubernetes"
 	"github.com/openkruise/kruise-game/cloudprovider/manager"
+	"github.com/openkruise/kruise-game/cloudprovider/utils"
 	"github.com/openkruise/kruise-game/pkg/util"
+	"github.com/openkruise/kruise-game/pkg/util/scoring"
 	admissionv1 "k8s.io/api/admission/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"net"
 	"net/http"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type GssValidaatingHandler struct {
@@ -35,6 +53,10 @@ type GssValidaatingHandler struct {
 }
 
 func (gvh *GssValidaatingHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.SubResource == "scale" {
+		return gvh.handleScale(ctx, req)
+	}
+
 	gss := &gamekruiseiov1alpha1.GameServerSet{}
 	err := gvh.decoder.Decode(req, gss)
 	if err != nil {
@@ -42,9 +64,14 @@ func (gvh *GssValidaatingHandler) Handle(ctx context.Context, req admission.Requ
 	}
 
 	if allowed, reason := validatingGss(gss, gvh.Client); !allowed {
-		admission.ValidationResponse(allowed, reason)
+		return admission.ValidationResponse(allowed, reason)
+	}
+
+	if err := validatingNetworkConf(gss, gvh.CloudProviderManager); err != nil {
+		return admission.ValidationResponse(false, err.Error())
 	}
 
+	warnings := lintGss(gss)
 	switch req.Operation {
 	case admissionv1.Update:
 		newGss := gss.DeepCopy()
@@ -53,12 +80,43 @@ func (gvh *GssValidaatingHandler) Handle(ctx context.Context, req admission.Requ
 		if err != nil {
 			return admission.Errored(http.StatusBadRequest, err)
 		}
-		return validatingUpdate(newGss, oldGss)
+		if allowed, reason := validatingPortBudget(ctx, newGss, getReplicas(newGss)-getReplicas(oldGss), gvh.CloudProviderManager, gvh.Client); !allowed {
+			return admission.ValidationResponse(allowed, reason)
+		}
+		return validatingUpdate(newGss, oldGss).WithWarnings(warnings...)
 	case admissionv1.Create:
 		newGss := gss.DeepCopy()
-		return validatingCreate(newGss, gvh.CloudProviderManager)
+		resp := validatingCreate(newGss, gvh.CloudProviderManager)
+		if !resp.Allowed {
+			return resp
+		}
+		if allowed, reason := validatingPortBudget(ctx, newGss, getReplicas(newGss), gvh.CloudProviderManager, gvh.Client); !allowed {
+			return admission.ValidationResponse(allowed, reason)
+		}
+		return resp.WithWarnings(warnings...)
 	}
 
+	return admission.ValidationResponse(true, "pass validating").WithWarnings(warnings...)
+}
+
+// handleScale validates the replicas updated through the scale subresource, such as by HPA or kubectl scale,
+// which bypasses the validation of the GameServerSet itself.
+func (gvh *GssValidaatingHandler) handleScale(ctx context.Context, req admission.Request) admission.Response {
+	scale := &autoscalingv1.Scale{}
+	if err := gvh.decoder.Decode(req, scale); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	oldScale := &autoscalingv1.Scale{}
+	if err := gvh.decoder.DecodeRaw(req.OldObject, oldScale); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	gss := &gamekruiseiov1alpha1.GameServerSet{}
+	if err := gvh.Client.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: req.Name}, gss); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if allowed, reason := validatingPortBudget(ctx, gss, int(scale.Spec.Replicas-oldScale.Spec.Replicas), gvh.CloudProviderManager, gvh.Client); !allowed {
+		return admission.ValidationResponse(allowed, reason)
+	}
 	return admission.ValidationResponse(true, "pass validating")
 }
 
@@ -71,10 +129,674 @@ func validatingGss(gss *gamekruiseiov1alpha1.GameServerSet, client client.Client
 	if util.IsHasNegativeNum(rgsIds) {
 		return false, fmt.Sprintf("reserveGameServerIds should be greater or equal to 0. Now it is %v", rgsIds)
 	}
+	if err := validatingReserveGameServerIdRanges(gss.Spec.ReserveGameServerIdRanges); err != nil {
+		return false, err.Error()
+	}
+
+	// validate freezeWindows
+	for _, window := range gss.Spec.UpdateStrategy.FreezeWindows {
+		if err := util.ValidateFreezeWindow(window); err != nil {
+			return false, err.Error()
+		}
+	}
+
+	// validate scoringPolicy
+	if gss.Spec.ScoringPolicy != nil && gss.Spec.ScoringPolicy.Expression != "" {
+		if _, err := scoring.Compile(gss.Spec.ScoringPolicy.Expression); err != nil {
+			return false, fmt.Sprintf("scoringPolicy.expression is invalid: %s", err.Error())
+		}
+	}
+
+	// validate customStatusFields
+	if err := validatingCustomStatusFields(gss.Spec.CustomStatusFields); err != nil {
+		return false, err.Error()
+	}
+
+	// validate scaleStrategy
+	if err := validatingScaleStrategy(gss.Spec.ScaleStrategy); err != nil {
+		return false, err.Error()
+	}
+
+	// validate overflowPolicy
+	if err := validatingOverflowPolicy(gss); err != nil {
+		return false, err.Error()
+	}
+
+	// validate architecturePools
+	if err := validatingArchitecturePools(gss); err != nil {
+		return false, err.Error()
+	}
+
+	// validate windows
+	if err := validatingWindows(gss); err != nil {
+		return false, err.Error()
+	}
+
+	// validate restartPolicy
+	if err := validatingRestartPolicy(gss.Spec.RestartPolicy); err != nil {
+		return false, err.Error()
+	}
+
+	// validate gameContainerName
+	if err := validatingGameContainerName(gss); err != nil {
+		return false, err.Error()
+	}
+
+	// validate memoryLeakPolicy
+	if err := validatingMemoryLeakPolicy(gss); err != nil {
+		return false, err.Error()
+	}
+
+	// validate egressPolicy
+	if err := validatingEgressPolicy(gss.Spec.EgressPolicy); err != nil {
+		return false, err.Error()
+	}
+
+	// validate stabilization window of network
+	if err := validatingStabilizationWindow(gss.Spec.Network); err != nil {
+		return false, err.Error()
+	}
+
+	// validate service collision policy of network
+	if gss.Spec.Network != nil {
+		if _, err := utils.GetServiceCollisionPolicy(gss.Spec.Network.NetworkConf); err != nil {
+			return false, err.Error()
+		}
+	}
+
+	// validate secret refs of network
+	if err := validatingSecretKeyRefs(gss); err != nil {
+		return false, err.Error()
+	}
+
+	// validate target ports of network
+	if err := validatingTargetPorts(gss); err != nil {
+		return false, err.Error()
+	}
+
+	// validate resourceProfiles
+	if err := validatingResourceProfiles(gss); err != nil {
+		return false, err.Error()
+	}
+
+	// validate serviceQualityTemplateName
+	if err := validatingServiceQualityTemplate(gss, client); err != nil {
+		return false, err.Error()
+	}
+
+	// validate probes of serviceQualities
+	if err := validatingServiceQualityProbes(gss.Spec.ServiceQualities); err != nil {
+		return false, err.Error()
+	}
+
+	// validate actions of serviceQualities
+	if err := validatingServiceQualityActions(gss.Spec.ServiceQualities); err != nil {
+		return false, err.Error()
+	}
+
+	// validate preDeleteHook
+	if err := validatingPreDeleteHook(gss.Spec.PreDeleteHook); err != nil {
+		return false, err.Error()
+	}
+
+	// validate scalingPolicy
+	if err := validatingScalingPolicy(gss.Spec.ScalingPolicy); err != nil {
+		return false, err.Error()
+	}
 
 	return true, "general validating success"
 }
 
+// validatingReserveGameServerIdRanges checks whether the ranges of ids are valid and not overlapped.
+func validatingReserveGameServerIdRanges(ranges []string) error {
+	ids, err := util.ParseReserveIdRanges(ranges)
+	if err != nil {
+		return fmt.Errorf("reserveGameServerIdRanges is invalid: %s", err.Error())
+	}
+	if util.IsRepeat(ids) {
+		return fmt.Errorf("reserveGameServerIdRanges should not be overlapped. Now it is %v", ranges)
+	}
+	return nil
+}
+
+func validatingCustomStatusFields(fields []gamekruiseiov1alpha1.CustomStatusField) error {
+	names := sets.NewString()
+	for _, field := range fields {
+		if names.Has(field.Name) {
+			return fmt.Errorf("customStatusFields should not be repeat. %s is repeated", field.Name)
+		}
+		names.Insert(field.Name)
+		if errs := validation.IsQualifiedName(gamekruiseiov1alpha1.GameServerCustomStatusPrefix + field.Name); len(errs) != 0 {
+			return fmt.Errorf("customStatusFields name %s is invalid: %s", field.Name, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// validatingScaleStrategy checks whether the number of players is read from somewhere for LeastPlayers.
+func validatingScaleStrategy(strategy gamekruiseiov1alpha1.ScaleStrategy) error {
+	if strategy.ScaleDownStrategyType != gamekruiseiov1alpha1.LeastPlayersScaleDownStrategyType {
+		return nil
+	}
+	source := strategy.PlayersSource
+	if source == nil || (source.ServiceQualityName == "" && source.Annotation == "") {
+		return fmt.Errorf("scaleStrategy.playersSource should be set when scaleDownStrategyType is %s", gamekruiseiov1alpha1.LeastPlayersScaleDownStrategyType)
+	}
+	return nil
+}
+
+// validatingServiceQualityTemplate checks whether the ServiceQualityTemplate referenced by the GameServerSet exists.
+func validatingServiceQualityTemplate(gss *gamekruiseiov1alpha1.GameServerSet, c client.Client) error {
+	name := gss.Spec.ServiceQualityTemplateName
+	if name == "" {
+		return nil
+	}
+	if _, err := util.GetServiceQualities(gss, c, context.Background()); err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("serviceQualityTemplate %s is not found", name)
+		}
+		return fmt.Errorf("failed to get serviceQualityTemplate %s: %s", name, err.Error())
+	}
+	return nil
+}
+
+// validatingServiceQualityProbes checks whether each service quality is probed by exactly one of exec, grpc and
+// tcpSocket.
+func validatingServiceQualityProbes(sqs []gamekruiseiov1alpha1.ServiceQuality) error {
+	for _, sq := range sqs {
+		if sq.HTTPGet != nil {
+			return fmt.Errorf("serviceQuality %s: httpGet is not supported, use exec, grpc or tcpSocket instead", sq.Name)
+		}
+		handlers := 0
+		if sq.Exec != nil {
+			handlers++
+		}
+		if sq.GRPC != nil {
+			handlers++
+			if sq.GRPC.Port < 1 || sq.GRPC.Port > 65535 {
+				return fmt.Errorf("serviceQuality %s: grpc port %d is out of range 1-65535", sq.Name, sq.GRPC.Port)
+			}
+		}
+		if sq.TCPSocket != nil {
+			handlers++
+			port := sq.TCPSocket.Port
+			if port.Type == intstr.Int && (port.IntVal < 1 || port.IntVal > 65535) {
+				return fmt.Errorf("serviceQuality %s: tcpSocket port %d is out of range 1-65535", sq.Name, port.IntVal)
+			}
+			if port.Type == intstr.String && port.StrVal == "" {
+				return fmt.Errorf("serviceQuality %s: tcpSocket port should be set", sq.Name)
+			}
+		}
+		if handlers != 1 {
+			return fmt.Errorf("serviceQuality %s should be probed by exactly one of exec, grpc and tcpSocket", sq.Name)
+		}
+	}
+	return nil
+}
+
+// validatingServiceQualityActions checks whether the result ranges, the types, the keys and the templates of the
+// actions of each service quality are valid. The values without templates are checked as they are.
+func validatingServiceQualityActions(sqs []gamekruiseiov1alpha1.ServiceQuality) error {
+	for _, sq := range sqs {
+		for i, action := range sq.ServiceQualityAction {
+			if err := validatingServiceQualityActionMatch(action); err != nil {
+				return fmt.Errorf("serviceQuality %s: action %d is invalid: %s", sq.Name, i, err.Error())
+			}
+			for _, item := range action.Actions {
+				if err := validatingServiceQualityActionItem(item); err != nil {
+					return fmt.Errorf("serviceQuality %s: %s action is invalid: %s", sq.Name, item.Type, err.Error())
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func validatingServiceQualityActionMatch(action gamekruiseiov1alpha1.ServiceQualityAction) error {
+	if action.ConsecutiveProbes != nil && *action.ConsecutiveProbes < 1 {
+		return fmt.Errorf("consecutiveProbes should be at least 1")
+	}
+	r := action.ResultRange
+	if r == nil {
+		return nil
+	}
+	if action.Result != "" {
+		return fmt.Errorf("result and resultRange cannot be both set")
+	}
+	if r.Min == nil && r.Max == nil && r.Gt == nil && r.Lt == nil {
+		return fmt.Errorf("resultRange should have at least one of min, max, gt and lt")
+	}
+	for _, lower := range []*resource.Quantity{r.Min, r.Gt} {
+		for _, upper := range []*resource.Quantity{r.Max, r.Lt} {
+			if lower == nil || upper == nil {
+				continue
+			}
+			if cmp := lower.Cmp(*upper); cmp > 0 || (cmp == 0 && (lower == r.Gt || upper == r.Lt)) {
+				return fmt.Errorf("resultRange matches no result")
+			}
+		}
+	}
+	return nil
+}
+
+func validatingServiceQualityActionItem(item gamekruiseiov1alpha1.ServiceQualityActionItem) error {
+	switch item.Type {
+	case gamekruiseiov1alpha1.SetOpsStateActionType, gamekruiseiov1alpha1.SetUpdatePriorityActionType, gamekruiseiov1alpha1.SetDeletionPriorityActionType:
+		if item.Value == "" {
+			return fmt.Errorf("value should be set")
+		}
+		if _, err := util.ParseServiceQualityActionTemplate(item.Value); err != nil {
+			return err
+		}
+		if item.Type != gamekruiseiov1alpha1.SetOpsStateActionType && !strings.Contains(item.Value, "{{") {
+			if intstr.Parse(item.Value).Type != intstr.Int {
+				return fmt.Errorf("priority %s is not an integer", item.Value)
+			}
+		}
+	case gamekruiseiov1alpha1.PatchLabelsActionType, gamekruiseiov1alpha1.PatchAnnotationsActionType:
+		if len(item.Values) == 0 {
+			return fmt.Errorf("values should be set")
+		}
+		for key, value := range item.Values {
+			if errs := validation.IsQualifiedName(key); len(errs) != 0 {
+				return fmt.Errorf("key %s is invalid: %s", key, strings.Join(errs, ", "))
+			}
+			// the labels and annotations of OKG are managed by the controllers
+			if strings.HasPrefix(key, "game.kruise.io/") {
+				return fmt.Errorf("key %s is reserved", key)
+			}
+			if _, err := util.ParseServiceQualityActionTemplate(value); err != nil {
+				return err
+			}
+			if item.Type == gamekruiseiov1alpha1.PatchLabelsActionType && !strings.Contains(value, "{{") {
+				if errs := validation.IsValidLabelValue(value); len(errs) != 0 {
+					return fmt.Errorf("label value %s is invalid: %s", value, strings.Join(errs, ", "))
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("unknown type, which should be one of %s, %s, %s, %s and %s", gamekruiseiov1alpha1.SetOpsStateActionType,
+			gamekruiseiov1alpha1.SetUpdatePriorityActionType, gamekruiseiov1alpha1.SetDeletionPriorityActionType,
+			gamekruiseiov1alpha1.PatchLabelsActionType, gamekruiseiov1alpha1.PatchAnnotationsActionType)
+	}
+	return nil
+}
+
+// validatingPreDeleteHook checks whether the endpoint of PreDeleteHook is valid.
+func validatingPreDeleteHook(hook *gamekruiseiov1alpha1.PreDeleteHook) error {
+	if hook == nil {
+		return nil
+	}
+	if hook.Scheme != "" && hook.Scheme != corev1.URISchemeHTTP && hook.Scheme != corev1.URISchemeHTTPS && hook.Scheme != gamekruiseiov1alpha1.PreDeleteHookSchemeGRPC {
+		return fmt.Errorf("preDeleteHook.scheme should be %s, %s or %s. Now it is %s", corev1.URISchemeHTTP, corev1.URISchemeHTTPS, gamekruiseiov1alpha1.PreDeleteHookSchemeGRPC, hook.Scheme)
+	}
+	if hook.Scheme != gamekruiseiov1alpha1.PreDeleteHookSchemeGRPC && !strings.HasPrefix(hook.Path, "/") {
+		return fmt.Errorf("preDeleteHook.path should start with /. Now it is %s", hook.Path)
+	}
+	if hook.Port < 1 || hook.Port > 65535 {
+		return fmt.Errorf("preDeleteHook.port should be in [1, 65535]. Now it is %d", hook.Port)
+	}
+	return nil
+}
+
+// validatingScalingPolicy checks whether the limits of idle GameServers and replicas of ScalingPolicy are consistent.
+func validatingScalingPolicy(policy *gamekruiseiov1alpha1.ScalingPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.MaxIdle != nil && *policy.MaxIdle < policy.MinIdle {
+		return fmt.Errorf("scalingPolicy.maxIdle should not be less than minIdle %d. Now it is %d", policy.MinIdle, *policy.MaxIdle)
+	}
+	if policy.MinReplicas != nil && *policy.MinReplicas > policy.MaxReplicas {
+		return fmt.Errorf("scalingPolicy.minReplicas should not be greater than maxReplicas %d. Now it is %d", policy.MaxReplicas, *policy.MinReplicas)
+	}
+	names := sets.NewString()
+	for _, schedule := range policy.Schedules {
+		if schedule.Name == "" || names.Has(schedule.Name) {
+			return fmt.Errorf("scalingPolicy.schedules should have unique and non-empty names. Now there is %q", schedule.Name)
+		}
+		names.Insert(schedule.Name)
+		if _, err := util.ParseCron(schedule.Cron); err != nil {
+			return fmt.Errorf("cron of scalingPolicy.schedules %s is invalid: %s", schedule.Name, err.Error())
+		}
+		if _, err := time.LoadLocation(schedule.TimeZone); err != nil {
+			return fmt.Errorf("timeZone of scalingPolicy.schedules %s is invalid: %s", schedule.Name, err.Error())
+		}
+		if schedule.Replicas > policy.MaxReplicas {
+			return fmt.Errorf("replicas of scalingPolicy.schedules %s should not be greater than maxReplicas %d. Now it is %d", schedule.Name, policy.MaxReplicas, schedule.Replicas)
+		}
+	}
+	return nil
+}
+
+// validatingRestartPolicy checks whether the cron, time zone and maxUnavailable of RestartPolicy are valid.
+func validatingRestartPolicy(policy *gamekruiseiov1alpha1.RestartPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if _, err := util.ParseCron(policy.Cron); err != nil {
+		return fmt.Errorf("restartPolicy.cron is invalid: %s", err.Error())
+	}
+	if _, err := time.LoadLocation(policy.TimeZone); err != nil {
+		return fmt.Errorf("restartPolicy.timeZone %s is invalid", policy.TimeZone)
+	}
+	if policy.MaxUnavailable != nil {
+		if _, err := intstr.GetScaledValueFromIntOrPercent(policy.MaxUnavailable, 100, false); err != nil {
+			return fmt.Errorf("restartPolicy.maxUnavailable is invalid: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+// validatingGameContainerName checks whether the game container exists in the template.
+func validatingGameContainerName(gss *gamekruiseiov1alpha1.GameServerSet) error {
+	if gss.Spec.GameContainerName == "" {
+		return nil
+	}
+	for _, container := range gss.Spec.GameServerTemplate.Spec.Containers {
+		if container.Name == gss.Spec.GameContainerName {
+			return nil
+		}
+	}
+	return fmt.Errorf("gameContainerName %s is not found in the containers of gameServerTemplate", gss.Spec.GameContainerName)
+}
+
+// validatingMemoryLeakPolicy checks whether MemoryLeakPolicy has any threshold, and watches an existing container.
+func validatingMemoryLeakPolicy(gss *gamekruiseiov1alpha1.GameServerSet) error {
+	policy := gss.Spec.MemoryLeakPolicy
+	if policy == nil {
+		return nil
+	}
+	if policy.LimitPercent == nil && policy.GrowthPerMinute == nil {
+		return fmt.Errorf("memoryLeakPolicy should have limitPercent or growthPerMinute")
+	}
+	if policy.GrowthPerMinute != nil && policy.GrowthPerMinute.Sign() <= 0 {
+		return fmt.Errorf("memoryLeakPolicy.growthPerMinute should be greater than 0. Now it is %s", policy.GrowthPerMinute.String())
+	}
+	if policy.ContainerName == "" {
+		return nil
+	}
+	for _, container := range gss.Spec.GameServerTemplate.Spec.Containers {
+		if container.Name == policy.ContainerName {
+			return nil
+		}
+	}
+	return fmt.Errorf("memoryLeakPolicy.containerName %s is not found in the containers of gameServerTemplate", policy.ContainerName)
+}
+
+// validatingEgressPolicy checks whether the CIDRs of EgressPolicy are valid.
+func validatingEgressPolicy(policy *gamekruiseiov1alpha1.EgressPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	for _, cidr := range policy.CIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("egressPolicy.cidrs %s is invalid: %s", cidr, err.Error())
+		}
+	}
+	return nil
+}
+
+// validatingResourceProfiles checks whether the names of ResourceProfiles are unique, and the profiles
+// refer to the existing containers.
+func validatingResourceProfiles(gss *gamekruiseiov1alpha1.GameServerSet) error {
+	containerNames := sets.NewString()
+	for _, container := range gss.Spec.GameServerTemplate.Spec.Containers {
+		containerNames.Insert(container.Name)
+	}
+	names := sets.NewString()
+	for _, profile := range gss.Spec.ResourceProfiles {
+		if names.Has(profile.Name) {
+			return fmt.Errorf("resourceProfiles should not be repeat. %s is repeated", profile.Name)
+		}
+		names.Insert(profile.Name)
+		for _, container := range profile.Containers {
+			if container.Name != "" && !containerNames.Has(container.Name) {
+				return fmt.Errorf("resourceProfiles %s container %s is not found in the containers of gameServerTemplate", profile.Name, container.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// validatingStabilizationWindow checks whether StabilizationWindowSeconds of the network conf is a non-negative integer.
+func validatingStabilizationWindow(network *gamekruiseiov1alpha1.Network) error {
+	if network == nil {
+		return nil
+	}
+	for _, conf := range network.NetworkConf {
+		if conf.Name != gamekruiseiov1alpha1.StabilizationWindowSecondsNetworkConfName {
+			continue
+		}
+		if seconds, err := strconv.Atoi(conf.Value); err != nil || seconds < 0 {
+			return fmt.Errorf("network %s should be a non-negative integer. Now it is %s", conf.Name, conf.Value)
+		}
+	}
+	return nil
+}
+
+// validatingSecretKeyRefs checks whether the network conf values referencing Secrets are well-formed and in the
+// namespace of the GameServerSet, which are the only Secrets the network manager resolves.
+func validatingSecretKeyRefs(gss *gamekruiseiov1alpha1.GameServerSet) error {
+	if gss.Spec.Network == nil {
+		return nil
+	}
+	for _, conf := range gss.Spec.Network.NetworkConf {
+		ref, found := strings.CutPrefix(conf.Value, utils.SecretKeyRefScheme+"://")
+		if !found {
+			continue
+		}
+		namespace, _, _, err := utils.ParseSecretKeyRef(ref)
+		if err != nil {
+			return fmt.Errorf("network %s is invalid: %s", conf.Name, err.Error())
+		}
+		if namespace != gss.GetNamespace() {
+			return fmt.Errorf("network %s references a Secret in namespace %s, which should be the namespace %s of the GameServerSet", conf.Name, namespace, gss.GetNamespace())
+		}
+	}
+	return nil
+}
+
+// getTargetPorts returns the target ports and protocols declared by PortProtocols of the network conf, such as
+// 7777/UDP,8080, where the protocol is TCP by default.
+func getTargetPorts(gss *gamekruiseiov1alpha1.GameServerSet) map[int32]string {
+	if gss.Spec.Network == nil {
+		return nil
+	}
+	targetPorts := make(map[int32]string)
+	for _, conf := range gss.Spec.Network.NetworkConf {
+		if conf.Name != alibabacloud.PortProtocolsConfigName {
+			continue
+		}
+		for _, pp := range strings.Split(conf.Value, ",") {
+			ppSlice := strings.Split(strings.TrimSpace(pp), "/")
+			port, err := strconv.ParseInt(ppSlice[0], 10, 32)
+			if err != nil {
+				continue
+			}
+			protocol := string(corev1.ProtocolTCP)
+			if len(ppSlice) == 2 {
+				protocol = ppSlice[1]
+			}
+			targetPorts[int32(port)] = protocol
+		}
+	}
+	return targetPorts
+}
+
+// getContainerPorts returns the protocols of the container ports declared in the template by port.
+func getContainerPorts(gss *gamekruiseiov1alpha1.GameServerSet) map[int32][]corev1.Protocol {
+	containerPorts := make(map[int32][]corev1.Protocol)
+	for _, c := range gss.Spec.GameServerTemplate.Spec.Containers {
+		for _, port := range c.Ports {
+			protocol := port.Protocol
+			if protocol == "" {
+				protocol = corev1.ProtocolTCP
+			}
+			containerPorts[port.ContainerPort] = append(containerPorts[port.ContainerPort], protocol)
+		}
+	}
+	return containerPorts
+}
+
+// validatingTargetPorts checks whether the target ports of PortProtocols are listened by the containers, which is
+// only checked when the template declares container ports, so that the network exposing a port no container listens
+// on is rejected before a fleet of unreachable game servers is created.
+func validatingTargetPorts(gss *gamekruiseiov1alpha1.GameServerSet) error {
+	containerPorts := getContainerPorts(gss)
+	if len(containerPorts) == 0 {
+		return nil
+	}
+	targetPorts := getTargetPorts(gss)
+	ports := make([]int, 0, len(targetPorts))
+	for port := range targetPorts {
+		ports = append(ports, int(port))
+	}
+	sort.Ints(ports)
+	for _, port := range ports {
+		if _, ok := containerPorts[int32(port)]; !ok {
+			return fmt.Errorf("network target port %d of %s is not found in the container ports of gameServerTemplate", port, alibabacloud.PortProtocolsConfigName)
+		}
+	}
+	return nil
+}
+
+// overflowIncompatibleNetworkTypes are the network types which are not supported by serverless nodes.
+var overflowIncompatibleNetworkTypes = sets.NewString(kubernetes.HostPortNetwork, kubernetes.HostNetworkNetwork)
+
+func validatingOverflowPolicy(gss *gamekruiseiov1alpha1.GameServerSet) error {
+	if gss.Spec.OverflowPolicy == nil {
+		return nil
+	}
+	if gss.Spec.OverflowPolicy.Threshold < 0 {
+		return fmt.Errorf("overflowPolicy.threshold should be greater or equal to 0. Now it is %d", gss.Spec.OverflowPolicy.Threshold)
+	}
+	podSpec := gss.Spec.GameServerTemplate.Spec
+	if podSpec.HostNetwork {
+		return fmt.Errorf("overflowPolicy is not compatible with hostNetwork, which is not supported by serverless nodes")
+	}
+	for _, c := range podSpec.Containers {
+		for _, port := range c.Ports {
+			if port.HostPort != 0 {
+				return fmt.Errorf("overflowPolicy is not compatible with hostPort %d of container %s, which is not supported by serverless nodes", port.HostPort, c.Name)
+			}
+		}
+	}
+	if gss.Spec.Network != nil && overflowIncompatibleNetworkTypes.Has(gss.Spec.Network.NetworkType) {
+		return fmt.Errorf("overflowPolicy is not compatible with network type %s, which is not supported by serverless nodes", gss.Spec.Network.NetworkType)
+	}
+	return nil
+}
+
+func validatingArchitecturePools(gss *gamekruiseiov1alpha1.GameServerSet) error {
+	pools := gss.Spec.ArchitecturePools
+	if len(pools) == 0 {
+		return nil
+	}
+	containerNames := sets.NewString()
+	for _, c := range gss.Spec.GameServerTemplate.Spec.Containers {
+		containerNames.Insert(c.Name)
+	}
+	archs := sets.NewString()
+	totalWeight := int32(0)
+	for _, pool := range pools {
+		if pool.Arch == "" {
+			return fmt.Errorf("architecturePools arch should not be empty")
+		}
+		if archs.Has(pool.Arch) {
+			return fmt.Errorf("architecturePools should not be repeat. %s is repeated", pool.Arch)
+		}
+		archs.Insert(pool.Arch)
+		if pool.Weight == nil {
+			totalWeight++
+		} else if *pool.Weight < 0 {
+			return fmt.Errorf("architecturePools weight of %s should be greater or equal to 0. Now it is %d", pool.Arch, *pool.Weight)
+		} else {
+			totalWeight += *pool.Weight
+		}
+		for _, image := range pool.Images {
+			if !containerNames.Has(image.Name) {
+				return fmt.Errorf("architecturePools images of %s refer to container %s, which does not exist", pool.Arch, image.Name)
+			}
+		}
+	}
+	if totalWeight == 0 {
+		return fmt.Errorf("architecturePools should have at least one pool with positive weight")
+	}
+	return nil
+}
+
+// validatingWindows rejects the GameServerSets running on Windows nodes with the features which Windows nodes do not support.
+func validatingWindows(gss *gamekruiseiov1alpha1.GameServerSet) error {
+	podSpec := gss.Spec.GameServerTemplate.Spec
+	if !util.IsWindowsPodSpec(&podSpec) {
+		return nil
+	}
+	if podSpec.HostNetwork || (gss.Spec.Network != nil && gss.Spec.Network.NetworkType == kubernetes.HostNetworkNetwork) {
+		return fmt.Errorf("hostNetwork is not supported by Windows nodes")
+	}
+	for _, c := range podSpec.Containers {
+		for _, port := range c.Ports {
+			if port.Protocol == corev1.ProtocolSCTP {
+				return fmt.Errorf("protocol SCTP of container %s port %d is not supported by Windows nodes", c.Name, port.ContainerPort)
+			}
+		}
+	}
+	return nil
+}
+
+// lintGss returns warnings of common footguns in GameServerSet, which do not block the admission.
+func lintGss(gss *gamekruiseiov1alpha1.GameServerSet) []string {
+	var warnings []string
+	podSpec := gss.Spec.GameServerTemplate.Spec
+	for _, c := range podSpec.Containers {
+		if len(c.Resources.Requests) == 0 && len(c.Resources.Limits) == 0 {
+			warnings = append(warnings, fmt.Sprintf("container %s has no resources requests or limits, game servers may be evicted or starved under node pressure", c.Name))
+		}
+		if c.ReadinessProbe != nil {
+			continue
+		}
+		for _, port := range c.Ports {
+			if port.Protocol == corev1.ProtocolUDP {
+				warnings = append(warnings, fmt.Sprintf("container %s exposes UDP port %d without readiness probe, traffic may be forwarded before the game server is ready", c.Name, port.ContainerPort))
+				break
+			}
+		}
+	}
+
+	if gss.Spec.Network == nil {
+		return warnings
+	}
+	networkType := gss.Spec.Network.NetworkType
+	if podSpec.HostNetwork && (networkType == alibabacloud.SlbNetwork || networkType == alibabacloud.SlbSPNetwork) {
+		warnings = append(warnings, fmt.Sprintf("hostNetwork is enabled with network type %s, the load balancer may forward traffic to host ports unexpectedly", networkType))
+	}
+	containerPorts := getContainerPorts(gss)
+	for port, protocol := range getTargetPorts(gss) {
+		protocols, ok := containerPorts[port]
+		if !ok || protocol == string(utils.ProtocolTCPUDP) {
+			continue
+		}
+		if !util.IsStringInList(protocol, protocolNames(protocols)) {
+			warnings = append(warnings, fmt.Sprintf("network target port %d/%s is declared as %v in the container ports, the protocols may be mismatched", port, protocol, protocols))
+		}
+	}
+	for _, conf := range gss.Spec.Network.NetworkConf {
+		if conf.Name == alibabacloud.FixedConfigName && conf.Value == "true" && gss.Spec.ScaleStrategy.ScaleDownStrategyType != gamekruiseiov1alpha1.ReserveIdsScaleDownStrategyType {
+			warnings = append(warnings, "Fixed network is used without ReserveIds scale down strategy, the ids of game servers are not stable across scaling, so the network fixed to an id may be taken over by another game server")
+		}
+	}
+	return warnings
+}
+
+func protocolNames(protocols []corev1.Protocol) []string {
+	names := make([]string, 0, len(protocols))
+	for _, protocol := range protocols {
+		names = append(names, string(protocol))
+	}
+	return names
+}
+
 func validatingUpdate(newGss, oldGss *gamekruiseiov1alpha1.GameServerSet) admission.Response {
 	if oldGss.Spec.Network != nil && newGss.Spec.Network != nil {
 		if oldGss.Spec.Network.NetworkType != "" && newGss.Spec.Network.NetworkType != oldGss.Spec.Network.NetworkType {
@@ -96,6 +818,59 @@ func validatingCreate(gss *gamekruiseiov1alpha1.GameServerSet, cpm *manager.Prov
 	return admission.ValidationResponse(true, "validatingCreate success")
 }
 
+func getReplicas(gss *gamekruiseiov1alpha1.GameServerSet) int {
+	if gss.Spec.Replicas == nil {
+		return 0
+	}
+	return int(*gss.Spec.Replicas)
+}
+
+// validatingPortBudget rejects the scale-up of GameServerSet which exceeds the remaining ports of the load balancers
+// it refers to, since the network of the pods created beyond the capacity can never be ready. The check is best-effort,
+// since the capacity is computed from the ports allocated by the plugin at the moment, and the scale-up is rejected
+// if the capacity can not be computed.
+func validatingPortBudget(ctx context.Context, gss *gamekruiseiov1alpha1.GameServerSet, increase int, cpm *manager.ProviderManager, c client.Client) (bool, string) {
+	if increase <= 0 || gss.Spec.Network == nil || cpm == nil {
+		return true, ""
+	}
+	plugin, ok := cpm.FindPlugin(gss.Spec.Network.NetworkType)
+	if !ok {
+		return true, ""
+	}
+	capacityPlugin, ok := plugin.(cloudprovider.CapacityPlugin)
+	if !ok {
+		return true, ""
+	}
+	capacity, err := capacityPlugin.Capacity(c, gss.Spec.Network.NetworkConf, ctx)
+	if err != nil {
+		return false, fmt.Sprintf("failed to compute the remaining port capacity of the load balancers of network %s: %s", gss.Spec.Network.NetworkType, err.Error())
+	}
+	if increase > capacity {
+		return false, fmt.Sprintf("scaling up %d game servers exceeds the remaining port capacity of the load balancers of network %s, only %d more game servers can be allocated ports. Add load balancers to the network conf or scale up at most %d", increase, gss.Spec.Network.NetworkType, capacity, capacity)
+	}
+	return true, ""
+}
+
+// validatingNetworkConf dry-runs the parsing of the network conf by the plugin, so that the invalid network conf
+// is rejected at admission rather than leaving the network of the pods never ready.
+func validatingNetworkConf(gss *gamekruiseiov1alpha1.GameServerSet, cpm *manager.ProviderManager) error {
+	if gss.Spec.Network == nil || cpm == nil {
+		return nil
+	}
+	plugin, ok := cpm.FindPlugin(gss.Spec.Network.NetworkType)
+	if !ok {
+		return nil
+	}
+	validator, ok := plugin.(cloudprovider.NetworkConfValidator)
+	if !ok {
+		return nil
+	}
+	if err := validator.ValidateNetworkConf(gss.Spec.Network.NetworkConf); err != nil {
+		return fmt.Errorf("invalid network conf of network type %s: %s", gss.Spec.Network.NetworkType, err.Error())
+	}
+	return nil
+}
+
 func listPluginNames(cpm *manager.ProviderManager) []string {
 	var pluginNames []string
 	for _, cp := range cpm.CloudProviders {
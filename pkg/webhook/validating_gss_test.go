@@ -1,11 +1,28 @@
 package webhook
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
 	gamekruiseiov1alpha1 "github.com/openkruise/kruise-game/apis/v1alpha1"
 	"github.com/openkruise/kruise-game/cloudprovider"
 	"github.com/openkruise/kruise-game/cloudprovider/alibabacloud"
+	cperrors "github.com/openkruise/kruise-game/cloudprovider/errors"
+	"github.com/openkruise/kruise-game/cloudprovider/kubernetes"
 	"github.com/openkruise/kruise-game/cloudprovider/manager"
-	"testing"
+	admissionv1 "k8s.io/api/admission/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 func TestValidatingCreate(t *testing.T) {
@@ -59,3 +76,1221 @@ func TestValidatingCreate(t *testing.T) {
 		}
 	}
 }
+
+func TestLintGss(t *testing.T) {
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("1"),
+		},
+	}
+	tests := []struct {
+		gss         *gamekruiseiov1alpha1.GameServerSet
+		numWarnings int
+	}{
+		{
+			gss: &gamekruiseiov1alpha1.GameServerSet{
+				Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+					GameServerTemplate: gamekruiseiov1alpha1.GameServerTemplate{
+						PodTemplateSpec: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{
+									{
+										Name:           "main",
+										Resources:      resources,
+										ReadinessProbe: &corev1.Probe{},
+										Ports: []corev1.ContainerPort{
+											{
+												ContainerPort: 7777,
+												Protocol:      corev1.ProtocolUDP,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			numWarnings: 0,
+		},
+		{
+			gss: &gamekruiseiov1alpha1.GameServerSet{
+				Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+					GameServerTemplate: gamekruiseiov1alpha1.GameServerTemplate{
+						PodTemplateSpec: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								HostNetwork: true,
+								Containers: []corev1.Container{
+									{
+										Name: "main",
+										Ports: []corev1.ContainerPort{
+											{
+												ContainerPort: 7777,
+												Protocol:      corev1.ProtocolUDP,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Network: &gamekruiseiov1alpha1.Network{
+						NetworkType: alibabacloud.SlbNetwork,
+						NetworkConf: []gamekruiseiov1alpha1.NetworkConfParams{
+							{
+								Name:  alibabacloud.FixedConfigName,
+								Value: "true",
+							},
+						},
+					},
+					ScaleStrategy: gamekruiseiov1alpha1.ScaleStrategy{
+						ScaleDownStrategyType: gamekruiseiov1alpha1.ReserveIdsScaleDownStrategyType,
+					},
+				},
+			},
+			numWarnings: 3,
+		},
+		// Fixed network with stable ids
+		{
+			gss: &gamekruiseiov1alpha1.GameServerSet{
+				Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+					GameServerTemplate: gamekruiseiov1alpha1.GameServerTemplate{
+						PodTemplateSpec: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{
+									{
+										Name:      "main",
+										Resources: resources,
+									},
+								},
+							},
+						},
+					},
+					Network: &gamekruiseiov1alpha1.Network{
+						NetworkType: alibabacloud.SlbNetwork,
+						NetworkConf: []gamekruiseiov1alpha1.NetworkConfParams{
+							{
+								Name:  alibabacloud.FixedConfigName,
+								Value: "true",
+							},
+						},
+					},
+					ScaleStrategy: gamekruiseiov1alpha1.ScaleStrategy{
+						ScaleDownStrategyType: gamekruiseiov1alpha1.ReserveIdsScaleDownStrategyType,
+					},
+				},
+			},
+			numWarnings: 0,
+		},
+		// Fixed network without stable ids
+		{
+			gss: &gamekruiseiov1alpha1.GameServerSet{
+				Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+					GameServerTemplate: gamekruiseiov1alpha1.GameServerTemplate{
+						PodTemplateSpec: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{
+									{
+										Name:      "main",
+										Resources: resources,
+									},
+								},
+							},
+						},
+					},
+					Network: &gamekruiseiov1alpha1.Network{
+						NetworkType: alibabacloud.SlbNetwork,
+						NetworkConf: []gamekruiseiov1alpha1.NetworkConfParams{
+							{
+								Name:  alibabacloud.FixedConfigName,
+								Value: "true",
+							},
+						},
+					},
+				},
+			},
+			numWarnings: 1,
+		},
+		{
+			gss: &gamekruiseiov1alpha1.GameServerSet{
+				Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+					GameServerTemplate: gamekruiseiov1alpha1.GameServerTemplate{
+						PodTemplateSpec: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{
+									{
+										Name:           "main",
+										Resources:      resources,
+										ReadinessProbe: &corev1.Probe{},
+										Ports: []corev1.ContainerPort{
+											{ContainerPort: 7777},
+											{ContainerPort: 8080},
+										},
+									},
+								},
+							},
+						},
+					},
+					Network: &gamekruiseiov1alpha1.Network{
+						NetworkType: alibabacloud.SlbNetwork,
+						NetworkConf: []gamekruiseiov1alpha1.NetworkConfParams{
+							{
+								Name:  alibabacloud.PortProtocolsConfigName,
+								Value: "7777/UDP,8080/TCPUDP",
+							},
+						},
+					},
+				},
+			},
+			numWarnings: 1,
+		},
+	}
+
+	for i, test := range tests {
+		actual := lintGss(test.gss)
+		if len(actual) != test.numWarnings {
+			t.Errorf("%d: expect %d warnings, got %v", i, test.numWarnings, actual)
+		}
+	}
+}
+
+type fakeCapacityPlugin struct {
+	capacity int
+	err      error
+}
+
+func (f *fakeCapacityPlugin) Name() string {
+	return "Fake-LB"
+}
+
+func (f *fakeCapacityPlugin) Alias() string {
+	return ""
+}
+
+func (f *fakeCapacityPlugin) Init(client client.Client, options cloudprovider.CloudProviderOptions, ctx context.Context) error {
+	return nil
+}
+
+func (f *fakeCapacityPlugin) OnPodAdded(client client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, cperrors.PluginError) {
+	return pod, nil
+}
+
+func (f *fakeCapacityPlugin) OnPodUpdated(client client.Client, pod *corev1.Pod, ctx context.Context) (*corev1.Pod, cperrors.PluginError) {
+	return pod, nil
+}
+
+func (f *fakeCapacityPlugin) OnPodDeleted(client client.Client, pod *corev1.Pod, ctx context.Context) cperrors.PluginError {
+	return nil
+}
+
+func (f *fakeCapacityPlugin) Capacity(client client.Client, conf []gamekruiseiov1alpha1.NetworkConfParams, ctx context.Context) (int, error) {
+	return f.capacity, f.err
+}
+
+type fakeCloudProvider struct {
+	plugin cloudprovider.Plugin
+}
+
+func (f *fakeCloudProvider) Name() string {
+	return "Fake"
+}
+
+func (f *fakeCloudProvider) ListPlugins() (map[string]cloudprovider.Plugin, error) {
+	return map[string]cloudprovider.Plugin{f.plugin.Name(): f.plugin}, nil
+}
+
+func TestValidatingPortBudget(t *testing.T) {
+	cpm := &manager.ProviderManager{
+		CloudProviders: map[string]cloudprovider.CloudProvider{
+			"Fake": &fakeCloudProvider{plugin: &fakeCapacityPlugin{capacity: 5}},
+		},
+	}
+	tests := []struct {
+		networkType string
+		increase    int
+		allowed     bool
+	}{
+		{networkType: "Fake-LB", increase: 5, allowed: true},
+		{networkType: "Fake-LB", increase: 6, allowed: false},
+		// scale down is always allowed
+		{networkType: "Fake-LB", increase: -10, allowed: true},
+		// the plugin does not limit capacity
+		{networkType: "Kubernetes-HostPort", increase: 100, allowed: true},
+	}
+	for i, test := range tests {
+		gss := &gamekruiseiov1alpha1.GameServerSet{
+			Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+				Network: &gamekruiseiov1alpha1.Network{NetworkType: test.networkType},
+			},
+		}
+		allowed, reason := validatingPortBudget(context.TODO(), gss, test.increase, cpm, nil)
+		if allowed != test.allowed {
+			t.Errorf("case %d: expect allowed %v, but actually got %v: %s", i, test.allowed, allowed, reason)
+		}
+	}
+
+	// the scale-up is rejected if the capacity can not be computed
+	failedCpm := &manager.ProviderManager{
+		CloudProviders: map[string]cloudprovider.CloudProvider{
+			"Fake": &fakeCloudProvider{plugin: &fakeCapacityPlugin{err: errors.New("failed to get load balancers")}},
+		},
+	}
+	gss := &gamekruiseiov1alpha1.GameServerSet{
+		Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+			Network: &gamekruiseiov1alpha1.Network{NetworkType: "Fake-LB"},
+		},
+	}
+	if allowed, _ := validatingPortBudget(context.TODO(), gss, 1, failedCpm, nil); allowed {
+		t.Errorf("expect the scale-up to be rejected when the capacity can not be computed")
+	}
+}
+
+func TestHandleScale(t *testing.T) {
+	cpm := &manager.ProviderManager{
+		CloudProviders: map[string]cloudprovider.CloudProvider{
+			"Fake": &fakeCloudProvider{plugin: &fakeCapacityPlugin{capacity: 5}},
+		},
+	}
+	gss := &gamekruiseiov1alpha1.GameServerSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+		Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+			Replicas: ptr.To[int32](2),
+			Network:  &gamekruiseiov1alpha1.Network{NetworkType: "Fake-LB"},
+		},
+	}
+	decoder, err := admission.NewDecoder(runtime.NewScheme())
+	if err != nil {
+		t.Fatal(err)
+	}
+	gvh := &GssValidaatingHandler{
+		Client:               fake.NewClientBuilder().WithScheme(scheme).WithObjects(gss).Build(),
+		decoder:              decoder,
+		CloudProviderManager: cpm,
+	}
+
+	tests := []struct {
+		replicas int32
+		allowed  bool
+	}{
+		{replicas: 7, allowed: true},
+		{replicas: 8, allowed: false},
+		{replicas: 0, allowed: true},
+	}
+	for i, test := range tests {
+		newScale, _ := json.Marshal(&autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: test.replicas}})
+		oldScale, _ := json.Marshal(&autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: 2}})
+		req := admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation:   admissionv1.Update,
+				Namespace:   "xxx",
+				Name:        "case",
+				SubResource: "scale",
+				Object:      runtime.RawExtension{Raw: newScale},
+				OldObject:   runtime.RawExtension{Raw: oldScale},
+			},
+		}
+		resp := gvh.Handle(context.TODO(), req)
+		if resp.Allowed != test.allowed {
+			t.Errorf("case %d: expect allowed %v, but actually got %v: %v", i, test.allowed, resp.Allowed, resp.Result)
+		}
+	}
+}
+
+func TestValidatingNetworkConf(t *testing.T) {
+	cpm := &manager.ProviderManager{
+		CloudProviders: map[string]cloudprovider.CloudProvider{
+			"Fake": &fakeCloudProvider{plugin: &kubernetes.NodePortPlugin{}},
+		},
+	}
+	tests := []struct {
+		networkType string
+		conf        []gamekruiseiov1alpha1.NetworkConfParams
+		valid       bool
+	}{
+		{
+			networkType: kubernetes.NodePortNetwork,
+			conf:        []gamekruiseiov1alpha1.NetworkConfParams{{Name: kubernetes.PortProtocolsConfigName, Value: "80,7777/UDP"}},
+			valid:       true,
+		},
+		{
+			networkType: kubernetes.NodePortNetwork,
+			conf:        []gamekruiseiov1alpha1.NetworkConfParams{{Name: kubernetes.PortProtocolsConfigName, Value: "80/tcp"}},
+			valid:       false,
+		},
+		{
+			networkType: kubernetes.NodePortNetwork,
+			conf:        []gamekruiseiov1alpha1.NetworkConfParams{{Name: kubernetes.PortProtocolsConfigName, Value: "80,80"}},
+			valid:       false,
+		},
+		// PortProtocols is required
+		{
+			networkType: kubernetes.NodePortNetwork,
+			conf:        []gamekruiseiov1alpha1.NetworkConfParams{{Name: kubernetes.FixedKey, Value: "true"}},
+			valid:       false,
+		},
+		// the plugin is not found
+		{
+			networkType: "Fake-LB",
+			conf:        []gamekruiseiov1alpha1.NetworkConfParams{{Name: kubernetes.PortProtocolsConfigName, Value: "80/tcp"}},
+			valid:       true,
+		},
+	}
+	for i, test := range tests {
+		gss := &gamekruiseiov1alpha1.GameServerSet{
+			Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+				Network: &gamekruiseiov1alpha1.Network{NetworkType: test.networkType, NetworkConf: test.conf},
+			},
+		}
+		if err := validatingNetworkConf(gss, cpm); (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingCustomStatusFields(t *testing.T) {
+	tests := []struct {
+		fields []gamekruiseiov1alpha1.CustomStatusField
+		valid  bool
+	}{
+		{
+			fields: []gamekruiseiov1alpha1.CustomStatusField{{Name: "map"}, {Name: "players", Type: gamekruiseiov1alpha1.NumberCustomStatusFieldType}},
+			valid:  true,
+		},
+		{
+			fields: []gamekruiseiov1alpha1.CustomStatusField{{Name: "map"}, {Name: "map", Type: gamekruiseiov1alpha1.NumberCustomStatusFieldType}},
+			valid:  false,
+		},
+		{
+			fields: []gamekruiseiov1alpha1.CustomStatusField{{Name: "game/map"}},
+			valid:  false,
+		},
+		{
+			fields: []gamekruiseiov1alpha1.CustomStatusField{{Name: ""}},
+			valid:  false,
+		},
+	}
+	for i, test := range tests {
+		err := validatingCustomStatusFields(test.fields)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingRestartPolicy(t *testing.T) {
+	tests := []struct {
+		policy *gamekruiseiov1alpha1.RestartPolicy
+		valid  bool
+	}{
+		{
+			policy: nil,
+			valid:  true,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.RestartPolicy{Cron: "0 4 * * *", TimeZone: "Asia/Shanghai", MaxUnavailable: ptr.To(intstr.FromString("10%"))},
+			valid:  true,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.RestartPolicy{Cron: "0 4 * *"},
+			valid:  false,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.RestartPolicy{Cron: "0 4 * * *", TimeZone: "Mars/Olympus"},
+			valid:  false,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.RestartPolicy{Cron: "0 4 * * *", MaxUnavailable: ptr.To(intstr.FromString("ten"))},
+			valid:  false,
+		},
+	}
+	for i, test := range tests {
+		err := validatingRestartPolicy(test.policy)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingScalingPolicy(t *testing.T) {
+	tests := []struct {
+		policy *gamekruiseiov1alpha1.ScalingPolicy
+		valid  bool
+	}{
+		{
+			policy: nil,
+			valid:  true,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.ScalingPolicy{MinIdle: 5, MaxIdle: ptr.To[int32](10), MinReplicas: ptr.To[int32](5), MaxReplicas: 100},
+			valid:  true,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.ScalingPolicy{MinIdle: 5, MaxIdle: ptr.To[int32](3), MaxReplicas: 100},
+			valid:  false,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.ScalingPolicy{MinIdle: 5, MinReplicas: ptr.To[int32](10), MaxReplicas: 5},
+			valid:  false,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.ScalingPolicy{MaxReplicas: 500, Schedules: []gamekruiseiov1alpha1.ScalingSchedule{
+				{Name: "peak", Cron: "0 18 * * 5", TimeZone: "Asia/Shanghai", Replicas: 500},
+				{Name: "off-peak", Cron: "0 2 * * *", TimeZone: "Asia/Shanghai", Replicas: 100},
+			}},
+			valid: true,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.ScalingPolicy{MaxReplicas: 500, Schedules: []gamekruiseiov1alpha1.ScalingSchedule{
+				{Name: "peak", Cron: "0 18 * * 5", Replicas: 500},
+				{Name: "peak", Cron: "0 2 * * *", Replicas: 100},
+			}},
+			valid: false,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.ScalingPolicy{MaxReplicas: 500, Schedules: []gamekruiseiov1alpha1.ScalingSchedule{
+				{Name: "peak", Cron: "0 18 * 5", Replicas: 500},
+			}},
+			valid: false,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.ScalingPolicy{MaxReplicas: 500, Schedules: []gamekruiseiov1alpha1.ScalingSchedule{
+				{Name: "peak", Cron: "0 18 * * 5", TimeZone: "Mars/Olympus", Replicas: 500},
+			}},
+			valid: false,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.ScalingPolicy{MaxReplicas: 100, Schedules: []gamekruiseiov1alpha1.ScalingSchedule{
+				{Name: "peak", Cron: "0 18 * * 5", Replicas: 500},
+			}},
+			valid: false,
+		},
+	}
+	for i, test := range tests {
+		err := validatingScalingPolicy(test.policy)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingScaleStrategy(t *testing.T) {
+	tests := []struct {
+		strategy gamekruiseiov1alpha1.ScaleStrategy
+		valid    bool
+	}{
+		{
+			strategy: gamekruiseiov1alpha1.ScaleStrategy{},
+			valid:    true,
+		},
+		{
+			strategy: gamekruiseiov1alpha1.ScaleStrategy{
+				ScaleDownStrategyType: gamekruiseiov1alpha1.LeastPlayersScaleDownStrategyType,
+				PlayersSource:         &gamekruiseiov1alpha1.PlayersSource{ServiceQualityName: "players"},
+			},
+			valid: true,
+		},
+		{
+			strategy: gamekruiseiov1alpha1.ScaleStrategy{
+				ScaleDownStrategyType: gamekruiseiov1alpha1.LeastPlayersScaleDownStrategyType,
+			},
+			valid: false,
+		},
+		{
+			strategy: gamekruiseiov1alpha1.ScaleStrategy{
+				ScaleDownStrategyType: gamekruiseiov1alpha1.LeastPlayersScaleDownStrategyType,
+				PlayersSource:         &gamekruiseiov1alpha1.PlayersSource{},
+			},
+			valid: false,
+		},
+	}
+	for i, test := range tests {
+		err := validatingScaleStrategy(test.strategy)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingReserveGameServerIdRanges(t *testing.T) {
+	tests := []struct {
+		ranges []string
+		valid  bool
+	}{
+		{
+			ranges: nil,
+			valid:  true,
+		},
+		{
+			ranges: []string{"1", "3-5", "7"},
+			valid:  true,
+		},
+		{
+			ranges: []string{"-1"},
+			valid:  false,
+		},
+		{
+			ranges: []string{"5-3"},
+			valid:  false,
+		},
+		{
+			ranges: []string{"a-b"},
+			valid:  false,
+		},
+		{
+			ranges: []string{"4", "3-5"},
+			valid:  false,
+		},
+		{
+			ranges: []string{"0-99999999"},
+			valid:  false,
+		},
+	}
+	for i, test := range tests {
+		err := validatingReserveGameServerIdRanges(test.ranges)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingPreDeleteHook(t *testing.T) {
+	tests := []struct {
+		hook  *gamekruiseiov1alpha1.PreDeleteHook
+		valid bool
+	}{
+		{
+			hook:  nil,
+			valid: true,
+		},
+		{
+			hook:  &gamekruiseiov1alpha1.PreDeleteHook{Path: "/shutdown", Port: 8080},
+			valid: true,
+		},
+		{
+			hook:  &gamekruiseiov1alpha1.PreDeleteHook{Path: "/shutdown", Port: 8443, Scheme: corev1.URISchemeHTTPS},
+			valid: true,
+		},
+		{
+			hook:  &gamekruiseiov1alpha1.PreDeleteHook{Path: "shutdown", Port: 8080},
+			valid: false,
+		},
+		{
+			hook:  &gamekruiseiov1alpha1.PreDeleteHook{Path: "/shutdown", Port: 0},
+			valid: false,
+		},
+		{
+			hook:  &gamekruiseiov1alpha1.PreDeleteHook{Port: 8080, Scheme: gamekruiseiov1alpha1.PreDeleteHookSchemeGRPC},
+			valid: true,
+		},
+		{
+			hook:  &gamekruiseiov1alpha1.PreDeleteHook{Path: "/shutdown", Port: 8080, Scheme: "TCP"},
+			valid: false,
+		},
+	}
+	for i, test := range tests {
+		err := validatingPreDeleteHook(test.hook)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingOverflowPolicy(t *testing.T) {
+	tests := []struct {
+		gss   *gamekruiseiov1alpha1.GameServerSet
+		valid bool
+	}{
+		{
+			gss:   &gamekruiseiov1alpha1.GameServerSet{},
+			valid: true,
+		},
+		{
+			gss: &gamekruiseiov1alpha1.GameServerSet{
+				Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+					OverflowPolicy: &gamekruiseiov1alpha1.OverflowPolicy{Threshold: 10},
+					Network:        &gamekruiseiov1alpha1.Network{NetworkType: alibabacloud.SlbNetwork},
+				},
+			},
+			valid: true,
+		},
+		{
+			gss: &gamekruiseiov1alpha1.GameServerSet{
+				Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+					OverflowPolicy: &gamekruiseiov1alpha1.OverflowPolicy{Threshold: 10},
+					Network:        &gamekruiseiov1alpha1.Network{NetworkType: "Kubernetes-HostPort"},
+				},
+			},
+			valid: false,
+		},
+		{
+			gss: &gamekruiseiov1alpha1.GameServerSet{
+				Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+					OverflowPolicy: &gamekruiseiov1alpha1.OverflowPolicy{Threshold: 10},
+					GameServerTemplate: gamekruiseiov1alpha1.GameServerTemplate{
+						PodTemplateSpec: corev1.PodTemplateSpec{Spec: corev1.PodSpec{HostNetwork: true}},
+					},
+				},
+			},
+			valid: false,
+		},
+		{
+			gss: &gamekruiseiov1alpha1.GameServerSet{
+				Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+					OverflowPolicy: &gamekruiseiov1alpha1.OverflowPolicy{Threshold: 10},
+					GameServerTemplate: gamekruiseiov1alpha1.GameServerTemplate{
+						PodTemplateSpec: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "game", Ports: []corev1.ContainerPort{{ContainerPort: 80, HostPort: 8080}}}},
+						}},
+					},
+				},
+			},
+			valid: false,
+		},
+	}
+	for i, test := range tests {
+		err := validatingOverflowPolicy(test.gss)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingWindows(t *testing.T) {
+	windowsSelector := map[string]string{corev1.LabelOSStable: "windows"}
+	tests := []struct {
+		podSpec corev1.PodSpec
+		network *gamekruiseiov1alpha1.Network
+		valid   bool
+	}{
+		{
+			podSpec: corev1.PodSpec{
+				HostNetwork: true,
+				Containers:  []corev1.Container{{Name: "game", Ports: []corev1.ContainerPort{{ContainerPort: 80, Protocol: corev1.ProtocolSCTP}}}},
+			},
+			valid: true,
+		},
+		{
+			podSpec: corev1.PodSpec{
+				NodeSelector: windowsSelector,
+				Containers:   []corev1.Container{{Name: "game", Ports: []corev1.ContainerPort{{ContainerPort: 7777, Protocol: corev1.ProtocolUDP}}}},
+			},
+			valid: true,
+		},
+		{
+			podSpec: corev1.PodSpec{
+				NodeSelector: windowsSelector,
+				HostNetwork:  true,
+			},
+			valid: false,
+		},
+		{
+			podSpec: corev1.PodSpec{
+				OS:         &corev1.PodOS{Name: corev1.Windows},
+				Containers: []corev1.Container{{Name: "game", Ports: []corev1.ContainerPort{{ContainerPort: 80, Protocol: corev1.ProtocolSCTP}}}},
+			},
+			valid: false,
+		},
+		{
+			podSpec: corev1.PodSpec{
+				NodeSelector: windowsSelector,
+			},
+			network: &gamekruiseiov1alpha1.Network{NetworkType: kubernetes.HostNetworkNetwork},
+			valid:   false,
+		},
+	}
+	for i, test := range tests {
+		gss := &gamekruiseiov1alpha1.GameServerSet{
+			Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+				GameServerTemplate: gamekruiseiov1alpha1.GameServerTemplate{
+					PodTemplateSpec: corev1.PodTemplateSpec{Spec: test.podSpec},
+				},
+				Network: test.network,
+			},
+		}
+		err := validatingWindows(gss)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingArchitecturePools(t *testing.T) {
+	tests := []struct {
+		pools []gamekruiseiov1alpha1.ArchitecturePool
+		valid bool
+	}{
+		{
+			pools: nil,
+			valid: true,
+		},
+		{
+			pools: []gamekruiseiov1alpha1.ArchitecturePool{
+				{Arch: "amd64"},
+				{Arch: "arm64", Weight: ptr.To[int32](2), Images: []gamekruiseiov1alpha1.ContainerImage{{Name: "game", Image: "game:arm64"}}},
+			},
+			valid: true,
+		},
+		{
+			pools: []gamekruiseiov1alpha1.ArchitecturePool{{Arch: "amd64"}, {Arch: "amd64"}},
+			valid: false,
+		},
+		{
+			pools: []gamekruiseiov1alpha1.ArchitecturePool{{Arch: ""}},
+			valid: false,
+		},
+		{
+			pools: []gamekruiseiov1alpha1.ArchitecturePool{{Arch: "arm64", Weight: ptr.To[int32](0)}},
+			valid: false,
+		},
+		{
+			pools: []gamekruiseiov1alpha1.ArchitecturePool{{Arch: "arm64", Images: []gamekruiseiov1alpha1.ContainerImage{{Name: "sidecar", Image: "sidecar:arm64"}}}},
+			valid: false,
+		},
+	}
+	for i, test := range tests {
+		gss := &gamekruiseiov1alpha1.GameServerSet{
+			Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+				ArchitecturePools: test.pools,
+				GameServerTemplate: gamekruiseiov1alpha1.GameServerTemplate{
+					PodTemplateSpec: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "game"}},
+					}},
+				},
+			},
+		}
+		err := validatingArchitecturePools(gss)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingMemoryLeakPolicy(t *testing.T) {
+	growth := resource.MustParse("10Mi")
+	negative := resource.MustParse("-1Mi")
+	tests := []struct {
+		policy *gamekruiseiov1alpha1.MemoryLeakPolicy
+		valid  bool
+	}{
+		{
+			policy: nil,
+			valid:  true,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.MemoryLeakPolicy{ContainerName: "game", LimitPercent: ptr.To[int32](90), GrowthPerMinute: &growth},
+			valid:  true,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.MemoryLeakPolicy{ContainerName: "game"},
+			valid:  false,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.MemoryLeakPolicy{GrowthPerMinute: &negative},
+			valid:  false,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.MemoryLeakPolicy{ContainerName: "sidecar", LimitPercent: ptr.To[int32](90)},
+			valid:  false,
+		},
+	}
+	for i, test := range tests {
+		gss := &gamekruiseiov1alpha1.GameServerSet{
+			Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+				MemoryLeakPolicy: test.policy,
+				GameServerTemplate: gamekruiseiov1alpha1.GameServerTemplate{
+					PodTemplateSpec: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "game"}},
+					}},
+				},
+			},
+		}
+		err := validatingMemoryLeakPolicy(gss)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingEgressPolicy(t *testing.T) {
+	tests := []struct {
+		policy *gamekruiseiov1alpha1.EgressPolicy
+		valid  bool
+	}{
+		{
+			policy: nil,
+			valid:  true,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.EgressPolicy{CIDRs: []string{"10.0.0.0/16", "2001:db8::/32"}},
+			valid:  true,
+		},
+		{
+			policy: &gamekruiseiov1alpha1.EgressPolicy{CIDRs: []string{"10.0.0.1"}},
+			valid:  false,
+		},
+	}
+	for i, test := range tests {
+		err := validatingEgressPolicy(test.policy)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingGameContainerName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{
+			name:  "",
+			valid: true,
+		},
+		{
+			name:  "game",
+			valid: true,
+		},
+		{
+			name:  "logtail",
+			valid: false,
+		},
+	}
+	for i, test := range tests {
+		gss := &gamekruiseiov1alpha1.GameServerSet{
+			Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+				GameContainerName: test.name,
+				GameServerTemplate: gamekruiseiov1alpha1.GameServerTemplate{
+					PodTemplateSpec: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "sidecar"}, {Name: "game"}},
+					}},
+				},
+			},
+		}
+		err := validatingGameContainerName(gss)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingResourceProfiles(t *testing.T) {
+	tests := []struct {
+		profiles []gamekruiseiov1alpha1.ResourceProfile
+		valid    bool
+	}{
+		{
+			profiles: nil,
+			valid:    true,
+		},
+		{
+			profiles: []gamekruiseiov1alpha1.ResourceProfile{
+				{Name: "small", Containers: []gamekruiseiov1alpha1.ResourceProfileContainer{{}}},
+				{Name: "large", Containers: []gamekruiseiov1alpha1.ResourceProfileContainer{{}, {Name: "sidecar"}}},
+			},
+			valid: true,
+		},
+		{
+			profiles: []gamekruiseiov1alpha1.ResourceProfile{
+				{Name: "small", Containers: []gamekruiseiov1alpha1.ResourceProfileContainer{{}}},
+				{Name: "small", Containers: []gamekruiseiov1alpha1.ResourceProfileContainer{{Name: "game"}}},
+			},
+			valid: false,
+		},
+		{
+			profiles: []gamekruiseiov1alpha1.ResourceProfile{
+				{Name: "large", Containers: []gamekruiseiov1alpha1.ResourceProfileContainer{{Name: "logtail"}}},
+			},
+			valid: false,
+		},
+	}
+	for i, test := range tests {
+		gss := &gamekruiseiov1alpha1.GameServerSet{
+			Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+				ResourceProfiles: test.profiles,
+				GameServerTemplate: gamekruiseiov1alpha1.GameServerTemplate{
+					PodTemplateSpec: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "game"}, {Name: "sidecar"}},
+					}},
+				},
+			},
+		}
+		err := validatingResourceProfiles(gss)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingTargetPorts(t *testing.T) {
+	tests := []struct {
+		containerPorts []corev1.ContainerPort
+		portProtocols  string
+		valid          bool
+	}{
+		// container ports are not declared
+		{
+			portProtocols: "7777/UDP",
+			valid:         true,
+		},
+		{
+			containerPorts: []corev1.ContainerPort{{ContainerPort: 7777, Protocol: corev1.ProtocolUDP}, {ContainerPort: 80}},
+			portProtocols:  "7777/UDP,80",
+			valid:          true,
+		},
+		{
+			containerPorts: []corev1.ContainerPort{{ContainerPort: 7778, Protocol: corev1.ProtocolUDP}},
+			portProtocols:  "7777/UDP",
+			valid:          false,
+		},
+	}
+	for i, test := range tests {
+		gss := &gamekruiseiov1alpha1.GameServerSet{
+			Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+				GameServerTemplate: gamekruiseiov1alpha1.GameServerTemplate{
+					PodTemplateSpec: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "game", Ports: test.containerPorts}},
+					}},
+				},
+				Network: &gamekruiseiov1alpha1.Network{
+					NetworkType: alibabacloud.SlbNetwork,
+					NetworkConf: []gamekruiseiov1alpha1.NetworkConfParams{
+						{Name: alibabacloud.PortProtocolsConfigName, Value: test.portProtocols},
+					},
+				},
+			},
+		}
+		err := validatingTargetPorts(gss)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingStabilizationWindow(t *testing.T) {
+	tests := []struct {
+		network *gamekruiseiov1alpha1.Network
+		valid   bool
+	}{
+		{
+			network: nil,
+			valid:   true,
+		},
+		{
+			network: &gamekruiseiov1alpha1.Network{NetworkConf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: gamekruiseiov1alpha1.StabilizationWindowSecondsNetworkConfName, Value: "30"},
+			}},
+			valid: true,
+		},
+		{
+			network: &gamekruiseiov1alpha1.Network{NetworkConf: []gamekruiseiov1alpha1.NetworkConfParams{
+				{Name: gamekruiseiov1alpha1.StabilizationWindowSecondsNetworkConfName, Value: "30s"},
+			}},
+			valid: false,
+		},
+	}
+	for i, test := range tests {
+		err := validatingStabilizationWindow(test.network)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingSecretKeyRefs(t *testing.T) {
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{
+			value: "cert-123",
+			valid: true,
+		},
+		{
+			value: "secretKeyRef://xxx/lb-secret/certId",
+			valid: true,
+		},
+		{
+			value: "secretKeyRef://yyy/lb-secret/certId",
+			valid: false,
+		},
+		{
+			value: "secretKeyRef://lb-secret/certId",
+			valid: false,
+		},
+	}
+	for i, test := range tests {
+		gss := &gamekruiseiov1alpha1.GameServerSet{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "xxx", Name: "case"},
+			Spec: gamekruiseiov1alpha1.GameServerSetSpec{
+				Network: &gamekruiseiov1alpha1.Network{NetworkConf: []gamekruiseiov1alpha1.NetworkConfParams{{Name: "CertId", Value: test.value}}},
+			},
+		}
+		err := validatingSecretKeyRefs(gss)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingServiceQualityProbes(t *testing.T) {
+	exec := corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"/bin/sh", "-c", "/healthy.sh"}}}
+	tests := []struct {
+		handler corev1.ProbeHandler
+		valid   bool
+	}{
+		{
+			handler: exec,
+			valid:   true,
+		},
+		{
+			handler: corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: 9090}},
+			valid:   true,
+		},
+		{
+			handler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("game")}},
+			valid:   true,
+		},
+		{
+			handler: corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: 0}},
+			valid:   false,
+		},
+		{
+			handler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(70000)}},
+			valid:   false,
+		},
+		{
+			handler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(8080)}},
+			valid:   false,
+		},
+		{
+			handler: corev1.ProbeHandler{},
+			valid:   false,
+		},
+		{
+			handler: corev1.ProbeHandler{Exec: exec.Exec, GRPC: &corev1.GRPCAction{Port: 9090}},
+			valid:   false,
+		},
+	}
+	for i, test := range tests {
+		sqs := []gamekruiseiov1alpha1.ServiceQuality{{Name: "healthy", Probe: corev1.Probe{ProbeHandler: test.handler}}}
+		err := validatingServiceQualityProbes(sqs)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingServiceQualityActions(t *testing.T) {
+	tests := []struct {
+		item  gamekruiseiov1alpha1.ServiceQualityActionItem
+		valid bool
+	}{
+		{
+			item:  gamekruiseiov1alpha1.ServiceQualityActionItem{Type: gamekruiseiov1alpha1.SetOpsStateActionType, Value: "Maintaining"},
+			valid: true,
+		},
+		{
+			item:  gamekruiseiov1alpha1.ServiceQualityActionItem{Type: gamekruiseiov1alpha1.SetDeletionPriorityActionType, Value: "{{ .Fields.players }}"},
+			valid: true,
+		},
+		{
+			item:  gamekruiseiov1alpha1.ServiceQualityActionItem{Type: gamekruiseiov1alpha1.SetUpdatePriorityActionType, Value: "high"},
+			valid: false,
+		},
+		{
+			item:  gamekruiseiov1alpha1.ServiceQualityActionItem{Type: gamekruiseiov1alpha1.SetOpsStateActionType},
+			valid: false,
+		},
+		{
+			item:  gamekruiseiov1alpha1.ServiceQualityActionItem{Type: gamekruiseiov1alpha1.SetOpsStateActionType, Value: "{{ .Result"},
+			valid: false,
+		},
+		{
+			item:  gamekruiseiov1alpha1.ServiceQualityActionItem{Type: gamekruiseiov1alpha1.PatchLabelsActionType, Values: map[string]string{"map": "{{ .Fields.map }}"}},
+			valid: true,
+		},
+		{
+			item:  gamekruiseiov1alpha1.ServiceQualityActionItem{Type: gamekruiseiov1alpha1.PatchLabelsActionType, Values: map[string]string{"map": "desert island"}},
+			valid: false,
+		},
+		{
+			item:  gamekruiseiov1alpha1.ServiceQualityActionItem{Type: gamekruiseiov1alpha1.PatchAnnotationsActionType, Values: map[string]string{"example.com/players": "{{ .Result }} players"}},
+			valid: true,
+		},
+		{
+			item:  gamekruiseiov1alpha1.ServiceQualityActionItem{Type: gamekruiseiov1alpha1.PatchAnnotationsActionType, Values: map[string]string{"game.kruise.io/opsState": "None"}},
+			valid: false,
+		},
+		{
+			item:  gamekruiseiov1alpha1.ServiceQualityActionItem{Type: gamekruiseiov1alpha1.PatchAnnotationsActionType},
+			valid: false,
+		},
+		{
+			item:  gamekruiseiov1alpha1.ServiceQualityActionItem{Type: "Restart"},
+			valid: false,
+		},
+	}
+	for i, test := range tests {
+		sqs := []gamekruiseiov1alpha1.ServiceQuality{{
+			Name:                 "players",
+			ServiceQualityAction: []gamekruiseiov1alpha1.ServiceQualityAction{{State: true, Actions: []gamekruiseiov1alpha1.ServiceQualityActionItem{test.item}}},
+		}}
+		err := validatingServiceQualityActions(sqs)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
+
+func TestValidatingServiceQualityActionMatch(t *testing.T) {
+	zero := resource.MustParse("0")
+	one := resource.MustParse("1")
+	ten := resource.MustParse("10")
+	tests := []struct {
+		action gamekruiseiov1alpha1.ServiceQualityAction
+		valid  bool
+	}{
+		{
+			action: gamekruiseiov1alpha1.ServiceQualityAction{State: true, Result: "idle"},
+			valid:  true,
+		},
+		{
+			action: gamekruiseiov1alpha1.ServiceQualityAction{State: true, ResultRange: &gamekruiseiov1alpha1.ServiceQualityResultRange{Min: &zero, Max: &ten}, ConsecutiveProbes: ptr.To[int32](3)},
+			valid:  true,
+		},
+		{
+			action: gamekruiseiov1alpha1.ServiceQualityAction{State: true, ResultRange: &gamekruiseiov1alpha1.ServiceQualityResultRange{Field: "players", Lt: &one}},
+			valid:  true,
+		},
+		{
+			action: gamekruiseiov1alpha1.ServiceQualityAction{State: true, ResultRange: &gamekruiseiov1alpha1.ServiceQualityResultRange{Min: &one, Max: &one}},
+			valid:  true,
+		},
+		{
+			action: gamekruiseiov1alpha1.ServiceQualityAction{State: true, ResultRange: &gamekruiseiov1alpha1.ServiceQualityResultRange{Gt: &one, Max: &one}},
+			valid:  false,
+		},
+		{
+			action: gamekruiseiov1alpha1.ServiceQualityAction{State: true, ResultRange: &gamekruiseiov1alpha1.ServiceQualityResultRange{Min: &ten, Lt: &one}},
+			valid:  false,
+		},
+		{
+			action: gamekruiseiov1alpha1.ServiceQualityAction{State: true, ResultRange: &gamekruiseiov1alpha1.ServiceQualityResultRange{Field: "players"}},
+			valid:  false,
+		},
+		{
+			action: gamekruiseiov1alpha1.ServiceQualityAction{State: true, Result: "0", ResultRange: &gamekruiseiov1alpha1.ServiceQualityResultRange{Lt: &one}},
+			valid:  false,
+		},
+		{
+			action: gamekruiseiov1alpha1.ServiceQualityAction{State: true, ConsecutiveProbes: ptr.To[int32](0)},
+			valid:  false,
+		},
+	}
+	for i, test := range tests {
+		err := validatingServiceQualityActionMatch(test.action)
+		if (err == nil) != test.valid {
+			t.Errorf("case %d: expect valid %v, but actually got %v", i, test.valid, err)
+		}
+	}
+}
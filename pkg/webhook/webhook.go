@@ -40,6 +40,7 @@ import (
 
 var (
 	mutatePodPath                      = "/mutate-v1-pod"
+	mutateGsaPath                      = "/mutate-v1alpha1-gsa"
 	validateGssPath                    = "/validate-v1alpha1-gss"
 	mutatingWebhookConfigurationName   = "kruise-game-mutating-webhook"
 	validatingWebhookConfigurationName = "kruise-game-validating-webhook"
@@ -50,6 +51,7 @@ var (
 	webhookCertDir          string
 	webhookServiceNamespace string
 	webhookServiceName      string
+	networkInitImage        string
 )
 
 func init() {
@@ -57,6 +59,7 @@ func init() {
 	flag.StringVar(&webhookCertDir, "webhook-server-certs-dir", "/tmp/webhook-certs/", "Path to the X.509-formatted webhook certificate.")
 	flag.StringVar(&webhookServiceNamespace, "webhook-service-namespace", "kruise-game-system", "kruise game webhook service namespace.")
 	flag.StringVar(&webhookServiceName, "webhook-service-name", "kruise-game-webhook-service", "kruise game wehook service name.")
+	flag.StringVar(&networkInitImage, "network-init-image", "", "The image of okg-network-init, which is injected into the pods annotated with game.kruise.io/network-inject-env.")
 }
 
 // +kubebuilder:rbac:groups=apps.kruise.io,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
@@ -77,7 +80,7 @@ func init() {
 // +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=create;get;list;watch;update;patch
 // +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations,verbs=create;get;list;watch;update;patch
 // +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch;update;patch
-// +kubebuilder:rbac:groups=alibabacloud.com,resources=poddnats,verbs=get;list;watch
+// +kubebuilder:rbac:groups=alibabacloud.com,resources=poddnats,verbs=get;list;watch;delete
 // +kubebuilder:rbac:groups=alibabacloud.com,resources=poddnats/status,verbs=get
 // +kubebuilder:rbac:groups=alibabacloud.com,resources=podeips,verbs=get;list;watch
 // +kubebuilder:rbac:groups=alibabacloud.com,resources=podeips/status,verbs=get
@@ -91,6 +94,11 @@ type Webhook struct {
 	cpm *manager2.ProviderManager
 }
 
+// GetCertDir returns the directory of the certificate of the webhook server.
+func GetCertDir() string {
+	return webhookCertDir
+}
+
 func NewWebhookServer(mgr manager.Manager, cpm *manager2.ProviderManager) *Webhook {
 	return &Webhook{
 		mgr: mgr,
@@ -109,6 +117,7 @@ func (ws *Webhook) SetupWithManager(mgr manager.Manager) *Webhook {
 	}
 	recorder := mgr.GetEventRecorderFor("kruise-game-webhook")
 	server.Register(mutatePodPath, &webhook.Admission{Handler: NewPodMutatingHandler(mgr.GetClient(), decoder, ws.cpm, recorder)})
+	server.Register(mutateGsaPath, &webhook.Admission{Handler: &GsaMutatingHandler{decoder: decoder}})
 	server.Register(validateGssPath, &webhook.Admission{Handler: &GssValidaatingHandler{Client: mgr.GetClient(), decoder: decoder, CloudProviderManager: ws.cpm}})
 	return ws
 }
@@ -246,6 +255,14 @@ func getValidatingWebhookConf(dnsName string, caBundle []byte) []admissionregist
 						Resources:   []string{"gameserversets"},
 					},
 				},
+				{
+					Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Update},
+					Rule: admissionregistrationv1.Rule{
+						APIGroups:   []string{"game.kruise.io"},
+						APIVersions: []string{"v1alpha1"},
+						Resources:   []string{"gameserversets/scale"},
+					},
+				},
 			},
 		},
 	}
@@ -288,5 +305,29 @@ func getMutatingWebhookConf(dnsName string, caBundle []byte) []admissionregistra
 				},
 			},
 		},
+		{
+			Name:                    "gsa." + dnsName,
+			SideEffects:             &sideEffectClassNone,
+			FailurePolicy:           &fail,
+			AdmissionReviewVersions: []string{"v1", "v1beta1"},
+			ClientConfig: admissionregistrationv1.WebhookClientConfig{
+				Service: &admissionregistrationv1.ServiceReference{
+					Namespace: webhookServiceNamespace,
+					Name:      webhookServiceName,
+					Path:      &mutateGsaPath,
+				},
+				CABundle: caBundle,
+			},
+			Rules: []admissionregistrationv1.RuleWithOperations{
+				{
+					Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+					Rule: admissionregistrationv1.Rule{
+						APIGroups:   []string{"game.kruise.io"},
+						APIVersions: []string{"v1alpha1"},
+						Resources:   []string{"gameserverallocations"},
+					},
+				},
+			},
+		},
 	}
 }
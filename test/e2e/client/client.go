@@ -23,6 +23,7 @@ const (
 	Namespace         = "e2e-test"
 	GameServerSet     = "default-gss"
 	GameContainerName = "default-game"
+	WindowsImage      = "mcr.microsoft.com/oss/kubernetes/pause:3.9"
 )
 
 type Client struct {
@@ -138,6 +139,10 @@ func (client *Client) GetPodList(labelSelector string) (*corev1.PodList, error)
 	return client.kubeClint.CoreV1().Pods(Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
 }
 
+func (client *Client) GetNodeList(labelSelector string) (*corev1.NodeList, error) {
+	return client.kubeClint.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+}
+
 func (client *Client) GetPod(podName string) (*corev1.Pod, error) {
 	return client.kubeClint.CoreV1().Pods(Namespace).Get(context.TODO(), podName, metav1.GetOptions{})
 }
@@ -122,6 +122,49 @@ func (f *Framework) DeployGssWithServiceQualities() (*gamekruiseiov1alpha1.GameS
 	return f.client.CreateGameServerSet(gss)
 }
 
+func (f *Framework) HasWindowsNodes() (bool, error) {
+	labelSelector := labels.SelectorFromSet(map[string]string{
+		corev1.LabelOSStable: string(corev1.Windows),
+	}).String()
+	nodeList, err := f.client.GetNodeList(labelSelector)
+	if err != nil {
+		return false, err
+	}
+	return len(nodeList.Items) != 0, nil
+}
+
+func (f *Framework) DeployWindowsGameServerSet() (*gamekruiseiov1alpha1.GameServerSet, error) {
+	gss := f.client.DefaultGameServerSet()
+	podSpec := &gss.Spec.GameServerTemplate.Spec
+	podSpec.NodeSelector = map[string]string{corev1.LabelOSStable: string(corev1.Windows)}
+	podSpec.Tolerations = []corev1.Toleration{
+		{
+			Key:      "os",
+			Operator: corev1.TolerationOpEqual,
+			Value:    string(corev1.Windows),
+			Effect:   corev1.TaintEffectNoSchedule,
+		},
+	}
+	podSpec.Containers[0].Image = client.WindowsImage
+	return f.client.CreateGameServerSet(gss)
+}
+
+func (f *Framework) ExpectWindowsPodsCorrect(gss *gamekruiseiov1alpha1.GameServerSet) error {
+	labelSelector := labels.SelectorFromSet(map[string]string{
+		gamekruiseiov1alpha1.GameServerOwnerGssKey: gss.GetName(),
+	}).String()
+	podList, err := f.client.GetPodList(labelSelector)
+	if err != nil {
+		return err
+	}
+	for _, pod := range podList.Items {
+		if pod.Spec.OS == nil || pod.Spec.OS.Name != corev1.Windows {
+			return fmt.Errorf("pod %s is not pinned to windows, os is %v", pod.GetName(), pod.Spec.OS)
+		}
+	}
+	return nil
+}
+
 func (f *Framework) GameServerScale(gss *gamekruiseiov1alpha1.GameServerSet, desireNum int, reserveGsId *int) (*gamekruiseiov1alpha1.GameServerSet, error) {
 	// TODO: change patch type
 	newReserves := gss.Spec.ReserveGameServerIds
@@ -135,5 +135,24 @@ func RunTestCases(f *framework.Framework) {
 			err = f.ExpectGsCorrect(gss.GetName()+"-1", "None", "0", "0")
 			gomega.Expect(err).To(gomega.BeNil())
 		})
+
+		ginkgo.It("windows game servers", func() {
+
+			hasWindowsNodes, err := f.HasWindowsNodes()
+			gomega.Expect(err).To(gomega.BeNil())
+			if !hasWindowsNodes {
+				ginkgo.Skip("no windows nodes in the cluster")
+			}
+
+			// deploy
+			gss, err := f.DeployWindowsGameServerSet()
+			gomega.Expect(err).To(gomega.BeNil())
+
+			err = f.ExpectGssCorrect(gss, []int{0, 1, 2})
+			gomega.Expect(err).To(gomega.BeNil())
+
+			err = f.ExpectWindowsPodsCorrect(gss)
+			gomega.Expect(err).To(gomega.BeNil())
+		})
 	})
 }